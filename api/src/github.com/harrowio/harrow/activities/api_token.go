@@ -0,0 +1,36 @@
+package activities
+
+import "github.com/harrowio/harrow/domain"
+
+func init() {
+	registerPayload(ApiTokenCreated(&domain.ApiToken{}))
+	registerPayload(ApiTokenUsed(&domain.ApiToken{}))
+	registerPayload(ApiTokenRevoked(&domain.ApiToken{}))
+}
+
+func ApiTokenCreated(payload *domain.ApiToken) *domain.Activity {
+	return newApiTokenActivity("api-token.created", payload)
+}
+
+// ApiTokenUsed is emitted when a token is used for the first time and
+// after that at most once per domain.ApiTokenUseReportInterval.
+func ApiTokenUsed(payload *domain.ApiToken) *domain.Activity {
+	return newApiTokenActivity("api-token.used", payload)
+}
+
+func ApiTokenRevoked(payload *domain.ApiToken) *domain.Activity {
+	return newApiTokenActivity("api-token.revoked", payload)
+}
+
+// newApiTokenActivity never includes the plain text token in the
+// payload, since activities are stored and shown to other users.
+func newApiTokenActivity(name string, payload *domain.ApiToken) *domain.Activity {
+	token := *payload
+	token.Token = ""
+	return &domain.Activity{
+		Name:       name,
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    &token,
+	}
+}
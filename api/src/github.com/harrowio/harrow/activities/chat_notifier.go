@@ -0,0 +1,48 @@
+package activities
+
+import (
+	"strings"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+func init() {
+	for _, notifierType := range []string{domain.ChatNotifierTeams, domain.ChatNotifierMattermost, domain.ChatNotifierDiscord, domain.BlameNotifierType} {
+		registerPayload(ChatNotifierCreated(domain.NewProjectNotifier(notifierType)))
+		registerPayload(ChatNotifierEdited(domain.NewProjectNotifier(notifierType)))
+		registerPayload(ChatNotifierDeleted(domain.NewProjectNotifier(notifierType)))
+	}
+}
+
+// chatNotifierActivityName returns the name of the activity about a
+// notifier of the given type, e.g. "teams-notifiers.created".
+func chatNotifierActivityName(payload domain.ProjectNotifier, event string) string {
+	return strings.Replace(payload.NotifierType(), "_", "-", -1) + "." + event
+}
+
+func ChatNotifierCreated(payload domain.ProjectNotifier) *domain.Activity {
+	return &domain.Activity{
+		Name:       chatNotifierActivityName(payload, "created"),
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+func ChatNotifierEdited(payload domain.ProjectNotifier) *domain.Activity {
+	return &domain.Activity{
+		Name:       chatNotifierActivityName(payload, "edited"),
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+func ChatNotifierDeleted(payload domain.ProjectNotifier) *domain.Activity {
+	return &domain.Activity{
+		Name:       chatNotifierActivityName(payload, "deleted"),
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
@@ -0,0 +1,48 @@
+package activities
+
+import "github.com/harrowio/harrow/domain"
+
+func init() {
+	registerPayload(CustomRoleCreated(&domain.CustomRole{}))
+	registerPayload(CustomRoleEdited(&domain.CustomRole{}))
+	registerPayload(CustomRoleArchived(&domain.CustomRole{}))
+	registerPayload(CustomRoleAssigned(&domain.ProjectMembership{}))
+}
+
+func CustomRoleCreated(payload *domain.CustomRole) *domain.Activity {
+	return &domain.Activity{
+		Name:       "custom-role.created",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+func CustomRoleEdited(payload *domain.CustomRole) *domain.Activity {
+	return &domain.Activity{
+		Name:       "custom-role.edited",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+func CustomRoleArchived(payload *domain.CustomRole) *domain.Activity {
+	return &domain.Activity{
+		Name:       "custom-role.archived",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+// CustomRoleAssigned is emitted whenever the custom role of a project
+// membership changes, including when it is removed.
+func CustomRoleAssigned(payload *domain.ProjectMembership) *domain.Activity {
+	return &domain.Activity{
+		Name:       "custom-role.assigned",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
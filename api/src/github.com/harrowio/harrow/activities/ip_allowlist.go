@@ -0,0 +1,59 @@
+package activities
+
+import "github.com/harrowio/harrow/domain"
+
+func init() {
+	registerPayload(IpAllowlistEntryCreated(&domain.IpAllowlistEntry{}))
+	registerPayload(IpAllowlistEntryDeleted(&domain.IpAllowlistEntry{}))
+	registerPayload(IpAllowlistRejectedRequest(&domain.Organization{}, "", "", ""))
+	registerPayload(IpAllowlistOverridden(&domain.Organization{}, "", ""))
+}
+
+func IpAllowlistEntryCreated(payload *domain.IpAllowlistEntry) *domain.Activity {
+	return &domain.Activity{
+		Name:       "ip-allowlist-entry.created",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+func IpAllowlistEntryDeleted(payload *domain.IpAllowlistEntry) *domain.Activity {
+	return &domain.Activity{
+		Name:       "ip-allowlist-entry.deleted",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+// IpAllowlistRejectedRequest records a request rejected because it
+// came from an address not on the organization's allowlist for scope.
+func IpAllowlistRejectedRequest(organization *domain.Organization, scope, clientAddress, path string) *domain.Activity {
+	return &domain.Activity{
+		Name:       "ip-allowlist.rejected-request",
+		OccurredOn: Clock.Now(),
+		Extra: map[string]interface{}{
+			"organizationUuid": organization.Uuid,
+			"scope":            scope,
+			"clientAddress":    clientAddress,
+			"path":             path,
+		},
+		Payload: organization,
+	}
+}
+
+// IpAllowlistOverridden records an owner managing the allowlist of an
+// organization from an address not on the allowlist.
+func IpAllowlistOverridden(organization *domain.Organization, clientAddress, path string) *domain.Activity {
+	return &domain.Activity{
+		Name:       "ip-allowlist.overridden",
+		OccurredOn: Clock.Now(),
+		Extra: map[string]interface{}{
+			"organizationUuid": organization.Uuid,
+			"clientAddress":    clientAddress,
+			"path":             path,
+		},
+		Payload: organization,
+	}
+}
@@ -0,0 +1,26 @@
+package activities
+
+import "github.com/harrowio/harrow/domain"
+
+func init() {
+	registerPayload(LogRetentionPolicyChanged(&domain.LogRetentionPolicy{}))
+	registerPayload(LogRetentionPolicyRemoved(&domain.LogRetentionPolicy{}))
+}
+
+func LogRetentionPolicyChanged(payload *domain.LogRetentionPolicy) *domain.Activity {
+	return &domain.Activity{
+		Name:       "log-retention-policy.changed",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+func LogRetentionPolicyRemoved(payload *domain.LogRetentionPolicy) *domain.Activity {
+	return &domain.Activity{
+		Name:       "log-retention-policy.removed",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
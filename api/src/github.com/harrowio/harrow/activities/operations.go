@@ -8,7 +8,7 @@ func init() {
 	registerPayload(OperationFailedFatally(&domain.Operation{}))
 	registerPayload(OperationSucceeded(&domain.Operation{}))
 	registerPayload(OperationFailed(&domain.Operation{}))
-	registerPayload(OperationTimedOut(""))
+	registerPayload(OperationTimedOut(&domain.Operation{}))
 	registerPayload(OperationCanceledDueToBilling(""))
 	registerPayload(OperationCanceledByUser(""))
 }
@@ -58,18 +58,12 @@ func OperationFailedFatally(operation *domain.Operation) *domain.Activity {
 	}
 }
 
-type OperationTimedOutPayload struct {
-	Uuid string
-}
-
-func OperationTimedOut(organizationUuid string) *domain.Activity {
+func OperationTimedOut(operation *domain.Operation) *domain.Activity {
 	return &domain.Activity{
 		Name:       "operation.timed-out",
 		OccurredOn: Clock.Now(),
 		Extra:      map[string]interface{}{},
-		Payload: &OperationTimedOutPayload{
-			Uuid: organizationUuid,
-		},
+		Payload:    operation,
 	}
 }
 
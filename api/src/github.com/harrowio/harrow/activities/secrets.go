@@ -6,6 +6,7 @@ func init() {
 	registerPayload(SecretAdded(&domain.Secret{}))
 	registerPayload(SecretEdited(&domain.Secret{}))
 	registerPayload(SecretDeleted(&domain.Secret{}))
+	registerPayload(SecretRolledBack(&domain.Secret{}, &domain.SecretVersion{}))
 }
 
 func SecretAdded(secret *domain.Secret) *domain.Activity {
@@ -34,3 +35,16 @@ func SecretDeleted(secret *domain.Secret) *domain.Activity {
 		Payload:    secret,
 	}
 }
+
+// SecretRolledBack records restoring the value of version.  The
+// payload is the secret after the rollback.
+func SecretRolledBack(secret *domain.Secret, version *domain.SecretVersion) *domain.Activity {
+	return &domain.Activity{
+		Name:       "secret.rolled-back",
+		OccurredOn: Clock.Now(),
+		Extra: map[string]interface{}{
+			"rolledBackTo": version.Version,
+		},
+		Payload: secret,
+	}
+}
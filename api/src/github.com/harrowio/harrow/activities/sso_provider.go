@@ -0,0 +1,26 @@
+package activities
+
+import "github.com/harrowio/harrow/domain"
+
+func init() {
+	registerPayload(SsoProviderChanged(&domain.SsoProvider{}))
+	registerPayload(SsoProviderRemoved(&domain.SsoProvider{}))
+}
+
+func SsoProviderChanged(payload *domain.SsoProvider) *domain.Activity {
+	return &domain.Activity{
+		Name:       "sso-provider.changed",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
+
+func SsoProviderRemoved(payload *domain.SsoProvider) *domain.Activity {
+	return &domain.Activity{
+		Name:       "sso-provider.removed",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload,
+	}
+}
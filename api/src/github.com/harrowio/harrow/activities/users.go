@@ -12,6 +12,10 @@ func init() {
 	registerPayload(UserSignedUpViaCapistrano(&domain.User{}))
 	registerPayload(UserLoggedIn(&domain.User{}))
 	registerPayload(UserLoggedInViaGithub(&domain.User{}))
+	registerPayload(UserSignedUpViaSso(&domain.User{}))
+	registerPayload(UserLoggedInViaSso(&domain.User{}))
+	registerPayload(UserRequestedSsoLink(&domain.SsoLink{}))
+	registerPayload(UserLinkedSsoIdentity(&domain.SsoLink{}))
 	registerPayload(UserConnectedGithub(&domain.User{}))
 	registerPayload(UserJoinedProject(nil, nil))
 	registerPayload(UserLeftProject(nil, nil))
@@ -24,6 +28,9 @@ func init() {
 	registerPayload(UserAddedToProject(&domain.User{}, &domain.Project{}))
 	registerPayload(UserAccountUsedInTooManyPlaces(&domain.User{}))
 	registerPayload(UserReportedAsActive(&domain.User{}))
+	registerPayload(UserGeneratedRecoveryCodes(&domain.User{}))
+	registerPayload(UserUsedRecoveryCode(&domain.User{}, 0))
+	registerPayload(UserChangedNotificationPreferences(&domain.User{}))
 	registerPayload(UserEnteredSegment(""))
 	registerPayload(UserLeftSegment(""))
 
@@ -69,6 +76,42 @@ func UserLoggedInViaGithub(user *domain.User) *domain.Activity {
 	}
 }
 
+func UserSignedUpViaSso(user *domain.User) *domain.Activity {
+	return &domain.Activity{
+		Name:       "user.signed-up-via-sso",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    user.Scrub(),
+	}
+}
+
+func UserLoggedInViaSso(user *domain.User) *domain.Activity {
+	return &domain.Activity{
+		Name:       "user.logged-in-via-sso",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    user.Scrub(),
+	}
+}
+
+func UserRequestedSsoLink(link *domain.SsoLink) *domain.Activity {
+	return &domain.Activity{
+		Name:       "user.requested-sso-link",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    link,
+	}
+}
+
+func UserLinkedSsoIdentity(link *domain.SsoLink) *domain.Activity {
+	return &domain.Activity{
+		Name:       "user.linked-sso-identity",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    link,
+	}
+}
+
 func UserConnectedGithub(user *domain.User) *domain.Activity {
 	return &domain.Activity{
 		Name:       "user.user-connected-github",
@@ -225,6 +268,35 @@ func UserAccountUsedInTooManyPlaces(user *domain.User) *domain.Activity {
 	}
 }
 
+func UserGeneratedRecoveryCodes(user *domain.User) *domain.Activity {
+	return &domain.Activity{
+		Name:       "user.generated-recovery-codes",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    user.Scrub(),
+	}
+}
+
+func UserChangedNotificationPreferences(user *domain.User) *domain.Activity {
+	return &domain.Activity{
+		Name:       "user.changed-notification-preferences",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    user.Scrub(),
+	}
+}
+
+func UserUsedRecoveryCode(user *domain.User, remaining int) *domain.Activity {
+	return &domain.Activity{
+		Name:       "user.used-recovery-code",
+		OccurredOn: Clock.Now(),
+		Extra: map[string]interface{}{
+			"remainingRecoveryCodes": remaining,
+		},
+		Payload: user.Scrub(),
+	}
+}
+
 type SegmentPayload struct {
 	Name string `json:"name"`
 }
@@ -0,0 +1,36 @@
+package activities
+
+import "github.com/harrowio/harrow/domain"
+
+func init() {
+	registerPayload(WebhookNotifierCreated(&domain.WebhookNotifier{}))
+	registerPayload(WebhookNotifierEdited(&domain.WebhookNotifier{}))
+	registerPayload(WebhookNotifierDeleted(&domain.WebhookNotifier{}))
+}
+
+func WebhookNotifierCreated(payload *domain.WebhookNotifier) *domain.Activity {
+	return &domain.Activity{
+		Name:       "webhook-notifiers.created",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload.WithoutSecret(),
+	}
+}
+
+func WebhookNotifierEdited(payload *domain.WebhookNotifier) *domain.Activity {
+	return &domain.Activity{
+		Name:       "webhook-notifiers.edited",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload.WithoutSecret(),
+	}
+}
+
+func WebhookNotifierDeleted(payload *domain.WebhookNotifier) *domain.Activity {
+	return &domain.Activity{
+		Name:       "webhook-notifiers.deleted",
+		OccurredOn: Clock.Now(),
+		Extra:      map[string]interface{}{},
+		Payload:    payload.WithoutSecret(),
+	}
+}
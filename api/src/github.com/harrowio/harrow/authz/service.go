@@ -57,6 +57,17 @@ type Subject interface {
 	AuthorizationName() string
 }
 
+// A Scope restricts the capabilities available to the current user,
+// for example when a request has been authenticated with an API token.
+// A capability is only granted if the user possesses it through their
+// roles and the scope permits it.
+type Scope interface {
+	// Permits returns true if capability may be exercised on a
+	// subject belonging to project, which is nil for subjects that
+	// don't belong to any project.
+	Permits(capability string, project *domain.Project) bool
+}
+
 type Service interface {
 	CanRead(thing interface{}) (bool, error)
 	CanCreate(thing interface{}) (bool, error)
@@ -85,8 +96,10 @@ type txService struct {
 
 	organizationMembershipStore *stores.DbOrganizationMembershipStore
 	projectMembershipStore      *stores.DbProjectMembershipStore
+	customRoleStore             *stores.DbCustomRoleStore
 
 	capabilities map[string]bool
+	scope        Scope
 
 	currentUser            *domain.User
 	user                   *domain.User
@@ -99,6 +112,7 @@ type txService struct {
 
 	cachedOrganizationMemberships map[string]*domain.OrganizationMembership
 	cachedProjectMemberships      map[string]*domain.ProjectMembership
+	cachedCustomRoles             map[string]*domain.CustomRole
 
 	log logger.Logger
 }
@@ -131,6 +145,9 @@ func NewService(tx *sqlx.Tx, currentUser *domain.User, c *config.Config) *txServ
 	service.projectMembershipStore = stores.NewDbProjectMembershipStore(tx)
 	service.cachedProjectMemberships = map[string]*domain.ProjectMembership{}
 
+	service.customRoleStore = stores.NewDbCustomRoleStore(tx)
+	service.cachedCustomRoles = map[string]*domain.CustomRole{}
+
 	service.currentUser = currentUser
 	service.capabilities = BasicCapabilities()
 
@@ -157,17 +174,21 @@ func (s *txService) Can(action string, thing interface{}) (bool, error) {
 		s.determineRoles()
 	}
 
+	subject := thing.(Subject)
+	capabilityName := action + "-" + subject.AuthorizationName()
+	if s.scope != nil && !s.scope.Permits(capabilityName, s.findProjectOf(thing)) {
+		return false, NewMissingCapabilityError(s.scopedCapabilities(), capabilityName)
+	}
+
 	if s.owned(thing) {
 		return true, nil
 	}
 
-	subject := thing.(Subject)
-	capabilityName := action + "-" + subject.AuthorizationName()
 	allowed := s.capabilities[capabilityName]
 	if allowed {
 		return true, nil
 	} else {
-		return false, NewMissingCapabilityError(s.capabilities, capabilityName)
+		return false, NewMissingCapabilityError(s.scopedCapabilities(), capabilityName)
 	}
 }
 
@@ -418,6 +439,32 @@ func (s *txService) cacheProjectMembership(membership *domain.ProjectMembership)
 	s.cachedProjectMemberships[key] = membership
 }
 
+// findCustomRole returns the custom role assigned to the current
+// user's project membership, if it has been defined by the project's
+// organization.
+func (s *txService) findCustomRole() *domain.CustomRole {
+	if s.projectMembership == nil || s.projectMembership.CustomRoleUuid == nil {
+		return nil
+	}
+
+	uuid := *s.projectMembership.CustomRoleUuid
+	customRole, found := s.cachedCustomRoles[uuid]
+	if !found {
+		role, err := s.customRoleStore.FindByUuid(uuid)
+		if err != nil {
+			s.Log().Debug().Msgf("authz: customrole(%q): %s", uuid, err)
+		}
+		customRole = role
+		s.cachedCustomRoles[uuid] = customRole
+	}
+
+	if customRole == nil || customRole.OrganizationUuid != s.project.OrganizationUuid {
+		return nil
+	}
+
+	return customRole
+}
+
 func (s *txService) logLoadedEntities() {
 	s.Log().Debug().Msgf("authz: organization %#v", s.organization)
 	s.Log().Debug().Msgf("authz: project %#v", s.project)
@@ -438,6 +485,10 @@ func (s *txService) determineRoles() {
 			s.addSource(projectMember)
 		}
 
+		if customRole := s.findCustomRole(); customRole != nil {
+			s.addSource(customRole)
+		}
+
 		invited, err := s.currentUser.InvitedTo(s.project, s.invitationStore)
 		if err == nil {
 			if invited {
@@ -460,6 +511,45 @@ func (s *txService) determineRoles() {
 	}
 }
 
+// RestrictTo limits all capabilities of the current user to those
+// permitted by scope.
+func (s *txService) RestrictTo(scope Scope) {
+	s.scope = scope
+}
+
+// findProjectOf returns the project thing belongs to, or nil.
+func (s *txService) findProjectOf(thing interface{}) *domain.Project {
+	object, ok := thing.(BelongsToProject)
+	if !ok {
+		return nil
+	}
+
+	project, err := object.FindProject(s.projectStore)
+	if err != nil {
+		return nil
+	}
+
+	return project
+}
+
+// scopedCapabilities returns the capabilities of the current user
+// that are permitted by the scope in the context of the last loaded
+// project.
+func (s *txService) scopedCapabilities() map[string]bool {
+	if s.scope == nil {
+		return s.capabilities
+	}
+
+	result := map[string]bool{}
+	for capability := range s.capabilities {
+		if s.scope.Permits(capability, s.project) {
+			result[capability] = true
+		}
+	}
+
+	return result
+}
+
 // AddRole adds the capabilities of the given role to all the capabilities
 // the user already possesses.  Use this method to endow the user with
 // additional capabilities that cannot be determined just by looking at
@@ -507,7 +597,7 @@ func (s *txService) CanUpdate(thing interface{}) (bool, error) {
 
 func (self *txService) CapabilitiesBySubject() map[string][]string {
 	result := map[string][]string{}
-	for cap := range self.capabilities {
+	for cap := range self.scopedCapabilities() {
 		sep := strings.Index(cap, "-")
 		verb := cap[0:sep]
 		subject := cap[sep+1:]
@@ -115,6 +115,7 @@ func Test_txService_DomainObjectsHaveAuthorizationNameDefined(t *testing.T) {
 		// &domain.ValidationError{},
 
 		// Accessible to the user
+		&domain.CustomRole{},
 		&domain.Delivery{},
 		&domain.Environment{},
 		&domain.Invitation{},
@@ -131,6 +132,7 @@ func Test_txService_DomainObjectsHaveAuthorizationNameDefined(t *testing.T) {
 		&domain.Repository{},
 		&domain.ScheduledExecution{},
 		&domain.Schedule{},
+		&domain.SecretVersion{},
 		&domain.Session{},
 		&domain.Subscription{},
 		&domain.Subscriptions{},
@@ -444,3 +446,148 @@ func Test_txService_organization_membership_type_trumps_project_membership_type(
 		t.Errorf(`canArchive = %v; want %v`, got, want)
 	}
 }
+
+func Test_txService_RestrictTo_deniesCapabilitiesNotPermittedByScope(t *testing.T) {
+	t.Parallel()
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	job := world.Job("default")
+	service := NewService(tx, user, config.GetConfig())
+	service.RestrictTo(domain.NewApiToken("ci", user.Uuid, nil, []string{"read-job"}, nil))
+
+	if allowed, err := service.CanRead(job); !allowed {
+		t.Fatalf("Expected token to allow reading job. Error: %s", err)
+	}
+
+	if allowed, _ := service.CanUpdate(job); allowed {
+		t.Fatalf("Expected token not to allow updating job")
+	}
+}
+
+func Test_txService_RestrictTo_neverGrantsMoreThanTheUsersRoles(t *testing.T) {
+	t.Parallel()
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("non-member")
+	project := world.Project("private")
+	service := NewService(tx, user, config.GetConfig())
+	service.RestrictTo(domain.NewApiToken("ci", user.Uuid, nil, []string{"read-project", "update-project"}, nil))
+
+	if allowed, _ := service.CanRead(project); allowed {
+		t.Fatalf("Expected token not to allow reading a project its user cannot read")
+	}
+}
+
+func Test_txService_RestrictTo_appliesToThingsOwnedByTheUser(t *testing.T) {
+	t.Parallel()
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	service := NewService(tx, user, config.GetConfig())
+	service.RestrictTo(domain.NewApiToken("ci", user.Uuid, nil, []string{"read-job"}, nil))
+
+	if allowed, _ := service.CanUpdate(user); allowed {
+		t.Fatalf("Expected token not to allow updating its own user")
+	}
+}
+
+func Test_txService_RestrictTo_limitsProjectTokensToTheirProject(t *testing.T) {
+	t.Parallel()
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	public := world.Project("public")
+	private := world.Project("private")
+	service := NewService(tx, user, config.GetConfig())
+	service.RestrictTo(domain.NewApiToken("ci", user.Uuid, &public.Uuid, []string{"read-project"}, nil))
+
+	if allowed, err := service.CanRead(public); !allowed {
+		t.Fatalf("Expected token to allow reading its project. Error: %s", err)
+	}
+
+	if allowed, _ := service.CanRead(private); allowed {
+		t.Fatalf("Expected token not to allow reading another project")
+	}
+}
+
+func Test_txService_custom_role_grants_additional_capabilities(t *testing.T) {
+	t.Parallel()
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+
+	user := world.User("non-member")
+	project := world.Project("private")
+
+	environment := world.Environment("private")
+
+	deployer := &domain.CustomRole{
+		OrganizationUuid: project.OrganizationUuid,
+		Name:             "Deployer",
+		Grants:           domain.CustomRoleCapabilities{"update-environment"},
+	}
+	if _, err := stores.NewDbCustomRoleStore(tx).Create(deployer); err != nil {
+		t.Fatal(err)
+	}
+
+	test_helpers.MustCreateProjectMembership(t, tx, &domain.ProjectMembership{
+		UserUuid:       user.Uuid,
+		ProjectUuid:    project.Uuid,
+		MembershipType: domain.MembershipTypeGuest,
+		CustomRoleUuid: &deployer.Uuid,
+	})
+
+	service := NewService(tx, user, config.GetConfig())
+
+	if allowed, err := service.CanUpdate(environment); !allowed {
+		t.Fatalf("Expected custom role to allow updating environment. Error: %s", err)
+	}
+
+	if allowed, _ := service.CanArchive(environment); allowed {
+		t.Fatalf("Expected custom role not to allow archiving environment")
+	}
+}
+
+func Test_txService_custom_role_of_other_organization_grants_nothing(t *testing.T) {
+	t.Parallel()
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+
+	user := world.User("non-member")
+	project := world.Project("private")
+	job := world.Job("other")
+
+	organization := test_helpers.MustCreateOrganization(t, tx, &domain.Organization{
+		Name: "Other Organization",
+	})
+
+	foreign := &domain.CustomRole{
+		OrganizationUuid: organization.Uuid,
+		Name:             "Job editor",
+		Grants:           domain.CustomRoleCapabilities{"update-job"},
+	}
+	if _, err := stores.NewDbCustomRoleStore(tx).Create(foreign); err != nil {
+		t.Fatal(err)
+	}
+
+	test_helpers.MustCreateProjectMembership(t, tx, &domain.ProjectMembership{
+		UserUuid:       user.Uuid,
+		ProjectUuid:    project.Uuid,
+		MembershipType: domain.MembershipTypeGuest,
+		CustomRoleUuid: &foreign.Uuid,
+	})
+
+	service := NewService(tx, user, config.GetConfig())
+
+	if allowed, _ := service.CanUpdate(job); allowed {
+		t.Fatalf("Expected custom role of another organization not to allow updating job")
+	}
+}
@@ -0,0 +1,189 @@
+package logevent
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/loxer"
+)
+
+// Archive keeps the logs of finished operations as gzipped files
+// with one JSON encoded message per line.
+type Archive interface {
+	ResumableSource
+	WriteLexemes(operationUUID string, lexemes []*Message) error
+
+	// Size returns the number of bytes the log of operationUUID
+	// occupies in storage.
+	Size(operationUUID string) (int64, error)
+
+	// Truncate drops messages from the beginning of the log of
+	// operationUUID until the remaining messages occupy at most
+	// keepBytes bytes uncompressed and returns the number of bytes
+	// reclaimed in storage.
+	Truncate(operationUUID string, keepBytes int64) (int64, error)
+
+	// Delete removes the log of operationUUID and returns the number
+	// of bytes reclaimed in storage.
+	Delete(operationUUID string) (int64, error)
+}
+
+// NewArchive returns the Archive selected by the log storage
+// configuration.
+func NewArchive(c *config.Config, log logger.Logger) (Archive, error) {
+	switch backend := c.LogStorageConfig().Backend; backend {
+	case config.LogStorageFilesystem:
+		return NewFileTransport(c, log), nil
+	case config.LogStorageS3:
+		return NewS3TransportFromConfig(c.LogStorageConfig(), log)
+	default:
+		return nil, fmt.Errorf("unknown log storage backend %q", backend)
+	}
+}
+
+func encodeMessages(lexemes []*Message) ([][]byte, error) {
+	lines := make([][]byte, 0, len(lexemes))
+	for _, l := range lexemes {
+		pkt, err := json.Marshal(l)
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal(%#v): %s", l, err)
+		}
+		lines = append(lines, pkt)
+	}
+
+	return lines, nil
+}
+
+// decodeMessages sends every message read from the uncompressed log
+// in src that has not been seen at offset to res.  Messages are
+// numbered by their position in the log.
+func decodeMessages(log logger.Logger, src io.Reader, operationUUID string, offset *Offset, res chan<- *Message) {
+	scanner := bufio.NewScanner(src)
+	seq := int64(-1)
+	for scanner.Scan() {
+		seq++
+		msg := new(Message)
+		msg.O = operationUUID
+		msg.E = loxer.SerializedEvent{}
+		peek := map[string]interface{}{}
+		if err := json.Unmarshal(scanner.Bytes(), &peek); err != nil {
+			log.Error().Msgf("json.unmarshal(%s): %s", scanner.Text(), err)
+			continue
+		}
+
+		var dest interface{} = &msg
+
+		if _, found := peek["type"]; found {
+			dest = &msg.E
+		} else {
+			dest = &msg
+		}
+
+		err := json.Unmarshal(scanner.Bytes(), dest)
+		if err != nil {
+			log.Error().Msgf("json.unmarshal(%s): %s", scanner.Text(), err)
+			return
+		}
+
+		msg.S = seq
+		if offset.Seen(msg) {
+			continue
+		}
+
+		res <- msg
+	}
+	if err := scanner.Err(); err != nil {
+		log.Error().Msgf("scanner.err(): %s", err)
+	}
+}
+
+func readGzippedLines(src io.Reader) ([][]byte, error) {
+	gz, err := gzip.NewReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	lines := [][]byte{}
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+func writeGzippedLines(dest io.Writer, lines [][]byte) error {
+	gz := gzip.NewWriter(dest)
+	defer gz.Close()
+
+	for _, pkt := range lines {
+		_, err := gz.Write(pkt)
+		if err != nil {
+			return fmt.Errorf("f.Write(%s): %s", pkt, err)
+		}
+		_, err = gz.Write([]byte("\n"))
+		if err != nil {
+			return fmt.Errorf("gz.Write(%q): %s", "\n", err)
+		}
+	}
+	err := gz.Close()
+	if err != nil {
+		return fmt.Errorf("gz.Close(): %s", err)
+	}
+
+	return nil
+}
+
+func gzipLines(lines [][]byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := writeGzippedLines(buf, lines); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// truncateLines keeps as many lines from the end of lines as fit into
+// keepBytes and puts a notice about the removed lines in front of
+// them.  The second return value is false if nothing needs to be
+// removed.
+func truncateLines(operationUUID string, lines [][]byte, keepBytes int64) ([][]byte, bool, error) {
+	kept := int64(0)
+	first := len(lines)
+	for first > 0 && kept+int64(len(lines[first-1])) <= keepBytes {
+		first--
+		kept += int64(len(lines[first]))
+	}
+
+	if first == 0 {
+		return lines, false, nil
+	}
+
+	marker, err := json.Marshal(&Message{
+		O:  operationUUID,
+		FD: 2,
+		E:  loxer.SerializedEvent{Inner: loxer.NewTextEvent(fmt.Sprintf("[%d log messages removed by log retention policy]", first), 0)},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	newline, err := json.Marshal(&Message{
+		O:  operationUUID,
+		FD: 2,
+		E:  loxer.SerializedEvent{Inner: loxer.NewCursorEvent("\n", 0)},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return append([][]byte{marker, newline}, lines[first:]...), true, nil
+}
@@ -1,16 +1,13 @@
 package logevent
 
 import (
-	"bufio"
 	"compress/gzip"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 
 	"github.com/harrowio/harrow/config"
 	"github.com/harrowio/harrow/logger"
-	"github.com/harrowio/harrow/loxer"
 )
 
 var fileNameTpl = "%s/%s.json.gz"
@@ -28,16 +25,22 @@ func NewFileTransport(c *config.Config, log logger.Logger) *FileTransport {
 }
 
 func (self *FileTransport) Consume(operationUUID string) (<-chan *Message, error) {
-	f, err := os.Open(fmt.Sprintf(fileNameTpl, self.config.FilesystemConfig().OpLogDir, operationUUID))
+	return self.ConsumeFrom(operationUUID, nil)
+}
+
+// ConsumeFrom returns all messages of the log of operationUUID that
+// have not been seen at offset.  A nil offset returns the whole log.
+func (self *FileTransport) ConsumeFrom(operationUUID string, offset *Offset) (<-chan *Message, error) {
+	f, err := os.Open(self.fileName(operationUUID))
 	if err != nil {
 		return nil, err
 	}
 	gz, err := gzip.NewReader(f)
 	if err != nil {
+		f.Close()
 		return nil, err
 	}
 	res := make(chan *Message)
-	scanner := bufio.NewScanner(gz)
 	go func() {
 		defer func() {
 			err := gz.Close()
@@ -50,36 +53,7 @@ func (self *FileTransport) Consume(operationUUID string) (<-chan *Message, error
 			}
 			close(res)
 		}()
-		for scanner.Scan() {
-			msg := new(Message)
-			msg.O = operationUUID
-			msg.E = loxer.SerializedEvent{}
-			peek := map[string]interface{}{}
-			if err := json.Unmarshal(scanner.Bytes(), &peek); err != nil {
-				self.log.Error().Msgf("json.unmarshal(%s): %s", scanner.Text(), err)
-				continue
-			}
-
-			var dest interface{} = &msg
-
-			if _, found := peek["type"]; found {
-				dest = &msg.E
-			} else {
-				dest = &msg
-			}
-
-			err := json.Unmarshal(scanner.Bytes(), dest)
-			if err != nil {
-				self.log.Error().Msgf("json.unmarshal(%s): %s", scanner.Text(), err)
-				return
-			}
-
-			res <- msg
-		}
-		if err := scanner.Err(); err != nil {
-			self.log.Error().Msgf("scanner.err(): %s", err)
-			return
-		}
+		decodeMessages(self.log, gz, operationUUID, offset, res)
 	}()
 	return res, nil
 }
@@ -89,6 +63,94 @@ func (self *FileTransport) Close() error {
 }
 
 func (self *FileTransport) WriteLexemes(operationUUID string, lexemes []*Message) error {
+	lines, err := encodeMessages(lexemes)
+	if err != nil {
+		return err
+	}
+
+	return self.writeLines(operationUUID, lines)
+}
+
+// Size returns the number of bytes the log of operationUUID occupies
+// on disk.
+func (self *FileTransport) Size(operationUUID string) (int64, error) {
+	info, err := os.Stat(self.fileName(operationUUID))
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+// Truncate drops messages from the beginning of the log of
+// operationUUID until the remaining messages occupy at most keepBytes
+// bytes uncompressed.  A message noting the truncation is added in
+// front of the remaining messages.  The number of bytes reclaimed on
+// disk is returned; missing logs are ignored.
+func (self *FileTransport) Truncate(operationUUID string, keepBytes int64) (int64, error) {
+	sizeBefore, err := self.Size(operationUUID)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	lines, err := self.readLines(operationUUID)
+	if err != nil {
+		return 0, err
+	}
+
+	remaining, truncated, err := truncateLines(operationUUID, lines, keepBytes)
+	if err != nil || !truncated {
+		return 0, err
+	}
+
+	if err := self.writeLines(operationUUID, remaining); err != nil {
+		return 0, err
+	}
+
+	sizeAfter, err := self.Size(operationUUID)
+	if err != nil {
+		return 0, err
+	}
+
+	return sizeBefore - sizeAfter, nil
+}
+
+// Delete removes the log of operationUUID and returns the number of
+// bytes reclaimed on disk; missing logs are ignored.
+func (self *FileTransport) Delete(operationUUID string) (int64, error) {
+	size, err := self.Size(operationUUID)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Remove(self.fileName(operationUUID)); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+func (self *FileTransport) fileName(operationUUID string) string {
+	return fmt.Sprintf(fileNameTpl, self.config.FilesystemConfig().OpLogDir, operationUUID)
+}
+
+func (self *FileTransport) readLines(operationUUID string) ([][]byte, error) {
+	f, err := os.Open(self.fileName(operationUUID))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readGzippedLines(f)
+}
+
+func (self *FileTransport) writeLines(operationUUID string, lines [][]byte) error {
 	tmp, err := ioutil.TempDir(self.config.FilesystemConfig().OpLogDir, "log_transport")
 	if err != nil {
 		return fmt.Errorf("ioutil.TempDir(\"\", \"log_transport\"): %s", err)
@@ -100,32 +162,15 @@ func (self *FileTransport) WriteLexemes(operationUUID string, lexemes []*Message
 		return fmt.Errorf("os.Create(%s): %s", tmpFileName, err)
 	}
 	defer f.Close()
-	gz := gzip.NewWriter(f)
-	defer gz.Close()
-
-	for _, l := range lexemes {
-		pkt, err := json.Marshal(l)
-		if err != nil {
-			return fmt.Errorf("json.Marshal(%#v): %s", l, err)
-		}
-		_, err = gz.Write(pkt)
-		if err != nil {
-			return fmt.Errorf("f.Write(%s): %s", pkt, err)
-		}
-		_, err = gz.Write([]byte("\n"))
-		if err != nil {
-			return fmt.Errorf("gz.Write(%q): %s", "\n", err)
-		}
-	}
-	err = gz.Close()
-	if err != nil {
-		return fmt.Errorf("gz.Close(): %s", err)
+
+	if err := writeGzippedLines(f, lines); err != nil {
+		return err
 	}
 	err = f.Close()
 	if err != nil {
 		return fmt.Errorf("f.Close(): %s", err)
 	}
-	fileName := fmt.Sprintf(fileNameTpl, self.config.FilesystemConfig().OpLogDir, operationUUID)
+	fileName := self.fileName(operationUUID)
 	err = os.Rename(tmpFileName, fileName)
 	if err != nil {
 		return fmt.Errorf("os.Rename(%q, %q): %s", tmpFileName, fileName, err)
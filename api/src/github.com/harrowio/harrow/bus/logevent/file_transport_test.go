@@ -0,0 +1,177 @@
+package logevent
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/loxer"
+)
+
+func newTestFileTransport(t *testing.T) (*FileTransport, func()) {
+	dir, err := ioutil.TempDir("", "file-transport-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HAR_FILESYSTEM_OP_LOG_DIR", dir)
+	return NewFileTransport(config.GetConfig(), logger.Discard), func() {
+		os.Unsetenv("HAR_FILESYSTEM_OP_LOG_DIR")
+		os.RemoveAll(dir)
+	}
+}
+
+func writeTestLog(t *testing.T, transport *FileTransport, operationUUID string, n int) {
+	lexemes := []*Message{}
+	for i := 0; i < n; i++ {
+		lexemes = append(lexemes, &Message{
+			O:  operationUUID,
+			FD: 1,
+			T:  int64(100 + i),
+			E:  loxer.SerializedEvent{Inner: loxer.NewTextEvent("line", 0)},
+		})
+	}
+	if err := transport.WriteLexemes(operationUUID, lexemes); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func collectSequenceNumbers(messages <-chan *Message) []int64 {
+	result := []int64{}
+	for msg := range messages {
+		result = append(result, msg.S)
+	}
+	return result
+}
+
+func TestFileTransport_ConsumeFrom_skipsMessagesUpToSequenceNumber(t *testing.T) {
+	transport, cleanup := newTestFileTransport(t)
+	defer cleanup()
+	writeTestLog(t, transport, "op", 5)
+
+	messages, err := transport.ConsumeFrom("op", &Offset{Seq: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := collectSequenceNumbers(messages)
+	if len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf(`sequence numbers = %v; want [3 4]`, got)
+	}
+}
+
+func TestFileTransport_ConsumeFrom_skipsMessagesUpToTimestamp(t *testing.T) {
+	transport, cleanup := newTestFileTransport(t)
+	defer cleanup()
+	writeTestLog(t, transport, "op", 5)
+
+	messages, err := transport.ConsumeFrom("op", &Offset{Seq: -1, Time: 103})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := collectSequenceNumbers(messages)
+	if len(got) != 1 || got[0] != 4 {
+		t.Errorf(`sequence numbers = %v; want [4]`, got)
+	}
+}
+
+func TestFileTransport_Consume_numbersAllMessages(t *testing.T) {
+	transport, cleanup := newTestFileTransport(t)
+	defer cleanup()
+	writeTestLog(t, transport, "op", 3)
+
+	messages, err := transport.Consume("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := collectSequenceNumbers(messages)
+	if len(got) != 3 || got[0] != 0 || got[2] != 2 {
+		t.Errorf(`sequence numbers = %v; want [0 1 2]`, got)
+	}
+}
+
+func TestFileTransport_Truncate_keepsTheTailOfTheLog(t *testing.T) {
+	transport, cleanup := newTestFileTransport(t)
+	defer cleanup()
+	writeTestLog(t, transport, "op", 100)
+
+	lines, err := transport.readLines("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lineLength := int64(len(lines[len(lines)-1]))
+
+	reclaimed, err := transport.Truncate("op", 3*lineLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reclaimed <= 0 {
+		t.Errorf(`reclaimed = %d; want > 0`, reclaimed)
+	}
+
+	messages, err := transport.Consume("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	times := []int64{}
+	for msg := range messages {
+		times = append(times, msg.T)
+	}
+
+	// the truncation notice, a line feed and the last three messages
+	if got, want := len(times), 5; got != want {
+		t.Fatalf(`len(times) = %d; want %d`, got, want)
+	}
+
+	if got, want := times[2], int64(197); got != want {
+		t.Errorf(`times[2] = %d; want %d`, got, want)
+	}
+}
+
+func TestFileTransport_Truncate_leavesShortLogsAlone(t *testing.T) {
+	transport, cleanup := newTestFileTransport(t)
+	defer cleanup()
+	writeTestLog(t, transport, "op", 3)
+
+	reclaimed, err := transport.Truncate("op", 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := reclaimed, int64(0); got != want {
+		t.Errorf(`reclaimed = %d; want %d`, got, want)
+	}
+}
+
+func TestFileTransport_Delete_removesTheLog(t *testing.T) {
+	transport, cleanup := newTestFileTransport(t)
+	defer cleanup()
+	writeTestLog(t, transport, "op", 3)
+
+	size, err := transport.Size("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reclaimed, err := transport.Delete("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := reclaimed, size; got != want {
+		t.Errorf(`reclaimed = %d; want %d`, got, want)
+	}
+
+	if _, err := transport.Size("op"); !os.IsNotExist(err) {
+		t.Errorf(`transport.Size("op"): err = %v; want not exist`, err)
+	}
+
+	if reclaimed, err := transport.Delete("op"); reclaimed != 0 || err != nil {
+		t.Errorf(`second transport.Delete("op") = %d, %v; want 0, nil`, reclaimed, err)
+	}
+}
@@ -7,6 +7,11 @@ type Message struct {
 	FD int
 	T  int64
 	E  loxer.SerializedEvent
+
+	// S is the sequence number of the message within the log of its
+	// operation, starting at 0.  It is assigned by the Source the
+	// message has been consumed from.
+	S int64
 }
 
 func (self *Message) OperationUUID() string {
@@ -26,3 +31,10 @@ type Source interface {
 	Consume(operationUUID string) (<-chan *Message, error)
 	Close() error
 }
+
+// ResumableSource is a Source which can skip the messages a consumer
+// has already seen.
+type ResumableSource interface {
+	Source
+	ConsumeFrom(operationUUID string, offset *Offset) (<-chan *Message, error)
+}
@@ -0,0 +1,50 @@
+package logevent
+
+// Offset describes how much of an operation's log a consumer has
+// already seen, so that consumption can resume without receiving any
+// message twice.
+//
+// Sequence numbers are exact and should be preferred; timestamps are
+// provided for clients that only kept the time of the last message.
+type Offset struct {
+	// Seq is the sequence number (Message.S) of the last message
+	// seen, or -1 if no sequence number is known.
+	Seq int64
+
+	// Time is the timestamp (Message.T) of the last message seen, or
+	// 0 if no timestamp is known.
+	Time int64
+}
+
+// NewOffset returns an offset at the start of the log.
+func NewOffset() *Offset {
+	return &Offset{Seq: -1}
+}
+
+// First returns the sequence number of the first message that needs
+// to be read when resuming at this offset.
+func (self *Offset) First() int64 {
+	if self == nil || self.Seq < 0 {
+		return 0
+	}
+
+	return self.Seq + 1
+}
+
+// Seen returns true if msg has already been delivered to the consumer
+// resuming at this offset.
+func (self *Offset) Seen(msg *Message) bool {
+	if self == nil {
+		return false
+	}
+
+	if self.Seq >= 0 && msg.S <= self.Seq {
+		return true
+	}
+
+	if self.Time > 0 && msg.T <= self.Time {
+		return true
+	}
+
+	return false
+}
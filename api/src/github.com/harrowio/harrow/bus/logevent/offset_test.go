@@ -0,0 +1,39 @@
+package logevent
+
+import "testing"
+
+func TestOffset_First_returnsZero_withoutSequenceNumber(t *testing.T) {
+	for _, offset := range []*Offset{nil, NewOffset(), &Offset{Seq: -1, Time: 100}} {
+		if got, want := offset.First(), int64(0); got != want {
+			t.Errorf(`(%#v).First() = %v; want %v`, offset, got, want)
+		}
+	}
+}
+
+func TestOffset_First_returnsMessageAfterSequenceNumber(t *testing.T) {
+	offset := &Offset{Seq: 41}
+	if got, want := offset.First(), int64(42); got != want {
+		t.Errorf(`offset.First() = %v; want %v`, got, want)
+	}
+}
+
+func TestOffset_Seen(t *testing.T) {
+	testcases := []struct {
+		offset *Offset
+		msg    *Message
+		seen   bool
+	}{
+		{nil, &Message{S: 0, T: 1}, false},
+		{NewOffset(), &Message{S: 0, T: 1}, false},
+		{&Offset{Seq: 3}, &Message{S: 3, T: 1}, true},
+		{&Offset{Seq: 3}, &Message{S: 4, T: 1}, false},
+		{&Offset{Seq: -1, Time: 10}, &Message{S: 0, T: 10}, true},
+		{&Offset{Seq: -1, Time: 10}, &Message{S: 0, T: 11}, false},
+	}
+
+	for i, tc := range testcases {
+		if got, want := tc.offset.Seen(tc.msg), tc.seen; got != want {
+			t.Errorf(`%d: offset.Seen(%#v) = %v; want %v`, i, tc.msg, got, want)
+		}
+	}
+}
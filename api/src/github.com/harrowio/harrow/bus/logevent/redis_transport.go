@@ -34,16 +34,19 @@ type redisConsumer struct {
 	messages      chan *Message
 	newLength     chan int64
 	lastMsgSent   int64
+	offset        *Offset
 	redsi         *redis.Client
 	ps            *redis.PubSub
 }
 
-func newRedisConsumer(transport *RedisTransport, operationUUID string) (*redisConsumer, error) {
+func newRedisConsumer(transport *RedisTransport, operationUUID string, offset *Offset) (*redisConsumer, error) {
 	transport.redsi.ConfigSet("notify-keyspace-events", "Kl")
 	consumer := &redisConsumer{
 		operationUUID: operationUUID,
 		messages:      make(chan *Message, 1),
 		newLength:     make(chan int64, 1),
+		lastMsgSent:   offset.First(),
+		offset:        offset,
 		transport:     transport,
 		log:           transport.log,
 	}
@@ -95,8 +98,12 @@ func (self *redisConsumer) Consume() error {
 						self.terminate()
 						return
 					}
-					self.messages <- msg
+					msg.S = self.lastMsgSent
 					self.lastMsgSent++
+					if self.offset.Seen(msg) {
+						continue
+					}
+					self.messages <- msg
 				}
 			default:
 				r, err := self.ps.ReceiveTimeout(100 * time.Millisecond)
@@ -145,7 +152,14 @@ func (self *redisConsumer) subscriptionChannel() string {
 }
 
 func (self *RedisTransport) Consume(operationUUID string) (<-chan *Message, error) {
-	consumer, err := newRedisConsumer(self, operationUUID)
+	return self.ConsumeFrom(operationUUID, nil)
+}
+
+// ConsumeFrom returns all messages of the log of operationUUID that
+// have not been seen at offset, followed by new messages as they are
+// published.  A nil offset starts at the beginning of the log.
+func (self *RedisTransport) ConsumeFrom(operationUUID string, offset *Offset) (<-chan *Message, error) {
+	consumer, err := newRedisConsumer(self, operationUUID, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,155 @@
+package logevent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/objectstore"
+)
+
+var objectNameTpl = "%s.json.gz"
+
+// S3Transport keeps operation logs in an S3 compatible bucket, using
+// the same format as FileTransport.  This allows running the api on
+// hosts that don't share a filesystem with the controller.
+type S3Transport struct {
+	store objectstore.Store
+	log   logger.Logger
+}
+
+func NewS3Transport(store objectstore.Store, log logger.Logger) *S3Transport {
+	return &S3Transport{
+		store: store,
+		log:   log,
+	}
+}
+
+func NewS3TransportFromConfig(c config.LogStorageConfig, log logger.Logger) (*S3Transport, error) {
+	store, err := objectstore.NewS3(c.AwsConfig(), c.S3Bucket, c.S3Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewS3Transport(store, log), nil
+}
+
+func (self *S3Transport) Consume(operationUUID string) (<-chan *Message, error) {
+	return self.ConsumeFrom(operationUUID, nil)
+}
+
+// ConsumeFrom returns all messages of the log of operationUUID that
+// have not been seen at offset.  A nil offset returns the whole log.
+func (self *S3Transport) ConsumeFrom(operationUUID string, offset *Offset) (<-chan *Message, error) {
+	data, err := self.store.Get(ObjectName(operationUUID))
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	res := make(chan *Message)
+	go func() {
+		defer func() {
+			err := gz.Close()
+			if err != nil {
+				self.log.Error().Msgf("gz.close(): %s", err)
+			}
+			close(res)
+		}()
+		decodeMessages(self.log, gz, operationUUID, offset, res)
+	}()
+	return res, nil
+}
+
+func (self *S3Transport) Close() error {
+	return nil
+}
+
+func (self *S3Transport) WriteLexemes(operationUUID string, lexemes []*Message) error {
+	lines, err := encodeMessages(lexemes)
+	if err != nil {
+		return err
+	}
+
+	return self.writeLines(operationUUID, lines)
+}
+
+// Size returns the number of bytes the log of operationUUID occupies
+// in the bucket.
+func (self *S3Transport) Size(operationUUID string) (int64, error) {
+	return self.store.Size(ObjectName(operationUUID))
+}
+
+// Truncate works like FileTransport.Truncate; missing logs are
+// ignored.
+func (self *S3Transport) Truncate(operationUUID string, keepBytes int64) (int64, error) {
+	data, err := self.store.Get(ObjectName(operationUUID))
+	if objectstore.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	lines, err := readGzippedLines(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	remaining, truncated, err := truncateLines(operationUUID, lines, keepBytes)
+	if err != nil || !truncated {
+		return 0, err
+	}
+
+	compressed, err := gzipLines(remaining)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := self.store.Put(ObjectName(operationUUID), compressed); err != nil {
+		return 0, err
+	}
+
+	return int64(len(data) - len(compressed)), nil
+}
+
+// Delete removes the log of operationUUID and returns the number of
+// bytes reclaimed in the bucket; missing logs are ignored.
+func (self *S3Transport) Delete(operationUUID string) (int64, error) {
+	size, err := self.Size(operationUUID)
+	if objectstore.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := self.store.Delete(ObjectName(operationUUID)); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+func (self *S3Transport) writeLines(operationUUID string, lines [][]byte) error {
+	compressed, err := gzipLines(lines)
+	if err != nil {
+		return err
+	}
+
+	if err := self.store.Put(ObjectName(operationUUID), compressed); err != nil {
+		return fmt.Errorf("store.Put(%q): %s", ObjectName(operationUUID), err)
+	}
+
+	return nil
+}
+
+// ObjectName returns the key under which the log of operationUUID is
+// stored in a bucket.
+func ObjectName(operationUUID string) string {
+	return fmt.Sprintf(objectNameTpl, operationUUID)
+}
@@ -0,0 +1,113 @@
+package logevent
+
+import (
+	"testing"
+
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/loxer"
+	"github.com/harrowio/harrow/objectstore"
+)
+
+func writeTestS3Log(t *testing.T, transport *S3Transport, operationUUID string, n int) {
+	lexemes := []*Message{}
+	for i := 0; i < n; i++ {
+		lexemes = append(lexemes, &Message{
+			O:  operationUUID,
+			FD: 1,
+			T:  int64(100 + i),
+			E:  loxer.SerializedEvent{Inner: loxer.NewTextEvent("line", 0)},
+		})
+	}
+	if err := transport.WriteLexemes(operationUUID, lexemes); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestS3Transport_WriteLexemes_storesGzippedLogUnderOperationUUID(t *testing.T) {
+	store := objectstore.NewMemory()
+	transport := NewS3Transport(store, logger.Discard)
+	writeTestS3Log(t, transport, "op", 3)
+
+	keys := store.Keys()
+	if len(keys) != 1 || keys[0] != "op.json.gz" {
+		t.Errorf(`store.Keys() = %v; want [op.json.gz]`, keys)
+	}
+}
+
+func TestS3Transport_ConsumeFrom_skipsMessagesUpToSequenceNumber(t *testing.T) {
+	transport := NewS3Transport(objectstore.NewMemory(), logger.Discard)
+	writeTestS3Log(t, transport, "op", 5)
+
+	messages, err := transport.ConsumeFrom("op", &Offset{Seq: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := collectSequenceNumbers(messages)
+	if len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf(`sequence numbers = %v; want [3 4]`, got)
+	}
+}
+
+func TestS3Transport_Consume_returnsErrorForMissingLog(t *testing.T) {
+	transport := NewS3Transport(objectstore.NewMemory(), logger.Discard)
+
+	if _, err := transport.Consume("missing"); !objectstore.IsNotFound(err) {
+		t.Errorf(`err = %v; want %v`, err, objectstore.ErrNotFound)
+	}
+}
+
+func TestS3Transport_Truncate_keepsTailOfLog(t *testing.T) {
+	transport := NewS3Transport(objectstore.NewMemory(), logger.Discard)
+	writeTestS3Log(t, transport, "op", 100)
+
+	if _, err := transport.Truncate("op", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := transport.Consume("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the truncation notice remains
+	if got, want := len(collectSequenceNumbers(messages)), 2; got != want {
+		t.Errorf(`len(messages) = %d; want %d`, got, want)
+	}
+}
+
+func TestS3Transport_Delete_ignoresMissingLogs(t *testing.T) {
+	transport := NewS3Transport(objectstore.NewMemory(), logger.Discard)
+
+	reclaimed, err := transport.Delete("missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reclaimed != 0 {
+		t.Errorf(`reclaimed = %d; want 0`, reclaimed)
+	}
+}
+
+func TestS3Transport_Delete_reportsReclaimedBytes(t *testing.T) {
+	transport := NewS3Transport(objectstore.NewMemory(), logger.Discard)
+	writeTestS3Log(t, transport, "op", 5)
+
+	size, err := transport.Size("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reclaimed, err := transport.Delete("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reclaimed != size {
+		t.Errorf(`reclaimed = %d; want %d`, reclaimed, size)
+	}
+
+	if _, err := transport.Size("op"); !objectstore.IsNotFound(err) {
+		t.Errorf(`err = %v; want %v`, err, objectstore.ErrNotFound)
+	}
+}
@@ -176,3 +176,34 @@ func Test_ActivityWorker_enrichesActivityWithProjectUuid_ifActivitySourceBelongs
 		t.Errorf(`received.Activity().ProjectUuid() = %v; want %v`, got, want)
 	}
 }
+
+func Test_ActivityWorker_enrichesActivityWithOrganizationUuid_ifActivitySourceBelongsToProject(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	bus := activity.NewMemoryTransport()
+	activityStore := NewMemoryActivityStore()
+
+	job := world.Job("default")
+	payload := domain.NewActivity(1, "test.belongs-to-project")
+	payload.Payload = job
+
+	wait := new(sync.WaitGroup)
+	wait.Add(1)
+	received := (*activity.MemoryMessage)(nil)
+	record := func(msg activity.Message) {
+		wait.Done()
+		received = msg.(*activity.MemoryMessage)
+	}
+	worker := NewActivityWorker(bus, activityStore).
+		AddMessageHandler(markOrganizationUuidTx(tx)).
+		AddMessageHandler(record)
+
+	worker.Start()
+	bus.Publish(payload)
+	wait.Wait()
+	if got, want := received.Activity().OrganizationUuid(), world.Organization("default").Uuid; got != want {
+		t.Errorf(`received.Activity().OrganizationUuid() = %v; want %v`, got, want)
+	}
+}
@@ -27,6 +27,10 @@ type BelongsToProject interface {
 	FindProject(store domain.ProjectStore) (*domain.Project, error)
 }
 
+type BelongsToOrganization interface {
+	FindOrganization(store domain.OrganizationStore) (*domain.Organization, error)
+}
+
 type BelongsToJob interface {
 	FindJob(store domain.JobStore) (*domain.Job, error)
 }
@@ -115,6 +119,44 @@ func MarkProjectUuid(db *sqlx.DB) func(msg activity.Message) {
 	}
 }
 
+// markOrganizationUuidTx adds the uuid of the organization this
+// activity belongs to the extra fields of the activity.  Activities
+// belonging to a project belong to the project's organization.
+func markOrganizationUuidTx(tx *sqlx.Tx) func(msg activity.Message) {
+	return func(msg activity.Message) {
+		activity := msg.Activity()
+
+		switch payload := activity.Payload.(type) {
+		case *domain.Organization:
+			activity.SetOrganizationUuid(payload.Uuid)
+		case BelongsToOrganization:
+			organization, err := payload.FindOrganization(stores.NewDbOrganizationStore(tx))
+			if err != nil {
+				log.Info().Msgf("markOrganizationUuidTx.belongsToOrganization.FindOrganization: %s", err)
+				return
+			}
+			activity.SetOrganizationUuid(organization.Uuid)
+		case BelongsToProject:
+			project, err := payload.FindProject(stores.NewDbProjectStore(tx))
+			if err != nil {
+				log.Info().Msgf("markOrganizationUuidTx.belongsToProject.FindProject: %s", err)
+				return
+			}
+			activity.SetOrganizationUuid(project.OrganizationUuid)
+		default:
+			log.Info().Msgf("markOrganizationUuidTx: %T does not belong to an organization", activity.Payload)
+		}
+	}
+}
+
+func MarkOrganizationUuid(db *sqlx.DB) func(msg activity.Message) {
+	return func(msg activity.Message) {
+		tx := db.MustBegin()
+		defer tx.Rollback()
+		markOrganizationUuidTx(tx)(msg)
+	}
+}
+
 // markJobUuidTx adds the uuid of the job this activity
 // belongs to the extra fields of the activity.
 func markJobUuidTx(tx *sqlx.Tx) func(msg activity.Message) {
@@ -30,6 +30,7 @@ func Main() {
 	worker := NewActivityWorker(bus, store).
 		AddMessageHandler(ListProjectMembers(db)).
 		AddMessageHandler(MarkProjectUuid(db)).
+		AddMessageHandler(MarkOrganizationUuid(db)).
 		AddMessageHandler(MarkJobUuid(db)).
 		AddMessageHandler(logMessage)
 
@@ -31,7 +31,10 @@ func Main() {
 	defer bus.Close()
 
 	kv := stores.NewRedisKeyValueStore(redis.NewTCPClient(c.RedisConnOpts(0)))
-	ss := stores.NewRedisSecretKeyValueStore(redis.NewTCPClient(c.RedisConnOpts(1)))
+	ss, err := stores.NewSecretKeyValueStore(c, redis.NewTCPClient(c.RedisConnOpts(1)))
+	if err != nil {
+		log.Fatal().Msgf("error opening secret storage %s", err)
+	}
 
 	go http.ListenAndServe(log, db, bus, kv, ss, c)
 
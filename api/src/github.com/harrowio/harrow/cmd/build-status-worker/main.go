@@ -57,11 +57,6 @@ func Main() {
 			continue
 		}
 
-		if activity.Name == "operation.started" {
-			message.Acknowledge()
-			continue
-		}
-
 		log.Info().Msgf("handling %s@%d\n", activity.Name, activityID)
 		operation, ok := activity.Payload.(*domain.Operation)
 		if !ok {
@@ -74,7 +69,7 @@ func Main() {
 		if err != nil {
 			panic(err)
 		}
-		command := exec.Command(executable, "report-build-status-to-github", "--operation-uuid", operation.Uuid)
+		command := exec.Command(executable, "report-commit-status", "--operation-uuid", operation.Uuid)
 		go run(message, log, command)
 	}
 }
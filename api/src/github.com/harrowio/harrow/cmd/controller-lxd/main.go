@@ -19,6 +19,7 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
 	"github.com/harrowio/harrow/stores"
 )
 
@@ -192,11 +193,16 @@ func deadlineReached(db *sqlx.DB, c *config.Config, connect string, operationUui
 	tx := mustBeginTx(db)
 	store := stores.NewDbOperationStore(tx)
 	store.MarkAsTimedOut(operationUuid)
+	operation, err := store.FindByUuid(operationUuid)
+	if err != nil {
+		log.Error().Msgf("store.FindByUuid(%s): %s", operationUuid, err)
+		operation = &domain.Operation{Uuid: operationUuid}
+	}
 	mustCommitTx(tx)
 
 	activityBus := activity.NewAMQPTransport(c.AmqpConnectionString(), "harrow/fsbuilder")
 	defer activityBus.Close()
-	activityBus.Publish(activities.OperationTimedOut(operationUuid))
+	activityBus.Publish(activities.OperationTimedOut(operation))
 
 	// sysexits.h: #define EX_TEMPFAIL	75	/* temp failure; user is invited to retry */
 	os.Exit(75)
@@ -72,9 +72,11 @@ func runUserScript(log logger.Logger, client *ssh.Client, activitySink ActivityS
 				E:  loxer.SerializedEvent{Inner: l.Event},
 			})
 		}
-		ft := logevent.NewFileTransport(config, log)
-		if err := ft.WriteLexemes(operationUuid, loxerEvents); err != nil {
-			log.Error().Msgf("ft.writelexemes(): %s", err)
+		archive, err := logevent.NewArchive(config, log)
+		if err != nil {
+			log.Error().Msgf("logevent.newarchive(): %s", err)
+		} else if err := archive.WriteLexemes(operationUuid, loxerEvents); err != nil {
+			log.Error().Msgf("archive.writelexemes(): %s", err)
 		} else {
 			err := logSink.Expire(operationUuid)
 			if err != nil {
@@ -176,6 +178,15 @@ func handleEvent(db *sqlx.DB, controlMessage *cast.ControlMessage, activitySink
 		return markRepositoryMetadata(db, activitySink, event)
 	}
 
+	if event.Get("event") == "host-keys" {
+		return pinRepositoryHostKeys(db, operation, event)
+	}
+
+	if event.Get("event") == "host-key-verification-failed" {
+		errStr := fmt.Sprintf("Host key verification failed: %s; if the host's keys changed legitimately, reset the host keys pinned for the repository", event.Get("message"))
+		return markFatal(db, operationUuid, errStr)
+	}
+
 	operation.HandleEvent(event)
 	if err := operationStore.MarkStatusLogs(operationUuid, operation.StatusLogs); err != nil {
 		return err
@@ -186,6 +197,9 @@ func handleEvent(db *sqlx.DB, controlMessage *cast.ControlMessage, activitySink
 	if err := operationStore.MarkGitLogs(operationUuid, operation.GitLogs); err != nil {
 		return err
 	}
+	if err := operationStore.MarkTestResults(operationUuid, operation.TestResults); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
@@ -240,6 +254,35 @@ func markExitStatus(log logger.Logger, store *stores.DbOperationStore, db *sqlx.
 	}
 }
 
+// pinRepositoryHostKeys records the SSH host keys accepted by the
+// first successful access check of a repository.  Events sent by any
+// other operation are ignored, since their scripts are controlled by
+// users.
+func pinRepositoryHostKeys(db *sqlx.DB, operation *domain.Operation, event domain.EventPayload) error {
+	repositoryUuid := event.Get("repository")
+	if !operation.IsGitAccessCheck() || operation.RepositoryUuid == nil || *operation.RepositoryUuid != repositoryUuid {
+		log.Warn().Msgf("operation %s: ignoring host keys for repository %s", operation.Uuid, repositoryUuid)
+		return nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	pinned, err := stores.NewDbRepositoryStore(tx).PinHostKeys(repositoryUuid, event.Get("knownHosts"))
+	if err != nil {
+		return err
+	}
+
+	if pinned {
+		log.Info().Msgf("pinned host keys for repository %s", repositoryUuid)
+	}
+
+	return tx.Commit()
+}
+
 func markRepositoryMetadata(db *sqlx.DB, activitySink ActivitySink, event domain.EventPayload) error {
 	tx, err := db.Beginx()
 	if err != nil {
@@ -16,6 +16,7 @@ import (
 	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
 	"github.com/harrowio/harrow/fsbuilder"
 	"github.com/harrowio/harrow/fsbuilder/rootfs"
 	"github.com/harrowio/harrow/stores"
@@ -50,16 +51,9 @@ func Main() {
 		deadlineReached(c, db, operationUuid)
 	}()
 
-	tx, err := db.Beginx()
-
-	if err != nil {
-		log.Error().Msgf("unable to start tx: %s", err)
-	}
-	defer tx.Rollback()
-
 	log.Info().Msg("starting for loop")
 	for {
-		if err := buildRootFs(c, os.Stdout, tx, *operationUuid); err != nil {
+		if err := attemptBuild(c, db, *operationUuid); err != nil {
 			log.Error().Msgf("unable to build rootfs: %s", err)
 			time.Sleep(interval)
 		} else {
@@ -68,10 +62,35 @@ func Main() {
 	}
 }
 
+// attemptBuild builds the rootfs for an operation in a transaction
+// of its own, so that a failed attempt does not leave behind a
+// transaction that cannot be used anymore.
+func attemptBuild(c *config.Config, db *sqlx.DB, operationUuid string) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("unable to start tx: %s", err)
+	}
+	defer tx.Rollback()
+
+	if err := buildRootFs(c, os.Stdout, tx, operationUuid); err != nil {
+		return err
+	}
+
+	// keep the secret versions recorded for the operation
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit tx: %s", err)
+	}
+
+	return nil
+}
+
 func buildRootFs(c *config.Config, out io.WriteCloser, tx *sqlx.Tx, uuid string) error {
 	redisClient := redis.NewTCPClient(c.RedisConnOpts(1))
 	defer redisClient.Close()
-	ss := stores.NewRedisSecretKeyValueStore(redisClient)
+	ss, err := stores.NewSecretKeyValueStore(c, redisClient)
+	if err != nil {
+		return err
+	}
 	config := fsbuilder.NewConfig(ss, tx)
 	builder := rootfs.NewBuilder(config)
 	builder.SetLogger(log)
@@ -95,6 +114,11 @@ func deadlineReached(c *config.Config, db *sqlx.DB, operationUuid *string) {
 	defer tx.Rollback()
 	store := stores.NewDbOperationStore(tx)
 	store.MarkAsTimedOut(*operationUuid)
+	operation, err := store.FindByUuid(*operationUuid)
+	if err != nil {
+		log.Error().Msgf("store.FindByUuid(%s): %s", *operationUuid, err)
+		operation = &domain.Operation{Uuid: *operationUuid}
+	}
 	err = tx.Commit()
 	if err != nil {
 		panic(fmt.Sprintf("Unable to commit tx, can't mark operation as timed out: %s", err))
@@ -102,7 +126,7 @@ func deadlineReached(c *config.Config, db *sqlx.DB, operationUuid *string) {
 
 	activityBus := activity.NewAMQPTransport(c.AmqpConnectionString(), "harrow/fsbuilder")
 	defer activityBus.Close()
-	activityBus.Publish(activities.OperationTimedOut(*operationUuid))
+	activityBus.Publish(activities.OperationTimedOut(operation))
 
 	// sysexits.h: #define EX_TEMPFAIL	75	/* temp failure; user is invited to retry */
 	os.Exit(75)
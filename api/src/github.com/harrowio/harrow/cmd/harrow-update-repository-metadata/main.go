@@ -46,7 +46,10 @@ func Main() {
 	redisClient := redis.NewTCPClient(conf.RedisConnOpts(1))
 	defer redisClient.Close()
 
-	secretStore := stores.NewRedisSecretKeyValueStore(redisClient)
+	secretStore, err := stores.NewSecretKeyValueStore(conf, redisClient)
+	if err != nil {
+		log.Fatal().Msgf("stores.NewSecretKeyValueStore: %s", err)
+	}
 	repoCredentialStore := stores.NewRepositoryCredentialStore(secretStore, tx)
 
 	activitySink := activity.NewAMQPTransport(
@@ -18,17 +18,21 @@ import (
 	"github.com/harrowio/harrow/cmd/harrow-update-repository-metadata"
 	"github.com/harrowio/harrow/cmd/keymaker"
 	limits "github.com/harrowio/harrow/cmd/limits"
+	logRetention "github.com/harrowio/harrow/cmd/log-retention"
 	mail "github.com/harrowio/harrow/cmd/mail"
 	"github.com/harrowio/harrow/cmd/mail-dispatcher"
 	"github.com/harrowio/harrow/cmd/metadata-preflight"
 	"github.com/harrowio/harrow/cmd/migrate"
+	migrateSecrets "github.com/harrowio/harrow/cmd/migrate-secrets"
 	"github.com/harrowio/harrow/cmd/notifier"
 	"github.com/harrowio/harrow/cmd/op-metrics"
 	"github.com/harrowio/harrow/cmd/postal-worker"
 	"github.com/harrowio/harrow/cmd/projector"
-	"github.com/harrowio/harrow/cmd/report-build-status-to-github"
+	"github.com/harrowio/harrow/cmd/report-commit-status"
 	"github.com/harrowio/harrow/cmd/runner"
 	"github.com/harrowio/harrow/cmd/scheduler"
+	ssoStubIdp "github.com/harrowio/harrow/cmd/sso-stub-idp"
+	uploadLogs "github.com/harrowio/harrow/cmd/upload-logs"
 	"github.com/harrowio/harrow/cmd/user-script-runner"
 	"github.com/harrowio/harrow/cmd/ws"
 	"github.com/harrowio/harrow/cmd/zob"
@@ -50,18 +54,22 @@ func main() {
 		gitTriggerWorker.ProgramName:               gitTriggerWorker.Main,
 		harrowArchivist.ProgramName:                harrowArchivist.Main,
 		limits.ProgramName:                         limits.Main,
+		logRetention.ProgramName:                   logRetention.Main,
 		mail.ProgramName:                           mail.Main,
 		harrowUpdateRepositoryMetadata.ProgramName: harrowUpdateRepositoryMetadata.Main,
 		keymaker.ProgramName:                       keymaker.Main,
 		mailDispatcher.ProgramName:                 mailDispatcher.Main,
 		metadataPreflight.ProgramName:              metadataPreflight.Main,
 		migrate.ProgramName:                        migrate.Main,
+		migrateSecrets.ProgramName:                 migrateSecrets.Main,
 		notifier.ProgramName:                       notifier.Main,
 		runner.ProgramName:                         runner.Main,
 		postalWorker.ProgramName:                   postalWorker.Main,
 		projector.ProgramName:                      projector.Main,
-		reportBuildStatusToGitHub.ProgramName:      reportBuildStatusToGitHub.Main,
+		reportCommitStatus.ProgramName:             reportCommitStatus.Main,
 		scheduler.ProgramName:                      scheduler.Main,
+		ssoStubIdp.ProgramName:                     ssoStubIdp.Main,
+		uploadLogs.ProgramName:                     uploadLogs.Main,
 		userScriptRunner.ProgramName:               userScriptRunner.Main,
 		ws.ProgramName:                             ws.Main,
 		zob.ProgramName:                            zob.Main,
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/harrowio/harrow/cast"
+	"github.com/harrowio/harrow/junit"
 )
 
 type Arguments []string
@@ -51,6 +52,11 @@ func (self Arguments) ToMessage(eventName string) (*cast.ControlMessage, error)
 		return nil, err
 	}
 	payload.Set("event", eventName)
+	if eventName == "test-report" {
+		if err := attachTestResults(payload); err != nil {
+			return nil, err
+		}
+	}
 	message := &cast.ControlMessage{
 		Type:    cast.Event,
 		Payload: payload,
@@ -59,6 +65,40 @@ func (self Arguments) ToMessage(eventName string) (*cast.ControlMessage, error)
 	return message, nil
 }
 
+// attachTestResults parses the JUnit XML reports referenced by the
+// "path" arguments and stores the results of all test cases as a JSON
+// array under the "results" key of payload.
+func attachTestResults(payload cast.Payload) error {
+	paths := payload["path"]
+	if len(paths) == 0 {
+		return fmt.Errorf("test-report: missing path=<report.xml>")
+	}
+
+	results := []*junit.Result{}
+	for _, path := range paths {
+		report, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		parsed, err := junit.Parse(report)
+		report.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+
+		results = append(results, parsed...)
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+
+	payload.Set("results", string(data))
+	return nil
+}
+
 func main() {
 	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	dial := flag.String("dial", "localhost:2003", "send events to this address")
@@ -93,7 +133,12 @@ func Usage() {
 
 Notifies the operation runner about <event_name> with the provided
 arguments.
-`, os.Args[0])
+
+The event "test-report" parses the JUnit XML files given as
+path=<report.xml> and sends the results of all test cases:
+
+    %s test-report path=build/test-results.xml
+`, os.Args[0], os.Args[0])
 }
 
 func die(thing interface{}) {
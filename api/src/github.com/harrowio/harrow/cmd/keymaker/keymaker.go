@@ -33,6 +33,7 @@ type keymaker struct {
 	logger      logger.Logger
 	config      *config.Config
 	redisClient *redis.Client
+	secrets     stores.SecretKeyValueStore
 }
 
 func (km *keymaker) generateSecret(c *config.Config, redisClient *redis.Client, message broadcast.Message) {
@@ -52,7 +53,7 @@ func (km *keymaker) generateSecret(c *config.Config, redisClient *redis.Client,
 	defer tx.Rollback()
 
 	secretUuid := message.UUID()
-	ss := stores.NewRedisSecretKeyValueStore(redisClient)
+	ss := km.secrets
 	store := stores.NewSecretStore(ss, tx)
 	store.SetLogger(km.logger)
 
@@ -93,7 +94,7 @@ func (km *keymaker) generateRepositoryCredential(activityBus activity.Sink, mess
 	repositoryUuid := message.UUID()
 	tx := db.MustBegin()
 	defer tx.Rollback()
-	ss := stores.NewRedisSecretKeyValueStore(km.redisClient)
+	ss := km.secrets
 	repositoryCredentialsStore := stores.NewRepositoryCredentialStore(ss, tx)
 	rc, err := repositoryCredentialsStore.FindByRepositoryUuidAndType(repositoryUuid, domain.RepositoryCredentialSsh)
 	if err != nil {
@@ -126,7 +127,7 @@ func mustParseUuid(body []byte) string {
 }
 
 func (km *keymaker) generateSecretBytes(secret *domain.Secret, tx *sqlx.Tx) error {
-	ss := stores.NewRedisSecretKeyValueStore(km.redisClient)
+	ss := km.secrets
 	store := stores.NewSecretStore(ss, tx)
 	store.SetLogger(km.logger)
 
@@ -152,7 +153,7 @@ func (km *keymaker) generateRepositoryCredentialBytes(db *sqlx.DB, repositoryCre
 		return err
 	}
 	defer tx.Rollback()
-	ss := stores.NewRedisSecretKeyValueStore(km.redisClient)
+	ss := km.secrets
 	store := stores.NewRepositoryCredentialStore(ss, tx)
 
 	priv, pub, err := sshkey.Generate(repositoryCredential.Name, "rsa", 8192)
@@ -190,7 +191,7 @@ func (km *keymaker) rekey() error {
 	if err != nil {
 		return err
 	}
-	ss := stores.NewRedisSecretKeyValueStore(km.redisClient)
+	ss := km.secrets
 	store := stores.NewSecretStore(ss, tx)
 	store.SetLogger(km.logger)
 
@@ -237,7 +238,7 @@ func (km *keymaker) rekeyMissing() error {
 	if err != nil {
 		return err
 	}
-	ss := stores.NewRedisSecretKeyValueStore(km.redisClient)
+	ss := km.secrets
 	secretStore := stores.NewSecretStore(ss, tx)
 	secretStore.SetLogger(km.logger)
 
@@ -309,11 +310,16 @@ func Main() {
 
 	c := config.GetConfig()
 	redisClient := redis.NewTCPClient(c.RedisConnOpts(1))
+	secrets, err := stores.NewSecretKeyValueStore(c, redisClient)
+	if err != nil {
+		logger.Fatal().Msgf("stores.NewSecretKeyValueStore: %s", err)
+	}
 
 	km := keymaker{
 		logger:      logger,
 		config:      c,
 		redisClient: redisClient,
+		secrets:     secrets,
 	}
 
 	if len(os.Args) > 1 {
@@ -0,0 +1,234 @@
+package logRetention
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+
+	"github.com/harrowio/harrow/bus/logevent"
+	"github.com/harrowio/harrow/clock"
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+const ProgramName = "log-retention"
+
+var log zerolog.Logger = zerolog.New(os.Stdout).With().Str("harrow", ProgramName).Timestamp().Logger()
+
+// LogStorage is where the logs of operations are kept.
+type LogStorage interface {
+	Size(operationUuid string) (int64, error)
+	Truncate(operationUuid string, keepBytes int64) (int64, error)
+	Delete(operationUuid string) (int64, error)
+}
+
+// OperationMarker records what has been done to the log of an
+// operation.
+type OperationMarker interface {
+	MarkLogTruncated(operationUuid string) error
+	MarkLogDeleted(operationUuid string) error
+}
+
+// Report summarizes a single run of enforcing log retention policies.
+type Report struct {
+	ProjectsChecked   int
+	OperationsChecked int
+	LogsTruncated     int
+	LogsDeleted       int
+	BytesReclaimed    int64
+	DryRun            bool
+	Errors            int
+}
+
+func (self *Report) String() string {
+	prefix := ""
+	if self.DryRun {
+		prefix = "dry run: "
+	}
+	return fmt.Sprintf("%sprojects=%d operations=%d truncated=%d deleted=%d reclaimed=%s errors=%d",
+		prefix,
+		self.ProjectsChecked,
+		self.OperationsChecked,
+		self.LogsTruncated,
+		self.LogsDeleted,
+		formatBytes(self.BytesReclaimed),
+		self.Errors,
+	)
+}
+
+func formatBytes(n int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	value := float64(n)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value = value / 1024
+		unit++
+	}
+
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
+
+func Main() {
+	dryRun := flag.Bool("n", false, "dry run: report what would be reclaimed without changing anything")
+	flag.Parse()
+
+	c := config.GetConfig()
+	db, err := c.DB()
+	if err != nil {
+		log.Fatal().Err(err)
+	}
+
+	storage, err := logevent.NewArchive(c, log)
+	if err != nil {
+		log.Fatal().Msgf("logevent.NewArchive: %s", err)
+	}
+	report, err := EnforceAll(db, storage, clock.Default.Now(), *dryRun)
+	if err != nil {
+		log.Fatal().Msgf("EnforceAll: %s", err)
+	}
+
+	log.Info().
+		Int("projects", report.ProjectsChecked).
+		Int("operations", report.OperationsChecked).
+		Int("truncated", report.LogsTruncated).
+		Int("deleted", report.LogsDeleted).
+		Int64("reclaimedBytes", report.BytesReclaimed).
+		Int("errors", report.Errors).
+		Bool("dryRun", report.DryRun).
+		Msg(report.String())
+}
+
+// EnforceAll applies the effective log retention policy of every
+// project, including archived ones.
+func EnforceAll(db *sqlx.DB, storage LogStorage, now time.Time, dryRun bool) (*Report, error) {
+	report := &Report{DryRun: dryRun}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := stores.NewDbProjectStore(tx).FindAllIncludingArchived()
+	tx.Rollback()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, project := range projects {
+		if err := enforceForProject(db, storage, project, now, report); err != nil {
+			log.Error().Msgf("project=%s: %s", project.Uuid, err)
+			report.Errors++
+		}
+	}
+
+	return report, nil
+}
+
+// enforceForProject uses one transaction per project, so that
+// progress is kept if a later project fails.
+func enforceForProject(db *sqlx.DB, storage LogStorage, project *domain.Project, now time.Time, report *Report) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	policy, err := stores.NewDbLogRetentionPolicyStore(tx).FindEffectiveByProject(project)
+	if domain.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	report.ProjectsChecked++
+
+	operationStore := stores.NewDbOperationStore(tx)
+	operations, err := operationStore.FindAllWithLogsByProjectUuid(project.Uuid, policy.FinishedBefore(now))
+	if err != nil {
+		return err
+	}
+
+	var marker OperationMarker = operationStore
+	if report.DryRun {
+		marker = dryRunMarker{}
+		storage = &dryRunStorage{storage}
+	}
+
+	Apply(policy, operations, storage, marker, now, report)
+
+	if report.DryRun {
+		return nil
+	}
+
+	return tx.Commit()
+}
+
+// Apply enforces policy on the logs of operations and records the
+// outcome in report.  Failures for individual operations are logged
+// and counted, but do not stop the processing of other operations.
+func Apply(policy *domain.LogRetentionPolicy, operations []*domain.Operation, storage LogStorage, marker OperationMarker, now time.Time, report *Report) {
+	for _, operation := range operations {
+		report.OperationsChecked++
+
+		var (
+			reclaimed int64
+			err       error
+			counter   *int
+		)
+
+		switch policy.ActionFor(operation, now) {
+		case domain.LogRetentionTruncate:
+			counter = &report.LogsTruncated
+			reclaimed, err = storage.Truncate(operation.Uuid, policy.KeepTailBytes())
+			if err == nil {
+				err = marker.MarkLogTruncated(operation.Uuid)
+			}
+		case domain.LogRetentionDelete:
+			counter = &report.LogsDeleted
+			reclaimed, err = storage.Delete(operation.Uuid)
+			if err == nil {
+				err = marker.MarkLogDeleted(operation.Uuid)
+			}
+		default:
+			continue
+		}
+
+		if err != nil {
+			log.Error().Msgf("operation=%s: %s", operation.Uuid, err)
+			report.Errors++
+			continue
+		}
+
+		*counter++
+		report.BytesReclaimed += reclaimed
+	}
+}
+
+type dryRunMarker struct{}
+
+func (self dryRunMarker) MarkLogTruncated(operationUuid string) error { return nil }
+func (self dryRunMarker) MarkLogDeleted(operationUuid string) error   { return nil }
+
+// dryRunStorage reports the size of logs to be deleted without
+// deleting them.  Truncation is not simulated and reports nothing
+// reclaimed, as the outcome depends on the contents of each log.
+type dryRunStorage struct {
+	LogStorage
+}
+
+func (self *dryRunStorage) Truncate(operationUuid string, keepBytes int64) (int64, error) {
+	return 0, nil
+}
+
+func (self *dryRunStorage) Delete(operationUuid string) (int64, error) {
+	size, err := self.Size(operationUuid)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return size, err
+}
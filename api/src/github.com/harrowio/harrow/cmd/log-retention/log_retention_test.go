@@ -0,0 +1,135 @@
+package logRetention
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+type storageInMemory struct {
+	sizes     map[string]int64
+	truncated map[string]int64
+	failWith  error
+}
+
+func newStorageInMemory() *storageInMemory {
+	return &storageInMemory{
+		sizes:     map[string]int64{},
+		truncated: map[string]int64{},
+	}
+}
+
+func (self *storageInMemory) Size(operationUuid string) (int64, error) {
+	return self.sizes[operationUuid], nil
+}
+
+func (self *storageInMemory) Truncate(operationUuid string, keepBytes int64) (int64, error) {
+	if self.failWith != nil {
+		return 0, self.failWith
+	}
+	self.truncated[operationUuid] = keepBytes
+	reclaimed := self.sizes[operationUuid] - keepBytes
+	self.sizes[operationUuid] = keepBytes
+	return reclaimed, nil
+}
+
+func (self *storageInMemory) Delete(operationUuid string) (int64, error) {
+	size := self.sizes[operationUuid]
+	delete(self.sizes, operationUuid)
+	return size, nil
+}
+
+type markerInMemory struct {
+	truncated []string
+	deleted   []string
+}
+
+func (self *markerInMemory) MarkLogTruncated(operationUuid string) error {
+	self.truncated = append(self.truncated, operationUuid)
+	return nil
+}
+
+func (self *markerInMemory) MarkLogDeleted(operationUuid string) error {
+	self.deleted = append(self.deleted, operationUuid)
+	return nil
+}
+
+func finishedOperation(uuid string, finishedAt time.Time) *domain.Operation {
+	return &domain.Operation{Uuid: uuid, FinishedAt: &finishedAt}
+}
+
+func TestApply_truncatesAndDeletesLogsAccordingToPolicy(t *testing.T) {
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	policy := &domain.LogRetentionPolicy{KeepFullDays: 7, KeepTailKB: 1, DeleteAfterDays: 30}
+	operations := []*domain.Operation{
+		finishedOperation("expired", now.Add(-40*day)),
+		finishedOperation("old", now.Add(-10*day)),
+		finishedOperation("recent", now.Add(-1*day)),
+	}
+
+	storage := newStorageInMemory()
+	storage.sizes["expired"] = 5000
+	storage.sizes["old"] = 3048
+	storage.sizes["recent"] = 8000
+	marker := &markerInMemory{}
+	report := &Report{}
+
+	Apply(policy, operations, storage, marker, now, report)
+
+	if got, want := report.LogsDeleted, 1; got != want {
+		t.Errorf(`report.LogsDeleted = %d; want %d`, got, want)
+	}
+
+	if got, want := report.LogsTruncated, 1; got != want {
+		t.Errorf(`report.LogsTruncated = %d; want %d`, got, want)
+	}
+
+	if got, want := report.BytesReclaimed, int64(5000+2024); got != want {
+		t.Errorf(`report.BytesReclaimed = %d; want %d`, got, want)
+	}
+
+	if got, want := storage.truncated["old"], int64(1024); got != want {
+		t.Errorf(`storage.truncated["old"] = %d; want %d`, got, want)
+	}
+
+	if len(marker.deleted) != 1 || marker.deleted[0] != "expired" {
+		t.Errorf(`marker.deleted = %v; want [expired]`, marker.deleted)
+	}
+
+	if len(marker.truncated) != 1 || marker.truncated[0] != "old" {
+		t.Errorf(`marker.truncated = %v; want [old]`, marker.truncated)
+	}
+}
+
+func TestApply_countsErrorsWithoutMarkingOperations(t *testing.T) {
+	now := time.Now()
+	policy := &domain.LogRetentionPolicy{KeepFullDays: 1}
+	operations := []*domain.Operation{
+		finishedOperation("old", now.Add(-48*time.Hour)),
+	}
+
+	storage := newStorageInMemory()
+	storage.failWith = errors.New("disk on fire")
+	marker := &markerInMemory{}
+	report := &Report{}
+
+	Apply(policy, operations, storage, marker, now, report)
+
+	if got, want := report.Errors, 1; got != want {
+		t.Errorf(`report.Errors = %d; want %d`, got, want)
+	}
+
+	if got := len(marker.truncated); got != 0 {
+		t.Errorf(`len(marker.truncated) = %d; want 0`, got)
+	}
+}
+
+func TestReport_String_formatsReclaimedSpace(t *testing.T) {
+	report := &Report{BytesReclaimed: 3 * 1024 * 1024, DryRun: true}
+	if got, want := report.String(), "dry run: projects=0 operations=0 truncated=0 deleted=0 reclaimed=3.0MB errors=0"; got != want {
+		t.Errorf(`report.String() = %q; want %q`, got, want)
+	}
+}
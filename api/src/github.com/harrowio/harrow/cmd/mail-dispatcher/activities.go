@@ -4,6 +4,7 @@ import (
 	"encoding/base32"
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/harrowio/harrow/config"
 	"github.com/harrowio/harrow/domain"
@@ -20,9 +21,15 @@ var activityHandlers = map[string]activityHandler{
 	"user.requested-password-reset":     handleUserRequestedPasswordReset,
 	"user.signed-up":                    userVerificationEmail,
 	"user.requested-verification-email": userVerificationEmail,
+	"user.requested-sso-link":           handleUserRequestedSsoLink,
 	"invitation.created":                handleInvitationCreated,
 	"invitation.accepted":               handleInvitationChanged,
 	"invitation.refused":                handleInvitationChanged,
+	"operation.started":                 handleSubscribedOperation,
+	"operation.succeeded":               handleSubscribedOperation,
+	"operation.failed":                  handleSubscribedOperation,
+	"operation.failed-fatally":          handleSubscribedOperation,
+	"operation.timed-out":               handleSubscribedOperation,
 }
 
 type ActivityCreatedData struct {
@@ -81,6 +88,68 @@ func handleUserRequestedPasswordReset(log logger.Logger, c *config.Config, activ
 	}}, nil
 }
 
+func handleUserRequestedSsoLink(log logger.Logger, c *config.Config, activity *domain.Activity, tx *sqlx.Tx) ([]*hmail.Mail, error) {
+	link, ok := activity.Payload.(*domain.SsoLink)
+	if !ok {
+		return nil, fmt.Errorf("Wrong payload type; have %T, want *domain.SsoLink", activity.Payload)
+	}
+
+	user, err := stores.NewDbUserStore(tx, c).FindByUuid(link.UserUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := stores.NewDbSsoProviderStore(tx).FindByUuid(link.ProviderUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := stores.NewDbOrganizationStore(tx).FindByUuid(provider.OrganizationUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := user.Name
+	if displayName == "" {
+		displayName = user.Email
+	}
+	recipient := &hmail.Recipient{
+		Subject:     "Link your account to " + organization.Name,
+		DisplayName: displayName,
+		UrlHost:     user.UrlHost,
+	}
+
+	actor := &hmail.Actor{
+		DisplayName: "Someone",
+	}
+
+	action := &hmail.Action{
+		DisplayName: "signed in through the single sign-on of",
+	}
+
+	params := url.Values{}
+	params.Set("user", link.UserUuid)
+	params.Set("provider", link.ProviderUuid)
+	params.Set("subject", link.Subject)
+	params.Set("expires", strconv.FormatInt(link.ExpiresAt.Unix(), 10))
+	params.Set("mac", base32.StdEncoding.EncodeToString(link.HMAC([]byte(c.HttpConfig().UserHmacSecret))))
+	object := &hmail.Object{
+		DisplayName: organization.Name,
+		Uri:         fmt.Sprintf("#/a/sso/link?%s", params.Encode()),
+	}
+
+	return []*hmail.Mail{{
+		To:         []string{user.Email},
+		RoutingKey: "users.requested-sso-link",
+		Data: &hmail.MailContext{
+			Recipient: recipient,
+			Actor:     actor,
+			Action:    action,
+			Object:    object,
+		},
+	}}, nil
+}
+
 func userVerificationEmail(log logger.Logger, c *config.Config, activity *domain.Activity, tx *sqlx.Tx) ([]*hmail.Mail, error) {
 	user, ok := activity.Payload.(*domain.User)
 	if !ok {
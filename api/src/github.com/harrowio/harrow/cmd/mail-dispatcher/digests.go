@@ -0,0 +1,335 @@
+package mailDispatcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/hmail"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/stores"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/streadway/amqp"
+)
+
+// digestCheckInterval is how often subscriptions are checked for
+// digests that are due.
+const digestCheckInterval = 15 * time.Minute
+
+// digestActivityNames are the activities about operations that ended,
+// which are the ones summarized in digests.
+var digestActivityNames = []string{
+	"operation.succeeded",
+	"operation.failed",
+	"operation.failed-fatally",
+	"operation.timed-out",
+}
+
+// digestRecipient is a user who is sent a digest about some of the
+// jobs of a project.
+type digestRecipient struct {
+	UserUuid    string
+	ProjectUuid string
+
+	// EventsByJobUuid holds the names of the events the user
+	// subscribed to, by job.
+	EventsByJobUuid map[string][]string
+}
+
+// subscribedTo reports whether the recipient subscribed to activity
+// on its job.
+func (self *digestRecipient) subscribedTo(activity *domain.Activity) bool {
+	return containsString(self.EventsByJobUuid[activity.JobUuid()], activity.SubscriptionKey())
+}
+
+// deliverDigests sends out due digests every digestCheckInterval.  It
+// uses a connection of its own, so that it does not interfere with
+// handling activities.
+func deliverDigests(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB) {
+	for {
+		conn := dial(c)
+		out := setUpOutboundChannel(log, conn)
+		for _, delivery := range domain.SubscriptionDigestDeliveries {
+			if err := deliverDueDigests(log, fromAddress, c, db, out, delivery, time.Now()); err != nil {
+				log.Error().Msgf("deliverDueDigests(%s): %s", delivery, err)
+			}
+		}
+		conn.Close()
+
+		time.Sleep(digestCheckInterval)
+	}
+}
+
+func deliverDueDigests(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB, out *amqp.Channel, delivery string, now time.Time) error {
+	tx := db.MustBegin()
+	recipients, err := findDigestRecipients(tx, delivery)
+	tx.Rollback()
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		if err := deliverDigest(log, fromAddress, c, db, out, recipient, delivery, now); err != nil {
+			log.Error().Msgf("deliverDigest(%s, user %s, project %s): %s", delivery, recipient.UserUuid, recipient.ProjectUuid, err)
+		}
+	}
+
+	return nil
+}
+
+// deliverDigest sends the digest due for recipient, if any, only
+// after marking it as sent has been committed, so that a digest is
+// never sent twice.
+func deliverDigest(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB, out *amqp.Channel, recipient *digestRecipient, delivery string, now time.Time) error {
+	tx := db.MustBegin()
+	defer tx.Rollback()
+
+	mail, err := digestFor(c, tx, recipient, delivery, now)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if mail == nil {
+		return nil
+	}
+
+	log.Info().Msgf("sendMail: %s digest -> %#v", delivery, mail.To)
+	return sendMail(fromAddress, out, mail)
+}
+
+// findDigestRecipients groups the subscriptions delivered as given by
+// user and project.
+func findDigestRecipients(tx *sqlx.Tx, delivery string) ([]*digestRecipient, error) {
+	subscriptions, err := stores.NewDbSubscriptionStore(tx).FindAllByDelivery(delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := stores.NewDbJobStore(tx)
+	projectUuidByJobUuid := map[string]string{}
+	recipientsByKey := map[string]*digestRecipient{}
+	result := []*digestRecipient{}
+	for _, subscription := range subscriptions {
+		projectUuid, found := projectUuidByJobUuid[subscription.WatchableUuid]
+		if !found {
+			job, err := jobs.FindByUuid(subscription.WatchableUuid)
+			if err != nil && !domain.IsNotFound(err) {
+				return nil, err
+			}
+			if job != nil {
+				projectUuid = job.ProjectUuid
+			}
+			projectUuidByJobUuid[subscription.WatchableUuid] = projectUuid
+		}
+
+		if projectUuid == "" {
+			continue
+		}
+
+		key := subscription.UserUuid + ":" + projectUuid
+		recipient, found := recipientsByKey[key]
+		if !found {
+			recipient = &digestRecipient{
+				UserUuid:        subscription.UserUuid,
+				ProjectUuid:     projectUuid,
+				EventsByJobUuid: map[string][]string{},
+			}
+			recipientsByKey[key] = recipient
+			result = append(result, recipient)
+		}
+
+		events := recipient.EventsByJobUuid[subscription.WatchableUuid]
+		if !containsString(events, subscription.EventName) {
+			recipient.EventsByJobUuid[subscription.WatchableUuid] = append(events, subscription.EventName)
+		}
+	}
+
+	return result, nil
+}
+
+// digestFor summarizes the activity since the last digest sent to
+// recipient and marks the digest as sent.  Only operations the
+// recipient subscribed to and was in the audience of are included.
+// It returns nil if no digest is due yet, if the recipient is no
+// longer a member of the project, if there was no activity to report,
+// or if the recipient's notification preferences rule out emailing it
+// now.
+func digestFor(c *config.Config, tx *sqlx.Tx, recipient *digestRecipient, delivery string, now time.Time) (*hmail.Mail, error) {
+	digests := stores.NewDbSubscriptionDigestStore(tx)
+	from := now.Add(-domain.DigestPeriod(delivery))
+	lastSentAt, err := digests.FindLastSentAt(recipient.UserUuid, recipient.ProjectUuid, delivery)
+	if err != nil && !domain.IsNotFound(err) {
+		return nil, err
+	}
+	if lastSentAt != nil {
+		if lastSentAt.After(from) {
+			return nil, nil
+		}
+		from = *lastSentAt
+	}
+
+	if err := digests.MarkSent(recipient.UserUuid, recipient.ProjectUuid, delivery, now); err != nil {
+		return nil, err
+	}
+
+	user, err := stores.NewDbUserStore(tx, c).FindByUuid(recipient.UserUuid)
+	if err != nil {
+		return nil, ignoreNotFound(err)
+	}
+
+	project, err := stores.NewDbProjectStore(tx).FindByUuid(recipient.ProjectUuid)
+	if err != nil {
+		return nil, ignoreNotFound(err)
+	}
+
+	isMember, err := isProjectMember(tx, user.Uuid, project.Uuid)
+	if err != nil || !isMember {
+		return nil, err
+	}
+
+	jobs := map[string]*domain.Job{}
+	seen := map[string]bool{}
+	jobStore := stores.NewDbJobStore(tx)
+	operations := stores.NewDbOperationStore(tx)
+	digest := domain.NewDigest(delivery, project, from, now)
+	err = stores.NewDbActivityStore(tx).AllByProjectSince(project.Uuid, from, func(activity *domain.Activity) error {
+		if !activity.OccurredOn.Before(now) ||
+			!containsString(digestActivityNames, activity.Name) ||
+			!recipient.subscribedTo(activity) ||
+			!containsString(activity.Audience(), user.Uuid) {
+			return nil
+		}
+
+		payload, ok := activity.Payload.(*domain.Operation)
+		if !ok || seen[payload.Uuid] {
+			return nil
+		}
+		seen[payload.Uuid] = true
+
+		jobUuid := activity.JobUuid()
+		if _, found := jobs[jobUuid]; !found {
+			job, err := jobStore.FindByUuid(jobUuid)
+			if err != nil {
+				if !domain.IsNotFound(err) {
+					return err
+				}
+				job = nil
+			}
+			jobs[jobUuid] = job
+		}
+		job := jobs[jobUuid]
+		if job == nil {
+			return nil
+		}
+
+		operation, err := operations.FindByUuid(payload.Uuid)
+		if err != nil {
+			return ignoreNotFound(err)
+		}
+		digest.Add(job, operation)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if digest.IsEmpty() {
+		return nil, nil
+	}
+
+	digest.Summarize()
+
+	return routeMail(tx, user, project.Uuid, newDigestMail(user, digest), now)
+}
+
+func newDigestMail(user *domain.User, digest *domain.Digest) *hmail.Mail {
+	subject := fmt.Sprintf("Your %s digest for %s: %d succeeded, %d failed",
+		digest.Delivery, digest.Project.Name,
+		digest.Succeeded, digest.Failed,
+	)
+
+	data := &hmail.Digest{
+		Period:         strings.Title(digest.Delivery),
+		ProjectName:    digest.Project.Name,
+		ProjectUri:     fmt.Sprintf("#/a/projects/%s", digest.Project.Uuid),
+		From:           digest.From.Format(time.Stamp),
+		To:             digest.To.Format(time.Stamp),
+		Succeeded:      digest.Succeeded,
+		Failed:         digest.Failed,
+		SlowestJobs:    []*hmail.DigestJob{},
+		NewestFailures: []*hmail.DigestFailure{},
+	}
+
+	for _, job := range digest.SlowestJobs {
+		data.SlowestJobs = append(data.SlowestJobs, &hmail.DigestJob{
+			Name:            job.Job.Name,
+			Uri:             fmt.Sprintf("#/a/jobs/%s", job.Job.Uuid),
+			Operations:      job.Operations,
+			AverageDuration: (job.AverageDuration / time.Second * time.Second).String(),
+		})
+	}
+
+	for _, failure := range digest.NewestFailures {
+		data.NewestFailures = append(data.NewestFailures, &hmail.DigestFailure{
+			JobName:  failure.Job.Name,
+			Uri:      fmt.Sprintf("#/a/operations/%s", failure.Operation.Uuid),
+			Status:   failure.Operation.Status(),
+			FailedAt: failure.FailedAt().Format(time.Stamp),
+		})
+	}
+
+	return &hmail.Mail{
+		RoutingKey: "digests.summary",
+		To:         []string{user.Email},
+		Data: &hmail.MailContext{
+			Subject: subject,
+			Recipient: &hmail.Recipient{
+				DisplayName: user.Name,
+				Subject:     subject,
+				UrlHost:     user.UrlHost,
+			},
+			Digest: data,
+		},
+	}
+}
+
+// isProjectMember reports whether the user currently is a member of
+// the project.
+func isProjectMember(tx *sqlx.Tx, userUuid, projectUuid string) (bool, error) {
+	members, err := stores.NewDbProjectMemberStore(tx).FindAllByProjectUuid(projectUuid)
+	if err != nil {
+		return false, err
+	}
+
+	for _, member := range members {
+		if member.Uuid == userUuid {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func ignoreNotFound(err error) error {
+	if domain.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package mailDispatcher
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/harrowio/harrow/bus/broadcast"
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/stores"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/streadway/amqp"
+)
+
+// replayCheckInterval is how often dead letters are checked for
+// whether an administrator asked to replay them.
+const replayCheckInterval = time.Minute
+
+// recordFailure records that handling the message about the row
+// identified by id in table failed with cause.  Failures are recorded
+// in their own transaction, because cause might have aborted the
+// transaction the message is handled in.
+func recordFailure(db *sqlx.DB, table, id string, cause error, giveUp bool) (*domain.FailedDelivery, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	failures := stores.NewDbFailedDeliveryStore(tx)
+	key := domain.MessageKey(table, id)
+	failed, err := failures.FindRetryingBySourceAndKey(domain.FailedDeliverySourceMailDispatcher, key)
+	isNew := domain.IsNotFound(err)
+	if isNew {
+		failed, err = domain.NewFailedDelivery(
+			domain.FailedDeliverySourceMailDispatcher,
+			domain.FailedDeliveryKindMessage,
+			key,
+			&domain.FailedMessage{Table: table, Id: id},
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if giveUp {
+		failed.GiveUp(failed.Attempts+1, cause)
+	} else {
+		failed.RecordAttempt(cause, domain.FailedDeliveryMaxAttempts)
+	}
+
+	if isNew {
+		err = failures.Create(failed)
+	} else {
+		err = failures.Update(failed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return failed, tx.Commit()
+}
+
+// rejectForever moves message to the dead letters and rejects it.
+func rejectForever(log logger.Logger, db *sqlx.DB, message broadcast.Message, cause error) error {
+	if _, err := recordFailure(db, message.Table(), message.UUID(), cause, true); err != nil {
+		log.Error().Msgf("recordFailure(%s): %s", message, err)
+	}
+
+	return message.RejectForever()
+}
+
+// requeue records a failed attempt at handling message.  The message
+// is requeued with backoff, until it has been tried
+// domain.FailedDeliveryMaxAttempts times and is moved to the dead
+// letters.
+func requeue(log logger.Logger, db *sqlx.DB, message broadcast.Message, cause error) error {
+	failed, err := recordFailure(db, message.Table(), message.UUID(), cause, false)
+	if err != nil {
+		log.Error().Msgf("recordFailure(%s): %s", message, err)
+		return message.RequeueAfter(domain.FailedDeliveryBackoff)
+	}
+
+	if failed.IsDead() {
+		log.Info().Msgf("Dead: %s after %d attempts", message, failed.Attempts)
+		return message.RejectForever()
+	}
+
+	log.Info().Msgf("Requeue: %s in %s", message, failed.Backoff())
+	return message.RequeueAfter(failed.Backoff())
+}
+
+// markDelivered marks earlier failed attempts at handling message as
+// delivered.
+func markDelivered(db *sqlx.DB, message broadcast.Message) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	failures := stores.NewDbFailedDeliveryStore(tx)
+	key := domain.MessageKey(message.Table(), message.UUID())
+	failed, err := failures.FindRetryingBySourceAndKey(domain.FailedDeliverySourceMailDispatcher, key)
+	if domain.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	failed.Delivered()
+	if err := failures.Update(failed); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replayDeadLetters replays the dead letters an administrator asked
+// to replay every replayCheckInterval.
+func replayDeadLetters(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB) {
+	for {
+		conn := dial(c)
+		out := setUpOutboundChannel(log, conn)
+		if err := replayFailedMessages(log, fromAddress, c, db, out); err != nil {
+			log.Error().Msgf("replayFailedMessages: %s", err)
+		}
+		conn.Close()
+
+		time.Sleep(replayCheckInterval)
+	}
+}
+
+func replayFailedMessages(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB, out *amqp.Channel) error {
+	tx := db.MustBegin()
+	defer tx.Rollback()
+
+	failures := stores.NewDbFailedDeliveryStore(tx)
+	replaying, err := failures.FindAllReplaying(domain.FailedDeliverySourceMailDispatcher)
+	if err != nil {
+		return err
+	}
+
+	for _, failed := range replaying {
+		failed.Replayed()
+		if err := failures.Update(failed); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, failed := range replaying {
+		payload := domain.FailedMessage{}
+		if err := json.Unmarshal(failed.Payload, &payload); err != nil {
+			log.Error().Msgf("replay %s: %s", failed.Uuid, err)
+			continue
+		}
+
+		log.Info().Msgf("Replay: %s %s", failed.Uuid, failed.Key)
+		if err := replayMessage(log, fromAddress, c, db, out, &payload); err != nil {
+			log.Error().Msgf("replay %s: %s", failed.Uuid, err)
+			if _, err := recordFailure(db, payload.Table, payload.Id, err, true); err != nil {
+				log.Error().Msgf("recordFailure(%s): %s", failed.Key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// replayMessage handles message as if it had just been received.
+func replayMessage(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB, out *amqp.Channel, message *domain.FailedMessage) error {
+	tx := db.MustBegin()
+	defer tx.Rollback()
+
+	activityId, err := strconv.Atoi(message.Id)
+	if err != nil {
+		return err
+	}
+
+	activity, err := stores.NewDbActivityStore(tx).FindActivityById(activityId)
+	if err != nil {
+		return err
+	}
+
+	handler, found := activityHandlers[activity.Name]
+	if !found {
+		return nil
+	}
+
+	mails, err := handler(log, c, activity, tx)
+	if err == ErrInternalOperation {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, mail := range mails {
+		log.Info().Msgf("sendMail: replay %s:%s -> %#v", message.Table, message.Id, mail.To)
+		if err := sendMail(fromAddress, out, mail); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package mailDispatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/hmail"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/netguard"
+	"github.com/harrowio/harrow/stores"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/streadway/amqp"
+)
+
+// deferredCheckInterval is how often mails held back during quiet
+// hours are checked for whether they are due.
+const deferredCheckInterval = time.Minute
+
+var slackClient = netguard.NewClient(30 * time.Second)
+
+// deferredMail is the payload of a deferred delivery of kind
+// domain.DeferredDeliveryMail.  The routing key is kept separately,
+// because it is not part of a mail's JSON representation.
+type deferredMail struct {
+	RoutingKey string      `json:"routingKey"`
+	Mail       *hmail.Mail `json:"mail"`
+}
+
+// routeMail respects the notification preferences of user for mail
+// about the project identified by projectUuid.  It returns the mail if
+// it should be sent by email right away, and nil if the user muted the
+// project, is in quiet hours or prefers Slack, in which case the mail
+// has been posted to their Slack webhook instead.
+func routeMail(tx *sqlx.Tx, user *domain.User, projectUuid string, mail *hmail.Mail, now time.Time) (*hmail.Mail, error) {
+	preferences, err := stores.NewDbNotificationPreferencesStore(tx).FindByUserUuid(user.Uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	if preferences.IsMuted(projectUuid) {
+		return nil, nil
+	}
+
+	if until, quiet := preferences.QuietUntil(now); quiet {
+		payload, err := json.Marshal(&deferredMail{RoutingKey: mail.RoutingKey, Mail: mail})
+		if err != nil {
+			return nil, err
+		}
+
+		delivery := domain.NewDeferredDelivery(user.Uuid, domain.DeferredDeliveryMail, payload, until)
+		return nil, stores.NewDbDeferredDeliveryStore(tx).Create(delivery)
+	}
+
+	if preferences.Channel == domain.NotificationChannelSlack {
+		return nil, postToSlack(preferences.SlackWebhookURL, mail)
+	}
+
+	return mail, nil
+}
+
+// postToSlack posts the subject of mail and a link to what it is
+// about to webhookURL, which has to be an incoming webhook of Slack.
+func postToSlack(webhookURL string, mail *hmail.Mail) error {
+	if !domain.IsSlackWebhookURL(webhookURL) {
+		return fmt.Errorf("postToSlack: not a Slack webhook: %q", webhookURL)
+	}
+
+	text := mail.Data.Subject
+	if text == "" && mail.Data.Recipient != nil {
+		text = mail.Data.Recipient.Subject
+	}
+
+	uri := ""
+	if mail.Data.Object != nil {
+		uri = mail.Data.Object.Uri
+	} else if mail.Data.Digest != nil {
+		uri = mail.Data.Digest.ProjectUri
+	}
+	if uri != "" && mail.Data.Recipient != nil {
+		text = fmt.Sprintf("%s\nhttps://%s/%s", text, mail.Data.Recipient.UrlHost, strings.TrimPrefix(uri, "/"))
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	response, err := slackClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("POST %s: %s", webhookURL, response.Status)
+	}
+
+	return nil
+}
+
+// deliverDeferredMails sends out mails held back during quiet hours
+// every deferredCheckInterval.
+func deliverDeferredMails(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB) {
+	for {
+		conn := dial(c)
+		out := setUpOutboundChannel(log, conn)
+		if err := deliverDueMails(log, fromAddress, c, db, out, time.Now()); err != nil {
+			log.Error().Msgf("deliverDueMails: %s", err)
+		}
+		conn.Close()
+
+		time.Sleep(deferredCheckInterval)
+	}
+}
+
+func deliverDueMails(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB, out *amqp.Channel, now time.Time) error {
+	tx := db.MustBegin()
+	due, err := stores.NewDbDeferredDeliveryStore(tx).FindAllDue(domain.DeferredDeliveryMail, now)
+	tx.Rollback()
+	if err != nil {
+		return err
+	}
+
+	for _, deferred := range due {
+		if err := deliverDeferredMail(log, fromAddress, c, db, out, deferred, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliverDeferredMail marks deferred as delivered and sends its mail
+// only after that has been committed, so that mails which have been
+// sent are not sent again when delivering another one fails.
+func deliverDeferredMail(log logger.Logger, fromAddress string, c *config.Config, db *sqlx.DB, out *amqp.Channel, deferred *domain.DeferredDelivery, now time.Time) error {
+	tx := db.MustBegin()
+	defer tx.Rollback()
+
+	if err := stores.NewDbDeferredDeliveryStore(tx).MarkDelivered(deferred.Uuid, now); err != nil {
+		return err
+	}
+
+	payload := deferredMail{}
+	if err := json.Unmarshal(deferred.Payload, &payload); err != nil || payload.Mail == nil {
+		log.Error().Msgf("deferred %s: malformed payload: %v", deferred.Uuid, err)
+		return tx.Commit()
+	}
+	payload.Mail.RoutingKey = payload.RoutingKey
+
+	user, err := stores.NewDbUserStore(tx, c).FindByUuid(deferred.UserUuid)
+	if domain.IsNotFound(err) {
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Muted projects have been dropped before deferring the mail
+	// already.
+	mail, err := routeMail(tx, user, "", payload.Mail, now)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if mail == nil {
+		return nil
+	}
+
+	log.Info().Msgf("sendMail: deferred %s -> %#v", deferred.Uuid, mail.To)
+	return sendMail(fromAddress, out, mail)
+}
@@ -0,0 +1,93 @@
+package mailDispatcher
+
+import (
+	"time"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/hmail"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/stores"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// handleSubscribedOperation emails the members of the activity's
+// audience who subscribed to the operation's job, as far as their
+// notification preferences allow.  Subscribers who receive digests
+// instead are not emailed.
+func handleSubscribedOperation(log logger.Logger, c *config.Config, activity *domain.Activity, tx *sqlx.Tx) ([]*hmail.Mail, error) {
+	operation, ok := activity.Payload.(*domain.Operation)
+	if !ok {
+		return nil, ErrMalformedPayload
+	}
+
+	if operation.JobUuid == nil {
+		return nil, ErrInternalOperation
+	}
+
+	operation, err := stores.NewDbOperationStore(tx).FindByUuid(operation.Uuid)
+	if err != nil {
+		log.Info().Msgf("handleSubscribedOperation: operationStore.FindByUuid: %s", err)
+		return nil, ignoreNotFound(err)
+	}
+
+	job, err := stores.NewDbJobStore(tx).FindByUuid(*operation.JobUuid)
+	if err != nil {
+		log.Info().Msgf("handleSubscribedOperation: jobStore.FindByUuid(%q): %s", *operation.JobUuid, err)
+		return nil, ignoreNotFound(err)
+	}
+
+	project, err := stores.NewDbProjectStore(tx).FindByUuid(job.ProjectUuid)
+	if err != nil {
+		log.Info().Msgf("handleSubscribedOperation: projectStore.FindByUuid(%q): %s", job.ProjectUuid, err)
+		return nil, ignoreNotFound(err)
+	}
+
+	subscribers, err := stores.NewDbUserStore(tx, c).FindAllSubscribers(job.Uuid, activity.SubscriptionKey())
+	if err != nil {
+		log.Info().Msgf("handleSubscribedOperation: userStore.FindAllSubscribers(%q): %s", job.Uuid, err)
+		return nil, ErrStorage
+	}
+
+	audience := activity.Audience()
+	now := time.Now()
+	mails := []*hmail.Mail{}
+	for _, user := range subscribers {
+		if !containsString(audience, user.Uuid) {
+			continue
+		}
+
+		data := &OperationChangedData{
+			operation:  operation,
+			project:    project,
+			job:        job,
+			recipients: []*domain.User{user},
+		}
+
+		composed := []*hmail.Mail(nil)
+		switch activity.Name {
+		case "operation.started":
+			composed, err = handleOperationChangedToStarted(data)
+		case "operation.timed-out":
+			composed, err = handleOperationChangedToTimedOut(data)
+		default:
+			composed, err = handleOperationChangedToFinished(data)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mail := range composed {
+			mail, err := routeMail(tx, user, project.Uuid, mail, now)
+			if err != nil {
+				return nil, err
+			}
+			if mail != nil {
+				mails = append(mails, mail)
+			}
+		}
+	}
+
+	return mails, nil
+}
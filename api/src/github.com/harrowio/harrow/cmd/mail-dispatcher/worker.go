@@ -55,12 +55,12 @@ func handleWork(log logger.Logger, fromAddress string, c *config.Config, db *sql
 	activityId, err := strconv.Atoi(message.UUID())
 	if err != nil {
 		log.Error().Msgf(" strconv.Atoi(message.UUID()): %s", err)
-		return message.RejectForever()
+		return rejectForever(log, db, message, err)
 	}
 	activity, err := activityStore.FindActivityById(activityId)
 	if err != nil {
 		log.Info().Msgf("activityStore.FindActivityById(%d): %s", activityId, err)
-		return message.RejectForever()
+		return rejectForever(log, db, message, err)
 	}
 
 	log.Info().Msgf("checking for handlers for %s", activity.Name)
@@ -77,7 +77,7 @@ func handleWork(log logger.Logger, fromAddress string, c *config.Config, db *sql
 	if err != nil {
 		if err == ErrMalformedPayload {
 			log.Info().Msgf("Drop: %s", err)
-			return message.RejectForever()
+			return rejectForever(log, db, message, err)
 		}
 
 		log.Info().Msgf("Error: %s", err)
@@ -85,7 +85,7 @@ func handleWork(log logger.Logger, fromAddress string, c *config.Config, db *sql
 			log.Info().Msgf("Internal Operation on the bus, nacking permanently")
 			return message.RejectForever()
 		} else {
-			return message.RequeueAfter(10 * time.Second)
+			return requeue(log, db, message, err)
 		}
 	}
 
@@ -93,10 +93,6 @@ func handleWork(log logger.Logger, fromAddress string, c *config.Config, db *sql
 		return err
 	}
 
-	if mails == nil {
-		return nil
-	}
-
 	for _, mail := range mails {
 		log.Info().Msgf("sendMail: %s -> %#v", message, mail.To)
 
@@ -107,11 +103,15 @@ func handleWork(log logger.Logger, fromAddress string, c *config.Config, db *sql
 		case nil:
 			// noop
 		default:
-			log.Info().Msgf("sendMail: %s, equeuing in 10s", e)
-			return message.RequeueAfter(10 * time.Second)
+			log.Info().Msgf("sendMail: %s", e)
+			return requeue(log, db, message, e)
 		}
 	}
 
+	if err := markDelivered(db, message); err != nil {
+		log.Error().Msgf("markDelivered(%s): %s", message, err)
+	}
+
 	return nil
 }
 
@@ -262,7 +262,12 @@ func handleOperationChangedToTimedOut(data *OperationChangedData) ([]*hmail.Mail
 
 	subject := fmt.Sprintf("%s/%s timed out on %s",
 		data.project.Name, data.job.Name,
-		data.operation.TimedOutAt.Format(time.Stamp),
+		(func() string {
+			if data.operation.TimedOutAt == nil {
+				return "?"
+			}
+			return data.operation.TimedOutAt.Format(time.Stamp)
+		})(),
 	)
 
 	for _, user := range data.recipients {
@@ -426,6 +431,10 @@ func Main() {
 	bus := broadcast.NewAMQPTransport(c.AmqpConnectionString(), "mail-dispatcher")
 	defer bus.Close()
 
+	go deliverDigests(log, fromAddress, c, db)
+	go deliverDeferredMails(log, fromAddress, c, db)
+	go replayDeadLetters(log, fromAddress, c, db)
+
 	work, err := bus.Consume(broadcast.Create)
 	if err != nil {
 		log.Fatal().Msgf("bus.consume(broadcast.create): %s", err)
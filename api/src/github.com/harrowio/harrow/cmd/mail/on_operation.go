@@ -2,6 +2,7 @@ package harrowMail
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/harrowio/harrow/domain"
 	"github.com/harrowio/harrow/hmail"
@@ -50,6 +51,29 @@ func OnOperation(actionName string) NotificationHandler {
 			ctxt.Project.Name, ctxt.Job.Name,
 		)
 
+		if results := operation.TestResults; results != nil && len(results.Entries) > 0 {
+			result.TestResults = &hmail.TestResults{
+				Total:  len(results.Entries),
+				Failed: results.FailedNames(),
+			}
+			result.Subject = fmt.Sprintf("%s%s", result.Subject, failingTestsSummary(result.TestResults.Failed))
+		}
+
 		return result, nil
 	}
 }
+
+// failingTestsSummary names the failing tests for use in a subject
+// line.  At most three tests are named.
+func failingTestsSummary(failed []string) string {
+	switch n := len(failed); {
+	case n == 0:
+		return ""
+	case n == 1:
+		return fmt.Sprintf(" (1 failing test: %s)", failed[0])
+	case n <= 3:
+		return fmt.Sprintf(" (%d failing tests: %s)", n, strings.Join(failed, ", "))
+	default:
+		return fmt.Sprintf(" (%d failing tests: %s, ...)", n, strings.Join(failed[0:3], ", "))
+	}
+}
@@ -18,3 +18,34 @@ func TestOnOperation_returns_notification_handler_that_includes_operation_logs(t
 		t.Fatalf("result.OperationLogs() was nil")
 	}
 }
+
+func TestOnOperation_returns_notification_handler_that_names_failing_tests(t *testing.T) {
+	subject := OnOperation("Failed")
+	context := NewContext("test@localhost")
+	context.Activity = activities.OperationFailed(&domain.Operation{
+		TestResults: &domain.TestResults{
+			Entries: []*domain.TestResult{
+				{ClassName: "models.UserTest", Name: "test_create", Status: domain.TestStatusPassed},
+				{ClassName: "models.UserTest", Name: "test_login", Status: domain.TestStatusFailed},
+			},
+		},
+	})
+	context.Project = &domain.Project{Name: "foo"}
+	context.Job = &domain.Job{Name: "bar"}
+	result, err := subject(context)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := result.Subject, "[Failed] foo: bar (1 failing test: models.UserTest.test_login)"; got != want {
+		t.Errorf(`result.Subject = %q; want %q`, got, want)
+	}
+
+	if got := result.TestResults; got == nil {
+		t.Fatalf("result.TestResults was nil")
+	}
+
+	if got, want := result.TestResults.Failed, []string{"models.UserTest.test_login"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf(`result.TestResults.Failed = %v; want %v`, got, want)
+	}
+}
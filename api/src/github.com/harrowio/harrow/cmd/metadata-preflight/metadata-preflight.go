@@ -169,7 +169,11 @@ func clonedRepositoryFor(c *config.Config, db *sqlx.DB, repository *domain.Repos
 	tx := db.MustBegin()
 	defer tx.Rollback()
 	redisClient := redis.NewTCPClient(c.RedisConnOpts(1))
-	secrets := stores.NewRedisSecretKeyValueStore(redisClient)
+	secrets, err := stores.NewSecretKeyValueStore(c, redisClient)
+	if err != nil {
+		log.Error().Msgf("stores.NewSecretKeyValueStore: %s", err)
+		return nil
+	}
 	repositoryCredentials := stores.NewRepositoryCredentialStore(secrets, tx)
 	OS := git.NewOperatingSystem(c.FilesystemConfig().GitTempDir)
 	clonedRepository, err := repository.ClonedGit(OS, repositoryCredentials)
@@ -0,0 +1,227 @@
+package migrateSecrets
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	redis "gopkg.in/redis.v2"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+const ProgramName = "migrate-secrets"
+
+var log zerolog.Logger = zerolog.New(os.Stdout).With().Str("harrow", ProgramName).Timestamp().Logger()
+
+// Entry identifies a secret stored in a SecretKeyValueStore: the uuid
+// of a secret or repository credential, or the key of a secret
+// version, and the key it is encrypted with.
+type Entry struct {
+	Uuid string `db:"uuid"`
+	Key  []byte `db:"key"`
+}
+
+// Options control how secrets are migrated.
+type Options struct {
+	// DryRun only reports what would be copied.
+	DryRun bool
+
+	// DeleteSource removes secrets from the source backend once
+	// they have been copied.
+	DeleteSource bool
+}
+
+// Report summarizes a single run of migrating secrets.
+type Report struct {
+	Copied  int
+	Skipped int
+	Missing int
+	Deleted int
+	Errors  int
+	DryRun  bool
+}
+
+func (self *Report) String() string {
+	prefix := ""
+	if self.DryRun {
+		prefix = "dry run: "
+	}
+	return fmt.Sprintf("%scopied=%d skipped=%d missing=%d deleted=%d errors=%d",
+		prefix,
+		self.Copied,
+		self.Skipped,
+		self.Missing,
+		self.Deleted,
+		self.Errors,
+	)
+}
+
+func Main() {
+	from := flag.String("from", config.SecretStorageRedis, "backend to read secrets from (redis or vault)")
+	to := flag.String("to", config.SecretStorageVault, "backend to write secrets to (redis or vault)")
+	dryRun := flag.Bool("n", false, "dry run: report what would be copied without changing anything")
+	deleteSource := flag.Bool("delete", false, "delete secrets from the source backend after copying them")
+	flag.Parse()
+
+	if *from == *to {
+		log.Fatal().Msgf("-from and -to are both %q", *from)
+	}
+
+	c := config.GetConfig()
+	redisClient := redis.NewTCPClient(c.RedisConnOpts(1))
+	defer redisClient.Close()
+
+	source, err := backendNamed(c, *from, redisClient)
+	if err != nil {
+		log.Fatal().Msgf("-from: %s", err)
+	}
+
+	destination, err := backendNamed(c, *to, redisClient)
+	if err != nil {
+		log.Fatal().Msgf("-to: %s", err)
+	}
+
+	db, err := c.DB()
+	if err != nil {
+		log.Fatal().Msgf("error opening database handle: %s", err)
+	}
+	defer db.Close()
+
+	tx := db.MustBegin()
+	defer tx.Rollback()
+
+	entries, err := FindEntries(tx)
+	if err != nil {
+		log.Fatal().Msgf("FindEntries: %s", err)
+	}
+
+	report := Migrate(entries, source, destination, Options{
+		DryRun:       *dryRun,
+		DeleteSource: *deleteSource,
+	})
+
+	log.Info().
+		Int("copied", report.Copied).
+		Int("skipped", report.Skipped).
+		Int("missing", report.Missing).
+		Int("deleted", report.Deleted).
+		Int("errors", report.Errors).
+		Bool("dryRun", report.DryRun).
+		Msg(report.String())
+
+	if report.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+func backendNamed(c *config.Config, backend string, redisClient *redis.Client) (stores.SecretKeyValueStore, error) {
+	storageConfig := c.SecretStorageConfig()
+	storageConfig.Backend = backend
+	return stores.NewSecretKeyValueStoreFromConfig(storageConfig, redisClient)
+}
+
+// FindEntries returns all secrets, secret versions and repository
+// credentials, including archived ones, whose contents might be kept
+// in a SecretKeyValueStore.
+func FindEntries(tx *sqlx.Tx) ([]*Entry, error) {
+	result := []*Entry{}
+	for _, table := range []string{"secrets", "repository_credentials"} {
+		entries := []*Entry{}
+		if err := tx.Select(&entries, `SELECT uuid, key FROM `+table+` ORDER BY uuid`); err != nil {
+			return nil, fmt.Errorf("%s: %s", table, err)
+		}
+		result = append(result, entries...)
+	}
+
+	versions := []struct {
+		SecretUuid string `db:"secret_uuid"`
+		Version    int    `db:"version"`
+		Key        []byte `db:"key"`
+	}{}
+	q := `SELECT sv.secret_uuid, sv.version, s.key
+	  FROM secret_versions sv
+	  JOIN secrets s ON s.uuid = sv.secret_uuid
+	  ORDER BY sv.secret_uuid, sv.version`
+	if err := tx.Select(&versions, q); err != nil {
+		return nil, fmt.Errorf("secret_versions: %s", err)
+	}
+
+	for _, version := range versions {
+		result = append(result, &Entry{
+			Uuid: domain.SecretVersionKey(version.SecretUuid, version.Version),
+			Key:  version.Key,
+		})
+	}
+
+	return result, nil
+}
+
+// Migrate copies every entry from source to destination.  Entries
+// that are missing in source are counted, but not treated as errors,
+// because pending secrets have not been generated yet.  Entries
+// already present in destination with the same contents are skipped,
+// so that an interrupted migration can be run again.  Failing entries
+// are counted and logged, but don't stop the migration.
+func Migrate(entries []*Entry, source, destination stores.SecretKeyValueStore, opts Options) *Report {
+	report := &Report{DryRun: opts.DryRun}
+
+	for _, entry := range entries {
+		if err := migrateEntry(entry, source, destination, opts, report); err != nil {
+			log.Error().Msgf("%s: %s", entry.Uuid, err)
+			report.Errors++
+		}
+	}
+
+	return report
+}
+
+func migrateEntry(entry *Entry, source, destination stores.SecretKeyValueStore, opts Options, report *Report) error {
+	data, err := source.Get(entry.Uuid, entry.Key)
+	if err == stores.ErrKeyNotFound {
+		report.Missing++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	existing, err := destination.Get(entry.Uuid, entry.Key)
+	if err != nil && err != stores.ErrKeyNotFound {
+		return err
+	}
+
+	if err == nil && string(existing) == string(data) {
+		report.Skipped++
+		return deleteSource(entry, source, opts, report)
+	}
+
+	if !opts.DryRun {
+		if err := destination.Set(entry.Uuid, entry.Key, data); err != nil {
+			return err
+		}
+	}
+
+	report.Copied++
+
+	return deleteSource(entry, source, opts, report)
+}
+
+func deleteSource(entry *Entry, source stores.SecretKeyValueStore, opts Options, report *Report) error {
+	if !opts.DeleteSource {
+		return nil
+	}
+
+	if !opts.DryRun {
+		if err := source.Del(entry.Uuid); err != nil {
+			return err
+		}
+	}
+
+	report.Deleted++
+	return nil
+}
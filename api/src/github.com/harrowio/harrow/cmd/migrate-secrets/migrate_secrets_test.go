@@ -0,0 +1,93 @@
+package migrateSecrets
+
+import (
+	"testing"
+
+	"github.com/harrowio/harrow/stores"
+	helpers "github.com/harrowio/harrow/test_helpers"
+)
+
+func TestMigrate_copiesSecretsToDestination(t *testing.T) {
+	source := helpers.NewMockSecretKeyValueStore()
+	destination := helpers.NewMockSecretKeyValueStore()
+	source.Set("a", []byte("key-a"), []byte("secret a"))
+	source.Set("b", []byte("key-b"), []byte("secret b"))
+
+	report := Migrate([]*Entry{
+		{Uuid: "a", Key: []byte("key-a")},
+		{Uuid: "b", Key: []byte("key-b")},
+	}, source, destination, Options{})
+
+	for key, want := range map[string]string{"a": "secret a", "b": "secret b"} {
+		data, err := destination.Get(key, nil)
+		if err != nil {
+			t.Fatalf("destination.Get(%q): %s", key, err)
+		}
+		if got := string(data); got != want {
+			t.Errorf(`destination.Get(%q) = %q; want %q`, key, got, want)
+		}
+	}
+
+	if report.Copied != 2 || report.Errors != 0 {
+		t.Errorf(`report = %s; want copied=2 errors=0`, report)
+	}
+}
+
+func TestMigrate_countsMissingSecrets(t *testing.T) {
+	source := helpers.NewMockSecretKeyValueStore()
+	destination := helpers.NewMockSecretKeyValueStore()
+
+	report := Migrate([]*Entry{{Uuid: "pending", Key: []byte("key")}}, source, destination, Options{})
+
+	if report.Missing != 1 || report.Copied != 0 || report.Errors != 0 {
+		t.Errorf(`report = %s; want missing=1 copied=0 errors=0`, report)
+	}
+}
+
+func TestMigrate_skipsSecretsAlreadyCopied(t *testing.T) {
+	source := helpers.NewMockSecretKeyValueStore()
+	destination := helpers.NewMockSecretKeyValueStore()
+	source.Set("a", []byte("key"), []byte("secret"))
+	destination.Set("a", []byte("key"), []byte("secret"))
+
+	report := Migrate([]*Entry{{Uuid: "a", Key: []byte("key")}}, source, destination, Options{})
+
+	if report.Skipped != 1 || report.Copied != 0 {
+		t.Errorf(`report = %s; want skipped=1 copied=0`, report)
+	}
+}
+
+func TestMigrate_dryRunDoesNotChangeAnything(t *testing.T) {
+	source := helpers.NewMockSecretKeyValueStore()
+	destination := helpers.NewMockSecretKeyValueStore()
+	source.Set("a", []byte("key"), []byte("secret"))
+
+	report := Migrate([]*Entry{{Uuid: "a", Key: []byte("key")}}, source, destination, Options{
+		DryRun:       true,
+		DeleteSource: true,
+	})
+
+	if _, err := destination.Get("a", nil); err != stores.ErrKeyNotFound {
+		t.Errorf(`destination.Get("a"): err = %v; want %v`, err, stores.ErrKeyNotFound)
+	}
+
+	if _, err := source.Get("a", nil); err != nil {
+		t.Errorf(`source.Get("a"): %s`, err)
+	}
+
+	if report.Copied != 1 || report.Deleted != 1 {
+		t.Errorf(`report = %s; want copied=1 deleted=1`, report)
+	}
+}
+
+func TestMigrate_deletesSourceAfterCopying(t *testing.T) {
+	source := helpers.NewMockSecretKeyValueStore()
+	destination := helpers.NewMockSecretKeyValueStore()
+	source.Set("a", []byte("key"), []byte("secret"))
+
+	Migrate([]*Entry{{Uuid: "a", Key: []byte("key")}}, source, destination, Options{DeleteSource: true})
+
+	if _, err := source.Get("a", nil); err != stores.ErrKeyNotFound {
+		t.Errorf(`source.Get("a"): err = %v; want %v`, err, stores.ErrKeyNotFound)
+	}
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/mohamedattahri/mail"
+)
+
+// BlameNotifier emails the authors of the commits that broke a job,
+// listing the commits that went into the failing operation.
+type BlameNotifier struct {
+	send func(message *mail.Message) error
+}
+
+func NewBlameNotifier() *BlameNotifier {
+	return &BlameNotifier{
+		send: sendmail,
+	}
+}
+
+func (self *BlameNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.BlameNotifier)
+	if !ok {
+		return fmt.Errorf("BlameNotifier: unexpected notifier type %T", notification.Notifier)
+	}
+
+	if notification.Job == nil {
+		return fmt.Errorf("BlameNotifier: activity %s@%d is not about a job", notification.Activity.Name, notification.Activity.Id)
+	}
+
+	// Only operations that broke a job are blamed on anybody.
+	blame := notification.Blame
+	if blame == nil {
+		return nil
+	}
+
+	// Every author is emailed, even if emailing one of the others
+	// failed.
+	from := fmt.Sprintf("notifications@%s", notifier.UrlHost)
+	subject := fmt.Sprintf("%s/%s broke after your changes", notification.Project.Name, notification.Job.Name)
+	failures := []string{}
+	for _, author := range blame.Authors {
+		to, name, urlHost := author.Email, author.Name, notifier.UrlHost
+		if user := author.User; user != nil {
+			to, urlHost = user.Email, user.UrlHost
+			if user.Name != "" {
+				name = user.Name
+			}
+		}
+
+		message, err := composeText(from, to, subject, blameBody(notification, name, urlHost))
+		if err == nil {
+			err = self.send(message)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", to, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("BlameNotifier: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// blameBody lists the commits of the blame of notification in a plain
+// text email addressed to name.
+func blameBody(notification *Notification, name, urlHost string) string {
+	blame := notification.Blame
+	body := new(bytes.Buffer)
+	fmt.Fprintf(body, "Hi %s,\n\n", name)
+	fmt.Fprintf(body, "%s/%s failed after succeeding before.  These commits went into the failing operation:\n\n",
+		notification.Project.Name, notification.Job.Name)
+
+	for _, commit := range blame.Commits {
+		hash := commit.Commit
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		fmt.Fprintf(body, "  %s %s (%s <%s>)\n", hash, commit.Subject, commit.Author, commit.AuthorEmail)
+	}
+
+	fmt.Fprintf(body, "\nhttps://%s/#/a/operations/%s\n", urlHost, blame.Operation.Uuid)
+
+	return body.String()
+}
@@ -0,0 +1,126 @@
+package notifier
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/mohamedattahri/mail"
+)
+
+func TestBlameNotifier_Notify_emailsEveryAuthorTheCommitsOfTheFailingOperation(t *testing.T) {
+	sent := []string{}
+	notifier := NewBlameNotifier()
+	notifier.send = func(message *mail.Message) error {
+		sent = append(sent, string(message.Bytes()))
+		return nil
+	}
+
+	operation := &domain.Operation{Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7", ExitStatus: 1}
+	blame := &domain.Blame{
+		Operation: operation,
+		Commits: []*domain.BlamedCommit{
+			{GitLogEntry: &domain.GitLogEntry{Commit: "4f2a9c1e7b", Author: "Jane", AuthorEmail: "jane@example.com", Subject: "Change the config"}},
+			{GitLogEntry: &domain.GitLogEntry{Commit: "8d3b6e0f2a", Author: "John", AuthorEmail: "john@example.com", Subject: "Break the build"}},
+		},
+	}
+	blame.Authors = []*domain.BlamedAuthor{
+		{Name: "Jane", Email: "jane@example.com", User: &domain.User{Name: "Jane Doe", Email: "jane.doe@example.com", UrlHost: "www.app.harrow.io"}, Commits: blame.Commits[:1]},
+		{Name: "John", Email: "john@example.com", Commits: blame.Commits[1:]},
+	}
+
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.failed", Payload: operation},
+		Notifier: &domain.BlameNotifier{ProjectNotifierBase: domain.ProjectNotifierBase{UrlHost: "www.app.harrow.io"}},
+		Project:  &domain.Project{Uuid: "a1e7b1f5-7a4b-4a6e-9c43-2d6a1b7c2e3f", Name: "shop"},
+		Job:      &domain.Job{Uuid: "f6d5b2a7-3c1e-4b8a-9e2d-5a4c3b2a1f0e", Name: "deploy"},
+		Blame:    blame,
+	}
+
+	if err := notifier.Notify(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(sent), 2; got != want {
+		t.Fatalf("len(sent) = %d; want %d", got, want)
+	}
+
+	for i, recipient := range []string{"jane.doe@example.com", "john@example.com"} {
+		if !strings.Contains(sent[i], recipient) {
+			t.Errorf("sent[%d] does not mention %q:\n%s", i, recipient, sent[i])
+		}
+
+		for _, hash := range []string{"4f2a9c1", "8d3b6e0"} {
+			if !strings.Contains(sent[i], hash) {
+				t.Errorf("sent[%d] does not list commit %q:\n%s", i, hash, sent[i])
+			}
+		}
+	}
+}
+
+func TestBlameNotifier_Notify_sendsNothing_ifOperationDidNotBreakTheJob(t *testing.T) {
+	sent := 0
+	notifier := NewBlameNotifier()
+	notifier.send = func(message *mail.Message) error {
+		sent++
+		return nil
+	}
+
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.failed", Payload: &domain.Operation{ExitStatus: 1}},
+		Notifier: &domain.BlameNotifier{ProjectNotifierBase: domain.ProjectNotifierBase{UrlHost: "www.app.harrow.io"}},
+		Project:  &domain.Project{Name: "shop"},
+		Job:      &domain.Job{Name: "deploy"},
+	}
+
+	if err := notifier.Notify(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sent, 0; got != want {
+		t.Errorf("sent = %d; want %d", got, want)
+	}
+}
+
+func TestBlameNotifier_Notify_emailsEveryAuthor_ifEmailingOneFails(t *testing.T) {
+	sent := []string{}
+	notifier := NewBlameNotifier()
+	notifier.send = func(message *mail.Message) error {
+		sent = append(sent, string(message.Bytes()))
+		if len(sent) == 1 {
+			return errors.New("sendmail: exit status 75")
+		}
+		return nil
+	}
+
+	operation := &domain.Operation{Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7", ExitStatus: 1}
+	blame := &domain.Blame{
+		Operation: operation,
+		Commits: []*domain.BlamedCommit{
+			{GitLogEntry: &domain.GitLogEntry{Commit: "4f2a9c1e7b", Author: "Jane", AuthorEmail: "jane@example.com", Subject: "Change the config"}},
+			{GitLogEntry: &domain.GitLogEntry{Commit: "8d3b6e0f2a", Author: "John", AuthorEmail: "john@example.com", Subject: "Break the build"}},
+		},
+	}
+	blame.Authors = []*domain.BlamedAuthor{
+		{Name: "Jane", Email: "jane@example.com", Commits: blame.Commits[:1]},
+		{Name: "John", Email: "john@example.com", Commits: blame.Commits[1:]},
+	}
+
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.failed", Payload: operation},
+		Notifier: &domain.BlameNotifier{ProjectNotifierBase: domain.ProjectNotifierBase{UrlHost: "www.app.harrow.io"}},
+		Project:  &domain.Project{Uuid: "a1e7b1f5-7a4b-4a6e-9c43-2d6a1b7c2e3f", Name: "shop"},
+		Job:      &domain.Job{Uuid: "f6d5b2a7-3c1e-4b8a-9e2d-5a4c3b2a1f0e", Name: "deploy"},
+		Blame:    blame,
+	}
+
+	err := notifier.Notify(notification)
+	if err == nil || !strings.Contains(err.Error(), "jane@example.com") {
+		t.Errorf("err = %v; want it to mention jane@example.com", err)
+	}
+
+	if got, want := len(sent), 2; got != want {
+		t.Fatalf("len(sent) = %d; want %d", got, want)
+	}
+}
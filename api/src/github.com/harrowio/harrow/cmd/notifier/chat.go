@@ -0,0 +1,141 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+const (
+	chatUsername   = "Harrow"
+	chatAuthorIcon = "https://www.app.harrow.io/images/favicons/apple-touch-icon-57x57.png"
+
+	// chatMaxFailingTests is the number of failing tests listed in
+	// a message.
+	chatMaxFailingTests = 10
+)
+
+// chatSummary is what chat notifiers say about a notification,
+// regardless of the message format of the chat service.
+type chatSummary struct {
+	Title     string
+	TitleLink string
+
+	// Text lists failing tests, if any.  Links are kept separate,
+	// because some services show them as buttons.
+	Text string
+
+	// LogsLink points to the operation the notification is about.
+	LogsLink string
+
+	Failed bool
+
+	// customText is true if the text has been rendered from the
+	// notifier's templates.
+	customText bool
+}
+
+// summarize describes notification for posting it to a chat.  Links
+// point to urlHost.
+func summarize(urlHost string, notification *Notification) (*chatSummary, error) {
+	summary := &chatSummary{
+		Title: strings.Replace(strings.Replace(notification.Activity.Name, ".", ": ", 1), "-", " ", 1),
+	}
+
+	if job := notification.Job; job != nil {
+		summary.TitleLink = fmt.Sprintf("https://%s/#/a/projects/%s/jobs/%s", urlHost, notification.Project.Uuid, job.Uuid)
+	}
+
+	operation := notification.Operation()
+	if operation != nil {
+		summary.LogsLink = fmt.Sprintf("https://%s/#/a/operations/%s", urlHost, operation.Uuid)
+	}
+
+	switch notification.Activity.Name {
+	case "operation.succeeded":
+		summary.Title = fmt.Sprintf("%s has finished successfully", jobName(notification))
+	case "operation.failed":
+		summary.Title = fmt.Sprintf("%s has failed", jobName(notification))
+		summary.Failed = true
+		summary.Text = failingTests(operation)
+	}
+
+	rendered, err := notification.Render()
+	if err != nil {
+		return nil, err
+	}
+	if rendered != nil {
+		summary.Customize(rendered)
+	}
+
+	return summary, nil
+}
+
+// Customize replaces the title and text of the summary with those
+// rendered from the notifier's templates, unless they are empty.
+func (self *chatSummary) Customize(rendered *domain.RenderedNotification) {
+	if rendered.Subject != "" {
+		self.Title = rendered.Subject
+	}
+	if rendered.Body != "" {
+		self.Text = rendered.Body
+		self.customText = true
+	}
+}
+
+// TextWithLogsLink returns the text followed by a sentence pointing to
+// the operation's logs, for services that cannot show links
+// separately.  Customized texts are returned unchanged.
+func (self *chatSummary) TextWithLogsLink() string {
+	if self.customText || self.LogsLink == "" {
+		return self.Text
+	}
+
+	return self.Text + "You can view the logs here: " + self.LogsLink
+}
+
+func jobName(notification *Notification) string {
+	if notification.Job == nil {
+		return notification.Project.Name
+	}
+
+	return notification.Job.Name
+}
+
+// failingTests lists the tests that failed in operation.
+func failingTests(operation *domain.Operation) string {
+	if operation == nil || operation.TestResults == nil {
+		return ""
+	}
+
+	failed := operation.TestResults.FailedNames()
+	if len(failed) == 0 {
+		return ""
+	}
+
+	count := len(failed)
+	if len(failed) > chatMaxFailingTests {
+		failed = failed[:chatMaxFailingTests]
+	}
+
+	return fmt.Sprintf("%d failing tests:\n• %s\n\n", count, strings.Join(failed, "\n• "))
+}
+
+// post sends body to url and treats every response but 2xx as an
+// error.
+func post(client *http.Client, url, contentType string, body []byte) error {
+	response, err := client.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("POST %s: %s", url, response.Status)
+	}
+
+	return nil
+}
@@ -26,3 +26,34 @@ func (self *DbNotificationRules) FindByProjectUuid(projectUuid string) ([]*domai
 	store := stores.NewDbNotificationRuleStore(tx)
 	return store.FindByProjectUuid(projectUuid)
 }
+
+type DbOperationHistory struct {
+	db *sqlx.DB
+}
+
+func NewDbOperationHistory(db *sqlx.DB) *DbOperationHistory {
+	return &DbOperationHistory{
+		db: db,
+	}
+}
+
+func (self *DbOperationHistory) PreviousOperation(operation *domain.Operation) (*domain.Operation, int, error) {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	operations := stores.NewDbOperationStore(tx)
+	previous, err := operations.FindPreviousConcludedOperation(operation.Uuid)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	failuresInARow, err := operations.CountFailuresInARow(operation.Uuid)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return previous, failuresInARow, nil
+}
@@ -0,0 +1,250 @@
+package notifier
+
+import (
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbNotifications struct {
+	db   *sqlx.DB
+	logs domain.OperationLogs
+}
+
+func NewDbNotifications(db *sqlx.DB, logs domain.OperationLogs) *DbNotifications {
+	return &DbNotifications{
+		db:   db,
+		logs: logs,
+	}
+}
+
+func (self *DbNotifications) Load(rule *domain.NotificationRule, activity *domain.Activity) (*Notification, error) {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	notifier, err := stores.NewDbNotifierStore(tx).FindByUuidAndType(rule.NotifierUuid, rule.NotifierType)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := stores.NewDbProjectStore(tx).FindByUuid(rule.ProjectUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Notification{
+		Rule:     rule,
+		Activity: activity,
+		Notifier: notifier,
+		Project:  project,
+	}
+
+	if jobUuid := activity.JobUuid(); jobUuid != "" {
+		job, err := stores.NewDbJobStore(tx).FindByUuid(jobUuid)
+		if err != nil {
+			return nil, err
+		}
+		result.Job = job
+	}
+
+	if notifier, ok := notifier.(*domain.EmailNotifier); ok {
+		recipient, err := stores.NewDbUserStore(tx, nil).FindByEmailAddress(notifier.Recipient)
+		if err != nil && !domain.IsNotFound(err) {
+			return nil, err
+		}
+
+		if recipient != nil {
+			preferences, err := stores.NewDbNotificationPreferencesStore(tx).FindByUserUuid(recipient.Uuid)
+			if err != nil {
+				return nil, err
+			}
+			result.Recipient = recipient
+			result.Preferences = preferences
+		}
+	}
+
+	if _, ok := notifier.(*domain.BlameNotifier); ok && result.Operation() != nil {
+		blame, err := self.loadBlame(tx, project, result.Operation())
+		if err != nil {
+			return nil, err
+		}
+		result.Blame = blame
+	}
+
+	if notifier, ok := notifier.(templated); ok && !notifier.NotificationTemplate().IsEmpty() {
+		result.Template = domain.NewNotificationTemplateContext(project, result.Job, result.Operation())
+		result.Template.SetActivity(activity)
+		if err := result.Template.LoadDetails(stores.NewDbEnvironmentStore(tx), stores.NewDbUserStore(tx, nil), self.logs); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// loadBlame returns the blame for operation, leaving out the authors
+// who muted project.  It returns nil unless operation broke its job.
+func (self *DbNotifications) loadBlame(tx *sqlx.Tx, project *domain.Project, operation *domain.Operation) (*domain.Blame, error) {
+	operations, err := stores.NewDbOperationStore(tx).FindAllSinceLastSuccess(operation.Uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	blame := domain.NewBlame(operations)
+	if blame == nil {
+		return nil, nil
+	}
+
+	if err := blame.MatchUsers(stores.NewDbUserStore(tx, nil)); err != nil {
+		return nil, err
+	}
+
+	preferences := stores.NewDbNotificationPreferencesStore(tx)
+	authors := []*domain.BlamedAuthor{}
+	for _, author := range blame.Authors {
+		if author.User != nil {
+			userPreferences, err := preferences.FindByUserUuid(author.User.Uuid)
+			if err != nil {
+				return nil, err
+			}
+			if userPreferences.IsMuted(project.Uuid) {
+				continue
+			}
+		}
+		authors = append(authors, author)
+	}
+	blame.Authors = authors
+
+	return blame, nil
+}
+
+type DbDeliveryLog struct {
+	db *sqlx.DB
+}
+
+func NewDbDeliveryLog(db *sqlx.DB) *DbDeliveryLog {
+	return &DbDeliveryLog{
+		db: db,
+	}
+}
+
+func (self *DbDeliveryLog) Create(delivery *domain.NotificationDelivery) error {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := stores.NewDbNotificationDeliveryStore(tx).Create(delivery); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (self *DbDeliveryLog) Update(delivery *domain.NotificationDelivery) error {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := stores.NewDbNotificationDeliveryStore(tx).Update(delivery); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// claimLimit is the maximum number of deliveries claimed for retrying
+// at once.
+const claimLimit = 100
+
+func (self *DbDeliveryLog) ClaimDue(now time.Time, lease time.Duration) ([]*PendingDelivery, error) {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deliveries := stores.NewDbNotificationDeliveryStore(tx)
+	due, err := deliveries.ClaimAllDue(now, now.Add(lease), claimLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := stores.NewDbNotificationRuleStore(tx)
+	activities := stores.NewDbActivityStore(tx)
+	result := []*PendingDelivery{}
+	for _, delivery := range due {
+		pending, err := loadPendingDelivery(rules, activities, delivery)
+		if domain.IsNotFound(err) {
+			// The rule or the activity is gone, so there is
+			// nothing left to deliver.
+			delivery.RecordAttempt(err)
+			delivery.GiveUp()
+			if err := deliveries.Update(delivery); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, pending)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func loadPendingDelivery(rules *stores.DbNotificationRuleStore, activities *stores.DbActivityStore, delivery *domain.NotificationDelivery) (*PendingDelivery, error) {
+	rule, err := rules.FindByUuid(delivery.NotificationRuleUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := activities.FindActivityById(delivery.ActivityId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PendingDelivery{
+		Delivery: delivery,
+		Rule:     rule,
+		Activity: activity,
+	}, nil
+}
+
+type DbWebhookAttemptLog struct {
+	db *sqlx.DB
+}
+
+func NewDbWebhookAttemptLog(db *sqlx.DB) *DbWebhookAttemptLog {
+	return &DbWebhookAttemptLog{
+		db: db,
+	}
+}
+
+func (self *DbWebhookAttemptLog) Record(attempt *domain.WebhookNotifierAttempt) error {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := stores.NewDbWebhookNotifierStore(tx).CreateAttempt(attempt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,180 @@
+package notifier
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/jmoiron/sqlx"
+)
+
+// replayCheckInterval is how often dead letters are checked for
+// whether an administrator asked to replay them.
+const replayCheckInterval = time.Minute
+
+// DbDeadLetters keeps notifications and messages the notifier gave up
+// on in the database.
+type DbDeadLetters struct {
+	db *sqlx.DB
+}
+
+func NewDbDeadLetters(db *sqlx.DB) *DbDeadLetters {
+	return &DbDeadLetters{
+		db: db,
+	}
+}
+
+func (self *DbDeadLetters) Bury(rule *domain.NotificationRule, activity *domain.Activity, attempts int, cause error) error {
+	failed, err := domain.NewFailedDelivery(
+		domain.FailedDeliverySourceNotifier,
+		domain.FailedDeliveryKindNotification,
+		domain.NotificationKey(rule, activity),
+		&domain.DeferredNotification{
+			NotificationRuleUuid: rule.Uuid,
+			ActivityId:           activity.Id,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	failed.GiveUp(attempts, cause)
+
+	return self.create(failed)
+}
+
+// BuryMessage records that the bus message about the row identified
+// by id in table could not be handled.
+func (self *DbDeadLetters) BuryMessage(table, id string, cause error) error {
+	failed, err := domain.NewFailedDelivery(
+		domain.FailedDeliverySourceNotifier,
+		domain.FailedDeliveryKindMessage,
+		domain.MessageKey(table, id),
+		&domain.FailedMessage{Table: table, Id: id},
+	)
+	if err != nil {
+		return err
+	}
+	failed.GiveUp(1, cause)
+
+	return self.create(failed)
+}
+
+func (self *DbDeadLetters) create(failed *domain.FailedDelivery) error {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := stores.NewDbFailedDeliveryStore(tx).Create(failed); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deadLetterReplay hands a dead letter back to the notifier.
+type deadLetterReplay struct {
+	failedUuid string
+	replay     func() error
+}
+
+// Replay hands all dead letters an administrator asked to replay back
+// to worker.  Notifications are scheduled again, messages are handled
+// as if they just arrived.  This only happens once the dead letters
+// have been marked as replayed, so that a failed commit does not lead
+// to replaying them twice.
+func (self *DbDeadLetters) Replay(worker *Worker, scheduler Scheduler) error {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	failures := stores.NewDbFailedDeliveryStore(tx)
+	replaying, err := failures.FindAllReplaying(domain.FailedDeliverySourceNotifier)
+	if err != nil {
+		return err
+	}
+
+	rules := stores.NewDbNotificationRuleStore(tx)
+	activities := stores.NewDbActivityStore(tx)
+	replays := []*deadLetterReplay{}
+	for _, failed := range replaying {
+		failed.Replayed()
+		if err := failures.Update(failed); err != nil {
+			return err
+		}
+
+		switch failed.Kind {
+		case domain.FailedDeliveryKindNotification:
+			payload := domain.DeferredNotification{}
+			if err := json.Unmarshal(failed.Payload, &payload); err != nil {
+				log.Error().Msgf("replay %s: %s", failed.Uuid, err)
+				continue
+			}
+
+			rule, err := rules.FindByUuid(payload.NotificationRuleUuid)
+			if err != nil {
+				log.Error().Msgf("replay %s: rule %s: %s", failed.Uuid, payload.NotificationRuleUuid, err)
+				continue
+			}
+
+			activity, err := activities.FindActivityById(payload.ActivityId)
+			if err != nil {
+				log.Error().Msgf("replay %s: activity %d: %s", failed.Uuid, payload.ActivityId, err)
+				continue
+			}
+
+			replays = append(replays, &deadLetterReplay{
+				failedUuid: failed.Uuid,
+				replay: func() error {
+					return scheduler.ScheduleNotification(rule, activity)
+				},
+			})
+		case domain.FailedDeliveryKindMessage:
+			payload := domain.FailedMessage{}
+			if err := json.Unmarshal(failed.Payload, &payload); err != nil {
+				log.Error().Msgf("replay %s: %s", failed.Uuid, err)
+				continue
+			}
+
+			activity, err := findActivity(tx, payload.Table, payload.Id)
+			if err != nil {
+				log.Error().Msgf("replay %s: %s", failed.Uuid, err)
+				continue
+			}
+
+			replays = append(replays, &deadLetterReplay{
+				failedUuid: failed.Uuid,
+				replay: func() error {
+					return worker.HandleActivity(activity)
+				},
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, replay := range replays {
+		if err := replay.replay(); err != nil {
+			log.Error().Msgf("replay %s: %s", replay.failedUuid, err)
+		}
+	}
+
+	return nil
+}
+
+// replayDeadLetters replays dead letters every replayCheckInterval.
+func replayDeadLetters(deadLetters *DbDeadLetters, worker *Worker, scheduler Scheduler) {
+	for {
+		if err := deadLetters.Replay(worker, scheduler); err != nil {
+			log.Error().Msgf("replay dead letters: %s", err)
+		}
+
+		time.Sleep(replayCheckInterval)
+	}
+}
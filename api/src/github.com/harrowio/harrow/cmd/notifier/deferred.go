@@ -0,0 +1,113 @@
+package notifier
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/jmoiron/sqlx"
+)
+
+// deferredCheckInterval is how often deferred notifications are
+// checked for whether they are due.
+const deferredCheckInterval = time.Minute
+
+// DbDeferrals stores notifications held back during quiet hours in
+// the database.
+type DbDeferrals struct {
+	db *sqlx.DB
+}
+
+func NewDbDeferrals(db *sqlx.DB) *DbDeferrals {
+	return &DbDeferrals{
+		db: db,
+	}
+}
+
+func (self *DbDeferrals) Defer(userUuid string, rule *domain.NotificationRule, activity *domain.Activity, until time.Time) error {
+	payload, err := json.Marshal(&domain.DeferredNotification{
+		NotificationRuleUuid: rule.Uuid,
+		ActivityId:           activity.Id,
+	})
+	if err != nil {
+		return err
+	}
+
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	delivery := domain.NewDeferredDelivery(userUuid, domain.DeferredDeliveryNotification, payload, until)
+	if err := stores.NewDbDeferredDeliveryStore(tx).Create(delivery); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Release schedules all notifications that are due at now with
+// scheduler and marks them as delivered.
+func (self *DbDeferrals) Release(scheduler Scheduler, now time.Time) error {
+	tx, err := self.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deferrals := stores.NewDbDeferredDeliveryStore(tx)
+	due, err := deferrals.FindAllDue(domain.DeferredDeliveryNotification, now)
+	if err != nil {
+		return err
+	}
+
+	rules := stores.NewDbNotificationRuleStore(tx)
+	activities := stores.NewDbActivityStore(tx)
+	for _, deferred := range due {
+		if err := deferrals.MarkDelivered(deferred.Uuid, now); err != nil {
+			return err
+		}
+
+		payload := domain.DeferredNotification{}
+		if err := json.Unmarshal(deferred.Payload, &payload); err != nil {
+			log.Error().Msgf("deferred %s: %s", deferred.Uuid, err)
+			continue
+		}
+
+		rule, err := rules.FindByUuid(payload.NotificationRuleUuid)
+		if err != nil {
+			if domain.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		activity, err := activities.FindActivityById(payload.ActivityId)
+		if err != nil {
+			if domain.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		if err := scheduler.ScheduleNotification(rule, activity); err != nil {
+			log.Error().Msgf("deferred %s: %s", deferred.Uuid, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// releaseDeferred releases due notifications every
+// deferredCheckInterval.
+func releaseDeferred(deferrals *DbDeferrals, scheduler Scheduler) {
+	for {
+		if err := deferrals.Release(scheduler, time.Now()); err != nil {
+			log.Error().Msgf("release deferred notifications: %s", err)
+		}
+
+		time.Sleep(deferredCheckInterval)
+	}
+}
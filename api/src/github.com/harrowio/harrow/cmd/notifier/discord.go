@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+const (
+	discordColorGood   = 0x2EB886
+	discordColorDanger = 0xD50200
+
+	// Discord rejects embeds with longer titles and descriptions.
+	discordMaxTitle       = 256
+	discordMaxDescription = 4096
+)
+
+type discordMessage struct {
+	Username  string         `json:"username"`
+	AvatarURL string         `json:"avatar_url"`
+	Embeds    []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Color       int    `json:"color"`
+}
+
+// DiscordNotifier posts messages to the webhook of a Discord channel.
+type DiscordNotifier struct {
+	client *http.Client
+}
+
+func NewDiscordNotifier(client *http.Client) *DiscordNotifier {
+	return &DiscordNotifier{
+		client: client,
+	}
+}
+
+func (self *DiscordNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.DiscordNotifier)
+	if !ok {
+		return fmt.Errorf("DiscordNotifier: unexpected notifier type %T", notification.Notifier)
+	}
+
+	summary, err := summarize(notifier.UrlHost, notification)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(newDiscordMessage(summary))
+	if err != nil {
+		return err
+	}
+
+	return post(self.client, notifier.WebhookURL, "application/json", body)
+}
+
+// newDiscordMessage builds the message sent to Discord for summary as
+// a single embed.
+func newDiscordMessage(summary *chatSummary) *discordMessage {
+	embed := discordEmbed{
+		Title:       truncate(summary.Title, discordMaxTitle),
+		URL:         summary.TitleLink,
+		Description: truncate(summary.TextWithLogsLink(), discordMaxDescription),
+		Color:       discordColorGood,
+	}
+
+	if summary.Failed {
+		embed.Color = discordColorDanger
+	}
+
+	return &discordMessage{
+		Username:  chatUsername,
+		AvatarURL: chatAuthorIcon,
+		Embeds:    []discordEmbed{embed},
+	}
+}
+
+// truncate shortens s to at most max characters, marking it as
+// shortened with an ellipsis.
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+
+	return string(runes[:max-1]) + "…"
+}
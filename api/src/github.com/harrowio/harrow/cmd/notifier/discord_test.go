@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+func TestDiscordNotifier_Notify_postsEmbedAboutFailedOperation(t *testing.T) {
+	received := &discordMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	operation := &domain.Operation{Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7"}
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.failed", Payload: operation},
+		Notifier: &domain.DiscordNotifier{ChatWebhookNotifier: domain.ChatWebhookNotifier{
+			ProjectNotifierBase: domain.ProjectNotifierBase{UrlHost: "www.app.harrow.io"},
+			WebhookURL:          server.URL,
+		}},
+		Project: &domain.Project{Uuid: "a1e7b1f5-7a4b-4a6e-9c43-2d6a1b7c2e3f"},
+		Job:     &domain.Job{Uuid: "f6d5b2a7-3c1e-4b8a-9e2d-5a4c3b2a1f0e", Name: "deploy"},
+	}
+
+	if err := NewDiscordNotifier(server.Client()).Notify(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(received.Embeds), 1; got != want {
+		t.Fatalf("len(received.Embeds) = %d; want %d", got, want)
+	}
+
+	embed := received.Embeds[0]
+	if got, want := embed.Title, "deploy has failed"; got != want {
+		t.Errorf("embed.Title = %q; want %q", got, want)
+	}
+
+	if got, want := embed.Color, discordColorDanger; got != want {
+		t.Errorf("embed.Color = %#x; want %#x", got, want)
+	}
+
+	if !strings.HasSuffix(embed.Description, "/#/a/operations/"+operation.Uuid) {
+		t.Errorf("embed.Description = %q; want link to operation", embed.Description)
+	}
+}
+
+func TestTruncate_shortensLongTextsWithEllipsis(t *testing.T) {
+	if got, want := truncate("deploy has failed", 6), "deplo…"; got != want {
+		t.Errorf("truncate(...) = %q; want %q", got, want)
+	}
+
+	if got, want := truncate("deploy", 6), "deploy"; got != want {
+		t.Errorf("truncate(...) = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,309 @@
+package notifier
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+type NotificationLoader interface {
+	Load(rule *domain.NotificationRule, activity *domain.Activity) (*Notification, error)
+}
+
+type DeliveryLog interface {
+	Create(delivery *domain.NotificationDelivery) error
+	Update(delivery *domain.NotificationDelivery) error
+
+	// ClaimDue returns the deliveries whose next attempt is due at
+	// now and postpones their next attempt by lease, so that they
+	// are not claimed again while being attempted.
+	ClaimDue(now time.Time, lease time.Duration) ([]*PendingDelivery, error)
+}
+
+// PendingDelivery is a delivery waiting for another attempt, together
+// with what is needed to make the attempt.
+type PendingDelivery struct {
+	Delivery *domain.NotificationDelivery
+	Rule     *domain.NotificationRule
+	Activity *domain.Activity
+}
+
+// DeadLetters keeps notifications that could not be delivered, so
+// that they can be inspected and replayed later.
+type DeadLetters interface {
+	Bury(rule *domain.NotificationRule, activity *domain.Activity, attempts int, err error) error
+}
+
+// Deferrals holds notifications back until the quiet hours of their
+// recipient are over.
+type Deferrals interface {
+	Defer(userUuid string, rule *domain.NotificationRule, activity *domain.Activity, until time.Time) error
+}
+
+const (
+	// DefaultAttempts is how often sending a notification is tried
+	// before giving up.
+	DefaultAttempts = 5
+
+	// DefaultBackoff is how long to wait before trying again after
+	// the first failed attempt.  The wait doubles after every
+	// further failed attempt.
+	DefaultBackoff = 5 * time.Second
+
+	// DefaultConcurrency is the number of notifications sent at the
+	// same time.
+	DefaultConcurrency = 8
+
+	// DefaultLease is how long an attempt may take before the
+	// delivery is attempted again, e.g. because the notifier
+	// stopped while sending it.
+	DefaultLease = 5 * time.Minute
+
+	// retryCheckInterval is how often deliveries are checked for
+	// whether their next attempt is due.
+	retryCheckInterval = 5 * time.Second
+)
+
+// Dispatcher sends notifications from within the notifier process,
+// using the Notifier registered for the type of notifier a rule
+// refers to.  Every notification is recorded in the delivery log,
+// which also keeps track of the deliveries to retry, so that retries
+// survive restarts.
+type Dispatcher struct {
+	notifications NotificationLoader
+	deliveries    DeliveryLog
+	deferrals     Deferrals
+	deadLetters   DeadLetters
+	notifiers     map[string]Notifier
+
+	Attempts int
+	Backoff  time.Duration
+	Lease    time.Duration
+
+	slots    chan struct{}
+	inFlight sync.WaitGroup
+}
+
+func NewDispatcher(notifications NotificationLoader, deliveries DeliveryLog) *Dispatcher {
+	return &Dispatcher{
+		notifications: notifications,
+		deliveries:    deliveries,
+		notifiers:     map[string]Notifier{},
+		Attempts:      DefaultAttempts,
+		Backoff:       DefaultBackoff,
+		Lease:         DefaultLease,
+		slots:         make(chan struct{}, DefaultConcurrency),
+	}
+}
+
+// Register makes notifier responsible for all notifiers of
+// notifierType, e.g. "slack_notifiers".
+func (self *Dispatcher) Register(notifierType string, notifier Notifier) *Dispatcher {
+	self.notifiers[notifierType] = notifier
+	return self
+}
+
+// DeferTo makes the dispatcher hold notifications back in deferrals
+// while their recipient has quiet hours.  Without deferrals, quiet
+// hours are ignored.
+func (self *Dispatcher) DeferTo(deferrals Deferrals) *Dispatcher {
+	self.deferrals = deferrals
+	return self
+}
+
+// DeadLetterTo makes the dispatcher keep notifications it gives up on
+// in deadLetters.
+func (self *Dispatcher) DeadLetterTo(deadLetters DeadLetters) *Dispatcher {
+	self.deadLetters = deadLetters
+	return self
+}
+
+// ScheduleNotification sends the notification in the background.  It
+// blocks while the maximum number of notifications is being sent.
+func (self *Dispatcher) ScheduleNotification(rule *domain.NotificationRule, activity *domain.Activity) error {
+	notifier, found := self.notifiers[rule.NotifierType]
+	if !found {
+		err := fmt.Errorf("no notifier registered for %q", rule.NotifierType)
+		self.bury(rule, activity, 0, err)
+		return err
+	}
+
+	self.inBackground(rule, activity, func() error {
+		return self.Deliver(notifier, rule, activity)
+	})
+
+	return nil
+}
+
+// Retry makes another attempt in the background for every delivery
+// that is due for one at now.
+func (self *Dispatcher) Retry(now time.Time) error {
+	pending, err := self.deliveries.ClaimDue(now, self.Lease)
+	if err != nil {
+		return err
+	}
+
+	for _, retry := range pending {
+		retry := retry
+		notifier, found := self.notifiers[retry.Delivery.NotifierType]
+		if !found {
+			err := fmt.Errorf("no notifier registered for %q", retry.Delivery.NotifierType)
+			retry.Delivery.RecordAttempt(err)
+			self.giveUp(retry.Delivery, retry.Rule, retry.Activity, err)
+			continue
+		}
+
+		self.inBackground(retry.Rule, retry.Activity, func() error {
+			return self.attempt(notifier, retry.Delivery, retry.Rule, retry.Activity)
+		})
+	}
+
+	return nil
+}
+
+func (self *Dispatcher) inBackground(rule *domain.NotificationRule, activity *domain.Activity, deliver func() error) {
+	self.slots <- struct{}{}
+	self.inFlight.Add(1)
+	go func() {
+		defer func() {
+			<-self.slots
+			self.inFlight.Done()
+		}()
+
+		if err := deliver(); err != nil {
+			log.Error().Msgf("deliver: rule=%s activity=%s@%d: %s", rule.Uuid, activity.Name, activity.Id, err)
+		}
+	}()
+}
+
+// Wait blocks until all scheduled notifications have been sent or
+// given up on.
+func (self *Dispatcher) Wait() {
+	self.inFlight.Wait()
+}
+
+// Deliver records a new delivery of the notification for activity and
+// makes the first attempt to send it through notifier.  Failed
+// attempts are retried by Retry with exponential backoff, until the
+// attempts are used up.  Deliver only returns an error if it gives up.
+func (self *Dispatcher) Deliver(notifier Notifier, rule *domain.NotificationRule, activity *domain.Activity) error {
+	delivery := domain.NewNotificationDelivery(rule, activity)
+	// The delivery is picked up again if the notifier stops before
+	// the attempt has been recorded.
+	delivery.RetryIn(self.Lease)
+	if err := self.deliveries.Create(delivery); err != nil {
+		return err
+	}
+
+	return self.attempt(notifier, delivery, rule, activity)
+}
+
+// attempt tries to send the notification recorded by delivery once.
+func (self *Dispatcher) attempt(notifier Notifier, delivery *domain.NotificationDelivery, rule *domain.NotificationRule, activity *domain.Activity) error {
+	notification, err := self.notifications.Load(rule, activity)
+	if err != nil {
+		delivery.RecordAttempt(err)
+		return self.giveUp(delivery, rule, activity, err)
+	}
+	notification.Delivery = delivery
+
+	notifier, held, err := self.applyPreferences(notifier, notification)
+	if held || err != nil {
+		self.record(delivery)
+		return err
+	}
+
+	err = notifier.Notify(notification)
+	delivery.RecordAttempt(err)
+	if err == nil {
+		self.record(delivery)
+		return nil
+	}
+
+	if delivery.Attempts >= self.Attempts || isPermanent(err) {
+		return self.giveUp(delivery, rule, activity, err)
+	}
+
+	delivery.RetryIn(self.Backoff << uint(delivery.Attempts-1))
+	self.record(delivery)
+	return nil
+}
+
+func (self *Dispatcher) giveUp(delivery *domain.NotificationDelivery, rule *domain.NotificationRule, activity *domain.Activity, err error) error {
+	delivery.GiveUp()
+	self.record(delivery)
+	self.bury(rule, activity, delivery.Attempts, err)
+	return err
+}
+
+// applyPreferences respects the notification preferences of the user
+// a notification is addressed to.  It returns true if the notification
+// must not be sent now, and otherwise the notifier to send it
+// through, which is the user's personal Slack webhook if they prefer
+// Slack.
+func (self *Dispatcher) applyPreferences(notifier Notifier, notification *Notification) (Notifier, bool, error) {
+	preferences := notification.Preferences
+	if preferences == nil {
+		return notifier, false, nil
+	}
+
+	if notification.Project != nil && preferences.IsMuted(notification.Project.Uuid) {
+		notification.Delivery.Mute()
+		return nil, true, nil
+	}
+
+	if until, quiet := preferences.QuietUntil(domain.Clock.Now()); quiet && self.deferrals != nil {
+		if err := self.deferrals.Defer(preferences.UserUuid, notification.Rule, notification.Activity, until); err != nil {
+			return nil, true, err
+		}
+		notification.Delivery.Defer()
+		return nil, true, nil
+	}
+
+	slack, found := self.notifiers["slack_notifiers"]
+	if preferences.Channel == domain.NotificationChannelSlack && found {
+		personal := &domain.SlackNotifier{
+			Name:       "personal",
+			WebhookURL: preferences.SlackWebhookURL,
+		}
+		if notification.Recipient != nil {
+			personal.UrlHost = notification.Recipient.UrlHost
+		}
+		if notification.Project != nil {
+			personal.ProjectUuid = notification.Project.Uuid
+		}
+		notification.Notifier = personal
+		return slack, false, nil
+	}
+
+	return notifier, false, nil
+}
+
+func (self *Dispatcher) bury(rule *domain.NotificationRule, activity *domain.Activity, attempts int, err error) {
+	if self.deadLetters == nil {
+		return
+	}
+
+	if err := self.deadLetters.Bury(rule, activity, attempts, err); err != nil {
+		log.Error().Msgf("bury rule=%s activity=%s@%d: %s", rule.Uuid, activity.Name, activity.Id, err)
+	}
+}
+
+func (self *Dispatcher) record(delivery *domain.NotificationDelivery) {
+	if err := self.deliveries.Update(delivery); err != nil {
+		log.Error().Msgf("record delivery %s: %s", delivery.Uuid, err)
+	}
+}
+
+// retryDeliveries retries due deliveries every retryCheckInterval.
+func retryDeliveries(dispatcher *Dispatcher) {
+	for {
+		if err := dispatcher.Retry(time.Now()); err != nil {
+			log.Error().Msgf("retry deliveries: %s", err)
+		}
+
+		time.Sleep(retryCheckInterval)
+	}
+}
@@ -0,0 +1,343 @@
+package notifier
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/clock"
+	"github.com/harrowio/harrow/domain"
+)
+
+type mockNotifications struct{}
+
+func (self *mockNotifications) Load(rule *domain.NotificationRule, activity *domain.Activity) (*Notification, error) {
+	return &Notification{Rule: rule, Activity: activity}, nil
+}
+
+type mockDeliveryLog struct {
+	recorded []domain.NotificationDelivery
+	current  map[string]*domain.NotificationDelivery
+}
+
+func (self *mockDeliveryLog) Create(delivery *domain.NotificationDelivery) error {
+	if self.current == nil {
+		self.current = map[string]*domain.NotificationDelivery{}
+	}
+	self.current[delivery.Uuid] = delivery
+	self.recorded = append(self.recorded, *delivery)
+	return nil
+}
+
+func (self *mockDeliveryLog) Update(delivery *domain.NotificationDelivery) error {
+	self.recorded = append(self.recorded, *delivery)
+	return nil
+}
+
+func (self *mockDeliveryLog) ClaimDue(now time.Time, lease time.Duration) ([]*PendingDelivery, error) {
+	result := []*PendingDelivery{}
+	for _, delivery := range self.current {
+		if delivery.Status != domain.NotificationDeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+
+		delivery.RetryIn(lease)
+		result = append(result, &PendingDelivery{
+			Delivery: delivery,
+			Rule:     &domain.NotificationRule{Uuid: delivery.NotificationRuleUuid, NotifierType: delivery.NotifierType},
+			Activity: &domain.Activity{Id: delivery.ActivityId},
+		})
+	}
+
+	return result, nil
+}
+
+func (self *mockDeliveryLog) last() domain.NotificationDelivery {
+	return self.recorded[len(self.recorded)-1]
+}
+
+type failingNotifier struct {
+	failures int
+	calls    int
+	err      error
+}
+
+func (self *failingNotifier) Notify(notification *Notification) error {
+	self.calls++
+	if self.calls <= self.failures {
+		if self.err != nil {
+			return self.err
+		}
+		return errors.New("connection refused")
+	}
+
+	return nil
+}
+
+func newTestDispatcher(log *mockDeliveryLog, notifier Notifier) *Dispatcher {
+	return NewDispatcher(&mockNotifications{}, log).Register("test_notifiers", notifier)
+}
+
+var testRule = &domain.NotificationRule{NotifierType: "test_notifiers"}
+
+// retryUntilDone retries the deliveries in log as if the time of their
+// next attempt had come, until none is left.
+func retryUntilDone(t *testing.T, dispatcher *Dispatcher, log *mockDeliveryLog) {
+	for i := 0; i < DefaultAttempts; i++ {
+		if err := dispatcher.Retry(domain.Clock.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		dispatcher.Wait()
+	}
+}
+
+func TestDispatcher_Deliver_retriesWithExponentialBackoff(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	domain.Clock = clock.At(now)
+	defer func() { domain.Clock = clock.System }()
+
+	log := &mockDeliveryLog{}
+	notifier := &failingNotifier{failures: 2}
+	dispatcher := newTestDispatcher(log, notifier)
+
+	if err := dispatcher.Deliver(notifier, testRule, &domain.Activity{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	retryUntilDone(t, dispatcher, log)
+
+	waited := []time.Duration{}
+	for _, delivery := range log.recorded[1:] {
+		if delivery.NextAttemptAt != nil {
+			waited = append(waited, delivery.NextAttemptAt.Sub(now))
+		}
+	}
+
+	if got, want := waited, []time.Duration{DefaultBackoff, 2 * DefaultBackoff}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("waited = %v; want %v", got, want)
+	}
+
+	delivery := log.last()
+	if got, want := delivery.Status, domain.NotificationDeliveryDelivered; got != want {
+		t.Errorf("delivery.Status = %q; want %q", got, want)
+	}
+
+	if got, want := delivery.Attempts, 3; got != want {
+		t.Errorf("delivery.Attempts = %d; want %d", got, want)
+	}
+}
+
+func TestDispatcher_Retry_resumesDeliveriesRecordedBeforeRestart(t *testing.T) {
+	log := &mockDeliveryLog{}
+	notifier := &failingNotifier{}
+	dispatcher := newTestDispatcher(log, notifier)
+
+	next := domain.Clock.Now().Add(-time.Second)
+	log.Create(&domain.NotificationDelivery{
+		Uuid:          "6f1c2b3a-4d5e-4f60-8a9b-0c1d2e3f4a5b",
+		NotifierType:  "test_notifiers",
+		ActivityId:    1,
+		Status:        domain.NotificationDeliveryPending,
+		Attempts:      1,
+		NextAttemptAt: &next,
+	})
+
+	if err := dispatcher.Retry(domain.Clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	dispatcher.Wait()
+
+	if got, want := notifier.calls, 1; got != want {
+		t.Errorf("notifier.calls = %d; want %d", got, want)
+	}
+
+	if got, want := log.last().Status, domain.NotificationDeliveryDelivered; got != want {
+		t.Errorf("delivery.Status = %q; want %q", got, want)
+	}
+}
+
+func TestDispatcher_Deliver_givesUpAfterAllAttemptsFailed(t *testing.T) {
+	log := &mockDeliveryLog{}
+	notifier := &failingNotifier{failures: DefaultAttempts}
+	dispatcher := newTestDispatcher(log, notifier)
+
+	if err := dispatcher.Deliver(notifier, testRule, &domain.Activity{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	retryUntilDone(t, dispatcher, log)
+
+	if got, want := notifier.calls, DefaultAttempts; got != want {
+		t.Errorf("notifier.calls = %d; want %d", got, want)
+	}
+
+	delivery := log.last()
+	if got, want := delivery.Status, domain.NotificationDeliveryFailed; got != want {
+		t.Errorf("delivery.Status = %q; want %q", got, want)
+	}
+
+	if got, want := delivery.LastError, "connection refused"; got != want {
+		t.Errorf("delivery.LastError = %q; want %q", got, want)
+	}
+}
+
+func TestDispatcher_Deliver_givesUpOnPermanentErrors(t *testing.T) {
+	log := &mockDeliveryLog{}
+	notifier := &failingNotifier{failures: 1, err: permanent(errors.New("no mail template"))}
+	dispatcher := newTestDispatcher(log, notifier)
+
+	if err := dispatcher.Deliver(notifier, testRule, &domain.Activity{Id: 1}); err == nil {
+		t.Fatalf("expected an error")
+	}
+	retryUntilDone(t, dispatcher, log)
+
+	if got, want := notifier.calls, 1; got != want {
+		t.Errorf("notifier.calls = %d; want %d", got, want)
+	}
+
+	if got, want := log.last().Status, domain.NotificationDeliveryFailed; got != want {
+		t.Errorf("delivery.Status = %q; want %q", got, want)
+	}
+}
+
+type mockDeferrals struct {
+	until []time.Time
+}
+
+func (self *mockDeferrals) Defer(userUuid string, rule *domain.NotificationRule, activity *domain.Activity, until time.Time) error {
+	self.until = append(self.until, until)
+	return nil
+}
+
+type preferringNotifications struct {
+	preferences *domain.NotificationPreferences
+}
+
+func (self *preferringNotifications) Load(rule *domain.NotificationRule, activity *domain.Activity) (*Notification, error) {
+	return &Notification{
+		Rule:        rule,
+		Activity:    activity,
+		Project:     &domain.Project{Uuid: "0d5bf4c8-0a27-4d26-a2ba-d2eb6d1aab7f"},
+		Recipient:   &domain.User{Uuid: self.preferences.UserUuid},
+		Preferences: self.preferences,
+	}, nil
+}
+
+func TestDispatcher_Deliver_doesNotSendNotificationsAboutMutedProjects(t *testing.T) {
+	log := &mockDeliveryLog{}
+	preferences := domain.NewNotificationPreferences("5e0b4e3f-6a53-4b61-9ad6-1f3c8b3a4f0e")
+	preferences.MutedProjectUuids = []string{"0d5bf4c8-0a27-4d26-a2ba-d2eb6d1aab7f"}
+	notifier := &failingNotifier{}
+	dispatcher := NewDispatcher(&preferringNotifications{preferences}, log)
+
+	if err := dispatcher.Deliver(notifier, &domain.NotificationRule{}, &domain.Activity{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := notifier.calls, 0; got != want {
+		t.Errorf("notifier.calls = %d; want %d", got, want)
+	}
+
+	if got, want := log.last().Status, domain.NotificationDeliveryMuted; got != want {
+		t.Errorf("delivery.Status = %q; want %q", got, want)
+	}
+}
+
+func TestDispatcher_Deliver_defersNotificationsDuringQuietHours(t *testing.T) {
+	log := &mockDeliveryLog{}
+	deferrals := &mockDeferrals{}
+	now := domain.Clock.Now().UTC()
+	preferences := domain.NewNotificationPreferences("5e0b4e3f-6a53-4b61-9ad6-1f3c8b3a4f0e")
+	preferences.QuietHoursStart = now.Add(-time.Hour).Format("15:04")
+	preferences.QuietHoursEnd = now.Add(time.Hour).Format("15:04")
+	notifier := &failingNotifier{}
+	dispatcher := NewDispatcher(&preferringNotifications{preferences}, log).DeferTo(deferrals)
+
+	if err := dispatcher.Deliver(notifier, &domain.NotificationRule{}, &domain.Activity{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := notifier.calls, 0; got != want {
+		t.Errorf("notifier.calls = %d; want %d", got, want)
+	}
+
+	if got, want := len(deferrals.until), 1; got != want {
+		t.Fatalf("len(deferrals.until) = %d; want %d", got, want)
+	}
+
+	if got, want := log.last().Status, domain.NotificationDeliveryDeferred; got != want {
+		t.Errorf("delivery.Status = %q; want %q", got, want)
+	}
+}
+
+func TestDispatcher_Deliver_sendsThroughPersonalSlackWebhook_ifUserPrefersSlack(t *testing.T) {
+	log := &mockDeliveryLog{}
+	preferences := domain.NewNotificationPreferences("5e0b4e3f-6a53-4b61-9ad6-1f3c8b3a4f0e")
+	preferences.Channel = domain.NotificationChannelSlack
+	preferences.SlackWebhookURL = "https://hooks.slack.com/services/T0/B0/x"
+	email := &failingNotifier{}
+	slack := &failingNotifier{}
+	dispatcher := NewDispatcher(&preferringNotifications{preferences}, log).
+		Register("email_notifiers", email).
+		Register("slack_notifiers", slack)
+
+	if err := dispatcher.Deliver(email, &domain.NotificationRule{}, &domain.Activity{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := email.calls, 0; got != want {
+		t.Errorf("email.calls = %d; want %d", got, want)
+	}
+
+	if got, want := slack.calls, 1; got != want {
+		t.Errorf("slack.calls = %d; want %d", got, want)
+	}
+}
+
+type mockDeadLetters struct {
+	buried []int
+}
+
+func (self *mockDeadLetters) Bury(rule *domain.NotificationRule, activity *domain.Activity, attempts int, err error) error {
+	self.buried = append(self.buried, attempts)
+	return nil
+}
+
+func TestDispatcher_Deliver_buriesNotificationAfterAllAttemptsFailed(t *testing.T) {
+	log := &mockDeliveryLog{}
+	deadLetters := &mockDeadLetters{}
+	notifier := &failingNotifier{failures: DefaultAttempts}
+	dispatcher := newTestDispatcher(log, notifier).DeadLetterTo(deadLetters)
+
+	if err := dispatcher.Deliver(notifier, testRule, &domain.Activity{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	retryUntilDone(t, dispatcher, log)
+
+	if got, want := deadLetters.buried, []int{DefaultAttempts}; len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("deadLetters.buried = %v; want %v", got, want)
+	}
+}
+
+func TestDispatcher_Deliver_recordsNextAttempt_whileRetrying(t *testing.T) {
+	log := &mockDeliveryLog{}
+	deadLetters := &mockDeadLetters{}
+	notifier := &failingNotifier{failures: 1}
+	dispatcher := newTestDispatcher(log, notifier).DeadLetterTo(deadLetters)
+
+	if err := dispatcher.Deliver(notifier, testRule, &domain.Activity{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	retryUntilDone(t, dispatcher, log)
+
+	retrying := log.recorded[len(log.recorded)-2]
+	if retrying.NextAttemptAt == nil {
+		t.Errorf("retrying.NextAttemptAt = nil; want a time")
+	}
+
+	if got := log.last().NextAttemptAt; got != nil {
+		t.Errorf("delivered.NextAttemptAt = %v; want nil", got)
+	}
+
+	if got, want := len(deadLetters.buried), 0; got != want {
+		t.Errorf("len(deadLetters.buried) = %d; want %d", got, want)
+	}
+}
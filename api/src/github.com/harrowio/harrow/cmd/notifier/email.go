@@ -0,0 +1,126 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+
+	harrowMail "github.com/harrowio/harrow/cmd/mail"
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/mohamedattahri/mail"
+)
+
+// EmailNotifier renders notification emails from the mail templates
+// and hands them to sendmail.
+type EmailNotifier struct {
+	templateDir    string
+	attachmentsDir string
+	send           func(message *mail.Message) error
+}
+
+func NewEmailNotifier(c config.MailConfig) *EmailNotifier {
+	return &EmailNotifier{
+		templateDir:    c.TemplateDir,
+		attachmentsDir: c.AttachmentsDir,
+		send:           sendmail,
+	}
+}
+
+func (self *EmailNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.EmailNotifier)
+	if !ok {
+		return permanent(fmt.Errorf("EmailNotifier: unexpected notifier type %T", notification.Notifier))
+	}
+
+	if notification.Job == nil {
+		return permanent(fmt.Errorf("EmailNotifier: activity %s@%d is not about a job", notification.Activity.Name, notification.Activity.Id))
+	}
+
+	ctxt := harrowMail.NewContext(fmt.Sprintf("notifications@%s", notifier.UrlHost))
+	ctxt.ToAddress = notifier.Recipient
+	ctxt.UrlHost = notifier.UrlHost
+	ctxt.Activity = notification.Activity
+	ctxt.Project = notification.Project
+	ctxt.Job = notification.Job
+	ctxt.Operation = notification.Operation()
+
+	mailCtxt, err := ctxt.ToMailContext()
+	if err != nil {
+		return err
+	}
+
+	rendered, err := notification.Render()
+	if err != nil {
+		return err
+	}
+	if rendered != nil && rendered.Subject != "" {
+		mailCtxt.Subject = rendered.Subject
+	}
+	if rendered != nil && rendered.Body != "" {
+		message, err := composeText(ctxt.FromAddress, ctxt.ToAddress, mailCtxt.Subject, rendered.Body)
+		if err != nil {
+			return err
+		}
+		return self.send(message)
+	}
+
+	templateDir, err := ctxt.MailTemplateDir(self.templateDir)
+	if os.IsNotExist(err) {
+		return permanent(fmt.Errorf("EmailNotifier: no mail template for %s", notification.Activity.Name))
+	}
+	if err != nil {
+		return err
+	}
+
+	attachments := (harrowMail.File)(nil)
+	if dir, err := os.Open(self.attachmentsDir); err == nil {
+		attachments = harrowMail.NewOSFile(dir)
+	}
+
+	composer, err := harrowMail.NewMail(ctxt.FromAddress, templateDir, attachments)
+	if err != nil {
+		return err
+	}
+
+	message, err := composer.Compose(mailCtxt, ctxt.ToAddress)
+	if err != nil {
+		return err
+	}
+
+	return self.send(message)
+}
+
+// composeText composes a plain text email, for bodies rendered from a
+// notifier's template instead of the mail templates.
+func composeText(from, to, subject, body string) (*mail.Message, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toAddr, err := mail.ParseAddress(to)
+	if err != nil {
+		return nil, err
+	}
+
+	message := mail.NewMessage()
+	message.SetSubject(subject)
+	message.SetFrom(fromAddr)
+	message.To().Add(toAddr)
+	multipart := mail.NewMultipart("multipart/alternative", message)
+	multipart.AddText("text/plain", bytes.NewBufferString(body))
+
+	return message, nil
+}
+
+func sendmail(message *mail.Message) error {
+	cmd := exec.Command("/usr/sbin/sendmail", "-t")
+	cmd.Stdin = bytes.NewReader(message.Bytes())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sendmail: %s: %s", err, output)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package notifier
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+// JobNotifier triggers another job through its webhook.
+type JobNotifier struct {
+	client *http.Client
+}
+
+func NewJobNotifier(client *http.Client) *JobNotifier {
+	return &JobNotifier{
+		client: client,
+	}
+}
+
+func (self *JobNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.JobNotifier)
+	if !ok {
+		return fmt.Errorf("JobNotifier: unexpected notifier type %T", notification.Notifier)
+	}
+
+	return post(self.client, notifier.WebhookURL, "text/plain", nil)
+}
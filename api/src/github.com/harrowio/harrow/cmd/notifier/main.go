@@ -1,14 +1,17 @@
 package notifier
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/harrowio/harrow/bus/broadcast"
 	"github.com/harrowio/harrow/config"
 	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/netguard"
 	"github.com/harrowio/harrow/stores"
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog"
@@ -28,9 +31,33 @@ func Main() {
 	bus := broadcast.NewAMQPTransport(c.AmqpConnectionString(), "notifier")
 	defer bus.Close()
 
+	logs, err := stores.NewArchivedLogStore(c)
+	if err != nil {
+		log.Fatal().Err(err)
+	}
+
+	// Notifiers send requests to URLs supplied by users, which must
+	// not reach hosts on internal networks.
+	client := netguard.NewClient(30 * time.Second)
 	rules := NewDbNotificationRules(db)
-	scheduler := NewDbScheduler(db)
-	worker := NewWorker(rules, scheduler)
+	deferrals := NewDbDeferrals(db)
+	deadLetters := NewDbDeadLetters(db)
+	dispatcher := NewDispatcher(NewDbNotifications(db, logs), NewDbDeliveryLog(db)).
+		DeferTo(deferrals).
+		DeadLetterTo(deadLetters).
+		Register("email_notifiers", NewEmailNotifier(c.MailConfig())).
+		Register("job_notifiers", NewJobNotifier(client)).
+		Register("slack_notifiers", NewSlackNotifier(client)).
+		Register("teams_notifiers", NewTeamsNotifier(client)).
+		Register("mattermost_notifiers", NewMattermostNotifier(client)).
+		Register("discord_notifiers", NewDiscordNotifier(client)).
+		Register("blame_notifiers", NewBlameNotifier()).
+		Register("webhook_notifiers", NewWebhookNotifier(client, NewDbWebhookAttemptLog(db)))
+	defer dispatcher.Wait()
+	go releaseDeferred(deferrals, dispatcher)
+	go retryDeliveries(dispatcher)
+	worker := NewWorker(rules, dispatcher, NewDbOperationHistory(db))
+	go replayDeadLetters(deadLetters, worker, dispatcher)
 	signals := make(chan os.Signal)
 	signal.Notify(signals, syscall.SIGKILL, syscall.SIGTERM)
 
@@ -42,7 +69,7 @@ func Main() {
 	for {
 		select {
 		case message := <-messages:
-			activity := activityForMessage(db, message)
+			activity := activityForMessage(db, deadLetters, message)
 			if activity == nil {
 				continue
 			}
@@ -53,29 +80,44 @@ func Main() {
 	}
 }
 
-func activityForMessage(db *sqlx.DB, message broadcast.Message) *domain.Activity {
+func activityForMessage(db *sqlx.DB, deadLetters *DbDeadLetters, message broadcast.Message) *domain.Activity {
 	if message.Table() != "activities" {
 		message.RejectForever()
 		return nil
 	}
 
-	activityId, err := strconv.Atoi(message.UUID())
-	if err != nil {
-		log.Error().Msgf("invalid activity id: %q: %s\n", message.UUID(), err)
-		message.RejectForever()
-		return nil
-	}
-
 	tx := db.MustBegin()
 	defer tx.Rollback()
-	activities := stores.NewDbActivityStore(tx)
 
-	activity, err := activities.FindActivityById(activityId)
+	activity, err := findActivity(tx, message.Table(), message.UUID())
 	if err != nil {
-		log.Error().Msgf("activity not found: id=%v\n", activityId)
+		log.Error().Msgf("activityForMessage: %s", err)
+		if err := deadLetters.BuryMessage(message.Table(), message.UUID(), err); err != nil {
+			log.Error().Msgf("bury message %s:%s: %s", message.Table(), message.UUID(), err)
+		}
 		message.RejectForever()
 		return nil
 	}
 	message.Acknowledge()
 	return activity
 }
+
+// findActivity loads the activity a bus message about the row
+// identified by id in table refers to.
+func findActivity(tx *sqlx.Tx, table, id string) (*domain.Activity, error) {
+	if table != "activities" {
+		return nil, fmt.Errorf("not an activity: %s:%s", table, id)
+	}
+
+	activityId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid activity id: %q: %s", id, err)
+	}
+
+	activity, err := stores.NewDbActivityStore(tx).FindActivityById(activityId)
+	if err != nil {
+		return nil, fmt.Errorf("activity %d: %s", activityId, err)
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+// MattermostNotifier posts messages to the incoming webhook of a
+// Mattermost channel.  Mattermost accepts Slack's message format.
+type MattermostNotifier struct {
+	client *http.Client
+}
+
+func NewMattermostNotifier(client *http.Client) *MattermostNotifier {
+	return &MattermostNotifier{
+		client: client,
+	}
+}
+
+func (self *MattermostNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.MattermostNotifier)
+	if !ok {
+		return fmt.Errorf("MattermostNotifier: unexpected notifier type %T", notification.Notifier)
+	}
+
+	summary, err := summarize(notifier.UrlHost, notification)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(newSlackMessage(summary))
+	if err != nil {
+		return err
+	}
+
+	return post(self.client, notifier.WebhookURL, "application/json", body)
+}
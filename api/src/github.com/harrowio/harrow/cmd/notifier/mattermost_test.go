@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+func TestMattermostNotifier_Notify_postsSlackCompatibleMessage(t *testing.T) {
+	received := &slackMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	operation := &domain.Operation{Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7"}
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.succeeded", Payload: operation},
+		Notifier: &domain.MattermostNotifier{ChatWebhookNotifier: domain.ChatWebhookNotifier{
+			ProjectNotifierBase: domain.ProjectNotifierBase{UrlHost: "www.app.harrow.io"},
+			WebhookURL:          server.URL,
+		}},
+		Project: &domain.Project{Uuid: "a1e7b1f5-7a4b-4a6e-9c43-2d6a1b7c2e3f"},
+		Job:     &domain.Job{Uuid: "f6d5b2a7-3c1e-4b8a-9e2d-5a4c3b2a1f0e", Name: "deploy"},
+	}
+
+	if err := NewMattermostNotifier(server.Client()).Notify(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(received.Attachments), 1; got != want {
+		t.Fatalf("len(received.Attachments) = %d; want %d", got, want)
+	}
+
+	attachment := received.Attachments[0]
+	if got, want := attachment.AuthorName, "deploy has finished successfully"; got != want {
+		t.Errorf("attachment.AuthorName = %q; want %q", got, want)
+	}
+
+	if got, want := attachment.Color, "good"; got != want {
+		t.Errorf("attachment.Color = %q; want %q", got, want)
+	}
+
+	if !strings.HasSuffix(attachment.Text, "/#/a/operations/"+operation.Uuid) {
+		t.Errorf("attachment.Text = %q; want link to operation", attachment.Text)
+	}
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import "github.com/harrowio/harrow/domain"
+
+// Notification is everything known about an activity matched by a
+// notification rule that a Notifier needs to tell somebody about it.
+type Notification struct {
+	Rule     *domain.NotificationRule
+	Activity *domain.Activity
+
+	// Notifier is the notifier referenced by Rule, e.g. a
+	// *domain.SlackNotifier.
+	Notifier interface{}
+
+	Project *domain.Project
+
+	// Job is nil if the activity isn't about a job.
+	Job *domain.Job
+
+	// Delivery records sending this notification.
+	Delivery *domain.NotificationDelivery
+
+	// Recipient is the user the notification is addressed to, if
+	// the notifier sends to a single Harrow user, and Preferences
+	// are the recipient's notification preferences.  Both are nil
+	// otherwise.
+	Recipient   *domain.User
+	Preferences *domain.NotificationPreferences
+
+	// Blame lists the commits and their authors if the notifier
+	// blames the authors of the commits that broke a job and the
+	// activity is about the operation that broke it.  It is nil
+	// otherwise.
+	Blame *domain.Blame
+
+	// Template is the data for rendering the notifier's message
+	// templates.  It is only loaded for notifiers that have
+	// templates.
+	Template *domain.NotificationTemplateContext
+}
+
+// templated is implemented by notifiers whose messages can be
+// customized with templates.
+type templated interface {
+	NotificationTemplate() *domain.NotificationTemplate
+}
+
+// Render renders the notifier's message templates.  It returns nil if
+// the notifier uses its default messages.
+func (self *Notification) Render() (*domain.RenderedNotification, error) {
+	notifier, ok := self.Notifier.(templated)
+	if !ok || self.Template == nil {
+		return nil, nil
+	}
+
+	template := notifier.NotificationTemplate()
+	if template.IsEmpty() {
+		return nil, nil
+	}
+
+	return template.Render(self.Template)
+}
+
+// Operation returns the operation the activity is about, or nil.
+func (self *Notification) Operation() *domain.Operation {
+	operation, _ := self.Activity.Payload.(*domain.Operation)
+	return operation
+}
+
+// Notifier sends notifications through a single type of notifier.
+type Notifier interface {
+	Notify(notification *Notification) error
+}
+
+// permanentError marks errors that trying again cannot fix, like a
+// notification for which there is nothing to send.
+type permanentError struct {
+	error
+}
+
+// permanent marks err as not worth retrying.
+func permanent(err error) error {
+	return &permanentError{err}
+}
+
+// isPermanent reports whether err has been marked by permanent.
+func isPermanent(err error) bool {
+	_, ok := err.(*permanentError)
+	return ok
+}
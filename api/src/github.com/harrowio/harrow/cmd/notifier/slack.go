@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+type slackMessage struct {
+	Username    string            `json:"username"`
+	IconUrl     string            `json:"icon_url"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	AuthorName string `json:"author_name"`
+	AuthorLink string `json:"author_link"`
+	Text       string `json:"text"`
+	Color      string `json:"color"`
+}
+
+// SlackNotifier posts messages to the incoming webhook of a Slack
+// channel.
+type SlackNotifier struct {
+	client *http.Client
+}
+
+func NewSlackNotifier(client *http.Client) *SlackNotifier {
+	return &SlackNotifier{
+		client: client,
+	}
+}
+
+func (self *SlackNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.SlackNotifier)
+	if !ok {
+		return fmt.Errorf("SlackNotifier: unexpected notifier type %T", notification.Notifier)
+	}
+
+	summary, err := summarize(notifier.UrlHost, notification)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(newSlackMessage(summary))
+	if err != nil {
+		return err
+	}
+
+	return post(self.client, notifier.WebhookURL, "application/json", body)
+}
+
+// newSlackMessage builds the message sent to Slack for summary.
+// Mattermost understands the same format.
+func newSlackMessage(summary *chatSummary) *slackMessage {
+	attachment := slackAttachment{
+		AuthorName: summary.Title,
+		AuthorLink: summary.TitleLink,
+		Text:       summary.TextWithLogsLink(),
+		Color:      "good",
+	}
+
+	if summary.Failed {
+		attachment.Color = "danger"
+	}
+
+	return &slackMessage{
+		Username:    chatUsername,
+		IconUrl:     chatAuthorIcon,
+		Attachments: []slackAttachment{attachment},
+	}
+}
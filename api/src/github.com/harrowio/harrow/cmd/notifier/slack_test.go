@@ -0,0 +1,121 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+func TestSlackNotifier_Notify_postsMessageAboutFailedOperation(t *testing.T) {
+	received := &slackMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	operation := &domain.Operation{
+		Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7",
+		TestResults: &domain.TestResults{
+			Entries: []*domain.TestResult{
+				{Name: "TestLogin", Status: "failed"},
+				{Name: "TestLogout", Status: "passed"},
+			},
+		},
+	}
+
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.failed", Payload: operation},
+		Notifier: &domain.SlackNotifier{WebhookURL: server.URL, UrlHost: "www.app.harrow.io"},
+		Project:  &domain.Project{Uuid: "a1e7b1f5-7a4b-4a6e-9c43-2d6a1b7c2e3f"},
+		Job:      &domain.Job{Uuid: "f6d5b2a7-3c1e-4b8a-9e2d-5a4c3b2a1f0e", Name: "deploy"},
+	}
+
+	if err := NewSlackNotifier(server.Client()).Notify(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(received.Attachments), 1; got != want {
+		t.Fatalf("len(received.Attachments) = %d; want %d", got, want)
+	}
+
+	attachment := received.Attachments[0]
+	if got, want := attachment.AuthorName, "deploy has failed"; got != want {
+		t.Errorf("attachment.AuthorName = %q; want %q", got, want)
+	}
+
+	if got, want := attachment.Color, "danger"; got != want {
+		t.Errorf("attachment.Color = %q; want %q", got, want)
+	}
+
+	if !strings.HasPrefix(attachment.Text, "1 failing tests:\n• TestLogin\n") {
+		t.Errorf("attachment.Text = %q; want failing tests listed", attachment.Text)
+	}
+
+	if !strings.HasSuffix(attachment.Text, "/#/a/operations/"+operation.Uuid) {
+		t.Errorf("attachment.Text = %q; want link to operation", attachment.Text)
+	}
+}
+
+func TestSlackNotifier_Notify_returnsErrorForUnsuccessfulResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "job.added"},
+		Notifier: &domain.SlackNotifier{WebhookURL: server.URL},
+		Project:  &domain.Project{},
+	}
+
+	if err := NewSlackNotifier(server.Client()).Notify(notification); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestSlackNotifier_Notify_usesMessageRenderedFromNotifierTemplates(t *testing.T) {
+	received := &slackMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	operation := &domain.Operation{Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7"}
+	project := &domain.Project{Uuid: "a1e7b1f5-7a4b-4a6e-9c43-2d6a1b7c2e3f", Name: "shop"}
+	job := &domain.Job{Uuid: "f6d5b2a7-3c1e-4b8a-9e2d-5a4c3b2a1f0e", Name: "deploy"}
+	templateContext := domain.NewNotificationTemplateContext(project, job, operation)
+	templateContext.LogTail = []string{"rake aborted!"}
+
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.failed", Payload: operation},
+		Notifier: &domain.SlackNotifier{
+			WebhookURL:      server.URL,
+			UrlHost:         "www.app.harrow.io",
+			SubjectTemplate: "{{.Project.Name}}/{{.Job.Name}} broke",
+		},
+		Project:  project,
+		Job:      job,
+		Template: templateContext,
+	}
+
+	if err := NewSlackNotifier(server.Client()).Notify(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	attachment := received.Attachments[0]
+	if got, want := attachment.AuthorName, "shop/deploy broke"; got != want {
+		t.Errorf("attachment.AuthorName = %q; want %q", got, want)
+	}
+
+	if !strings.HasSuffix(attachment.Text, "/#/a/operations/"+operation.Uuid) {
+		t.Errorf("attachment.Text = %q; want default text", attachment.Text)
+	}
+}
@@ -0,0 +1,107 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+const (
+	teamsColorGood   = "2EB886"
+	teamsColorDanger = "D50200"
+)
+
+// teamsMessageCard is a legacy actionable message card, which is what
+// incoming webhooks of Microsoft Teams channels accept.
+type teamsMessageCard struct {
+	Type            string        `json:"@type"`
+	Context         string        `json:"@context"`
+	ThemeColor      string        `json:"themeColor"`
+	Summary         string        `json:"summary"`
+	Title           string        `json:"title"`
+	Text            string        `json:"text,omitempty"`
+	PotentialAction []teamsAction `json:"potentialAction,omitempty"`
+}
+
+type teamsAction struct {
+	Type    string        `json:"@type"`
+	Name    string        `json:"name"`
+	Targets []teamsTarget `json:"targets"`
+}
+
+type teamsTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+// TeamsNotifier posts message cards to the incoming webhook of a
+// Microsoft Teams channel.
+type TeamsNotifier struct {
+	client *http.Client
+}
+
+func NewTeamsNotifier(client *http.Client) *TeamsNotifier {
+	return &TeamsNotifier{
+		client: client,
+	}
+}
+
+func (self *TeamsNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.TeamsNotifier)
+	if !ok {
+		return fmt.Errorf("TeamsNotifier: unexpected notifier type %T", notification.Notifier)
+	}
+
+	summary, err := summarize(notifier.UrlHost, notification)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(newTeamsMessageCard(summary))
+	if err != nil {
+		return err
+	}
+
+	return post(self.client, notifier.WebhookURL, "application/json", body)
+}
+
+// newTeamsMessageCard builds the card sent to Teams for summary.
+// Links are shown as buttons below the card's text.
+func newTeamsMessageCard(summary *chatSummary) *teamsMessageCard {
+	card := &teamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: teamsColorGood,
+		Summary:    summary.Title,
+		Title:      summary.Title,
+
+		// Teams renders the text as Markdown, which needs two
+		// line breaks for starting a new line.
+		Text: strings.Replace(summary.Text, "\n", "\n\n", -1),
+	}
+
+	if summary.Failed {
+		card.ThemeColor = teamsColorDanger
+	}
+
+	if summary.LogsLink != "" {
+		card.PotentialAction = append(card.PotentialAction, newTeamsOpenURIAction("View logs", summary.LogsLink))
+	}
+
+	if summary.TitleLink != "" {
+		card.PotentialAction = append(card.PotentialAction, newTeamsOpenURIAction("View job", summary.TitleLink))
+	}
+
+	return card
+}
+
+func newTeamsOpenURIAction(name, uri string) teamsAction {
+	return teamsAction{
+		Type:    "OpenUri",
+		Name:    name,
+		Targets: []teamsTarget{{OS: "default", URI: uri}},
+	}
+}
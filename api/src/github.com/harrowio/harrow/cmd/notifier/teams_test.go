@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+func TestTeamsNotifier_Notify_postsMessageCardAboutFailedOperation(t *testing.T) {
+	received := &teamsMessageCard{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	operation := &domain.Operation{
+		Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7",
+		TestResults: &domain.TestResults{
+			Entries: []*domain.TestResult{
+				{Name: "TestLogin", Status: "failed"},
+			},
+		},
+	}
+
+	notification := &Notification{
+		Activity: &domain.Activity{Name: "operation.failed", Payload: operation},
+		Notifier: &domain.TeamsNotifier{ChatWebhookNotifier: domain.ChatWebhookNotifier{
+			ProjectNotifierBase: domain.ProjectNotifierBase{UrlHost: "www.app.harrow.io"},
+			WebhookURL:          server.URL,
+		}},
+		Project: &domain.Project{Uuid: "a1e7b1f5-7a4b-4a6e-9c43-2d6a1b7c2e3f"},
+		Job:     &domain.Job{Uuid: "f6d5b2a7-3c1e-4b8a-9e2d-5a4c3b2a1f0e", Name: "deploy"},
+	}
+
+	if err := NewTeamsNotifier(server.Client()).Notify(notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := received.Type, "MessageCard"; got != want {
+		t.Errorf("received.Type = %q; want %q", got, want)
+	}
+
+	if got, want := received.Title, "deploy has failed"; got != want {
+		t.Errorf("received.Title = %q; want %q", got, want)
+	}
+
+	if got, want := received.ThemeColor, teamsColorDanger; got != want {
+		t.Errorf("received.ThemeColor = %q; want %q", got, want)
+	}
+
+	if !strings.Contains(received.Text, "TestLogin") {
+		t.Errorf("received.Text = %q; want failing tests listed", received.Text)
+	}
+
+	if got, want := len(received.PotentialAction), 2; got != want {
+		t.Fatalf("len(received.PotentialAction) = %d; want %d", got, want)
+	}
+
+	if got, want := received.PotentialAction[0].Targets[0].URI, "https://www.app.harrow.io/#/a/operations/"+operation.Uuid; got != want {
+		t.Errorf("received.PotentialAction[0].Targets[0].URI = %q; want %q", got, want)
+	}
+}
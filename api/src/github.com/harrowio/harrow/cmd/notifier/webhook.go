@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/netguard"
+)
+
+type WebhookAttemptLog interface {
+	Record(attempt *domain.WebhookNotifierAttempt) error
+}
+
+// WebhookNotifier posts the rendered body template of a webhook
+// notifier to its URL.  Every request is recorded in the attempt log,
+// so that users can see how their endpoint responded.  Requests to
+// addresses which are not public are refused by the client passed to
+// NewWebhookNotifier and not retried.
+type WebhookNotifier struct {
+	client   *http.Client
+	attempts WebhookAttemptLog
+}
+
+func NewWebhookNotifier(client *http.Client, attempts WebhookAttemptLog) *WebhookNotifier {
+	return &WebhookNotifier{
+		client:   client,
+		attempts: attempts,
+	}
+}
+
+func (self *WebhookNotifier) Notify(notification *Notification) error {
+	notifier, ok := notification.Notifier.(*domain.WebhookNotifier)
+	if !ok {
+		return fmt.Errorf("WebhookNotifier: unexpected notifier type %T", notification.Notifier)
+	}
+
+	attempt := domain.NewWebhookNotifierAttempt(notifier, notification.Activity, 1)
+	if delivery := notification.Delivery; delivery != nil {
+		attempt.Attempt = delivery.Attempts + 1
+		attempt.NotificationDeliveryUuid = &delivery.Uuid
+	}
+
+	err := self.send(notifier, notification, attempt)
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	if err := self.attempts.Record(attempt); err != nil {
+		log.Error().Msgf("record webhook attempt %s: %s", attempt.Uuid, err)
+	}
+
+	var forbidden *netguard.ForbiddenAddressError
+	if errors.As(err, &forbidden) {
+		return permanent(err)
+	}
+
+	return err
+}
+
+func (self *WebhookNotifier) send(notifier *domain.WebhookNotifier, notification *Notification, attempt *domain.WebhookNotifierAttempt) error {
+	body, err := notifier.Render(domain.NewWebhookNotifierContext(
+		notification.Activity,
+		notification.Operation(),
+		notification.Project,
+		notification.Job,
+	))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", notifier.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Harrow-Webhook-Notifier")
+	request.Header.Set("X-Harrow-Event", notification.Activity.Name)
+	request.Header.Set("X-Harrow-Attempt", attempt.Uuid)
+	request.Header.Set("X-Harrow-Signature-256", notifier.Sign(body))
+
+	response, err := self.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	status := response.StatusCode
+	attempt.ResponseStatus = &status
+	if !attempt.Succeeded() {
+		return fmt.Errorf("POST %s: %s", notifier.URL, response.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/netguard"
+)
+
+type mockWebhookAttemptLog struct {
+	recorded []*domain.WebhookNotifierAttempt
+}
+
+func (self *mockWebhookAttemptLog) Record(attempt *domain.WebhookNotifierAttempt) error {
+	self.recorded = append(self.recorded, attempt)
+	return nil
+}
+
+func TestWebhookNotifier_Notify_signsBodyAndRecordsAttempt(t *testing.T) {
+	notifier := &domain.WebhookNotifier{
+		Uuid:         "2f7c4d6e-0a1b-4c3d-8e5f-6a7b8c9d0e1f",
+		Secret:       "0123456789abcdef",
+		BodyTemplate: `{"event": {{ json .Activity.Name }}}`,
+	}
+
+	signature := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if got, want := r.Header.Get("X-Harrow-Signature-256"), notifier.Sign(body); got != want {
+			t.Errorf("X-Harrow-Signature-256 = %q; want %q", got, want)
+		}
+		signature = r.Header.Get("X-Harrow-Signature-256")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	notifier.URL = server.URL
+
+	attempts := &mockWebhookAttemptLog{}
+	delivery := &domain.NotificationDelivery{Uuid: "5d6e7f80-1a2b-4c3d-9e4f-5a6b7c8d9e0f", Attempts: 2}
+	err := NewWebhookNotifier(server.Client(), attempts).Notify(&Notification{
+		Activity: &domain.Activity{Id: 7, Name: "operation.succeeded"},
+		Notifier: notifier,
+		Project:  &domain.Project{},
+		Delivery: delivery,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signature == "" {
+		t.Fatalf("request was not signed")
+	}
+
+	if got, want := len(attempts.recorded), 1; got != want {
+		t.Fatalf("len(attempts.recorded) = %d; want %d", got, want)
+	}
+
+	attempt := attempts.recorded[0]
+	if attempt.ResponseStatus == nil || *attempt.ResponseStatus != http.StatusAccepted {
+		t.Errorf("attempt.ResponseStatus = %v; want %d", attempt.ResponseStatus, http.StatusAccepted)
+	}
+
+	if got, want := attempt.Attempt, 3; got != want {
+		t.Errorf("attempt.Attempt = %d; want %d", got, want)
+	}
+}
+
+func TestWebhookNotifier_Notify_recordsUnsuccessfulResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	attempts := &mockWebhookAttemptLog{}
+	err := NewWebhookNotifier(server.Client(), attempts).Notify(&Notification{
+		Activity: &domain.Activity{Id: 7, Name: "operation.failed"},
+		Notifier: &domain.WebhookNotifier{URL: server.URL, Secret: "0123456789abcdef"},
+		Project:  &domain.Project{},
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	if got, want := *attempts.recorded[0].ResponseStatus, http.StatusBadGateway; got != want {
+		t.Errorf("ResponseStatus = %d; want %d", got, want)
+	}
+
+	if attempts.recorded[0].Error == "" {
+		t.Errorf("expected error to be recorded")
+	}
+}
+
+func TestWebhookNotifier_Notify_refusesInternalAddressesWithoutRetrying(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	attempts := &mockWebhookAttemptLog{}
+	err := NewWebhookNotifier(netguard.NewClient(time.Second), attempts).Notify(&Notification{
+		Activity: &domain.Activity{Id: 7, Name: "operation.failed"},
+		Notifier: &domain.WebhookNotifier{URL: server.URL, Secret: "0123456789abcdef"},
+		Project:  &domain.Project{},
+	})
+
+	if !isPermanent(err) {
+		t.Errorf("err = %v; want a permanent error", err)
+	}
+
+	if requested {
+		t.Errorf("expected %s not to be requested", server.URL)
+	}
+
+	if got, want := len(attempts.recorded), 1; got != want {
+		t.Errorf("len(attempts.recorded) = %d; want %d", got, want)
+	}
+}
@@ -1,8 +1,6 @@
 package notifier
 
 import (
-	"fmt"
-
 	"github.com/harrowio/harrow/domain"
 )
 
@@ -14,15 +12,26 @@ type Scheduler interface {
 	ScheduleNotification(rule *domain.NotificationRule, activity *domain.Activity) error
 }
 
+// OperationHistory looks up how the previous operations of the same
+// job as an operation turned out.
+type OperationHistory interface {
+	// PreviousOperation returns the job's last operation before
+	// operation and how many of the job's operations failed in a row
+	// up to and including operation.
+	PreviousOperation(operation *domain.Operation) (previous *domain.Operation, failuresInARow int, err error)
+}
+
 type Worker struct {
 	notificationRules NotificationRulesStore
 	scheduler         Scheduler
+	history           OperationHistory
 }
 
-func NewWorker(rules NotificationRulesStore, scheduler Scheduler) *Worker {
+func NewWorker(rules NotificationRulesStore, scheduler Scheduler, history OperationHistory) *Worker {
 	return &Worker{
 		notificationRules: rules,
 		scheduler:         scheduler,
+		history:           history,
 	}
 }
 
@@ -37,17 +46,58 @@ func (self *Worker) HandleActivity(activity *domain.Activity) error {
 		return err
 	}
 
+	outcome := &operationOutcome{activity: activity, history: self.history}
 	for _, rule := range possibleRules {
-		if rule.Matches(activity) {
-			self.Notify(rule, activity)
+		if !rule.Matches(activity) {
+			continue
+		}
+
+		if rule.HasCondition() {
+			matches, err := outcome.matches(rule)
+			if err != nil {
+				return err
+			}
+			if !matches {
+				continue
+			}
 		}
+
+		self.Notify(rule, activity)
 	}
 	return nil
 }
 
 func (self *Worker) Notify(rule *domain.NotificationRule, activity *domain.Activity) {
-	fmt.Printf("notify: rule=%s activity=%s@%d\n", rule.Uuid, activity.Name, activity.Id)
+	log.Info().Msgf("notify: rule=%s activity=%s@%d", rule.Uuid, activity.Name, activity.Id)
 	if err := self.scheduler.ScheduleNotification(rule, activity); err != nil {
-		fmt.Printf("notify: %s\n", err)
+		log.Error().Msgf("notify: rule=%s activity=%s@%d: %s", rule.Uuid, activity.Name, activity.Id, err)
+	}
+}
+
+// operationOutcome looks up the history of the operation an activity
+// is about at most once, no matter how many rules need it.
+type operationOutcome struct {
+	activity *domain.Activity
+	history  OperationHistory
+
+	loaded         bool
+	previous       *domain.Operation
+	failuresInARow int
+}
+
+func (self *operationOutcome) matches(rule *domain.NotificationRule) (bool, error) {
+	operation, ok := self.activity.Payload.(*domain.Operation)
+	if !ok {
+		return false, nil
 	}
+
+	if !self.loaded {
+		previous, failuresInARow, err := self.history.PreviousOperation(operation)
+		if err != nil {
+			return false, err
+		}
+		self.previous, self.failuresInARow, self.loaded = previous, failuresInARow, true
+	}
+
+	return rule.MatchesOutcome(operation, self.previous, self.failuresInARow), nil
 }
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+type mockNotificationRules struct {
+	rules []*domain.NotificationRule
+}
+
+func (self *mockNotificationRules) FindByProjectUuid(projectUuid string) ([]*domain.NotificationRule, error) {
+	return self.rules, nil
+}
+
+type mockScheduler struct {
+	scheduled []*domain.NotificationRule
+}
+
+func (self *mockScheduler) ScheduleNotification(rule *domain.NotificationRule, activity *domain.Activity) error {
+	self.scheduled = append(self.scheduled, rule)
+	return nil
+}
+
+type mockOperationHistory struct {
+	previous       *domain.Operation
+	failuresInARow int
+	lookups        int
+}
+
+func (self *mockOperationHistory) PreviousOperation(operation *domain.Operation) (*domain.Operation, int, error) {
+	self.lookups++
+	return self.previous, self.failuresInARow, nil
+}
+
+func newFinishedOperation(exitStatus int) *domain.Operation {
+	finishedAt := time.Now()
+	return &domain.Operation{
+		Uuid:       "d7a4b0a6-6b0e-4c6b-9a4e-5a8f5d5c3f01",
+		FinishedAt: &finishedAt,
+		ExitStatus: exitStatus,
+	}
+}
+
+func TestWorker_HandleActivity_onlyNotifiesRulesWhoseConditionMatches(t *testing.T) {
+	projectUuid := "ad2c4b7c-bd46-4ab2-8b8b-6b2b3c1a2f6e"
+	newRule := func(condition string) *domain.NotificationRule {
+		return &domain.NotificationRule{
+			ProjectUuid:   projectUuid,
+			MatchActivity: "operation.failed",
+			Condition:     condition,
+			NotifyEvery:   1,
+		}
+	}
+	unconditional := newRule(domain.NotificationConditionNone)
+	broken := newRule(domain.NotificationConditionBroken)
+	fixed := newRule(domain.NotificationConditionFixed)
+	stillFailing := newRule(domain.NotificationConditionStillFailing)
+
+	rules := &mockNotificationRules{rules: []*domain.NotificationRule{unconditional, broken, fixed, stillFailing}}
+	scheduler := &mockScheduler{}
+	history := &mockOperationHistory{previous: newFinishedOperation(0), failuresInARow: 1}
+	worker := NewWorker(rules, scheduler, history)
+
+	activity := &domain.Activity{
+		Name:    "operation.failed",
+		Extra:   map[string]interface{}{},
+		Payload: newFinishedOperation(1),
+	}
+	activity.SetProjectUuid(projectUuid)
+
+	if err := worker.HandleActivity(activity); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(scheduler.scheduled), 2; got != want {
+		t.Fatalf(`len(scheduler.scheduled) = %d; want %d`, got, want)
+	}
+
+	if got, want := scheduler.scheduled[0], unconditional; got != want {
+		t.Errorf(`scheduler.scheduled[0] = %#v; want %#v`, got, want)
+	}
+
+	if got, want := scheduler.scheduled[1], broken; got != want {
+		t.Errorf(`scheduler.scheduled[1] = %#v; want %#v`, got, want)
+	}
+
+	if got, want := history.lookups, 1; got != want {
+		t.Errorf(`history.lookups = %d; want %d`, got, want)
+	}
+}
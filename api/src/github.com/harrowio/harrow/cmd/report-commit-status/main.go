@@ -0,0 +1,208 @@
+package reportCommitStatus
+
+import (
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/domain/interaction"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/stores"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	redis "gopkg.in/redis.v2"
+)
+
+const ProgramName = "report-commit-status"
+
+var commitHash = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+var log zerolog.Logger = zerolog.New(os.Stdout).With().Str("harrow", ProgramName).Timestamp().Logger()
+
+func Main() {
+	operationUUID := flag.String("operation-uuid", "", "derive repositories and state from operation")
+	repositoryUUID := flag.String("repository", "", "uuid of the repository to which to add the status")
+	ref := flag.String("ref", "", "commit hash to which to add the status")
+	state := flag.String("state", "success", "one of: success, error, pending, failure")
+	targetURL := flag.String("target-url", "", "URL to link the status to")
+	flag.Parse()
+
+	conf := config.GetConfig()
+	urls := conf.URLConfig()
+	db, err := conf.DB()
+	if err != nil {
+		log.Fatal().Err(err)
+	}
+
+	redisClient := redis.NewTCPClient(conf.RedisConnOpts(1))
+	defer redisClient.Close()
+	secrets, err := stores.NewSecretKeyValueStore(conf, redisClient)
+	if err != nil {
+		log.Fatal().Err(err)
+	}
+
+	if *operationUUID != "" {
+		if err := runForOperation(log, urls.Base(), db, secrets, *operationUUID); err != nil {
+			log.Fatal().Err(err)
+		}
+	} else {
+		tx := db.MustBegin()
+		defer tx.Rollback()
+		datasource := NewDatasource(tx, secrets)
+		report := interaction.NewReportCommitStatus(
+			*repositoryUUID,
+			*ref,
+			*state,
+			*targetURL,
+			log,
+			http.DefaultClient,
+			datasource,
+			datasource,
+		)
+
+		if err := report.Execute(); err != nil {
+			log.Fatal().Err(err)
+		}
+	}
+}
+
+func runForOperation(log logger.Logger, base *url.URL, db *sqlx.DB, secrets stores.SecretKeyValueStore, operationUUID string) error {
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	operation, err := stores.NewDbOperationStore(tx).FindByUuid(operationUUID)
+	if err != nil {
+		return err
+	}
+
+	datasource := NewDatasource(tx, secrets)
+	checkouts := operation.RepositoryCheckouts
+	if operation.Status() == "active" && operation.IsUserJob() && (checkouts == nil || len(checkouts.Refs) == 0) {
+		// Operations that just started have not checked out
+		// anything yet, so the commits they are going to build
+		// are taken from what Harrow last saw in each repository.
+		checkouts, err = expectedCheckouts(operation, stores.NewDbRepositoryStore(tx))
+		if err != nil {
+			return err
+		}
+	}
+
+	if checkouts == nil {
+		log.Info().Msgf("No repositories used by operation %s", operationUUID)
+		return nil
+	}
+	state := interaction.CommitStatusSuccess
+	switch operation.Status() {
+	case "canceled":
+		state = interaction.CommitStatusFailure
+	case "timeout":
+		state = interaction.CommitStatusError
+	case "fatal":
+		state = interaction.CommitStatusError
+	case "failure":
+		state = interaction.CommitStatusFailure
+	case "active":
+		state = interaction.CommitStatusPending
+	default:
+		state = interaction.CommitStatusSuccess
+	}
+
+	operationURL := base.ResolveReference(&url.URL{
+		Path:     "/",
+		Fragment: "/a/operations/" + operation.Uuid,
+	}).String()
+
+	for repository, checkouts := range checkouts.Refs {
+		for _, checkout := range checkouts {
+			log.Info().Msgf("UPDATE repository %s %s %s", repository, checkout.Hash, checkout.Ref)
+			report := interaction.NewReportCommitStatus(repository, checkout.Hash, state, operationURL, log, http.DefaultClient, datasource, datasource)
+			if err := report.Execute(); err != nil {
+				log.Info().Msgf("repository %s: %s\n", repository, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// expectedCheckouts returns the commits operation is going to check
+// out, according to the refs it was scheduled for and the metadata of
+// its repositories.  Repositories whose ref cannot be resolved to a
+// commit are left out.
+func expectedCheckouts(operation *domain.Operation, repositories domain.RepositoryStore) (*domain.RepositoryCheckouts, error) {
+	result := domain.NewRepositoryCheckouts()
+	found, err := operation.Repositories(repositories)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repository := range found {
+		ref := ""
+		if operation.Parameters != nil {
+			ref = operation.Parameters.Checkout[repository.Uuid]
+		}
+		if ref == "" {
+			ref = "master"
+		}
+
+		hash := ""
+		if commitHash.MatchString(ref) {
+			hash = ref
+		} else if repository.Metadata != nil {
+			hash = repository.Metadata.Refs["refs/heads/"+ref]
+			if hash == "" {
+				hash = repository.Metadata.Refs[ref]
+			}
+		}
+
+		if hash == "" {
+			continue
+		}
+
+		result.Refs[repository.Uuid] = []*domain.RepositoryCheckout{{Ref: ref, Hash: hash}}
+	}
+
+	return result, nil
+}
+
+type Datasource struct {
+	tx      *sqlx.Tx
+	secrets stores.SecretKeyValueStore
+}
+
+func NewDatasource(tx *sqlx.Tx, secrets stores.SecretKeyValueStore) *Datasource {
+	return &Datasource{
+		tx:      tx,
+		secrets: secrets,
+	}
+}
+
+func (self *Datasource) FindRepository(repositoryUUID string) (*domain.Repository, error) {
+	return stores.NewDbRepositoryStore(self.tx).FindByUuid(repositoryUUID)
+}
+
+// FindCommitStatusToken returns the token credential of the
+// repository.  Repositories without one that were connected through
+// GitHub use the OAuth token of that connection.
+func (self *Datasource) FindCommitStatusToken(repositoryUUID string) (*domain.TokenRepositoryCredential, error) {
+	credential, err := stores.NewRepositoryCredentialStore(self.secrets, self.tx).FindByRepositoryUuidAndType(repositoryUUID, domain.RepositoryCredentialToken)
+	if err == nil {
+		return domain.AsTokenRepositoryCredential(credential)
+	}
+	if !domain.IsNotFound(err) {
+		return nil, err
+	}
+
+	oAuthToken, err := stores.NewDbOAuthTokenStore(self.tx).FindByRepositoryUUID(repositoryUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenRepositoryCredential{
+		Token:    oAuthToken.AccessToken,
+		Provider: domain.CommitStatusProviderGitHub,
+	}, nil
+}
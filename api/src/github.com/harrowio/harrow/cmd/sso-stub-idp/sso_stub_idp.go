@@ -0,0 +1,62 @@
+package ssoStubIdp
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/harrowio/harrow/services/sso/ssotest"
+)
+
+const ProgramName = "sso-stub-idp"
+
+var log zerolog.Logger = zerolog.New(os.Stdout).With().Str("harrow", ProgramName).Timestamp().Logger()
+
+// Main runs an OpenID Connect issuer and a SAML identity provider for
+// trying out single sign-on locally.  Both sign in the user given on
+// the command line without asking for credentials.
+func Main() {
+	var (
+		listen       = flag.String("listen", "127.0.0.1:8090", "Address to listen on")
+		baseUrl      = flag.String("base-url", "http://127.0.0.1:8090", "URL the identity providers are reachable at")
+		clientId     = flag.String("client-id", "harrow", "OpenID Connect client id")
+		clientSecret = flag.String("client-secret", "secret", "OpenID Connect client secret")
+		subject      = flag.String("subject", "stub-user", "Subject identifier of the user")
+		email        = flag.String("email", "user@example.com", "Email address of the user")
+		name         = flag.String("name", "Stub User", "Name of the user")
+		groups       = flag.String("groups", "", "Comma separated groups of the user")
+	)
+
+	flag.CommandLine.Parse(os.Args[1:])
+
+	user := ssotest.User{
+		Subject: *subject,
+		Email:   *email,
+		Name:    *name,
+		Groups:  []string{},
+	}
+	for _, group := range strings.Split(*groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			user.Groups = append(user.Groups, group)
+		}
+	}
+
+	base := strings.TrimRight(*baseUrl, "/")
+	oidc := ssotest.NewOIDCProvider(*clientId, *clientSecret, user)
+	oidc.Issuer = base + "/oidc"
+	saml := ssotest.NewSAMLProvider(base+"/saml", user)
+
+	mux := http.NewServeMux()
+	mux.Handle("/oidc/", http.StripPrefix("/oidc", oidc))
+	mux.Handle("/saml/", http.StripPrefix("/saml", saml))
+
+	log.Info().Msgf("oidc: issuer=%s client_id=%s client_secret=%s", oidc.Issuer, *clientId, *clientSecret)
+	log.Info().Msgf("saml: sso_url=%s/sso issuer=%s certificate:\n%s", saml.Issuer, saml.Issuer, saml.CertificatePEM())
+
+	if err := http.ListenAndServe(*listen, mux); err != nil {
+		log.Fatal().Err(err).Msg("listen")
+	}
+}
@@ -0,0 +1,181 @@
+package uploadLogs
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/objectstore"
+)
+
+const ProgramName = "upload-logs"
+
+var log zerolog.Logger = zerolog.New(os.Stdout).With().Str("harrow", ProgramName).Timestamp().Logger()
+
+// Options control how logs are uploaded.
+type Options struct {
+	// DryRun only reports what would be uploaded.
+	DryRun bool
+
+	// DeleteLocal removes local files once they have been uploaded.
+	DeleteLocal bool
+
+	// Force uploads files even if an object of the same size
+	// already exists.
+	Force bool
+}
+
+// Report summarizes a single run of uploading logs.
+type Report struct {
+	Uploaded int
+	Skipped  int
+	Deleted  int
+	Bytes    int64
+	Errors   int
+	DryRun   bool
+}
+
+func (self *Report) String() string {
+	prefix := ""
+	if self.DryRun {
+		prefix = "dry run: "
+	}
+	return fmt.Sprintf("%suploaded=%d skipped=%d deleted=%d bytes=%d errors=%d",
+		prefix,
+		self.Uploaded,
+		self.Skipped,
+		self.Deleted,
+		self.Bytes,
+		self.Errors,
+	)
+}
+
+func Main() {
+	dryRun := flag.Bool("n", false, "dry run: report what would be uploaded without changing anything")
+	deleteLocal := flag.Bool("delete", false, "delete local files after uploading them")
+	force := flag.Bool("f", false, "upload files even if they already exist in the bucket")
+	flag.Parse()
+
+	c := config.GetConfig()
+	storageConfig := c.LogStorageConfig()
+	store, err := objectstore.NewS3(storageConfig.AwsConfig(), storageConfig.S3Bucket, storageConfig.S3Prefix)
+	if err != nil {
+		log.Fatal().Msgf("objectstore.NewS3: %s", err)
+	}
+
+	report, err := Upload(c.FilesystemConfig().OpLogDir, store, Options{
+		DryRun:      *dryRun,
+		DeleteLocal: *deleteLocal,
+		Force:       *force,
+	})
+	if err != nil {
+		log.Fatal().Msgf("Upload: %s", err)
+	}
+
+	log.Info().
+		Int("uploaded", report.Uploaded).
+		Int("skipped", report.Skipped).
+		Int("deleted", report.Deleted).
+		Int64("bytes", report.Bytes).
+		Int("errors", report.Errors).
+		Bool("dryRun", report.DryRun).
+		Msg(report.String())
+}
+
+// Upload copies all operation logs found in logDir to store, using
+// the same keys the S3 backed log storage looks them up with: the
+// gzipped logevent logs directly in logDir and the plain text logs of
+// stores.DiskLogStore in its subdirectories.  Failing files are
+// counted and logged, but don't stop the upload.
+func Upload(logDir string, store objectstore.Store, opts Options) (*Report, error) {
+	report := &Report{DryRun: opts.DryRun}
+
+	err := filepath.Walk(logDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			// temporary directories of logevent.FileTransport
+			if strings.HasPrefix(info.Name(), "log_transport") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		key, err := filepath.Rel(logDir, path)
+		if err != nil {
+			return err
+		}
+		key = filepath.ToSlash(key)
+		if !isLogFile(key) {
+			return nil
+		}
+
+		if err := uploadFile(path, key, info, store, opts, report); err != nil {
+			log.Error().Msgf("%s: %s", path, err)
+			report.Errors++
+		}
+
+		return nil
+	})
+
+	return report, err
+}
+
+func isLogFile(key string) bool {
+	if strings.HasSuffix(key, ".json.gz") {
+		return !strings.Contains(key, "/")
+	}
+
+	return strings.HasSuffix(key, ".txt")
+}
+
+func uploadFile(path, key string, info os.FileInfo, store objectstore.Store, opts Options, report *Report) error {
+	if !opts.Force {
+		size, err := store.Size(key)
+		if err != nil && !objectstore.IsNotFound(err) {
+			return err
+		}
+		if err == nil && size == info.Size() {
+			report.Skipped++
+			return deleteLocal(path, opts, report)
+		}
+	}
+
+	if !opts.DryRun {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := store.Put(key, data); err != nil {
+			return err
+		}
+	}
+
+	report.Uploaded++
+	report.Bytes += info.Size()
+
+	return deleteLocal(path, opts, report)
+}
+
+func deleteLocal(path string, opts Options, report *Report) error {
+	if !opts.DeleteLocal {
+		return nil
+	}
+
+	if !opts.DryRun {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	report.Deleted++
+	return nil
+}
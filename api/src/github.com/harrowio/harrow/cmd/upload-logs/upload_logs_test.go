@@ -0,0 +1,117 @@
+package uploadLogs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/harrowio/harrow/objectstore"
+)
+
+func newTestLogDir(t *testing.T, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "upload-logs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fileName, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestUpload_uploadsLogsUnderTheirRelativePath(t *testing.T) {
+	dir, cleanup := newTestLogDir(t, map[string]string{
+		"a.json.gz":                       "a",
+		"1234/5678/12345678-verbose.txt":  "b",
+		"log_transport123/b.json.gz":      "c",
+		"nested/c.json.gz":                "d",
+		"unrelated.pid":                   "e",
+		"1234/5678/12345678-workspace.gz": "f",
+	})
+	defer cleanup()
+	store := objectstore.NewMemory()
+
+	report, err := Upload(dir, store, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := store.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "1234/5678/12345678-verbose.txt" || keys[1] != "a.json.gz" {
+		t.Errorf(`keys = %v; want [1234/5678/12345678-verbose.txt a.json.gz]`, keys)
+	}
+
+	if got, want := report.Uploaded, 2; got != want {
+		t.Errorf(`report.Uploaded = %d; want %d`, got, want)
+	}
+}
+
+func TestUpload_skipsLogsAlreadyUploaded(t *testing.T) {
+	dir, cleanup := newTestLogDir(t, map[string]string{
+		"a.json.gz": "a",
+	})
+	defer cleanup()
+	store := objectstore.NewMemory()
+	store.Put("a.json.gz", []byte("a"))
+
+	report, err := Upload(dir, store, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Uploaded != 0 || report.Skipped != 1 {
+		t.Errorf(`report = %s; want uploaded=0 skipped=1`, report)
+	}
+}
+
+func TestUpload_dryRunDoesNotChangeAnything(t *testing.T) {
+	dir, cleanup := newTestLogDir(t, map[string]string{
+		"a.json.gz": "a",
+	})
+	defer cleanup()
+	store := objectstore.NewMemory()
+
+	report, err := Upload(dir, store, Options{DryRun: true, DeleteLocal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.Keys()) != 0 {
+		t.Errorf(`store.Keys() = %v; want none`, store.Keys())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a.json.gz")); err != nil {
+		t.Errorf(`local file: %s`, err)
+	}
+
+	if report.Uploaded != 1 || report.Deleted != 1 {
+		t.Errorf(`report = %s; want uploaded=1 deleted=1`, report)
+	}
+}
+
+func TestUpload_deletesLocalFilesAfterUploading(t *testing.T) {
+	dir, cleanup := newTestLogDir(t, map[string]string{
+		"a.json.gz": "a",
+	})
+	defer cleanup()
+	store := objectstore.NewMemory()
+
+	if _, err := Upload(dir, store, Options{DeleteLocal: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a.json.gz")); !os.IsNotExist(err) {
+		t.Errorf(`err = %v; want file to be deleted`, err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +32,8 @@ func (self *socket) newCommand(name, data string) (Command, error) {
 		return newSubLogeventsCmd(self, data)
 	case "subRow":
 		return newSubRowCmd(self, data)
+	case "subActivities":
+		return newSubActivitiesCmd(self, data)
 	default:
 		return nil, fmt.Errorf("Unsupported Command: %s", name)
 	}
@@ -154,6 +158,14 @@ func writeBatch(socket *socket, cid string, batch []*logevent.Message) error {
 type subLogeventsCmd struct {
 	baseCmd
 	OperationUuid string `json:"operationUuid"`
+
+	// Since is the sequence number of the last logevent the client
+	// has received.  Only later logevents are sent.
+	Since *int64 `json:"since"`
+
+	// SinceTime is the timestamp of the last logevent the client has
+	// received, in nanoseconds.  Only later logevents are sent.
+	SinceTime int64 `json:"sinceTime"`
 }
 
 type logeventUpdate struct {
@@ -176,9 +188,27 @@ func (self *subLogeventsCmd) String() string {
 	return fmt.Sprintf("subLogeventsCmd(%s)", self.OperationUuid)
 }
 
+// Offset returns the position in the log from which to resume sending
+// logevents, or nil if the client wants the whole log.
+func (self *subLogeventsCmd) Offset() *logevent.Offset {
+	if self.Since == nil && self.SinceTime == 0 {
+		return nil
+	}
+
+	offset := logevent.NewOffset()
+	if self.Since != nil {
+		offset.Seq = *self.Since
+	}
+	offset.Time = self.SinceTime
+	return offset
+}
+
 func (self *subLogeventsCmd) Exec() error {
-	logeventSource := self.socket.ws.newLogeventSource()
-	messages, err := logeventSource.Consume(self.OperationUuid)
+	logeventSource, err := self.socket.ws.newLogeventSource()
+	if err != nil {
+		return err
+	}
+	messages, err := logeventSource.ConsumeFrom(self.OperationUuid, self.Offset())
 	if err != nil {
 		logeventSource.Close()
 		return err
@@ -390,3 +420,219 @@ func (self *subRowCmd) getSubject(tx *sqlx.Tx) (domain.Subject, error) {
 	}
 	return nil, fmt.Errorf("unknown table %s", self.Table)
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// subActivitiesCmd
+////////////////////////////////////////////////////////////////////////////////
+
+// subActivitiesCmd streams the activities of a project or of all
+// projects of an organization to the client.
+//
+// Activities are picked up once they have been stored by the activity
+// worker, because only then their audience and project are known.
+type subActivitiesCmd struct {
+	baseCmd
+	ProjectUuid      string `json:"projectUuid"`
+	OrganizationUuid string `json:"organizationUuid"`
+
+	// Names optionally restricts the activities sent to those whose
+	// name matches at least one of the given patterns, e.g.
+	// "operation.*".
+	Names []string `json:"names"`
+}
+
+type activityUpdate struct {
+	updateCmd
+	Activity *domain.Activity `json:"activity"`
+}
+
+func newSubActivitiesCmd(socket *socket, data string) (*subActivitiesCmd, error) {
+	c := &subActivitiesCmd{}
+	c.terminator = make(chan chan error)
+	c.socket = socket
+	err := json.Unmarshal([]byte(data), c)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range c.Names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %s", pattern, err)
+		}
+	}
+	return c, nil
+}
+
+func (self *subActivitiesCmd) String() string {
+	return fmt.Sprintf("subActivitiesCmd(%s,%s,%v)", self.ProjectUuid, self.OrganizationUuid, self.Names)
+}
+
+func (self *subActivitiesCmd) Exec() error {
+	source := self.socket.ws.newBroadcastSource(self.CID)
+	creations, err := source.Consume(broadcast.Create)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			select {
+			case errors := <-self.terminator:
+				closeRes := source.Close()
+				if errors != nil {
+					errors <- closeRes
+					close(errors)
+				}
+				self.socket.removeCommand(self)
+				return
+			case created, ok := <-creations:
+				if !ok {
+					creations = nil
+					go self.Terminate()
+					continue
+				}
+				if created.Table() != "activities" {
+					created.RejectForever()
+					continue
+				}
+
+				activity, err := self.loadVisibleActivity(created.UUID())
+				if err != nil {
+					zl.Debug().Msgf("cid=%s activity=%s: %s", self.CID, created.UUID(), err)
+					created.RejectForever()
+					continue
+				}
+
+				if activity != nil {
+					if err := self.sendUpdate(activity); err != nil {
+						zl.Info().Msgf("Unable to send update, terminating: %s\n", err)
+						go self.Terminate()
+						created.RejectForever()
+						continue
+					}
+				}
+
+				created.Acknowledge()
+			}
+		}
+	}()
+	return nil
+}
+
+// loadVisibleActivity loads the activity identified by activityId and
+// returns it if it should be sent to the client.  Nil is returned for
+// activities that are filtered out.
+func (self *subActivitiesCmd) loadVisibleActivity(activityId string) (*domain.Activity, error) {
+	id, err := strconv.Atoi(activityId)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := self.socket.ws.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	activity, err := stores.NewDbActivityStore(tx).FindActivityById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !self.matchesName(activity) || !self.inAudience(activity) {
+		return nil, nil
+	}
+
+	projectUuid := activity.ProjectUuid()
+	if projectUuid == "" {
+		return nil, nil
+	}
+
+	if self.ProjectUuid != "" && projectUuid != self.ProjectUuid {
+		return nil, nil
+	}
+
+	project, err := stores.NewDbProjectStore(tx).FindByUuid(projectUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	if self.OrganizationUuid != "" && project.OrganizationUuid != self.OrganizationUuid {
+		return nil, nil
+	}
+
+	// Access might have been revoked since subscribing
+	authzService := authz.NewService(tx, self.socket.user, self.socket.ws.config)
+	if can, err := authzService.CanRead(project); !can || err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+// matchesName returns true if no name patterns have been given or the
+// activity's name matches at least one of them.
+func (self *subActivitiesCmd) matchesName(activity *domain.Activity) bool {
+	if len(self.Names) == 0 {
+		return true
+	}
+
+	for _, pattern := range self.Names {
+		if matched, _ := path.Match(pattern, activity.Name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inAudience returns true if the subscribed user is part of the
+// audience of activity.
+func (self *subActivitiesCmd) inAudience(activity *domain.Activity) bool {
+	if self.socket.user == nil {
+		return false
+	}
+
+	for _, userUuid := range activity.Audience() {
+		if userUuid == self.socket.user.Uuid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (self *subActivitiesCmd) sendUpdate(activity *domain.Activity) error {
+	update := &activityUpdate{
+		updateCmd: updateCmd{
+			CID: self.CID,
+		},
+		Activity: activity,
+	}
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	return self.socket.send(data)
+}
+
+func (self *subActivitiesCmd) Authorize(tx *sqlx.Tx, service authz.Service) (bool, error) {
+	if self.socket.user == nil {
+		return false, nil
+	}
+
+	switch {
+	case self.ProjectUuid != "" && self.OrganizationUuid == "":
+		project, err := stores.NewDbProjectStore(tx).FindByUuid(self.ProjectUuid)
+		if err != nil {
+			return false, fmt.Errorf("Unable to load project %s: %s", self.ProjectUuid, err)
+		}
+		return service.CanRead(project)
+	case self.OrganizationUuid != "" && self.ProjectUuid == "":
+		organization, err := stores.NewDbOrganizationStore(tx).FindByUuid(self.OrganizationUuid)
+		if err != nil {
+			return false, fmt.Errorf("Unable to load organization %s: %s", self.OrganizationUuid, err)
+		}
+		return service.CanRead(organization)
+	default:
+		return false, errors.New("exactly one of projectUuid and organizationUuid is required")
+	}
+}
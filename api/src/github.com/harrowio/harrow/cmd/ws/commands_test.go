@@ -0,0 +1,50 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+func TestSubActivitiesCmd_matchesName(t *testing.T) {
+	testcases := []struct {
+		names []string
+		name  string
+		match bool
+	}{
+		{nil, "job.added", true},
+		{[]string{"operation.*"}, "operation.failed", true},
+		{[]string{"operation.*"}, "job.added", false},
+		{[]string{"job.added", "operation.*"}, "job.added", true},
+	}
+
+	for _, tc := range testcases {
+		cmd := &subActivitiesCmd{Names: tc.names}
+		activity := domain.NewActivity(1, tc.name)
+		if got, want := cmd.matchesName(activity), tc.match; got != want {
+			t.Errorf(`names=%v matchesName(%q) = %v; want %v`, tc.names, tc.name, got, want)
+		}
+	}
+}
+
+func TestSubActivitiesCmd_inAudience(t *testing.T) {
+	cmd := &subActivitiesCmd{}
+	cmd.socket = &socket{user: &domain.User{Uuid: "user-1"}}
+
+	activity := domain.NewActivity(1, "job.added")
+	if cmd.inAudience(activity) {
+		t.Errorf(`cmd.inAudience(activity) = true for activity without audience`)
+	}
+
+	activity.SetAudience([]string{"user-2", "user-1"})
+	if !cmd.inAudience(activity) {
+		t.Errorf(`cmd.inAudience(activity) = false; want true`)
+	}
+}
+
+func TestNewSubActivitiesCmd_rejectsMalformedPatterns(t *testing.T) {
+	_, err := newSubActivitiesCmd(&socket{}, `{"command":"subActivities","projectUuid":"p","names":["["]}`)
+	if err == nil {
+		t.Fatal(`expected an error for a malformed pattern`)
+	}
+}
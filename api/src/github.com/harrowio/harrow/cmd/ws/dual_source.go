@@ -12,18 +12,22 @@ import (
 )
 
 type dualSource struct {
-	fSource logevent.Source
-	rSource logevent.Source
+	fSource logevent.ResumableSource
+	rSource logevent.ResumableSource
 	log     logger.Logger
 }
 
-// A logevent.Source that first tries FileTransport, and uses RedisTransport
-// on failure
-func NewDualSource(redsi *redis.Client, c *config.Config) *dualSource {
+// A logevent.Source that first tries the configured logevent.Archive,
+// and uses RedisTransport on failure
+func NewDualSource(redsi *redis.Client, c *config.Config) (*dualSource, error) {
 	s := new(dualSource)
 	s.rSource = logevent.NewRedisTransport(redsi, s.Log())
-	s.fSource = logevent.NewFileTransport(c, s.Log())
-	return s
+	archive, err := logevent.NewArchive(c, s.Log())
+	if err != nil {
+		return nil, err
+	}
+	s.fSource = archive
+	return s, nil
 }
 
 func (store *dualSource) Log() logger.Logger {
@@ -38,9 +42,18 @@ func (store *dualSource) SetLogger(l logger.Logger) {
 }
 
 func (self *dualSource) Consume(operationUUID string) (<-chan *logevent.Message, error) {
-	msgs, err := self.fSource.Consume(operationUUID)
+	return self.ConsumeFrom(operationUUID, nil)
+}
+
+// ConsumeFrom serves all messages not seen at offset.  Both transports
+// number messages by their position in the log, so resuming works the
+// same regardless of whether the operation has finished (archive) or is
+// still running (redis), in which case live messages follow the
+// missing ones.
+func (self *dualSource) ConsumeFrom(operationUUID string, offset *logevent.Offset) (<-chan *logevent.Message, error) {
+	msgs, err := self.fSource.ConsumeFrom(operationUUID, offset)
 	if err != nil {
-		msgs, err = self.rSource.Consume(operationUUID)
+		msgs, err = self.rSource.ConsumeFrom(operationUUID, offset)
 	}
 	return msgs, err
 }
@@ -40,7 +40,10 @@ func Main() {
 
 	client := redis.NewTCPClient(c.RedisConnOpts(1))
 	defer client.Close()
-	ss := stores.NewRedisSecretKeyValueStore(client)
+	ss, err := stores.NewSecretKeyValueStore(c, client)
+	if err != nil {
+		log.Fatal().Msgf("error opening secret storage: %s", err)
+	}
 
 	w := newWs(c, db, ss)
 	w.SetLogger(log)
@@ -135,11 +138,15 @@ func (self *ws) newHandler() http.Handler {
 	return sockjs.NewHandler("/ws", sockjs.DefaultOptions, self.sessionHandler)
 }
 
-func (self *ws) newLogeventSource() logevent.Source {
+func (self *ws) newLogeventSource() (logevent.ResumableSource, error) {
 	redisClient := redis.NewTCPClient(self.config.RedisConnOpts(0))
-	ds := NewDualSource(redisClient, self.config)
+	ds, err := NewDualSource(redisClient, self.config)
+	if err != nil {
+		redisClient.Close()
+		return nil, err
+	}
 	ds.SetLogger(log)
-	return ds
+	return ds, nil
 }
 
 func (self *ws) newBroadcastSource(name string) broadcast.Source {
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// AdminConfig lists the users who may use the administrative parts
+// of the API, such as inspecting failed deliveries.  Administrators
+// are identified by their uuid, because users can change their email
+// address.
+type AdminConfig struct {
+	UserUuids []string
+}
+
+func (c Config) AdminConfig() AdminConfig {
+	result := AdminConfig{UserUuids: []string{}}
+	for _, userUuid := range strings.Split(os.Getenv("HAR_ADMIN_USER_UUIDS"), ",") {
+		if userUuid = strings.TrimSpace(userUuid); userUuid != "" {
+			result.UserUuids = append(result.UserUuids, userUuid)
+		}
+	}
+
+	return result
+}
+
+// IsAdmin returns true if the user with the given uuid is an
+// administrator.
+func (self AdminConfig) IsAdmin(userUuid string) bool {
+	for _, admin := range self.UserUuids {
+		if admin == userUuid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type HttpConfig struct {
@@ -14,6 +15,10 @@ type HttpConfig struct {
 	WebSocketPort        int    `json:"websocketPort"`
 	WebSocketBind        string `json:"websocketBind"`
 	MaxSimultaneousConns int
+
+	// TrustedProxies lists the addresses and networks of the
+	// proxies whose X-Forwarded-For headers are trusted.
+	TrustedProxies []string `json:"-"`
 }
 
 func (h HttpConfig) String() string {
@@ -42,6 +47,13 @@ func (c *Config) HttpConfig() HttpConfig {
 		panic("can't read random bytes, erring")
 	}
 
+	trustedProxies := []string{}
+	for _, proxy := range strings.Split(os.Getenv("HAR_HTTP_TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
 	return HttpConfig{
 		Port:                 f("HAR_HTTP_PORT", "8080"),
 		Bind:                 getEnvWithDefault("HAR_HTTP_BIND", "0.0.0.0"),
@@ -49,5 +61,6 @@ func (c *Config) HttpConfig() HttpConfig {
 		WebSocketPort:        f("HAR_HTTP_WEBSOCKET_PORT", "8383"),
 		WebSocketBind:        getEnvWithDefault("HAR_HTTP_WEBSOCKET_BIND", "0.0.0.0"),
 		MaxSimultaneousConns: f("HAR_HTTP_MAX_CONNS", "50"),
+		TrustedProxies:       trustedProxies,
 	}
 }
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+const (
+	// LogStorageFilesystem keeps operation logs in
+	// FilesystemConfig().OpLogDir, which needs to be shared between
+	// all hosts running the controller and the api.
+	LogStorageFilesystem = "filesystem"
+
+	// LogStorageS3 keeps operation logs in an S3 compatible bucket.
+	LogStorageS3 = "s3"
+)
+
+type LogStorageConfig struct {
+	Backend string `json:"backend"`
+
+	S3Endpoint        string `json:"s3_endpoint"`
+	S3Region          string `json:"s3_region"`
+	S3Bucket          string `json:"s3_bucket"`
+	S3Prefix          string `json:"s3_prefix"`
+	S3AccessKeyId     string `json:"s3_access_key_id"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+	S3ForcePathStyle  bool   `json:"s3_force_path_style"`
+}
+
+func (c *Config) LogStorageConfig() LogStorageConfig {
+	return LogStorageConfig{
+		Backend:           getEnvWithDefault("HAR_LOG_STORAGE", LogStorageFilesystem),
+		S3Endpoint:        os.Getenv("HAR_LOG_STORAGE_S3_ENDPOINT"),
+		S3Region:          getEnvWithDefault("HAR_LOG_STORAGE_S3_REGION", "us-east-1"),
+		S3Bucket:          os.Getenv("HAR_LOG_STORAGE_S3_BUCKET"),
+		S3Prefix:          getEnvWithDefault("HAR_LOG_STORAGE_S3_PREFIX", "op-logs"),
+		S3AccessKeyId:     os.Getenv("HAR_LOG_STORAGE_S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: os.Getenv("HAR_LOG_STORAGE_S3_SECRET_ACCESS_KEY"),
+		S3ForcePathStyle:  getEnvBoolWithDefault("HAR_LOG_STORAGE_S3_FORCE_PATH_STYLE", false),
+	}
+}
+
+// AwsConfig returns the configuration for talking to the log storage
+// bucket.  An empty endpoint talks to AWS itself; MinIO and other S3
+// compatible servers usually need S3ForcePathStyle.  Without static
+// credentials the default AWS credential chain is used.
+func (self LogStorageConfig) AwsConfig() *aws.Config {
+	awsConfig := &aws.Config{
+		Region:           aws.String(self.S3Region),
+		S3ForcePathStyle: aws.Bool(self.S3ForcePathStyle),
+		MaxRetries:       aws.Int(3),
+	}
+
+	if self.S3Endpoint != "" {
+		awsConfig.Endpoint = aws.String(self.S3Endpoint)
+	}
+
+	if self.S3AccessKeyId != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(self.S3AccessKeyId, self.S3SecretAccessKey, "")
+	}
+
+	return awsConfig
+}
@@ -4,10 +4,20 @@ import "os"
 
 type MailConfig struct {
 	FromAddress string `json:"fromAddress"`
+
+	// TemplateDir contains the templates for notification emails,
+	// one directory per activity.
+	TemplateDir string `json:"templateDir"`
+
+	// AttachmentsDir contains attachments added to every
+	// notification email.
+	AttachmentsDir string `json:"attachmentsDir"`
 }
 
 func (c *Config) MailConfig() MailConfig {
 	return MailConfig{
-		FromAddress: os.Getenv("HAR_MAIL_FROM_ADDRESS"),
+		FromAddress:    os.Getenv("HAR_MAIL_FROM_ADDRESS"),
+		TemplateDir:    getEnvWithDefault("HAR_MAIL_TEMPLATE_DIR", "/srv/harrow/config/mail"),
+		AttachmentsDir: getEnvWithDefault("HAR_MAIL_ATTACHMENTS_DIR", "/harrow/mail/global/attachments"),
 	}
 }
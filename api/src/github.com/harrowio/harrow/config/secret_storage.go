@@ -0,0 +1,35 @@
+package config
+
+import "os"
+
+const (
+	// SecretStorageRedis keeps secrets in Redis, encrypted with a
+	// per secret key stored in the database.
+	SecretStorageRedis = "redis"
+
+	// SecretStorageVault keeps secrets in the KV version 2 secrets
+	// engine of a HashiCorp Vault server.  Secrets are encrypted
+	// the same way as in Redis before being written to Vault.
+	SecretStorageVault = "vault"
+)
+
+type SecretStorageConfig struct {
+	Backend string `json:"backend"`
+
+	VaultAddress   string `json:"vault_address"`
+	VaultToken     string `json:"vault_token"`
+	VaultNamespace string `json:"vault_namespace"`
+	VaultMount     string `json:"vault_mount"`
+	VaultPrefix    string `json:"vault_prefix"`
+}
+
+func (c *Config) SecretStorageConfig() SecretStorageConfig {
+	return SecretStorageConfig{
+		Backend:        getEnvWithDefault("HAR_SECRET_STORAGE", SecretStorageRedis),
+		VaultAddress:   getEnvWithDefault("HAR_SECRET_STORAGE_VAULT_ADDRESS", "http://127.0.0.1:8200"),
+		VaultToken:     os.Getenv("HAR_SECRET_STORAGE_VAULT_TOKEN"),
+		VaultNamespace: os.Getenv("HAR_SECRET_STORAGE_VAULT_NAMESPACE"),
+		VaultMount:     getEnvWithDefault("HAR_SECRET_STORAGE_VAULT_MOUNT", "secret"),
+		VaultPrefix:    getEnvWithDefault("HAR_SECRET_STORAGE_VAULT_PREFIX", "harrow"),
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+// SsoConfig describes how identity providers configured for single
+// sign-on reach Harrow.
+type SsoConfig struct {
+	// CallbackUri is the URI identity providers send users back
+	// to.  It contains a %s for the uuid of the organization.
+	CallbackUri string
+
+	// SessionRedirectUri is the frontend URI users are sent to
+	// after signing in.  It contains a %s for the uuid of the new
+	// session.
+	SessionRedirectUri string
+
+	// LinkRequestedRedirectUri is the frontend URI users are sent
+	// to when they need to confirm linking their existing account
+	// to an identity.
+	LinkRequestedRedirectUri string
+
+	// EntityId identifies Harrow as a SAML service provider.
+	EntityId string
+}
+
+func (c Config) SsoConfig() SsoConfig {
+	return SsoConfig{
+		CallbackUri:              getEnvWithDefault("HAR_SSO_CALLBACK_URI", "https://test.tld/api/sso/%s/callback"),
+		SessionRedirectUri:       getEnvWithDefault("HAR_SSO_SESSION_REDIRECT_URI", "https://test.tld/#/a/sso/session/%s"),
+		LinkRequestedRedirectUri: getEnvWithDefault("HAR_SSO_LINK_REQUESTED_REDIRECT_URI", "https://test.tld/#/a/sso/link-requested"),
+		EntityId:                 getEnvWithDefault("HAR_SSO_ENTITY_ID", "https://test.tld/api/sso"),
+	}
+}
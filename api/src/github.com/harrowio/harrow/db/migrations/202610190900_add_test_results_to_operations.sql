@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE operations ADD COLUMN test_results json;
+
+-- +migrate Down
+ALTER TABLE operations DROP COLUMN test_results;
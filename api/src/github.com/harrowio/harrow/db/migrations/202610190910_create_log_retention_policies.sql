@@ -0,0 +1,23 @@
+-- +migrate Up
+CREATE TABLE log_retention_policies (
+    uuid uuid NOT NULL PRIMARY KEY,
+    organization_uuid uuid NOT NULL REFERENCES organizations(uuid),
+    project_uuid uuid REFERENCES projects(uuid),
+    keep_full_days integer NOT NULL,
+    keep_tail_kb integer NOT NULL,
+    delete_after_days integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    archived_at timestamp with time zone
+);
+
+CREATE UNIQUE INDEX log_retention_policies_scope_idx
+    ON log_retention_policies (organization_uuid, COALESCE(project_uuid, '00000000-0000-0000-0000-000000000000'::uuid))
+    WHERE archived_at IS NULL;
+
+ALTER TABLE operations ADD COLUMN log_truncated_at timestamp with time zone;
+ALTER TABLE operations ADD COLUMN log_deleted_at timestamp with time zone;
+
+-- +migrate Down
+ALTER TABLE operations DROP COLUMN log_deleted_at;
+ALTER TABLE operations DROP COLUMN log_truncated_at;
+DROP TABLE log_retention_policies;
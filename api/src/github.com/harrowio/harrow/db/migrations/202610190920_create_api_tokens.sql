@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE api_tokens (
+    uuid uuid NOT NULL PRIMARY KEY,
+    name text NOT NULL,
+    user_uuid uuid NOT NULL REFERENCES users(uuid),
+    project_uuid uuid REFERENCES projects(uuid),
+    token_hash text NOT NULL,
+    token_prefix text NOT NULL,
+    capabilities jsonb NOT NULL DEFAULT '[]',
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    last_used_from text,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE UNIQUE INDEX api_tokens_token_hash_idx ON api_tokens (token_hash);
+CREATE INDEX api_tokens_user_uuid_idx ON api_tokens (user_uuid);
+CREATE INDEX api_tokens_project_uuid_idx ON api_tokens (project_uuid);
+
+-- +migrate Down
+DROP TABLE api_tokens;
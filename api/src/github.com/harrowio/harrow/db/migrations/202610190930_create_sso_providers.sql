@@ -0,0 +1,37 @@
+-- +migrate Up
+CREATE TABLE sso_providers (
+    uuid uuid NOT NULL PRIMARY KEY,
+    organization_uuid uuid NOT NULL REFERENCES organizations(uuid),
+    protocol text NOT NULL,
+    enforced boolean NOT NULL DEFAULT false,
+    email_domains jsonb NOT NULL DEFAULT '[]',
+    groups_attribute text NOT NULL DEFAULT 'groups',
+    group_mappings jsonb NOT NULL DEFAULT '{}',
+    default_membership_type text NOT NULL DEFAULT '',
+    oidc_issuer text NOT NULL DEFAULT '',
+    oidc_client_id text NOT NULL DEFAULT '',
+    oidc_client_secret text NOT NULL DEFAULT '',
+    saml_sso_url text NOT NULL DEFAULT '',
+    saml_issuer text NOT NULL DEFAULT '',
+    saml_certificate text NOT NULL DEFAULT '',
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    archived_at timestamp with time zone
+);
+
+CREATE UNIQUE INDEX sso_providers_organization_uuid_idx ON sso_providers (organization_uuid) WHERE archived_at IS NULL;
+
+CREATE TABLE sso_identities (
+    uuid uuid NOT NULL PRIMARY KEY,
+    provider_uuid uuid NOT NULL REFERENCES sso_providers(uuid),
+    subject text NOT NULL,
+    user_uuid uuid NOT NULL REFERENCES users(uuid),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    last_login_at timestamp with time zone
+);
+
+CREATE UNIQUE INDEX sso_identities_provider_uuid_subject_idx ON sso_identities (provider_uuid, subject);
+CREATE INDEX sso_identities_user_uuid_idx ON sso_identities (user_uuid);
+
+-- +migrate Down
+DROP TABLE sso_identities;
+DROP TABLE sso_providers;
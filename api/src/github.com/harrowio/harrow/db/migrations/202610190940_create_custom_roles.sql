@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE custom_roles (
+    uuid uuid NOT NULL PRIMARY KEY,
+    organization_uuid uuid NOT NULL REFERENCES organizations(uuid),
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    capabilities jsonb NOT NULL DEFAULT '[]',
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    archived_at timestamp with time zone
+);
+
+CREATE UNIQUE INDEX custom_roles_organization_uuid_name_idx ON custom_roles (organization_uuid, lower(name)) WHERE archived_at IS NULL;
+
+ALTER TABLE project_memberships ADD COLUMN custom_role_uuid uuid REFERENCES custom_roles(uuid);
+
+-- +migrate Down
+ALTER TABLE project_memberships DROP COLUMN custom_role_uuid;
+DROP TABLE custom_roles;
@@ -0,0 +1,9 @@
+-- +migrate Up
+CREATE INDEX activities_organization_uuid_idx ON activities ((extra->>'organizationUuid'));
+CREATE INDEX activities_project_uuid_idx ON activities ((extra->>'projectUuid'));
+CREATE INDEX activities_context_user_uuid_idx ON activities (context_user_uuid);
+
+-- +migrate Down
+DROP INDEX activities_context_user_uuid_idx;
+DROP INDEX activities_project_uuid_idx;
+DROP INDEX activities_organization_uuid_idx;
@@ -0,0 +1,27 @@
+-- +migrate Up
+ALTER TABLE secrets ADD COLUMN version integer NOT NULL DEFAULT 0;
+
+CREATE TABLE secret_versions (
+    uuid uuid NOT NULL PRIMARY KEY,
+    secret_uuid uuid NOT NULL REFERENCES secrets(uuid),
+    version integer NOT NULL,
+    created_by_uuid uuid REFERENCES users(uuid),
+    rolled_back_from integer,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE UNIQUE INDEX secret_versions_secret_uuid_version_idx ON secret_versions (secret_uuid, version);
+
+CREATE TABLE operation_secret_versions (
+    operation_uuid uuid NOT NULL REFERENCES operations(uuid),
+    secret_uuid uuid NOT NULL REFERENCES secrets(uuid),
+    version integer NOT NULL,
+    PRIMARY KEY (operation_uuid, secret_uuid)
+);
+
+CREATE INDEX operation_secret_versions_secret_uuid_version_idx ON operation_secret_versions (secret_uuid, version);
+
+-- +migrate Down
+DROP TABLE operation_secret_versions;
+DROP TABLE secret_versions;
+ALTER TABLE secrets DROP COLUMN version;
@@ -0,0 +1,52 @@
+-- +migrate Up
+ALTER TABLE secrets ALTER COLUMN environment_uuid DROP NOT NULL;
+ALTER TABLE secrets ADD COLUMN project_uuid uuid REFERENCES projects(uuid);
+ALTER TABLE secrets ADD COLUMN organization_uuid uuid REFERENCES organizations(uuid);
+ALTER TABLE secrets ADD COLUMN override_policy text NOT NULL DEFAULT 'allow';
+
+ALTER TABLE secrets ADD CONSTRAINT secrets_scope_check CHECK (
+  (environment_uuid IS NOT NULL)::integer +
+  (project_uuid IS NOT NULL)::integer +
+  (organization_uuid IS NOT NULL)::integer = 1
+);
+
+ALTER TABLE secrets ADD CONSTRAINT secrets_override_policy_check CHECK (override_policy IN ('allow', 'deny'));
+
+CREATE INDEX secrets_project_uuid_idx ON secrets (project_uuid) WHERE project_uuid IS NOT NULL;
+CREATE INDEX secrets_organization_uuid_idx ON secrets (organization_uuid) WHERE organization_uuid IS NOT NULL;
+
+-- +migrate StatementBegin
+CREATE FUNCTION cascade_shared_secret_archival() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  IF TG_TABLE_NAME = 'projects' THEN
+    UPDATE secrets SET archived_at = new.archived_at WHERE project_uuid = new.uuid AND archived_at IS NULL;
+  ELSE
+    UPDATE secrets SET archived_at = new.archived_at WHERE organization_uuid = new.uuid AND archived_at IS NULL;
+  END IF;
+  RETURN new;
+END;
+$$;
+-- +migrate StatementEnd
+
+CREATE TRIGGER cascade_shared_secret_archival AFTER UPDATE OF archived_at ON projects FOR EACH ROW EXECUTE PROCEDURE cascade_shared_secret_archival();
+CREATE TRIGGER cascade_shared_secret_archival AFTER UPDATE OF archived_at ON organizations FOR EACH ROW EXECUTE PROCEDURE cascade_shared_secret_archival();
+
+-- +migrate Down
+DROP TRIGGER cascade_shared_secret_archival ON organizations;
+DROP TRIGGER cascade_shared_secret_archival ON projects;
+DROP FUNCTION cascade_shared_secret_archival();
+
+DELETE FROM operation_secret_versions WHERE secret_uuid IN (SELECT uuid FROM secrets WHERE environment_uuid IS NULL);
+DELETE FROM secret_versions WHERE secret_uuid IN (SELECT uuid FROM secrets WHERE environment_uuid IS NULL);
+DELETE FROM secrets WHERE environment_uuid IS NULL;
+
+DROP INDEX secrets_organization_uuid_idx;
+DROP INDEX secrets_project_uuid_idx;
+ALTER TABLE secrets DROP CONSTRAINT secrets_override_policy_check;
+ALTER TABLE secrets DROP CONSTRAINT secrets_scope_check;
+ALTER TABLE secrets DROP COLUMN override_policy;
+ALTER TABLE secrets DROP COLUMN organization_uuid;
+ALTER TABLE secrets DROP COLUMN project_uuid;
+ALTER TABLE secrets ALTER COLUMN environment_uuid SET NOT NULL;
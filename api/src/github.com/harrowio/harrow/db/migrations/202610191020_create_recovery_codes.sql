@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE recovery_codes (
+    uuid uuid NOT NULL PRIMARY KEY,
+    user_uuid uuid NOT NULL REFERENCES users(uuid),
+    code_hash text NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX recovery_codes_user_uuid_idx ON recovery_codes (user_uuid);
+
+ALTER TABLE organizations ADD COLUMN require_totp boolean NOT NULL DEFAULT false;
+
+-- +migrate Down
+ALTER TABLE organizations DROP COLUMN require_totp;
+DROP TABLE recovery_codes;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE repositories ADD COLUMN host_keys text NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE repositories DROP COLUMN host_keys;
@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE ip_allowlist_entries (
+    uuid uuid NOT NULL PRIMARY KEY,
+    organization_uuid uuid NOT NULL REFERENCES organizations(uuid),
+    scope text NOT NULL CHECK (scope IN ('api', 'webhook')),
+    cidr cidr NOT NULL,
+    description text NOT NULL DEFAULT '',
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX ip_allowlist_entries_organization_uuid_scope_idx ON ip_allowlist_entries (organization_uuid, scope);
+
+-- +migrate Down
+DROP TABLE ip_allowlist_entries;
@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE notification_deliveries (
+    uuid uuid NOT NULL PRIMARY KEY,
+    notification_rule_uuid uuid NOT NULL REFERENCES notification_rules(uuid),
+    project_uuid uuid NOT NULL REFERENCES projects(uuid),
+    notifier_uuid uuid NOT NULL,
+    notifier_type text NOT NULL,
+    activity_id integer NOT NULL,
+    status text NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text NOT NULL DEFAULT '',
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    delivered_at timestamp with time zone
+);
+
+CREATE INDEX notification_deliveries_notification_rule_uuid_idx ON notification_deliveries (notification_rule_uuid, created_at);
+
+-- +migrate Down
+DROP TABLE notification_deliveries;
@@ -0,0 +1,30 @@
+-- +migrate Up
+CREATE TABLE webhook_notifiers (
+    uuid uuid NOT NULL PRIMARY KEY,
+    name text NOT NULL,
+    project_uuid uuid NOT NULL REFERENCES projects(uuid),
+    url text NOT NULL,
+    secret text NOT NULL,
+    body_template text NOT NULL DEFAULT '',
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    archived_at timestamp with time zone
+);
+
+CREATE INDEX webhook_notifiers_project_uuid_idx ON webhook_notifiers (project_uuid);
+
+CREATE TABLE webhook_notifier_attempts (
+    uuid uuid NOT NULL PRIMARY KEY,
+    webhook_notifier_uuid uuid NOT NULL REFERENCES webhook_notifiers(uuid),
+    notification_delivery_uuid uuid REFERENCES notification_deliveries(uuid),
+    activity_id integer NOT NULL,
+    attempt integer NOT NULL,
+    response_status integer,
+    error text NOT NULL DEFAULT '',
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX webhook_notifier_attempts_webhook_notifier_uuid_idx ON webhook_notifier_attempts (webhook_notifier_uuid, created_at);
+
+-- +migrate Down
+DROP TABLE webhook_notifier_attempts;
+DROP TABLE webhook_notifiers;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE notification_rules
+  ADD COLUMN condition text NOT NULL DEFAULT '' CHECK (condition IN ('', 'broken', 'fixed', 'still-failing')),
+  ADD COLUMN notify_every integer NOT NULL DEFAULT 1 CHECK (notify_every > 0);
+
+-- +migrate Down
+ALTER TABLE notification_rules
+  DROP COLUMN condition,
+  DROP COLUMN notify_every;
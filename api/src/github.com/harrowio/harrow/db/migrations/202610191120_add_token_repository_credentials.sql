@@ -0,0 +1,7 @@
+-- +migrate Up notransaction
+ALTER TYPE repository_credential_type ADD VALUE IF NOT EXISTS 'token';
+
+-- +migrate Down
+-- PostgreSQL cannot drop a single enum value, so only the credentials
+-- using it are removed.
+DELETE FROM repository_credentials WHERE type = 'token';
@@ -0,0 +1,17 @@
+-- +migrate Up
+ALTER TABLE slack_notifiers
+  ADD COLUMN subject_template text NOT NULL DEFAULT '',
+  ADD COLUMN body_template text NOT NULL DEFAULT '';
+
+ALTER TABLE email_notifiers
+  ADD COLUMN subject_template text NOT NULL DEFAULT '',
+  ADD COLUMN body_template text NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE slack_notifiers
+  DROP COLUMN subject_template,
+  DROP COLUMN body_template;
+
+ALTER TABLE email_notifiers
+  DROP COLUMN subject_template,
+  DROP COLUMN body_template;
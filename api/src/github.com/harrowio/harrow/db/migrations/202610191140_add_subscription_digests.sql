@@ -0,0 +1,18 @@
+-- +migrate Up
+ALTER TABLE subscriptions
+  ADD COLUMN delivery text NOT NULL DEFAULT 'immediate'
+    CHECK (delivery IN ('immediate', 'daily', 'weekly'));
+
+CREATE TABLE subscription_digests (
+  user_uuid uuid NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+  project_uuid uuid NOT NULL REFERENCES projects(uuid) ON DELETE CASCADE,
+  delivery text NOT NULL,
+  sent_at timestamp with time zone NOT NULL,
+  PRIMARY KEY (user_uuid, project_uuid, delivery)
+);
+
+-- +migrate Down
+DROP TABLE subscription_digests;
+
+ALTER TABLE subscriptions
+  DROP COLUMN delivery;
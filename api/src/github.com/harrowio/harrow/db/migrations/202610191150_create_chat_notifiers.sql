@@ -0,0 +1,50 @@
+-- +migrate Up
+CREATE TABLE teams_notifiers (
+    uuid uuid NOT NULL PRIMARY KEY,
+    name text NOT NULL,
+    url_host text NOT NULL,
+    project_uuid uuid NOT NULL REFERENCES projects(uuid),
+    webhook_url text NOT NULL,
+    subject_template text NOT NULL DEFAULT '',
+    body_template text NOT NULL DEFAULT '',
+    archived_at timestamp with time zone
+);
+
+CREATE INDEX teams_notifiers_project_uuid_idx ON teams_notifiers (project_uuid);
+CREATE TRIGGER broadcast_change AFTER UPDATE ON teams_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_change();
+CREATE TRIGGER broadcast_create AFTER INSERT ON teams_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_create();
+
+CREATE TABLE mattermost_notifiers (
+    uuid uuid NOT NULL PRIMARY KEY,
+    name text NOT NULL,
+    url_host text NOT NULL,
+    project_uuid uuid NOT NULL REFERENCES projects(uuid),
+    webhook_url text NOT NULL,
+    subject_template text NOT NULL DEFAULT '',
+    body_template text NOT NULL DEFAULT '',
+    archived_at timestamp with time zone
+);
+
+CREATE INDEX mattermost_notifiers_project_uuid_idx ON mattermost_notifiers (project_uuid);
+CREATE TRIGGER broadcast_change AFTER UPDATE ON mattermost_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_change();
+CREATE TRIGGER broadcast_create AFTER INSERT ON mattermost_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_create();
+
+CREATE TABLE discord_notifiers (
+    uuid uuid NOT NULL PRIMARY KEY,
+    name text NOT NULL,
+    url_host text NOT NULL,
+    project_uuid uuid NOT NULL REFERENCES projects(uuid),
+    webhook_url text NOT NULL,
+    subject_template text NOT NULL DEFAULT '',
+    body_template text NOT NULL DEFAULT '',
+    archived_at timestamp with time zone
+);
+
+CREATE INDEX discord_notifiers_project_uuid_idx ON discord_notifiers (project_uuid);
+CREATE TRIGGER broadcast_change AFTER UPDATE ON discord_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_change();
+CREATE TRIGGER broadcast_create AFTER INSERT ON discord_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_create();
+
+-- +migrate Down
+DROP TABLE teams_notifiers;
+DROP TABLE mattermost_notifiers;
+DROP TABLE discord_notifiers;
@@ -0,0 +1,45 @@
+-- +migrate Up
+CREATE TABLE notification_preferences (
+  user_uuid uuid PRIMARY KEY REFERENCES users(uuid) ON DELETE CASCADE,
+  channel text NOT NULL DEFAULT 'email' CHECK (channel IN ('email', 'slack')),
+  slack_webhook_url text NOT NULL DEFAULT '',
+  timezone text NOT NULL DEFAULT 'UTC',
+  quiet_hours_start text NOT NULL DEFAULT '',
+  quiet_hours_end text NOT NULL DEFAULT '',
+  updated_at timestamp with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE notification_project_mutes (
+  user_uuid uuid NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+  project_uuid uuid NOT NULL REFERENCES projects(uuid) ON DELETE CASCADE,
+  PRIMARY KEY (user_uuid, project_uuid)
+);
+
+CREATE TABLE deferred_deliveries (
+  uuid uuid PRIMARY KEY,
+  user_uuid uuid NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+  kind text NOT NULL CHECK (kind IN ('mail', 'notification')),
+  payload jsonb NOT NULL,
+  deliver_at timestamp with time zone NOT NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  delivered_at timestamp with time zone
+);
+
+CREATE INDEX deferred_deliveries_due_idx ON deferred_deliveries (kind, deliver_at) WHERE delivered_at IS NULL;
+
+ALTER TABLE notification_deliveries
+  DROP CONSTRAINT notification_deliveries_status_check,
+  ADD CONSTRAINT notification_deliveries_status_check
+    CHECK (status IN ('pending', 'delivered', 'failed', 'muted', 'deferred'));
+
+-- +migrate Down
+UPDATE notification_deliveries SET status = 'failed' WHERE status IN ('muted', 'deferred');
+
+ALTER TABLE notification_deliveries
+  DROP CONSTRAINT notification_deliveries_status_check,
+  ADD CONSTRAINT notification_deliveries_status_check
+    CHECK (status IN ('pending', 'delivered', 'failed'));
+
+DROP TABLE deferred_deliveries;
+DROP TABLE notification_project_mutes;
+DROP TABLE notification_preferences;
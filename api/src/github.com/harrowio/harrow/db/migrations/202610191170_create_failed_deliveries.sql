@@ -0,0 +1,28 @@
+-- +migrate Up
+CREATE TABLE failed_deliveries (
+  uuid uuid PRIMARY KEY,
+  source text NOT NULL CHECK (source IN ('notifier', 'mail-dispatcher')),
+  kind text NOT NULL CHECK (kind IN ('message', 'notification')),
+  key text NOT NULL,
+  payload jsonb NOT NULL,
+  status text NOT NULL CHECK (status IN ('retrying', 'dead', 'replaying', 'replayed', 'delivered')),
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text NOT NULL DEFAULT '',
+  next_attempt_at timestamp with time zone,
+  created_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  dead_at timestamp with time zone,
+  replayed_at timestamp with time zone
+);
+
+CREATE INDEX failed_deliveries_source_key_idx ON failed_deliveries (source, key);
+CREATE INDEX failed_deliveries_status_idx ON failed_deliveries (status, updated_at);
+
+ALTER TABLE notification_deliveries
+  ADD COLUMN next_attempt_at timestamp with time zone;
+
+-- +migrate Down
+ALTER TABLE notification_deliveries
+  DROP COLUMN next_attempt_at;
+
+DROP TABLE failed_deliveries;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE blame_notifiers (
+    uuid uuid NOT NULL PRIMARY KEY,
+    name text NOT NULL,
+    url_host text NOT NULL,
+    project_uuid uuid NOT NULL REFERENCES projects(uuid),
+    archived_at timestamp with time zone
+);
+
+CREATE INDEX blame_notifiers_project_uuid_idx ON blame_notifiers (project_uuid);
+CREATE TRIGGER broadcast_change AFTER UPDATE ON blame_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_change();
+CREATE TRIGGER broadcast_create AFTER INSERT ON blame_notifiers FOR EACH ROW EXECUTE PROCEDURE broadcast_create();
+
+-- +migrate Down
+DROP TABLE blame_notifiers;
@@ -0,0 +1,5 @@
+-- +migrate Up
+CREATE INDEX notification_deliveries_next_attempt_at_idx ON notification_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +migrate Down
+DROP INDEX notification_deliveries_next_attempt_at_idx;
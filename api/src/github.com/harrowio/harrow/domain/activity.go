@@ -96,6 +96,8 @@ func (self *Activity) SubscriptionKey() string {
 		return "operations.succeeded"
 	case "operation.scheduled":
 		return "operations.scheduled"
+	case "operation.timed-out":
+		return "operations.timed_out"
 	default:
 		return self.Name
 	}
@@ -134,3 +136,32 @@ func (self *Activity) SetJobUuid(jobUuid string) *Activity {
 	self.Extra["jobUuid"] = jobUuid
 	return self
 }
+
+// OrganizationUuid returns the uuid of the organization this activity
+// is associated with or the empty string if this activity is not
+// associated with any organization.
+func (self *Activity) OrganizationUuid() string {
+	switch organizationUuid := self.Extra["organizationUuid"].(type) {
+	case string:
+		return organizationUuid
+	default:
+		return ""
+	}
+}
+
+func (self *Activity) SetOrganizationUuid(organizationUuid string) *Activity {
+	self.Extra["organizationUuid"] = organizationUuid
+	return self
+}
+
+// Previous returns the state of the payload before the activity
+// occurred.  It is only recorded for activities that change an
+// existing object and is nil otherwise.
+func (self *Activity) Previous() interface{} {
+	return self.Extra["previous"]
+}
+
+func (self *Activity) SetPrevious(previous interface{}) *Activity {
+	self.Extra["previous"] = previous
+	return self
+}
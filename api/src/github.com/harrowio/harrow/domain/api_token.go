@@ -0,0 +1,253 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+const (
+	// apiTokenPrefix makes tokens recognizable, e.g. when they
+	// accidentally end up in a repository.
+	apiTokenPrefix = "hrw_"
+
+	// ApiTokenUseReportInterval is the minimum time between two
+	// "api-token.used" activities for the same token.
+	ApiTokenUseReportInterval = time.Hour
+)
+
+var (
+	apiTokenSubjectPattern = regexp.MustCompile(`^[a-z][a-z-]*$`)
+
+	// apiTokenVerbs are the verbs that can be used in the
+	// capabilities of an ApiToken.
+	apiTokenVerbs = []string{
+		CapabilityCreate,
+		CapabilityRead,
+		CapabilityUpdate,
+		CapabilityArchive,
+		CapabilityReadPrivileged,
+		"cancel",
+		"diff-scripts",
+		"save-scripts",
+	}
+)
+
+// ApiToken is a long-lived credential for automation.  A personal
+// token acts on behalf of UserUuid.  A project token (ProjectUuid is
+// set) is a service account for that project: it still acts on behalf
+// of the user who created it, but is only accepted for things
+// belonging to that project.
+//
+// A token only grants the capabilities listed in Capabilities, and
+// only if the user of the token possesses them through their roles.
+type ApiToken struct {
+	defaultSubject
+
+	Uuid         string               `json:"uuid" db:"uuid"`
+	Name         string               `json:"name" db:"name"`
+	UserUuid     string               `json:"userUuid" db:"user_uuid"`
+	ProjectUuid  *string              `json:"projectUuid" db:"project_uuid"`
+	TokenHash    string               `json:"-" db:"token_hash"`
+	TokenPrefix  string               `json:"tokenPrefix" db:"token_prefix"`
+	Capabilities ApiTokenCapabilities `json:"capabilities" db:"capabilities"`
+	ExpiresAt    *time.Time           `json:"expiresAt" db:"expires_at"`
+	LastUsedAt   *time.Time           `json:"lastUsedAt" db:"last_used_at"`
+	LastUsedFrom *string              `json:"lastUsedFrom" db:"last_used_from"`
+	RevokedAt    *time.Time           `json:"revokedAt" db:"revoked_at"`
+	CreatedAt    time.Time            `json:"createdAt" db:"created_at"`
+
+	// Token is the token in plain text.  Only its hash is stored,
+	// so it is known only right after it has been generated.
+	Token string `json:"token,omitempty" db:"-"`
+}
+
+// NewApiToken returns a new token for userUuid with a freshly
+// generated secret.
+func NewApiToken(name, userUuid string, projectUuid *string, capabilities []string, expiresAt *time.Time) *ApiToken {
+	token := &ApiToken{
+		Name:         name,
+		UserUuid:     userUuid,
+		ProjectUuid:  projectUuid,
+		Capabilities: ApiTokenCapabilities(capabilities),
+		ExpiresAt:    expiresAt,
+	}
+	token.GenerateSecret()
+	return token
+}
+
+// HashApiToken returns the hash under which token is stored.
+func HashApiToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// LooksLikeApiToken returns true if value has the format of tokens
+// generated by GenerateSecret.
+func LooksLikeApiToken(value string) bool {
+	return strings.HasPrefix(value, apiTokenPrefix)
+}
+
+func (self *ApiToken) GenerateSecret() {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		panic("ApiToken.GenerateSecret: " + err.Error())
+	}
+
+	self.Token = apiTokenPrefix + hex.EncodeToString(secret)
+	self.TokenHash = HashApiToken(self.Token)
+	self.TokenPrefix = self.Token[0 : len(apiTokenPrefix)+8]
+}
+
+func (self *ApiToken) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/api-tokens/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *ApiToken) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["user"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/users/%s", requestScheme, requestBase, self.UserUuid),
+	}
+	if self.ProjectUuid != nil {
+		response["project"] = map[string]string{
+			"href": fmt.Sprintf("%s://%s/projects/%s", requestScheme, requestBase, *self.ProjectUuid),
+		}
+	}
+	return response
+}
+
+func (self *ApiToken) AuthorizationName() string { return "api-token" }
+
+func (self *ApiToken) FindUser(store UserStore) (*User, error) {
+	return store.FindByUuid(self.UserUuid)
+}
+
+func (self *ApiToken) FindProject(store ProjectStore) (*Project, error) {
+	if self.ProjectUuid == nil {
+		return nil, new(NotFoundError)
+	}
+
+	return store.FindByUuid(*self.ProjectUuid)
+}
+
+func (self *ApiToken) OwnedBy(user *User) bool {
+	return user.Uuid == self.UserUuid
+}
+
+func (self *ApiToken) Validate() error {
+	result := NewValidationError("", "")
+
+	if strings.TrimSpace(self.Name) == "" {
+		result.Add("name", "empty")
+	}
+
+	if !uuidhelper.IsValid(self.UserUuid) {
+		result.Add("userUuid", "malformed")
+	}
+
+	if self.ProjectUuid != nil && !uuidhelper.IsValid(*self.ProjectUuid) {
+		result.Add("projectUuid", "malformed")
+	}
+
+	if len(self.Capabilities) == 0 {
+		result.Add("capabilities", "empty")
+	}
+
+	for _, capability := range self.Capabilities {
+		if !isValidApiTokenCapability(capability) {
+			result.Add("capabilities", "malformed")
+			break
+		}
+	}
+
+	if self.ExpiresAt != nil && !self.ExpiresAt.After(Clock.Now()) {
+		result.Add("expiresAt", "in_past")
+	}
+
+	return result.ToError()
+}
+
+// isValidApiTokenCapability checks that capability consists of a
+// known verb and a subject.
+func isValidApiTokenCapability(capability string) bool {
+	for _, verb := range apiTokenVerbs {
+		if strings.HasPrefix(capability, verb+"-") {
+			return apiTokenSubjectPattern.MatchString(capability[len(verb)+1:])
+		}
+	}
+
+	return false
+}
+
+func (self *ApiToken) IsExpired(now time.Time) bool {
+	return self.ExpiresAt != nil && !now.Before(*self.ExpiresAt)
+}
+
+func (self *ApiToken) IsRevoked() bool {
+	return self.RevokedAt != nil
+}
+
+func (self *ApiToken) Revoke(now time.Time) {
+	self.RevokedAt = &now
+}
+
+// Permits returns true if the token may be used to exercise
+// capability on something belonging to project, which is nil for
+// things that don't belong to any project.
+func (self *ApiToken) Permits(capability string, project *Project) bool {
+	if self.ProjectUuid != nil {
+		if project == nil || project.Uuid != *self.ProjectUuid {
+			return false
+		}
+	}
+
+	for _, granted := range self.Capabilities {
+		if granted == capability {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarkUsed records that the token has been used at now from
+// clientAddress.  The return value is true if this use should be
+// reported, which is the case for the first use and after
+// ApiTokenUseReportInterval has passed since the last use.
+func (self *ApiToken) MarkUsed(now time.Time, clientAddress string) bool {
+	report := self.LastUsedAt == nil || now.Sub(*self.LastUsedAt) >= ApiTokenUseReportInterval
+	self.LastUsedAt = &now
+	self.LastUsedFrom = &clientAddress
+	return report
+}
+
+// ApiTokenCapabilities is the list of capabilities granted by an
+// ApiToken, stored as JSON.
+type ApiTokenCapabilities []string
+
+func (self ApiTokenCapabilities) Value() (driver.Value, error) {
+	if self == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(self))
+}
+
+func (self *ApiTokenCapabilities) Scan(data interface{}) error {
+	switch raw := data.(type) {
+	case []byte:
+		return json.Unmarshal(raw, (*[]string)(self))
+	case string:
+		return json.Unmarshal([]byte(raw), (*[]string)(self))
+	default:
+		return fmt.Errorf("ApiTokenCapabilities: cannot scan from %T", data)
+	}
+}
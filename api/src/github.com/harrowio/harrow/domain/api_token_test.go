@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/clock"
+)
+
+func validApiToken() *ApiToken {
+	return NewApiToken(
+		"ci",
+		"b9f8ef10-d2f4-4a1b-8bd1-0e46d3bf3f0b",
+		nil,
+		[]string{"create-operation", "read-job"},
+		nil,
+	)
+}
+
+func TestNewApiToken_generatesTokenAndStoresOnlyItsHash(t *testing.T) {
+	token := validApiToken()
+
+	if !LooksLikeApiToken(token.Token) {
+		t.Fatalf(`token.Token = %q; want prefix %q`, token.Token, apiTokenPrefix)
+	}
+
+	if got, want := token.TokenHash, HashApiToken(token.Token); got != want {
+		t.Errorf(`token.TokenHash = %q; want %q`, got, want)
+	}
+
+	if token.TokenHash == token.Token {
+		t.Errorf("Expected token hash to differ from token")
+	}
+}
+
+func TestApiToken_Validate_rejectsUnknownVerbs(t *testing.T) {
+	token := validApiToken()
+	token.Capabilities = ApiTokenCapabilities{"destroy-project"}
+
+	err, ok := token.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`token.Validate() = %v; want *ValidationError`, token.Validate())
+	}
+
+	if got, want := err.Get("capabilities"), "malformed"; got != want {
+		t.Errorf(`err.Get("capabilities") = %q; want %q`, got, want)
+	}
+}
+
+func TestApiToken_Validate_acceptsVerbsContainingDashes(t *testing.T) {
+	token := validApiToken()
+	token.Capabilities = ApiTokenCapabilities{"save-scripts-project", "readPrivileged-secret"}
+
+	if err := token.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApiToken_Validate_requiresCapabilities(t *testing.T) {
+	token := validApiToken()
+	token.Capabilities = nil
+
+	err, ok := token.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`token.Validate() = %v; want *ValidationError`, token.Validate())
+	}
+
+	if got, want := err.Get("capabilities"), "empty"; got != want {
+		t.Errorf(`err.Get("capabilities") = %q; want %q`, got, want)
+	}
+}
+
+func TestApiToken_Validate_rejectsExpiryInThePast(t *testing.T) {
+	now := time.Date(2016, 10, 19, 12, 0, 0, 0, time.UTC)
+	Clock = clock.At(now)
+	defer func() { Clock = clock.System }()
+
+	token := validApiToken()
+	expiresAt := now.Add(-time.Minute)
+	token.ExpiresAt = &expiresAt
+
+	err, ok := token.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`token.Validate() = %v; want *ValidationError`, token.Validate())
+	}
+
+	if got, want := err.Get("expiresAt"), "in_past"; got != want {
+		t.Errorf(`err.Get("expiresAt") = %q; want %q`, got, want)
+	}
+}
+
+func TestApiToken_IsExpired(t *testing.T) {
+	now := time.Date(2016, 10, 19, 12, 0, 0, 0, time.UTC)
+	token := validApiToken()
+
+	if token.IsExpired(now) {
+		t.Errorf("Expected token without expiry not to expire")
+	}
+
+	token.ExpiresAt = &now
+	if !token.IsExpired(now) {
+		t.Errorf("Expected token to be expired at %s", now)
+	}
+}
+
+func TestApiToken_Permits_onlyListedCapabilities(t *testing.T) {
+	token := validApiToken()
+
+	if !token.Permits("read-job", nil) {
+		t.Errorf(`Expected token to permit "read-job"`)
+	}
+
+	if token.Permits("archive-job", nil) {
+		t.Errorf(`Expected token not to permit "archive-job"`)
+	}
+}
+
+func TestApiToken_Permits_projectTokensOnlyForTheirProject(t *testing.T) {
+	token := validApiToken()
+	projectUuid := "4e8a5a0d-8d5c-4a5e-9ef4-ea1e30bcf4e6"
+	token.ProjectUuid = &projectUuid
+
+	if !token.Permits("read-job", &Project{Uuid: projectUuid}) {
+		t.Errorf("Expected token to permit access to its own project")
+	}
+
+	if token.Permits("read-job", &Project{Uuid: "8c2e7d86-bd2b-4b5f-b8ae-7ed0c5f0d8e1"}) {
+		t.Errorf("Expected token not to permit access to another project")
+	}
+
+	if token.Permits("read-job", nil) {
+		t.Errorf("Expected token not to permit access outside of projects")
+	}
+}
+
+func TestApiToken_MarkUsed_reportsFirstUseAndThenOncePerInterval(t *testing.T) {
+	now := time.Date(2016, 10, 19, 12, 0, 0, 0, time.UTC)
+	token := validApiToken()
+
+	if !token.MarkUsed(now, "127.0.0.1") {
+		t.Errorf("Expected first use to be reported")
+	}
+
+	if token.MarkUsed(now.Add(time.Minute), "127.0.0.1") {
+		t.Errorf("Expected use within interval not to be reported")
+	}
+
+	if !token.MarkUsed(now.Add(time.Minute+ApiTokenUseReportInterval), "127.0.0.1") {
+		t.Errorf("Expected use after interval to be reported")
+	}
+}
+
+func TestApiTokenCapabilities_roundTrip(t *testing.T) {
+	capabilities := ApiTokenCapabilities{"read-job"}
+	value, err := capabilities.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanned := ApiTokenCapabilities{}
+	if err := scanned.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scanned) != 1 || scanned[0] != "read-job" {
+		t.Errorf(`scanned = %v; want [read-job]`, scanned)
+	}
+}
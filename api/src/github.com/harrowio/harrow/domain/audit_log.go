@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+var auditLogNamePattern = regexp.MustCompile(`^[a-z0-9.*-]+$`)
+
+// AuditLogFilter selects the activities of an organization that are
+// shown in its audit log.  Empty fields match everything.
+type AuditLogFilter struct {
+	OrganizationUuid string
+	ActorUuid        string
+	ProjectUuid      string
+
+	// NamePattern matches activity names, with "*" matching any
+	// sequence of characters, e.g. "secret.*".
+	NamePattern string
+
+	Since *time.Time
+	Until *time.Time
+}
+
+func (self *AuditLogFilter) Validate() error {
+	result := NewValidationError("", "")
+
+	if !uuidhelper.IsValid(self.OrganizationUuid) {
+		result.Add("organizationUuid", "malformed")
+	}
+
+	if self.ActorUuid != "" && !uuidhelper.IsValid(self.ActorUuid) {
+		result.Add("actor", "malformed")
+	}
+
+	if self.ProjectUuid != "" && !uuidhelper.IsValid(self.ProjectUuid) {
+		result.Add("project", "malformed")
+	}
+
+	if self.NamePattern != "" && !auditLogNamePattern.MatchString(self.NamePattern) {
+		result.Add("name", "malformed")
+	}
+
+	if self.Since != nil && self.Until != nil && self.Until.Before(*self.Since) {
+		result.Add("until", "before_since")
+	}
+
+	return result.ToError()
+}
+
+// NameLike returns NamePattern as an SQL LIKE pattern.
+func (self *AuditLogFilter) NameLike() string {
+	if self.NamePattern == "" {
+		return "%"
+	}
+
+	return strings.Replace(self.NamePattern, "*", "%", -1)
+}
+
+// AuditLogChange describes how a single field of an object changed.
+type AuditLogChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLogEntry presents an activity as part of an organization's
+// audit log.
+type AuditLogEntry struct {
+	defaultSubject
+
+	Id               int               `json:"id"`
+	Name             string            `json:"name"`
+	OccurredOn       time.Time         `json:"occurredOn"`
+	ActorUuid        *string           `json:"actorUuid"`
+	OrganizationUuid string            `json:"organizationUuid"`
+	ProjectUuid      string            `json:"projectUuid,omitempty"`
+	Payload          interface{}       `json:"payload"`
+	Changes          []*AuditLogChange `json:"changes,omitempty"`
+}
+
+// NewAuditLogEntry returns the audit log entry for activity.  If the
+// activity has recorded the previous state of its payload, the entry
+// lists the fields that have changed.
+func NewAuditLogEntry(organizationUuid string, activity *Activity) *AuditLogEntry {
+	result := &AuditLogEntry{
+		Id:               activity.Id,
+		Name:             activity.Name,
+		OccurredOn:       activity.OccurredOn,
+		ActorUuid:        activity.ContextUserUuid,
+		OrganizationUuid: organizationUuid,
+		ProjectUuid:      activity.ProjectUuid(),
+		Payload:          activity.Payload,
+	}
+
+	if previous := activity.Previous(); previous != nil {
+		result.Changes = diffAuditLogPayloads(previous, activity.Payload)
+	}
+
+	return result
+}
+
+func (self *AuditLogEntry) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/organizations/%s/audit-log", requestScheme, requestBase, self.OrganizationUuid)
+}
+
+func (self *AuditLogEntry) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["organization"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/organizations/%s", requestScheme, requestBase, self.OrganizationUuid),
+	}
+
+	if self.ActorUuid != nil {
+		response["actor"] = map[string]string{
+			"href": fmt.Sprintf("%s://%s/users/%s", requestScheme, requestBase, *self.ActorUuid),
+		}
+	}
+
+	if self.ProjectUuid != "" {
+		response["project"] = map[string]string{
+			"href": fmt.Sprintf("%s://%s/projects/%s", requestScheme, requestBase, self.ProjectUuid),
+		}
+	}
+
+	return response
+}
+
+func (self *AuditLogEntry) AuthorizationName() string { return "organization" }
+
+// AuditLogCSVHeader returns the column names of the CSV export of an
+// audit log.
+func AuditLogCSVHeader() []string {
+	return []string{"id", "occurredOn", "name", "actorUuid", "projectUuid", "changes", "payload"}
+}
+
+// CSVRecord returns this entry as a row of the CSV export.  Changes
+// and the payload are encoded as JSON.
+func (self *AuditLogEntry) CSVRecord() ([]string, error) {
+	actorUuid := ""
+	if self.ActorUuid != nil {
+		actorUuid = *self.ActorUuid
+	}
+
+	changes := ""
+	if len(self.Changes) > 0 {
+		data, err := json.Marshal(self.Changes)
+		if err != nil {
+			return nil, err
+		}
+		changes = string(data)
+	}
+
+	payload, err := json.Marshal(self.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		strconv.Itoa(self.Id),
+		self.OccurredOn.UTC().Format(time.RFC3339),
+		self.Name,
+		actorUuid,
+		self.ProjectUuid,
+		changes,
+		string(payload),
+	}, nil
+}
+
+// diffAuditLogPayloads compares the top level fields of before and
+// after, both of which need to encode as JSON objects.
+func diffAuditLogPayloads(before, after interface{}) []*AuditLogChange {
+	beforeFields, err := auditLogFields(before)
+	if err != nil {
+		return nil
+	}
+
+	afterFields, err := auditLogFields(after)
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, found := beforeFields[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	result := []*AuditLogChange{}
+	for _, name := range names {
+		if reflect.DeepEqual(beforeFields[name], afterFields[name]) {
+			continue
+		}
+
+		result = append(result, &AuditLogChange{
+			Field:  name,
+			Before: beforeFields[name],
+			After:  afterFields[name],
+		})
+	}
+
+	return result
+}
+
+func auditLogFields(payload interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "testing"
+
+func TestNewAuditLogEntry_listsChangedFields(t *testing.T) {
+	activity := NewActivity(1, "environment.edited")
+	activity.Payload = map[string]interface{}{"name": "staging", "projectUuid": "p"}
+	activity.SetPrevious(map[string]interface{}{"name": "test", "projectUuid": "p"})
+
+	entry := NewAuditLogEntry("o", activity)
+
+	if got, want := len(entry.Changes), 1; got != want {
+		t.Fatalf(`len(entry.Changes) = %d; want %d`, got, want)
+	}
+
+	change := entry.Changes[0]
+	if change.Field != "name" || change.Before != "test" || change.After != "staging" {
+		t.Errorf(`entry.Changes[0] = %#v; want name changed from "test" to "staging"`, change)
+	}
+}
+
+func TestNewAuditLogEntry_hasNoChanges_withoutPreviousState(t *testing.T) {
+	activity := NewActivity(1, "environment.added")
+	activity.Payload = map[string]interface{}{"name": "staging"}
+
+	if got := NewAuditLogEntry("o", activity).Changes; got != nil {
+		t.Errorf(`Changes = %#v; want nil`, got)
+	}
+}
+
+func TestAuditLogFilter_NameLike_translatesWildcards(t *testing.T) {
+	filter := &AuditLogFilter{NamePattern: "secret.*"}
+
+	if got, want := filter.NameLike(), "secret.%"; got != want {
+		t.Errorf(`filter.NameLike() = %q; want %q`, got, want)
+	}
+}
+
+func TestAuditLogFilter_Validate_rejectsMalformedNamePatterns(t *testing.T) {
+	filter := &AuditLogFilter{
+		OrganizationUuid: "1ac7a8c6-bd5f-4d59-8ea2-b86d8e85a3a8",
+		NamePattern:      "secret.%",
+	}
+
+	err, ok := filter.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`filter.Validate() = %v; want *ValidationError`, filter.Validate())
+	}
+
+	if got, want := err.Get("name"), "malformed"; got != want {
+		t.Errorf(`err.Get("name") = %q; want %q`, got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// BlamedCommit is a commit that went into an operation which broke a
+// job.
+type BlamedCommit struct {
+	*GitLogEntry
+
+	RepositoryUuid string `json:"repositoryUuid"`
+}
+
+// BlamedAuthor is the author of commits that went into an operation
+// which broke a job.
+type BlamedAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+
+	// User is the Harrow user with the author's email address, or
+	// nil if the author is not a Harrow user.
+	User *User `json:"-"`
+
+	Commits []*BlamedCommit `json:"commits"`
+}
+
+// Blame lists the commits that went into a job between its last
+// successful operation and the operation that broke it, and who
+// authored them.
+type Blame struct {
+	// Operation is the operation that broke the job.
+	Operation *Operation `json:"-"`
+
+	Commits []*BlamedCommit `json:"commits"`
+	Authors []*BlamedAuthor `json:"authors"`
+}
+
+// NewBlame returns the blame for the last of operations, which are
+// the operations of a job since it last succeeded, oldest first.  The
+// git logs of each operation list the commits since the operation
+// before it, so together they cover all commits since the checkouts
+// of the last successful operation.
+//
+// NewBlame returns nil unless the last operation is the first one to
+// fail since then, i.e. unless it broke the job.
+func NewBlame(operations []*Operation) *Blame {
+	if len(operations) == 0 {
+		return nil
+	}
+
+	for i, operation := range operations {
+		failed, _ := operationOutcome(operation)
+		if failed != (i == len(operations)-1) {
+			return nil
+		}
+	}
+
+	result := &Blame{
+		Operation: operations[len(operations)-1],
+		Commits:   []*BlamedCommit{},
+		Authors:   []*BlamedAuthor{},
+	}
+
+	seen := map[string]bool{}
+	authors := map[string]*BlamedAuthor{}
+	for _, operation := range operations {
+		if operation.GitLogs == nil {
+			continue
+		}
+
+		repositoryUuids := []string{}
+		for repositoryUuid := range operation.GitLogs.Repositories {
+			repositoryUuids = append(repositoryUuids, repositoryUuid)
+		}
+		sort.Strings(repositoryUuids)
+
+		for _, repositoryUuid := range repositoryUuids {
+			for _, entry := range operation.GitLogs.Repositories[repositoryUuid] {
+				if seen[entry.Commit] {
+					continue
+				}
+				seen[entry.Commit] = true
+
+				commit := &BlamedCommit{GitLogEntry: entry, RepositoryUuid: repositoryUuid}
+				result.Commits = append(result.Commits, commit)
+
+				email := strings.ToLower(strings.TrimSpace(entry.AuthorEmail))
+				if email == "" {
+					continue
+				}
+
+				author, found := authors[email]
+				if !found {
+					author = &BlamedAuthor{Name: entry.Author, Email: entry.AuthorEmail}
+					authors[email] = author
+					result.Authors = append(result.Authors, author)
+				}
+				author.Commits = append(author.Commits, commit)
+			}
+		}
+	}
+
+	return result
+}
+
+// MatchUsers looks up the Harrow users with the email addresses of
+// the authors.  Authors without a Harrow account are kept, with a nil
+// User.
+func (self *Blame) MatchUsers(users UserByEmailStore) error {
+	for _, author := range self.Authors {
+		user, err := users.FindByEmailAddress(author.Email)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		author.User = user
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "fmt"
+
+// BlameNotifierType is the type of blame notifiers, which is also the
+// name of the table they are stored in.
+const BlameNotifierType = "blame_notifiers"
+
+// BlameNotifier emails the authors of the commits that went into an
+// operation which broke a job.  Unlike the other notifiers, it has no
+// fixed recipients: they are taken from the operation's git logs.
+type BlameNotifier struct {
+	defaultSubject
+	ProjectNotifierBase
+}
+
+func (self *BlameNotifier) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/blame-notifiers/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *BlameNotifier) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{
+		"href": self.OwnUrl(requestScheme, requestBase),
+	}
+
+	return response
+}
+
+func (self *BlameNotifier) Validate() error {
+	result := NewValidationError("", "")
+	self.validateInto(result)
+	return result.ToError()
+}
+
+func (self *BlameNotifier) NotifierType() string {
+	return BlameNotifierType
+}
+
+func (self *BlameNotifier) AuthorizationName() string {
+	return "blame-notifier"
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// mockUserByEmailStore returns the same user and error for any email
+// address.
+type mockUserByEmailStore struct {
+	user *User
+	err  error
+}
+
+func (store *mockUserByEmailStore) FindByEmailAddress(email string) (*User, error) {
+	return store.user, store.err
+}
+
+func newBlameTestOperation(exitStatus int, commits ...*GitLogEntry) *Operation {
+	finishedAt := time.Date(2016, 4, 1, 12, 0, 0, 0, time.UTC)
+	logs := NewGitLogs()
+	logs.Repositories["3a7c1b9e-5d2f-4e8a-9b6c-0f1e2d3c4b5a"] = commits
+
+	return &Operation{
+		ExitStatus: exitStatus,
+		FinishedAt: &finishedAt,
+		GitLogs:    logs,
+	}
+}
+
+func TestNewBlame_collectsCommitsAndAuthorsSinceLastSuccess(t *testing.T) {
+	first := &GitLogEntry{Commit: "a1", Author: "Jane", AuthorEmail: "jane@example.com", Subject: "Change the config"}
+	second := &GitLogEntry{Commit: "b2", Author: "John", AuthorEmail: "john@example.com", Subject: "Break the build"}
+	third := &GitLogEntry{Commit: "c3", Author: "Jane", AuthorEmail: "JANE@example.com", Subject: "Fix typo"}
+
+	canceled := newBlameTestOperation(0, first)
+	canceledAt := *canceled.FinishedAt
+	canceled.CanceledAt = &canceledAt
+	failed := newBlameTestOperation(1, first, second, third)
+
+	blame := NewBlame([]*Operation{canceled, failed})
+	if blame == nil {
+		t.Fatalf("blame = nil; want a blame")
+	}
+
+	if got, want := len(blame.Commits), 3; got != want {
+		t.Fatalf("len(blame.Commits) = %d; want %d", got, want)
+	}
+
+	if got, want := len(blame.Authors), 2; got != want {
+		t.Fatalf("len(blame.Authors) = %d; want %d", got, want)
+	}
+
+	if got, want := len(blame.Authors[0].Commits), 2; got != want {
+		t.Errorf("len(blame.Authors[0].Commits) = %d; want %d", got, want)
+	}
+
+	if got, want := blame.Operation, failed; got != want {
+		t.Errorf("blame.Operation = %p; want %p", got, want)
+	}
+}
+
+func TestNewBlame_returnsNil_unlessLastOperationBrokeTheJob(t *testing.T) {
+	commit := &GitLogEntry{Commit: "a1", Author: "Jane", AuthorEmail: "jane@example.com"}
+
+	testcases := []struct {
+		name       string
+		operations []*Operation
+	}{
+		{"no operations", []*Operation{}},
+		{"succeeded", []*Operation{newBlameTestOperation(0, commit)}},
+		{"still failing", []*Operation{newBlameTestOperation(1, commit), newBlameTestOperation(1, commit)}},
+	}
+
+	for _, testcase := range testcases {
+		if blame := NewBlame(testcase.operations); blame != nil {
+			t.Errorf("%s: blame = %#v; want nil", testcase.name, blame)
+		}
+	}
+}
+
+func TestBlame_MatchUsers_keepsAuthorsWithoutAccount(t *testing.T) {
+	blame := NewBlame([]*Operation{
+		newBlameTestOperation(1, &GitLogEntry{Commit: "a1", Author: "Jane", AuthorEmail: "jane@example.com"}),
+	})
+
+	if err := blame.MatchUsers(&mockUserByEmailStore{err: &NotFoundError{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(blame.Authors), 1; got != want {
+		t.Fatalf("len(blame.Authors) = %d; want %d", got, want)
+	}
+
+	if blame.Authors[0].User != nil {
+		t.Errorf("blame.Authors[0].User = %#v; want nil", blame.Authors[0].User)
+	}
+}
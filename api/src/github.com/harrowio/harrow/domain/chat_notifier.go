@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/netguard"
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+// Types of the notifiers that post messages to an incoming webhook of
+// a chat service.
+const (
+	ChatNotifierSlack      = "slack_notifiers"
+	ChatNotifierTeams      = "teams_notifiers"
+	ChatNotifierMattermost = "mattermost_notifiers"
+	ChatNotifierDiscord    = "discord_notifiers"
+)
+
+// IsChatNotifierType returns true if notifierType names one of the
+// chat notifier types.
+func IsChatNotifierType(notifierType string) bool {
+	switch notifierType {
+	case ChatNotifierSlack, ChatNotifierTeams, ChatNotifierMattermost, ChatNotifierDiscord:
+		return true
+	}
+
+	return false
+}
+
+// ProjectNotifier is a notifier that is managed through the shared
+// chat notifier endpoints and stored in a table of its own, named
+// after its type.  Besides the chat notifiers, this includes blame
+// notifiers.
+type ProjectNotifier interface {
+	Subject
+
+	// NotifierType returns the type of the notifier, which is also
+	// the name of the table it is stored in.
+	NotifierType() string
+
+	// NotifierBase returns the fields every project notifier has.
+	NotifierBase() *ProjectNotifierBase
+
+	Validate() error
+}
+
+// NewProjectNotifier returns an empty notifier of the given type, or
+// nil if notifierType is not a project notifier type.
+func NewProjectNotifier(notifierType string) ProjectNotifier {
+	switch notifierType {
+	case ChatNotifierTeams:
+		return &TeamsNotifier{}
+	case ChatNotifierMattermost:
+		return &MattermostNotifier{}
+	case ChatNotifierDiscord:
+		return &DiscordNotifier{}
+	case BlameNotifierType:
+		return &BlameNotifier{}
+	}
+
+	return nil
+}
+
+// ProjectNotifierBase holds the fields every project notifier has.
+type ProjectNotifierBase struct {
+	Uuid        string `json:"uuid" db:"uuid"`
+	Name        string `json:"name" db:"name"`
+	UrlHost     string `json:"urlHost" db:"url_host"`
+	ProjectUuid string `json:"projectUuid" db:"project_uuid"`
+
+	ArchivedAt *time.Time `json:"archivedAt" db:"archived_at"`
+}
+
+func (self *ProjectNotifierBase) NotifierBase() *ProjectNotifierBase {
+	return self
+}
+
+func (self *ProjectNotifierBase) FindProject(projects ProjectStore) (*Project, error) {
+	return projects.FindByUuid(self.ProjectUuid)
+}
+
+func (self *ProjectNotifierBase) validateInto(result *ValidationError) {
+	if strings.TrimSpace(self.Name) == "" {
+		result.Add("name", "empty")
+	}
+
+	if strings.TrimSpace(self.UrlHost) == "" {
+		result.Add("urlHost", "empty")
+	}
+
+	if !uuidhelper.IsValid(self.ProjectUuid) {
+		result.Add("projectUuid", "malformed")
+	}
+}
+
+// ChatWebhookNotifier holds the fields of the notifiers posting
+// messages to an incoming webhook of a chat service.
+type ChatWebhookNotifier struct {
+	ProjectNotifierBase
+
+	WebhookURL string `json:"webhookURL" db:"webhook_url"`
+
+	SubjectTemplate string `json:"subjectTemplate" db:"subject_template"`
+	BodyTemplate    string `json:"bodyTemplate" db:"body_template"`
+}
+
+// NotificationTemplate returns the templates for the messages sent by
+// this notifier.  The subject is used as the title of the message.
+func (self *ChatWebhookNotifier) NotificationTemplate() *NotificationTemplate {
+	return &NotificationTemplate{
+		Subject: self.SubjectTemplate,
+		Body:    self.BodyTemplate,
+	}
+}
+
+// validateWebhookInto validates the notifier, requiring its webhook
+// URL to be an https URL on a host accepted by isProviderHost.
+func (self *ChatWebhookNotifier) validateWebhookInto(result *ValidationError, isProviderHost func(host string) bool) {
+	self.ProjectNotifierBase.validateInto(result)
+
+	if strings.TrimSpace(self.WebhookURL) == "" {
+		result.Add("webhookURL", "empty")
+	} else if target, err := url.Parse(self.WebhookURL); err != nil || target.Scheme != "https" || target.Host == "" {
+		result.Add("webhookURL", "malformed")
+	} else if !isProviderHost(strings.ToLower(target.Hostname())) {
+		result.Add("webhookURL", "unknown_host")
+	}
+
+	self.NotificationTemplate().validateInto(result)
+}
+
+// hostIn returns a function accepting the given hosts and their
+// subdomains listed with a leading dot.
+func hostIn(hosts ...string) func(host string) bool {
+	return func(host string) bool {
+		for _, allowed := range hosts {
+			if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isPublicHost accepts hosts which only resolve to public addresses.
+// It is used for services that are usually hosted by their users.
+func isPublicHost(host string) bool {
+	return netguard.CheckHost(host) == nil
+}
+
+// ChatNotifier describes a chat notifier of any type.  It is used
+// where the chat service is only known at runtime, e.g. when setting
+// up a project from a stencil.
+type ChatNotifier struct {
+	Uuid         string
+	NotifierType string
+	Name         string
+	WebhookURL   string
+	UrlHost      string
+	ProjectUuid  string
+}
+
+// Notifier returns the notifier of the described type.  It returns nil
+// for unknown notifier types.
+func (self *ChatNotifier) Notifier() interface{} {
+	chat := ChatWebhookNotifier{
+		ProjectNotifierBase: ProjectNotifierBase{Uuid: self.Uuid, Name: self.Name, UrlHost: self.UrlHost, ProjectUuid: self.ProjectUuid},
+		WebhookURL:          self.WebhookURL,
+	}
+
+	switch self.NotifierType {
+	case ChatNotifierSlack:
+		return &SlackNotifier{Uuid: self.Uuid, Name: self.Name, WebhookURL: self.WebhookURL, UrlHost: self.UrlHost, ProjectUuid: self.ProjectUuid}
+	case ChatNotifierTeams:
+		return &TeamsNotifier{ChatWebhookNotifier: chat}
+	case ChatNotifierMattermost:
+		return &MattermostNotifier{ChatWebhookNotifier: chat}
+	case ChatNotifierDiscord:
+		return &DiscordNotifier{ChatWebhookNotifier: chat}
+	}
+
+	return nil
+}
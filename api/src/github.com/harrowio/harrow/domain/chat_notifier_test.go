@@ -0,0 +1,45 @@
+package domain
+
+import "testing"
+
+func TestChatNotifier_Validate_webhookURL(t *testing.T) {
+	testcases := []struct {
+		notifierType string
+		webhookURL   string
+		want         string
+	}{
+		{ChatNotifierTeams, "https://example.webhook.office.com/webhookb2/1/IncomingWebhook/2", ""},
+		{ChatNotifierTeams, "https://outlook.office.com/webhook/1", ""},
+		{ChatNotifierTeams, "http://example.webhook.office.com/webhookb2/1", "malformed"},
+		{ChatNotifierTeams, "https://webhook.office.com.example.org/webhookb2/1", "unknown_host"},
+		{ChatNotifierTeams, "https://127.0.0.1/webhookb2/1", "unknown_host"},
+		{ChatNotifierDiscord, "https://discord.com/api/webhooks/1/token", ""},
+		{ChatNotifierDiscord, "https://canary.discord.com/api/webhooks/1/token", ""},
+		{ChatNotifierDiscord, "http://discord.com/api/webhooks/1/token", "malformed"},
+		{ChatNotifierDiscord, "https://evil-discord.com/api/webhooks/1/token", "unknown_host"},
+		{ChatNotifierMattermost, "https://93.184.215.14/hooks/xw8ntq4ypb8ydpz4f5gzmhkbko", ""},
+		{ChatNotifierMattermost, "http://93.184.215.14/hooks/xw8ntq4ypb8ydpz4f5gzmhkbko", "malformed"},
+		{ChatNotifierMattermost, "https://10.0.0.1/hooks/xw8ntq4ypb8ydpz4f5gzmhkbko", "unknown_host"},
+		{ChatNotifierMattermost, "https://169.254.169.254/latest/meta-data", "unknown_host"},
+		{ChatNotifierMattermost, "", "empty"},
+	}
+
+	for _, testcase := range testcases {
+		notifier := (&ChatNotifier{
+			NotifierType: testcase.notifierType,
+			Name:         "chat",
+			WebhookURL:   testcase.webhookURL,
+			UrlHost:      "www.app.harrow.io",
+			ProjectUuid:  "c4c2d5f3-ec49-4e28-ba4d-fe1b8a8c8b71",
+		}).Notifier().(ProjectNotifier)
+
+		got := ""
+		if err, ok := notifier.Validate().(*ValidationError); ok {
+			got = err.Get("webhookURL")
+		}
+
+		if got != testcase.want {
+			t.Errorf("%s %q: webhookURL = %q; want %q", testcase.notifierType, testcase.webhookURL, got, testcase.want)
+		}
+	}
+}
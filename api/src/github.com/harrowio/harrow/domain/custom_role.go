@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+// CustomRole is a named set of capabilities defined by an
+// organization.  Custom roles are assigned to project memberships and
+// grant their capabilities in addition to the capabilities of the
+// membership type.  A deployer for example can be a guest with a
+// custom role granting "create-schedule" and "read-secret".
+//
+// Only capabilities a project owner has can be granted, so custom
+// roles never extend beyond a single project.
+type CustomRole struct {
+	defaultSubject
+
+	Uuid             string                 `json:"uuid" db:"uuid"`
+	OrganizationUuid string                 `json:"organizationUuid" db:"organization_uuid"`
+	Name             string                 `json:"name" db:"name"`
+	Description      string                 `json:"description" db:"description"`
+	Grants           CustomRoleCapabilities `json:"capabilities" db:"capabilities"`
+
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	ArchivedAt *time.Time `json:"archivedAt" db:"archived_at"`
+}
+
+// AssignableCapabilities returns all capabilities that can be granted
+// by a custom role.
+func AssignableCapabilities() []string {
+	result := make([]string, len(projectMemberOwnerCapabilities))
+	copy(result, projectMemberOwnerCapabilities)
+	sort.Strings(result)
+	return result
+}
+
+func (self *CustomRole) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/custom-roles/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *CustomRole) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["organization"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/organizations/%s", requestScheme, requestBase, self.OrganizationUuid),
+	}
+	return response
+}
+
+func (self *CustomRole) AuthorizationName() string { return "custom-role" }
+
+func (self *CustomRole) FindOrganization(store OrganizationStore) (*Organization, error) {
+	return store.FindByUuid(self.OrganizationUuid)
+}
+
+// Capabilities satisfies authz.Role by returning the capabilities
+// granted by this role.
+func (self *CustomRole) Capabilities() []string {
+	if self.ArchivedAt != nil {
+		return []string{}
+	}
+
+	return []string(self.Grants)
+}
+
+func (self *CustomRole) Validate() error {
+	result := NewValidationError("", "")
+
+	if !uuidhelper.IsValid(self.OrganizationUuid) {
+		result.Add("organizationUuid", "malformed")
+	}
+
+	if strings.TrimSpace(self.Name) == "" {
+		result.Add("name", "empty")
+	}
+
+	if len(self.Grants) == 0 {
+		result.Add("capabilities", "empty")
+	}
+
+	assignable := map[string]bool{}
+	for _, capability := range projectMemberOwnerCapabilities {
+		assignable[capability] = true
+	}
+
+	for _, capability := range self.Grants {
+		if !assignable[capability] {
+			result.Add("capabilities", "not_assignable")
+			break
+		}
+	}
+
+	return result.ToError()
+}
+
+// CustomRoleCapabilities is the list of capabilities granted by a
+// CustomRole, stored as JSON.
+type CustomRoleCapabilities []string
+
+func (self CustomRoleCapabilities) Value() (driver.Value, error) {
+	if self == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(self))
+}
+
+func (self *CustomRoleCapabilities) Scan(data interface{}) error {
+	switch raw := data.(type) {
+	case []byte:
+		return json.Unmarshal(raw, (*[]string)(self))
+	case string:
+		return json.Unmarshal([]byte(raw), (*[]string)(self))
+	default:
+		return fmt.Errorf("CustomRoleCapabilities: cannot scan from %T", data)
+	}
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func validCustomRole() *CustomRole {
+	return &CustomRole{
+		Uuid:             "5a0b6f0e-3f4c-4cf2-9f6b-4fb8a4f0c2d1",
+		OrganizationUuid: "1ac7a8c6-bd5f-4d59-8ea2-b86d8e85a3a8",
+		Name:             "Deployer",
+		Grants:           CustomRoleCapabilities{"create-schedule", "read-secret"},
+	}
+}
+
+func TestCustomRole_Validate_acceptsValidRole(t *testing.T) {
+	if err := validCustomRole().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCustomRole_Validate_rejectsCapabilitiesBeyondProjectOwner(t *testing.T) {
+	role := validCustomRole()
+	role.Grants = append(role.Grants, "archive-organization")
+
+	err, ok := role.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`role.Validate() = %v; want *ValidationError`, role.Validate())
+	}
+
+	if got, want := err.Get("capabilities"), "not_assignable"; got != want {
+		t.Errorf(`err.Get("capabilities") = %q; want %q`, got, want)
+	}
+}
+
+func TestCustomRole_Capabilities_isEmptyIfArchived(t *testing.T) {
+	role := validCustomRole()
+	now := time.Now()
+	role.ArchivedAt = &now
+
+	if got, want := len(role.Capabilities()), 0; got != want {
+		t.Errorf(`len(role.Capabilities()) = %d; want %d`, got, want)
+	}
+}
+
+func Test_ProjectMember_AssignCustomRole(t *testing.T) {
+	project := &Project{OrganizationUuid: validCustomRole().OrganizationUuid}
+	otherProject := &Project{OrganizationUuid: "0a6a8a0c-58a4-4b4e-8b41-9d7f5b2b8a11"}
+
+	testcases := []struct {
+		assignerMembershipType string
+		project                *Project
+		err                    error
+	}{
+		{MembershipTypeOwner, project, nil},
+		{MembershipTypeManager, project, nil},
+		{MembershipTypeMember, project, NewValidationError("membershipType", "too_low")},
+		{MembershipTypeManager, otherProject, NewValidationError("customRoleUuid", "invalid")},
+	}
+
+	for _, test := range testcases {
+		role := validCustomRole()
+		assigner := &ProjectMember{MembershipType: test.assignerMembershipType}
+		assignee := &ProjectMember{MembershipType: MembershipTypeGuest}
+
+		err := assigner.AssignCustomRole(assignee, test.project, role)
+
+		if got, want := fmt.Sprintf("%s", err), fmt.Sprintf("%s", test.err); got != want {
+			t.Errorf("err = %q; want %q", got, want)
+			continue
+		}
+
+		if test.err == nil && (assignee.CustomRoleUuid == nil || *assignee.CustomRoleUuid != role.Uuid) {
+			t.Errorf("assignee.CustomRoleUuid = %v; want %q", assignee.CustomRoleUuid, role.Uuid)
+		}
+	}
+}
+
+func Test_ProjectMember_AssignCustomRole_rejectsSelfAssignment(t *testing.T) {
+	project := &Project{OrganizationUuid: validCustomRole().OrganizationUuid}
+	user := &User{Uuid: "8a8ab9bd-4a34-4a0b-9ca6-5df4b8e6f7a1"}
+	assigner := &ProjectMember{User: user, MembershipType: MembershipTypeOwner}
+	assignee := &ProjectMember{User: user, MembershipType: MembershipTypeOwner}
+
+	err := assigner.AssignCustomRole(assignee, project, validCustomRole())
+	if got, want := fmt.Sprintf("%s", err), fmt.Sprintf("%s", NewValidationError("customRoleUuid", "self_assignment")); got != want {
+		t.Errorf("err = %q; want %q", got, want)
+	}
+}
+
+func Test_ProjectMember_AssignCustomRole_rejectsCapabilitiesBeyondAssigner(t *testing.T) {
+	project := &Project{OrganizationUuid: validCustomRole().OrganizationUuid}
+	role := validCustomRole()
+	role.Grants = append(role.Grants, "archive-project")
+
+	testcases := []struct {
+		assignerMembershipType string
+		err                    error
+	}{
+		{MembershipTypeOwner, nil},
+		{MembershipTypeManager, NewValidationError("customRoleUuid", "not_assignable")},
+	}
+
+	for _, test := range testcases {
+		assigner := &ProjectMember{MembershipType: test.assignerMembershipType}
+		assignee := &ProjectMember{MembershipType: MembershipTypeGuest}
+
+		err := assigner.AssignCustomRole(assignee, project, role)
+		if got, want := fmt.Sprintf("%s", err), fmt.Sprintf("%s", test.err); got != want {
+			t.Errorf("%s: err = %q; want %q", test.assignerMembershipType, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+const (
+	// DeferredDeliveryMail is a mail prepared by the
+	// mail-dispatcher, stored as JSON.
+	DeferredDeliveryMail = "mail"
+
+	// DeferredDeliveryNotification is a notification for an
+	// activity matched by a notification rule.
+	DeferredDeliveryNotification = "notification"
+)
+
+// DeferredDelivery is a notification for a user that is held back
+// until the user's quiet hours are over.
+type DeferredDelivery struct {
+	Uuid     string `json:"uuid"     db:"uuid"`
+	UserUuid string `json:"userUuid" db:"user_uuid"`
+
+	// Kind is either DeferredDeliveryMail or
+	// DeferredDeliveryNotification and determines what is stored
+	// in Payload.
+	Kind    string `json:"kind"    db:"kind"`
+	Payload []byte `json:"payload" db:"payload"`
+
+	DeliverAt   time.Time  `json:"deliverAt"   db:"deliver_at"`
+	CreatedAt   time.Time  `json:"createdAt"   db:"created_at"`
+	DeliveredAt *time.Time `json:"deliveredAt" db:"delivered_at"`
+}
+
+// NewDeferredDelivery holds back payload for the user identified by
+// userUuid until deliverAt.
+func NewDeferredDelivery(userUuid, kind string, payload []byte, deliverAt time.Time) *DeferredDelivery {
+	return &DeferredDelivery{
+		Uuid:      uuidhelper.MustNewV4(),
+		UserUuid:  userUuid,
+		Kind:      kind,
+		Payload:   payload,
+		DeliverAt: deliverAt,
+		CreatedAt: Clock.Now(),
+	}
+}
+
+// DeferredNotification is the payload of a deferred delivery of kind
+// DeferredDeliveryNotification.
+type DeferredNotification struct {
+	NotificationRuleUuid string `json:"notificationRuleUuid"`
+	ActivityId           int    `json:"activityId"`
+}
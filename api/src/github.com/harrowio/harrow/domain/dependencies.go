@@ -26,6 +26,12 @@ type UserStore interface {
 	FindAllSubscribers(watchableId, event string) ([]*User, error)
 }
 
+// UserByEmailStore defines the operations that are necessary for the
+// domain to match email addresses, e.g. of commit authors, to users.
+type UserByEmailStore interface {
+	FindByEmailAddress(email string) (*User, error)
+}
+
 // OrganizationStore defines all the operations that are necessary for
 // the domain to fetch associated organization objects.
 type OrganizationStore interface {
@@ -43,6 +49,7 @@ type EnvironmentStore interface {
 // objects to fetch associated secret objects
 type SecretStore interface {
 	FindAllByEnvironmentUuid(environmentUuid string) ([]*Secret, error)
+	FindAllSharedWithEnvironmentUuid(environmentUuid string) ([]*Secret, error)
 }
 
 // WorkspaceBaseImageStore defines all the operations that are necessary
@@ -81,6 +88,8 @@ type SubscriptionStore interface {
 	Create(subscription *Subscription) (string, error)
 	Find(watchableId, event, userUuid string) (*Subscription, error)
 	FindEventsForUser(watchableId, userUuid string) ([]string, error)
+	FindDeliveryForUser(watchableId, userUuid string) (string, error)
+	UpdateDelivery(watchableId, userUuid, delivery string) error
 	Delete(subscriptionUuid string) error
 }
 
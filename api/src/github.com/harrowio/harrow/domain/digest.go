@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// Limits of the lists included in a digest.
+const (
+	DigestSlowestJobs    = 5
+	DigestNewestFailures = 10
+)
+
+// Digest summarizes the operations of a project's jobs that finished
+// during a period, for subscribers who do not want to be notified
+// about every single operation.
+type Digest struct {
+	Delivery string
+	Project  *Project
+	From     time.Time
+	To       time.Time
+
+	Succeeded int
+	Failed    int
+
+	// SlowestJobs are the jobs with the longest average run time,
+	// slowest first.
+	SlowestJobs []*DigestJob
+
+	// NewestFailures are the operations that failed, most recent
+	// first.
+	NewestFailures []*DigestFailure
+
+	jobs map[string]*DigestJob
+}
+
+// DigestJob holds the operations of a single job in a digest.
+type DigestJob struct {
+	Job             *Job
+	Operations      int
+	TotalDuration   time.Duration
+	AverageDuration time.Duration
+}
+
+// DigestFailure is a failed operation in a digest.
+type DigestFailure struct {
+	Job       *Job
+	Operation *Operation
+}
+
+// FailedAt returns the time at which the operation failed.  Operations
+// that timed out or failed fatally do not necessarily have finished.
+func (self *DigestFailure) FailedAt() time.Time {
+	for _, at := range []*time.Time{
+		self.Operation.FinishedAt,
+		self.Operation.TimedOutAt,
+		self.Operation.FailedAt,
+		self.Operation.CreatedAt,
+	} {
+		if at != nil {
+			return *at
+		}
+	}
+
+	return time.Time{}
+}
+
+func NewDigest(delivery string, project *Project, from, to time.Time) *Digest {
+	return &Digest{
+		Delivery:       delivery,
+		Project:        project,
+		From:           from,
+		To:             to,
+		SlowestJobs:    []*DigestJob{},
+		NewestFailures: []*DigestFailure{},
+		jobs:           map[string]*DigestJob{},
+	}
+}
+
+// Add adds an operation of job to the digest.  Operations that have
+// not finished or have been canceled are ignored.
+func (self *Digest) Add(job *Job, operation *Operation) {
+	switch operation.Status() {
+	case "success":
+		self.Succeeded++
+	case "failure", "timeout", "fatal":
+		self.Failed++
+		self.NewestFailures = append(self.NewestFailures, &DigestFailure{
+			Job:       job,
+			Operation: operation,
+		})
+	default:
+		return
+	}
+
+	if operation.StartedAt == nil || operation.FinishedAt == nil {
+		return
+	}
+
+	entry, found := self.jobs[job.Uuid]
+	if !found {
+		entry = &DigestJob{Job: job}
+		self.jobs[job.Uuid] = entry
+	}
+
+	entry.Operations++
+	entry.TotalDuration += operation.FinishedAt.Sub(*operation.StartedAt)
+	entry.AverageDuration = entry.TotalDuration / time.Duration(entry.Operations)
+}
+
+// Summarize ranks the jobs and failures added to the digest so far
+// and limits them to DigestSlowestJobs and DigestNewestFailures
+// entries.
+func (self *Digest) Summarize() {
+	self.SlowestJobs = []*DigestJob{}
+	for _, job := range self.jobs {
+		self.SlowestJobs = append(self.SlowestJobs, job)
+	}
+
+	sort.Sort(digestJobsBySlowest(self.SlowestJobs))
+	if len(self.SlowestJobs) > DigestSlowestJobs {
+		self.SlowestJobs = self.SlowestJobs[:DigestSlowestJobs]
+	}
+
+	sort.Stable(digestFailuresByNewest(self.NewestFailures))
+	if len(self.NewestFailures) > DigestNewestFailures {
+		self.NewestFailures = self.NewestFailures[:DigestNewestFailures]
+	}
+}
+
+// IsEmpty returns true if no finished operations have been added to
+// the digest.
+func (self *Digest) IsEmpty() bool {
+	return self.Succeeded+self.Failed == 0
+}
+
+type digestJobsBySlowest []*DigestJob
+
+func (self digestJobsBySlowest) Len() int      { return len(self) }
+func (self digestJobsBySlowest) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+func (self digestJobsBySlowest) Less(i, j int) bool {
+	if self[i].AverageDuration == self[j].AverageDuration {
+		return self[i].Job.Name < self[j].Job.Name
+	}
+	return self[i].AverageDuration > self[j].AverageDuration
+}
+
+type digestFailuresByNewest []*DigestFailure
+
+func (self digestFailuresByNewest) Len() int      { return len(self) }
+func (self digestFailuresByNewest) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+func (self digestFailuresByNewest) Less(i, j int) bool {
+	return self[i].FailedAt().After(self[j].FailedAt())
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func digestTestOperation(startedAt time.Time, duration time.Duration, exitStatus int) *Operation {
+	finishedAt := startedAt.Add(duration)
+	return &Operation{
+		Uuid:       "f4ae7c55-0ec4-4bc5-9e9b-0cb7a8b9fb3e",
+		StartedAt:  &startedAt,
+		FinishedAt: &finishedAt,
+		ExitStatus: exitStatus,
+	}
+}
+
+func TestDigest_Add_countsSucceededAndFailedOperations(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	job := &Job{Uuid: "3b0b2d47-8b0c-43d8-a0b4-7e8ab2b3df39", Name: "test"}
+	digest := NewDigest(SubscriptionDeliveryDaily, &Project{}, now.Add(-24*time.Hour), now)
+
+	digest.Add(job, digestTestOperation(now.Add(-3*time.Hour), time.Minute, 0))
+	digest.Add(job, digestTestOperation(now.Add(-2*time.Hour), time.Minute, 1))
+	digest.Add(job, digestTestOperation(now.Add(-1*time.Hour), time.Minute, 0))
+	digest.Add(job, &Operation{StartedAt: &now})
+
+	if got, want := digest.Succeeded, 2; got != want {
+		t.Errorf("digest.Succeeded = %d; want %d", got, want)
+	}
+
+	if got, want := digest.Failed, 1; got != want {
+		t.Errorf("digest.Failed = %d; want %d", got, want)
+	}
+}
+
+func TestDigest_Summarize_ranksJobsByAverageDuration(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	fast := &Job{Uuid: "3b0b2d47-8b0c-43d8-a0b4-7e8ab2b3df39", Name: "lint"}
+	slow := &Job{Uuid: "9f7f0e26-0b5e-4b8c-b1ce-6c3bb9e3a2d1", Name: "deploy"}
+	digest := NewDigest(SubscriptionDeliveryDaily, &Project{}, now.Add(-24*time.Hour), now)
+
+	digest.Add(fast, digestTestOperation(now.Add(-3*time.Hour), 1*time.Minute, 0))
+	digest.Add(slow, digestTestOperation(now.Add(-3*time.Hour), 10*time.Minute, 0))
+	digest.Add(slow, digestTestOperation(now.Add(-2*time.Hour), 20*time.Minute, 0))
+	digest.Summarize()
+
+	if got, want := len(digest.SlowestJobs), 2; got != want {
+		t.Fatalf("len(digest.SlowestJobs) = %d; want %d", got, want)
+	}
+
+	if got, want := digest.SlowestJobs[0].Job, slow; got != want {
+		t.Errorf("digest.SlowestJobs[0].Job = %q; want %q", got.Name, want.Name)
+	}
+
+	if got, want := digest.SlowestJobs[0].AverageDuration, 15*time.Minute; got != want {
+		t.Errorf("digest.SlowestJobs[0].AverageDuration = %s; want %s", got, want)
+	}
+}
+
+func TestDigest_Summarize_limitsFailuresToTheNewestOnes(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	job := &Job{Uuid: "3b0b2d47-8b0c-43d8-a0b4-7e8ab2b3df39", Name: "test"}
+	digest := NewDigest(SubscriptionDeliveryWeekly, &Project{}, now.Add(-7*24*time.Hour), now)
+
+	for i := 0; i < DigestNewestFailures+2; i++ {
+		digest.Add(job, digestTestOperation(now.Add(-time.Duration(i)*time.Hour), time.Minute, 1))
+	}
+	digest.Summarize()
+
+	if got, want := len(digest.NewestFailures), DigestNewestFailures; got != want {
+		t.Fatalf("len(digest.NewestFailures) = %d; want %d", got, want)
+	}
+
+	if got, want := digest.NewestFailures[0].FailedAt(), now.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("digest.NewestFailures[0].FailedAt() = %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "fmt"
+
+// DiscordNotifier posts messages to an incoming webhook of a Discord channel.
+type DiscordNotifier struct {
+	defaultSubject
+	ChatWebhookNotifier
+}
+
+func (self *DiscordNotifier) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/discord-notifiers/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *DiscordNotifier) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{
+		"href": self.OwnUrl(requestScheme, requestBase),
+	}
+
+	return response
+}
+
+func (self *DiscordNotifier) Validate() error {
+	result := NewValidationError("", "")
+	self.validateWebhookInto(result, hostIn("discord.com", "discordapp.com", "ptb.discord.com", "canary.discord.com"))
+	return result.ToError()
+}
+
+func (self *DiscordNotifier) NotifierType() string {
+	return ChatNotifierDiscord
+}
+
+func (self *DiscordNotifier) AuthorizationName() string {
+	return "discord-notifier"
+}
@@ -16,6 +16,9 @@ type EmailNotifier struct {
 	ProjectUuid *string `json:"projectUuid" db:"project_uuid"`
 	UrlHost     string  `json:"urlHost" db:"url_host"`
 
+	SubjectTemplate string `json:"subjectTemplate" db:"subject_template"`
+	BodyTemplate    string `json:"bodyTemplate" db:"body_template"`
+
 	ArchivedAt *time.Time `json:"archivedAt" db:"archived_at"`
 }
 
@@ -58,5 +61,16 @@ func (self *EmailNotifier) Validate() error {
 		result.Add("projectUuid", "malformed")
 	}
 
+	self.NotificationTemplate().validateInto(result)
+
 	return result.ToError()
 }
+
+// NotificationTemplate returns the templates for the emails sent by
+// this notifier.
+func (self *EmailNotifier) NotificationTemplate() *NotificationTemplate {
+	return &NotificationTemplate{
+		Subject: self.SubjectTemplate,
+		Body:    self.BodyTemplate,
+	}
+}
@@ -182,3 +182,20 @@ func (self *Environment) NewSecret(name string, kind SecretType) *Secret {
 		Status:          SecretPending,
 	}
 }
+
+// Secrets returns the secrets available in this environment: its own
+// secrets and the ones shared by its project and organization, resolved
+// according to their override policies.
+func (self *Environment) Secrets(store SecretStore) ([]*Secret, error) {
+	own, err := store.FindAllByEnvironmentUuid(self.Uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := store.FindAllSharedWithEnvironmentUuid(self.Uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	return ResolveSecrets(append(own, shared...)), nil
+}
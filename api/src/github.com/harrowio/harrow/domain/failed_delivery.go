@@ -0,0 +1,216 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+const (
+	FailedDeliverySourceNotifier       = "notifier"
+	FailedDeliverySourceMailDispatcher = "mail-dispatcher"
+)
+
+const (
+	// FailedDeliveryKindMessage is a bus message about an
+	// activity that could not be handled.  The payload is a
+	// FailedMessage.
+	FailedDeliveryKindMessage = "message"
+
+	// FailedDeliveryKindNotification is a notification that could
+	// not be sent.  The payload is a DeferredNotification.
+	FailedDeliveryKindNotification = "notification"
+)
+
+const (
+	// FailedDeliveryRetrying is a delivery that is tried again at
+	// NextAttemptAt.
+	FailedDeliveryRetrying = "retrying"
+
+	// FailedDeliveryDead is a delivery that has been given up on.
+	// Dead deliveries are kept until they are replayed.
+	FailedDeliveryDead = "dead"
+
+	// FailedDeliveryReplaying is a dead delivery an administrator
+	// asked to be tried again.  Once handed back to its worker, it
+	// is FailedDeliveryReplayed.  Replays are handled like new
+	// deliveries, so failing again records a new failed delivery.
+	FailedDeliveryReplaying = "replaying"
+	FailedDeliveryReplayed  = "replayed"
+
+	// FailedDeliveryDelivered is a delivery that succeeded after
+	// failing at first.
+	FailedDeliveryDelivered = "delivered"
+)
+
+const (
+	// FailedDeliveryMaxAttempts is how often a delivery is tried
+	// before it is moved to the dead letters.
+	FailedDeliveryMaxAttempts = 5
+
+	// FailedDeliveryBackoff is the time to wait after the first
+	// failed attempt.  It doubles with every further attempt.
+	FailedDeliveryBackoff = 10 * time.Second
+)
+
+// FailedDelivery records something that the notifier or the
+// mail-dispatcher could not deliver, across all attempts.
+type FailedDelivery struct {
+	defaultSubject
+
+	Uuid   string `json:"uuid"   db:"uuid"`
+	Source string `json:"source" db:"source"`
+	Kind   string `json:"kind"   db:"kind"`
+
+	// Key identifies the delivery across attempts, e.g.
+	// "activities:42".
+	Key     string          `json:"key"     db:"key"`
+	Payload json.RawMessage `json:"payload" db:"payload"`
+
+	Status        string     `json:"status"        db:"status"`
+	Attempts      int        `json:"attempts"      db:"attempts"`
+	LastError     string     `json:"lastError"     db:"last_error"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt" db:"next_attempt_at"`
+
+	CreatedAt  time.Time  `json:"createdAt"  db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt"  db:"updated_at"`
+	DeadAt     *time.Time `json:"deadAt"     db:"dead_at"`
+	ReplayedAt *time.Time `json:"replayedAt" db:"replayed_at"`
+}
+
+// FailedMessage is the payload of failed deliveries of kind
+// FailedDeliveryKindMessage.
+type FailedMessage struct {
+	Table string `json:"table"`
+	Id    string `json:"id"`
+}
+
+func NewFailedDelivery(source, kind, key string, payload interface{}) (*FailedDelivery, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := Clock.Now()
+	return &FailedDelivery{
+		Uuid:      uuidhelper.MustNewV4(),
+		Source:    source,
+		Kind:      kind,
+		Key:       key,
+		Payload:   data,
+		Status:    FailedDeliveryRetrying,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func (self *FailedDelivery) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/admin/failed-deliveries/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *FailedDelivery) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["replay"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase) + "/replay"}
+
+	return response
+}
+
+// RecordAttempt records that trying to deliver failed with err.  The
+// delivery is scheduled to be tried again with exponential backoff,
+// unless maxAttempts have been made, in which case it is dead.
+func (self *FailedDelivery) RecordAttempt(err error, maxAttempts int) {
+	now := Clock.Now()
+	self.Attempts++
+	self.LastError = err.Error()
+	self.UpdatedAt = now
+
+	if self.Attempts >= maxAttempts {
+		self.GiveUp(self.Attempts, err)
+		return
+	}
+
+	next := now.Add(self.Backoff())
+	self.Status = FailedDeliveryRetrying
+	self.NextAttemptAt = &next
+}
+
+// Backoff returns how long to wait before the next attempt.
+func (self *FailedDelivery) Backoff() time.Duration {
+	backoff := FailedDeliveryBackoff
+	for i := 1; i < self.Attempts; i++ {
+		backoff *= 2
+	}
+
+	return backoff
+}
+
+// GiveUp moves the delivery to the dead letters after attempts
+// failed, the last one with err.  Deliveries that cannot succeed,
+// e.g. because their payload is malformed, are given up on right
+// away.
+func (self *FailedDelivery) GiveUp(attempts int, err error) {
+	now := Clock.Now()
+	self.Attempts = attempts
+	self.LastError = err.Error()
+	self.Status = FailedDeliveryDead
+	self.NextAttemptAt = nil
+	self.DeadAt = &now
+	self.UpdatedAt = now
+}
+
+// Delivered marks the delivery as having succeeded eventually.
+func (self *FailedDelivery) Delivered() {
+	self.Status = FailedDeliveryDelivered
+	self.NextAttemptAt = nil
+	self.UpdatedAt = Clock.Now()
+}
+
+// IsDead returns true if the delivery has been given up on.
+func (self *FailedDelivery) IsDead() bool {
+	return self.Status == FailedDeliveryDead
+}
+
+// Replay asks for a dead delivery to be tried again.
+func (self *FailedDelivery) Replay() error {
+	if !self.IsDead() {
+		return NewValidationError("status", "not_dead")
+	}
+
+	now := Clock.Now()
+	self.Status = FailedDeliveryReplaying
+	self.ReplayedAt = &now
+	self.UpdatedAt = now
+
+	return nil
+}
+
+// Replayed marks the delivery as handed back to its worker.
+func (self *FailedDelivery) Replayed() {
+	self.Status = FailedDeliveryReplayed
+	self.UpdatedAt = Clock.Now()
+}
+
+// IsFailedDeliveryStatus returns true if status is a valid status of a
+// failed delivery.
+func IsFailedDeliveryStatus(status string) bool {
+	switch status {
+	case FailedDeliveryRetrying, FailedDeliveryDead, FailedDeliveryReplaying, FailedDeliveryReplayed, FailedDeliveryDelivered:
+		return true
+	}
+
+	return false
+}
+
+// MessageKey returns the key identifying failed deliveries of the bus
+// message referring to the row identified by id in table.
+func MessageKey(table, id string) string {
+	return table + ":" + id
+}
+
+// NotificationKey returns the key identifying failed deliveries of the
+// notification for activity matched by rule.
+func NotificationKey(rule *NotificationRule, activity *Activity) string {
+	return fmt.Sprintf("notification-rule:%s:activity:%d", rule.Uuid, activity.Id)
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestFailedDelivery(t *testing.T) *FailedDelivery {
+	failed, err := NewFailedDelivery(
+		FailedDeliverySourceMailDispatcher,
+		FailedDeliveryKindMessage,
+		MessageKey("activities", "42"),
+		&FailedMessage{Table: "activities", Id: "42"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return failed
+}
+
+func TestFailedDelivery_RecordAttempt_backsOffExponentially(t *testing.T) {
+	failed := newTestFailedDelivery(t)
+	cause := errors.New("connection refused")
+
+	failed.RecordAttempt(cause, FailedDeliveryMaxAttempts)
+	if got, want := failed.Backoff(), FailedDeliveryBackoff; got != want {
+		t.Errorf("failed.Backoff() = %s; want %s", got, want)
+	}
+
+	failed.RecordAttempt(cause, FailedDeliveryMaxAttempts)
+	if got, want := failed.Backoff(), 2*FailedDeliveryBackoff; got != want {
+		t.Errorf("failed.Backoff() = %s; want %s", got, want)
+	}
+
+	if got, want := failed.Status, FailedDeliveryRetrying; got != want {
+		t.Errorf("failed.Status = %q; want %q", got, want)
+	}
+
+	if failed.NextAttemptAt == nil {
+		t.Errorf("failed.NextAttemptAt = nil; want a time")
+	}
+}
+
+func TestFailedDelivery_RecordAttempt_movesDeliveryToDeadLetters_afterMaxAttempts(t *testing.T) {
+	failed := newTestFailedDelivery(t)
+
+	for i := 0; i < FailedDeliveryMaxAttempts; i++ {
+		failed.RecordAttempt(errors.New("connection refused"), FailedDeliveryMaxAttempts)
+	}
+
+	if !failed.IsDead() {
+		t.Fatalf("failed.Status = %q; want %q", failed.Status, FailedDeliveryDead)
+	}
+
+	if failed.DeadAt == nil {
+		t.Errorf("failed.DeadAt = nil; want a time")
+	}
+
+	if failed.NextAttemptAt != nil {
+		t.Errorf("failed.NextAttemptAt = %v; want nil", failed.NextAttemptAt)
+	}
+
+	if got, want := failed.LastError, "connection refused"; got != want {
+		t.Errorf("failed.LastError = %q; want %q", got, want)
+	}
+}
+
+func TestFailedDelivery_Replay_failsUnlessDeliveryIsDead(t *testing.T) {
+	failed := newTestFailedDelivery(t)
+
+	err := failed.Replay()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("err.(type) = %T; want %T", err, verr)
+	}
+
+	if got, want := verr.Get("status"), "not_dead"; got != want {
+		t.Errorf(`verr.Get("status") = %q; want %q`, got, want)
+	}
+
+	failed.GiveUp(1, errors.New("malformed payload"))
+	if err := failed.Replay(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := failed.Status, FailedDeliveryReplaying; got != want {
+		t.Errorf("failed.Status = %q; want %q", got, want)
+	}
+}
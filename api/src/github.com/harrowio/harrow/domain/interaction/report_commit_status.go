@@ -0,0 +1,254 @@
+package interaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/git"
+	"github.com/harrowio/harrow/logger"
+)
+
+// States of a commit status.  These are the states understood by
+// GitHub, other providers get them translated to their own.
+const (
+	CommitStatusPending = "pending"
+	CommitStatusSuccess = "success"
+	CommitStatusFailure = "failure"
+	CommitStatusError   = "error"
+)
+
+// commitStatusContext is the name under which statuses show up next
+// to a commit.
+const commitStatusContext = "Harrow"
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type CommitStatusTokenFinder interface {
+	FindCommitStatusToken(repositoryUUID string) (*domain.TokenRepositoryCredential, error)
+}
+
+type RepositoryFinder interface {
+	FindRepository(repositoryUUID string) (*domain.Repository, error)
+}
+
+// ReportCommitStatus reports the state of a build to the service
+// hosting the repository that was built.  The service is chosen by the
+// host of the repository's URL, unless the repository's token says
+// otherwise.
+type ReportCommitStatus struct {
+	httpClient   HTTPClient
+	tokens       CommitStatusTokenFinder
+	repositories RepositoryFinder
+	log          logger.Logger
+
+	CommitRef      string
+	RepositoryUUID string
+	State          string
+	TargetURL      string
+}
+
+func NewReportCommitStatus(repositoryUUID string, commitRef string, state string, targetURL string, log logger.Logger, httpClient HTTPClient, tokens CommitStatusTokenFinder, repositories RepositoryFinder) *ReportCommitStatus {
+	return &ReportCommitStatus{
+		httpClient:   httpClient,
+		tokens:       tokens,
+		repositories: repositories,
+		log:          log,
+
+		RepositoryUUID: repositoryUUID,
+		CommitRef:      commitRef,
+		State:          state,
+		TargetURL:      targetURL,
+	}
+}
+
+func (self *ReportCommitStatus) Execute() error {
+	token, err := self.tokens.FindCommitStatusToken(self.RepositoryUUID)
+	if err != nil {
+		if domain.IsNotFound(err) {
+			return fmt.Errorf("No token for repository %s", self.RepositoryUUID)
+		}
+		return err
+	}
+
+	repository, err := self.repositories.FindRepository(self.RepositoryUUID)
+	if err != nil {
+		if domain.IsNotFound(err) {
+			return fmt.Errorf("No such repository: %s", self.RepositoryUUID)
+		}
+		return err
+	}
+
+	request, err := self.createStatusRequest(token, repository)
+	if err != nil {
+		return err
+	}
+	self.log.Info().Msgf("repository %s %s %s", self.RepositoryUUID, request.Method, request.URL.Path)
+	response, err := self.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("Error %d (%s):\n%s\n", response.StatusCode, response.Status, body)
+	}
+
+	return nil
+}
+
+// CommitStatusProviderFor returns the service hosting repositories on
+// host, or the empty string if it cannot be told from the host name.
+func CommitStatusProviderFor(host string) string {
+	switch {
+	case host == "github.com":
+		return domain.CommitStatusProviderGitHub
+	case host == "gitlab.com", strings.HasPrefix(host, "gitlab."):
+		return domain.CommitStatusProviderGitLab
+	case host == "bitbucket.org":
+		return domain.CommitStatusProviderBitbucket
+	case host == "gitea.com", host == "codeberg.org", strings.HasPrefix(host, "gitea."):
+		return domain.CommitStatusProviderGitea
+	}
+
+	return ""
+}
+
+func (self *ReportCommitStatus) createStatusRequest(token *domain.TokenRepositoryCredential, repository *domain.Repository) (*http.Request, error) {
+	gitURL, err := git.Parse(repository.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := token.Provider
+	if provider == "" {
+		provider = CommitStatusProviderFor(gitURL.Hostname())
+	}
+
+	repositoryPath := strings.TrimSuffix(strings.Trim(gitURL.Path, "/"), ".git")
+	if !strings.Contains(repositoryPath, "/") {
+		return nil, fmt.Errorf("%s expected to contain at least two segments", gitURL.Path)
+	}
+
+	if self.TargetURL != "" {
+		if _, err := url.Parse(self.TargetURL); err != nil {
+			return nil, fmt.Errorf("invalid TargetURL: %q: %s", self.TargetURL, err)
+		}
+	}
+
+	switch provider {
+	case domain.CommitStatusProviderGitHub:
+		return self.gitHubStatusRequest(token.Token, gitURL.Hostname(), repositoryPath)
+	case domain.CommitStatusProviderGitLab:
+		return self.gitLabStatusRequest(token.Token, gitURL.Hostname(), repositoryPath)
+	case domain.CommitStatusProviderGitea:
+		return self.giteaStatusRequest(token.Token, gitURL.Hostname(), repositoryPath)
+	case domain.CommitStatusProviderBitbucket:
+		return self.bitbucketStatusRequest(token.Token, repositoryPath)
+	}
+
+	return nil, fmt.Errorf("%s: cannot tell where to report commit statuses to", gitURL)
+}
+
+// gitHubStatusRequest creates a request against the GitHub API, or the
+// API of a GitHub Enterprise installation on host.
+func (self *ReportCommitStatus) gitHubStatusRequest(token, host, repositoryPath string) (*http.Request, error) {
+	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/statuses/%s", repositoryPath, self.CommitRef)
+	if host != "github.com" {
+		apiURL = fmt.Sprintf("https://%s/api/v3/repos/%s/statuses/%s", host, repositoryPath, self.CommitRef)
+	}
+
+	payload := map[string]string{
+		"state":   self.State,
+		"context": commitStatusContext,
+	}
+	if self.TargetURL != "" {
+		payload["target_url"] = self.TargetURL
+	}
+
+	return newJSONRequest(apiURL, payload, "Authorization", fmt.Sprintf("token %s", token))
+}
+
+func (self *ReportCommitStatus) gitLabStatusRequest(token, host, repositoryPath string) (*http.Request, error) {
+	apiURL := fmt.Sprintf("https://%s/api/v4/projects/%s/statuses/%s", host, url.PathEscape(repositoryPath), self.CommitRef)
+
+	state := "success"
+	switch self.State {
+	case CommitStatusPending:
+		state = "pending"
+	case CommitStatusFailure, CommitStatusError:
+		state = "failed"
+	}
+
+	payload := map[string]string{
+		"state": state,
+		"name":  commitStatusContext,
+	}
+	if self.TargetURL != "" {
+		payload["target_url"] = self.TargetURL
+	}
+
+	return newJSONRequest(apiURL, payload, "PRIVATE-TOKEN", token)
+}
+
+func (self *ReportCommitStatus) giteaStatusRequest(token, host, repositoryPath string) (*http.Request, error) {
+	apiURL := fmt.Sprintf("https://%s/api/v1/repos/%s/statuses/%s", host, repositoryPath, self.CommitRef)
+
+	payload := map[string]string{
+		"state":   self.State,
+		"context": commitStatusContext,
+	}
+	if self.TargetURL != "" {
+		payload["target_url"] = self.TargetURL
+	}
+
+	return newJSONRequest(apiURL, payload, "Authorization", fmt.Sprintf("token %s", token))
+}
+
+func (self *ReportCommitStatus) bitbucketStatusRequest(token, repositoryPath string) (*http.Request, error) {
+	apiURL := fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s/commit/%s/statuses/build", repositoryPath, self.CommitRef)
+
+	state := "SUCCESSFUL"
+	switch self.State {
+	case CommitStatusPending:
+		state = "INPROGRESS"
+	case CommitStatusFailure, CommitStatusError:
+		state = "FAILED"
+	}
+
+	payload := map[string]string{
+		"key":   strings.ToLower(commitStatusContext),
+		"name":  commitStatusContext,
+		"state": state,
+	}
+	if self.TargetURL != "" {
+		payload["url"] = self.TargetURL
+	}
+
+	return newJSONRequest(apiURL, payload, "Authorization", fmt.Sprintf("Bearer %s", token))
+}
+
+func newJSONRequest(apiURL string, payload map[string]string, authHeader, authValue string) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(authHeader, authValue)
+
+	return request, nil
+}
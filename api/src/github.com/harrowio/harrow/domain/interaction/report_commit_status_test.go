@@ -0,0 +1,174 @@
+package interaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+)
+
+type RecordingHTTPClient struct {
+	Requests []*http.Request
+	Bodies   []map[string]string
+}
+
+func (self *RecordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	body := map[string]string{}
+	data, _ := ioutil.ReadAll(req.Body)
+	json.Unmarshal(data, &body)
+	self.Requests = append(self.Requests, req)
+	self.Bodies = append(self.Bodies, body)
+
+	return &http.Response{
+		StatusCode: http.StatusCreated,
+		Status:     "201 Created",
+		Body:       ioutil.NopCloser(bytes.NewBufferString("{}")),
+	}, nil
+}
+
+type CommitStatusDatasourceInMemory struct {
+	Repository *domain.Repository
+	Token      *domain.TokenRepositoryCredential
+}
+
+func (self *CommitStatusDatasourceInMemory) FindRepository(repositoryUUID string) (*domain.Repository, error) {
+	return self.Repository, nil
+}
+
+func (self *CommitStatusDatasourceInMemory) FindCommitStatusToken(repositoryUUID string) (*domain.TokenRepositoryCredential, error) {
+	return self.Token, nil
+}
+
+func reportCommitStatusTo(t *testing.T, repositoryURL string, token *domain.TokenRepositoryCredential, state string) (*http.Request, map[string]string) {
+	repositoryUUID := "0b9ec8ae-1ab1-4a51-9d0f-0d3cd1b3d8e3"
+	datasource := &CommitStatusDatasourceInMemory{
+		Repository: &domain.Repository{Uuid: repositoryUUID, Url: repositoryURL},
+		Token:      token,
+	}
+	client := &RecordingHTTPClient{}
+	report := NewReportCommitStatus(
+		repositoryUUID,
+		"e242ed3bffccdf271b7fbaf34ed72d089537b42f",
+		state,
+		"https://www.app.harrow.io/#/a/operations/1",
+		logger.Discard,
+		client,
+		datasource,
+		datasource,
+	)
+
+	if err := report.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(client.Requests), 1; got != want {
+		t.Fatalf(`len(client.Requests) = %d; want %d`, got, want)
+	}
+
+	return client.Requests[0], client.Bodies[0]
+}
+
+func TestReportCommitStatus_Execute_postsGitLabStatus(t *testing.T) {
+	request, body := reportCommitStatusTo(t,
+		"git@gitlab.com:group/subgroup/project.git",
+		&domain.TokenRepositoryCredential{Token: "glpat-secret"},
+		CommitStatusFailure,
+	)
+
+	if got, want := request.URL.String(), "https://gitlab.com/api/v4/projects/group%2Fsubgroup%2Fproject/statuses/e242ed3bffccdf271b7fbaf34ed72d089537b42f"; got != want {
+		t.Errorf(`request.URL = %q; want %q`, got, want)
+	}
+
+	if got, want := request.Header.Get("PRIVATE-TOKEN"), "glpat-secret"; got != want {
+		t.Errorf(`request.Header.Get("PRIVATE-TOKEN") = %q; want %q`, got, want)
+	}
+
+	if got, want := body["state"], "failed"; got != want {
+		t.Errorf(`body["state"] = %q; want %q`, got, want)
+	}
+
+	if got, want := body["target_url"], "https://www.app.harrow.io/#/a/operations/1"; got != want {
+		t.Errorf(`body["target_url"] = %q; want %q`, got, want)
+	}
+}
+
+func TestReportCommitStatus_Execute_postsBitbucketBuildStatus(t *testing.T) {
+	request, body := reportCommitStatusTo(t,
+		"https://bitbucket.org/workspace/repository.git",
+		&domain.TokenRepositoryCredential{Token: "secret"},
+		CommitStatusPending,
+	)
+
+	if got, want := request.URL.String(), "https://api.bitbucket.org/2.0/repositories/workspace/repository/commit/e242ed3bffccdf271b7fbaf34ed72d089537b42f/statuses/build"; got != want {
+		t.Errorf(`request.URL = %q; want %q`, got, want)
+	}
+
+	if got, want := request.Header.Get("Authorization"), "Bearer secret"; got != want {
+		t.Errorf(`request.Header.Get("Authorization") = %q; want %q`, got, want)
+	}
+
+	if got, want := body["state"], "INPROGRESS"; got != want {
+		t.Errorf(`body["state"] = %q; want %q`, got, want)
+	}
+
+	if got, want := body["url"], "https://www.app.harrow.io/#/a/operations/1"; got != want {
+		t.Errorf(`body["url"] = %q; want %q`, got, want)
+	}
+}
+
+func TestReportCommitStatus_Execute_usesProviderOfTokenForSelfHostedGitea(t *testing.T) {
+	request, body := reportCommitStatusTo(t,
+		"ssh://git@git.example.com:2222/owner/repository.git",
+		&domain.TokenRepositoryCredential{Token: "secret", Provider: domain.CommitStatusProviderGitea},
+		CommitStatusSuccess,
+	)
+
+	if got, want := request.URL.String(), "https://git.example.com/api/v1/repos/owner/repository/statuses/e242ed3bffccdf271b7fbaf34ed72d089537b42f"; got != want {
+		t.Errorf(`request.URL = %q; want %q`, got, want)
+	}
+
+	if got, want := request.Header.Get("Authorization"), "token secret"; got != want {
+		t.Errorf(`request.Header.Get("Authorization") = %q; want %q`, got, want)
+	}
+
+	if got, want := body["state"], "success"; got != want {
+		t.Errorf(`body["state"] = %q; want %q`, got, want)
+	}
+}
+
+func TestReportCommitStatus_Execute_postsGitHubStatus(t *testing.T) {
+	request, body := reportCommitStatusTo(t,
+		"https://github.com/owner/repository.git",
+		&domain.TokenRepositoryCredential{Token: "oauth-token"},
+		CommitStatusError,
+	)
+
+	if got, want := request.URL.String(), "https://api.github.com/repos/owner/repository/statuses/e242ed3bffccdf271b7fbaf34ed72d089537b42f"; got != want {
+		t.Errorf(`request.URL = %q; want %q`, got, want)
+	}
+
+	if got, want := body["state"], "error"; got != want {
+		t.Errorf(`body["state"] = %q; want %q`, got, want)
+	}
+}
+
+func TestCommitStatusProviderFor(t *testing.T) {
+	testcases := map[string]string{
+		"github.com":         domain.CommitStatusProviderGitHub,
+		"gitlab.com":         domain.CommitStatusProviderGitLab,
+		"gitlab.example.com": domain.CommitStatusProviderGitLab,
+		"bitbucket.org":      domain.CommitStatusProviderBitbucket,
+		"gitea.example.com":  domain.CommitStatusProviderGitea,
+		"git.example.com":    "",
+	}
+
+	for host, want := range testcases {
+		if got := CommitStatusProviderFor(host); got != want {
+			t.Errorf(`CommitStatusProviderFor(%q) = %q; want %q`, host, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+const (
+	// IpAllowlistScopeApi restricts the addresses from which members
+	// of an organization can use the API.
+	IpAllowlistScopeApi = "api"
+
+	// IpAllowlistScopeWebhook restricts the addresses from which
+	// webhooks of an organization's projects can be delivered.
+	IpAllowlistScopeWebhook = "webhook"
+)
+
+// IpAllowlistEntry allows requests from the addresses in Cidr.  As
+// soon as an organization has an entry for a scope, requests in that
+// scope coming from any other address are rejected.
+type IpAllowlistEntry struct {
+	defaultSubject
+
+	Uuid             string    `json:"uuid" db:"uuid"`
+	OrganizationUuid string    `json:"organizationUuid" db:"organization_uuid"`
+	Scope            string    `json:"scope" db:"scope"`
+	Cidr             string    `json:"cidr" db:"cidr"`
+	Description      string    `json:"description" db:"description"`
+	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
+}
+
+func (self *IpAllowlistEntry) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/ip-allowlist-entries/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *IpAllowlistEntry) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["organization"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/organizations/%s", requestScheme, requestBase, self.OrganizationUuid),
+	}
+	return response
+}
+
+func (self *IpAllowlistEntry) AuthorizationName() string { return "ip-allowlist-entry" }
+
+func (self *IpAllowlistEntry) FindOrganization(store OrganizationStore) (*Organization, error) {
+	return store.FindByUuid(self.OrganizationUuid)
+}
+
+// Validate checks the entry and normalizes Cidr.  A single address is
+// accepted in place of a network and stored as a network containing
+// only that address.
+func (self *IpAllowlistEntry) Validate() error {
+	result := NewValidationError("", "")
+
+	if !uuidhelper.IsValid(self.OrganizationUuid) {
+		result.Add("organizationUuid", "malformed")
+	}
+
+	if self.Scope != IpAllowlistScopeApi && self.Scope != IpAllowlistScopeWebhook {
+		result.Add("scope", "invalid")
+	}
+
+	cidr := strings.TrimSpace(self.Cidr)
+	if ip := net.ParseIP(cidr); ip != nil {
+		if ip.To4() != nil {
+			cidr = cidr + "/32"
+		} else {
+			cidr = cidr + "/128"
+		}
+	}
+
+	if _, network, err := net.ParseCIDR(cidr); err != nil {
+		result.Add("cidr", "malformed")
+	} else {
+		self.Cidr = network.String()
+	}
+
+	return result.ToError()
+}
+
+// Contains reports whether ip belongs to the network of this entry.
+func (self *IpAllowlistEntry) Contains(ip net.IP) bool {
+	_, network, err := net.ParseCIDR(self.Cidr)
+	if err != nil {
+		return false
+	}
+
+	return network.Contains(ip)
+}
+
+// IpAllowlist is the list of entries an organization has for a scope.
+type IpAllowlist []*IpAllowlistEntry
+
+// Allows reports whether requests from clientAddress are allowed.
+// Every address is allowed by an empty allowlist.
+func (self IpAllowlist) Allows(clientAddress string) bool {
+	if len(self) == 0 {
+		return true
+	}
+
+	ip := ParseClientAddress(clientAddress)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range self {
+		if entry.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseClientAddress returns the address of the client that sent a
+// request, given either a single address or the remote address of the
+// connection including its port.  It returns nil if clientAddress
+// contains no valid address.
+func ParseClientAddress(clientAddress string) net.IP {
+	address := strings.TrimSpace(clientAddress)
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+
+	return net.ParseIP(strings.Trim(address, "[]"))
+}
@@ -0,0 +1,80 @@
+package domain
+
+import "testing"
+
+func TestIpAllowlistEntry_Validate_normalizesSingleAddresses(t *testing.T) {
+	testcases := []struct {
+		cidr string
+		want string
+	}{
+		{"192.0.2.1", "192.0.2.1/32"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{"10.1.2.3/8", "10.0.0.0/8"},
+	}
+
+	for _, testcase := range testcases {
+		entry := &IpAllowlistEntry{
+			OrganizationUuid: "c4c2d5f3-ec49-4e28-ba4d-fe1b8a8c8b71",
+			Scope:            IpAllowlistScopeApi,
+			Cidr:             testcase.cidr,
+		}
+
+		if err := entry.Validate(); err != nil {
+			t.Fatalf("Validate(%q): %s", testcase.cidr, err)
+		}
+
+		if got, want := entry.Cidr, testcase.want; got != want {
+			t.Errorf("entry.Cidr = %q; want %q", got, want)
+		}
+	}
+}
+
+func TestIpAllowlistEntry_Validate_rejectsUnknownScopeAndMalformedCidr(t *testing.T) {
+	entry := &IpAllowlistEntry{
+		OrganizationUuid: "c4c2d5f3-ec49-4e28-ba4d-fe1b8a8c8b71",
+		Scope:            "ssh",
+		Cidr:             "10.0.0.0/33",
+	}
+
+	err, ok := entry.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf("err = %T; want *ValidationError", entry.Validate())
+	}
+
+	if got := err.Get("scope"); got != "invalid" {
+		t.Errorf(`err.Get("scope") = %q; want %q`, got, "invalid")
+	}
+
+	if got := err.Get("cidr"); got != "malformed" {
+		t.Errorf(`err.Get("cidr") = %q; want %q`, got, "malformed")
+	}
+}
+
+func TestIpAllowlist_Allows(t *testing.T) {
+	allowlist := IpAllowlist{
+		{Cidr: "10.0.0.0/8"},
+		{Cidr: "2001:db8::/32"},
+	}
+
+	testcases := []struct {
+		clientAddress string
+		want          bool
+	}{
+		{"10.20.30.40", true},
+		{"10.20.30.40:51234", true},
+		{"[2001:db8::1]:443", true},
+		{"192.0.2.1", false},
+		{"10.20.30.40, 198.51.100.7", false},
+		{"", false},
+	}
+
+	for _, testcase := range testcases {
+		if got, want := allowlist.Allows(testcase.clientAddress), testcase.want; got != want {
+			t.Errorf("allowlist.Allows(%q) = %v; want %v", testcase.clientAddress, got, want)
+		}
+	}
+
+	if !(IpAllowlist{}).Allows("192.0.2.1") {
+		t.Errorf("empty allowlist does not allow 192.0.2.1")
+	}
+}
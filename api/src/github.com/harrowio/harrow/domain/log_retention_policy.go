@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+const (
+	LogRetentionKeep     = "keep"
+	LogRetentionTruncate = "truncate"
+	LogRetentionDelete   = "delete"
+)
+
+// LogRetentionPolicy describes how long the logs of operations are
+// kept.  Logs are kept in full for KeepFullDays after an operation has
+// finished.  After that only the last KeepTailKB kilobytes of each log
+// are kept, until the log is deleted after DeleteAfterDays.  The status
+// logs of an operation are stored with the operation itself and are
+// never affected by a retention policy.
+//
+// A policy applies to all projects of an organization, unless a policy
+// for a specific project exists.
+type LogRetentionPolicy struct {
+	defaultSubject
+
+	Uuid             string  `json:"uuid" db:"uuid"`
+	OrganizationUuid string  `json:"organizationUuid" db:"organization_uuid"`
+	ProjectUuid      *string `json:"projectUuid" db:"project_uuid"`
+
+	KeepFullDays int `json:"keepFullDays" db:"keep_full_days"`
+	KeepTailKB   int `json:"keepTailKB" db:"keep_tail_kb"`
+
+	// DeleteAfterDays is the number of days after which logs are
+	// deleted entirely.  Logs are never deleted if this is 0.
+	DeleteAfterDays int `json:"deleteAfterDays" db:"delete_after_days"`
+
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	ArchivedAt *time.Time `json:"archivedAt" db:"archived_at"`
+}
+
+func (self *LogRetentionPolicy) OwnUrl(requestScheme, requestBase string) string {
+	if self.ProjectUuid != nil {
+		return fmt.Sprintf("%s://%s/projects/%s/log-retention", requestScheme, requestBase, *self.ProjectUuid)
+	}
+
+	return fmt.Sprintf("%s://%s/organizations/%s/log-retention", requestScheme, requestBase, self.OrganizationUuid)
+}
+
+func (self *LogRetentionPolicy) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["organization"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/organizations/%s", requestScheme, requestBase, self.OrganizationUuid),
+	}
+	if self.ProjectUuid != nil {
+		response["project"] = map[string]string{
+			"href": fmt.Sprintf("%s://%s/projects/%s", requestScheme, requestBase, *self.ProjectUuid),
+		}
+	}
+	return response
+}
+
+func (self *LogRetentionPolicy) AuthorizationName() string { return "log-retention-policy" }
+
+func (self *LogRetentionPolicy) Validate() error {
+	result := NewValidationError("", "")
+
+	if !uuidhelper.IsValid(self.OrganizationUuid) {
+		result.Add("organizationUuid", "malformed")
+	}
+
+	if self.ProjectUuid != nil && !uuidhelper.IsValid(*self.ProjectUuid) {
+		result.Add("projectUuid", "malformed")
+	}
+
+	if self.KeepFullDays < 1 {
+		result.Add("keepFullDays", "too_small")
+	}
+
+	if self.KeepTailKB < 0 {
+		result.Add("keepTailKB", "too_small")
+	}
+
+	if self.DeleteAfterDays < 0 {
+		result.Add("deleteAfterDays", "too_small")
+	} else if self.DeleteAfterDays > 0 && self.DeleteAfterDays < self.KeepFullDays {
+		result.Add("deleteAfterDays", "too_small")
+	}
+
+	return result.ToError()
+}
+
+// KeepTailBytes returns the number of bytes kept from the end of a
+// truncated log.
+func (self *LogRetentionPolicy) KeepTailBytes() int64 {
+	return int64(self.KeepTailKB) * 1024
+}
+
+// ActionFor returns what needs to be done with the log of operation at
+// the given time: LogRetentionKeep, LogRetentionTruncate or
+// LogRetentionDelete.  Logs of unfinished operations and logs that
+// have already been deleted are always kept.
+func (self *LogRetentionPolicy) ActionFor(operation *Operation, now time.Time) string {
+	if operation.FinishedAt == nil || operation.LogDeletedAt != nil {
+		return LogRetentionKeep
+	}
+
+	age := now.Sub(*operation.FinishedAt)
+	if self.DeleteAfterDays > 0 && age >= daysToDuration(self.DeleteAfterDays) {
+		return LogRetentionDelete
+	}
+
+	if age >= daysToDuration(self.KeepFullDays) && operation.LogTruncatedAt == nil {
+		return LogRetentionTruncate
+	}
+
+	return LogRetentionKeep
+}
+
+// FinishedBefore returns the time before which operations need to
+// have finished for ActionFor to return anything but LogRetentionKeep.
+func (self *LogRetentionPolicy) FinishedBefore(now time.Time) time.Time {
+	return now.Add(-daysToDuration(self.KeepFullDays))
+}
+
+func daysToDuration(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// EffectiveLogRetentionPolicy returns the policy that applies to a
+// project: the project's own policy if it has one, otherwise the
+// policy of its organization.  The result is nil if neither exists, in
+// which case logs are kept forever.
+func EffectiveLogRetentionPolicy(organizationPolicy, projectPolicy *LogRetentionPolicy) *LogRetentionPolicy {
+	if projectPolicy != nil {
+		return projectPolicy
+	}
+
+	return organizationPolicy
+}
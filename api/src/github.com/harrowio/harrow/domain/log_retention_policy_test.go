@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func operationFinishedDaysAgo(now time.Time, n int) *Operation {
+	finishedAt := now.Add(-time.Duration(n) * 24 * time.Hour)
+	return &Operation{FinishedAt: &finishedAt}
+}
+
+func TestLogRetentionPolicy_ActionFor(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	policy := &LogRetentionPolicy{
+		KeepFullDays:    7,
+		KeepTailKB:      64,
+		DeleteAfterDays: 30,
+	}
+
+	truncated := operationFinishedDaysAgo(now, 10)
+	truncated.LogTruncatedAt = &now
+
+	deleted := operationFinishedDaysAgo(now, 40)
+	deleted.LogDeletedAt = &now
+
+	testcases := []struct {
+		name      string
+		operation *Operation
+		action    string
+	}{
+		{"running", &Operation{}, LogRetentionKeep},
+		{"recent", operationFinishedDaysAgo(now, 1), LogRetentionKeep},
+		{"old", operationFinishedDaysAgo(now, 7), LogRetentionTruncate},
+		{"already truncated", truncated, LogRetentionKeep},
+		{"expired", operationFinishedDaysAgo(now, 30), LogRetentionDelete},
+		{"already deleted", deleted, LogRetentionKeep},
+	}
+
+	for _, tc := range testcases {
+		if got, want := policy.ActionFor(tc.operation, now), tc.action; got != want {
+			t.Errorf(`%s: policy.ActionFor(operation) = %q; want %q`, tc.name, got, want)
+		}
+	}
+}
+
+func TestLogRetentionPolicy_ActionFor_neverDeletesWithoutDeleteAfterDays(t *testing.T) {
+	now := time.Now()
+	policy := &LogRetentionPolicy{KeepFullDays: 7}
+
+	if got, want := policy.ActionFor(operationFinishedDaysAgo(now, 3650), now), LogRetentionTruncate; got != want {
+		t.Errorf(`policy.ActionFor(operation) = %q; want %q`, got, want)
+	}
+}
+
+func TestLogRetentionPolicy_Validate_rejectsDeletingBeforeTruncating(t *testing.T) {
+	policy := &LogRetentionPolicy{
+		OrganizationUuid: "e8e9ab97-f4b6-4a46-bbaf-4f6d0c0ee0a7",
+		KeepFullDays:     30,
+		DeleteAfterDays:  7,
+	}
+
+	err, ok := policy.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`policy.Validate() = %v; want a *ValidationError`, err)
+	}
+
+	if got, want := err.Get("deleteAfterDays"), "too_small"; got != want {
+		t.Errorf(`err.Get("deleteAfterDays") = %q; want %q`, got, want)
+	}
+}
+
+func TestEffectiveLogRetentionPolicy_prefersProjectPolicy(t *testing.T) {
+	organizationPolicy := &LogRetentionPolicy{Uuid: "organization"}
+	projectPolicy := &LogRetentionPolicy{Uuid: "project"}
+
+	if got, want := EffectiveLogRetentionPolicy(organizationPolicy, projectPolicy), projectPolicy; got != want {
+		t.Errorf(`EffectiveLogRetentionPolicy(...) = %v; want %v`, got, want)
+	}
+
+	if got, want := EffectiveLogRetentionPolicy(organizationPolicy, nil), organizationPolicy; got != want {
+		t.Errorf(`EffectiveLogRetentionPolicy(...) = %v; want %v`, got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "fmt"
+
+// MattermostNotifier posts messages to an incoming webhook of a Mattermost channel.
+type MattermostNotifier struct {
+	defaultSubject
+	ChatWebhookNotifier
+}
+
+func (self *MattermostNotifier) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/mattermost-notifiers/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *MattermostNotifier) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{
+		"href": self.OwnUrl(requestScheme, requestBase),
+	}
+
+	return response
+}
+
+func (self *MattermostNotifier) Validate() error {
+	result := NewValidationError("", "")
+	self.validateWebhookInto(result, isPublicHost)
+	return result.ToError()
+}
+
+func (self *MattermostNotifier) NotifierType() string {
+	return ChatNotifierMattermost
+}
+
+func (self *MattermostNotifier) AuthorizationName() string {
+	return "mattermost-notifier"
+}
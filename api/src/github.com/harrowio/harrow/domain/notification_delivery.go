@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+const (
+	NotificationDeliveryPending   = "pending"
+	NotificationDeliveryDelivered = "delivered"
+	NotificationDeliveryFailed    = "failed"
+
+	// NotificationDeliveryMuted and NotificationDeliveryDeferred
+	// mark notifications that have not been sent because of the
+	// recipient's notification preferences.
+	NotificationDeliveryMuted    = "muted"
+	NotificationDeliveryDeferred = "deferred"
+)
+
+// NotificationDelivery records sending the notification for an
+// activity matched by a notification rule, across all attempts.
+type NotificationDelivery struct {
+	defaultSubject
+
+	Uuid                 string     `json:"uuid" db:"uuid"`
+	NotificationRuleUuid string     `json:"notificationRuleUuid" db:"notification_rule_uuid"`
+	ProjectUuid          string     `json:"projectUuid" db:"project_uuid"`
+	NotifierUuid         string     `json:"notifierUuid" db:"notifier_uuid"`
+	NotifierType         string     `json:"notifierType" db:"notifier_type"`
+	ActivityId           int        `json:"activityId" db:"activity_id"`
+	Status               string     `json:"status" db:"status"`
+	Attempts             int        `json:"attempts" db:"attempts"`
+	LastError            string     `json:"lastError" db:"last_error"`
+	NextAttemptAt        *time.Time `json:"nextAttemptAt" db:"next_attempt_at"`
+	CreatedAt            time.Time  `json:"createdAt" db:"created_at"`
+	DeliveredAt          *time.Time `json:"deliveredAt" db:"delivered_at"`
+}
+
+func NewNotificationDelivery(rule *NotificationRule, activity *Activity) *NotificationDelivery {
+	return &NotificationDelivery{
+		Uuid:                 uuidhelper.MustNewV4(),
+		NotificationRuleUuid: rule.Uuid,
+		ProjectUuid:          rule.ProjectUuid,
+		NotifierUuid:         rule.NotifierUuid,
+		NotifierType:         rule.NotifierType,
+		ActivityId:           activity.Id,
+		Status:               NotificationDeliveryPending,
+		CreatedAt:            Clock.Now(),
+	}
+}
+
+func (self *NotificationDelivery) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/notification-rules/%s/deliveries/%s", requestScheme, requestBase, self.NotificationRuleUuid, self.Uuid)
+}
+
+func (self *NotificationDelivery) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{
+		"href": self.OwnUrl(requestScheme, requestBase),
+	}
+	response["notification-rule"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/notification-rules/%s", requestScheme, requestBase, self.NotificationRuleUuid),
+	}
+
+	return response
+}
+
+// AuthorizationName returns the name of notification rules, since
+// deliveries are visible to everybody who can see their rule.
+func (self *NotificationDelivery) AuthorizationName() string { return "notification-rule" }
+
+func (self *NotificationDelivery) FindProject(projects ProjectStore) (*Project, error) {
+	return projects.FindByUuid(self.ProjectUuid)
+}
+
+// RecordAttempt records the outcome of trying to send the
+// notification.  A nil err marks the notification as delivered.
+func (self *NotificationDelivery) RecordAttempt(err error) {
+	self.Attempts++
+	self.NextAttemptAt = nil
+	if err != nil {
+		self.LastError = err.Error()
+		return
+	}
+
+	now := Clock.Now()
+	self.Status = NotificationDeliveryDelivered
+	self.DeliveredAt = &now
+	self.LastError = ""
+}
+
+// RetryIn records that the notification is tried again after wait.
+func (self *NotificationDelivery) RetryIn(wait time.Duration) {
+	next := Clock.Now().Add(wait)
+	self.NextAttemptAt = &next
+}
+
+// GiveUp marks the notification as not deliverable.
+func (self *NotificationDelivery) GiveUp() {
+	self.Status = NotificationDeliveryFailed
+	self.NextAttemptAt = nil
+}
+
+// Mute marks the notification as not sent, because the recipient
+// muted the project.
+func (self *NotificationDelivery) Mute() {
+	self.Status = NotificationDeliveryMuted
+	self.NextAttemptAt = nil
+}
+
+// Defer marks the notification as held back until the recipient's
+// quiet hours are over.  It is delivered again from scratch then.
+func (self *NotificationDelivery) Defer() {
+	self.Status = NotificationDeliveryDeferred
+	self.NextAttemptAt = nil
+}
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSlack = "slack"
+)
+
+// quietHoursLayout is the format of the start and end of quiet hours.
+const quietHoursLayout = "15:04"
+
+// NotificationPreferences control how a user is told about the
+// activities they are subscribed to, across all projects.
+type NotificationPreferences struct {
+	defaultSubject
+
+	UserUuid string `json:"userUuid" db:"user_uuid"`
+
+	// Channel is either NotificationChannelEmail or
+	// NotificationChannelSlack.  Slack messages are posted to
+	// SlackWebhookURL, which is expected to direct message the
+	// user.
+	Channel         string `json:"channel"         db:"channel"`
+	SlackWebhookURL string `json:"slackWebhookURL" db:"slack_webhook_url"`
+
+	// Timezone is the name of the timezone quiet hours are in,
+	// e.g. "Europe/Berlin".
+	Timezone string `json:"timezone" db:"timezone"`
+
+	// QuietHoursStart and QuietHoursEnd are given as "15:04".  No
+	// notifications are sent in between, but delivered once quiet
+	// hours are over.  Quiet hours may span midnight.  Both are
+	// empty if the user has no quiet hours.
+	QuietHoursStart string `json:"quietHoursStart" db:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quietHoursEnd"   db:"quiet_hours_end"`
+
+	// MutedProjectUuids lists the projects the user does not want
+	// to be notified about at all.
+	MutedProjectUuids []string `json:"mutedProjectUuids" db:"-"`
+
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// NewNotificationPreferences returns the preferences of a user who
+// has not changed any of them: everything is sent by email right
+// away.
+func NewNotificationPreferences(userUuid string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserUuid:          userUuid,
+		Channel:           NotificationChannelEmail,
+		Timezone:          "UTC",
+		MutedProjectUuids: []string{},
+	}
+}
+
+func (self *NotificationPreferences) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/users/%s/notification-preferences", requestScheme, requestBase, self.UserUuid)
+}
+
+func (self *NotificationPreferences) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["user"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s", requestScheme, requestBase, self.UserUuid)}
+
+	return response
+}
+
+// IsSlackWebhookURL returns true if rawurl points to an incoming
+// webhook of Slack.  Messages are only ever posted to those, so that
+// users cannot make Harrow send requests to arbitrary hosts.
+func IsSlackWebhookURL(rawurl string) bool {
+	webhookURL, err := url.Parse(rawurl)
+	if err != nil {
+		return false
+	}
+
+	return webhookURL.Scheme == "https" && webhookURL.Host == "hooks.slack.com"
+}
+
+func (self *NotificationPreferences) Validate() error {
+	result := EmptyValidationError()
+
+	switch self.Channel {
+	case NotificationChannelEmail:
+	case NotificationChannelSlack:
+		if !IsSlackWebhookURL(self.SlackWebhookURL) {
+			result.Add("slackWebhookURL", "malformed")
+		}
+	default:
+		result.Add("channel", "invalid")
+	}
+
+	if _, err := time.LoadLocation(self.Timezone); err != nil {
+		result.Add("timezone", "invalid")
+	}
+
+	if self.QuietHoursStart != "" || self.QuietHoursEnd != "" {
+		start, startErr := time.Parse(quietHoursLayout, self.QuietHoursStart)
+		if startErr != nil {
+			result.Add("quietHoursStart", "malformed")
+		}
+
+		end, endErr := time.Parse(quietHoursLayout, self.QuietHoursEnd)
+		if endErr != nil {
+			result.Add("quietHoursEnd", "malformed")
+		}
+
+		if startErr == nil && endErr == nil && start.Equal(end) {
+			result.Add("quietHoursEnd", "equals_start")
+		}
+	}
+
+	return result.ToError()
+}
+
+// IsMuted returns true if the user does not want to be notified about
+// the project identified by projectUuid.
+func (self *NotificationPreferences) IsMuted(projectUuid string) bool {
+	for _, muted := range self.MutedProjectUuids {
+		if muted == projectUuid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasQuietHours returns true if the user has configured quiet hours.
+func (self *NotificationPreferences) HasQuietHours() bool {
+	return self.QuietHoursStart != "" && self.QuietHoursEnd != ""
+}
+
+// QuietUntil returns the end of the quiet hours now falls into.  The
+// second return value is false if now is outside of quiet hours, in
+// which case notifications can be sent right away.
+func (self *NotificationPreferences) QuietUntil(now time.Time) (time.Time, bool) {
+	if !self.HasQuietHours() {
+		return time.Time{}, false
+	}
+
+	location, err := time.LoadLocation(self.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	start, err := time.Parse(quietHoursLayout, self.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse(quietHoursLayout, self.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	quiet := false
+	if startMinute < endMinute {
+		quiet = minute >= startMinute && minute < endMinute
+	} else {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, location)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+
+	return until, true
+}
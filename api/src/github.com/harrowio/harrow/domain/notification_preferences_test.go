@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationPreferences_QuietUntil_returnsEndOfQuietHoursSpanningMidnight(t *testing.T) {
+	preferences := NewNotificationPreferences("a4b1a2ab-2b54-4d54-8c1f-d6be8a5b9b2e")
+	preferences.Timezone = "Europe/Berlin"
+	preferences.QuietHoursStart = "22:00"
+	preferences.QuietHoursEnd = "07:00"
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 10, 19, 23, 30, 0, 0, berlin)
+	until, quiet := preferences.QuietUntil(now.UTC())
+	if !quiet {
+		t.Fatalf("quiet = false; want true")
+	}
+
+	if got, want := until, time.Date(2026, 10, 20, 7, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("until = %s; want %s", got, want)
+	}
+}
+
+func TestNotificationPreferences_QuietUntil_returnsFalseOutsideOfQuietHours(t *testing.T) {
+	preferences := NewNotificationPreferences("a4b1a2ab-2b54-4d54-8c1f-d6be8a5b9b2e")
+	preferences.QuietHoursStart = "22:00"
+	preferences.QuietHoursEnd = "07:00"
+
+	if _, quiet := preferences.QuietUntil(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)); quiet {
+		t.Errorf("quiet = true; want false")
+	}
+}
+
+func TestNotificationPreferences_QuietUntil_handlesQuietHoursWithinADay(t *testing.T) {
+	preferences := NewNotificationPreferences("a4b1a2ab-2b54-4d54-8c1f-d6be8a5b9b2e")
+	preferences.QuietHoursStart = "12:00"
+	preferences.QuietHoursEnd = "13:30"
+
+	until, quiet := preferences.QuietUntil(time.Date(2026, 10, 19, 12, 15, 0, 0, time.UTC))
+	if !quiet {
+		t.Fatalf("quiet = false; want true")
+	}
+
+	if got, want := until, time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("until = %s; want %s", got, want)
+	}
+}
+
+func TestNotificationPreferences_Validate_requiresAWebhookURLForSlack(t *testing.T) {
+	preferences := NewNotificationPreferences("a4b1a2ab-2b54-4d54-8c1f-d6be8a5b9b2e")
+	preferences.Channel = NotificationChannelSlack
+
+	err, ok := preferences.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf("err = %#v; want *ValidationError", err)
+	}
+
+	if got, want := err.Get("slackWebhookURL"), "malformed"; got != want {
+		t.Errorf(`err.Get("slackWebhookURL") = %q; want %q`, got, want)
+	}
+}
+
+func TestNotificationPreferences_Validate_requiresASlackWebhookURL(t *testing.T) {
+	for _, webhookURL := range []string{
+		"http://hooks.slack.com/services/T0/B0/x",
+		"https://hooks.slack.com.example.org/services/T0/B0/x",
+		"https://169.254.169.254/latest/meta-data",
+		"https://hooks.slack.com:8443/services/T0/B0/x",
+	} {
+		preferences := NewNotificationPreferences("a4b1a2ab-2b54-4d54-8c1f-d6be8a5b9b2e")
+		preferences.Channel = NotificationChannelSlack
+		preferences.SlackWebhookURL = webhookURL
+
+		err, ok := preferences.Validate().(*ValidationError)
+		if !ok {
+			t.Errorf("%s: err = %#v; want *ValidationError", webhookURL, err)
+			continue
+		}
+
+		if got, want := err.Get("slackWebhookURL"), "malformed"; got != want {
+			t.Errorf(`%s: err.Get("slackWebhookURL") = %q; want %q`, webhookURL, got, want)
+		}
+	}
+
+	preferences := NewNotificationPreferences("a4b1a2ab-2b54-4d54-8c1f-d6be8a5b9b2e")
+	preferences.Channel = NotificationChannelSlack
+	preferences.SlackWebhookURL = "https://hooks.slack.com/services/T0/B0/x"
+	if err := preferences.Validate(); err != nil {
+		t.Errorf("err = %s; want nil", err)
+	}
+}
@@ -9,6 +9,24 @@ import (
 	"github.com/harrowio/harrow/uuidhelper"
 )
 
+const (
+	// NotificationConditionNone matches every activity.
+	NotificationConditionNone = ""
+
+	// NotificationConditionBroken matches the first failed operation
+	// of a job after a successful one.
+	NotificationConditionBroken = "broken"
+
+	// NotificationConditionFixed matches the first successful
+	// operation of a job after a failed one.
+	NotificationConditionFixed = "fixed"
+
+	// NotificationConditionStillFailing matches every NotifyEvery-th
+	// failed operation of a job in a row, not counting the one that
+	// broke it.
+	NotificationConditionStillFailing = "still-failing"
+)
+
 type NotificationRule struct {
 	defaultSubject
 
@@ -20,6 +38,12 @@ type NotificationRule struct {
 	CreatorUuid   string     `json:"creatorUuid" db:"creator_uuid"`
 	JobUuid       *string    `json:"jobUuid" db:"job_uuid"`
 	ArchivedAt    *time.Time `json:"archivedAt" db:"archived_at"`
+
+	// Condition restricts the rule to operations whose outcome
+	// differs from, or repeats, the outcome of the job's previous
+	// operation.
+	Condition   string `json:"condition" db:"condition"`
+	NotifyEvery int    `json:"notifyEvery" db:"notify_every"`
 }
 
 func (self *NotificationRule) OwnUrl(requestScheme, requestBase string) string {
@@ -33,6 +57,9 @@ func (self *NotificationRule) Links(response map[string]map[string]string, reque
 	response["project"] = map[string]string{
 		"href": fmt.Sprintf("%s://%s/projects/%s", requestScheme, requestBase, self.ProjectUuid),
 	}
+	response["deliveries"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/notification-rules/%s/deliveries", requestScheme, requestBase, self.Uuid),
+	}
 
 	if self.JobUuid != nil {
 		response["job"] = map[string]string{
@@ -73,6 +100,20 @@ func (self *NotificationRule) Validate() error {
 		result.Add("creatorUuid", "malformed")
 	}
 
+	switch self.Condition {
+	case NotificationConditionNone, NotificationConditionBroken, NotificationConditionFixed, NotificationConditionStillFailing:
+	default:
+		result.Add("condition", "invalid")
+	}
+
+	if self.NotifyEvery == 0 {
+		self.NotifyEvery = 1
+	}
+
+	if self.NotifyEvery < 0 {
+		result.Add("notifyEvery", "invalid")
+	}
+
 	return result.ToError()
 }
 
@@ -92,6 +133,58 @@ func (self *NotificationRule) Matches(activity *Activity) bool {
 	return matches
 }
 
+// HasCondition reports whether the rule only matches some outcomes of
+// operations, depending on the previous operations of the same job.
+func (self *NotificationRule) HasCondition() bool {
+	return self.Condition != NotificationConditionNone
+}
+
+// MatchesOutcome reports whether the outcome of current satisfies the
+// rule's condition, given the job's previous operation (nil if there
+// is none) and the number of operations of the job that failed in a
+// row up to and including current.
+func (self *NotificationRule) MatchesOutcome(current, previous *Operation, failuresInARow int) bool {
+	if !self.HasCondition() {
+		return true
+	}
+
+	currentFailed, currentSucceeded := operationOutcome(current)
+	previousFailed, previousSucceeded := operationOutcome(previous)
+
+	switch self.Condition {
+	case NotificationConditionBroken:
+		return currentFailed && (previous == nil || previousSucceeded)
+	case NotificationConditionFixed:
+		return currentSucceeded && previousFailed
+	case NotificationConditionStillFailing:
+		every := self.NotifyEvery
+		if every < 1 {
+			every = 1
+		}
+		repeated := failuresInARow - 1
+		return currentFailed && previousFailed && repeated > 0 && repeated%every == 0
+	}
+
+	return false
+}
+
+// operationOutcome classifies operation as failed or succeeded.
+// Unfinished and canceled operations are neither.
+func operationOutcome(operation *Operation) (failed bool, succeeded bool) {
+	if operation == nil {
+		return false, false
+	}
+
+	switch operation.Status() {
+	case "success":
+		return false, true
+	case "failure", "fatal", "timeout":
+		return true, false
+	}
+
+	return false, false
+}
+
 func (self *NotificationRule) AppliesToJob(jobUuid string) bool {
 	if self.JobUuid == nil {
 		return true
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func finishedOperation(exitStatus int) *Operation {
+	finishedAt := time.Now()
+	return &Operation{
+		FinishedAt: &finishedAt,
+		ExitStatus: exitStatus,
+	}
+}
+
+func TestNotificationRule_MatchesOutcome(t *testing.T) {
+	succeeded := finishedOperation(0)
+	failed := finishedOperation(1)
+
+	testcases := []struct {
+		condition      string
+		notifyEvery    int
+		current        *Operation
+		previous       *Operation
+		failuresInARow int
+		want           bool
+	}{
+		{NotificationConditionNone, 1, succeeded, succeeded, 0, true},
+		{NotificationConditionBroken, 1, failed, succeeded, 1, true},
+		{NotificationConditionBroken, 1, failed, nil, 1, true},
+		{NotificationConditionBroken, 1, failed, failed, 2, false},
+		{NotificationConditionBroken, 1, succeeded, succeeded, 0, false},
+		{NotificationConditionFixed, 1, succeeded, failed, 0, true},
+		{NotificationConditionFixed, 1, succeeded, succeeded, 0, false},
+		{NotificationConditionFixed, 1, succeeded, nil, 0, false},
+		{NotificationConditionStillFailing, 1, failed, failed, 2, true},
+		{NotificationConditionStillFailing, 1, failed, succeeded, 1, false},
+		{NotificationConditionStillFailing, 3, failed, failed, 3, false},
+		{NotificationConditionStillFailing, 3, failed, failed, 4, true},
+		{NotificationConditionStillFailing, 3, failed, failed, 7, true},
+	}
+
+	for i, testcase := range testcases {
+		rule := &NotificationRule{
+			Condition:   testcase.condition,
+			NotifyEvery: testcase.notifyEvery,
+		}
+		got := rule.MatchesOutcome(testcase.current, testcase.previous, testcase.failuresInARow)
+		if got != testcase.want {
+			t.Errorf("%d: MatchesOutcome(%s, every %d, %d in a row) = %v; want %v",
+				i, testcase.condition, testcase.notifyEvery, testcase.failuresInARow, got, testcase.want)
+		}
+	}
+}
+
+func TestNotificationRule_Validate_rejectsUnknownCondition(t *testing.T) {
+	rule := &NotificationRule{
+		Condition: "sometimes",
+	}
+
+	err := rule.Validate()
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("err.(type) = %T; want %T", err, verr)
+	}
+
+	if got, want := verr.Get("condition"), "invalid"; got != want {
+		t.Errorf(`verr.Get("condition") = %q; want %q`, got, want)
+	}
+}
@@ -0,0 +1,236 @@
+package domain
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// NotificationTemplateLogTailLines is the number of lines at the end
+// of an operation's log that are available to notification templates.
+const NotificationTemplateLogTailLines = 20
+
+// notificationTemplateFuncs are the functions available to
+// notification templates in addition to the builtin ones.
+var notificationTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"shortHash": func(hash string) string {
+		if len(hash) > 7 {
+			return hash[:7]
+		}
+		return hash
+	},
+}
+
+// NotificationTemplate customizes the subject and body of the
+// messages sent by a notifier.  Both are Go text templates rendered
+// against a NotificationTemplateContext.  An empty template means the
+// notifier's default text is used.
+type NotificationTemplate struct {
+	Subject string `json:"subjectTemplate"`
+	Body    string `json:"bodyTemplate"`
+}
+
+// RenderedNotification is the result of rendering a
+// NotificationTemplate.
+type RenderedNotification struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// OperationLogs finds the log of an operation.
+type OperationLogs interface {
+	FindByOperationUuid(uuid string, tepy string) (*Loggable, error)
+}
+
+// NotificationTemplateContext is the data notification templates are
+// rendered against.
+type NotificationTemplateContext struct {
+	Activity    *Activity
+	Project     *Project
+	Job         *Job
+	Environment *Environment
+	Operation   *Operation
+
+	// TriggeredBy is the user who started the operation, or nil if
+	// it was not started by a user.
+	TriggeredBy *NotificationTemplateUser
+
+	// Commits are the commits that were new to the operation, over
+	// all repositories it checked out.
+	Commits []*GitLogEntry
+
+	// LogTail holds the last lines of the operation's log.
+	LogTail []string
+}
+
+// NotificationTemplateUser is the part of a user that notification
+// templates can refer to.
+type NotificationTemplateUser struct {
+	Uuid  string
+	Name  string
+	Email string
+}
+
+func NewNotificationTemplateContext(project *Project, job *Job, operation *Operation) *NotificationTemplateContext {
+	result := &NotificationTemplateContext{
+		Project: project,
+		Job:     job,
+		Commits: []*GitLogEntry{},
+		LogTail: []string{},
+	}
+
+	if operation != nil {
+		result.Operation = operationWithoutSecrets(operation)
+	}
+
+	if operation != nil && operation.GitLogs != nil {
+		for _, commits := range operation.GitLogs.Repositories {
+			result.Commits = append(result.Commits, commits...)
+		}
+	}
+
+	return result
+}
+
+// SetActivity makes activity available to templates.  Operations in
+// the activity's payload are replaced by copies without secrets.
+func (self *NotificationTemplateContext) SetActivity(activity *Activity) {
+	self.Activity = activityWithoutSecrets(activity)
+}
+
+// activityWithoutSecrets returns a copy of activity whose payload, if
+// it is an operation, does not contain the secrets passed to it.
+func activityWithoutSecrets(activity *Activity) *Activity {
+	if activity == nil {
+		return nil
+	}
+
+	scrubbed := *activity
+	if operation, ok := activity.Payload.(*Operation); ok && operation != nil {
+		scrubbed.Payload = operationWithoutSecrets(operation)
+	}
+	return &scrubbed
+}
+
+// operationWithoutSecrets returns a copy of operation without the
+// secrets passed to it, which must not end up in notifications.
+func operationWithoutSecrets(operation *Operation) *Operation {
+	withoutSecrets := *operation
+	if operation.Parameters != nil {
+		parameters := *operation.Parameters
+		parameters.Secrets = nil
+		withoutSecrets.Parameters = &parameters
+	}
+	return &withoutSecrets
+}
+
+// LoadDetails loads the environment, triggering user and log tail of
+// the context's operation.  Missing details are not an error, the
+// corresponding fields are left empty instead.  Logs that cannot be
+// read, e.g. because they have been deleted, count as missing.
+func (self *NotificationTemplateContext) LoadDetails(environments EnvironmentStore, users UserStore, logs OperationLogs) error {
+	if self.Operation == nil {
+		return nil
+	}
+
+	if params := self.Operation.Parameters; params != nil && params.Environment != nil {
+		self.Environment = params.Environment
+	} else if self.Job != nil {
+		environment, err := environments.FindByJobUuid(self.Job.Uuid)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		self.Environment = environment
+	}
+
+	if params := self.Operation.Parameters; params != nil && params.UserUuid != "" {
+		user, err := users.FindByUuid(params.UserUuid)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		if user != nil {
+			self.TriggeredBy = &NotificationTemplateUser{
+				Uuid:  user.Uuid,
+				Name:  user.Name,
+				Email: user.Email,
+			}
+		}
+	}
+
+	if log, err := logs.FindByOperationUuid(self.Operation.Uuid, LoggableWorkspace); err == nil && log != nil {
+		lines := log.LogLines
+		if len(lines) > NotificationTemplateLogTailLines {
+			lines = lines[len(lines)-NotificationTemplateLogTailLines:]
+		}
+		for _, line := range lines {
+			self.LogTail = append(self.LogTail, line.Msg)
+		}
+	}
+
+	return nil
+}
+
+func (self *NotificationTemplate) IsEmpty() bool {
+	return strings.TrimSpace(self.Subject) == "" && strings.TrimSpace(self.Body) == ""
+}
+
+func (self *NotificationTemplate) Validate() error {
+	result := NewValidationError("", "")
+	self.validateInto(result)
+	return result.ToError()
+}
+
+// validateInto adds the errors in the templates to result, so that
+// notifiers can report them along with their own.
+func (self *NotificationTemplate) validateInto(result *ValidationError) {
+	if _, err := parseNotificationTemplate("subject", self.Subject); err != nil {
+		result.Add("subjectTemplate", "malformed")
+	}
+
+	if _, err := parseNotificationTemplate("body", self.Body); err != nil {
+		result.Add("bodyTemplate", "malformed")
+	}
+}
+
+// Render renders the subject and body templates against ctxt.  An
+// empty template renders to an empty string.  Line breaks are removed
+// from the subject.
+func (self *NotificationTemplate) Render(ctxt *NotificationTemplateContext) (*RenderedNotification, error) {
+	subject, err := renderNotificationTemplate("subject", self.Subject, ctxt)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := renderNotificationTemplate("body", self.Body, ctxt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenderedNotification{
+		Subject: strings.Join(strings.Fields(subject), " "),
+		Body:    body,
+	}, nil
+}
+
+func parseNotificationTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(notificationTemplateFuncs).Parse(text)
+}
+
+func renderNotificationTemplate(name, text string, ctxt *NotificationTemplateContext) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+
+	tmpl, err := parseNotificationTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	out := new(bytes.Buffer)
+	if err := tmpl.Execute(out, ctxt); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,86 @@
+package domain
+
+import "testing"
+
+func TestNotificationTemplate_Render_doesNotExposeOperationSecrets(t *testing.T) {
+	parameters := NewOperationParameters().AddSecret("DEPLOY_KEY", "very-secret")
+	operation := &Operation{Parameters: parameters}
+	ctxt := NewNotificationTemplateContext(&Project{}, &Job{}, operation)
+	template := &NotificationTemplate{
+		Body: "{{range .Operation.Parameters.Secrets}}{{.Value}}{{end}}",
+	}
+
+	rendered, err := template.Render(ctxt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rendered.Body, ""; got != want {
+		t.Errorf(`rendered.Body = %q; want %q`, got, want)
+	}
+
+	if got, want := len(operation.Parameters.Secrets), 1; got != want {
+		t.Errorf(`len(operation.Parameters.Secrets) = %d; want %d`, got, want)
+	}
+}
+
+func TestNotificationTemplate_Render_doesNotExposeSecretsOfActivityPayload(t *testing.T) {
+	parameters := NewOperationParameters().AddSecret("DEPLOY_KEY", "very-secret")
+	operation := &Operation{Parameters: parameters}
+	activity := &Activity{Name: "operation.failed", Payload: operation}
+	ctxt := NewNotificationTemplateContext(&Project{}, &Job{}, operation)
+	ctxt.SetActivity(activity)
+	template := &NotificationTemplate{
+		Body: "{{range .Activity.Payload.Parameters.Secrets}}{{.Value}}{{end}}",
+	}
+
+	rendered, err := template.Render(ctxt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rendered.Body, ""; got != want {
+		t.Errorf(`rendered.Body = %q; want %q`, got, want)
+	}
+
+	if got, want := activity.Payload, interface{}(operation); got != want {
+		t.Errorf(`activity.Payload = %v; want %v`, got, want)
+	}
+}
+
+func TestNotificationTemplate_Render_joinsSubjectIntoASingleLine(t *testing.T) {
+	ctxt := NewNotificationTemplateContext(&Project{Name: "shop"}, &Job{Name: "deploy"}, nil)
+	template := &NotificationTemplate{
+		Subject: "{{.Project.Name}}\n{{.Job.Name}}",
+	}
+
+	rendered, err := template.Render(ctxt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rendered.Subject, "shop deploy"; got != want {
+		t.Errorf(`rendered.Subject = %q; want %q`, got, want)
+	}
+}
+
+func TestNotificationTemplate_Validate_rejectsMalformedTemplates(t *testing.T) {
+	template := &NotificationTemplate{
+		Subject: "{{.Job.Name}}",
+		Body:    "{{if .Job}}",
+	}
+
+	err := template.Validate()
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("err.(type) = %T; want %T", err, verr)
+	}
+
+	if got, want := verr.Get("bodyTemplate"), "malformed"; got != want {
+		t.Errorf(`verr.Get("bodyTemplate") = %q; want %q`, got, want)
+	}
+
+	if got, want := verr.Get("subjectTemplate"), ""; got != want {
+		t.Errorf(`verr.Get("subjectTemplate") = %q; want %q`, got, want)
+	}
+}
@@ -67,6 +67,8 @@ type Operation struct {
 	TimedOutAt             *time.Time `json:"timedOutAt"             db:"timed_out_at"`
 	CanceledAt             *time.Time `json:"canceledAt"             db:"canceled_at"`
 	FatalError             *string    `json:"fatalError"             db:"fatal_error"`
+	LogTruncatedAt         *time.Time `json:"logTruncatedAt"         db:"log_truncated_at"`
+	LogDeletedAt           *time.Time `json:"logDeletedAt"           db:"log_deleted_at"`
 
 	Parameters *OperationParameters `json:"parameters" db:"parameters"`
 
@@ -76,6 +78,8 @@ type Operation struct {
 
 	StatusLogs *StatusLogs `json:"statusLogs" db:"status_logs"`
 
+	TestResults *TestResults `json:"testResults" db:"test_results"`
+
 	LogEvents []*logevent.Message `json:"logEvents" db:"-"`
 }
 
@@ -285,6 +289,7 @@ func (self *Operation) Links(response map[string]map[string]string, requestSchem
 	}
 	if self.JobUuid != nil {
 		response["job"] = map[string]string{"href": fmt.Sprintf("%s://%s/jobs/%s", requestScheme, requestBaseUri, *self.JobUuid)}
+		response["notification-preview"] = map[string]string{"href": fmt.Sprintf("%s://%s/operations/%s/notification-preview", requestScheme, requestBaseUri, self.Uuid)}
 	}
 	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBaseUri)}
 	return response
@@ -368,7 +373,7 @@ func (self *Operation) Secrets(es EnvironmentStore, ss SecretStore) ([]*Secret,
 			return result, nil
 		}
 
-		envSecrets, err := ss.FindAllByEnvironmentUuid(env.Uuid)
+		envSecrets, err := env.Secrets(ss)
 		if err != nil {
 			return result, nil
 		}
@@ -387,7 +392,7 @@ func (self *Operation) Secrets(es EnvironmentStore, ss SecretStore) ([]*Secret,
 		return nil, err
 	}
 
-	return ss.FindAllByEnvironmentUuid(env.Uuid)
+	return env.Secrets(ss)
 }
 
 // Category returns the broader category an operation belongs into based
@@ -543,10 +548,14 @@ func (self *Operation) HandleEvent(payload EventPayload) {
 	if self.StatusLogs == nil {
 		self.StatusLogs = NewStatusLogs()
 	}
+	if self.TestResults == nil {
+		self.TestResults = NewTestResults()
+	}
 
 	self.RepositoryCheckouts.HandleEvent(payload)
 	self.GitLogs.HandleEvent(payload)
 	self.StatusLogs.HandleEvent(payload)
+	self.TestResults.HandleEvent(payload)
 }
 
 func (self *Operation) IsReady(repos RepositoryStore, credentials RepositoryCredentialStore, envs EnvironmentStore, secrets SecretStore) (bool, error) {
@@ -560,7 +569,7 @@ func (self *Operation) IsReady(repos RepositoryStore, credentials RepositoryCred
 	// just skip the secret check. Readyness is then solely determined by the
 	// presence of RepositoryCredentials
 	if env != nil {
-		ses, err := secrets.FindAllByEnvironmentUuid(env.Uuid)
+		ses, err := env.Secrets(secrets)
 		if err != nil {
 			return false, err
 		}
@@ -695,6 +704,25 @@ func (vars *OperationSetupScriptCtxt) AddSshConfig(repository *Repository, repos
 	return vars
 }
 
+// KnownHosts returns the SSH host keys pinned for the repositories
+// of this operation, in known_hosts format.
+func (vars *OperationSetupScriptCtxt) KnownHosts() string {
+	seen := map[string]bool{}
+	knownHosts := []string{}
+	for _, repository := range vars.Repositories {
+		for _, line := range strings.Split(repository.HostKeys, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || seen[line] {
+				continue
+			}
+			seen[line] = true
+			knownHosts = append(knownHosts, line+"\n")
+		}
+	}
+
+	return strings.Join(knownHosts, "")
+}
+
 type keyPair struct {
 	BelongsTo, Name, Private, Public string
 }
@@ -100,13 +100,18 @@ func (store *mockTaskStore) FindByJobUuid(jobUuid string) (*Task, error) {
 }
 
 type mockSecretStore struct {
-	byEnvironmentUuid map[string][]*Secret
+	byEnvironmentUuid       map[string][]*Secret
+	sharedByEnvironmentUuid map[string][]*Secret
 }
 
 func (store *mockSecretStore) FindAllByEnvironmentUuid(environmentUuid string) ([]*Secret, error) {
 	return store.byEnvironmentUuid[environmentUuid], nil
 }
 
+func (store *mockSecretStore) FindAllSharedWithEnvironmentUuid(environmentUuid string) ([]*Secret, error) {
+	return store.sharedByEnvironmentUuid[environmentUuid], nil
+}
+
 func Test_Operation_Environment_ReturnsNothingWithoutAJob(t *testing.T) {
 	operation := &Operation{}
 	store := &mockEnvStore{byJobUuid: map[string]*Environment{}}
@@ -180,6 +185,47 @@ func Test_Operation_Secrets(t *testing.T) {
 	}
 }
 
+func Test_Operation_Secrets_includesSharedSecrets(t *testing.T) {
+
+	job := &Job{
+		Uuid:            "ce36a377-3250-4a89-8b84-a276084fd511",
+		EnvironmentUuid: "4a1e8157-5f9f-4f9d-a1e6-33b9222c272f",
+	}
+	operation := &Operation{
+		Uuid:    "eaaab676-d4e7-4e71-ba48-b13192253587",
+		JobUuid: &job.Uuid,
+	}
+
+	env := &Environment{
+		Uuid: job.EnvironmentUuid,
+	}
+	envStore := &mockEnvStore{
+		byJobUuid: map[string]*Environment{
+			job.Uuid: env,
+		},
+	}
+
+	projectUuid := "7d2a5ad4-5a4a-4b5c-a6f4-9d0b3e1f0a39"
+	own := &Secret{EnvironmentUuid: env.Uuid, Type: SecretEnv, Name: "registry"}
+	shared := &Secret{ProjectUuid: &projectUuid, Type: SecretEnv, Name: "deploy"}
+	overridden := &Secret{ProjectUuid: &projectUuid, Type: SecretEnv, Name: "registry"}
+	secretStore := &mockSecretStore{
+		byEnvironmentUuid: map[string][]*Secret{
+			env.Uuid: {own},
+		},
+		sharedByEnvironmentUuid: map[string][]*Secret{
+			env.Uuid: {shared, overridden},
+		},
+	}
+
+	expSecrets := []*Secret{own, shared}
+	if secrets, err := operation.Secrets(envStore, secretStore); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(secrets, expSecrets) {
+		t.Fatalf("secrets=%#v, want=%#v\n", secrets, expSecrets)
+	}
+}
+
 func Test_Operation_IsReady(t *testing.T) {
 
 	job := &Job{
@@ -496,6 +542,21 @@ func TestOperationSetupScriptCtxt_LoadWebhookBody_loadsBodyFromDelivery(t *testi
 	}
 }
 
+func Test_OperationCtxt_KnownHosts_combinesHostKeysOfRepositories(t *testing.T) {
+	ctxt := &OperationSetupScriptCtxt{
+		Repositories: []*Repository{
+			{HostKeys: "github.com ssh-ed25519 AAAA\n"},
+			{HostKeys: ""},
+			{HostKeys: "github.com ssh-ed25519 AAAA\nbitbucket.org ssh-rsa BBBB"},
+		},
+	}
+
+	want := "github.com ssh-ed25519 AAAA\nbitbucket.org ssh-rsa BBBB\n"
+	if got := ctxt.KnownHosts(); got != want {
+		t.Errorf("ctxt.KnownHosts() = %q; want %q", got, want)
+	}
+}
+
 func Test_OperationCtxt_AddSshConfig_AddsOneNewEntry_ForSshUrls(t *testing.T) {
 
 	operation := &Operation{
@@ -11,6 +11,7 @@ type Organization struct {
 	Name        string     `json:"name"`
 	GithubLogin string     `json:"githubLogin"  db:"github_login"`
 	Public      bool       `json:"public"`
+	RequireTotp bool       `json:"requireTotp"  db:"require_totp"`
 	CreatedAt   time.Time  `json:"createdAt"    db:"created_at"`
 	ArchivedAt  *time.Time `json:"archivedAt"   db:"archived_at"`
 }
@@ -4,12 +4,13 @@ import "fmt"
 
 var (
 	organizationMemberGuestCapabilities = newCapabilityList().
-						reads("organization", "project-member", "organization-member").
+						reads("organization", "project-member", "organization-member", "custom-role").
 						strings()
 
 	organizationMemberMemberCapabilities = newCapabilityList().
 						add(organizationMemberGuestCapabilities).
 						reads("limits").
+						reads("secret").
 						creates("project").
 						creates("public").
 						strings()
@@ -20,12 +21,20 @@ var (
 						updates("project").
 						updates("public").
 						writesFor("organization-member").
+						does("create", "secret").
+						updates("secret").
+						does("archive", "secret").
+						does(CapabilityReadPrivileged, "secret").
+						does("rollback", "secret").
+						reads("ip-allowlist-entry").
 						strings()
 
 	organizationMemberOwnerCapabilities = newCapabilityList().
 						add(organizationMemberManagerCapabilities).
 						does("braintree", "purchase").
 						writesFor("organization").
+						writesFor("custom-role").
+						writesFor("ip-allowlist-entry").
 						strings()
 )
 
@@ -50,6 +50,11 @@ func (self *Project) Links(response map[string]map[string]string, requestScheme,
 	response["project-card"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/card", requestScheme, requestBaseUri, self.Uuid)}
 	response["git-triggers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/git-triggers", requestScheme, requestBaseUri, self.Uuid)}
 	response["slack-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/slack-notifiers", requestScheme, requestBaseUri, self.Uuid)}
+	response["teams-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/teams-notifiers", requestScheme, requestBaseUri, self.Uuid)}
+	response["mattermost-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/mattermost-notifiers", requestScheme, requestBaseUri, self.Uuid)}
+	response["discord-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/discord-notifiers", requestScheme, requestBaseUri, self.Uuid)}
+	response["blame-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/blame-notifiers", requestScheme, requestBaseUri, self.Uuid)}
+	response["webhook-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/webhook-notifiers", requestScheme, requestBaseUri, self.Uuid)}
 	response["email-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/email-notifiers", requestScheme, requestBaseUri, self.Uuid)}
 	response["job-notifiers"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/job-notifiers", requestScheme, requestBaseUri, self.Uuid)}
 	response["tasks"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s/tasks", requestScheme, requestBaseUri, self.Uuid)}
@@ -175,6 +180,19 @@ func (self *Project) NewEmailNotifier(recipient, urlHost string) *EmailNotifier
 	}
 }
 
+// NewChatNotifier returns a new chat notifier of the given type
+// posting to webhookURL in this project.
+func (self *Project) NewChatNotifier(notifierType, webhookURL, urlHost string) *ChatNotifier {
+	return &ChatNotifier{
+		Uuid:         uuidhelper.MustNewV4(),
+		NotifierType: notifierType,
+		Name:         self.Name,
+		WebhookURL:   webhookURL,
+		UrlHost:      urlHost,
+		ProjectUuid:  self.Uuid,
+	}
+}
+
 // NewGitTrigger returns a new git trigger in this project which fires
 // on changes to master.
 func (self *Project) NewGitTrigger(name, creatorUuid, jobUuid string) *GitTrigger {
@@ -28,6 +28,11 @@ var (
 					writesFor("email-notifier").
 					reads("job-notifier").
 					reads("slack-notifier").
+					reads("webhook-notifier").
+					reads("teams-notifier").
+					reads("mattermost-notifier").
+					reads("discord-notifier").
+					reads("blame-notifier").
 					reads("secret").
 					reads("credential").
 					reads("repository-credential").
@@ -52,10 +57,18 @@ var (
 						writesFor("git-trigger").
 						writesFor("job-notifier").
 						writesFor("slack-notifier").
+						writesFor("webhook-notifier").
+						writesFor("teams-notifier").
+						writesFor("mattermost-notifier").
+						writesFor("discord-notifier").
+						writesFor("blame-notifier").
 						writesFor("stencil").
+						writesFor("api-token").
 						does("create", "secret").
+						updates("secret").
 						does("archive", "secret").
 						does(CapabilityReadPrivileged, "secret").
+						does("rollback", "secret").
 						strings()
 	projectMemberOwnerCapabilities = newCapabilityList().
 					add(projectMemberManagerCapabilities).
@@ -76,6 +89,7 @@ type ProjectMember struct {
 	MembershipType string    `json:"type" db:"membership_type"`
 	MembershipUuid *string   `json:"membershipUuid" db:"membership_uuid"`
 	ProjectUuid    string    `json:"projectUuid" db:"project_uuid"`
+	CustomRoleUuid *string   `json:"customRoleUuid" db:"custom_role_uuid"`
 }
 
 func (self *ProjectMember) OwnedBy(user *User) bool {
@@ -115,6 +129,7 @@ func NewProjectMember(user *User, project *Project, projectMembership *ProjectMe
 	if projectMembership != nil {
 		result.MembershipType = projectMembership.MembershipType
 		result.MembershipUuid = &projectMembership.Uuid
+		result.CustomRoleUuid = projectMembership.CustomRoleUuid
 		result.CreatedAt = projectMembership.CreatedAt
 	}
 
@@ -169,9 +184,48 @@ func (self *ProjectMember) Promote(other *ProjectMember) error {
 	return nil
 }
 
+// AssignCustomRole assigns role to other, who needs to be a member of
+// a project of the organization that defined role.  Passing nil
+// removes the custom role.  Only managers and owners can assign custom
+// roles, never to themselves and only roles granting nothing beyond
+// their own capabilities.
+func (self *ProjectMember) AssignCustomRole(other *ProjectMember, project *Project, role *CustomRole) error {
+	if MembershipTypeHierarchyLevel(self.MembershipType) < MembershipTypeHierarchyLevel(MembershipTypeManager) {
+		return NewValidationError("membershipType", "too_low")
+	}
+
+	if self.User != nil && other.User != nil && self.User.Uuid == other.User.Uuid {
+		return NewValidationError("customRoleUuid", "self_assignment")
+	}
+
+	if role == nil {
+		other.CustomRoleUuid = nil
+		return nil
+	}
+
+	if role.OrganizationUuid != project.OrganizationUuid || role.ArchivedAt != nil {
+		return NewValidationError("customRoleUuid", "invalid")
+	}
+
+	own := map[string]bool{}
+	for _, capability := range self.Capabilities() {
+		own[capability] = true
+	}
+	for _, capability := range role.Grants {
+		if !own[capability] {
+			return NewValidationError("customRoleUuid", "not_assignable")
+		}
+	}
+
+	other.CustomRoleUuid = &role.Uuid
+
+	return nil
+}
+
 func (self *ProjectMember) ToMembership() *ProjectMembership {
 	membership := &ProjectMembership{
 		MembershipType: self.MembershipType,
+		CustomRoleUuid: self.CustomRoleUuid,
 		UserUuid:       self.User.Uuid,
 		ProjectUuid:    self.ProjectUuid,
 		CreatedAt:      self.CreatedAt,
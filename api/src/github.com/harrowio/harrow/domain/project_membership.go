@@ -11,6 +11,7 @@ type ProjectMembership struct {
 	ProjectUuid    string     `json:"projectUuid" db:"project_uuid"`
 	UserUuid       string     `json:"userUuid" db:"user_uuid"`
 	MembershipType string     `json:"membershipType" db:"membership_type"`
+	CustomRoleUuid *string    `json:"customRoleUuid" db:"custom_role_uuid"`
 	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
 	ArchivedAt     *time.Time `json:"archivedAt" db:"archived_at"`
 }
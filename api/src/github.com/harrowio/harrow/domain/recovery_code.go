@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount is the number of recovery codes generated at once.
+const RecoveryCodeCount = 10
+
+var (
+	ErrRecoveryCodeNotValid = NewValidationError("recoveryCode", "invalid")
+
+	recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// RecoveryCode can be used once in place of a TOTP token, for users
+// who have lost access to their authenticator.  Only a hash of the
+// code is stored; the code itself is shown to the user once.
+type RecoveryCode struct {
+	Uuid      string     `json:"uuid"      db:"uuid"`
+	UserUuid  string     `json:"userUuid"  db:"user_uuid"`
+	CodeHash  string     `json:"-"         db:"code_hash"`
+	UsedAt    *time.Time `json:"usedAt"    db:"used_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// NewRecoveryCodes generates a fresh set of recovery codes for a
+// user.  It returns the codes in plain text for showing them to the
+// user, and hashed for storing them.
+func NewRecoveryCodes(userUuid string) ([]string, []*RecoveryCode) {
+	plain := make([]string, RecoveryCodeCount)
+	codes := make([]*RecoveryCode, RecoveryCodeCount)
+	for i := range plain {
+		plain[i] = randomRecoveryCode()
+		codes[i] = &RecoveryCode{
+			UserUuid: userUuid,
+			CodeHash: HashRecoveryCode(plain[i]),
+		}
+	}
+
+	return plain, codes
+}
+
+// HashRecoveryCode returns the hash under which code is stored.  Codes
+// are compared ignoring case, spaces and dashes, so that users can
+// type them in however they have written them down.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func (self *RecoveryCode) IsUsed() bool {
+	return self.UsedAt != nil
+}
+
+func randomRecoveryCode() string {
+	secret := make([]byte, 10)
+	if _, err := rand.Read(secret); err != nil {
+		panic("domain.randomRecoveryCode: " + err.Error())
+	}
+
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(secret))
+	return code[:8] + "-" + code[8:]
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "testing"
+
+func TestHashRecoveryCode_ignoresCaseDashesAndSpaces(t *testing.T) {
+	want := HashRecoveryCode("abcdefgh-ijklmnop")
+	for _, code := range []string{"ABCDEFGH-IJKLMNOP", "abcdefghijklmnop", "abcd efgh ijkl mnop"} {
+		if got := HashRecoveryCode(code); got != want {
+			t.Errorf("HashRecoveryCode(%q) = %q; want %q", code, got, want)
+		}
+	}
+}
+
+func TestNewRecoveryCodes_generatesDistinctCodes(t *testing.T) {
+	plain, codes := NewRecoveryCodes("user")
+	if got, want := len(plain), RecoveryCodeCount; got != want {
+		t.Fatalf("len(plain) = %d; want %d", got, want)
+	}
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if seen[plain[i]] {
+			t.Errorf("duplicate code %q", plain[i])
+		}
+		seen[plain[i]] = true
+
+		if got, want := code.CodeHash, HashRecoveryCode(plain[i]); got != want {
+			t.Errorf("codes[%d].CodeHash = %q; want %q", i, got, want)
+		}
+		if got, want := code.UserUuid, "user"; got != want {
+			t.Errorf("codes[%d].UserUuid = %q; want %q", i, got, want)
+		}
+	}
+}
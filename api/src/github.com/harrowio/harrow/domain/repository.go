@@ -27,6 +27,10 @@ type Repository struct {
 	Metadata          *RepositoryMetaData `json:"metadata" db:"metadata"`
 
 	ConnectedSuccessfully *bool `json:"connectedSuccessfully,omitempty" db:"connected_successfully"`
+
+	// HostKeys are the SSH host keys, in known_hosts format, pinned
+	// for this repository by its first successful access check.
+	HostKeys string `json:"hostKeys" db:"host_keys"`
 }
 
 func ValidateRepository(u *Repository) error {
@@ -78,6 +82,7 @@ func (self *Repository) ClonedGit(OS git.System, credentials RepositoryCredentia
 	}
 	clonedRepository := git.NewClonedRepository(OS, &repositoryURL.URL)
 	clonedRepository.MakePersistent()
+	clonedRepository.SetKnownHosts(self.HostKeys)
 	credentialType := RepositoryCredentialBasic
 	if clonedRepository.UsesSSH() {
 		credentialType = RepositoryCredentialSsh
@@ -178,6 +183,7 @@ func (self *Repository) Links(response map[string]map[string]string, requestSche
 	response["operations"] = map[string]string{"href": fmt.Sprintf("%s://%s/repositories/%s/operations", requestScheme, requestBaseUri, self.Uuid)}
 	response["project"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s", requestScheme, requestBaseUri, self.ProjectUuid)}
 	response["credential"] = map[string]string{"href": fmt.Sprintf("%s://%s/repositories/%s/credential", requestScheme, requestBaseUri, self.Uuid)}
+	response["status-token"] = map[string]string{"href": fmt.Sprintf("%s://%s/repositories/%s/status-token", requestScheme, requestBaseUri, self.Uuid)}
 	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBaseUri)}
 	response["github-deploy-key"] = map[string]string{"href": fmt.Sprintf("%s://%s/oauth/github/repositories/%s/keys", requestScheme, requestBaseUri, self.Uuid)}
 	return response
@@ -204,3 +210,16 @@ func (self *Repository) NewMetadataUpdateOperation() *Operation {
 		Parameters: NewOperationParameters(),
 	}
 }
+
+// NewAccessCheckOperation returns an operation for checking whether
+// this repository can be accessed.  The first successful check pins
+// the SSH host keys presented by the repository's host.
+func (self *Repository) NewAccessCheckOperation() *Operation {
+	uuid := self.Uuid
+	return &Operation{
+		RepositoryUuid:         &uuid,
+		WorkspaceBaseImageUuid: "31b0127a-6d63-4d22-b32b-e1cfc04f4007",
+		Type:                   OperationTypeGitAccessCheck,
+		Parameters:             NewOperationParameters(),
+	}
+}
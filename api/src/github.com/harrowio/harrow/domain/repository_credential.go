@@ -27,6 +27,7 @@ func (self *RepositoryCredentialTypeError) Error() string {
 var (
 	RepositoryCredentialSsh   RepositoryCredentialType = "ssh"
 	RepositoryCredentialBasic RepositoryCredentialType = "basic"
+	RepositoryCredentialToken RepositoryCredentialType = "token"
 )
 
 // driver.Valuer
@@ -93,6 +94,10 @@ func (self *RepositoryCredential) IsBasic() bool {
 	return self.Type == RepositoryCredentialBasic
 }
 
+func (self *RepositoryCredential) IsToken() bool {
+	return self.Type == RepositoryCredentialToken
+}
+
 // authz.BelongsToProject
 func (self *RepositoryCredential) FindProject(store ProjectStore) (*Project, error) {
 	return store.FindByRepositoryUuid(self.RepositoryUuid)
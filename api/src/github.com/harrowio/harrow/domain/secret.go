@@ -53,16 +53,65 @@ func (self *SecretStatus) Scan(value interface{}) error {
 	}
 }
 
+// SecretOverridePolicy controls whether a secret shared by an
+// organization or a project can be overridden by a secret with the
+// same name in a narrower scope.
+type SecretOverridePolicy string
+
+var (
+	SecretOverrideAllow SecretOverridePolicy = "allow"
+	SecretOverrideDeny  SecretOverridePolicy = "deny"
+)
+
+// driver.Valuer
+func (self SecretOverridePolicy) Value() (driver.Value, error) {
+	return string(self), nil
+}
+
+// driver.Scanner
+func (self *SecretOverridePolicy) Scan(value interface{}) error {
+	switch t := value.(type) {
+	default:
+		return fmt.Errorf("unexpected type %T", t)
+	case []byte:
+		*self = SecretOverridePolicy(t)
+		return nil
+	case string:
+		*self = SecretOverridePolicy(t)
+		return nil
+	}
+}
+
+const (
+	SecretScopeOrganization = "organization"
+	SecretScopeProject      = "project"
+	SecretScopeEnvironment  = "environment"
+)
+
+// Secrets belong to exactly one of an environment, a project or an
+// organization.  Environments inherit the secrets of their project and
+// of the project's organization.
 type Secret struct {
 	defaultSubject
-	Uuid            string       `json:"uuid"`
-	Name            string       `json:"name"`
-	EnvironmentUuid string       `json:"environmentUuid" db:"environment_uuid"`
-	Type            SecretType   `json:"type"`
-	Status          SecretStatus `json:"status"`
-	ArchivedAt      *time.Time   `json:"archivedAt"      db:"archived_at"`
-	Key             []byte       `json:"-"               db:"key"`
-	SecretBytes     []byte       `json:"-"               db:"-"`
+	Uuid             string               `json:"uuid"`
+	Name             string               `json:"name"`
+	EnvironmentUuid  string               `json:"environmentUuid"  db:"environment_uuid"`
+	ProjectUuid      *string              `json:"projectUuid"      db:"project_uuid"`
+	OrganizationUuid *string              `json:"organizationUuid" db:"organization_uuid"`
+	OverridePolicy   SecretOverridePolicy `json:"overridePolicy"   db:"override_policy"`
+	Type             SecretType           `json:"type"`
+	Status           SecretStatus         `json:"status"`
+	ArchivedAt       *time.Time           `json:"archivedAt"       db:"archived_at"`
+	Key              []byte               `json:"-"                db:"key"`
+	SecretBytes      []byte               `json:"-"                db:"-"`
+
+	// Version is the number of the latest SecretVersion, or 0 if
+	// the value of this secret has not been written yet.
+	Version int `json:"version" db:"version"`
+
+	// AuthorUuid identifies the user writing SecretBytes.  It is
+	// recorded as the creator of the next SecretVersion.
+	AuthorUuid *string `json:"-" db:"-"`
 }
 
 func (self *Secret) IsPending() bool {
@@ -81,9 +130,86 @@ func (self *Secret) IsEnvOverride() bool {
 	return self.Type == SecretEnvOverride
 }
 
+// Scope returns the kind of object this secret belongs to.
+func (self *Secret) Scope() string {
+	switch {
+	case self.OrganizationUuid != nil:
+		return SecretScopeOrganization
+	case self.ProjectUuid != nil:
+		return SecretScopeProject
+	default:
+		return SecretScopeEnvironment
+	}
+}
+
+// IsShared returns true if this secret is inherited by environments
+// instead of belonging to a single environment.
+func (self *Secret) IsShared() bool {
+	return self.Scope() != SecretScopeEnvironment
+}
+
+// Validate checks that this secret belongs to exactly one scope and
+// has a known override policy.
+func (self *Secret) Validate() error {
+	scopes := 0
+	if self.EnvironmentUuid != "" {
+		scopes++
+	}
+	if self.ProjectUuid != nil {
+		scopes++
+	}
+	if self.OrganizationUuid != nil {
+		scopes++
+	}
+
+	if scopes != 1 {
+		return NewValidationError("scope", "exactly_one")
+	}
+
+	switch self.OverridePolicy {
+	case "", SecretOverrideAllow, SecretOverrideDeny:
+	default:
+		return NewValidationError("overridePolicy", "invalid")
+	}
+
+	return nil
+}
+
+// RollbackTo checks whether this secret can be rolled back to version.
+func (self *Secret) RollbackTo(version *SecretVersion) error {
+	if version.SecretUuid != self.Uuid {
+		return NewValidationError("version", "invalid")
+	}
+
+	if version.Version == self.Version {
+		return NewValidationError("version", "current")
+	}
+
+	return nil
+}
+
 // authz.BelongsToProject
 func (self *Secret) FindProject(store ProjectStore) (*Project, error) {
-	return store.FindByEnvironmentUuid(self.EnvironmentUuid)
+	switch self.Scope() {
+	case SecretScopeProject:
+		return store.FindByUuid(*self.ProjectUuid)
+	case SecretScopeEnvironment:
+		return store.FindByEnvironmentUuid(self.EnvironmentUuid)
+	default:
+		return nil, new(NotFoundError)
+	}
+}
+
+// authz.BelongsToOrganization
+//
+// Only organization secrets know their organization; for all other
+// secrets it is derived from their project.
+func (self *Secret) FindOrganization(store OrganizationStore) (*Organization, error) {
+	if self.OrganizationUuid == nil {
+		return nil, new(NotFoundError)
+	}
+
+	return store.FindByUuid(*self.OrganizationUuid)
 }
 
 // domain.Subject
@@ -94,7 +220,15 @@ func (self *Secret) OwnUrl(requestScheme, requestBaseUri string) string {
 // domain.Subject
 func (self *Secret) Links(response map[string]map[string]string, requestScheme, requestBaseUri string) map[string]map[string]string {
 	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBaseUri)}
-	response["environment"] = map[string]string{"href": fmt.Sprintf("%s://%s/environments/%s", requestScheme, requestBaseUri, self.EnvironmentUuid)}
+	switch self.Scope() {
+	case SecretScopeOrganization:
+		response["organization"] = map[string]string{"href": fmt.Sprintf("%s://%s/organizations/%s", requestScheme, requestBaseUri, *self.OrganizationUuid)}
+	case SecretScopeProject:
+		response["project"] = map[string]string{"href": fmt.Sprintf("%s://%s/projects/%s", requestScheme, requestBaseUri, *self.ProjectUuid)}
+	default:
+		response["environment"] = map[string]string{"href": fmt.Sprintf("%s://%s/environments/%s", requestScheme, requestBaseUri, self.EnvironmentUuid)}
+	}
+	response["versions"] = map[string]string{"href": fmt.Sprintf("%s/versions", self.OwnUrl(requestScheme, requestBaseUri))}
 	return response
 }
 
@@ -102,3 +236,54 @@ func (self *Secret) Links(response map[string]map[string]string, requestScheme,
 func (self *Secret) AuthorizationName() string {
 	return "secret"
 }
+
+// secretScopeLevel orders scopes from the broadest to the narrowest.
+var secretScopeLevel = map[string]int{
+	SecretScopeOrganization: 0,
+	SecretScopeProject:      1,
+	SecretScopeEnvironment:  2,
+}
+
+// overrides returns true if self takes precedence over other, which
+// has the same type and name.  A secret in a narrower scope overrides
+// one in a broader scope, unless the broader secret denies overrides.
+func (self *Secret) overrides(other *Secret) bool {
+	selfLevel, otherLevel := secretScopeLevel[self.Scope()], secretScopeLevel[other.Scope()]
+	if selfLevel == otherLevel {
+		return false
+	}
+
+	if selfLevel < otherLevel {
+		return self.OverridePolicy == SecretOverrideDeny
+	}
+
+	return other.OverridePolicy != SecretOverrideDeny
+}
+
+// ResolveSecrets determines the secrets an environment ends up with,
+// given its own secrets and the ones it inherits from its project and
+// organization.  Secrets are identified by their type and name; of
+// secrets with the same identity only those from the scope with
+// precedence are kept.  The order of secrets is otherwise preserved.
+func ResolveSecrets(secrets []*Secret) []*Secret {
+	winners := map[string]*Secret{}
+	for _, secret := range secrets {
+		id := secret.identity()
+		if winner, found := winners[id]; !found || secret.overrides(winner) {
+			winners[id] = secret
+		}
+	}
+
+	result := []*Secret{}
+	for _, secret := range secrets {
+		if secret.Scope() == winners[secret.identity()].Scope() {
+			result = append(result, secret)
+		}
+	}
+
+	return result
+}
+
+func (self *Secret) identity() string {
+	return string(self.Type) + ":" + self.Name
+}
@@ -84,8 +84,103 @@ func Test_Secret_Links(t *testing.T) {
 		"environment": {
 			"href": "https://test.tld/environments/123",
 		},
+		"versions": {
+			"href": "https://test.tld/secrets/456/versions",
+		},
 	}
 	if !reflect.DeepEqual(resp, want) {
 		t.Errorf("url=%#v, want %#v", resp, want)
 	}
 }
+
+func Test_Secret_Links_linksToProjectOfSharedSecret(t *testing.T) {
+	projectUuid := "123"
+	secret := &Secret{Uuid: "456", ProjectUuid: &projectUuid}
+	resp := secret.Links(make(map[string]map[string]string), "https", "test.tld")
+	if _, found := resp["environment"]; found {
+		t.Errorf(`resp["environment"] = %v; want none`, resp["environment"])
+	}
+	if got, want := resp["project"]["href"], "https://test.tld/projects/123"; got != want {
+		t.Errorf(`resp["project"]["href"] = %q; want %q`, got, want)
+	}
+}
+
+func TestSecret_Validate_requiresExactlyOneScope(t *testing.T) {
+	projectUuid := "123"
+	for _, secret := range []*Secret{
+		{},
+		{EnvironmentUuid: "456", ProjectUuid: &projectUuid},
+	} {
+		if got, want := secret.Validate(), NewValidationError("scope", "exactly_one"); !reflect.DeepEqual(got, want) {
+			t.Errorf(`Validate() = %#v; want %#v`, got, want)
+		}
+	}
+}
+
+func TestResolveSecrets_narrowerScopeOverridesBroaderScope(t *testing.T) {
+	organizationUuid, projectUuid := "org", "project"
+	org := &Secret{OrganizationUuid: &organizationUuid, Type: SecretEnv, Name: "REGISTRY_PASSWORD"}
+	project := &Secret{ProjectUuid: &projectUuid, Type: SecretEnv, Name: "REGISTRY_PASSWORD"}
+	env := &Secret{EnvironmentUuid: "env", Type: SecretEnv, Name: "REGISTRY_PASSWORD"}
+
+	resolved := ResolveSecrets([]*Secret{env, project, org})
+	if got, want := resolved, []*Secret{env}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`resolved = %#v; want %#v`, got, want)
+	}
+
+	resolved = ResolveSecrets([]*Secret{project, org})
+	if got, want := resolved, []*Secret{project}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`resolved = %#v; want %#v`, got, want)
+	}
+}
+
+func TestResolveSecrets_broadestSecretDenyingOverridesWins(t *testing.T) {
+	organizationUuid, projectUuid := "org", "project"
+	org := &Secret{OrganizationUuid: &organizationUuid, Type: SecretEnv, Name: "REGISTRY_PASSWORD", OverridePolicy: SecretOverrideDeny}
+	project := &Secret{ProjectUuid: &projectUuid, Type: SecretEnv, Name: "REGISTRY_PASSWORD", OverridePolicy: SecretOverrideDeny}
+	env := &Secret{EnvironmentUuid: "env", Type: SecretEnv, Name: "REGISTRY_PASSWORD"}
+
+	resolved := ResolveSecrets([]*Secret{env, project, org})
+	if got, want := resolved, []*Secret{org}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`resolved = %#v; want %#v`, got, want)
+	}
+}
+
+func TestResolveSecrets_keepsSecretsWithDifferentTypeOrName(t *testing.T) {
+	projectUuid := "project"
+	secrets := []*Secret{
+		{EnvironmentUuid: "env", Type: SecretEnv, Name: "deploy"},
+		{ProjectUuid: &projectUuid, Type: SecretSsh, Name: "deploy"},
+		{ProjectUuid: &projectUuid, Type: SecretEnv, Name: "registry"},
+	}
+
+	if got, want := ResolveSecrets(secrets), secrets; !reflect.DeepEqual(got, want) {
+		t.Errorf(`resolved = %#v; want %#v`, got, want)
+	}
+}
+
+func TestSecret_RollbackTo_failsForCurrentVersion(t *testing.T) {
+	secret := &Secret{Uuid: "a", Version: 2}
+
+	err := secret.RollbackTo(&SecretVersion{SecretUuid: "a", Version: 2})
+	if got, want := err, NewValidationError("version", "current"); !reflect.DeepEqual(got, want) {
+		t.Errorf(`err = %#v; want %#v`, got, want)
+	}
+}
+
+func TestSecret_RollbackTo_failsForVersionOfOtherSecret(t *testing.T) {
+	secret := &Secret{Uuid: "a", Version: 2}
+
+	err := secret.RollbackTo(&SecretVersion{SecretUuid: "b", Version: 1})
+	if got, want := err, NewValidationError("version", "invalid"); !reflect.DeepEqual(got, want) {
+		t.Errorf(`err = %#v; want %#v`, got, want)
+	}
+}
+
+func TestSecret_RollbackTo_acceptsOlderVersion(t *testing.T) {
+	secret := &Secret{Uuid: "a", Version: 2}
+
+	if err := secret.RollbackTo(&SecretVersion{SecretUuid: "a", Version: 1}); err != nil {
+		t.Errorf(`err = %v; want nil`, err)
+	}
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// SecretVersion is an immutable snapshot of the value of a secret.
+// Every write of a secret's value creates a new version; rolling back
+// creates a new version with the value of an older one.
+//
+// Only metadata is kept in the database, the value itself lives in the
+// SecretKeyValueStore under ValueKey, encrypted with the key of the
+// secret.
+type SecretVersion struct {
+	defaultSubject
+
+	Uuid           string    `json:"uuid" db:"uuid"`
+	SecretUuid     string    `json:"secretUuid" db:"secret_uuid"`
+	Version        int       `json:"version" db:"version"`
+	CreatedByUuid  *string   `json:"createdByUuid" db:"created_by_uuid"`
+	RolledBackFrom *int      `json:"rolledBackFrom" db:"rolled_back_from"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// SecretVersionKey returns the key under which the value of the given
+// version of a secret is stored.
+func SecretVersionKey(secretUuid string, version int) string {
+	return fmt.Sprintf("%s:v%d", secretUuid, version)
+}
+
+func (self *SecretVersion) ValueKey() string {
+	return SecretVersionKey(self.SecretUuid, self.Version)
+}
+
+func (self *SecretVersion) OwnUrl(requestScheme, requestBaseUri string) string {
+	return fmt.Sprintf("%s://%s/secrets/%s/versions/%d", requestScheme, requestBaseUri, self.SecretUuid, self.Version)
+}
+
+func (self *SecretVersion) Links(response map[string]map[string]string, requestScheme, requestBaseUri string) map[string]map[string]string {
+	response["secret"] = map[string]string{"href": fmt.Sprintf("%s://%s/secrets/%s", requestScheme, requestBaseUri, self.SecretUuid)}
+	response["operations"] = map[string]string{"href": fmt.Sprintf("%s/operations", self.OwnUrl(requestScheme, requestBaseUri))}
+	response["rollback"] = map[string]string{"href": fmt.Sprintf("%s/rollback", self.OwnUrl(requestScheme, requestBaseUri))}
+	if self.CreatedByUuid != nil {
+		response["createdBy"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s", requestScheme, requestBaseUri, *self.CreatedByUuid)}
+	}
+	return response
+}
+
+// AuthorizationName returns "secret", because access to versions is
+// granted by access to the secret they belong to.
+func (self *SecretVersion) AuthorizationName() string { return "secret" }
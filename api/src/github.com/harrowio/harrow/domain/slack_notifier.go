@@ -18,6 +18,9 @@ type SlackNotifier struct {
 	UrlHost     string `json:"urlHost" db:"url_host"`
 	ProjectUuid string `json:"projectUuid" db:"project_uuid"`
 
+	SubjectTemplate string `json:"subjectTemplate" db:"subject_template"`
+	BodyTemplate    string `json:"bodyTemplate" db:"body_template"`
+
 	ArchivedAt *time.Time `json:"archivedAt" db:"archived_at"`
 }
 
@@ -55,9 +58,20 @@ func (self *SlackNotifier) Validate() error {
 		result.Add("projectUuid", "malformed")
 	}
 
+	self.NotificationTemplate().validateInto(result)
+
 	return result.ToError()
 }
 
+// NotificationTemplate returns the templates for the messages sent by
+// this notifier.  The subject is used as the title of the message.
+func (self *SlackNotifier) NotificationTemplate() *NotificationTemplate {
+	return &NotificationTemplate{
+		Subject: self.SubjectTemplate,
+		Body:    self.BodyTemplate,
+	}
+}
+
 func (self *SlackNotifier) FindProject(projects ProjectStore) (*Project, error) {
 	return projects.FindByUuid(self.ProjectUuid)
 }
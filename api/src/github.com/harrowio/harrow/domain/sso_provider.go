@@ -0,0 +1,292 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql/driver"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+const (
+	SsoProtocolOIDC = "oidc"
+	SsoProtocolSAML = "saml"
+)
+
+// SsoProvider is the single sign-on configuration of an organization.
+// Users whose email address belongs to one of EmailDomains can sign in
+// through the organization's OpenID Connect issuer or SAML identity
+// provider.  Users signing in for the first time are created on the
+// fly.
+//
+// The groups reported by the identity provider in GroupsAttribute are
+// mapped to organization membership types through GroupMappings.
+// Users not belonging to any mapped group get DefaultMembershipType,
+// or are rejected if it is empty.
+//
+// If Enforced is set, members of the organization can no longer sign
+// in with a password or through GitHub.
+type SsoProvider struct {
+	defaultSubject
+
+	Uuid             string `json:"uuid" db:"uuid"`
+	OrganizationUuid string `json:"organizationUuid" db:"organization_uuid"`
+	Protocol         string `json:"protocol" db:"protocol"`
+	Enforced         bool   `json:"enforced" db:"enforced"`
+
+	EmailDomains          SsoEmailDomains  `json:"emailDomains" db:"email_domains"`
+	GroupsAttribute       string           `json:"groupsAttribute" db:"groups_attribute"`
+	GroupMappings         SsoGroupMappings `json:"groupMappings" db:"group_mappings"`
+	DefaultMembershipType string           `json:"defaultMembershipType" db:"default_membership_type"`
+
+	OidcIssuer       string `json:"oidcIssuer" db:"oidc_issuer"`
+	OidcClientId     string `json:"oidcClientId" db:"oidc_client_id"`
+	OidcClientSecret string `json:"-" db:"oidc_client_secret"`
+
+	SamlSsoUrl      string `json:"samlSsoUrl" db:"saml_sso_url"`
+	SamlIssuer      string `json:"samlIssuer" db:"saml_issuer"`
+	SamlCertificate string `json:"samlCertificate" db:"saml_certificate"`
+
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	ArchivedAt *time.Time `json:"archivedAt" db:"archived_at"`
+}
+
+func (self *SsoProvider) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/organizations/%s/sso", requestScheme, requestBase, self.OrganizationUuid)
+}
+
+func (self *SsoProvider) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["organization"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/organizations/%s", requestScheme, requestBase, self.OrganizationUuid),
+	}
+	response["signin"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/sso/%s/signin", requestScheme, requestBase, self.OrganizationUuid),
+	}
+	return response
+}
+
+func (self *SsoProvider) AuthorizationName() string { return "sso-provider" }
+
+func (self *SsoProvider) FindOrganization(store OrganizationStore) (*Organization, error) {
+	return store.FindByUuid(self.OrganizationUuid)
+}
+
+func (self *SsoProvider) Validate() error {
+	result := NewValidationError("", "")
+
+	if !uuidhelper.IsValid(self.OrganizationUuid) {
+		result.Add("organizationUuid", "malformed")
+	}
+
+	if len(self.EmailDomains) == 0 {
+		result.Add("emailDomains", "empty")
+	}
+
+	for _, domain := range self.EmailDomains {
+		if domain == "" || strings.ContainsAny(domain, "@ /") {
+			result.Add("emailDomains", "malformed")
+			break
+		}
+	}
+
+	for _, membershipType := range self.GroupMappings {
+		if MembershipTypeHierarchyLevel(membershipType) == 0 {
+			result.Add("groupMappings", "invalid_membership_type")
+			break
+		}
+	}
+
+	if self.DefaultMembershipType != "" && MembershipTypeHierarchyLevel(self.DefaultMembershipType) == 0 {
+		result.Add("defaultMembershipType", "invalid")
+	}
+
+	switch self.Protocol {
+	case SsoProtocolOIDC:
+		if !isAbsoluteUrl(self.OidcIssuer) {
+			result.Add("oidcIssuer", "malformed")
+		}
+		if self.OidcClientId == "" {
+			result.Add("oidcClientId", "empty")
+		}
+		if self.OidcClientSecret == "" {
+			result.Add("oidcClientSecret", "empty")
+		}
+	case SsoProtocolSAML:
+		if !isAbsoluteUrl(self.SamlSsoUrl) {
+			result.Add("samlSsoUrl", "malformed")
+		}
+		if self.SamlIssuer == "" {
+			result.Add("samlIssuer", "empty")
+		}
+		if _, err := self.SamlCertificates(); err != nil {
+			result.Add("samlCertificate", "malformed")
+		}
+	default:
+		result.Add("protocol", "unsupported")
+	}
+
+	return result.ToError()
+}
+
+func isAbsoluteUrl(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.IsAbs() && parsed.Host != ""
+}
+
+// SamlCertificates parses the PEM encoded certificates with which the
+// identity provider signs its responses.
+func (self *SsoProvider) SamlCertificates() ([]*x509.Certificate, error) {
+	result := []*x509.Certificate{}
+	rest := []byte(self.SamlCertificate)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, certificate)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("SsoProvider: no certificate found")
+	}
+
+	return result, nil
+}
+
+// AcceptsEmail returns true if email belongs to one of the provider's
+// email domains.
+func (self *SsoProvider) AcceptsEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	emailDomain := strings.ToLower(email[at+1:])
+	for _, domain := range self.EmailDomains {
+		if strings.ToLower(domain) == emailDomain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MembershipTypeFor returns the highest membership type any of groups
+// is mapped to, or DefaultMembershipType if none of them are mapped.
+func (self *SsoProvider) MembershipTypeFor(groups []string) string {
+	result := ""
+	for _, group := range groups {
+		membershipType, found := self.GroupMappings[group]
+		if !found {
+			continue
+		}
+
+		if MembershipTypeHierarchyLevel(membershipType) > MembershipTypeHierarchyLevel(result) {
+			result = membershipType
+		}
+	}
+
+	if result == "" {
+		return self.DefaultMembershipType
+	}
+
+	return result
+}
+
+// SsoIdentity links the subject identifier issued by an SsoProvider
+// to a user.
+type SsoIdentity struct {
+	Uuid         string     `json:"uuid" db:"uuid"`
+	ProviderUuid string     `json:"providerUuid" db:"provider_uuid"`
+	Subject      string     `json:"subject" db:"subject"`
+	UserUuid     string     `json:"userUuid" db:"user_uuid"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	LastLoginAt  *time.Time `json:"lastLoginAt" db:"last_login_at"`
+}
+
+// SsoLink is an identity reported by an SsoProvider for the email
+// address of an existing user, waiting for that user to confirm that
+// it is theirs.  Identities are never linked by email address alone,
+// since organizations choose their email domains and identity
+// providers freely.
+type SsoLink struct {
+	UserUuid     string    `json:"userUuid"`
+	ProviderUuid string    `json:"providerUuid"`
+	Subject      string    `json:"subject"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// HMAC returns the signature sent to the user for confirming the
+// link.
+func (self *SsoLink) HMAC(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, field := range []string{self.UserUuid, self.ProviderUuid, self.Subject, strconv.FormatInt(self.ExpiresAt.Unix(), 10)} {
+		mac.Write([]byte(field))
+		mac.Write([]byte{0})
+	}
+	return mac.Sum(nil)
+}
+
+// Expired returns true if the link can no longer be confirmed.
+func (self *SsoLink) Expired(now time.Time) bool {
+	return !now.Before(self.ExpiresAt)
+}
+
+// SsoEmailDomains is the list of email domains bound to an
+// SsoProvider, stored as JSON.
+type SsoEmailDomains []string
+
+func (self SsoEmailDomains) Value() (driver.Value, error) {
+	if self == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(self))
+}
+
+func (self *SsoEmailDomains) Scan(data interface{}) error {
+	switch raw := data.(type) {
+	case []byte:
+		return json.Unmarshal(raw, (*[]string)(self))
+	case string:
+		return json.Unmarshal([]byte(raw), (*[]string)(self))
+	default:
+		return fmt.Errorf("SsoEmailDomains: cannot scan from %T", data)
+	}
+}
+
+// SsoGroupMappings maps group names reported by an identity provider
+// to organization membership types, stored as JSON.
+type SsoGroupMappings map[string]string
+
+func (self SsoGroupMappings) Value() (driver.Value, error) {
+	if self == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(self))
+}
+
+func (self *SsoGroupMappings) Scan(data interface{}) error {
+	switch raw := data.(type) {
+	case []byte:
+		return json.Unmarshal(raw, (*map[string]string)(self))
+	case string:
+		return json.Unmarshal([]byte(raw), (*map[string]string)(self))
+	default:
+		return fmt.Errorf("SsoGroupMappings: cannot scan from %T", data)
+	}
+}
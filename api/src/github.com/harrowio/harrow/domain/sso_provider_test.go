@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func validOidcSsoProvider() *SsoProvider {
+	return &SsoProvider{
+		OrganizationUuid: "1ac7a8c6-bd5f-4d59-8ea2-b86d8e85a3a8",
+		Protocol:         SsoProtocolOIDC,
+		EmailDomains:     SsoEmailDomains{"example.com"},
+		GroupsAttribute:  "groups",
+		GroupMappings: SsoGroupMappings{
+			"developers": MembershipTypeMember,
+			"admins":     MembershipTypeOwner,
+		},
+		OidcIssuer:       "https://idp.example.com",
+		OidcClientId:     "harrow",
+		OidcClientSecret: "secret",
+	}
+}
+
+func TestSsoProvider_Validate_acceptsValidOidcProvider(t *testing.T) {
+	if err := validOidcSsoProvider().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSsoProvider_Validate_rejectsUnknownMembershipTypes(t *testing.T) {
+	provider := validOidcSsoProvider()
+	provider.GroupMappings["developers"] = "superuser"
+
+	err, ok := provider.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`provider.Validate() = %v; want *ValidationError`, provider.Validate())
+	}
+
+	if got, want := err.Get("groupMappings"), "invalid_membership_type"; got != want {
+		t.Errorf(`err.Get("groupMappings") = %q; want %q`, got, want)
+	}
+}
+
+func TestSsoProvider_Validate_requiresCertificateForSaml(t *testing.T) {
+	provider := validOidcSsoProvider()
+	provider.Protocol = SsoProtocolSAML
+	provider.SamlSsoUrl = "https://idp.example.com/sso"
+	provider.SamlIssuer = "https://idp.example.com"
+	provider.SamlCertificate = "not a certificate"
+
+	err, ok := provider.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf(`provider.Validate() = %v; want *ValidationError`, provider.Validate())
+	}
+
+	if got, want := err.Get("samlCertificate"), "malformed"; got != want {
+		t.Errorf(`err.Get("samlCertificate") = %q; want %q`, got, want)
+	}
+}
+
+func TestSsoProvider_AcceptsEmail_matchesDomainCaseInsensitively(t *testing.T) {
+	provider := validOidcSsoProvider()
+
+	if !provider.AcceptsEmail("jane@Example.COM") {
+		t.Errorf("Expected provider to accept email in its domain")
+	}
+
+	if provider.AcceptsEmail("jane@example.com.evil.org") {
+		t.Errorf("Expected provider not to accept email in another domain")
+	}
+}
+
+func TestSsoProvider_MembershipTypeFor_returnsHighestMappedType(t *testing.T) {
+	provider := validOidcSsoProvider()
+
+	if got, want := provider.MembershipTypeFor([]string{"developers", "admins"}), MembershipTypeOwner; got != want {
+		t.Errorf(`MembershipTypeFor(developers, admins) = %q; want %q`, got, want)
+	}
+
+	if got, want := provider.MembershipTypeFor([]string{"marketing"}), ""; got != want {
+		t.Errorf(`MembershipTypeFor(marketing) = %q; want %q`, got, want)
+	}
+
+	provider.DefaultMembershipType = MembershipTypeGuest
+	if got, want := provider.MembershipTypeFor(nil), MembershipTypeGuest; got != want {
+		t.Errorf(`MembershipTypeFor(nil) = %q; want %q`, got, want)
+	}
+}
+
+func TestSsoLink_HMAC_coversEveryField(t *testing.T) {
+	expiresAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	link := &SsoLink{
+		UserUuid:     "8a8ab9bd-4a34-4a0b-9ca6-5df4b8e6f7a1",
+		ProviderUuid: "1ac7a8c6-bd5f-4d59-8ea2-b86d8e85a3a8",
+		Subject:      "jane",
+		ExpiresAt:    expiresAt,
+	}
+	key := []byte("secret")
+	mac := link.HMAC(key)
+
+	for _, changed := range []*SsoLink{
+		{UserUuid: "3f0c2b8e-9d61-4c0e-a1b5-0b8f2d7c6e4a", ProviderUuid: link.ProviderUuid, Subject: link.Subject, ExpiresAt: expiresAt},
+		{UserUuid: link.UserUuid, ProviderUuid: link.ProviderUuid, Subject: "mallory", ExpiresAt: expiresAt},
+		{UserUuid: link.UserUuid, ProviderUuid: link.ProviderUuid, Subject: link.Subject, ExpiresAt: expiresAt.Add(time.Hour)},
+	} {
+		if bytes.Equal(changed.HMAC(key), mac) {
+			t.Errorf("Expected HMAC of %#v to differ", changed)
+		}
+	}
+
+	if !link.Expired(expiresAt) {
+		t.Errorf("Expected link to expire at %s", expiresAt)
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/harrowio/harrow/uuidhelper"
@@ -14,6 +15,11 @@ type Stencil struct {
 	UserUuid       string `json:"userUuid,omitempty"`
 	NotifyViaEmail string `json:"notifyViaEmail,omitempty"`
 	UrlHost        string `json:"urlHost,omitempty"`
+
+	// NotifyViaChat is the type of chat notifier to set up, e.g.
+	// "teams_notifiers", posting to ChatWebhookURL.
+	NotifyViaChat  string `json:"notifyViaChat,omitempty"`
+	ChatWebhookURL string `json:"chatWebhookURL,omitempty"`
 }
 
 func (self *Stencil) OwnUrl(requestScheme, requestBase string) string {
@@ -51,6 +57,20 @@ func (self *Stencil) Validate() error {
 		}
 	}
 
+	if self.NotifyViaChat != "" {
+		if !IsChatNotifierType(self.NotifyViaChat) {
+			err.Add("notifyViaChat", "unknown")
+		}
+
+		if webhookURL, parseErr := url.Parse(self.ChatWebhookURL); parseErr != nil || webhookURL.Host == "" {
+			err.Add("chatWebhookURL", "malformed")
+		}
+
+		if self.UrlHost == "" {
+			err.Add("urlHost", "empty")
+		}
+	}
+
 	if !uuidhelper.IsValid(self.UserUuid) {
 		err.Add("userUuid", "malformed")
 	}
@@ -113,6 +113,27 @@ func (self *CapistranoRails) Create() error {
 		}
 	}
 
+	if domain.IsChatNotifierType(self.conf.NotifyViaChat) {
+		chatNotifier := project.NewChatNotifier(self.conf.NotifyViaChat, self.conf.ChatWebhookURL, self.conf.UrlHost)
+		if err := self.conf.ChatNotifiers.CreateChatNotifier(chatNotifier); err != nil {
+			errors.Add("CreateChatNotifier", chatNotifier, err)
+		}
+
+		for _, job := range []*domain.Job{runTestsJob, deployToStagingJob} {
+			notifyAboutFailures := project.NewNotificationRule(
+				chatNotifier.NotifierType,
+				chatNotifier.Uuid,
+				job.Uuid,
+				"operation.failed",
+			)
+			notifyAboutFailures.CreatorUuid = self.conf.UserUuid
+
+			if err := self.conf.NotificationRules.CreateNotificationRule(notifyAboutFailures); err != nil {
+				errors.Add("CreateNotificationRule", notifyAboutFailures, err)
+			}
+		}
+	}
+
 	runNotesWeekly := notesJob.NewRecurringSchedule(self.conf.UserUuid, "@weekly")
 	if err := self.conf.Schedules.CreateSchedule(runNotesWeekly); err != nil {
 		errors.Add("CreateSchedule", runNotesWeekly, err)
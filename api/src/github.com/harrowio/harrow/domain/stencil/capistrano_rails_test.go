@@ -331,6 +331,65 @@ func TestCapistranoRails_does_not_notify_via_email_if_invalid_address_is_provide
 	}
 }
 
+func TestCapistranoRails_creates_a_chat_notifier_for_failures_if_a_chat_is_provided(t *testing.T) {
+	projectUuid := "ded9add4-ab9a-400f-81d6-05b9b51c670d"
+	jobs := NewJobsInMemory()
+	chatNotifiers := NewChatNotifiersInMemory()
+	notificationRules := NewNotificationRulesInMemory()
+	configuration := &Configuration{
+		UserUuid:       "8978dce1-269a-4c6e-8fde-dcd83bdb4ace",
+		UrlHost:        "www.app.harrow.io",
+		NotifyViaChat:  domain.ChatNotifierTeams,
+		ChatWebhookURL: "https://example.webhook.office.com/webhookb2/1",
+
+		Environments: NewEnvironmentsInMemory(),
+		Projects: NewProjectsInMemory().Add(&domain.Project{
+			Uuid: projectUuid,
+		}),
+		ProjectUuid:       projectUuid,
+		Tasks:             NewTasksInMemory(),
+		Jobs:              jobs,
+		EmailNotifiers:    NewEmailNotifiersInMemory(),
+		ChatNotifiers:     chatNotifiers,
+		NotificationRules: notificationRules,
+		Schedules:         NewSchedulesInMemory(),
+		GitTriggers:       NewGitTriggersInMemory(),
+		Secrets:           NewSecretsInMemory(),
+		Users:             NewUsersInMemory(),
+		Webhooks:          NewWebhooksInMemory(),
+	}
+	stencil := NewCapistranoRails(configuration)
+
+	if err := stencil.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	chatNotifier := chatNotifiers.FindByWebhookURL(configuration.ChatWebhookURL)
+	if chatNotifier == nil {
+		t.Fatalf(`chatNotifier is nil`)
+	}
+
+	if got, want := chatNotifier.NotifierType, domain.ChatNotifierTeams; got != want {
+		t.Errorf(`chatNotifier.NotifierType = %v; want %v`, got, want)
+	}
+
+	for _, jobName := range []string{"Test - Run tests", "Staging - Deploy"} {
+		job, err := jobs.FindJobByName(jobName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		notificationRule, err := notificationRules.FindByNotifierAndJobUuidAndType(chatNotifier.Uuid, job.Uuid, domain.ChatNotifierTeams)
+		if err != nil {
+			t.Fatalf("notification rule for %q: %s", jobName, err)
+		}
+
+		if got, want := notificationRule.MatchActivity, "operation.failed"; got != want {
+			t.Errorf(`notificationRule.MatchActivity = %v; want %v`, got, want)
+		}
+	}
+}
+
 func TestCapistranoRails_schedules_notes_task_to_run_weekly(t *testing.T) {
 	projectUuid := "ded9add4-ab9a-400f-81d6-05b9b51c670d"
 	environments := NewEnvironmentsInMemory()
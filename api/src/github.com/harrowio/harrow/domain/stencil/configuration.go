@@ -17,6 +17,12 @@ type Configuration struct {
 	// notifier, if it is set to any string containing an "@".
 	NotifyViaEmail string
 
+	// NotifyViaChat causes the stencil to set up a chat notifier
+	// of this type, e.g. "slack_notifiers", posting to
+	// ChatWebhookURL.
+	NotifyViaChat  string
+	ChatWebhookURL string
+
 	// UrlHost is the host name to be used when generating URLs in
 	// notifiers.
 	UrlHost string
@@ -26,6 +32,7 @@ type Configuration struct {
 	Tasks             TaskStore
 	Jobs              JobStore
 	EmailNotifiers    EmailNotifierStore
+	ChatNotifiers     ChatNotifierStore
 	JobNotifiers      JobNotifierStore
 	NotificationRules NotificationRuleStore
 	Schedules         ScheduleStore
@@ -71,6 +78,12 @@ type EmailNotifierStore interface {
 	CreateEmailNotifier(notifier *domain.EmailNotifier) error
 }
 
+// ChatNotifierStore allows a stencil to create chat notifiers for a
+// project.
+type ChatNotifierStore interface {
+	CreateChatNotifier(notifier *domain.ChatNotifier) error
+}
+
 // NotificationRuleStore allows a stencil to create notification rules
 // for wiring up notifiers with jobs.
 type NotificationRuleStore interface {
@@ -191,6 +191,38 @@ func (self *EmailNotifiersInMemory) FindByEmailAddress(address string) *domain.E
 	return nil
 }
 
+type ChatNotifiersInMemory struct {
+	chatNotifiers map[string]*domain.ChatNotifier
+}
+
+func NewChatNotifiersInMemory() *ChatNotifiersInMemory {
+	return &ChatNotifiersInMemory{
+		chatNotifiers: map[string]*domain.ChatNotifier{},
+	}
+}
+
+// CreateChatNotifier stores chatNotifier in memory.
+func (self *ChatNotifiersInMemory) CreateChatNotifier(chatNotifier *domain.ChatNotifier) error {
+	if !uuidhelper.IsValid(chatNotifier.Uuid) {
+		chatNotifier.Uuid = uuidhelper.MustNewV4()
+	}
+
+	self.chatNotifiers[chatNotifier.Uuid] = chatNotifier
+	return nil
+}
+
+// FindByWebhookURL returns a chat notifier posting to webhookURL or
+// nil if no such notifier has been stored.
+func (self *ChatNotifiersInMemory) FindByWebhookURL(webhookURL string) *domain.ChatNotifier {
+	for _, notifier := range self.chatNotifiers {
+		if notifier.WebhookURL == webhookURL {
+			return notifier
+		}
+	}
+
+	return nil
+}
+
 type NotificationRulesInMemory struct {
 	notificationRules map[string]*domain.NotificationRule
 }
@@ -6,12 +6,29 @@ import (
 	"github.com/harrowio/harrow/uuidhelper"
 )
 
+// Delivery modes of a subscription.  Events of subscriptions delivered
+// immediately are mailed one by one, all others are collected into a
+// digest per project that is mailed once per period.
+const (
+	SubscriptionDeliveryImmediate = "immediate"
+	SubscriptionDeliveryDaily     = "daily"
+	SubscriptionDeliveryWeekly    = "weekly"
+)
+
+// SubscriptionDigestDeliveries lists the delivery modes that collect
+// events into digests.
+var SubscriptionDigestDeliveries = []string{
+	SubscriptionDeliveryDaily,
+	SubscriptionDeliveryWeekly,
+}
+
 type Subscription struct {
 	Uuid          string     `json:"uuid" db:"uuid"`
 	UserUuid      string     `json:"userUuid" db:"user_uuid"`
 	WatchableUuid string     `json:"watchableUuid" db:"watchable_uuid"`
 	WatchableType string     `json:"watchableType" db:"watchable_type"`
 	EventName     string     `json:"eventName" db:"event_name"`
+	Delivery      string     `json:"delivery" db:"delivery"`
 	ArchivedAt    *time.Time `json:"archivedAt" db:"archived_at"`
 	CreatedAt     *time.Time `json:"createdAt" db:"created_at"`
 }
@@ -23,7 +40,32 @@ func NewSubscription(watchable Watchable, event, userUuid string) *Subscription
 		WatchableUuid: watchable.Id(),
 		WatchableType: watchable.WatchableType(),
 		EventName:     event,
+		Delivery:      SubscriptionDeliveryImmediate,
+	}
+}
+
+// IsSubscriptionDelivery returns true if delivery is a known delivery
+// mode.
+func IsSubscriptionDelivery(delivery string) bool {
+	switch delivery {
+	case SubscriptionDeliveryImmediate, SubscriptionDeliveryDaily, SubscriptionDeliveryWeekly:
+		return true
+	}
+
+	return false
+}
+
+// DigestPeriod returns the time covered by a single digest of the
+// given delivery mode, or zero for immediate delivery.
+func DigestPeriod(delivery string) time.Duration {
+	switch delivery {
+	case SubscriptionDeliveryDaily:
+		return 24 * time.Hour
+	case SubscriptionDeliveryWeekly:
+		return 7 * 24 * time.Hour
 	}
+
+	return 0
 }
 
 func (self *Subscription) AuthorizationName() string { return "subscription" }
@@ -10,6 +10,10 @@ type Subscriptions struct {
 	WatchableUuid string          `json:"watchableUuid"`
 	WatchableType string          `json:"watchableType"`
 	Subscribed    map[string]bool `json:"subscribed"`
+
+	// Delivery is how events are delivered to the watcher, one of
+	// the SubscriptionDelivery* constants.
+	Delivery string `json:"delivery"`
 }
 
 func (self *Subscriptions) OwnUrl(requestScheme, requestBase string) string {
@@ -0,0 +1,35 @@
+package domain
+
+import "fmt"
+
+// TeamsNotifier posts messages to an incoming webhook of a Microsoft Teams channel.
+type TeamsNotifier struct {
+	defaultSubject
+	ChatWebhookNotifier
+}
+
+func (self *TeamsNotifier) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/teams-notifiers/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *TeamsNotifier) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{
+		"href": self.OwnUrl(requestScheme, requestBase),
+	}
+
+	return response
+}
+
+func (self *TeamsNotifier) Validate() error {
+	result := NewValidationError("", "")
+	self.validateWebhookInto(result, hostIn(".webhook.office.com", "outlook.office.com", "outlook.office365.com"))
+	return result.ToError()
+}
+
+func (self *TeamsNotifier) NotifierType() string {
+	return ChatNotifierTeams
+}
+
+func (self *TeamsNotifier) AuthorizationName() string {
+	return "teams-notifier"
+}
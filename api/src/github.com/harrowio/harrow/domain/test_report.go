@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FlakyTestMinFlips is the number of times a test needs to change
+// between passing and failing to be considered flaky.  A single change
+// just means that the test broke (or got fixed).
+const FlakyTestMinFlips = 2
+
+// TestReport summarizes the test results reported by a single
+// operation.
+type TestReport struct {
+	defaultSubject
+
+	OperationUuid string  `json:"operationUuid"`
+	Total         int     `json:"total"`
+	Passed        int     `json:"passed"`
+	Failed        int     `json:"failed"`
+	Skipped       int     `json:"skipped"`
+	Duration      float64 `json:"duration"`
+
+	// Failures lists all tests that failed or errored.
+	Failures []*TestResult `json:"failures"`
+
+	// Tests lists all reported tests, slowest first.
+	Tests []*TestResult `json:"tests"`
+}
+
+// NewTestReport builds the test report for operation.
+func NewTestReport(operation *Operation) *TestReport {
+	results := operation.TestResults
+	if results == nil {
+		results = NewTestResults()
+	}
+
+	tests := make([]*TestResult, len(results.Entries))
+	copy(tests, results.Entries)
+	sort.Stable(testResultsBySlowest(tests))
+
+	return &TestReport{
+		OperationUuid: operation.Uuid,
+		Total:         len(results.Entries),
+		Passed:        results.Count(TestStatusPassed),
+		Failed:        len(results.Failed()),
+		Skipped:       results.Count(TestStatusSkipped),
+		Duration:      results.Duration(),
+		Failures:      results.Failed(),
+		Tests:         tests,
+	}
+}
+
+func (self *TestReport) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/operations/%s/test-results", requestScheme, requestBase, self.OperationUuid)
+}
+
+func (self *TestReport) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["operation"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/operations/%s", requestScheme, requestBase, self.OperationUuid),
+	}
+	return response
+}
+
+type testResultsBySlowest []*TestResult
+
+func (self testResultsBySlowest) Len() int           { return len(self) }
+func (self testResultsBySlowest) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self testResultsBySlowest) Less(i, j int) bool { return self[i].Duration > self[j].Duration }
+
+// FlakyTest is a test whose outcome changed between passing and
+// failing across recent runs of the same job.
+type FlakyTest struct {
+	Suite     string `json:"suite"`
+	ClassName string `json:"className"`
+	Name      string `json:"name"`
+
+	// Runs is the number of analyzed operations in which this
+	// test passed or failed.
+	Runs int `json:"runs"`
+
+	// Failures is the number of analyzed operations in which this
+	// test failed.
+	Failures int `json:"failures"`
+
+	// Flips is the number of times the outcome of this test
+	// changed between consecutive runs.
+	Flips int `json:"flips"`
+
+	// LastStatus is the status of the test in the most recent
+	// operation that reported it.
+	LastStatus string `json:"lastStatus"`
+
+	// LastFailedOperationUuid is the uuid of the most recent
+	// operation in which this test failed.
+	LastFailedOperationUuid string `json:"lastFailedOperationUuid"`
+}
+
+// FlakyTests lists the flaky tests of a job.
+type FlakyTests struct {
+	defaultSubject
+
+	JobUuid            string       `json:"jobUuid"`
+	OperationsAnalyzed int          `json:"operationsAnalyzed"`
+	Tests              []*FlakyTest `json:"tests"`
+}
+
+// FindFlakyTests analyzes the test results of operations, which are
+// expected to be the most recent operations of job, newest first.
+// Operations that have not finished yet or have not reported any test
+// results are ignored.  Skipped tests do not count as runs.
+func FindFlakyTests(jobUuid string, operations []*Operation) *FlakyTests {
+	result := &FlakyTests{
+		JobUuid: jobUuid,
+		Tests:   []*FlakyTest{},
+	}
+
+	seen := map[string]*FlakyTest{}
+	order := []string{}
+	for i := len(operations) - 1; i >= 0; i-- {
+		operation := operations[i]
+		if operation.FinishedAt == nil || operation.TestResults == nil || len(operation.TestResults.Entries) == 0 {
+			continue
+		}
+
+		result.OperationsAnalyzed++
+		for _, entry := range operation.TestResults.Entries {
+			if !entry.Passed() && !entry.Failed() {
+				continue
+			}
+
+			key := entry.Key()
+			test, found := seen[key]
+			if !found {
+				test = &FlakyTest{
+					Suite:     entry.Suite,
+					ClassName: entry.ClassName,
+					Name:      entry.Name,
+				}
+				seen[key] = test
+				order = append(order, key)
+			} else if entry.Failed() != (test.LastStatus == TestStatusFailed) {
+				test.Flips++
+			}
+
+			test.Runs++
+			test.LastStatus = TestStatusPassed
+			if entry.Failed() {
+				test.Failures++
+				test.LastStatus = TestStatusFailed
+				test.LastFailedOperationUuid = operation.Uuid
+			}
+		}
+	}
+
+	for _, key := range order {
+		if test := seen[key]; test.Flips >= FlakyTestMinFlips {
+			result.Tests = append(result.Tests, test)
+		}
+	}
+
+	sort.Stable(flakyTestsByFlips(result.Tests))
+
+	return result
+}
+
+type flakyTestsByFlips []*FlakyTest
+
+func (self flakyTestsByFlips) Len() int           { return len(self) }
+func (self flakyTestsByFlips) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self flakyTestsByFlips) Less(i, j int) bool { return self[i].Flips > self[j].Flips }
+
+func (self *FlakyTests) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/jobs/%s/flaky-tests", requestScheme, requestBase, self.JobUuid)
+}
+
+func (self *FlakyTests) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBase)}
+	response["job"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/jobs/%s", requestScheme, requestBase, self.JobUuid),
+	}
+	return response
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func operationWithTestResults(uuid string, statuses map[string]string) *Operation {
+	now := time.Now()
+	results := NewTestResults()
+	for name, status := range statuses {
+		results.Entries = append(results.Entries, &TestResult{
+			Suite:  "suite",
+			Name:   name,
+			Status: status,
+		})
+	}
+
+	return &Operation{
+		Uuid:        uuid,
+		FinishedAt:  &now,
+		TestResults: results,
+	}
+}
+
+func TestFindFlakyTests_returnsTestsThatFlipBetweenPassingAndFailing(t *testing.T) {
+	// newest first
+	operations := []*Operation{
+		operationWithTestResults("op-4", map[string]string{"flaky": TestStatusPassed, "broken": TestStatusFailed, "stable": TestStatusPassed}),
+		operationWithTestResults("op-3", map[string]string{"flaky": TestStatusFailed, "broken": TestStatusFailed, "stable": TestStatusPassed}),
+		operationWithTestResults("op-2", map[string]string{"flaky": TestStatusPassed, "broken": TestStatusPassed, "stable": TestStatusSkipped}),
+		operationWithTestResults("op-1", map[string]string{"flaky": TestStatusFailed, "broken": TestStatusPassed, "stable": TestStatusPassed}),
+	}
+
+	flaky := FindFlakyTests("job-uuid", operations)
+
+	if got, want := flaky.OperationsAnalyzed, 4; got != want {
+		t.Errorf(`flaky.OperationsAnalyzed = %v; want %v`, got, want)
+	}
+
+	if got, want := len(flaky.Tests), 1; got != want {
+		t.Fatalf(`len(flaky.Tests) = %v; want %v`, got, want)
+	}
+
+	test := flaky.Tests[0]
+	if got, want := test.Name, "flaky"; got != want {
+		t.Errorf(`test.Name = %q; want %q`, got, want)
+	}
+
+	if got, want := test.Flips, 3; got != want {
+		t.Errorf(`test.Flips = %v; want %v`, got, want)
+	}
+
+	if got, want := test.Failures, 2; got != want {
+		t.Errorf(`test.Failures = %v; want %v`, got, want)
+	}
+
+	if got, want := test.LastStatus, TestStatusPassed; got != want {
+		t.Errorf(`test.LastStatus = %q; want %q`, got, want)
+	}
+
+	if got, want := test.LastFailedOperationUuid, "op-3"; got != want {
+		t.Errorf(`test.LastFailedOperationUuid = %q; want %q`, got, want)
+	}
+}
+
+func TestFindFlakyTests_ignoresUnfinishedOperations(t *testing.T) {
+	unfinished := operationWithTestResults("op-2", map[string]string{"test": TestStatusFailed})
+	unfinished.FinishedAt = nil
+	operations := []*Operation{
+		unfinished,
+		operationWithTestResults("op-1", map[string]string{"test": TestStatusPassed}),
+	}
+
+	flaky := FindFlakyTests("job-uuid", operations)
+
+	if got, want := flaky.OperationsAnalyzed, 1; got != want {
+		t.Errorf(`flaky.OperationsAnalyzed = %v; want %v`, got, want)
+	}
+}
+
+func TestNewTestReport_listsTestsSlowestFirst(t *testing.T) {
+	operation := &Operation{
+		Uuid: "op-1",
+		TestResults: &TestResults{
+			Entries: []*TestResult{
+				{Name: "fast", Status: TestStatusPassed, Duration: 0.1},
+				{Name: "slow", Status: TestStatusFailed, Duration: 3},
+				{Name: "skipped", Status: TestStatusSkipped},
+			},
+		},
+	}
+
+	report := NewTestReport(operation)
+
+	if got, want := report.Tests[0].Name, "slow"; got != want {
+		t.Errorf(`report.Tests[0].Name = %q; want %q`, got, want)
+	}
+
+	if got, want := report.Failed, 1; got != want {
+		t.Errorf(`report.Failed = %v; want %v`, got, want)
+	}
+
+	if got, want := report.Skipped, 1; got != want {
+		t.Errorf(`report.Skipped = %v; want %v`, got, want)
+	}
+}
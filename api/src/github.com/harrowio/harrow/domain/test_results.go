@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	TestStatusPassed  = "passed"
+	TestStatusFailed  = "failed"
+	TestStatusError   = "error"
+	TestStatusSkipped = "skipped"
+)
+
+// TestResults holds the results of all test cases reported by an
+// operation through `hevent test-report`.
+type TestResults struct {
+	Entries []*TestResult `json:"entries"`
+}
+
+// TestResult is the outcome of a single test case.
+type TestResult struct {
+	Suite     string `json:"suite"`
+	ClassName string `json:"className"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+
+	// Duration is the time it took to run the test, in seconds.
+	Duration float64 `json:"duration"`
+
+	// Message is the failure or error message reported for the
+	// test, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// Key returns a string identifying this test across different runs
+// of the same job.
+func (self *TestResult) Key() string {
+	return fmt.Sprintf("%s/%s/%s", self.Suite, self.ClassName, self.Name)
+}
+
+// DisplayName returns a short, human readable name for the test.
+func (self *TestResult) DisplayName() string {
+	if self.ClassName == "" {
+		return self.Name
+	}
+
+	return self.ClassName + "." + self.Name
+}
+
+// Failed returns true if the test failed or could not be run because
+// of an error.
+func (self *TestResult) Failed() bool {
+	return self.Status == TestStatusFailed || self.Status == TestStatusError
+}
+
+// Passed returns true if the test ran successfully.
+func (self *TestResult) Passed() bool {
+	return self.Status == TestStatusPassed
+}
+
+// NewTestResults returns an empty list of test results.
+func NewTestResults() *TestResults {
+	return &TestResults{
+		Entries: []*TestResult{},
+	}
+}
+
+// HandleEvent adds the results transmitted in a "test-report" event.
+// The results are expected to be a JSON array in the "results" field
+// of the payload.
+func (self *TestResults) HandleEvent(payload EventPayload) {
+	if payload.Get("event") != "test-report" {
+		return
+	}
+
+	results := []*TestResult{}
+	if err := json.Unmarshal([]byte(payload.Get("results")), &results); err != nil {
+		return
+	}
+
+	self.Entries = append(self.Entries, results...)
+}
+
+// Failed returns all failed tests in the order they were reported.
+func (self *TestResults) Failed() []*TestResult {
+	result := []*TestResult{}
+	for _, entry := range self.Entries {
+		if entry.Failed() {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// FailedNames returns the display names of all failed tests.
+func (self *TestResults) FailedNames() []string {
+	result := []string{}
+	for _, entry := range self.Failed() {
+		result = append(result, entry.DisplayName())
+	}
+
+	return result
+}
+
+// Count returns the number of tests with the given status.
+func (self *TestResults) Count(status string) int {
+	n := 0
+	for _, entry := range self.Entries {
+		if entry.Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Duration returns the sum of the durations of all tests, in seconds.
+func (self *TestResults) Duration() float64 {
+	total := 0.0
+	for _, entry := range self.Entries {
+		total += entry.Duration
+	}
+
+	return total
+}
+
+// Value serializes the results as JSON.
+func (self *TestResults) Value() (driver.Value, error) {
+	data, err := json.Marshal(self)
+	return data, err
+}
+
+// Scan deserializes the results from JSON.
+func (self *TestResults) Scan(data interface{}) error {
+	src := []byte{}
+	switch raw := data.(type) {
+	case []byte:
+		src = raw
+	default:
+		return fmt.Errorf("TestResults: cannot scan from %T", data)
+	}
+
+	if err := json.Unmarshal(src, self); err != nil {
+		return err
+	}
+	if self.Entries == nil {
+		self.Entries = []*TestResult{}
+	}
+	return nil
+}
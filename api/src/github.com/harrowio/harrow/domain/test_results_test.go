@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTestResults_HandleEvent_unmarshalsResultsAsJSON(t *testing.T) {
+	results := NewTestResults()
+	reported := []*TestResult{
+		{Suite: "models", ClassName: "UserTest", Name: "test_create", Status: TestStatusPassed, Duration: 0.5},
+		{Suite: "models", ClassName: "UserTest", Name: "test_login", Status: TestStatusFailed, Duration: 1.5},
+	}
+	resultsAsJson, err := json.Marshal(reported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results.HandleEvent(MapPayload{
+		"event":   "test-report",
+		"results": string(resultsAsJson),
+	})
+
+	if got, want := len(results.Entries), 2; got != want {
+		t.Fatalf(`len(results.Entries) = %v; want %v`, got, want)
+	}
+
+	if got, want := results.FailedNames(), "UserTest.test_login"; len(got) != 1 || got[0] != want {
+		t.Errorf(`results.FailedNames() = %v; want [%v]`, got, want)
+	}
+
+	if got, want := results.Duration(), 2.0; got != want {
+		t.Errorf(`results.Duration() = %v; want %v`, got, want)
+	}
+}
+
+func TestTestResults_HandleEvent_ignoresOtherEvents(t *testing.T) {
+	results := NewTestResults()
+	results.HandleEvent(MapPayload{
+		"event":   "status",
+		"results": `[{"name":"test_create","status":"passed"}]`,
+	})
+
+	if got, want := len(results.Entries), 0; got != want {
+		t.Errorf(`len(results.Entries) = %v; want %v`, got, want)
+	}
+}
+
+func TestOperation_HandleEvent_recordsTestResults(t *testing.T) {
+	operation := &Operation{}
+	operation.HandleEvent(MapPayload{
+		"event":   "test-report",
+		"results": `[{"name":"test_create","status":"error"}]`,
+	})
+
+	if got := operation.TestResults; got == nil {
+		t.Fatalf("operation.TestResults is nil")
+	}
+
+	if got, want := len(operation.TestResults.Failed()), 1; got != want {
+		t.Errorf(`len(operation.TestResults.Failed()) = %v; want %v`, got, want)
+	}
+}
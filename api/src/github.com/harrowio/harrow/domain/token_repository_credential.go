@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Services to which the status of operations can be reported.
+const (
+	CommitStatusProviderGitHub    = "github"
+	CommitStatusProviderGitLab    = "gitlab"
+	CommitStatusProviderGitea     = "gitea"
+	CommitStatusProviderBitbucket = "bitbucket"
+)
+
+// IsCommitStatusProvider reports whether provider names a known
+// service for reporting commit statuses.
+func IsCommitStatusProvider(provider string) bool {
+	switch provider {
+	case CommitStatusProviderGitHub, CommitStatusProviderGitLab, CommitStatusProviderGitea, CommitStatusProviderBitbucket:
+		return true
+	}
+
+	return false
+}
+
+// TokenRepositoryCredential is an API token for the service hosting a
+// repository.  It is used for reporting the status of operations
+// back to the commits they built.
+type TokenRepositoryCredential struct {
+	*RepositoryCredential `json:"-"`
+	Token                 string `json:"token"`
+
+	// Provider overrides which service the repository is assumed to
+	// be hosted on, for self-hosted installations whose host name
+	// doesn't give it away.  Empty means guess from the host.
+	Provider string `json:"provider"`
+}
+
+func AsTokenRepositoryCredential(rc *RepositoryCredential) (*TokenRepositoryCredential, error) {
+	if !rc.IsToken() {
+		return nil, NewRepositoryCredentialTypeError(rc.Type, RepositoryCredentialToken)
+	}
+	tokenRc := &TokenRepositoryCredential{RepositoryCredential: rc}
+	err := json.Unmarshal(rc.SecretBytes, tokenRc)
+	if err != nil {
+		return nil, err
+	}
+	return tokenRc, nil
+}
+
+func (self *TokenRepositoryCredential) AsRepositoryCredential() (*RepositoryCredential, error) {
+	secretBytes, err := json.Marshal(self)
+	if err != nil {
+		return nil, err
+	}
+	if self.RepositoryCredential == nil {
+		self.RepositoryCredential = &RepositoryCredential{
+			Type: RepositoryCredentialToken,
+		}
+	}
+	self.RepositoryCredential.SecretBytes = secretBytes
+	return self.RepositoryCredential, nil
+}
+
+func (self *TokenRepositoryCredential) Validate() error {
+	result := NewValidationError("", "")
+
+	if strings.TrimSpace(self.Token) == "" {
+		result.Add("token", "empty")
+	}
+
+	if self.Provider != "" && !IsCommitStatusProvider(self.Provider) {
+		result.Add("provider", "invalid")
+	}
+
+	return result.ToError()
+}
@@ -83,6 +83,8 @@ func (self *User) Links(response map[string]map[string]string, requestScheme, re
 	response["activities"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s/activities", requestScheme, requestBaseUri, self.Uuid)}
 	response["self"] = map[string]string{"href": self.OwnUrl(requestScheme, requestBaseUri)}
 	response["mfa"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s/mfa", requestScheme, requestBaseUri, self.Uuid)}
+	response["notification-preferences"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s/notification-preferences", requestScheme, requestBaseUri, self.Uuid)}
+	response["recovery-codes"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s/recovery-codes", requestScheme, requestBaseUri, self.Uuid)}
 	response["blocks"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s/blocks", requestScheme, requestBaseUri, self.Uuid)}
 	response["sessions"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s/sessions", requestScheme, requestBaseUri, self.Uuid)}
 	response["verify-email"] = map[string]string{"href": fmt.Sprintf("%s://%s/users/%s/verify-email", requestScheme, requestBaseUri, self.Uuid)}
@@ -156,6 +158,23 @@ func (self *User) TotpEnabled() bool {
 	return self.TotpEnabledAt != nil
 }
 
+// TotpRequiredBy returns the first of organizations requiring
+// two-factor authentication from its members, or nil if there is none
+// or this user has already enabled it.
+func (self *User) TotpRequiredBy(organizations []*Organization) *Organization {
+	if self.TotpEnabled() {
+		return nil
+	}
+
+	for _, organization := range organizations {
+		if organization.RequireTotp {
+			return organization
+		}
+	}
+
+	return nil
+}
+
 func (self *User) FindProject(store ProjectStore) (*Project, error) {
 	if self.Uuid == "" {
 		return nil, new(NotFoundError)
@@ -171,6 +190,13 @@ func (self *User) AuthorizationName() string { return "user" }
 
 func (self *User) SubscribeTo(watchable Watchable, event string, subscriptions SubscriptionStore) error {
 	subscription := NewSubscription(watchable, event, self.Uuid)
+	delivery, err := subscriptions.FindDeliveryForUser(watchable.Id(), self.Uuid)
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+	if delivery != "" {
+		subscription.Delivery = delivery
+	}
 	if _, err := subscriptions.Create(subscription); err != nil {
 		return err
 	}
@@ -238,12 +264,24 @@ func (self *User) Unwatch(watchable Watchable, subscriptions SubscriptionStore)
 	return nil
 }
 
+// DeliverSubscriptionsAs changes how events of all of the user's
+// subscriptions on watchable are delivered.  Subscriptions made later
+// on inherit the delivery mode.
+func (self *User) DeliverSubscriptionsAs(watchable Watchable, delivery string, subscriptions SubscriptionStore) error {
+	if !IsSubscriptionDelivery(delivery) {
+		return NewValidationError("delivery", "invalid")
+	}
+
+	return subscriptions.UpdateDelivery(watchable.Id(), self.Uuid, delivery)
+}
+
 func (self *User) SubscriptionsFor(watchable Watchable, allSubscriptions SubscriptionStore) (*Subscriptions, error) {
 	result := &Subscriptions{
 		WatcherUuid:   self.Uuid,
 		WatchableUuid: watchable.Id(),
 		WatchableType: watchable.WatchableType(),
 		Subscribed:    map[string]bool{},
+		Delivery:      SubscriptionDeliveryImmediate,
 	}
 
 	subscribed, err := allSubscriptions.FindEventsForUser(watchable.Id(), self.Uuid)
@@ -251,6 +289,14 @@ func (self *User) SubscriptionsFor(watchable Watchable, allSubscriptions Subscri
 		return result, err
 	}
 
+	delivery, err := allSubscriptions.FindDeliveryForUser(watchable.Id(), self.Uuid)
+	if err != nil && !IsNotFound(err) {
+		return result, err
+	}
+	if delivery != "" {
+		result.Delivery = delivery
+	}
+
 	for _, event := range watchable.WatchableEvents() {
 		result.Subscribed[event] = false
 	}
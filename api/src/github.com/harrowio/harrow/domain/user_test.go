@@ -36,6 +36,28 @@ func (store *mockSubscriptionStore) FindEventsForUser(watchableId, userUuid stri
 	return result, nil
 }
 
+func (store *mockSubscriptionStore) FindDeliveryForUser(watchableId, userUuid string) (string, error) {
+	prefix := watchableId + ":" + userUuid + ":"
+	for key, subscription := range store.subscriptions {
+		if strings.HasPrefix(key, prefix) {
+			return subscription.Delivery, nil
+		}
+	}
+
+	return "", new(NotFoundError)
+}
+
+func (store *mockSubscriptionStore) UpdateDelivery(watchableId, userUuid, delivery string) error {
+	prefix := watchableId + ":" + userUuid + ":"
+	for key, subscription := range store.subscriptions {
+		if strings.HasPrefix(key, prefix) {
+			subscription.Delivery = delivery
+		}
+	}
+
+	return nil
+}
+
 func (store *mockSubscriptionStore) Create(subscription *Subscription) (string, error) {
 	id := subscription.WatchableUuid + ":" + subscription.UserUuid + ":" + subscription.EventName
 	store.subscriptions[id] = subscription
@@ -158,6 +180,61 @@ func Test_User_Watch_failsIfUserIsAlreadyWatching(t *testing.T) {
 	}
 }
 
+func Test_User_DeliverSubscriptionsAs_isInheritedByNewSubscriptions(t *testing.T) {
+	user := &User{
+		Uuid: "2560599d-1e46-4869-b2a8-09bb18cb9bc9",
+	}
+
+	watchable := &Job{
+		Uuid: "1a478524-e7d9-4072-9cc0-27c3ca89e93e",
+	}
+
+	subscriptions := newMockSubscriptionStore()
+
+	if err := user.SubscribeTo(watchable, EventOperationFailed, subscriptions); err != nil {
+		t.Fatal(err)
+	}
+	if err := user.DeliverSubscriptionsAs(watchable, SubscriptionDeliveryWeekly, subscriptions); err != nil {
+		t.Fatal(err)
+	}
+	if err := user.SubscribeTo(watchable, EventOperationSucceeded, subscriptions); err != nil {
+		t.Fatal(err)
+	}
+
+	subscription, _ := subscriptions.Find(watchable.Uuid, EventOperationSucceeded, user.Uuid)
+	if got, want := subscription.Delivery, SubscriptionDeliveryWeekly; got != want {
+		t.Errorf("subscription.Delivery = %q; want %q", got, want)
+	}
+
+	result, err := user.SubscriptionsFor(watchable, subscriptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result.Delivery, SubscriptionDeliveryWeekly; got != want {
+		t.Errorf("result.Delivery = %q; want %q", got, want)
+	}
+}
+
+func Test_User_DeliverSubscriptionsAs_rejectsUnknownDelivery(t *testing.T) {
+	user := &User{
+		Uuid: "2560599d-1e46-4869-b2a8-09bb18cb9bc9",
+	}
+
+	watchable := &Job{
+		Uuid: "1a478524-e7d9-4072-9cc0-27c3ca89e93e",
+	}
+
+	err := user.DeliverSubscriptionsAs(watchable, "hourly", newMockSubscriptionStore())
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("err.(type) = %T; want %T", err, verr)
+	}
+
+	if got, want := verr.Get("delivery"), "invalid"; got != want {
+		t.Errorf("verr.Get(%q) = %q; want %q", "delivery", got, want)
+	}
+}
+
 func Test_User_Unwatch_unsubscribesUserFromAllEvents(t *testing.T) {
 	user := &User{
 		Uuid: "2560599d-1e46-4869-b2a8-09bb18cb9bc9",
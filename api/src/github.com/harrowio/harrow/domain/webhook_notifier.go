@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/harrowio/harrow/netguard"
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+// DefaultWebhookNotifierBodyTemplate is used for webhook notifiers
+// without a body template of their own.
+const DefaultWebhookNotifierBodyTemplate = `{"activity": {{ json .Activity }}, "operation": {{ json .Operation }}, "project": {{ json .Project }}, "job": {{ json .Job }}}`
+
+var (
+	ErrWebhookNotifierBodyNotJSON = errors.New("rendered body is not valid JSON")
+
+	webhookNotifierTemplateFuncs = template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+)
+
+// WebhookNotifier posts a JSON document rendered from its body
+// template to an arbitrary URL.  Requests are signed with Secret, so
+// that receivers can verify they come from Harrow.  The secret is
+// only shown once, when the notifier is created.
+type WebhookNotifier struct {
+	defaultSubject
+
+	Uuid         string     `json:"uuid" db:"uuid"`
+	Name         string     `json:"name" db:"name"`
+	ProjectUuid  string     `json:"projectUuid" db:"project_uuid"`
+	URL          string     `json:"url" db:"url"`
+	Secret       string     `json:"-" db:"secret"`
+	BodyTemplate string     `json:"bodyTemplate" db:"body_template"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	ArchivedAt   *time.Time `json:"archivedAt" db:"archived_at"`
+}
+
+// WebhookNotifierContext is the data available to body templates.
+type WebhookNotifierContext struct {
+	Activity  *Activity
+	Operation *Operation
+	Project   *Project
+	Job       *Job
+}
+
+// NewWebhookNotifierContext returns the data available to body
+// templates.  Secrets passed to the operation, including the operation
+// in the activity's payload, are left out, because the body is posted
+// to a URL of the user's choosing.
+func NewWebhookNotifierContext(activity *Activity, operation *Operation, project *Project, job *Job) *WebhookNotifierContext {
+	result := &WebhookNotifierContext{
+		Activity: activityWithoutSecrets(activity),
+		Project:  project,
+		Job:      job,
+	}
+
+	if operation != nil {
+		result.Operation = operationWithoutSecrets(operation)
+	}
+
+	return result
+}
+
+func (self *WebhookNotifier) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/webhook-notifiers/%s", requestScheme, requestBase, self.Uuid)
+}
+
+func (self *WebhookNotifier) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{
+		"href": self.OwnUrl(requestScheme, requestBase),
+	}
+	response["project"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/projects/%s", requestScheme, requestBase, self.ProjectUuid),
+	}
+	response["attempts"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/webhook-notifiers/%s/attempts", requestScheme, requestBase, self.Uuid),
+	}
+
+	return response
+}
+
+func (self *WebhookNotifier) Validate() error {
+	result := NewValidationError("", "")
+	if strings.TrimSpace(self.Name) == "" {
+		result.Add("name", "empty")
+	}
+
+	if target, err := url.Parse(self.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		result.Add("url", "malformed")
+	} else if err := netguard.CheckHost(target.Hostname()); err != nil {
+		if _, forbidden := err.(*netguard.ForbiddenAddressError); forbidden {
+			result.Add("url", "forbidden")
+		} else {
+			result.Add("url", "unresolvable")
+		}
+	}
+
+	if len(self.Secret) < 16 {
+		result.Add("secret", "too_short")
+	}
+
+	if _, err := self.template(); err != nil {
+		result.Add("bodyTemplate", "malformed")
+	}
+
+	if !uuidhelper.IsValid(self.ProjectUuid) {
+		result.Add("projectUuid", "malformed")
+	}
+
+	return result.ToError()
+}
+
+// WithoutSecret returns a copy of the notifier without its secret,
+// e.g. for recording it in activities.
+func (self *WebhookNotifier) WithoutSecret() *WebhookNotifier {
+	result := *self
+	result.Secret = ""
+	return &result
+}
+
+func (self *WebhookNotifier) FindProject(projects ProjectStore) (*Project, error) {
+	return projects.FindByUuid(self.ProjectUuid)
+}
+
+func (self *WebhookNotifier) AuthorizationName() string { return "webhook-notifier" }
+
+// GenerateSecret sets Secret to a new random value.
+func (self *WebhookNotifier) GenerateSecret() {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	self.Secret = hex.EncodeToString(secret)
+}
+
+func (self *WebhookNotifier) template() (*template.Template, error) {
+	body := self.BodyTemplate
+	if strings.TrimSpace(body) == "" {
+		body = DefaultWebhookNotifierBodyTemplate
+	}
+
+	return template.New("body").Funcs(webhookNotifierTemplateFuncs).Parse(body)
+}
+
+// Render renders the body template for ctxt and makes sure the result
+// is valid JSON.
+func (self *WebhookNotifier) Render(ctxt *WebhookNotifierContext) ([]byte, error) {
+	tmpl, err := self.template()
+	if err != nil {
+		return nil, err
+	}
+
+	body := new(bytes.Buffer)
+	if err := tmpl.Execute(body, ctxt); err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(body.Bytes()) {
+		return nil, ErrWebhookNotifierBodyNotJSON
+	}
+
+	return body.Bytes(), nil
+}
+
+// Sign returns the signature of body, sent in the
+// X-Harrow-Signature-256 header: the hex encoded HMAC-SHA256 of body
+// keyed with Secret, prefixed with "sha256=".
+func (self *WebhookNotifier) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(self.Secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
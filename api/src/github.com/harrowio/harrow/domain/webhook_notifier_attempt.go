@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+// WebhookNotifierAttempt records a single request made by a webhook
+// notifier.  ResponseStatus is nil if no response was received.
+type WebhookNotifierAttempt struct {
+	defaultSubject
+
+	Uuid                     string    `json:"uuid" db:"uuid"`
+	WebhookNotifierUuid      string    `json:"webhookNotifierUuid" db:"webhook_notifier_uuid"`
+	NotificationDeliveryUuid *string   `json:"notificationDeliveryUuid" db:"notification_delivery_uuid"`
+	ActivityId               int       `json:"activityId" db:"activity_id"`
+	Attempt                  int       `json:"attempt" db:"attempt"`
+	ResponseStatus           *int      `json:"responseStatus" db:"response_status"`
+	Error                    string    `json:"error" db:"error"`
+	CreatedAt                time.Time `json:"createdAt" db:"created_at"`
+}
+
+func NewWebhookNotifierAttempt(notifier *WebhookNotifier, activity *Activity, attempt int) *WebhookNotifierAttempt {
+	return &WebhookNotifierAttempt{
+		Uuid:                uuidhelper.MustNewV4(),
+		WebhookNotifierUuid: notifier.Uuid,
+		ActivityId:          activity.Id,
+		Attempt:             attempt,
+		CreatedAt:           Clock.Now(),
+	}
+}
+
+func (self *WebhookNotifierAttempt) OwnUrl(requestScheme, requestBase string) string {
+	return fmt.Sprintf("%s://%s/webhook-notifiers/%s/attempts/%s", requestScheme, requestBase, self.WebhookNotifierUuid, self.Uuid)
+}
+
+func (self *WebhookNotifierAttempt) Links(response map[string]map[string]string, requestScheme, requestBase string) map[string]map[string]string {
+	response["self"] = map[string]string{
+		"href": self.OwnUrl(requestScheme, requestBase),
+	}
+	response["webhook-notifier"] = map[string]string{
+		"href": fmt.Sprintf("%s://%s/webhook-notifiers/%s", requestScheme, requestBase, self.WebhookNotifierUuid),
+	}
+
+	return response
+}
+
+// Succeeded reports whether the request was answered with a 2xx
+// status code.
+func (self *WebhookNotifierAttempt) Succeeded() bool {
+	return self.ResponseStatus != nil && *self.ResponseStatus >= 200 && *self.ResponseStatus < 300
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWebhookNotifier_Render_usesDefaultTemplate(t *testing.T) {
+	notifier := &WebhookNotifier{}
+	body, err := notifier.Render(&WebhookNotifierContext{
+		Activity: &Activity{Name: "operation.failed"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := struct {
+		Activity struct {
+			Name string `json:"name"`
+		} `json:"activity"`
+	}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := result.Activity.Name, "operation.failed"; got != want {
+		t.Errorf("result.Activity.Name = %q; want %q", got, want)
+	}
+}
+
+func TestWebhookNotifier_Render_doesNotExposeSecretsOfOperation(t *testing.T) {
+	parameters := NewOperationParameters().AddSecret("DEPLOY_KEY", "very-secret")
+	operation := &Operation{Uuid: "9b1a1d42-94ab-4a3c-8d6d-1c3f63d0f1c7", Parameters: parameters}
+	activity := &Activity{Name: "operation.failed", Payload: operation}
+
+	notifier := &WebhookNotifier{}
+	body, err := notifier.Render(NewWebhookNotifierContext(activity, operation, &Project{}, &Job{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(body), "very-secret") {
+		t.Errorf("body contains secret value:\n%s", body)
+	}
+
+	if !strings.Contains(string(body), operation.Uuid) {
+		t.Errorf("body does not contain operation %s:\n%s", operation.Uuid, body)
+	}
+}
+
+func TestWebhookNotifier_Render_rejectsBodiesThatAreNotJSON(t *testing.T) {
+	notifier := &WebhookNotifier{BodyTemplate: `{"text": {{ .Activity.Name }}}`}
+	_, err := notifier.Render(&WebhookNotifierContext{
+		Activity: &Activity{Name: "operation.failed"},
+	})
+
+	if got, want := err, ErrWebhookNotifierBodyNotJSON; got != want {
+		t.Errorf("err = %v; want %v", got, want)
+	}
+}
+
+func TestWebhookNotifier_Sign_returnsHmacSha256OfBody(t *testing.T) {
+	notifier := &WebhookNotifier{Secret: "key"}
+	got := notifier.Sign([]byte("The quick brown fox jumps over the lazy dog"))
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got != want {
+		t.Errorf("Sign() = %q; want %q", got, want)
+	}
+}
+
+func TestWebhookNotifier_Validate_rejectsMalformedTemplate(t *testing.T) {
+	notifier := &WebhookNotifier{
+		Name:         "ci",
+		ProjectUuid:  "c4c2d5f3-ec49-4e28-ba4d-fe1b8a8c8b71",
+		URL:          "https://example.com/hook",
+		BodyTemplate: `{"name": {{ json .Activity.Name }`,
+	}
+	notifier.GenerateSecret()
+
+	err, ok := notifier.Validate().(*ValidationError)
+	if !ok {
+		t.Fatalf("err = %T; want *ValidationError", notifier.Validate())
+	}
+
+	if got, want := err.Get("bodyTemplate"), "malformed"; got != want {
+		t.Errorf(`err.Get("bodyTemplate") = %q; want %q`, got, want)
+	}
+}
+
+func TestWebhookNotifier_Validate_rejectsInternalHosts(t *testing.T) {
+	for _, target := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+	} {
+		notifier := &WebhookNotifier{
+			Name:        "ci",
+			ProjectUuid: "c4c2d5f3-ec49-4e28-ba4d-fe1b8a8c8b71",
+			URL:         target,
+		}
+		notifier.GenerateSecret()
+
+		err, ok := notifier.Validate().(*ValidationError)
+		if !ok {
+			t.Errorf("%s: err = %T; want *ValidationError", target, notifier.Validate())
+			continue
+		}
+
+		if got, want := err.Get("url"), "forbidden"; got != want {
+			t.Errorf(`%s: err.Get("url") = %q; want %q`, target, got, want)
+		}
+	}
+}
@@ -258,6 +258,10 @@ func (self *rootFs) constructContext() error {
 		return fmt.Errorf("Can't load secrets for operation %s: %s", self.operation.Uuid, err)
 	}
 
+	if err := secretStore.RecordUsage(self.operation.Uuid, secrets); err != nil {
+		return fmt.Errorf("Can't record secret versions used by operation %s: %s", self.operation.Uuid, err)
+	}
+
 	if err := self.OperationCtxt.LoadWebhookBody(deliveryStore); err != nil {
 		self.Log().Warn().Msgf("Can't load delivered webhook body: %s\n", err)
 	}
@@ -296,8 +300,11 @@ func (self *rootFs) constructContext() error {
 
 func (self *rootFs) loadOperationLogs(operation *domain.Operation) error {
 	redisClient := redis.NewTCPClient(self.systemConfig.RedisConnOpts(0))
-	fileTransport := logevent.NewFileTransport(self.systemConfig, self.log)
-	messages, err := fileTransport.Consume(operation.Uuid)
+	var messages <-chan *logevent.Message
+	archive, err := logevent.NewArchive(self.systemConfig, self.log)
+	if err == nil {
+		messages, err = archive.Consume(operation.Uuid)
+	}
 	if err != nil {
 		messages, err = logevent.NewRedisTransport(redisClient, self.log).Consume(operation.Uuid)
 	}
@@ -371,6 +378,22 @@ func (self *rootFs) buildFilesystem() (io.Reader, error) {
 		return nil, fmt.Errorf("Can't add ssh config contents %s to filesystem: %s", sshConfigPath, err)
 	}
 
+	// Write pinned SSH host keys
+	knownHostsPath := ".ssh/known_hosts"
+	knownHosts := self.OperationCtxt.KnownHosts()
+	hdr = &tar.Header{
+		Name:    knownHostsPath,
+		Mode:    0600,
+		ModTime: time.Now().UTC(),
+		Size:    int64(len(knownHosts)),
+	}
+	if err := tarGzWriter.WriteHeader(hdr); err != nil {
+		return nil, fmt.Errorf("Can't add known hosts header %s to filesystem: %s", knownHostsPath, err)
+	}
+	if _, err := tarGzWriter.Write([]byte(knownHosts)); err != nil {
+		return nil, fmt.Errorf("Can't add known hosts contents %s to filesystem: %s", knownHostsPath, err)
+	}
+
 	// Write binary and script executables
 	var setupShBuffer bytes.Buffer
 	err = self.template("setup.sh").Execute(&setupShBuffer, self.OperationCtxt)
@@ -380,11 +403,12 @@ func (self *rootFs) buildFilesystem() (io.Reader, error) {
 	}
 
 	gitAnalyzeRepository := MustAsset("templates/git-analyze-repository")
+	gitSSH := MustAsset("templates/git-ssh")
 
 	var executableFiles []rootFsFile = []rootFsFile{
 		{path: ".bin/git-analyze-repository", contents: gitAnalyzeRepository},
 		{path: ".bin/setup", contents: setupShBuffer.Bytes()},
-		{path: ".bin/git-ssh", contents: []byte("#!/bin/sh -e\nexec /usr/bin/ssh -o LogLevel=quiet -o PasswordAuthentication=no -o StrictHostKeyChecking=no \"$@\"\n")},
+		{path: ".bin/git-ssh", contents: gitSSH},
 	}
 
 	// Check what kind of operation we're running
@@ -393,11 +417,11 @@ func (self *rootFs) buildFilesystem() (io.Reader, error) {
 		executableFiles = append(executableFiles, scriptRootFsFile)
 	} else if self.operation.IsGitAccessCheck() {
 		var scriptBuffer bytes.Buffer
+		err := self.template("git-check-access.sh").Execute(&scriptBuffer, self.repository)
 		if err != nil {
-			self.Log().Warn().Msgf("Error making git.Repository from domain.Repository: %s\n", err)
+			self.Log().Warn().Msgf("Error rendering git-check-access.sh: %s\n", err)
 			return nil, err
 		}
-		fmt.Fprintf(&scriptBuffer, "#!/bin/bash -e\ngit ls-remote -h \"%s\"\n", self.repository.Url)
 		scriptRootFsFile := rootFsFile{path: ".bin/script", contents: scriptBuffer.Bytes()}
 		executableFiles = append(executableFiles, scriptRootFsFile)
 	} else if self.operation.IsGitMetadataCollect() {
@@ -476,6 +500,7 @@ func (self *rootFs) templates() map[string]*template.Template {
 		"setup.sh":            template.Must(template.New("setup.sh").Funcs(self.templateFuncMap()).Parse(string(MustAsset("templates/setup.sh")))),
 		"collect-metadata.sh": template.Must(template.New("collect-metadata.sh").Funcs(self.templateFuncMap()).Parse(string(MustAsset("templates/collect-metadata.sh")))),
 		"ssh_config":          template.Must(template.New("ssh_config").Funcs(self.templateFuncMap()).Parse(string(MustAsset("templates/ssh_config")))),
+		"git-check-access.sh": template.Must(template.New("git-check-access.sh").Funcs(self.templateFuncMap()).Parse(string(MustAsset("templates/git-check-access.sh")))),
 	}
 }
 
@@ -0,0 +1,15 @@
+#!/bin/bash -e
+{{ if .HostKeys }}
+git ls-remote -h "{{ .Url }}"
+{{ else }}
+# No host keys have been pinned for this repository yet, so accept and
+# record whatever keys the host presents.
+export HARROW_KNOWN_HOSTS=$(mktemp)
+export HARROW_STRICT_HOST_KEY_CHECKING=accept-new
+
+git ls-remote -h "{{ .Url }}"
+
+if [ -s "$HARROW_KNOWN_HOSTS" ]; then
+  hevent host-keys repository={{ .Uuid }} knownHosts@"$HARROW_KNOWN_HOSTS"
+fi
+{{ end }}
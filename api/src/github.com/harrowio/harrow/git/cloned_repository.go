@@ -10,10 +10,15 @@ import (
 	"strings"
 )
 
+// pinnedSSHWrapperFormatTpl is like sshWrapperFormatTpl, but verifies
+// host keys against a known_hosts file.
+const pinnedSSHWrapperFormatTpl = "%s -o IdentitiesOnly=yes -o PasswordAuthentication=no -o UserKnownHostsFile=%s -o StrictHostKeyChecking=%s -i %s \"$@\"\n"
+
 type System interface {
 	TempDir() (string, error)
 	PersistentDir(key string) (string, error)
 	CreateFile(filename string) (io.WriteCloser, error)
+	ReadFile(filename string) ([]byte, error)
 	DeleteFile(filename string) error
 	SetPermissions(filename string, mode int) error
 	Run(cmd *SystemCommand) ([]byte, error)
@@ -24,6 +29,7 @@ type ClonedRepository struct {
 	cloneURL *url.URL
 
 	credential   Credential
+	knownHosts   string
 	tempDir      string
 	clonedInto   string
 	isPersistent bool
@@ -56,6 +62,31 @@ func (self *ClonedRepository) MakePersistent() {
 	self.isPersistent = true
 }
 
+// SetKnownHosts pins the SSH host keys accepted for this repository.
+// knownHosts is in the format of OpenSSH's known_hosts file.  If no
+// host keys are pinned, the key presented by the host is accepted and
+// can be retrieved with KnownHosts afterwards.
+//
+// SetKnownHosts needs to be called before SetCredential.
+func (self *ClonedRepository) SetKnownHosts(knownHosts string) {
+	self.knownHosts = knownHosts
+}
+
+// KnownHosts returns the host keys accepted while talking to the
+// repository host over SSH.
+func (self *ClonedRepository) KnownHosts() (string, error) {
+	if self.tempDir == "" {
+		return self.knownHosts, nil
+	}
+
+	contents, err := self.os.ReadFile(filepath.Join(self.tempDir, "known_hosts"))
+	if err != nil {
+		return "", err
+	}
+
+	return string(contents), nil
+}
+
 func (self *ClonedRepository) Pull() error {
 	if err := self.Clone(); err != nil {
 		return err
@@ -156,7 +187,10 @@ func (self *ClonedRepository) IsAccessible() (bool, error) {
 		SetEnv("GIT_ASKPASS", "/bin/echo").
 		SetEnv("GIT_SSH", filepath.Join(self.tempDir, "git-ssh"))
 
-	if _, err := self.os.Run(lsRemote); err != nil {
+	if output, err := self.os.Run(lsRemote); err != nil {
+		if self.knownHosts != "" && strings.Contains(string(output), "Host key verification failed") {
+			return false, ErrHostKeyChanged
+		}
 		return false, err
 	}
 
@@ -310,14 +344,41 @@ func (self *ClonedRepository) writeSSHCredential(credential Credential) (string,
 	return sshCredentialPath, nil
 }
 
+func (self *ClonedRepository) writeKnownHosts() (string, error) {
+	knownHostsPath := filepath.Join(self.tempDir, "known_hosts")
+	knownHosts, err := self.os.CreateFile(knownHostsPath)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.WriteString(knownHosts, self.knownHosts); err != nil {
+		return "", err
+	}
+	if err := knownHosts.Close(); err != nil {
+		return "", err
+	}
+
+	return knownHostsPath, nil
+}
+
 func (self *ClonedRepository) writeGitSSHWrapper(sshCredentialPath string) error {
+	knownHostsPath, err := self.writeKnownHosts()
+	if err != nil {
+		return err
+	}
+
+	strictHostKeyChecking := "accept-new"
+	if self.knownHosts != "" {
+		strictHostKeyChecking = "yes"
+	}
+
 	gitSSHWrapperName := filepath.Join(self.tempDir, "git-ssh")
 	gitSSHWrapper, err := self.os.CreateFile(gitSSHWrapperName)
 	if err != nil {
 		return err
 	}
 
-	if _, err := fmt.Fprintf(gitSSHWrapper, sshWrapperFormatTpl, "/usr/bin/ssh", sshCredentialPath); err != nil {
+	if _, err := fmt.Fprintf(gitSSHWrapper, pinnedSSHWrapperFormatTpl, "/usr/bin/ssh", knownHostsPath, strictHostKeyChecking, sshCredentialPath); err != nil {
 		return err
 	}
 	if err := gitSSHWrapper.Close(); err != nil {
@@ -252,14 +252,62 @@ func TestClonedRepository_SetCredential_writes_git_SSH_wrapper_to_disk_when_sett
 	}
 
 	credentialFilename := filepath.Join(mockSystem.tempDirs[0], "ssh_credential")
+	knownHostsFilename := filepath.Join(mockSystem.tempDirs[0], "known_hosts")
 	expectedFilename := filepath.Join(mockSystem.tempDirs[0], "git-ssh")
-	expectedContents := fmt.Sprintf("/usr/bin/ssh -o IdentitiesOnly=yes -o PasswordAuthentication=no -o StrictHostKeyChecking=no -i %s \"$@\"\n", credentialFilename)
+	expectedContents := fmt.Sprintf("/usr/bin/ssh -o IdentitiesOnly=yes -o PasswordAuthentication=no -o UserKnownHostsFile=%s -o StrictHostKeyChecking=accept-new -i %s \"$@\"\n", knownHostsFilename, credentialFilename)
 	if hasFile, contents := mockSystem.HasFile(expectedFilename, []byte(expectedContents)); !hasFile {
 		t.Fatalf("Expected file %q to have contents:\n%s\nGot:\n%s\n", expectedFilename, expectedContents, contents)
 	}
 
 }
 
+func TestClonedRepository_SetCredential_enforces_pinned_host_keys(t *testing.T) {
+	mockSystem := NewMockSystem()
+
+	knownHosts := "example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl\n"
+	credential := sshUserAndSecret()
+	repositoryURL := cloneURL()
+	repositoryURL.Scheme = "ssh"
+	repo := NewClonedRepository(mockSystem, repositoryURL)
+	repo.SetKnownHosts(knownHosts)
+	if err := repo.SetCredential(credential); err != nil {
+		t.Fatal(err)
+	}
+
+	knownHostsFilename := filepath.Join(mockSystem.tempDirs[0], "known_hosts")
+	if hasFile, contents := mockSystem.HasFile(knownHostsFilename, []byte(knownHosts)); !hasFile {
+		t.Fatalf("Expected file %q to have contents:\n%s\nGot:\n%s\n", knownHostsFilename, knownHosts, contents)
+	}
+
+	wrapper, err := mockSystem.ReadFile(filepath.Join(mockSystem.tempDirs[0], "git-ssh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(wrapper), "-o StrictHostKeyChecking=yes") {
+		t.Errorf("wrapper does not enforce host key checking:\n%s", wrapper)
+	}
+}
+
+func TestClonedRepository_IsAccessible_returns_ErrHostKeyChanged_if_pinned_host_key_does_not_match(t *testing.T) {
+	mockSystem := NewMockSystem()
+	repo := NewClonedRepository(mockSystem, cloneURL())
+	repo.SetKnownHosts("example.com ssh-ed25519 AAAA\n")
+	if err := repo.ensureTempDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	lsRemote := NewSystemCommand("git", "ls-remote", cloneURL().String()).
+		WorkingDirectory(mockSystem.tempDirs[0])
+	mockSystem.setExpectedEnvironment(lsRemote)
+	mockSystem.setOutputForCommand(lsRemote, "Host key verification failed.\nfatal: Could not read from remote repository.\n")
+	mockSystem.failCommandWith(errors.New("exit status 128"))
+
+	if _, err := repo.IsAccessible(); err != ErrHostKeyChanged {
+		t.Errorf("err = %v; want %v", err, ErrHostKeyChanged)
+	}
+}
+
 func TestClonedRepository_SetCredential_marks_git_SSH_wrapper_as_executable(t *testing.T) {
 	mockSystem := NewMockSystem()
 
@@ -810,6 +858,14 @@ func (self *MockSystem) CreateFile(filename string) (io.WriteCloser, error) {
 	return NopCloser{self.files[filename]}, nil
 }
 
+func (self *MockSystem) ReadFile(filename string) ([]byte, error) {
+	contents, found := self.files[filename]
+	if !found {
+		return nil, os.ErrNotExist
+	}
+	return contents.Bytes(), nil
+}
+
 func (self *MockSystem) DeleteFile(filename string) error {
 	self.deletedFiles[filename] = true
 	return nil
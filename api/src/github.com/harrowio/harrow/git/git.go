@@ -33,4 +33,5 @@ var (
 	ErrRemovingSensitveFiles           = errors.New("git: error removing sensitive files")
 	ErrWritingHttpsCredentialCacheFile = errors.New("git: error writing https credential cache helper file to disk")
 	ErrCallingGitConfig                = errors.New("git: error calling `git config'")
+	ErrHostKeyChanged                  = errors.New("git: ssh host key does not match the pinned host key")
 )
@@ -40,6 +40,10 @@ func (self *OperatingSystem) CreateFile(filename string) (io.WriteCloser, error)
 	return os.Create(filename)
 }
 
+func (self *OperatingSystem) ReadFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
 func (self *OperatingSystem) SetPermissions(filename string, mode int) error {
 	return os.Chmod(filename, os.FileMode(mode))
 }
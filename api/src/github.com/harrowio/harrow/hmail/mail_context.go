@@ -8,6 +8,8 @@ type MailContext struct {
 	Action        *Action
 	Object        *Object
 	OperationLogs *OperationLogs
+	TestResults   *TestResults
+	Digest        *Digest
 }
 
 // Actor holds information about who or what initiated a transaction.
@@ -39,3 +41,41 @@ type Recipient struct {
 type OperationLogs struct {
 	Text string
 }
+
+// TestResults holds a summary of the test results reported by an
+// operation.
+type TestResults struct {
+	Total  int
+	Failed []string
+}
+
+// Digest summarizes the activity in a project over a day or a week.
+type Digest struct {
+	Period      string
+	ProjectName string
+	ProjectUri  string
+	From        string
+	To          string
+
+	Succeeded int
+	Failed    int
+
+	SlowestJobs    []*DigestJob
+	NewestFailures []*DigestFailure
+}
+
+// DigestJob is a job listed in a digest.
+type DigestJob struct {
+	Name            string
+	Uri             string
+	Operations      int
+	AverageDuration string
+}
+
+// DigestFailure is a failed operation listed in a digest.
+type DigestFailure struct {
+	JobName  string
+	Uri      string
+	Status   string
+	FailedAt string
+}
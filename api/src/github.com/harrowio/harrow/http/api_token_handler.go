@@ -0,0 +1,244 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// apiTokenHandler manages personal and project API tokens.  Listing
+// and creating tokens is mounted as a relationship of users and
+// projects.
+//
+// Creating a project token requires the permission to update the
+// project.  A request authenticated with an API token can only create
+// tokens that are limited to a subset of its own capabilities.
+type apiTokenHandler struct {
+}
+
+type apiTokenParams struct {
+	Name         string     `json:"name"`
+	Capabilities []string   `json:"capabilities"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+}
+
+func MountApiTokenHandler(r *mux.Router, ctxt ServerContext) {
+	h := apiTokenHandler{}
+
+	root := r.PathPrefix("/api-tokens").Subrouter()
+
+	// Item
+	item := root.PathPrefix("/{uuid}").Subrouter()
+	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
+		Name("api-token-show")
+	item.Methods("DELETE").Handler(HandlerFunc(ctxt, h.Revoke)).
+		Name("api-token-revoke")
+}
+
+func (self apiTokenHandler) paramsFrom(src io.Reader) (*apiTokenParams, error) {
+	params := struct {
+		Subject apiTokenParams `json:"subject"`
+	}{}
+
+	if err := json.NewDecoder(src).Decode(&params); err != nil {
+		return nil, err
+	}
+
+	return &params.Subject, nil
+}
+
+func (self apiTokenHandler) Show(ctxt RequestContext) error {
+
+	token, err := stores.NewDbApiTokenStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(token); !allowed {
+		return err
+	}
+
+	writeAsJson(ctxt, token)
+
+	return nil
+}
+
+func (self apiTokenHandler) Revoke(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	store := stores.NewDbApiTokenStore(ctxt.Tx())
+	token, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanArchive(token); !allowed {
+		return err
+	}
+
+	if err := store.RevokeByUuid(token.Uuid); err != nil {
+		return err
+	}
+
+	token.Revoke(time.Now())
+	ctxt.EnqueueActivity(activities.ApiTokenRevoked(token), nil)
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (self apiTokenHandler) IndexForUser(ctxt RequestContext) error {
+
+	c := ctxt.Config()
+	user, err := stores.NewDbUserStore(ctxt.Tx(), &c).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(user); !allowed {
+		return err
+	}
+
+	tokens, err := stores.NewDbApiTokenStore(ctxt.Tx()).FindAllByUserUuid(user.Uuid)
+	if err != nil {
+		return err
+	}
+
+	return self.writeTokens(ctxt, tokens)
+}
+
+func (self apiTokenHandler) CreateForUser(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	c := ctxt.Config()
+	user, err := stores.NewDbUserStore(ctxt.Tx(), &c).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	params, err := self.paramsFrom(ctxt.R().Body)
+	if err != nil {
+		return err
+	}
+
+	token := domain.NewApiToken(params.Name, user.Uuid, nil, params.Capabilities, params.ExpiresAt)
+
+	return self.create(ctxt, token)
+}
+
+func (self apiTokenHandler) IndexForProject(ctxt RequestContext) error {
+
+	project, err := stores.NewDbProjectStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(project); !allowed {
+		return err
+	}
+
+	tokens, err := stores.NewDbApiTokenStore(ctxt.Tx()).FindAllByProjectUuid(project.Uuid)
+	if err != nil {
+		return err
+	}
+
+	return self.writeTokens(ctxt, tokens)
+}
+
+func (self apiTokenHandler) CreateForProject(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	project, err := stores.NewDbProjectStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(project); !allowed {
+		return err
+	}
+
+	params, err := self.paramsFrom(ctxt.R().Body)
+	if err != nil {
+		return err
+	}
+
+	token := domain.NewApiToken(params.Name, ctxt.User().Uuid, &project.Uuid, params.Capabilities, params.ExpiresAt)
+
+	return self.create(ctxt, token)
+}
+
+func (self apiTokenHandler) create(ctxt RequestContext, token *domain.ApiToken) error {
+
+	if allowed, err := ctxt.Auth().CanCreate(token); !allowed {
+		return err
+	}
+
+	if current := ctxt.ApiToken(); current != nil && !self.isCoveredBy(token, current) {
+		return ErrApiTokenCapabilitiesExceeded
+	}
+
+	if err := token.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := stores.NewDbApiTokenStore(ctxt.Tx()).Create(token); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.ApiTokenCreated(token), nil)
+	ctxt.W().Header().Set("Location", urlForSubject(ctxt.R(), token))
+	ctxt.W().WriteHeader(http.StatusCreated)
+	writeAsJson(ctxt, token)
+
+	return nil
+}
+
+// isCoveredBy returns true if current permits every capability of
+// token in the context of token's project.
+func (self apiTokenHandler) isCoveredBy(token, current *domain.ApiToken) bool {
+	var project *domain.Project
+	if token.ProjectUuid != nil {
+		project = &domain.Project{Uuid: *token.ProjectUuid}
+	}
+
+	for _, capability := range token.Capabilities {
+		if !current.Permits(capability, project) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (self apiTokenHandler) writeTokens(ctxt RequestContext, tokens []*domain.ApiToken) error {
+	result := []interface{}{}
+	for _, token := range tokens {
+		if allowed, _ := ctxt.Auth().CanRead(token); allowed {
+			result = append(result, token)
+		}
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+	"github.com/jmoiron/sqlx"
+)
+
+// scopedApiTokenTest sends requests authenticated with an API token
+// through a standard request context, so that the token's capabilities
+// are enforced like in production.  The request context rolls back the
+// test transaction on errors, so each test case should send a single
+// request only.
+type scopedApiTokenTest struct {
+	t      *testing.T
+	tx     *sqlx.Tx
+	world  *test_helpers.World
+	server *httptest.Server
+	token  *domain.ApiToken
+}
+
+func newScopedApiTokenTest(fn func(*mux.Router, ServerContext), userTag string, capabilities []string, t *testing.T) *scopedApiTokenTest {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	world := test_helpers.MustNewWorld(tx, t)
+
+	token := domain.NewApiToken("ci", world.User(userTag).Uuid, nil, capabilities, nil)
+	if _, err := stores.NewDbApiTokenStore(tx).Create(token); err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	fn(r, NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore()))
+
+	return &scopedApiTokenTest{
+		t:      t,
+		tx:     tx,
+		world:  world,
+		server: httptest.NewServer(r),
+		token:  token,
+	}
+}
+
+func (test *scopedApiTokenTest) World() *test_helpers.World { return test.world }
+func (test *scopedApiTokenTest) Tx() *sqlx.Tx               { return test.tx }
+
+// Do sends a request to path on the test server and returns the
+// response's status code together with the decoded error, if any.
+func (test *scopedApiTokenTest) Do(method, path string, params interface{}) (int, *ErrorJSON) {
+	req, err := newRequestJSON(method, test.server.URL+path, params)
+	if err != nil {
+		test.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+test.token.Token)
+
+	res, err := new(http.Client).Do(req)
+	if err != nil {
+		test.t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		test.t.Fatal(err)
+	}
+
+	apiError := &ErrorJSON{}
+	if len(body) > 0 {
+		json.Unmarshal(body, apiError)
+	}
+
+	return res.StatusCode, apiError
+}
+
+func (test *scopedApiTokenTest) Cleanup() {
+	test.server.Close()
+	test.tx.Rollback()
+}
+
+func Test_ApiTokenHandler_Routing(t *testing.T) {
+	r := mux.NewRouter()
+	MountApiTokenHandler(r, nil)
+
+	spec := routingSpec{
+		{"GET", "/api-tokens/:uuid", "api-token-show"},
+		{"DELETE", "/api-tokens/:uuid", "api-token-revoke"},
+	}
+
+	spec.run(r, t)
+}
+
+func Test_ApiTokenHandler_CreateForUser_returnsPlainTextTokenOnce(t *testing.T) {
+	h := NewHandlerTest(MountUserHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("default")
+	user := h.User()
+	result := struct {
+		Subject struct {
+			Uuid  string
+			Token string
+		}
+	}{}
+	h.ResultTo(&result)
+	h.Do("POST", h.Url("/users/"+user.Uuid+"/api-tokens"), &halWrapper{
+		Subject: &apiTokenParams{
+			Name:         "ci",
+			Capabilities: []string{"create-operation", "read-job"},
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if !domain.LooksLikeApiToken(result.Subject.Token) {
+		t.Fatalf(`result.Subject.Token = %q; want an API token`, result.Subject.Token)
+	}
+
+	token, err := stores.NewDbApiTokenStore(h.Tx()).FindByToken(result.Subject.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := token.Uuid, result.Subject.Uuid; got != want {
+		t.Errorf(`token.Uuid = %q; want %q`, got, want)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "api-token.created" {
+			if payload := activity.Payload.(*domain.ApiToken); payload.Token != "" {
+				t.Errorf("Expected activity payload not to contain the plain text token")
+			}
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "api-token.created")
+}
+
+func Test_ApiTokenHandler_Revoke_revokesTokenAndEmitsActivity(t *testing.T) {
+	h := NewHandlerTest(MountApiTokenHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("default")
+	store := stores.NewDbApiTokenStore(h.Tx())
+	token := domain.NewApiToken("ci", h.User().Uuid, nil, []string{"read-job"}, nil)
+	if _, err := store.Create(token); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Do("DELETE", h.Url("/api-tokens/"+token.Uuid), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusNoContent; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	found, err := store.FindByUuid(token.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !found.IsRevoked() {
+		t.Errorf("Expected token to be revoked")
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "api-token.revoked" {
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "api-token.revoked")
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// auditLogPageSize is the maximum number of entries returned per page
+// of JSON.  Exports are not limited.
+const auditLogPageSize = 500
+
+// auditLogHandler presents the activities of an organization as an
+// audit log.  Its routes are mounted as relationships of
+// organizations.
+//
+// Reading the audit log requires the permission to update the
+// organization.
+type auditLogHandler struct {
+}
+
+// IndexForOrganization returns the audit log of an organization,
+// newest entries first.  The log can be filtered with the following
+// query parameters:
+//
+//	actor    uuid of the user who caused the activity
+//	name     activity name, "*" matches anything, e.g. "secret.*"
+//	project  uuid of the project the activity belongs to
+//	since    RFC3339 timestamp, inclusive
+//	until    RFC3339 timestamp, exclusive
+//
+// The "format" parameter selects between "json" (the default), "csv"
+// and "ndjson".  JSON is returned in pages selected by the "offset"
+// and "limit" parameters.
+func (self auditLogHandler) IndexForOrganization(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(organization); !allowed {
+		return err
+	}
+
+	query := ctxt.R().URL.Query()
+	filter, err := self.filterFrom(organization.Uuid, query)
+	if err != nil {
+		return err
+	}
+
+	store := stores.NewDbActivityStore(ctxt.Tx())
+	store.SetLogger(ctxt.Log())
+
+	switch format := query.Get("format"); format {
+	case "", "json":
+		offset, limit, err := self.pageFrom(query)
+		if err != nil {
+			return err
+		}
+		return self.writeJson(ctxt, store, filter, offset, limit)
+	case "csv":
+		return self.writeCsv(ctxt, store, filter)
+	case "ndjson":
+		return self.writeNdjson(ctxt, store, filter)
+	default:
+		return domain.NewValidationError("format", "unsupported")
+	}
+}
+
+func (self auditLogHandler) filterFrom(organizationUuid string, query url.Values) (*domain.AuditLogFilter, error) {
+	filter := &domain.AuditLogFilter{
+		OrganizationUuid: organizationUuid,
+		ActorUuid:        query.Get("actor"),
+		ProjectUuid:      query.Get("project"),
+		NamePattern:      query.Get("name"),
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, domain.NewValidationError(param.name, "malformed")
+		}
+		*param.dest = &parsed
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+func (self auditLogHandler) pageFrom(query url.Values) (offset int, limit int, err error) {
+	if param := query.Get("offset"); param != "" {
+		offset, err = strconv.Atoi(param)
+		if err != nil || offset < 0 {
+			return 0, 0, domain.NewValidationError("offset", "invalid")
+		}
+	}
+
+	limit = auditLogPageSize
+	if param := query.Get("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 {
+			return 0, 0, domain.NewValidationError("limit", "invalid")
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	return offset, limit, nil
+}
+
+func (self auditLogHandler) writeJson(ctxt RequestContext, store *stores.DbActivityStore, filter *domain.AuditLogFilter, offset, limit int) error {
+	total, err := store.CountForAuditLog(filter)
+	if err != nil {
+		return err
+	}
+
+	result := []interface{}{}
+	err = store.AllForAuditLog(filter, offset, limit, func(activity *domain.Activity) error {
+		result = append(result, domain.NewAuditLogEntry(filter.OrganizationUuid, activity))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      total,
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
+
+func (self auditLogHandler) writeCsv(ctxt RequestContext, store *stores.DbActivityStore, filter *domain.AuditLogFilter) error {
+	ctxt.W().Header().Set("Content-Type", "text/csv; charset=utf-8")
+	ctxt.W().Header().Set("Content-Disposition", `attachment; filename="audit-log.csv"`)
+
+	out := csv.NewWriter(ctxt.W())
+	if err := out.Write(domain.AuditLogCSVHeader()); err != nil {
+		return err
+	}
+
+	err := store.AllForAuditLog(filter, 0, 0, func(activity *domain.Activity) error {
+		record, err := domain.NewAuditLogEntry(filter.OrganizationUuid, activity).CSVRecord()
+		if err != nil {
+			return err
+		}
+		return out.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func (self auditLogHandler) writeNdjson(ctxt RequestContext, store *stores.DbActivityStore, filter *domain.AuditLogFilter) error {
+	ctxt.W().Header().Set("Content-Type", "application/x-ndjson")
+	ctxt.W().Header().Set("Content-Disposition", `attachment; filename="audit-log.ndjson"`)
+
+	out := json.NewEncoder(ctxt.W())
+	return store.AllForAuditLog(filter, 0, 0, func(activity *domain.Activity) error {
+		return out.Encode(domain.NewAuditLogEntry(filter.OrganizationUuid, activity))
+	})
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+func storeJobEditedActivity(h *httpHandlerTest) *domain.Job {
+	job := h.World().Job("default")
+	previous := *job
+	previous.Name = "old name"
+
+	user := h.World().User("default")
+	activity := activities.JobEdited(job).
+		SetPrevious(&previous).
+		SetOrganizationUuid(h.World().Organization("default").Uuid)
+	activity.ContextUserUuid = &user.Uuid
+
+	if err := stores.NewDbActivityStore(h.Tx()).Store(activity); err != nil {
+		h.t.Fatal(err)
+	}
+
+	return job
+}
+
+func Test_AuditLogHandler_IndexForOrganization_exportsNdjsonWithChanges(t *testing.T) {
+	h := NewHandlerTest(MountOrganizationHandler, t)
+	defer h.Cleanup()
+
+	job := storeJobEditedActivity(h)
+
+	h.LoginAs("default")
+	h.Do("GET", h.Url("/organizations/"+h.World().Organization("default").Uuid+"/audit-log?format=ndjson&name=job.*"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	entries := []*domain.AuditLogEntry{}
+	lines := bufio.NewScanner(bytes.NewReader(h.ResponseBody()))
+	for lines.Scan() {
+		entry := &domain.AuditLogEntry{}
+		if err := json.Unmarshal(lines.Bytes(), entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if got, want := len(entries), 1; got != want {
+		t.Fatalf(`len(entries) = %d; want %d`, got, want)
+	}
+
+	changes := entries[0].Changes
+	if got, want := len(changes), 1; got != want {
+		t.Fatalf(`len(changes) = %d; want %d`, got, want)
+	}
+
+	if got, want := changes[0].Field, "name"; got != want {
+		t.Errorf(`changes[0].Field = %q; want %q`, got, want)
+	}
+
+	if got, want := changes[0].After, job.Name; got != want {
+		t.Errorf(`changes[0].After = %v; want %q`, got, want)
+	}
+}
+
+func Test_AuditLogHandler_IndexForOrganization_exportsCsv(t *testing.T) {
+	h := NewHandlerTest(MountOrganizationHandler, t)
+	defer h.Cleanup()
+
+	storeJobEditedActivity(h)
+
+	h.LoginAs("default")
+	h.Do("GET", h.Url("/organizations/"+h.World().Organization("default").Uuid+"/audit-log?format=csv&actor="+h.World().User("default").Uuid), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(h.ResponseBody())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(records), 2; got != want {
+		t.Fatalf(`len(records) = %d; want %d`, got, want)
+	}
+
+	if got, want := records[1][2], "job.edited"; got != want {
+		t.Errorf(`records[1][2] = %q; want %q`, got, want)
+	}
+}
+
+func Test_AuditLogHandler_IndexForOrganization_requiresOrganizationOwner(t *testing.T) {
+	h := NewHandlerTest(MountOrganizationHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("other")
+	h.Do("GET", h.Url("/organizations/"+h.World().Organization("default").Uuid+"/audit-log"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func Test_AuditLogHandler_IndexForOrganization_pagesNewestFirst(t *testing.T) {
+	h := NewHandlerTest(MountOrganizationHandler, t)
+	defer h.Cleanup()
+
+	organization := h.World().Organization("default")
+	store := stores.NewDbActivityStore(h.Tx())
+	occurredOn := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		activity := activities.CustomRoleCreated(&domain.CustomRole{
+			OrganizationUuid: organization.Uuid,
+			Name:             fmt.Sprintf("role-%d", i),
+		}).SetOrganizationUuid(organization.Uuid)
+		activity.OccurredOn = occurredOn.Add(time.Duration(i) * time.Hour)
+		if err := store.Store(activity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h.LoginAs("default")
+	pages := [][]time.Time{}
+	for _, offset := range []int{0, 2} {
+		result := struct {
+			Collection []struct {
+				Subject domain.AuditLogEntry
+			}
+			Meta struct {
+				Total string
+			} `json:"_meta"`
+		}{}
+		h.ResultTo(&result)
+		h.Do("GET", h.Url(fmt.Sprintf("/organizations/%s/audit-log?name=custom-role.*&limit=2&offset=%d", organization.Uuid, offset)), nil)
+
+		if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+			t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+		}
+
+		if got, want := result.Meta.Total, "3"; got != want {
+			t.Errorf("result.Meta.Total = %q; want %q", got, want)
+		}
+
+		page := []time.Time{}
+		for _, item := range result.Collection {
+			page = append(page, item.Subject.OccurredOn.UTC())
+		}
+		pages = append(pages, page)
+	}
+
+	want := [][]time.Time{
+		{occurredOn.Add(2 * time.Hour), occurredOn.Add(time.Hour)},
+		{occurredOn},
+	}
+	if got := fmt.Sprint(pages); got != fmt.Sprint(want) {
+		t.Errorf("pages = %s; want %s", got, fmt.Sprint(want))
+	}
+}
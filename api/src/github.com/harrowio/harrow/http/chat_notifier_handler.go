@@ -0,0 +1,202 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// chatNotifierHandler manages the project notifiers of one type, which
+// is also the name of the table they are stored in.
+type chatNotifierHandler struct {
+	notifierType string
+	store        *stores.DbChatNotifierStore
+	subject      domain.ProjectNotifier
+}
+
+func (h *chatNotifierHandler) init(ctxt RequestContext) (*chatNotifierHandler, error) {
+	handler := &chatNotifierHandler{notifierType: h.notifierType}
+	handler.store = stores.NewDbChatNotifierStore(ctxt.Tx(), h.notifierType)
+
+	uuid := ctxt.PathParameter("uuid")
+	if uuid != "" {
+		subject, err := handler.store.FindByUuid(uuid)
+		if err != nil {
+			return nil, err
+		}
+		handler.subject = subject
+	}
+
+	return handler, nil
+}
+
+func MountTeamsNotifierHandler(r *mux.Router, ctxt ServerContext) {
+	mountChatNotifierHandler(r, ctxt, domain.ChatNotifierTeams)
+}
+
+func MountMattermostNotifierHandler(r *mux.Router, ctxt ServerContext) {
+	mountChatNotifierHandler(r, ctxt, domain.ChatNotifierMattermost)
+}
+
+func MountDiscordNotifierHandler(r *mux.Router, ctxt ServerContext) {
+	mountChatNotifierHandler(r, ctxt, domain.ChatNotifierDiscord)
+}
+
+func MountBlameNotifierHandler(r *mux.Router, ctxt ServerContext) {
+	mountChatNotifierHandler(r, ctxt, domain.BlameNotifierType)
+}
+
+// mountChatNotifierHandler mounts the handler for notifiers of the
+// given type, e.g. "teams_notifiers" at /teams-notifiers.
+func mountChatNotifierHandler(r *mux.Router, ctxt ServerContext, notifierType string) {
+	h := &chatNotifierHandler{notifierType: notifierType}
+	name := strings.Replace(notifierType, "_", "-", -1)
+
+	root := r.PathPrefix("/" + name).Subrouter()
+
+	// Collection
+	root.Methods("POST").Handler(HandlerFunc(ctxt, h.Create)).
+		Name(name + "-create")
+	root.Methods("PUT").Handler(HandlerFunc(ctxt, h.Update)).
+		Name(name + "-update")
+
+	// Item
+	item := root.PathPrefix("/{uuid}").Subrouter()
+	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
+		Name(name + "-show")
+	item.Methods("DELETE").Handler(HandlerFunc(ctxt, h.Archive)).
+		Name(name + "-archive")
+
+}
+
+func (h *chatNotifierHandler) Create(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	self := domain.NewProjectNotifier(h.notifierType)
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: self}); err != nil {
+		return err
+	}
+
+	self.NotifierBase().UrlHost = ctxt.User().UrlHost
+	if allowed, err := ctxt.Auth().CanCreate(self); !allowed {
+		return err
+	}
+
+	if err := self.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := h.store.Create(self); err != nil {
+		return err
+	}
+	ctxt.EnqueueActivity(activities.ChatNotifierCreated(self), nil)
+	ctxt.W().Header().Set("Location", urlForSubject(ctxt.R(), self))
+	ctxt.W().WriteHeader(http.StatusCreated)
+	writeAsJson(ctxt, self)
+
+	return nil
+}
+
+func (h *chatNotifierHandler) Update(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	newVersion := domain.NewProjectNotifier(h.notifierType)
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: newVersion}); err != nil {
+		return err
+	}
+
+	h.subject, err = h.store.FindByUuid(newVersion.NotifierBase().Uuid)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(h.subject); !allowed {
+		return err
+	}
+
+	// Only the name and the fields specific to the type of notifier
+	// can be changed.
+	base := *h.subject.NotifierBase()
+	base.Name = newVersion.NotifierBase().Name
+	*newVersion.NotifierBase() = base
+	if err := newVersion.Validate(); err != nil {
+		return err
+	}
+
+	if err := h.store.Update(newVersion); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.ChatNotifierEdited(newVersion).SetPrevious(h.subject), nil)
+	writeAsJson(ctxt, newVersion)
+
+	return nil
+}
+
+func (h *chatNotifierHandler) Show(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(h.subject); !allowed {
+		return err
+	}
+
+	writeAsJson(ctxt, h.subject)
+
+	return nil
+}
+
+func (h *chatNotifierHandler) Archive(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanArchive(h.subject); !allowed {
+		return err
+	}
+
+	if err := h.store.ArchiveByUuid(h.subject.NotifierBase().Uuid); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.ChatNotifierDeleted(h.subject), nil)
+
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
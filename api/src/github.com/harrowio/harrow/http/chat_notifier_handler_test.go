@@ -0,0 +1,182 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+// chatNotifierHandlerTests lists the notifier types served by the
+// chat notifier handler, together with a notifier of each type that
+// is valid once the fields shared by all types are set.
+var chatNotifierHandlerTests = []struct {
+	notifierType string
+	mount        func(*mux.Router, ServerContext)
+	path         string
+	notifier     func() domain.ProjectNotifier
+}{
+	{
+		notifierType: domain.ChatNotifierTeams,
+		mount:        MountTeamsNotifierHandler,
+		path:         "teams-notifiers",
+		notifier: func() domain.ProjectNotifier {
+			return &domain.TeamsNotifier{ChatWebhookNotifier: domain.ChatWebhookNotifier{
+				WebhookURL: "https://example.webhook.office.com/webhookb2/8c1f4d0e-2f6b-4b7e-9a55-3f0c6f8d2a17/IncomingWebhook/5b9e0c3a1d7f4e2b8c6a9d0e1f2a3b4c",
+			}}
+		},
+	},
+	{
+		notifierType: domain.ChatNotifierMattermost,
+		mount:        MountMattermostNotifierHandler,
+		path:         "mattermost-notifiers",
+		notifier: func() domain.ProjectNotifier {
+			return &domain.MattermostNotifier{ChatWebhookNotifier: domain.ChatWebhookNotifier{
+				WebhookURL: "https://example.com/hooks/xw8ntq4ypb8ydpz4f5gzmhkbko",
+			}}
+		},
+	},
+	{
+		notifierType: domain.ChatNotifierDiscord,
+		mount:        MountDiscordNotifierHandler,
+		path:         "discord-notifiers",
+		notifier: func() domain.ProjectNotifier {
+			return &domain.DiscordNotifier{ChatWebhookNotifier: domain.ChatWebhookNotifier{
+				WebhookURL: "https://discord.com/api/webhooks/1029384756/Yq3nZ8vK2mT7wR4xP1sL6dF9gH0jB5cN",
+			}}
+		},
+	},
+	{
+		notifierType: domain.BlameNotifierType,
+		mount:        MountBlameNotifierHandler,
+		path:         "blame-notifiers",
+		notifier: func() domain.ProjectNotifier {
+			return &domain.BlameNotifier{}
+		},
+	},
+}
+
+// newProjectNotifier sets the fields shared by all project notifiers
+// on notifier.
+func newProjectNotifier(h *httpHandlerTest, notifier domain.ProjectNotifier) domain.ProjectNotifier {
+	*notifier.NotifierBase() = domain.ProjectNotifierBase{
+		Uuid:        uuidhelper.MustNewV4(),
+		Name:        "default " + notifier.NotifierType(),
+		UrlHost:     "https://www.vm.harrow.io",
+		ProjectUuid: h.World().Project("public").Uuid,
+	}
+
+	return notifier
+}
+
+func createDefaultProjectNotifier(t *testing.T, h *httpHandlerTest, notifier domain.ProjectNotifier) domain.ProjectNotifier {
+	subject := newProjectNotifier(h, notifier)
+	if _, err := stores.NewDbChatNotifierStore(h.Tx(), subject.NotifierType()).Create(subject); err != nil {
+		t.Fatal(err)
+	}
+
+	return subject
+}
+
+func emittedActivity(h *httpHandlerTest, name string) bool {
+	for _, activity := range h.Activities() {
+		if activity.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func Test_ChatNotifierHandler_Routing(t *testing.T) {
+	for _, test := range chatNotifierHandlerTests {
+		r := mux.NewRouter()
+		test.mount(r, nil)
+
+		spec := routingSpec{
+			{"POST", "/" + test.path, test.path + "-create"},
+			{"PUT", "/" + test.path, test.path + "-update"},
+			{"GET", "/" + test.path + "/:uuid", test.path + "-show"},
+			{"DELETE", "/" + test.path + "/:uuid", test.path + "-archive"},
+		}
+
+		spec.run(r, t)
+	}
+}
+
+func Test_ChatNotifierHandler_Create_emitsCreatedActivity(t *testing.T) {
+	for _, test := range chatNotifierHandlerTests {
+		h := NewHandlerTest(test.mount, t)
+		h.LoginAs("default")
+
+		h.Do("POST", h.Url("/"+test.path), &halWrapper{
+			Subject: newProjectNotifier(h, test.notifier()),
+		})
+		if !emittedActivity(h, test.path+".created") {
+			t.Errorf("Activity %q not found", test.path+".created")
+		}
+
+		h.Cleanup()
+	}
+}
+
+func Test_ChatNotifierHandler_Create_rejectsWebhooksOfOtherHosts(t *testing.T) {
+	for _, test := range chatNotifierHandlerTests {
+		if !domain.IsChatNotifierType(test.notifierType) {
+			continue
+		}
+
+		h := NewHandlerTest(test.mount, t)
+		h.LoginAs("default")
+
+		notifier := (&domain.ChatNotifier{
+			NotifierType: test.notifierType,
+			Name:         "internal " + test.notifierType,
+			WebhookURL:   "http://127.0.0.1:8080/hooks",
+			ProjectUuid:  h.World().Project("public").Uuid,
+		}).Notifier()
+		h.Do("POST", h.Url("/"+test.path), &halWrapper{
+			Subject: notifier.(domain.ProjectNotifier),
+		})
+		if got, want := h.Response().StatusCode, StatusUnprocessableEntity; got != want {
+			t.Errorf("%s: status = %d; want %d", test.notifierType, got, want)
+		}
+
+		h.Cleanup()
+	}
+}
+
+func Test_ChatNotifierHandler_Update_emitsEditedActivity(t *testing.T) {
+	for _, test := range chatNotifierHandlerTests {
+		h := NewHandlerTest(test.mount, t)
+		h.LoginAs("default")
+		subject := createDefaultProjectNotifier(t, h, test.notifier())
+
+		h.Do("PUT", h.Url("/"+test.path), &halWrapper{
+			Subject: subject,
+		})
+		if !emittedActivity(h, test.path+".edited") {
+			t.Errorf("Activity %q not found", test.path+".edited")
+		}
+
+		h.Cleanup()
+	}
+}
+
+func Test_ChatNotifierHandler_Delete_emitsDeletedActivity(t *testing.T) {
+	for _, test := range chatNotifierHandlerTests {
+		h := NewHandlerTest(test.mount, t)
+		h.LoginAs("default")
+		subject := createDefaultProjectNotifier(t, h, test.notifier())
+
+		h.Subject(subject)
+		h.Do("DELETE", h.UrlFor("self"), nil)
+		if !emittedActivity(h, test.path+".deleted") {
+			t.Errorf("Activity %q not found", test.path+".deleted")
+		}
+
+		h.Cleanup()
+	}
+}
@@ -44,6 +44,10 @@ type RequestContext interface {
 	// User returns the user who is currently logged in.
 	User() *domain.User
 
+	// ApiToken returns the API token the current request has been
+	// authenticated with, or nil.
+	ApiToken() *domain.ApiToken
+
 	// Auth provides access to the authorization logic.
 	Auth() authz.Service
 
@@ -58,6 +62,12 @@ type RequestContext interface {
 	// Rollback the Tx() in case of an error
 	RollbackTx()
 
+	// AfterCommit registers fn to be called once the transaction
+	// returned by Tx has been committed.  This is used for writes
+	// to stores outside of the database, which cannot be rolled
+	// back together with the transaction.
+	AfterCommit(fn func() error)
+
 	// PathParameter returns the path parameter identified by key
 	// for the current request.
 	PathParameter(key string) string
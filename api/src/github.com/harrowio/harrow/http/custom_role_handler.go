@@ -0,0 +1,221 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// customRoleHandler manages the custom roles defined by organizations.
+// Listing the roles of an organization is mounted as a relationship of
+// organizations; assigning roles is handled by projectMemberHandler.
+type customRoleHandler struct {
+}
+
+type customRoleParams struct {
+	Uuid             string   `json:"uuid"`
+	OrganizationUuid string   `json:"organizationUuid"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Capabilities     []string `json:"capabilities"`
+}
+
+func MountCustomRoleHandler(r *mux.Router, ctxt ServerContext) {
+	h := customRoleHandler{}
+
+	root := r.PathPrefix("/custom-roles").Subrouter()
+	root.Methods("GET").Path("/capabilities").Handler(HandlerFunc(ctxt, h.Capabilities)).
+		Name("custom-role-capabilities")
+	root.Methods("POST").Handler(HandlerFunc(ctxt, h.Create)).
+		Name("custom-role-create")
+	root.Methods("PUT").Handler(HandlerFunc(ctxt, h.Update)).
+		Name("custom-role-update")
+
+	// Item
+	item := root.PathPrefix("/{uuid}").Subrouter()
+	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
+		Name("custom-role-show")
+	item.Methods("DELETE").Handler(HandlerFunc(ctxt, h.Archive)).
+		Name("custom-role-archive")
+}
+
+func (self customRoleHandler) paramsFrom(src io.Reader) (*customRoleParams, error) {
+	params := struct {
+		Subject customRoleParams `json:"subject"`
+	}{}
+
+	if err := json.NewDecoder(src).Decode(&params); err != nil {
+		return nil, err
+	}
+
+	return &params.Subject, nil
+}
+
+// Capabilities lists all capabilities that can be granted by custom
+// roles.
+func (self customRoleHandler) Capabilities(ctxt RequestContext) error {
+	ctxt.W().Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(ctxt.W()).Encode(map[string][]string{
+		"capabilities": domain.AssignableCapabilities(),
+	})
+}
+
+func (self customRoleHandler) Show(ctxt RequestContext) error {
+
+	role, err := stores.NewDbCustomRoleStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(role); !allowed {
+		return err
+	}
+
+	writeAsJson(ctxt, role)
+
+	return nil
+}
+
+func (self customRoleHandler) Create(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	params, err := self.paramsFrom(ctxt.R().Body)
+	if err != nil {
+		return err
+	}
+
+	role := &domain.CustomRole{
+		OrganizationUuid: params.OrganizationUuid,
+		Name:             params.Name,
+		Description:      params.Description,
+		Grants:           domain.CustomRoleCapabilities(params.Capabilities),
+	}
+
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanCreate(role); !allowed {
+		return err
+	}
+
+	if _, err := stores.NewDbCustomRoleStore(ctxt.Tx()).Create(role); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.CustomRoleCreated(role), nil)
+	ctxt.W().Header().Set("Location", urlForSubject(ctxt.R(), role))
+	ctxt.W().WriteHeader(http.StatusCreated)
+	writeAsJson(ctxt, role)
+
+	return nil
+}
+
+func (self customRoleHandler) Update(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	params, err := self.paramsFrom(ctxt.R().Body)
+	if err != nil {
+		return err
+	}
+
+	store := stores.NewDbCustomRoleStore(ctxt.Tx())
+	role, err := store.FindByUuid(params.Uuid)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(role); !allowed {
+		return err
+	}
+
+	previous := *role
+	role.Name = params.Name
+	role.Description = params.Description
+	role.Grants = domain.CustomRoleCapabilities(params.Capabilities)
+
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
+	if err := store.Update(role); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.CustomRoleEdited(role).SetPrevious(&previous), nil)
+	writeAsJson(ctxt, role)
+
+	return nil
+}
+
+// Archive archives a custom role.  Project memberships keep referring
+// to archived roles, but archived roles no longer grant anything.
+func (self customRoleHandler) Archive(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	store := stores.NewDbCustomRoleStore(ctxt.Tx())
+	role, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanArchive(role); !allowed {
+		return err
+	}
+
+	if err := store.ArchiveByUuid(role.Uuid); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.CustomRoleArchived(role), nil)
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (self customRoleHandler) IndexForOrganization(ctxt RequestContext) error {
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(organization); !allowed {
+		return err
+	}
+
+	roles, err := stores.NewDbCustomRoleStore(ctxt.Tx()).FindAllByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		return err
+	}
+
+	result := []interface{}{}
+	for _, role := range roles {
+		if allowed, _ := ctxt.Auth().CanRead(role); allowed {
+			result = append(result, role)
+		}
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
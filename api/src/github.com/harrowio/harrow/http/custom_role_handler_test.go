@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+func Test_CustomRoleHandler_Routing(t *testing.T) {
+	r := mux.NewRouter()
+	MountCustomRoleHandler(r, nil)
+
+	spec := routingSpec{
+		{"GET", "/custom-roles/capabilities", "custom-role-capabilities"},
+		{"POST", "/custom-roles", "custom-role-create"},
+		{"PUT", "/custom-roles", "custom-role-update"},
+		{"GET", "/custom-roles/:uuid", "custom-role-show"},
+		{"DELETE", "/custom-roles/:uuid", "custom-role-archive"},
+	}
+
+	spec.run(r, t)
+}
+
+func Test_CustomRoleHandler_Create_createsRoleForOrganization(t *testing.T) {
+	h := NewHandlerTest(MountCustomRoleHandler, t)
+	defer h.Cleanup()
+
+	organization := h.World().Organization("default")
+	result := struct {
+		Subject struct {
+			Uuid string
+		}
+	}{}
+	h.ResultTo(&result)
+	h.LoginAs("default")
+	h.Do("POST", h.Url("/custom-roles"), &halWrapper{
+		Subject: &customRoleParams{
+			OrganizationUuid: organization.Uuid,
+			Name:             "Deployer",
+			Capabilities:     []string{"create-schedule", "read-secret"},
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	role, err := stores.NewDbCustomRoleStore(h.Tx()).FindByUuid(result.Subject.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(role.Grants), 2; got != want {
+		t.Errorf(`len(role.Grants) = %d; want %d`, got, want)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "custom-role.created" {
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "custom-role.created")
+}
+
+func Test_CustomRoleHandler_Create_requiresOrganizationOwner(t *testing.T) {
+	h := NewHandlerTest(MountCustomRoleHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("other")
+	h.Do("POST", h.Url("/custom-roles"), &halWrapper{
+		Subject: &customRoleParams{
+			OrganizationUuid: h.World().Organization("default").Uuid,
+			Name:             "Deployer",
+			Capabilities:     []string{"create-schedule"},
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func Test_CustomRoleHandler_Create_rejectsCapabilitiesBeyondProjectOwner(t *testing.T) {
+	h := NewHandlerTest(MountCustomRoleHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("default")
+	h.Do("POST", h.Url("/custom-roles"), &halWrapper{
+		Subject: &customRoleParams{
+			OrganizationUuid: h.World().Organization("default").Uuid,
+			Name:             "Escalator",
+			Capabilities:     []string{"archive-organization"},
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func Test_CustomRoleHandler_Archive_archivesRole(t *testing.T) {
+	h := NewHandlerTest(MountCustomRoleHandler, t)
+	defer h.Cleanup()
+
+	role := &domain.CustomRole{
+		OrganizationUuid: h.World().Organization("default").Uuid,
+		Name:             "Deployer",
+		Grants:           domain.CustomRoleCapabilities{"create-schedule"},
+	}
+	store := stores.NewDbCustomRoleStore(h.Tx())
+	if _, err := store.Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("default")
+	h.Do("DELETE", h.Url("/custom-roles/"+role.Uuid), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusNoContent; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if _, err := store.FindByUuid(role.Uuid); !domain.IsNotFound(err) {
+		t.Fatalf("store.FindByUuid: err = %v; want not found", err)
+	}
+}
@@ -114,8 +114,12 @@ func (h *EmailNotifierHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
+	previous := *h.subject
+
 	h.subject.Recipient = newVersion.Recipient
 	h.subject.ProjectUuid = newVersion.ProjectUuid
+	h.subject.SubjectTemplate = newVersion.SubjectTemplate
+	h.subject.BodyTemplate = newVersion.BodyTemplate
 
 	if err := h.subject.Validate(); err != nil {
 		return err
@@ -125,7 +129,7 @@ func (h *EmailNotifierHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
-	ctxt.EnqueueActivity(activities.EmailNotifierEdited(h.subject), nil)
+	ctxt.EnqueueActivity(activities.EmailNotifierEdited(h.subject).SetPrevious(&previous), nil)
 	writeAsJson(ctxt, h.subject)
 
 	return nil
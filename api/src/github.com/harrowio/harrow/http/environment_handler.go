@@ -116,6 +116,7 @@ func (self envHandler) CreateUpdate(ctxt RequestContext) (err error) {
 		env = &domain.Environment{}
 	}
 
+	previous := *env
 	copyEnvParams(params, env)
 
 	var uuid string
@@ -138,7 +139,7 @@ func (self envHandler) CreateUpdate(ctxt RequestContext) (err error) {
 		}
 		err = store.Update(env)
 		uuid = env.Uuid
-		ctxt.EnqueueActivity(activities.EnvironmentEdited(env), nil)
+		ctxt.EnqueueActivity(activities.EnvironmentEdited(env).SetPrevious(&previous), nil)
 	}
 
 	if err != nil {
@@ -281,46 +282,9 @@ func (self envHandler) Secrets(ctxt RequestContext) (err error) {
 		return err
 	}
 
-	var interfaceSecrets []interface{} = make([]interface{}, 0)
-	for _, secret := range secrets {
-
-		allowed, _ := ctxt.Auth().CanRead(secret)
-		if allowed {
-			if secret.IsPending() {
-				interfaceSecrets = append(interfaceSecrets, secret)
-			} else {
-				if secret.IsSsh() {
-					sshSecret, err := domain.AsSshSecret(secret)
-					if err != nil {
-						return err
-					}
-					unpriv, err := sshSecret.AsUnprivileged()
-					if err != nil {
-						return err
-					}
-					interfaceSecrets = append(interfaceSecrets, unpriv)
-				} else {
-					envSecret, err := domain.AsEnvironmentSecret(secret)
-					if err != nil {
-						return err
-					}
-					if privileged, _ := ctxt.Auth().Can(domain.CapabilityReadPrivileged, secret); privileged {
-						priv, err := envSecret.AsPrivileged()
-						if err != nil {
-							return err
-						}
-						interfaceSecrets = append(interfaceSecrets, priv)
-					} else {
-						unpriv, err := envSecret.AsUnprivileged()
-						if err != nil {
-							return err
-						}
-						interfaceSecrets = append(interfaceSecrets, unpriv)
-					}
-				}
-			}
-		}
-
+	interfaceSecrets, err := readableSecrets(ctxt, secrets)
+	if err != nil {
+		return err
 	}
 
 	writeCollectionPageAsJson(ctxt, &CollectionPage{
@@ -122,6 +122,10 @@ func NewOAuthError(provider string, reason string, err error) *Error {
 	return NewError(http.StatusBadRequest, fmt.Sprintf("oauth.%s.%s", provider, reason), err.Error())
 }
 
+func NewSsoError(reason string, err error) *Error {
+	return NewError(http.StatusBadRequest, fmt.Sprintf("sso.%s", reason), err.Error())
+}
+
 func (e *Error) Error() string {
 	return e.msg
 }
@@ -140,4 +144,15 @@ var (
 	ErrSessionUuidMalformed = NewError(400, "session_uuid_malformed", "Session UUID malformed")
 	ErrLoginRequired        = NewError(403, "login_required", "Login required")
 	ErrUserBlocked          = NewError(403, "user_blocked", "User blocked")
+	ErrApiTokenNotFound     = NewError(403, "api_token_not_found", "API token not found")
+	ErrApiTokenExpired      = NewError(403, "api_token_expired", "API token expired")
+	ErrApiTokenRevoked      = NewError(403, "api_token_revoked", "API token revoked")
+	ErrApiTokenUserNotFound = NewError(403, "api_token_user_not_found", "API token user not found")
+	ErrSsoRequired          = NewError(403, "sso_required", "Single sign-on required")
+	ErrTotpRequired         = NewError(403, "totp_required", "Two-factor authentication required")
+	ErrIpNotAllowed         = NewError(403, "ip_not_allowed", "Client address not allowed")
+	ErrAdminRequired        = NewError(403, "admin_required", "Administrator required")
+
+	ErrApiTokenCapabilitiesExceeded = NewError(403, "api_token_capabilities_exceeded", "API token capabilities exceeded")
+	ErrApiTokenNotPermitted         = NewError(403, "api_token_not_permitted", "API tokens are not permitted for this request")
 )
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// failedDeliveryPageSize is the default and maximum number of failed
+// deliveries listed at once.
+const failedDeliveryPageSize = 100
+
+// failedDeliveryHandler lets administrators inspect the deliveries the
+// notifier and the mail-dispatcher failed at, and replay dead ones.
+// Administrators are configured through HAR_ADMIN_USER_UUIDS.
+type failedDeliveryHandler struct{}
+
+func MountFailedDeliveryHandler(r *mux.Router, ctxt ServerContext) {
+	h := failedDeliveryHandler{}
+	root := r.PathPrefix("/admin/failed-deliveries").Subrouter()
+
+	root.Methods("GET").Path("/{uuid}").Handler(HandlerFunc(ctxt, h.Show)).
+		Name("admin-failed-delivery-show")
+	root.Methods("POST").Path("/{uuid}/replay").Handler(HandlerFunc(ctxt, h.Replay)).
+		Name("admin-failed-delivery-replay")
+	root.Methods("GET").Handler(HandlerFunc(ctxt, h.Index)).
+		Name("admin-failed-deliveries")
+}
+
+// Index lists failed deliveries, most recently updated first.  The
+// list can be filtered with the following query parameters:
+//
+//	status  one of retrying, dead (the default), replaying, replayed
+//	        and delivered
+//	source  "notifier" or "mail-dispatcher"; all sources by default
+//	limit   the maximum number of deliveries to return
+func (self failedDeliveryHandler) Index(ctxt RequestContext) error {
+
+	if err := self.requireAdmin(ctxt); err != nil {
+		return err
+	}
+
+	query := ctxt.R().URL.Query()
+	status := query.Get("status")
+	if status == "" {
+		status = domain.FailedDeliveryDead
+	}
+	if !domain.IsFailedDeliveryStatus(status) {
+		return domain.NewValidationError("status", "invalid")
+	}
+
+	source := query.Get("source")
+	switch source {
+	case "", domain.FailedDeliverySourceNotifier, domain.FailedDeliverySourceMailDispatcher:
+	default:
+		return domain.NewValidationError("source", "invalid")
+	}
+
+	limit := failedDeliveryPageSize
+	if param := query.Get("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed <= 0 {
+			return domain.NewValidationError("limit", "invalid")
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	store := stores.NewDbFailedDeliveryStore(ctxt.Tx())
+	store.SetLogger(ctxt.Log())
+	failures, err := store.FindAllByStatus(source, status, limit)
+	if err != nil {
+		return err
+	}
+
+	collection := []interface{}{}
+	for _, failed := range failures {
+		collection = append(collection, failed)
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(collection),
+		Count:      len(collection),
+		Collection: collection,
+	})
+
+	return nil
+}
+
+func (self failedDeliveryHandler) Show(ctxt RequestContext) error {
+
+	if err := self.requireAdmin(ctxt); err != nil {
+		return err
+	}
+
+	failed, err := stores.NewDbFailedDeliveryStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	writeAsJson(ctxt, failed)
+
+	return nil
+}
+
+// Replay asks the worker a dead delivery failed in to try it again.
+// Workers pick up replays within a minute.
+func (self failedDeliveryHandler) Replay(ctxt RequestContext) error {
+
+	if err := self.requireAdmin(ctxt); err != nil {
+		return err
+	}
+
+	store := stores.NewDbFailedDeliveryStore(ctxt.Tx())
+	failed, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if err := failed.Replay(); err != nil {
+		return err
+	}
+
+	if err := store.Update(failed); err != nil {
+		return err
+	}
+
+	ctxt.W().WriteHeader(http.StatusAccepted)
+	writeAsJson(ctxt, failed)
+
+	return nil
+}
+
+func (self failedDeliveryHandler) requireAdmin(ctxt RequestContext) error {
+	user := ctxt.User()
+	if user == nil {
+		return ErrLoginRequired
+	}
+
+	if !ctxt.Config().AdminConfig().IsAdmin(user.Uuid) {
+		return ErrAdminRequired
+	}
+
+	return nil
+}
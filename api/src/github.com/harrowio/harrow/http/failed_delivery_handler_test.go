@@ -0,0 +1,106 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+func Test_FailedDeliveryHandler_Routing(t *testing.T) {
+	r := mux.NewRouter()
+	MountFailedDeliveryHandler(r, nil)
+
+	spec := routingSpec{
+		{"GET", "/admin/failed-deliveries", "admin-failed-deliveries"},
+		{"GET", "/admin/failed-deliveries/:uuid", "admin-failed-delivery-show"},
+		{"POST", "/admin/failed-deliveries/:uuid/replay", "admin-failed-delivery-replay"},
+	}
+
+	spec.run(r, t)
+}
+
+func newDeadFailedDelivery(t *testing.T, h *httpHandlerTest) *domain.FailedDelivery {
+	failed, err := domain.NewFailedDelivery(
+		domain.FailedDeliverySourceMailDispatcher,
+		domain.FailedDeliveryKindMessage,
+		domain.MessageKey("activities", "42"),
+		&domain.FailedMessage{Table: "activities", Id: "42"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed.GiveUp(domain.FailedDeliveryMaxAttempts, errors.New("connection refused"))
+
+	if err := stores.NewDbFailedDeliveryStore(h.Tx()).Create(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	return failed
+}
+
+func Test_FailedDeliveryHandler_Index_requiresAnAdministrator(t *testing.T) {
+	h := NewHandlerTest(MountFailedDeliveryHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+	os.Setenv("HAR_ADMIN_USER_UUIDS", "")
+
+	h.Do("GET", h.Url("/admin/failed-deliveries"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+	}
+}
+
+func Test_FailedDeliveryHandler_Replay_marksDeadDeliveryAsReplaying(t *testing.T) {
+	h := NewHandlerTest(MountFailedDeliveryHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+	os.Setenv("HAR_ADMIN_USER_UUIDS", h.User().Uuid)
+	defer os.Setenv("HAR_ADMIN_USER_UUIDS", "")
+
+	failed := newDeadFailedDelivery(t, h)
+	h.Subject(failed)
+	h.Do("POST", h.UrlFor("replay"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusAccepted; got != want {
+		t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+	}
+
+	found, err := stores.NewDbFailedDeliveryStore(h.Tx()).FindByUuid(failed.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := found.Status, domain.FailedDeliveryReplaying; got != want {
+		t.Errorf("found.Status = %q; want %q", got, want)
+	}
+}
+
+func Test_FailedDeliveryHandler_Replay_rejectsDeliveriesThatAreNotDead(t *testing.T) {
+	h := NewHandlerTest(MountFailedDeliveryHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+	os.Setenv("HAR_ADMIN_USER_UUIDS", h.User().Uuid)
+	defer os.Setenv("HAR_ADMIN_USER_UUIDS", "")
+
+	failed := newDeadFailedDelivery(t, h)
+	if err := failed.Replay(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stores.NewDbFailedDeliveryStore(h.Tx()).Update(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Subject(failed)
+	h.Do("POST", h.UrlFor("replay"), nil)
+
+	if got, want := h.Response().StatusCode, StatusUnprocessableEntity; got != want {
+		t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+	}
+}
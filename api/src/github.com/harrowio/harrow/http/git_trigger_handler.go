@@ -114,6 +114,8 @@ func (h *GitTriggerHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
+	previous := *h.subject
+
 	h.subject.Name = newVersion.Name
 	h.subject.JobUuid = newVersion.JobUuid
 	h.subject.RepositoryUuid = newVersion.RepositoryUuid
@@ -128,7 +130,7 @@ func (h *GitTriggerHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
-	ctxt.EnqueueActivity(activities.GitTriggerEdited(h.subject), nil)
+	ctxt.EnqueueActivity(activities.GitTriggerEdited(h.subject).SetPrevious(&previous), nil)
 	writeAsJson(ctxt, h.subject)
 
 	return nil
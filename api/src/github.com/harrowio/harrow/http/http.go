@@ -45,17 +45,23 @@ func ListenAndServe(l zerolog.Logger, db *sqlx.DB, bus activity.Sink, kv stores.
 	MountIndexHandler(r, ctxt)
 
 	MountActivitiesHandler(r, ctxt)
+	MountApiTokenHandler(r, ctxt)
 	MountBillingPlanHandler(r, ctxt)
 	MountCapistranoHandler(r, ctxt)
+	MountCustomRoleHandler(r, ctxt)
 	MountEnvironmentHandler(r, ctxt)
 	MountEmailNotifierHandler(r, ctxt)
+	MountFailedDeliveryHandler(r, ctxt)
 	MountDeliveryHandler(r, ctxt)
 	MountInvitationHandler(r, ctxt)
+	MountIpAllowlistHandler(r, ctxt)
 	MountGitTriggerHandler(r, ctxt)
 	MountFeaturesHandler(r, ctxt)
 	MountJobHandler(r, ctxt)
 	MountJobNotifierHandler(r, ctxt)
-	MountLogHandler(r, ctxt)
+	if err := MountLogHandler(r, ctxt); err != nil {
+		l.Fatal().Msgf("mounting log handler: %s", err)
+	}
 	MountNotificationRuleHandler(r, ctxt)
 	MountOAuthHandler(r, ctxt)
 	MountOperationHandler(r, ctxt)
@@ -68,8 +74,14 @@ func ListenAndServe(l zerolog.Logger, db *sqlx.DB, bus activity.Sink, kv stores.
 	MountPasswordResetHandler(r, ctxt)
 	MountScheduleHandler(r, ctxt)
 	MountSlackNotifierHandler(r, ctxt)
+	MountWebhookNotifierHandler(r, ctxt)
+	MountTeamsNotifierHandler(r, ctxt)
+	MountMattermostNotifierHandler(r, ctxt)
+	MountDiscordNotifierHandler(r, ctxt)
+	MountBlameNotifierHandler(r, ctxt)
 	MountSecretHandler(r, ctxt)
 	MountSessionHandler(r, ctxt)
+	MountSsoHandler(r, ctxt)
 	MountStencilHandler(r, ctxt)
 	MountScriptEditorHandler(r, ctxt)
 	MountTaskHandler(r, ctxt)
@@ -155,3 +167,45 @@ func CurrentUser(c *config.Config, tx *sqlx.Tx, sessionUuid string) (*domain.Use
 	tx.MustExec(query)
 	return user, nil
 }
+
+// CurrentApiTokenUser returns the user on whose behalf the given API
+// token acts, together with the token itself.
+func CurrentApiTokenUser(c *config.Config, tx *sqlx.Tx, plainToken string) (*domain.User, *domain.ApiToken, error) {
+	tokenStore := stores.NewDbApiTokenStore(tx)
+	userStore := stores.NewDbUserStore(tx, c)
+
+	token, err := tokenStore.FindByToken(plainToken)
+	if err != nil {
+		return nil, nil, ErrApiTokenNotFound
+	}
+
+	if token.IsRevoked() {
+		return nil, nil, ErrApiTokenRevoked
+	}
+
+	if token.IsExpired(time.Now()) {
+		return nil, nil, ErrApiTokenExpired
+	}
+
+	user, err := userStore.FindByUuid(token.UserUuid)
+	if err != nil {
+		return nil, nil, ErrApiTokenUserNotFound
+	}
+
+	query := fmt.Sprintf("SET LOCAL harrow.context_user_uuid TO '%s'", user.Uuid)
+	tx.MustExec(query)
+
+	return user, token, nil
+}
+
+// requireSessionUser returns ErrApiTokenNotPermitted if the current
+// request has been authenticated with an API token.  Handlers which
+// only touch the current user's own state have no subject to check a
+// token's capabilities against and use this instead.
+func requireSessionUser(ctxt RequestContext) error {
+	if ctxt.ApiToken() != nil {
+		return ErrApiTokenNotPermitted
+	}
+
+	return nil
+}
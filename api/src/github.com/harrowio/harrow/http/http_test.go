@@ -148,6 +148,7 @@ type testContext struct {
 	// need to be pointers so both ServerContext and RequestContext can
 	// point to the same values
 	txRolledBack, txCommitted *bool
+	afterCommit               []func() error
 	authz                     *helpers.MockAuthzService
 	log                       logger.Logger
 }
@@ -187,6 +188,10 @@ func (tc *testContext) User() *domain.User {
 	return tc.u
 }
 
+func (tc *testContext) ApiToken() *domain.ApiToken {
+	return nil
+}
+
 func (tc *testContext) Auth() authz.Service {
 	return tc.authz
 }
@@ -202,9 +207,18 @@ func (tc *testContext) Tx() *sqlx.Tx {
 func (tc *testContext) CommitTx() error {
 	// no-op, just record that we committed
 	*tc.txCommitted = !*tc.txRolledBack
+	for _, fn := range tc.afterCommit {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (tc *testContext) AfterCommit(fn func() error) {
+	tc.afterCommit = append(tc.afterCommit, fn)
+}
+
 func (tc *testContext) RollbackTx() {
 	// no-op, just record that we rolled back
 	*tc.txRolledBack = !*tc.txCommitted
@@ -316,6 +330,7 @@ type authzTestContext struct {
 	// point to the same values
 	txRolledBack, txCommitted *bool
 	activities                *[]*domain.Activity
+	afterCommit               []func() error
 	authz                     authz.Service
 	log                       logger.Logger
 }
@@ -355,6 +370,10 @@ func (tc *authzTestContext) User() *domain.User {
 	return tc.u
 }
 
+func (tc *authzTestContext) ApiToken() *domain.ApiToken {
+	return nil
+}
+
 func (tc *authzTestContext) Auth() authz.Service {
 	return tc.authz
 }
@@ -388,9 +407,18 @@ func (tc *authzTestContext) Activities() []*domain.Activity {
 func (tc *authzTestContext) CommitTx() error {
 	// no-op, just record that we committed
 	*tc.txCommitted = !*tc.txRolledBack
+	for _, fn := range tc.afterCommit {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (tc *authzTestContext) AfterCommit(fn func() error) {
+	tc.afterCommit = append(tc.afterCommit, fn)
+}
+
 func (tc *authzTestContext) RollbackTx() {
 	// no-op, just record that we rolled back
 	*tc.txRolledBack = !*tc.txCommitted
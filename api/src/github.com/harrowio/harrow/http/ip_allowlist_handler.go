@@ -0,0 +1,170 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// ipAllowlistHandler manages the entries of organizations' IP
+// allowlists.  The allowlists are enforced by the request context.
+type ipAllowlistHandler struct {
+}
+
+type ipAllowlistEntryParams struct {
+	OrganizationUuid string `json:"organizationUuid"`
+	Scope            string `json:"scope"`
+	Cidr             string `json:"cidr"`
+	Description      string `json:"description"`
+}
+
+func MountIpAllowlistHandler(r *mux.Router, ctxt ServerContext) {
+	h := ipAllowlistHandler{}
+
+	root := r.PathPrefix("/ip-allowlist-entries").Subrouter()
+	root.Methods("POST").Handler(HandlerFunc(ctxt, h.Create)).
+		Name("ip-allowlist-entry-create")
+
+	// Item
+	item := root.PathPrefix("/{uuid}").Subrouter()
+	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
+		Name("ip-allowlist-entry-show")
+	item.Methods("DELETE").Handler(HandlerFunc(ctxt, h.Delete)).
+		Name("ip-allowlist-entry-delete")
+}
+
+func (self ipAllowlistHandler) paramsFrom(src io.Reader) (*ipAllowlistEntryParams, error) {
+	params := struct {
+		Subject ipAllowlistEntryParams `json:"subject"`
+	}{}
+
+	if err := json.NewDecoder(src).Decode(&params); err != nil {
+		return nil, err
+	}
+
+	return &params.Subject, nil
+}
+
+func (self ipAllowlistHandler) Show(ctxt RequestContext) error {
+
+	entry, err := stores.NewDbIpAllowlistStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(entry); !allowed {
+		return err
+	}
+
+	writeAsJson(ctxt, entry)
+
+	return nil
+}
+
+func (self ipAllowlistHandler) Create(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	params, err := self.paramsFrom(ctxt.R().Body)
+	if err != nil {
+		return err
+	}
+
+	entry := &domain.IpAllowlistEntry{
+		OrganizationUuid: params.OrganizationUuid,
+		Scope:            params.Scope,
+		Cidr:             params.Cidr,
+		Description:      params.Description,
+	}
+
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanCreate(entry); !allowed {
+		return err
+	}
+
+	store := stores.NewDbIpAllowlistStore(ctxt.Tx())
+	if _, err := store.Create(entry); err != nil {
+		return err
+	}
+
+	entry, err = store.FindByUuid(entry.Uuid)
+	if err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.IpAllowlistEntryCreated(entry), nil)
+	ctxt.W().Header().Set("Location", urlForSubject(ctxt.R(), entry))
+	ctxt.W().WriteHeader(http.StatusCreated)
+	writeAsJson(ctxt, entry)
+
+	return nil
+}
+
+func (self ipAllowlistHandler) Delete(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	store := stores.NewDbIpAllowlistStore(ctxt.Tx())
+	entry, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanArchive(entry); !allowed {
+		return err
+	}
+
+	if err := store.DeleteByUuid(entry.Uuid); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.IpAllowlistEntryDeleted(entry), nil)
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (self ipAllowlistHandler) IndexForOrganization(ctxt RequestContext) error {
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(organization); !allowed {
+		return err
+	}
+
+	entries, err := stores.NewDbIpAllowlistStore(ctxt.Tx()).FindAllByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		return err
+	}
+
+	result := []interface{}{}
+	for _, entry := range entries {
+		if allowed, _ := ctxt.Auth().CanRead(entry); allowed {
+			result = append(result, entry)
+		}
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
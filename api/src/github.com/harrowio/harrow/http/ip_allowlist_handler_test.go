@@ -0,0 +1,75 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+func Test_IpAllowlistHandler_Routing(t *testing.T) {
+	r := mux.NewRouter()
+	MountIpAllowlistHandler(r, nil)
+
+	spec := routingSpec{
+		{"POST", "/ip-allowlist-entries", "ip-allowlist-entry-create"},
+		{"GET", "/ip-allowlist-entries/:uuid", "ip-allowlist-entry-show"},
+		{"DELETE", "/ip-allowlist-entries/:uuid", "ip-allowlist-entry-delete"},
+	}
+
+	spec.run(r, t)
+}
+
+func Test_IpAllowlistHandler_Create_createsEntryForOrganization(t *testing.T) {
+	h := NewHandlerTest(MountIpAllowlistHandler, t)
+	defer h.Cleanup()
+
+	result := struct {
+		Subject struct {
+			Uuid string
+		}
+	}{}
+	h.ResultTo(&result)
+	h.LoginAs("default")
+	h.Do("POST", h.Url("/ip-allowlist-entries"), &halWrapper{
+		Subject: &ipAllowlistEntryParams{
+			OrganizationUuid: h.World().Organization("default").Uuid,
+			Scope:            domain.IpAllowlistScopeApi,
+			Cidr:             "127.0.0.1",
+			Description:      "Office",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	entry, err := stores.NewDbIpAllowlistStore(h.Tx()).FindByUuid(result.Subject.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := entry.Cidr, "127.0.0.1/32"; got != want {
+		t.Errorf("entry.Cidr = %q; want %q", got, want)
+	}
+}
+
+func Test_IpAllowlistHandler_Create_requiresOrganizationOwner(t *testing.T) {
+	h := NewHandlerTest(MountIpAllowlistHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("other")
+	h.Do("POST", h.Url("/ip-allowlist-entries"), &halWrapper{
+		Subject: &ipAllowlistEntryParams{
+			OrganizationUuid: h.World().Organization("default").Uuid,
+			Scope:            domain.IpAllowlistScopeApi,
+			Cidr:             "10.0.0.0/8",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
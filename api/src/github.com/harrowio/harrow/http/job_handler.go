@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -60,6 +61,8 @@ func MountJobHandler(r *mux.Router, ctxt ServerContext) {
 	related := root.PathPrefix("/{uuid}/").Subrouter()
 	related.Methods("GET").Path("/operations").Handler(HandlerFunc(ctxt, jh.Operations)).
 		Name("job-operations")
+	related.Methods("GET").Path("/flaky-tests").Handler(HandlerFunc(ctxt, jh.FlakyTests)).
+		Name("job-flaky-tests")
 	related.Methods("GET").Path("/scheduled-executions").Handler(HandlerFunc(ctxt, jh.ScheduledExecutions)).
 		Name("job-scheduled-executions")
 	related.Methods("GET").Path("/subscriptions").Handler(HandlerFunc(ctxt, jh.Subscriptions)).
@@ -234,6 +237,7 @@ func (self jobHandler) Update(ctxt RequestContext) (err error) {
 		return err
 	}
 
+	previous := *job
 	copyJobParams(params, job)
 
 	if allowed, err := ctxt.Auth().CanUpdate(job); !allowed {
@@ -244,7 +248,7 @@ func (self jobHandler) Update(ctxt RequestContext) (err error) {
 		return err
 	}
 
-	ctxt.EnqueueActivity(activities.JobEdited(job), nil)
+	ctxt.EnqueueActivity(activities.JobEdited(job).SetPrevious(&previous), nil)
 
 	writeAsJson(ctxt, job)
 
@@ -320,6 +324,38 @@ func (self jobHandler) Operations(ctxt RequestContext) (err error) {
 	return err
 }
 
+// FlakyTests lists the tests of this job that changed between passing
+// and failing across the most recent operations.  The number of
+// operations to look at can be set with the "runs" query parameter.
+func (self jobHandler) FlakyTests(ctxt RequestContext) (err error) {
+
+	runs := 20
+	if param := ctxt.R().URL.Query().Get("runs"); param != "" {
+		runs, err = strconv.Atoi(param)
+		if err != nil || runs <= 0 || runs > 100 {
+			return NewMalformedParameters("runs", fmt.Errorf("must be between 1 and 100"))
+		}
+	}
+
+	job, err := stores.NewDbJobStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(job); !allowed {
+		return err
+	}
+
+	operations, err := stores.NewDbOperationStore(ctxt.Tx()).FindRecentByJobUuid(runs, job.Uuid)
+	if err != nil {
+		return err
+	}
+
+	writeAsJson(ctxt, domain.FindFlakyTests(job.Uuid, operations))
+
+	return nil
+}
+
 func (self jobHandler) ScheduledExecutions(ctxt RequestContext) (err error) {
 
 	uuid := ctxt.PathParameter("uuid")
@@ -369,11 +405,29 @@ func (self jobHandler) ScheduledExecutions(ctxt RequestContext) (err error) {
 }
 
 type subscribeParams struct {
-	Watch  bool            `json:"watch"`
-	Events map[string]bool `json:"events"`
+	Watch    bool            `json:"watch"`
+	Events   map[string]bool `json:"events"`
+	Delivery string          `json:"delivery"`
 }
 
-func (params *subscribeParams) handle(user *domain.User, watchable domain.Watchable, subscriptions domain.SubscriptionStore) (err error) {
+func (params *subscribeParams) handle(user *domain.User, watchable domain.Watchable, subscriptions domain.SubscriptionStore) error {
+
+	// A request only changing the delivery mode keeps the user's
+	// subscriptions as they are.
+	if params.Delivery == "" || params.Watch || params.Events != nil {
+		if err := params.handleEvents(user, watchable, subscriptions); err != nil {
+			return err
+		}
+	}
+
+	if params.Delivery != "" {
+		return user.DeliverSubscriptionsAs(watchable, params.Delivery, subscriptions)
+	}
+
+	return nil
+}
+
+func (params *subscribeParams) handleEvents(user *domain.User, watchable domain.Watchable, subscriptions domain.SubscriptionStore) (err error) {
 
 	if params.Watch {
 		return user.Watch(watchable, subscriptions)
@@ -476,6 +530,10 @@ func (self jobHandler) Watch(ctxt RequestContext) error {
 		return err
 	}
 
+	if allowed, err := ctxt.Auth().CanRead(job); !allowed {
+		return err
+	}
+
 	notifier := &domain.EmailNotifier{
 		Uuid:      uuidhelper.MustNewV4(),
 		Recipient: user.Email,
@@ -520,6 +578,10 @@ func (self jobHandler) Watch(ctxt RequestContext) error {
 				return err
 			}
 
+			if allowed, err := ctxt.Auth().CanCreate(rule); !allowed {
+				return err
+			}
+
 			_, err := rules.Create(rule)
 			if err != nil {
 				return err
@@ -528,6 +590,9 @@ func (self jobHandler) Watch(ctxt RequestContext) error {
 		}
 	} else {
 		if rule != nil {
+			if allowed, err := ctxt.Auth().CanArchive(rule); !allowed {
+				return err
+			}
 			if err := rules.ArchiveByUuid(rule.Uuid); err != nil {
 				return err
 			}
@@ -550,6 +615,10 @@ func (self jobHandler) WatchStatus(ctxt RequestContext) error {
 		return err
 	}
 
+	if allowed, err := ctxt.Auth().CanRead(job); !allowed {
+		return err
+	}
+
 	result := []interface{}{}
 	notifiers := stores.NewDbEmailNotifierStore(ctxt.Tx())
 	existingNotifier, err := notifiers.FindByRecipient(user.Email)
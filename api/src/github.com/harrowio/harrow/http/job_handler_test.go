@@ -25,6 +25,7 @@ func Test_JobHandler_Routing(t *testing.T) {
 		{"GET", "/jobs/:uuid/watch", "job-watch-status"},
 		{"PUT", "/jobs/:uuid/watch", "job-watch"},
 		{"GET", "/jobs/:uuid/operations", "job-operations"},
+		{"GET", "/jobs/:uuid/flaky-tests", "job-flaky-tests"},
 		{"GET", "/jobs/:uuid/scheduled-executions", "job-scheduled-executions"},
 		{"GET", "/jobs/:uuid", "job-show"},
 		{"DELETE", "/jobs/:uuid", "job-archive"},
@@ -76,6 +77,50 @@ func Test_JobHandler_Subscribe_watch_true_SubscribesToAllEvents(t *testing.T) {
 	}
 }
 
+func Test_JobHandler_Subscribe_delivery_changesDeliveryOfSubscriptions(t *testing.T) {
+	ts, ctxt := setupHandlerTestServer(MountJobHandler, t)
+	tx := ctxt.Tx()
+	defer tx.Rollback()
+	defer ts.Close()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	subscriptions := stores.NewDbSubscriptionStore(tx)
+	user := world.User("default")
+	ctxt.u = user
+	job := world.Job("default")
+
+	s := setupTestLoginSession(t, tx, user)
+	url := ts.URL + "/jobs/" + job.Uuid + "/subscriptions"
+	req, err := newAuthenticatedRequest(s.Uuid, "PUT", url, `{"watch": true, "delivery": "daily"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := new(http.Client).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Fatal(string(body))
+	}
+
+	for _, event := range job.WatchableEvents() {
+		subscription, err := subscriptions.Find(job.Uuid, event, user.Uuid)
+		if err != nil {
+			t.Fatalf("subscription for %s: %s", event, err)
+		}
+
+		if got, want := subscription.Delivery, domain.SubscriptionDeliveryDaily; got != want {
+			t.Errorf("subscription.Delivery for %s = %q; want %q", event, got, want)
+		}
+	}
+}
+
 func Test_JobHandler_Subscribe_watch_false_UnsubscribesFromAllEvents(t *testing.T) {
 	ts, ctxt := setupHandlerTestServer(MountJobHandler, t)
 	tx := ctxt.Tx()
@@ -350,3 +395,23 @@ func Test_JobHandler_Create_emits_JobAddedActivity(t *testing.T) {
 
 	t.Fatalf("Activity %q not found", "job.added")
 }
+
+func Test_JobHandler_Watch_respectsApiTokenCapabilities(t *testing.T) {
+	h := newScopedApiTokenTest(MountJobHandler, "default", []string{"read-job"}, t)
+	defer h.Cleanup()
+
+	job := h.World().Job("default")
+	if status, _ := h.Do("PUT", "/jobs/"+job.Uuid+"/watch", map[string]bool{"watch": true}); status != http.StatusForbidden {
+		t.Errorf("status = %d; want %d", status, http.StatusForbidden)
+	}
+}
+
+func Test_JobHandler_WatchStatus_respectsApiTokenCapabilities(t *testing.T) {
+	h := newScopedApiTokenTest(MountJobHandler, "default", []string{"read-project"}, t)
+	defer h.Cleanup()
+
+	job := h.World().Job("default")
+	if status, _ := h.Do("GET", "/jobs/"+job.Uuid+"/watch", nil); status != http.StatusForbidden {
+		t.Errorf("status = %d; want %d", status, http.StatusForbidden)
+	}
+}
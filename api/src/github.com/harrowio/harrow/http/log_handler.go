@@ -11,12 +11,18 @@ type logHandler struct {
 	logStores []stores.LogStore
 }
 
-func MountLogHandler(r *mux.Router, ctxt ServerContext) {
+// MountLogHandler mounts the routes for reading operation logs.  It
+// returns an error if the log storage is misconfigured.
+func MountLogHandler(r *mux.Router, ctxt ServerContext) error {
 	config := ctxt.Config()
+	archivedLogs, err := stores.NewArchivedLogStore(&config)
+	if err != nil {
+		return err
+	}
 	lh := logHandler{
 		logStores: []stores.LogStore{ // the order is important
 			stores.NewRedisLogStore(ctxt.KeyValueStore()),
-			stores.NewDiskLogStore(config.FilesystemConfig().OpLogDir),
+			archivedLogs,
 		},
 	}
 
@@ -27,6 +33,8 @@ func MountLogHandler(r *mux.Router, ctxt ServerContext) {
 	item := root.PathPrefix("/{uuid}").Subrouter()
 	item.Methods("GET").Handler(HandlerFunc(ctxt, lh.Show)).
 		Name("log-show")
+
+	return nil
 }
 
 func (self logHandler) Show(ctxt RequestContext) error {
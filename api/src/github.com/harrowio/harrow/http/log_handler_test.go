@@ -1,6 +1,7 @@
 package http
 
 import (
+	"os"
 	"testing"
 
 	"github.com/harrowio/harrow/config"
@@ -11,7 +12,9 @@ import (
 func Test_LogHandler_Routing(t *testing.T) {
 	r := mux.NewRouter()
 	ctxt := NewTestContext(nil, nil, nil, nil, config.GetConfig())
-	MountLogHandler(r, ctxt)
+	if err := MountLogHandler(r, ctxt); err != nil {
+		t.Fatal(err)
+	}
 
 	spec := routingSpec{
 		{"GET", "/logs/:uuid", "log-show"},
@@ -19,3 +22,14 @@ func Test_LogHandler_Routing(t *testing.T) {
 
 	spec.run(r, t)
 }
+
+func Test_LogHandler_Mount_failsOnUnknownLogStorage(t *testing.T) {
+	os.Setenv("HAR_LOG_STORAGE", "tape")
+	defer os.Unsetenv("HAR_LOG_STORAGE")
+
+	r := mux.NewRouter()
+	ctxt := NewTestContext(nil, nil, nil, nil, config.GetConfig())
+	if err := MountLogHandler(r, ctxt); err == nil {
+		t.Fatal("Expected an error for an unknown log storage backend")
+	}
+}
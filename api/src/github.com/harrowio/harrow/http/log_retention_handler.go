@@ -0,0 +1,219 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// logRetentionHandler manages the log retention policies of
+// organizations and projects.  Its routes are mounted as relationships
+// of organizations and projects.
+//
+// Reading a policy requires read access to its organization or
+// project; changing it requires the permission to update the
+// organization or project.
+type logRetentionHandler struct {
+}
+
+func (self logRetentionHandler) ShowForOrganization(ctxt RequestContext) error {
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(organization); !allowed {
+		return err
+	}
+
+	policy, err := stores.NewDbLogRetentionPolicyStore(ctxt.Tx()).FindByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		return err
+	}
+
+	writeAsJson(ctxt, policy)
+
+	return nil
+}
+
+func (self logRetentionHandler) UpdateForOrganization(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(organization); !allowed {
+		return err
+	}
+
+	store := stores.NewDbLogRetentionPolicyStore(ctxt.Tx())
+	existing, err := store.FindByOrganizationUuid(organization.Uuid)
+	if err != nil && !domain.IsNotFound(err) {
+		return err
+	}
+
+	return self.save(ctxt, store, existing, organization.Uuid, nil)
+}
+
+func (self logRetentionHandler) ArchiveForOrganization(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(organization); !allowed {
+		return err
+	}
+
+	store := stores.NewDbLogRetentionPolicyStore(ctxt.Tx())
+	policy, err := store.FindByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		return err
+	}
+
+	return self.archive(ctxt, store, policy)
+}
+
+// ShowForProject returns the policy that applies to the project, which
+// is the organization's policy unless the project has its own.
+func (self logRetentionHandler) ShowForProject(ctxt RequestContext) error {
+
+	project, err := stores.NewDbProjectStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(project); !allowed {
+		return err
+	}
+
+	policy, err := stores.NewDbLogRetentionPolicyStore(ctxt.Tx()).FindEffectiveByProject(project)
+	if err != nil {
+		return err
+	}
+
+	writeAsJson(ctxt, policy)
+
+	return nil
+}
+
+func (self logRetentionHandler) UpdateForProject(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	project, err := stores.NewDbProjectStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(project); !allowed {
+		return err
+	}
+
+	store := stores.NewDbLogRetentionPolicyStore(ctxt.Tx())
+	existing, err := store.FindByProjectUuid(project.Uuid)
+	if err != nil && !domain.IsNotFound(err) {
+		return err
+	}
+
+	return self.save(ctxt, store, existing, project.OrganizationUuid, &project.Uuid)
+}
+
+func (self logRetentionHandler) ArchiveForProject(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	project, err := stores.NewDbProjectStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(project); !allowed {
+		return err
+	}
+
+	store := stores.NewDbLogRetentionPolicyStore(ctxt.Tx())
+	policy, err := store.FindByProjectUuid(project.Uuid)
+	if err != nil {
+		return err
+	}
+
+	return self.archive(ctxt, store, policy)
+}
+
+// save creates or updates the policy for the given scope from the
+// request body.
+func (self logRetentionHandler) save(ctxt RequestContext, store *stores.DbLogRetentionPolicyStore, existing *domain.LogRetentionPolicy, organizationUuid string, projectUuid *string) error {
+
+	params := new(domain.LogRetentionPolicy)
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: params}); err != nil {
+		return err
+	}
+
+	var previous *domain.LogRetentionPolicy
+	policy := existing
+	if policy == nil {
+		policy = &domain.LogRetentionPolicy{}
+	} else {
+		copied := *existing
+		previous = &copied
+	}
+	policy.OrganizationUuid = organizationUuid
+	policy.ProjectUuid = projectUuid
+	policy.KeepFullDays = params.KeepFullDays
+	policy.KeepTailKB = params.KeepTailKB
+	policy.DeleteAfterDays = params.DeleteAfterDays
+
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	if existing == nil {
+		if _, err := store.Create(policy); err != nil {
+			return err
+		}
+	} else {
+		if err := store.Update(policy); err != nil {
+			return err
+		}
+	}
+
+	activity := activities.LogRetentionPolicyChanged(policy)
+	if previous != nil {
+		activity.SetPrevious(previous)
+	}
+	ctxt.EnqueueActivity(activity, nil)
+	writeAsJson(ctxt, policy)
+
+	return nil
+}
+
+func (self logRetentionHandler) archive(ctxt RequestContext, store *stores.DbLogRetentionPolicyStore, policy *domain.LogRetentionPolicy) error {
+
+	if err := store.ArchiveByUuid(policy.Uuid); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.LogRetentionPolicyRemoved(policy), nil)
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+func Test_LogRetentionHandler_UpdateForProject_createsPolicyAndEmitsActivity(t *testing.T) {
+	h := NewHandlerTest(MountProjectHandler, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("private")
+	h.LoginAs("project-owner")
+	h.Do("PUT", h.Url("/projects/"+project.Uuid+"/log-retention"), &halWrapper{
+		Subject: &domain.LogRetentionPolicy{
+			KeepFullDays:    14,
+			KeepTailKB:      256,
+			DeleteAfterDays: 90,
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	policy, err := stores.NewDbLogRetentionPolicyStore(h.Tx()).FindByProjectUuid(project.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := policy.KeepTailKB, 256; got != want {
+		t.Errorf(`policy.KeepTailKB = %d; want %d`, got, want)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "log-retention-policy.changed" {
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "log-retention-policy.changed")
+}
+
+func Test_LogRetentionHandler_ShowForProject_fallsBackToOrganizationPolicy(t *testing.T) {
+	h := NewHandlerTest(MountProjectHandler, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("public")
+	organizationPolicy := &domain.LogRetentionPolicy{
+		OrganizationUuid: project.OrganizationUuid,
+		KeepFullDays:     30,
+	}
+	if _, err := stores.NewDbLogRetentionPolicyStore(h.Tx()).Create(organizationPolicy); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("default")
+	h.Do("GET", h.Url("/projects/"+project.Uuid+"/log-retention"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func Test_LogRetentionHandler_UpdateForOrganization_requiresUpdatePermission(t *testing.T) {
+	h := NewHandlerTest(MountOrganizationHandler, t)
+	defer h.Cleanup()
+
+	organization := h.World().Organization("default")
+	h.LoginAs("non-member")
+	h.Do("PUT", h.Url("/organizations/"+organization.Uuid+"/log-retention"), &halWrapper{
+		Subject: &domain.LogRetentionPolicy{KeepFullDays: 1},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Errorf("h.Response().StatusCode = %d; want %d", got, want)
+	}
+}
@@ -9,27 +9,60 @@ import (
 )
 
 func login(ctxt RequestContext, user *domain.User) (err error) {
+	enforcedProviders, err := stores.NewDbSsoProviderStore(ctxt.Tx()).FindAllEnforcedByUserUuid(user.Uuid)
+	if err != nil {
+		return err
+	}
+
+	if len(enforcedProviders) > 0 {
+		return ErrSsoRequired
+	}
+
+	session, userBlocks, err := startSession(ctxt, user)
+	if err != nil {
+		return err
+	}
+
+	ctxt.W().Header().Add("Location", urlForSubject(ctxt.R(), session))
+	ctxt.W().WriteHeader(http.StatusCreated)
+
+	result := struct {
+		*domain.Session
+		Blocks []*domain.UserBlock `json:"blocks"`
+	}{
+		Session: session,
+		Blocks:  userBlocks,
+	}
+	writeAsJson(ctxt, &result)
+
+	return err
+}
+
+// startSession creates a new session for user without checking how
+// the user authenticated.
+func startSession(ctxt RequestContext, user *domain.User) (*domain.Session, []*domain.UserBlock, error) {
 	userBlockStore := stores.NewDbUserBlockStore(ctxt.Tx())
 	sessionStore := stores.NewDbSessionStore(ctxt.Tx())
 
 	userBlocks, err := userBlockStore.FindAllByUserUuid(user.Uuid)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
+	c := ctxt.Config()
 	session := &domain.Session{
 		UserUuid:      user.Uuid,
 		UserAgent:     ctxt.R().UserAgent(),
-		ClientAddress: ctxt.R().Header.Get("X-Forwarded-For"),
+		ClientAddress: requestClientAddress(ctxt.R(), c.HttpConfig().TrustedProxies),
 	}
 
 	if allowed, err := ctxt.Auth().CanCreate(session); !allowed {
-		return err
+		return nil, nil, err
 	}
 
 	invalidatedSessions, err := sessionStore.InvalidateAllButMostRecentSessionForUser(user.Uuid)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	ctxt.Log().Debug().Msgf("invalidated %d sessions for user %q\n", invalidatedSessions, user.Uuid)
 	if invalidatedSessions > 0 {
@@ -38,27 +71,15 @@ func login(ctxt RequestContext, user *domain.User) (err error) {
 
 	sessionUuid, err := sessionStore.Create(session)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	session, err = sessionStore.FindByUuid(sessionUuid)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	ctxt.EnqueueActivity(activities.UserLoggedIn(user), &user.Uuid)
 
-	ctxt.W().Header().Add("Location", urlForSubject(ctxt.R(), session))
-	ctxt.W().WriteHeader(http.StatusCreated)
-
-	result := struct {
-		*domain.Session
-		Blocks []*domain.UserBlock `json:"blocks"`
-	}{
-		Session: session,
-		Blocks:  userBlocks,
-	}
-	writeAsJson(ctxt, &result)
-
-	return err
+	return session, userBlocks, nil
 }
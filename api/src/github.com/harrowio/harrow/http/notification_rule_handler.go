@@ -43,6 +43,13 @@ func MountNotificationRuleHandler(r *mux.Router, ctxt ServerContext) {
 	root.Methods("PUT").Handler(HandlerFunc(ctxt, h.Update)).
 		Name("notification-rules-update")
 
+	// Relationships
+	related := root.PathPrefix("/{uuid}/").Subrouter()
+	related.Methods("GET").Path("/deliveries").Handler(HandlerFunc(ctxt, h.Deliveries)).
+		Name("notification-rules-deliveries")
+	related.Methods("GET").Path("/deliveries/{deliveryUuid}").Handler(HandlerFunc(ctxt, h.Delivery)).
+		Name("notification-rules-delivery")
+
 	// Item
 	item := root.PathPrefix("/{uuid}").Subrouter()
 	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
@@ -52,6 +59,10 @@ func MountNotificationRuleHandler(r *mux.Router, ctxt ServerContext) {
 
 }
 
+// notificationRuleDeliveriesLimit is the number of deliveries shown
+// for a notification rule.
+const notificationRuleDeliveriesLimit = 100
+
 func (h *NotificationRuleHandler) Create(ctxt RequestContext) error {
 
 	if ctxt.User() == nil {
@@ -114,10 +125,14 @@ func (h *NotificationRuleHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
+	previous := *h.subject
+
 	h.subject.NotifierUuid = newVersion.NotifierUuid
 	h.subject.NotifierType = newVersion.NotifierType
 	h.subject.MatchActivity = newVersion.MatchActivity
 	h.subject.JobUuid = newVersion.JobUuid
+	h.subject.Condition = newVersion.Condition
+	h.subject.NotifyEvery = newVersion.NotifyEvery
 	h.subject.CreatorUuid = ctxt.User().Uuid
 
 	if err := h.subject.Validate(); err != nil {
@@ -128,7 +143,7 @@ func (h *NotificationRuleHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
-	ctxt.EnqueueActivity(activities.NotificationRuleEdited(h.subject), nil)
+	ctxt.EnqueueActivity(activities.NotificationRuleEdited(h.subject).SetPrevious(&previous), nil)
 	writeAsJson(ctxt, h.subject)
 
 	return nil
@@ -179,3 +194,66 @@ func (h *NotificationRuleHandler) Archive(ctxt RequestContext) error {
 
 	return nil
 }
+
+func (h *NotificationRuleHandler) Deliveries(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(h.subject); !allowed {
+		return err
+	}
+
+	deliveries, err := stores.NewDbNotificationDeliveryStore(ctxt.Tx()).FindAllByNotificationRuleUuid(h.subject.Uuid, notificationRuleDeliveriesLimit)
+	if err != nil {
+		return err
+	}
+
+	result := []interface{}{}
+	for _, delivery := range deliveries {
+		result = append(result, delivery)
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
+
+func (h *NotificationRuleHandler) Delivery(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(h.subject); !allowed {
+		return err
+	}
+
+	delivery, err := stores.NewDbNotificationDeliveryStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("deliveryUuid"))
+	if err != nil {
+		return err
+	}
+
+	if delivery.NotificationRuleUuid != h.subject.Uuid {
+		return new(domain.NotFoundError)
+	}
+
+	writeAsJson(ctxt, delivery)
+
+	return nil
+}
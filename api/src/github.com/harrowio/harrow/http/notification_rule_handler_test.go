@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -18,6 +19,8 @@ func Test_NotificationRuleHandler_Routing(t *testing.T) {
 		{"PUT", "/notification-rules", "notification-rules-update"},
 		{"GET", "/notification-rules/:uuid", "notification-rules-show"},
 		{"DELETE", "/notification-rules/:uuid", "notification-rules-archive"},
+		{"GET", "/notification-rules/:uuid/deliveries", "notification-rules-deliveries"},
+		{"GET", "/notification-rules/:uuid/deliveries/:delivery", "notification-rules-delivery"},
 	}
 
 	spec.run(r, t)
@@ -115,3 +118,32 @@ func Test_NotificationRuleHandler_Delete_emitsNotificationRuleDeletedActivity(t
 
 	t.Fatalf("Activity %q not found", "notification-rules.deleted")
 }
+
+func Test_NotificationRuleHandler_Deliveries_listsDeliveriesOfRule(t *testing.T) {
+	h := NewHandlerTest(MountNotificationRuleHandler, t)
+	defer h.Cleanup()
+
+	rule := createDefaultNotificationRule(t, h)
+	delivery := domain.NewNotificationDelivery(rule, &domain.Activity{Id: 1})
+	delivery.RecordAttempt(errors.New("connection refused"))
+	if _, err := stores.NewDbNotificationDeliveryStore(h.Tx()).Create(delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	result := struct {
+		Collection []struct {
+			Subject *domain.NotificationDelivery
+		}
+	}{}
+	h.ResultTo(&result)
+	h.LoginAs("default")
+	h.Do("GET", h.Url("/notification-rules/"+rule.Uuid+"/deliveries"), nil)
+
+	if got, want := len(result.Collection), 1; got != want {
+		t.Fatalf("len(result.Collection) = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if got, want := result.Collection[0].Subject.LastError, "connection refused"; got != want {
+		t.Errorf("LastError = %q; want %q", got, want)
+	}
+}
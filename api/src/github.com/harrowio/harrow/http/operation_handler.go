@@ -1,6 +1,8 @@
 package http
 
 import (
+	"encoding/json"
+
 	"github.com/gorilla/mux"
 
 	"github.com/harrowio/harrow/activities"
@@ -14,6 +16,13 @@ func MountOperationHandler(r *mux.Router, ctxt ServerContext) {
 	// Collection
 	root := r.PathPrefix("/operations").Subrouter()
 
+	// Relationships
+	related := root.PathPrefix("/{uuid}/").Subrouter()
+	related.Methods("GET").Path("/test-results").Handler(HandlerFunc(ctxt, oh.TestResults)).
+		Name("operation-test-results")
+	related.Methods("POST").Path("/notification-preview").Handler(HandlerFunc(ctxt, oh.NotificationPreview)).
+		Name("operation-notification-preview")
+
 	// Item
 	item := root.PathPrefix("/{uuid}").Subrouter()
 	item.Methods("GET").Handler(HandlerFunc(ctxt, oh.Show)).
@@ -64,6 +73,84 @@ func (self operationHandler) Show(ctxt RequestContext) (err error) {
 	return err
 }
 
+func (self operationHandler) TestResults(ctxt RequestContext) error {
+
+	store := stores.NewDbOperationStore(ctxt.Tx())
+
+	operation, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(operation); !allowed {
+		return err
+	}
+
+	writeAsJson(ctxt, domain.NewTestReport(operation))
+
+	return nil
+}
+
+// NotificationPreview renders notification templates against a past
+// operation, so that they can be tried out without sending
+// notifications.
+func (self operationHandler) NotificationPreview(ctxt RequestContext) error {
+
+	operation, err := stores.NewDbOperationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(operation); !allowed {
+		return err
+	}
+
+	if operation.JobUuid == nil {
+		return domain.NewValidationError("operation", "no_job")
+	}
+
+	job, err := stores.NewDbJobStore(ctxt.Tx()).FindByUuid(*operation.JobUuid)
+	if err != nil {
+		return err
+	}
+
+	project, err := stores.NewDbProjectStore(ctxt.Tx()).FindByJobUuid(job.Uuid)
+	if err != nil {
+		return err
+	}
+
+	template := &domain.NotificationTemplate{}
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: template}); err != nil {
+		return err
+	}
+
+	if err := template.Validate(); err != nil {
+		return err
+	}
+
+	c := ctxt.Config()
+	logs, err := stores.NewArchivedLogStore(&c)
+	if err != nil {
+		return err
+	}
+
+	templateContext := domain.NewNotificationTemplateContext(project, job, operation)
+	users := stores.NewDbUserStore(ctxt.Tx(), &c)
+	if err := templateContext.LoadDetails(stores.NewDbEnvironmentStore(ctxt.Tx()), users, logs); err != nil {
+		return err
+	}
+
+	rendered, err := template.Render(templateContext)
+	if err != nil {
+		// Templates that parse can still fail to execute, e.g. by
+		// referring to a field that doesn't exist.
+		return domain.NewValidationError("template", err.Error())
+	}
+
+	ctxt.W().Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(ctxt.W()).Encode(rendered)
+}
+
 func (self operationHandler) Cancel(ctxt RequestContext) error {
 
 	if ctxt.User() == nil {
@@ -1,9 +1,12 @@
 package http
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/test_helpers"
 )
 
 func Test_OperationHandler_Routing(t *testing.T) {
@@ -12,7 +15,79 @@ func Test_OperationHandler_Routing(t *testing.T) {
 
 	spec := routingSpec{
 		{"GET", "/operations/:uuid", "operation-show"},
+		{"GET", "/operations/:uuid/test-results", "operation-test-results"},
+		{"POST", "/operations/:uuid/notification-preview", "operation-notification-preview"},
 	}
 
 	spec.run(r, t)
 }
+
+func Test_OperationHandler_NotificationPreview_rendersTemplatesAgainstOperation(t *testing.T) {
+	h := NewHandlerTest(MountOperationHandler, t)
+	defer h.Cleanup()
+
+	world := h.World()
+	job := world.Job("default")
+	gitLogs := domain.NewGitLogs()
+	gitLogs.Repositories[world.Repository("default").Uuid] = []*domain.GitLogEntry{
+		{Commit: "e242ed3bffccdf271b7fbaf34ed72d089537b42f", Subject: "Fix the build"},
+	}
+	parameters := domain.NewOperationParameters()
+	parameters.UserUuid = world.User("default").Uuid
+	parameters.AddSecret("DEPLOY_KEY", "very-secret")
+	operation := test_helpers.MustCreateOperation(t, h.Tx(), &domain.Operation{
+		Type:                   domain.OperationTypeJobScheduled,
+		JobUuid:                &job.Uuid,
+		WorkspaceBaseImageUuid: world.WorkspaceBaseImage("default").Uuid,
+		GitLogs:                gitLogs,
+		Parameters:             parameters,
+	})
+
+	rendered := &domain.RenderedNotification{}
+	h.LoginAs("default")
+	h.ResultTo(rendered)
+	h.Subject(operation)
+	h.Do("POST", h.UrlFor("notification-preview"), &halWrapper{
+		Subject: &domain.NotificationTemplate{
+			Subject: "{{.Job.Name}} by {{.TriggeredBy.Name}}",
+			Body:    "{{range .Commits}}{{shortHash .Commit}} {{.Subject}}{{end}}{{range .Operation.Parameters.Secrets}}{{.Value}}{{end}}",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d", got, want)
+	}
+
+	if got, want := rendered.Subject, job.Name+" by "+world.User("default").Name; got != want {
+		t.Errorf(`rendered.Subject = %q; want %q`, got, want)
+	}
+
+	if got, want := rendered.Body, "e242ed3 Fix the build"; got != want {
+		t.Errorf(`rendered.Body = %q; want %q`, got, want)
+	}
+}
+
+func Test_OperationHandler_NotificationPreview_rejectsMalformedTemplates(t *testing.T) {
+	h := NewHandlerTest(MountOperationHandler, t)
+	defer h.Cleanup()
+
+	world := h.World()
+	job := world.Job("default")
+	operation := test_helpers.MustCreateOperation(t, h.Tx(), &domain.Operation{
+		Type:                   domain.OperationTypeJobScheduled,
+		JobUuid:                &job.Uuid,
+		WorkspaceBaseImageUuid: world.WorkspaceBaseImage("default").Uuid,
+	})
+
+	h.LoginAs("default")
+	h.Subject(operation)
+	h.Do("POST", h.UrlFor("notification-preview"), &halWrapper{
+		Subject: &domain.NotificationTemplate{
+			Body: "{{.Job.Name",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Errorf("h.Response().StatusCode = %d; want %d", got, want)
+	}
+}
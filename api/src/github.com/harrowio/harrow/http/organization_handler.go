@@ -24,6 +24,7 @@ type OrgParams struct {
 	Public      bool   `json:"public"`
 	GithubLogin string `json:"github_login"`
 	PlanUuid    string `json:"planUuid"`
+	RequireTotp *bool  `json:"requireTotp"`
 }
 
 func ReadOrgParams(r io.Reader) (*OrgParamsWrapper, error) {
@@ -40,6 +41,9 @@ func copyOrgParams(params *OrgParams, model *domain.Organization) {
 	model.Name = params.Name
 	model.Public = params.Public
 	model.GithubLogin = params.GithubLogin
+	if params.RequireTotp != nil {
+		model.RequireTotp = *params.RequireTotp
+	}
 }
 
 type orgHandler struct {
@@ -68,6 +72,38 @@ func MountOrganizationHandler(r *mux.Router, ctxt ServerContext) {
 	related.Methods("GET").Path("/project-cards").Handler(HandlerFunc(ctxt, h.ProjectCards)).
 		Name("organization-project-cards")
 
+	lr := logRetentionHandler{}
+	related.Methods("GET").Path("/log-retention").Handler(HandlerFunc(ctxt, lr.ShowForOrganization)).
+		Name("organization-log-retention-show")
+	related.Methods("PUT").Path("/log-retention").Handler(HandlerFunc(ctxt, lr.UpdateForOrganization)).
+		Name("organization-log-retention-update")
+	related.Methods("DELETE").Path("/log-retention").Handler(HandlerFunc(ctxt, lr.ArchiveForOrganization)).
+		Name("organization-log-retention-archive")
+
+	sp := ssoProviderHandler{}
+	related.Methods("GET").Path("/sso").Handler(HandlerFunc(ctxt, sp.ShowForOrganization)).
+		Name("organization-sso-show")
+	related.Methods("PUT").Path("/sso").Handler(HandlerFunc(ctxt, sp.UpdateForOrganization)).
+		Name("organization-sso-update")
+	related.Methods("DELETE").Path("/sso").Handler(HandlerFunc(ctxt, sp.ArchiveForOrganization)).
+		Name("organization-sso-archive")
+
+	cr := customRoleHandler{}
+	related.Methods("GET").Path("/custom-roles").Handler(HandlerFunc(ctxt, cr.IndexForOrganization)).
+		Name("organization-custom-roles")
+
+	ia := ipAllowlistHandler{}
+	related.Methods("GET").Path("/ip-allowlist-entries").Handler(HandlerFunc(ctxt, ia.IndexForOrganization)).
+		Name("organization-ip-allowlist-entries")
+
+	sh := secretHandler{}
+	related.Methods("GET").Path("/secrets").Handler(HandlerFunc(ctxt, sh.IndexForOrganization)).
+		Name("organization-secrets")
+
+	al := auditLogHandler{}
+	related.Methods("GET").Path("/audit-log").Handler(HandlerFunc(ctxt, al.IndexForOrganization)).
+		Name("organization-audit-log")
+
 	// Item
 	item := root.Path("/{uuid}").Subrouter()
 	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
@@ -164,8 +200,15 @@ func (self orgHandler) CreateOrUpdate(ctxt RequestContext) (err error) {
 			return err
 		}
 	}
+	requiredTotp := model.RequireTotp
 	copyOrgParams(&params.Subject, model)
 
+	// Requiring two-factor authentication would lock out the user
+	// requiring it until they enroll themselves.
+	if model.RequireTotp && !requiredTotp && !currentUser.TotpEnabled() {
+		return domain.NewValidationError("requireTotp", "totp_not_enabled")
+	}
+
 	if model.Public == false && !uuidhelper.IsValid(params.Subject.PlanUuid) {
 		return domain.NewValidationError("planUuid", "empty")
 	}
@@ -26,6 +26,16 @@ func Test_OrganizationHandler_Routing(t *testing.T) {
 		{"GET", "/organizations/:uuid/projects", "organization-projects"},
 		{"GET", "/organizations/:uuid/memberships", "organization-memberships"},
 		{"GET", "/organizations/:uuid/members", "organization-members"},
+		{"GET", "/organizations/:uuid/log-retention", "organization-log-retention-show"},
+		{"PUT", "/organizations/:uuid/log-retention", "organization-log-retention-update"},
+		{"DELETE", "/organizations/:uuid/log-retention", "organization-log-retention-archive"},
+		{"GET", "/organizations/:uuid/sso", "organization-sso-show"},
+		{"PUT", "/organizations/:uuid/sso", "organization-sso-update"},
+		{"DELETE", "/organizations/:uuid/sso", "organization-sso-archive"},
+		{"GET", "/organizations/:uuid/custom-roles", "organization-custom-roles"},
+		{"GET", "/organizations/:uuid/ip-allowlist-entries", "organization-ip-allowlist-entries"},
+		{"GET", "/organizations/:uuid/secrets", "organization-secrets"},
+		{"GET", "/organizations/:uuid/audit-log", "organization-audit-log"},
 	}
 
 	spec.run(r, t)
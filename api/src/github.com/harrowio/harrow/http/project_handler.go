@@ -88,6 +88,16 @@ func MountProjectHandler(r *mux.Router, ctxt ServerContext) {
 		Name("project-job-notifiers")
 	related.Methods("GET").Path("/slack-notifiers").Handler(HandlerFunc(ctxt, ph.SlackNotifiers)).
 		Name("project-slack-notifiers")
+	related.Methods("GET").Path("/webhook-notifiers").Handler(HandlerFunc(ctxt, ph.WebhookNotifiers)).
+		Name("project-webhook-notifiers")
+	related.Methods("GET").Path("/teams-notifiers").Handler(HandlerFunc(ctxt, ph.TeamsNotifiers)).
+		Name("project-teams-notifiers")
+	related.Methods("GET").Path("/mattermost-notifiers").Handler(HandlerFunc(ctxt, ph.MattermostNotifiers)).
+		Name("project-mattermost-notifiers")
+	related.Methods("GET").Path("/discord-notifiers").Handler(HandlerFunc(ctxt, ph.DiscordNotifiers)).
+		Name("project-discord-notifiers")
+	related.Methods("GET").Path("/blame-notifiers").Handler(HandlerFunc(ctxt, ph.BlameNotifiers)).
+		Name("project-blame-notifiers")
 	related.Methods("GET").Path("/email-notifiers").Handler(HandlerFunc(ctxt, ph.EmailNotifiers)).
 		Name("project-email-notifiers")
 	related.Methods("GET").Path("/scripts").Handler(HandlerFunc(ctxt, ph.ScriptCards)).
@@ -95,6 +105,24 @@ func MountProjectHandler(r *mux.Router, ctxt ServerContext) {
 	related.Methods("GET").Path("/notification-rules").Handler(HandlerFunc(ctxt, ph.NotificationRules)).
 		Name("project-notification-rules")
 
+	lr := logRetentionHandler{}
+	related.Methods("GET").Path("/log-retention").Handler(HandlerFunc(ctxt, lr.ShowForProject)).
+		Name("project-log-retention-show")
+	related.Methods("PUT").Path("/log-retention").Handler(HandlerFunc(ctxt, lr.UpdateForProject)).
+		Name("project-log-retention-update")
+	related.Methods("DELETE").Path("/log-retention").Handler(HandlerFunc(ctxt, lr.ArchiveForProject)).
+		Name("project-log-retention-archive")
+
+	at := apiTokenHandler{}
+	related.Methods("GET").Path("/api-tokens").Handler(HandlerFunc(ctxt, at.IndexForProject)).
+		Name("project-api-tokens")
+	related.Methods("POST").Path("/api-tokens").Handler(HandlerFunc(ctxt, at.CreateForProject)).
+		Name("project-api-token-create")
+
+	sh := secretHandler{}
+	related.Methods("GET").Path("/secrets").Handler(HandlerFunc(ctxt, sh.IndexForProject)).
+		Name("project-secrets")
+
 	root.Methods("PUT").Handler(HandlerFunc(ctxt, ph.CreateUpdate)).
 		Name("project-update")
 	root.Methods("POST").Handler(HandlerFunc(ctxt, ph.CreateUpdate)).
@@ -742,6 +770,76 @@ func (self projectHandler) SlackNotifiers(ctxt RequestContext) error {
 	return nil
 }
 
+// chatNotifiers lists the notifiers of the given type in the project
+// that the current user can read.
+func (self projectHandler) chatNotifiers(ctxt RequestContext, notifierType string) error {
+
+	projectUuid := ctxt.PathParameter("uuid")
+	notifiers := stores.NewDbChatNotifierStore(ctxt.Tx(), notifierType)
+
+	result, err := notifiers.FindByProjectUuid(projectUuid)
+	if err != nil {
+		return err
+	}
+
+	items := []interface{}{}
+	for _, notifier := range result {
+		if allowed, _ := ctxt.Auth().CanRead(notifier); allowed {
+			items = append(items, notifier)
+		}
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(items),
+		Count:      len(items),
+		Collection: items,
+	})
+
+	return nil
+}
+
+func (self projectHandler) TeamsNotifiers(ctxt RequestContext) error {
+	return self.chatNotifiers(ctxt, domain.ChatNotifierTeams)
+}
+
+func (self projectHandler) MattermostNotifiers(ctxt RequestContext) error {
+	return self.chatNotifiers(ctxt, domain.ChatNotifierMattermost)
+}
+
+func (self projectHandler) DiscordNotifiers(ctxt RequestContext) error {
+	return self.chatNotifiers(ctxt, domain.ChatNotifierDiscord)
+}
+
+func (self projectHandler) BlameNotifiers(ctxt RequestContext) error {
+	return self.chatNotifiers(ctxt, domain.BlameNotifierType)
+}
+
+func (self projectHandler) WebhookNotifiers(ctxt RequestContext) error {
+
+	projectUuid := ctxt.PathParameter("uuid")
+	webhookNotifiers := stores.NewDbWebhookNotifierStore(ctxt.Tx())
+
+	result, err := webhookNotifiers.FindAllByProjectUuid(projectUuid)
+	if err != nil {
+		return err
+	}
+
+	items := []interface{}{}
+	for _, notifier := range result {
+		if allowed, _ := ctxt.Auth().CanRead(notifier); allowed {
+			items = append(items, notifier)
+		}
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(items),
+		Count:      len(items),
+		Collection: items,
+	})
+
+	return nil
+}
+
 func (self projectHandler) EmailNotifiers(ctxt RequestContext) error {
 
 	projectUuid := ctxt.PathParameter("uuid")
@@ -837,6 +935,9 @@ func (self projectHandler) Leave(ctxt RequestContext) error {
 	if user == nil {
 		return ErrSessionNotValid
 	}
+	if err := requireSessionUser(ctxt); err != nil {
+		return err
+	}
 	membership, err := store.FindByUserAndProjectUuid(user.Uuid, projectUuid)
 	if err != nil {
 		return err
@@ -40,7 +40,18 @@ func Test_ProjectHandler_Routing(t *testing.T) {
 		{"GET", "/projects/:uuid/scheduled-executions", "project-scheduled-executions"},
 		{"GET", "/projects/:uuid/job-notifiers", "project-job-notifiers"},
 		{"GET", "/projects/:uuid/slack-notifiers", "project-slack-notifiers"},
+		{"GET", "/projects/:uuid/webhook-notifiers", "project-webhook-notifiers"},
+		{"GET", "/projects/:uuid/teams-notifiers", "project-teams-notifiers"},
+		{"GET", "/projects/:uuid/mattermost-notifiers", "project-mattermost-notifiers"},
+		{"GET", "/projects/:uuid/discord-notifiers", "project-discord-notifiers"},
+		{"GET", "/projects/:uuid/blame-notifiers", "project-blame-notifiers"},
 		{"GET", "/projects/:uuid/email-notifiers", "project-email-notifiers"},
+		{"GET", "/projects/:uuid/log-retention", "project-log-retention-show"},
+		{"PUT", "/projects/:uuid/log-retention", "project-log-retention-update"},
+		{"DELETE", "/projects/:uuid/log-retention", "project-log-retention-archive"},
+		{"GET", "/projects/:uuid/api-tokens", "project-api-tokens"},
+		{"POST", "/projects/:uuid/api-tokens", "project-api-token-create"},
+		{"GET", "/projects/:uuid/secrets", "project-secrets"},
 	}
 	spec.run(r, t)
 }
@@ -731,3 +742,18 @@ func Test_ProjectHandler_Create_creates_default_environment_for_project(t *testi
 		t.Errorf(`environments[0].IsDefault = %v; want %v`, got, want)
 	}
 }
+
+func Test_ProjectHandler_Leave_rejectsApiTokens(t *testing.T) {
+	h := newScopedApiTokenTest(MountProjectHandler, "project-member", []string{"read-project"}, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("private")
+	status, apiError := h.Do("DELETE", "/projects/"+project.Uuid+"/members", nil)
+	if status != http.StatusForbidden {
+		t.Errorf("status = %d; want %d", status, http.StatusForbidden)
+	}
+
+	if got, want := apiError.Reason, "api_token_not_permitted"; got != want {
+		t.Errorf("apiError.Reason = %q; want %q", got, want)
+	}
+}
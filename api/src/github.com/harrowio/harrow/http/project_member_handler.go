@@ -22,6 +22,7 @@ type projectMemberHandler struct {
 		projectMemberships      *stores.DbProjectMembershipStore
 		organizationMemberships *stores.DbOrganizationMembershipStore
 		users                   *stores.DbUserStore
+		customRoles             *stores.DbCustomRoleStore
 	}
 }
 
@@ -35,6 +36,8 @@ func MountProjectMemberHandler(r *mux.Router, ctxt ServerContext) {
 
 	item.Methods("DELETE").Handler(HandlerFunc(ctxt, ph.Remove)).
 		Name("project-member-remove")
+	item.Methods("PUT").Path("/custom-role").Handler(HandlerFunc(ctxt, ph.AssignCustomRole)).
+		Name("project-member-custom-role")
 
 	root.Methods("PUT").Handler(HandlerFunc(ctxt, ph.Update)).
 		Name("project-member-update")
@@ -52,6 +55,7 @@ func (self *projectMemberHandler) init(ctxt RequestContext) (*projectMemberHandl
 	h.stores.projects = stores.NewDbProjectStore(tx)
 	h.stores.projectMemberships = stores.NewDbProjectMembershipStore(tx)
 	h.stores.users = stores.NewDbUserStore(tx, &c)
+	h.stores.customRoles = stores.NewDbCustomRoleStore(tx)
 
 	return h, nil
 }
@@ -127,6 +131,85 @@ func (self *projectMemberHandler) Update(ctxt RequestContext) error {
 
 }
 
+// AssignCustomRole assigns a custom role to the project member
+// identified by the user uuid in the URL.  Sending a null
+// customRoleUuid removes the member's custom role.
+func (self *projectMemberHandler) AssignCustomRole(ctxt RequestContext) error {
+	h, err := self.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	params := struct {
+		ProjectUuid    string  `json:"projectUuid"`
+		CustomRoleUuid *string `json:"customRoleUuid"`
+	}{}
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: &params}); err != nil {
+		return err
+	}
+
+	if !uuidhelper.IsValid(params.ProjectUuid) {
+		return NewMalformedParameters("projectUuid", errors.New("invalid_uuid"))
+	}
+
+	project, err := h.stores.projects.FindByUuid(params.ProjectUuid)
+	if err != nil {
+		return err
+	}
+
+	var role *domain.CustomRole
+	if params.CustomRoleUuid != nil {
+		role, err = h.stores.customRoles.FindByUuid(*params.CustomRoleUuid)
+		if err != nil {
+			return err
+		}
+	}
+
+	assigner, err := h.loadMember(project.Uuid, ctxt.User().Uuid)
+	if err != nil {
+		return err
+	}
+	if assigner == nil {
+		return domain.NewValidationError("membershipType", "too_low")
+	}
+
+	assignee, err := h.loadMember(project.Uuid, ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+	if assignee == nil {
+		return ErrNotFound
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(assignee); !allowed {
+		return err
+	}
+
+	if err := assigner.AssignCustomRole(assignee, project, role); err != nil {
+		return err
+	}
+
+	membership := assignee.ToMembership()
+	if len(membership.Uuid) > 0 {
+		if err := h.stores.projectMemberships.Update(membership); err != nil {
+			return err
+		}
+	} else {
+		if _, err := h.stores.projectMemberships.Create(membership); err != nil {
+			return err
+		}
+	}
+
+	ctxt.EnqueueActivity(activities.CustomRoleAssigned(membership), nil)
+	writeAsJson(ctxt, assignee)
+
+	return nil
+}
+
 func (self *projectMemberHandler) Remove(ctxt RequestContext) error {
 	h, err := self.init(ctxt)
 	if err != nil {
@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -19,6 +20,7 @@ func Test_ProjectMemberHandler_Routing(t *testing.T) {
 	spec := &routingSpec{
 		{"DELETE", "/project-members/:uuid", "project-member-remove"},
 		{"PUT", "/project-members", "project-member-update"},
+		{"PUT", "/project-members/:uuid/custom-role", "project-member-custom-role"},
 	}
 
 	spec.run(r, t)
@@ -134,3 +136,162 @@ func Test_ProjectMemberHandler_Remove_emitsUserRemovedFromProjectActivity_ifUser
 	t.Fatalf("Activity %q not found", "user.removed-from-project")
 
 }
+
+func Test_ProjectMemberHandler_AssignCustomRole_updatesProjectMembership(t *testing.T) {
+	h := NewHandlerTest(MountProjectMemberHandler, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("private")
+	membership := h.World().ProjectMembership("project-member-private")
+	role := &domain.CustomRole{
+		OrganizationUuid: project.OrganizationUuid,
+		Name:             "Deployer",
+		Grants:           domain.CustomRoleCapabilities{"create-schedule"},
+	}
+	if _, err := stores.NewDbCustomRoleStore(h.Tx()).Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("project-owner")
+	h.Do("PUT", h.Url("/project-members/"+membership.UserUuid+"/custom-role"), &halWrapper{
+		Subject: map[string]interface{}{
+			"projectUuid":    project.Uuid,
+			"customRoleUuid": role.Uuid,
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	updated, err := stores.NewDbProjectMembershipStore(h.Tx()).FindByUuid(membership.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.CustomRoleUuid == nil || *updated.CustomRoleUuid != role.Uuid {
+		t.Errorf(`updated.CustomRoleUuid = %v; want %q`, updated.CustomRoleUuid, role.Uuid)
+	}
+
+	if got, want := updated.MembershipType, membership.MembershipType; got != want {
+		t.Errorf(`updated.MembershipType = %q; want %q`, got, want)
+	}
+}
+
+func Test_ProjectMemberHandler_AssignCustomRole_requiresManager(t *testing.T) {
+	h := NewHandlerTest(MountProjectMemberHandler, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("private")
+	role := &domain.CustomRole{
+		OrganizationUuid: project.OrganizationUuid,
+		Name:             "Deployer",
+		Grants:           domain.CustomRoleCapabilities{"create-schedule"},
+	}
+	if _, err := stores.NewDbCustomRoleStore(h.Tx()).Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("project-member")
+	h.Do("PUT", h.Url("/project-members/"+h.World().User("project-member").Uuid+"/custom-role"), &halWrapper{
+		Subject: map[string]interface{}{
+			"projectUuid":    project.Uuid,
+			"customRoleUuid": role.Uuid,
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func Test_ProjectMemberHandler_AssignCustomRole_rejectsSelfAssignment(t *testing.T) {
+	h := NewHandlerTest(MountProjectMemberHandler, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("private")
+	role := &domain.CustomRole{
+		OrganizationUuid: project.OrganizationUuid,
+		Name:             "Deployer",
+		Grants:           domain.CustomRoleCapabilities{"create-schedule"},
+	}
+	if _, err := stores.NewDbCustomRoleStore(h.Tx()).Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("project-owner")
+	h.Do("PUT", h.Url("/project-members/"+h.World().User("project-owner").Uuid+"/custom-role"), &halWrapper{
+		Subject: map[string]interface{}{
+			"projectUuid":    project.Uuid,
+			"customRoleUuid": role.Uuid,
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if !strings.Contains(string(h.ResponseBody()), "self_assignment") {
+		t.Fatalf("Expected self_assignment in response:\n%s", h.ResponseBody())
+	}
+}
+
+func Test_ProjectMemberHandler_AssignCustomRole_rejectsCapabilitiesBeyondAssigner(t *testing.T) {
+	h := NewHandlerTest(MountProjectMemberHandler, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("private")
+	h.Tx().MustExec(`UPDATE project_memberships SET membership_type = $1 WHERE uuid = $2`,
+		domain.MembershipTypeManager, h.World().ProjectMembership("project-member-private").Uuid)
+
+	role := &domain.CustomRole{
+		OrganizationUuid: project.OrganizationUuid,
+		Name:             "Archiver",
+		Grants:           domain.CustomRoleCapabilities{"create-schedule", "archive-project"},
+	}
+	if _, err := stores.NewDbCustomRoleStore(h.Tx()).Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("project-member")
+	h.Do("PUT", h.Url("/project-members/"+h.World().User("project-owner").Uuid+"/custom-role"), &halWrapper{
+		Subject: map[string]interface{}{
+			"projectUuid":    project.Uuid,
+			"customRoleUuid": role.Uuid,
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if !strings.Contains(string(h.ResponseBody()), "not_assignable") {
+		t.Fatalf("Expected not_assignable in response:\n%s", h.ResponseBody())
+	}
+}
+
+func Test_ProjectMemberHandler_AssignCustomRole_respectsApiTokenCapabilities(t *testing.T) {
+	h := newScopedApiTokenTest(MountProjectMemberHandler, "default", []string{"read-project-member"}, t)
+	defer h.Cleanup()
+
+	project := h.World().Project("private")
+	role := &domain.CustomRole{
+		OrganizationUuid: project.OrganizationUuid,
+		Name:             "Deployer",
+		Grants:           domain.CustomRoleCapabilities{"create-schedule"},
+	}
+	if _, err := stores.NewDbCustomRoleStore(h.Tx()).Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	status, _ := h.Do("PUT", "/project-members/"+h.World().User("project-member").Uuid+"/custom-role", &halWrapper{
+		Subject: map[string]interface{}{
+			"projectUuid":    project.Uuid,
+			"customRoleUuid": role.Uuid,
+		},
+	})
+
+	if got, want := status, 403; got != want {
+		t.Fatalf("status = %d; want %d", got, want)
+	}
+}
@@ -37,6 +37,12 @@ type promptHandler struct {
 func (self promptHandler) Index(ctxt RequestContext) error {
 
 	currentUser := ctxt.User()
+	if currentUser == nil {
+		return ErrLoginRequired
+	}
+	if err := requireSessionUser(ctxt); err != nil {
+		return err
+	}
 
 	members, err := ctxt.KeyValueStore().SMembers(currentUser.Uuid)
 	if err != nil {
@@ -63,6 +69,12 @@ func (self promptHandler) Show(ctxt RequestContext) (err error) {
 
 	promptKey := ctxt.PathParameter("key")
 	currentUser := ctxt.User()
+	if currentUser == nil {
+		return ErrLoginRequired
+	}
+	if err := requireSessionUser(ctxt); err != nil {
+		return err
+	}
 
 	dismissed, err := ctxt.KeyValueStore().SIsMember(currentUser.Uuid, promptKey)
 	if err != nil {
@@ -83,6 +95,12 @@ func (self promptHandler) Post(ctxt RequestContext) error {
 
 	promptKey := ctxt.PathParameter("key")
 	currentUser := ctxt.User()
+	if currentUser == nil {
+		return ErrLoginRequired
+	}
+	if err := requireSessionUser(ctxt); err != nil {
+		return err
+	}
 
 	_, err := ctxt.KeyValueStore().SRem(currentUser.Uuid, promptKey)
 	if err != nil {
@@ -98,6 +116,12 @@ func (self promptHandler) Delete(ctxt RequestContext) error {
 
 	promptKey := ctxt.PathParameter("key")
 	currentUser := ctxt.User()
+	if currentUser == nil {
+		return ErrLoginRequired
+	}
+	if err := requireSessionUser(ctxt); err != nil {
+		return err
+	}
 
 	if promptKey == "all" {
 		err := ctxt.KeyValueStore().Del(currentUser.Uuid)
@@ -19,3 +19,26 @@ func Test_PromptHandler_Routing(t *testing.T) {
 
 	spec.run(r, t)
 }
+
+func Test_PromptHandler_rejectsApiTokens(t *testing.T) {
+	for _, method := range []string{"GET", "POST", "DELETE"} {
+		h := newScopedApiTokenTest(MountPromptHandler, "default", []string{"read-project"}, t)
+		status, apiError := h.Do(method, "/prompts/welcome", nil)
+		h.Cleanup()
+
+		if status != 403 {
+			t.Errorf("%s: status = %d; want %d", method, status, 403)
+		}
+
+		if got, want := apiError.Reason, "api_token_not_permitted"; got != want {
+			t.Errorf("%s: apiError.Reason = %q; want %q", method, got, want)
+		}
+	}
+
+	h := newScopedApiTokenTest(MountPromptHandler, "default", []string{"read-project"}, t)
+	defer h.Cleanup()
+
+	if status, _ := h.Do("GET", "/prompts", nil); status != 403 {
+		t.Errorf("GET /prompts: status = %d; want %d", status, 403)
+	}
+}
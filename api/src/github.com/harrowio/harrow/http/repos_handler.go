@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -75,6 +76,12 @@ func MountRepoHandler(r *mux.Router, ctxt ServerContext) {
 
 	related.Methods("GET").Path("/credential").Handler(HandlerFunc(ctxt, rh.Credential)).
 		Name("repository-credential")
+	related.Methods("DELETE").Path("/host-keys").Handler(HandlerFunc(ctxt, rh.ResetHostKeys)).
+		Name("repository-reset-host-keys")
+	related.Methods("PUT").Path("/status-token").Handler(HandlerFunc(ctxt, rh.UpdateStatusToken)).
+		Name("repository-status-token-update")
+	related.Methods("DELETE").Path("/status-token").Handler(HandlerFunc(ctxt, rh.DeleteStatusToken)).
+		Name("repository-status-token-delete")
 
 	// Item
 	item := root.PathPrefix("/{uuid}").Subrouter()
@@ -169,6 +176,12 @@ func (self repoHandler) Create(ctxt RequestContext) error {
 		return err
 	}
 
+	if gitRepo.UsesSSH() {
+		if err := self.checkAccess(ctxt, repo); err != nil {
+			return err
+		}
+	}
+
 	if gitRepo.IsAccessible() {
 		if (gitRepo.UsesHTTP() && userInfo == nil) || gitRepo.UsesSSH() {
 			ctxt.EnqueueActivity(activities.RepositoryDetectedAsPublic(repo), nil)
@@ -201,6 +214,7 @@ func (self repoHandler) Update(ctxt RequestContext) (err error) {
 		return err
 	}
 
+	previous := *repo
 	copyRepoParams(params, repo)
 
 	if err := domain.ValidateRepository(repo); err != nil {
@@ -227,8 +241,22 @@ func (self repoHandler) Update(ctxt RequestContext) (err error) {
 		return err
 	}
 
+	// The repository might have moved to a different host, whose
+	// keys are pinned by the next access check.
+	if repo.Url != previous.Url {
+		if err := store.ResetHostKeys(repo.Uuid); err != nil {
+			return err
+		}
+
+		if gitRepo.UsesSSH() {
+			if err := self.checkAccess(ctxt, repo); err != nil {
+				return err
+			}
+		}
+	}
+
 	uuid = repo.Uuid
-	ctxt.EnqueueActivity(activities.RepositoryEdited(repo), nil)
+	ctxt.EnqueueActivity(activities.RepositoryEdited(repo).SetPrevious(&previous), nil)
 
 	if userInfo != nil && gitRepo.UsesHTTP() {
 		toSave := (*domain.BasicRepositoryCredential)(nil)
@@ -341,6 +369,7 @@ func (self repoHandler) Checks(ctxt RequestContext) (err error) {
 	}
 
 	accessible, err := gitRepo.IsAccessible()
+	hostKeyChanged := err == git.ErrHostKeyChanged
 	if err != nil {
 		ctxt.Log().Info().Msgf("gitrepo(%q).isaccessible: %s", repo.Uuid, err)
 	}
@@ -348,6 +377,16 @@ func (self repoHandler) Checks(ctxt RequestContext) (err error) {
 		return err
 	}
 
+	if accessible && gitRepo.UsesSSH() && repo.HostKeys == "" {
+		hostKeys, err := gitRepo.KnownHosts()
+		if err != nil {
+			return NewInternalError(err)
+		}
+		if _, err := repoStore.PinHostKeys(repoUuid, hostKeys); err != nil {
+			return err
+		}
+	}
+
 	if accessible && (repo.ConnectedSuccessfully != nil && !*repo.ConnectedSuccessfully) {
 		if repo.ConnectedSuccessfully != nil && !*repo.ConnectedSuccessfully {
 			ctxt.EnqueueActivity(activities.RepositoryConnectedSuccessfully(repo), nil)
@@ -357,6 +396,8 @@ func (self repoHandler) Checks(ctxt RequestContext) (err error) {
 	ctxt.W().Header().Set("Content-Type", "application/json")
 	if accessible {
 		fmt.Fprintf(ctxt.W(), `{"accessible":true}`)
+	} else if hostKeyChanged {
+		fmt.Fprintf(ctxt.W(), `{"accessible":false,"reason":"host_key_changed"}`)
 	} else {
 		fmt.Fprintf(ctxt.W(), `{"accessible":false}`)
 	}
@@ -364,6 +405,145 @@ func (self repoHandler) Checks(ctxt RequestContext) (err error) {
 	return nil
 }
 
+// ResetHostKeys forgets the SSH host keys pinned for a repository,
+// e.g. after the repository host has legitimately changed its keys.
+func (self repoHandler) ResetHostKeys(ctxt RequestContext) (err error) {
+
+	repoUuid := ctxt.PathParameter("uuid")
+	repoStore := stores.NewDbRepositoryStore(ctxt.Tx())
+
+	repo, err := repoStore.FindByUuid(repoUuid)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(repo); !allowed {
+		return err
+	}
+
+	if err := repoStore.ResetHostKeys(repoUuid); err != nil {
+		return err
+	}
+
+	if err := self.checkAccess(ctxt, repo); err != nil {
+		return err
+	}
+
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+type statusTokenParams struct {
+	Token    string `json:"token"`
+	Provider string `json:"provider"`
+}
+
+// UpdateStatusToken stores the API token used for reporting the
+// status of operations to the commits of a repository, replacing any
+// token stored before.
+func (self repoHandler) UpdateStatusToken(ctxt RequestContext) (err error) {
+
+	repoUuid := ctxt.PathParameter("uuid")
+	repo, err := stores.NewDbRepositoryStore(ctxt.Tx()).FindByUuid(repoUuid)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(repo); !allowed {
+		return err
+	}
+
+	tokenParams := &statusTokenParams{}
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: tokenParams}); err != nil {
+		return err
+	}
+
+	repositoryCredentials := stores.NewRepositoryCredentialStore(self.ss, ctxt.Tx())
+	toSave := &domain.TokenRepositoryCredential{
+		RepositoryCredential: &domain.RepositoryCredential{
+			Name:           "Commit Status Token",
+			RepositoryUuid: repo.Uuid,
+			Type:           domain.RepositoryCredentialToken,
+			Status:         domain.RepositoryCredentialPresent,
+		},
+		Token:    tokenParams.Token,
+		Provider: tokenParams.Provider,
+	}
+	if err := toSave.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := repositoryCredentials.FindByRepositoryUuidAndTypeNoLoad(repo.Uuid, domain.RepositoryCredentialToken)
+	if err != nil && !domain.IsNotFound(err) {
+		return err
+	}
+	if existing != nil {
+		toSave.RepositoryCredential = existing
+	}
+
+	credential, err := toSave.AsRepositoryCredential()
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		err = repositoryCredentials.Update(credential)
+	} else {
+		_, err = repositoryCredentials.Create(credential)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// DeleteStatusToken removes the repository's commit status token.
+func (self repoHandler) DeleteStatusToken(ctxt RequestContext) (err error) {
+
+	repoUuid := ctxt.PathParameter("uuid")
+	repo, err := stores.NewDbRepositoryStore(ctxt.Tx()).FindByUuid(repoUuid)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(repo); !allowed {
+		return err
+	}
+
+	repositoryCredentials := stores.NewRepositoryCredentialStore(self.ss, ctxt.Tx())
+	credential, err := repositoryCredentials.FindByRepositoryUuidAndType(repo.Uuid, domain.RepositoryCredentialToken)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	credential.ArchivedAt = &now
+	if err := repositoryCredentials.Update(credential); err != nil {
+		return err
+	}
+
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// checkAccess schedules an access check for repo, which pins the
+// SSH host keys of the repository if none are pinned yet.
+func (self repoHandler) checkAccess(ctxt RequestContext, repo *domain.Repository) error {
+	op := repo.NewAccessCheckOperation()
+	op.Parameters.Reason = domain.OperationTriggeredByUser
+
+	if _, err := stores.NewDbOperationStore(ctxt.Tx()).Create(op); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (self repoHandler) MetaData(ctxt RequestContext) (err error) {
 
 	repoUuid := ctxt.PathParameter("uuid")
@@ -491,6 +671,21 @@ func (self repoHandler) Credential(ctxt RequestContext) (err error) {
 		}
 		writeAsJson(ctxt, &result)
 		return nil
+	} else if rc.IsToken() {
+		tokenCredential, err := domain.AsTokenRepositoryCredential(rc)
+		if err != nil {
+			return err
+		}
+		// The token itself is never sent back to clients.
+		result := struct {
+			*domain.RepositoryCredential
+			Provider string `json:"provider"`
+		}{
+			RepositoryCredential: rc,
+			Provider:             tokenCredential.Provider,
+		}
+		writeAsJson(ctxt, &result)
+		return nil
 	}
 	return fmt.Errorf("rendering non-ssh RepositoryCredentials not implemented")
 }
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"testing"
 
 	"github.com/gorilla/mux"
 	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
 	"github.com/harrowio/harrow/test_helpers"
 )
 
@@ -29,6 +32,9 @@ func Test_RepoHandler_Routing(t *testing.T) {
 		{"POST", "/repositories/:uuid/checks", "repository-checks"},
 		{"POST", "/repositories/:uuid/metadata", "repository-metadata"},
 		{"GET", "/repositories/:uuid/credential", "repository-credential"},
+		{"DELETE", "/repositories/:uuid/host-keys", "repository-reset-host-keys"},
+		{"PUT", "/repositories/:uuid/status-token", "repository-status-token-update"},
+		{"DELETE", "/repositories/:uuid/status-token", "repository-status-token-delete"},
 		{"GET", "/repositories/:uuid", "repository-show"},
 		{"DELETE", "/repositories/:uuid", "repository-archive"},
 	}
@@ -60,6 +66,65 @@ func Test_RepoHandler_Credential_returnsRC(t *testing.T) {
 	}
 }
 
+func Test_RepoHandler_UpdateStatusToken_storesTokenWithoutRenderingIt(t *testing.T) {
+	h := NewHandlerTest(MountRepoHandler, t)
+	defer h.Cleanup()
+
+	repo := h.World().Repository("public")
+	h.LoginAs("default")
+	h.Subject(repo)
+
+	h.Do("PUT", h.UrlFor("status-token"), &halWrapper{
+		Subject: &statusTokenParams{
+			Token:    "glpat-secret",
+			Provider: domain.CommitStatusProviderGitLab,
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusNoContent; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d", got, want)
+	}
+
+	stored, err := stores.NewRepositoryCredentialStore(h.context.SecretKeyValueStore(), h.Tx()).
+		FindByRepositoryUuidAndType(repo.Uuid, domain.RepositoryCredentialToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := domain.AsTokenRepositoryCredential(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := token.Token, "glpat-secret"; got != want {
+		t.Errorf(`token.Token = %q; want %q`, got, want)
+	}
+
+	h.Do("GET", h.UrlFor("credential")+"?type=token", nil)
+	if body := h.ResponseBody(); bytes.Contains(body, []byte("glpat-secret")) {
+		t.Errorf("response contains token: %s", body)
+	}
+}
+
+func Test_RepoHandler_UpdateStatusToken_rejectsUnknownProvider(t *testing.T) {
+	h := NewHandlerTest(MountRepoHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("default")
+	h.Subject(h.World().Repository("public"))
+
+	h.Do("PUT", h.UrlFor("status-token"), &halWrapper{
+		Subject: &statusTokenParams{
+			Token:    "secret",
+			Provider: "sourceforge",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Errorf("h.Response().StatusCode = %d; want %d", got, want)
+	}
+}
+
 func Test_RepoHandler_CreateUpdate_emitsRepositoryAddedActivity_whenCreatingARepository(t *testing.T) {
 	h := NewHandlerTest(MountRepoHandler, t)
 	defer h.Cleanup()
@@ -162,3 +227,42 @@ func Test_RepoHandler_CreateUpdate_emitsRepositoryEditedActivity_whenUpdatingARe
 
 	t.Fatalf("Activity %q not found", "repository.edited")
 }
+
+func Test_RepoHandler_Create_schedulesAccessCheck_forSshRepositories(t *testing.T) {
+	h := NewHandlerTest(MountRepoHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("default")
+
+	project := h.World().Project("public")
+
+	h.Do("POST", h.Url("/repositories"), &repoParamsWrapper{
+		Subject: repoParams{
+			Url:         "git@github.com:harrowio/private-private.git",
+			Name:        "created",
+			ProjectUuid: project.Uuid,
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	created := &domain.Repository{}
+	if err := json.Unmarshal(h.ResponseBody(), &halWrapper{Subject: created}); err != nil {
+		t.Fatal(err)
+	}
+
+	operations, err := stores.NewDbOperationStore(h.Tx()).FindAllByRepositoryUuid(created.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, operation := range operations {
+		if operation.IsGitAccessCheck() {
+			return
+		}
+	}
+
+	t.Fatalf("no %q operation found for repository %s", domain.OperationTypeGitAccessCheck, created.Uuid)
+}
@@ -116,6 +116,7 @@ func (self schedHandler) Edit(ctxt RequestContext) error {
 		return err
 	}
 
+	previous := *schedule
 	schedule.Cronspec = params.Cronspec
 	schedule.Timespec = params.Timespec
 	schedule.Description = params.Description
@@ -124,7 +125,7 @@ func (self schedHandler) Edit(ctxt RequestContext) error {
 		return err
 	}
 
-	ctxt.EnqueueActivity(activities.ScheduleEdited(schedule), nil)
+	ctxt.EnqueueActivity(activities.ScheduleEdited(schedule).SetPrevious(&previous), nil)
 
 	return nil
 }
@@ -142,6 +143,11 @@ func (self schedHandler) Delete(ctxt RequestContext) error {
 	if err != nil {
 		return err
 	}
+
+	if allowed, err := ctxt.Auth().CanArchive(schedule); !allowed {
+		return err
+	}
+
 	if err := schedules.ArchiveByUuid(scheduleUuid); err != nil {
 		return err
 	}
@@ -307,3 +307,13 @@ func Test_ScheduleHandler_Edit_updates_schedule(t *testing.T) {
 		t.Errorf(`updatedSchedule.Description = %v; want %v`, got, want)
 	}
 }
+
+func Test_ScheduleHandler_Delete_respectsApiTokenCapabilities(t *testing.T) {
+	h := newScopedApiTokenTest(MountScheduleHandler, "default", []string{"read-schedule"}, t)
+	defer h.Cleanup()
+
+	schedule := h.World().Schedule("default")
+	if status, _ := h.Do("DELETE", "/schedules/"+schedule.Uuid, nil); status != 403 {
+		t.Errorf("status = %d; want %d", status, 403)
+	}
+}
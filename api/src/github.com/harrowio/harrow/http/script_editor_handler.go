@@ -282,12 +282,13 @@ func (self *scriptEditorHandler) Save(ctxt RequestContext) error {
 			activity.Extra["script-editor"] = true
 			ctxt.EnqueueActivity(activity, nil)
 		} else {
+			previous := *task
 			task.Body = params.Task.Body
 			task.Name = params.Task.Name
 			if err := h.tasks.Update(task); err != nil {
 				errors.Add("task", err.Error())
 			} else {
-				activity := activities.TaskEdited(task)
+				activity := activities.TaskEdited(task).SetPrevious(&previous)
 				activity.Extra["script-editor"] = true
 				ctxt.EnqueueActivity(activity, nil)
 			}
@@ -306,11 +307,12 @@ func (self *scriptEditorHandler) Save(ctxt RequestContext) error {
 				ctxt.EnqueueActivity(activity, nil)
 			}
 		} else {
+			previous := *environment
 			environment.Variables = params.Environment.Variables
 			if err := h.environments.Update(environment); err != nil {
 				errors.Add("environment", err.Error())
 			} else {
-				activity := activities.EnvironmentAdded(environment)
+				activity := activities.EnvironmentEdited(environment).SetPrevious(&previous)
 				activity.Extra["script-editor"] = true
 				ctxt.EnqueueActivity(activity, nil)
 			}
@@ -339,7 +341,13 @@ func (self *scriptEditorHandler) Save(ctxt RequestContext) error {
 
 func (self *scriptEditorHandler) saveSecrets(params *scriptEditParams, existingSecrets []*domain.Secret, environment *domain.Environment, errors *domain.ValidationError, ctxt interface {
 	EnqueueActivity(*domain.Activity, *string)
+	User() *domain.User
 }) {
+	var authorUuid *string
+	if user := ctxt.User(); user != nil {
+		authorUuid = &user.Uuid
+	}
+
 	for _, existingSecret := range existingSecrets {
 		found := params.SecretByName(existingSecret.Name)
 		if found == nil && !existingSecret.IsSsh() {
@@ -363,10 +371,11 @@ func (self *scriptEditorHandler) saveSecrets(params *scriptEditParams, existingS
 				errors.Add(fmt.Sprintf("secrets[%s]", existingSecret.Name), err.Error())
 				continue
 			}
+			toSave.AuthorUuid = authorUuid
 			if err := self.secrets.Update(toSave); err != nil {
 				errors.Add(fmt.Sprintf("secrets[%s]", existingSecret.Name), err.Error())
 			} else {
-				activity := activities.SecretEdited(toSave)
+				activity := activities.SecretEdited(toSave).SetPrevious(existingSecret)
 				activity.Extra["script-editor"] = true
 				ctxt.EnqueueActivity(activity, nil)
 			}
@@ -403,6 +412,7 @@ func (self *scriptEditorHandler) saveSecrets(params *scriptEditParams, existingS
 			errors.Add(fmt.Sprintf("secrets[%s]", secret.Name), err.Error())
 			continue
 		}
+		toSave.AuthorUuid = authorUuid
 
 		if _, err := self.secrets.Create(toSave); err != nil {
 			errors.Add(fmt.Sprintf("secrets[%s]", secret.Name), err.Error())
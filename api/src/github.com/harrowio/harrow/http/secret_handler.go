@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
@@ -18,28 +19,30 @@ type secretParamsWrapper struct {
 }
 
 type secretParams struct {
-	Uuid            string              `json:"uuid"`
-	Name            string              `json:"name"`
-	EnvironmentUuid string              `json:"environmentUuid"`
-	Type            domain.SecretType   `json:"type"`
-	Status          domain.SecretStatus `json:"status"`
-	Value           string              `json:"value"`
+	Uuid             string                      `json:"uuid"`
+	Name             string                      `json:"name"`
+	EnvironmentUuid  string                      `json:"environmentUuid"`
+	ProjectUuid      *string                     `json:"projectUuid"`
+	OrganizationUuid *string                     `json:"organizationUuid"`
+	OverridePolicy   domain.SecretOverridePolicy `json:"overridePolicy"`
+	Type             domain.SecretType           `json:"type"`
+	Status           domain.SecretStatus         `json:"status"`
+	Value            string                      `json:"value"`
 }
 
 func copySecretParams(p *secretParams, m *domain.Secret) {
 	m.Uuid = p.Uuid
 	m.Name = p.Name
 	m.EnvironmentUuid = p.EnvironmentUuid
+	m.ProjectUuid = p.ProjectUuid
+	m.OrganizationUuid = p.OrganizationUuid
+	m.OverridePolicy = p.OverridePolicy
 	m.Type = p.Type
 	m.Status = p.Status
 }
 
 func copyEnvSecretParams(p *secretParams, m *domain.EnvironmentSecret) {
-	m.Uuid = p.Uuid
-	m.Name = p.Name
-	m.EnvironmentUuid = p.EnvironmentUuid
-	m.Type = p.Type
-	m.Status = p.Status
+	copySecretParams(p, m.Secret)
 	m.Value = p.Value
 }
 
@@ -62,10 +65,21 @@ func MountSecretHandler(r *mux.Router, ctxt ServerContext) {
 	root.Methods("POST").Handler(HandlerFunc(ctxt, h.Create)).
 		Name("secret-create")
 
+	// Relationships
+	related := root.PathPrefix("/{uuid}/").Subrouter()
+	related.Methods("GET").Path("/versions").Handler(HandlerFunc(ctxt, h.Versions)).
+		Name("secret-versions")
+	related.Methods("GET").Path("/versions/{version}/operations").Handler(HandlerFunc(ctxt, h.VersionOperations)).
+		Name("secret-version-operations")
+	related.Methods("POST").Path("/versions/{version}/rollback").Handler(HandlerFunc(ctxt, h.Rollback)).
+		Name("secret-rollback")
+
 	// Item
 	item := root.PathPrefix("/{uuid}").Subrouter()
 	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
 		Name("secret-show")
+	item.Methods("PUT").Handler(HandlerFunc(ctxt, h.Update)).
+		Name("secret-update")
 	item.Methods("DELETE").Handler(HandlerFunc(ctxt, h.Archive)).
 		Name("secret-archive")
 }
@@ -93,6 +107,10 @@ func (self secretHandler) CreateSsh(ctxt RequestContext, params *secretParams) (
 
 	copySecretParams(params, secret)
 
+	if err := secret.Validate(); err != nil {
+		return err
+	}
+
 	if allowed, err := ctxt.Auth().CanCreate(secret); !allowed {
 		return err
 	}
@@ -101,6 +119,10 @@ func (self secretHandler) CreateSsh(ctxt RequestContext, params *secretParams) (
 		return err
 	}
 
+	if user := ctxt.User(); user != nil {
+		secret.AuthorUuid = &user.Uuid
+	}
+
 	uuid, err := store.Create(secret)
 
 	if err != nil {
@@ -134,6 +156,10 @@ func (self secretHandler) CreateEnv(ctxt RequestContext, params *secretParams) (
 		return err
 	}
 
+	if err := secret.Validate(); err != nil {
+		return err
+	}
+
 	if allowed, err := ctxt.Auth().CanCreate(secret); !allowed {
 		return err
 	}
@@ -142,6 +168,10 @@ func (self secretHandler) CreateEnv(ctxt RequestContext, params *secretParams) (
 		return err
 	}
 
+	if user := ctxt.User(); user != nil {
+		secret.AuthorUuid = &user.Uuid
+	}
+
 	uuid, err := store.Create(secret)
 
 	if err != nil {
@@ -220,6 +250,74 @@ func (self secretHandler) Show(ctxt RequestContext) error {
 	}
 }
 
+// Update changes the value and override policy of an environment
+// secret.  Since environments look up inherited secrets whenever they
+// are used, changes to shared secrets apply to all environments
+// inheriting them.
+func (self secretHandler) Update(ctxt RequestContext) error {
+
+	params, err := ReadSecretParams(ctxt.R().Body)
+	if err != nil {
+		return err
+	}
+
+	store := stores.NewSecretStore(ctxt.SecretKeyValueStore(), ctxt.Tx())
+	store.SetLogger(ctxt.Log())
+
+	secret, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(secret); !allowed {
+		return err
+	}
+
+	if !secret.IsEnv() {
+		return domain.NewValidationError("type", "not_updatable")
+	}
+
+	// Activities are stored and sent to other services, so they
+	// must not carry the value of the secret.
+	previous := *secret
+	previous.SecretBytes = nil
+
+	if params.OverridePolicy != "" {
+		secret.OverridePolicy = params.OverridePolicy
+	}
+
+	if err := secret.Validate(); err != nil {
+		return err
+	}
+
+	envSecret, err := domain.AsEnvironmentSecret(secret)
+	if err != nil {
+		return err
+	}
+	envSecret.Value = params.Value
+
+	secret, err = envSecret.AsSecret()
+	if err != nil {
+		return err
+	}
+
+	if user := ctxt.User(); user != nil {
+		secret.AuthorUuid = &user.Uuid
+	}
+
+	if err := store.Update(secret); err != nil {
+		return err
+	}
+
+	edited := *secret
+	edited.SecretBytes = nil
+	ctxt.EnqueueActivity(activities.SecretEdited(&edited).SetPrevious(&previous), nil)
+
+	writeAsJson(ctxt, secret)
+
+	return nil
+}
+
 func (self secretHandler) Archive(ctxt RequestContext) (err error) {
 
 	uuid := ctxt.PathParameter("uuid")
@@ -245,3 +343,247 @@ func (self secretHandler) Archive(ctxt RequestContext) (err error) {
 
 	return err
 }
+
+// IndexForProject lists the secrets a project shares with all of its
+// environments.
+func (self secretHandler) IndexForProject(ctxt RequestContext) error {
+
+	project, err := stores.NewDbProjectStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(project); !allowed {
+		return err
+	}
+
+	secrets, err := stores.NewSecretStore(ctxt.SecretKeyValueStore(), ctxt.Tx()).FindAllByProjectUuid(project.Uuid)
+	if err != nil {
+		return err
+	}
+
+	return self.writeSecrets(ctxt, secrets)
+}
+
+// IndexForOrganization lists the secrets an organization shares with
+// all environments of its projects.
+func (self secretHandler) IndexForOrganization(ctxt RequestContext) error {
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(organization); !allowed {
+		return err
+	}
+
+	secrets, err := stores.NewSecretStore(ctxt.SecretKeyValueStore(), ctxt.Tx()).FindAllByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		return err
+	}
+
+	return self.writeSecrets(ctxt, secrets)
+}
+
+func (self secretHandler) writeSecrets(ctxt RequestContext, secrets []*domain.Secret) error {
+	result, err := readableSecrets(ctxt, secrets)
+	if err != nil {
+		return err
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
+
+// readableSecrets returns the secrets the current user is allowed to
+// read, rendered with their values only if the user is allowed to
+// read privileged secrets.
+func readableSecrets(ctxt RequestContext, secrets []*domain.Secret) ([]interface{}, error) {
+	result := []interface{}{}
+	for _, secret := range secrets {
+		if allowed, _ := ctxt.Auth().CanRead(secret); !allowed {
+			continue
+		}
+
+		if secret.IsPending() {
+			result = append(result, secret)
+			continue
+		}
+
+		if secret.IsSsh() {
+			sshSecret, err := domain.AsSshSecret(secret)
+			if err != nil {
+				return nil, err
+			}
+			unpriv, err := sshSecret.AsUnprivileged()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, unpriv)
+			continue
+		}
+
+		envSecret, err := domain.AsEnvironmentSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+		if privileged, _ := ctxt.Auth().Can(domain.CapabilityReadPrivileged, secret); privileged {
+			priv, err := envSecret.AsPrivileged()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, priv)
+		} else {
+			unpriv, err := envSecret.AsUnprivileged()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, unpriv)
+		}
+	}
+
+	return result, nil
+}
+
+// Versions lists the versions of a secret, most recent first.  Only
+// metadata is returned, never the values.
+func (self secretHandler) Versions(ctxt RequestContext) error {
+
+	store := stores.NewSecretStore(ctxt.SecretKeyValueStore(), ctxt.Tx())
+	store.SetLogger(ctxt.Log())
+
+	secret, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(secret); !allowed {
+		return err
+	}
+
+	versions, err := store.FindAllVersionsBySecretUuid(secret.Uuid)
+	if err != nil {
+		return err
+	}
+
+	result := []interface{}{}
+	for _, version := range versions {
+		result = append(result, version)
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
+
+// VersionOperations lists the operations that have been given a
+// version of a secret.
+func (self secretHandler) VersionOperations(ctxt RequestContext) error {
+
+	store := stores.NewSecretStore(ctxt.SecretKeyValueStore(), ctxt.Tx())
+	store.SetLogger(ctxt.Log())
+
+	secret, version, err := self.findVersion(ctxt, store)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(secret); !allowed {
+		return err
+	}
+
+	operationUuids, err := store.FindOperationUuidsBySecretVersion(secret.Uuid, version.Version)
+	if err != nil {
+		return err
+	}
+
+	operationStore := stores.NewDbOperationStore(ctxt.Tx())
+	result := []interface{}{}
+	for _, operationUuid := range operationUuids {
+		operation, err := operationStore.FindByUuid(operationUuid)
+		if err != nil {
+			return err
+		}
+
+		if allowed, _ := ctxt.Auth().CanRead(operation); allowed {
+			result = append(result, operation)
+		}
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
+
+// Rollback restores the value a secret had in an earlier version by
+// creating a new version with that value.
+func (self secretHandler) Rollback(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	store := stores.NewSecretStore(ctxt.SecretKeyValueStore(), ctxt.Tx())
+	store.SetLogger(ctxt.Log())
+
+	secret, version, err := self.findVersion(ctxt, store)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().Can("rollback", secret); !allowed {
+		return err
+	}
+
+	secret.AuthorUuid = &ctxt.User().Uuid
+	if err := store.Rollback(secret, version); err != nil {
+		return err
+	}
+	ctxt.AfterCommit(func() error { return store.WriteCurrentValue(secret) })
+
+	current, err := store.FindVersion(secret.Uuid, secret.Version)
+	if err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.SecretRolledBack(secret, version), nil)
+
+	ctxt.W().Header().Set("Location", urlForSubject(ctxt.R(), current))
+	ctxt.W().WriteHeader(http.StatusCreated)
+	writeAsJson(ctxt, current)
+
+	return nil
+}
+
+func (self secretHandler) findVersion(ctxt RequestContext, store *stores.SecretStore) (*domain.Secret, *domain.SecretVersion, error) {
+	number, err := strconv.Atoi(ctxt.PathParameter("version"))
+	if err != nil {
+		return nil, nil, domain.NewValidationError("version", "malformed")
+	}
+
+	secret, err := store.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	version, err := store.FindVersion(secret.Uuid, number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return secret, version, nil
+}
@@ -20,7 +20,11 @@ func Test_SecretHandler_Routing(t *testing.T) {
 	spec := routingSpec{
 		{"POST", "/secrets", "secret-create"},
 		{"GET", "/secrets/:uuid", "secret-show"},
+		{"PUT", "/secrets/:uuid", "secret-update"},
 		{"DELETE", "/secrets/:uuid", "secret-archive"},
+		{"GET", "/secrets/:uuid/versions", "secret-versions"},
+		{"GET", "/secrets/:uuid/versions/:version/operations", "secret-version-operations"},
+		{"POST", "/secrets/:uuid/versions/:version/rollback", "secret-rollback"},
 	}
 
 	spec.run(r, t)
@@ -388,3 +392,298 @@ func Test_SecretHandler_Create_emitsSecretAddedActivity(t *testing.T) {
 
 	t.Fatalf("Activity %q not found", "secret.added")
 }
+
+func newSecretVersionForHandlerTest(h *httpHandlerTest, value string) (*stores.SecretStore, *domain.Secret) {
+	store := stores.NewSecretStore(h.context.SecretKeyValueStore(), h.Tx())
+	secret, err := store.FindByUuid(h.World().Secret("env").Uuid)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	envSecret, err := domain.AsEnvironmentSecret(secret)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	envSecret.Value = value
+	secret, err = envSecret.AsSecret()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	if err := store.Update(secret); err != nil {
+		h.t.Fatal(err)
+	}
+
+	return store, secret
+}
+
+func Test_SecretHandler_Versions_listsVersionsWithoutValues(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	_, secret := newSecretVersionForHandlerTest(h, "changed")
+
+	h.LoginAs("project-member")
+	h.Do("GET", h.Url("/secrets/"+secret.Uuid+"/versions"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	result := struct {
+		Collection []struct {
+			Subject map[string]interface{} `json:"subject"`
+		} `json:"collection"`
+	}{}
+	if err := json.Unmarshal(h.ResponseBody(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(result.Collection), 2; got != want {
+		t.Fatalf(`len(result.Collection) = %d; want %d`, got, want)
+	}
+
+	for _, item := range result.Collection {
+		if _, found := item.Subject["value"]; found {
+			t.Errorf("version %v contains a value", item.Subject["version"])
+		}
+	}
+}
+
+func Test_SecretHandler_Rollback_restoresPreviousValue(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	store, secret := newSecretVersionForHandlerTest(h, "changed")
+
+	h.LoginAs("project-owner")
+	h.Do("POST", h.Url("/secrets/"+secret.Uuid+"/versions/1/rollback"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	secret, err := store.FindByUuid(secret.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envSecret, err := domain.AsEnvironmentSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := envSecret.Value, "foo"; got != want {
+		t.Errorf(`envSecret.Value = %q; want %q`, got, want)
+	}
+
+	version, err := store.FindVersion(secret.Uuid, secret.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version.CreatedByUuid == nil || *version.CreatedByUuid != h.World().User("project-owner").Uuid {
+		t.Errorf(`version.CreatedByUuid = %v; want %s`, version.CreatedByUuid, h.World().User("project-owner").Uuid)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "secret.rolled-back" {
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "secret.rolled-back")
+}
+
+func Test_SecretHandler_Rollback_requiresManager(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	_, secret := newSecretVersionForHandlerTest(h, "changed")
+
+	h.LoginAs("project-member")
+	h.Do("POST", h.Url("/secrets/"+secret.Uuid+"/versions/1/rollback"), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func newSharedSecretForHandlerTest(h *httpHandlerTest, secret *domain.Secret, value string) *domain.Secret {
+	secret, err := (&domain.EnvironmentSecret{Secret: secret, Value: value}).AsSecret()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	secret.Type = domain.SecretEnv
+
+	return test_helpers.MustCreateSecret(h.t, h.Tx(), h.context.SecretKeyValueStore(), secret)
+}
+
+func Test_SecretHandler_Create_organizationSecretAsOrganizationOwner(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	organizationUuid := h.World().Organization("default").Uuid
+
+	h.LoginAs("default")
+	h.Do("POST", h.Url("/secrets"), &halWrapper{
+		Subject: &secretParams{
+			Name:             "REGISTRY_PASSWORD",
+			OrganizationUuid: &organizationUuid,
+			OverridePolicy:   domain.SecretOverrideDeny,
+			Type:             domain.SecretEnv,
+			Value:            "hunter2",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	secrets, err := stores.NewSecretStore(h.context.SecretKeyValueStore(), h.Tx()).
+		FindAllSharedWithEnvironmentUuid(h.World().Environment("private").Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(secrets), 1; got != want {
+		t.Fatalf(`len(secrets) = %d; want %d`, got, want)
+	}
+
+	if got, want := secrets[0].OverridePolicy, domain.SecretOverrideDeny; got != want {
+		t.Errorf(`secrets[0].OverridePolicy = %q; want %q`, got, want)
+	}
+}
+
+func Test_SecretHandler_Create_organizationSecretRequiresManager(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	organizationUuid := h.World().Organization("default").Uuid
+
+	h.LoginAs("other")
+	h.Do("POST", h.Url("/secrets"), &halWrapper{
+		Subject: &secretParams{
+			Name:             "REGISTRY_PASSWORD",
+			OrganizationUuid: &organizationUuid,
+			Type:             domain.SecretEnv,
+			Value:            "hunter2",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func Test_SecretHandler_Create_rejectsSecretWithMultipleScopes(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	projectUuid := h.World().Project("private").Uuid
+
+	h.LoginAs("project-owner")
+	h.Do("POST", h.Url("/secrets"), &halWrapper{
+		Subject: &secretParams{
+			Name:            "REGISTRY_PASSWORD",
+			EnvironmentUuid: h.World().Environment("private").Uuid,
+			ProjectUuid:     &projectUuid,
+			Type:            domain.SecretEnv,
+			Value:           "hunter2",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
+
+func Test_SecretHandler_Update_changesValueOfSharedSecretForAllEnvironments(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	projectUuid := h.World().Project("private").Uuid
+	secret := newSharedSecretForHandlerTest(h, &domain.Secret{
+		Name:        "REGISTRY_PASSWORD",
+		ProjectUuid: &projectUuid,
+	}, "old")
+
+	h.LoginAs("project-owner")
+	h.Do("PUT", h.Url("/secrets/"+secret.Uuid), &halWrapper{
+		Subject: &secretParams{Value: "new"},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	secrets, err := stores.NewSecretStore(h.context.SecretKeyValueStore(), h.Tx()).
+		FindAllSharedWithEnvironmentUuid(h.World().Environment("private").Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(secrets), 1; got != want {
+		t.Fatalf(`len(secrets) = %d; want %d`, got, want)
+	}
+
+	envSecret, err := domain.AsEnvironmentSecret(secrets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := envSecret.Value, "new"; got != want {
+		t.Errorf(`envSecret.Value = %q; want %q`, got, want)
+	}
+}
+
+func Test_SecretHandler_Update_emitsSecretEditedWithoutValue(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("project-owner")
+	h.Do("PUT", h.Url("/secrets/"+h.World().Secret("env").Uuid), &halWrapper{
+		Subject: &secretParams{Value: "s3cr3t-value"},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name != "secret.edited" {
+			continue
+		}
+
+		secret := activity.Payload.(*domain.Secret)
+		if len(secret.SecretBytes) > 0 {
+			t.Errorf(`secret.SecretBytes = %q; want empty`, secret.SecretBytes)
+		}
+
+		previous, ok := activity.Extra["previous"].(*domain.Secret)
+		if !ok {
+			t.Fatalf(`activity.Extra["previous"] = %#v; want *domain.Secret`, activity.Extra["previous"])
+		}
+		if len(previous.SecretBytes) > 0 {
+			t.Errorf(`previous.SecretBytes = %q; want empty`, previous.SecretBytes)
+		}
+
+		return
+	}
+
+	t.Fatalf("Activity %q not found", "secret.edited")
+}
+
+func Test_SecretHandler_Update_requiresManager(t *testing.T) {
+	h := NewHandlerTest(MountSecretHandler, t)
+	defer h.Cleanup()
+
+	h.LoginAs("project-member")
+	h.Do("PUT", h.Url("/secrets/"+h.World().Secret("env").Uuid), &halWrapper{
+		Subject: &secretParams{Value: "new"},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
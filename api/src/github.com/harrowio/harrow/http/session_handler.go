@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/harrowio/harrow/activities"
 	"github.com/harrowio/harrow/domain"
 	"github.com/harrowio/harrow/stores"
 )
@@ -41,7 +42,8 @@ type createSessionParams struct {
 }
 
 type patchSessionParams struct {
-	Totp int32 `json:"totp"`
+	Totp         int32  `json:"totp"`
+	RecoveryCode string `json:"recoveryCode"`
 }
 
 func MountSessionHandler(r *mux.Router, ctxt ServerContext) {
@@ -158,7 +160,19 @@ func (self sessionHandler) Validate(ctxt RequestContext) (err error) {
 		return err
 	}
 
-	if !user.IsValidTotpToken(params.Totp) {
+	if params.RecoveryCode != "" {
+		recoveryCodeStore := stores.NewDbRecoveryCodeStore(ctxt.Tx())
+		if _, err := recoveryCodeStore.Use(user.Uuid, params.RecoveryCode); err != nil {
+			return err
+		}
+
+		remaining, err := recoveryCodeStore.CountUnusedByUserUuid(user.Uuid)
+		if err != nil {
+			return err
+		}
+
+		ctxt.EnqueueActivity(activities.UserUsedRecoveryCode(user, remaining), &user.Uuid)
+	} else if !user.IsValidTotpToken(params.Totp) {
 		return domain.ErrTotpTokenNotValid
 	}
 
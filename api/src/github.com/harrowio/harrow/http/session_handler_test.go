@@ -326,6 +326,58 @@ func Test_SessionHandler_Validate_Returns422IfTokenIsInvalid(t *testing.T) {
 	}
 }
 
+func Test_SessionHandler_Validate_acceptsRecoveryCodeOnce(t *testing.T) {
+	h := NewHandlerTest(MountSessionHandler, t)
+	defer h.Cleanup()
+
+	u := h.World().User("default")
+	u.GenerateTotpSecret()
+	if err := u.EnableTotp(u.CurrentTotpToken()); err != nil {
+		t.Fatal(err)
+	}
+
+	userStore := stores.NewDbUserStore(h.Tx(), h.Config())
+	if err := userStore.Update(u); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, codes := domain.NewRecoveryCodes(u.Uuid)
+	if err := stores.NewDbRecoveryCodeStore(h.Tx()).ReplaceAllForUser(u.Uuid, codes); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionStore := stores.NewDbSessionStore(h.Tx())
+	for _, status := range []int{http.StatusOK, StatusUnprocessableEntity} {
+		session := u.NewSession("go-test", "127.0.0.1")
+		if _, err := sessionStore.Create(session); err != nil {
+			t.Fatal(err)
+		}
+
+		h.Subject(session)
+		h.Do("PATCH", h.UrlFor("self"), struct {
+			RecoveryCode string `json:"recoveryCode"`
+		}{
+			strings.ToUpper(plain[0]),
+		})
+
+		if got, want := h.Response().StatusCode, status; got != want {
+			t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+		}
+	}
+
+	if got, want := len(h.Activities()), 1; got != want {
+		t.Fatalf("len(h.Activities()) = %d; want %d", got, want)
+	}
+
+	activity := h.Activities()[0]
+	if got, want := activity.Name, "user.used-recovery-code"; got != want {
+		t.Errorf("activity.Name = %q; want %q", got, want)
+	}
+	if got, want := activity.Extra["remainingRecoveryCodes"], domain.RecoveryCodeCount-1; got != want {
+		t.Errorf(`activity.Extra["remainingRecoveryCodes"] = %v; want %v`, got, want)
+	}
+}
+
 func Test_SessionHandler_Show_ReturnsErrSessionExpiredIfSessionIsExpired(t *testing.T) {
 	h := NewHandlerTest(MountSessionHandler, t)
 	defer h.Cleanup()
@@ -372,3 +424,38 @@ func TestSessionHandler_Create_enqueuesUserLoggedInActivity(t *testing.T) {
 
 	t.Fatalf("Activity %q not found", "user.logged-in")
 }
+
+func Test_SessionHandler_Create_requiresSsoIfEnforcedByOrganization(t *testing.T) {
+
+	h := NewHandlerTest(MountSessionHandler, t)
+	defer h.Cleanup()
+
+	user := h.World().User("other")
+	provider := &domain.SsoProvider{
+		OrganizationUuid: h.World().Organization("default").Uuid,
+		Protocol:         domain.SsoProtocolOIDC,
+		Enforced:         true,
+		EmailDomains:     domain.SsoEmailDomains{"localhost"},
+		OidcIssuer:       "https://idp.example.com",
+		OidcClientId:     "harrow",
+		OidcClientSecret: "secret",
+	}
+	if _, err := stores.NewDbSsoProviderStore(h.Tx()).Create(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Do("POST", h.Url("/sessions"), &halWrapper{
+		Subject: &domain.User{
+			Email:    user.Email,
+			Password: "password-is-long-enough",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusForbidden; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d", got, want)
+	}
+
+	if !strings.Contains(string(h.ResponseBody()), "sso_required") {
+		t.Fatalf("Expected sso_required in response:\n%s", h.ResponseBody())
+	}
+}
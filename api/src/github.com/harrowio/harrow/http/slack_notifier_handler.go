@@ -113,8 +113,12 @@ func (h *SlackNotifierHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
+	previous := *h.subject
+
 	h.subject.Name = newVersion.Name
 	h.subject.WebhookURL = newVersion.WebhookURL
+	h.subject.SubjectTemplate = newVersion.SubjectTemplate
+	h.subject.BodyTemplate = newVersion.BodyTemplate
 	if err := h.subject.Validate(); err != nil {
 		return err
 	}
@@ -123,7 +127,7 @@ func (h *SlackNotifierHandler) Update(ctxt RequestContext) error {
 		return err
 	}
 
-	ctxt.EnqueueActivity(activities.SlackNotifierEdited(h.subject), nil)
+	ctxt.EnqueueActivity(activities.SlackNotifierEdited(h.subject).SetPrevious(&previous), nil)
 	writeAsJson(ctxt, h.subject)
 
 	return nil
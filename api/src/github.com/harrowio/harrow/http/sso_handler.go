@@ -0,0 +1,541 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/services/sso"
+	"github.com/harrowio/harrow/stores"
+)
+
+// ssoStateLifetime is the time users have to sign in at the identity
+// provider.
+const ssoStateLifetime = 10 * time.Minute
+
+// ssoBindingCookie names the cookie binding a sign-in to the browser
+// that started it.
+const ssoBindingCookie = "harrow-sso-binding"
+
+// ssoLinkLifetime is the time existing users have to confirm linking
+// their account to an identity.
+const ssoLinkLifetime = 24 * time.Hour
+
+// ssoState is kept in the key value store while the user signs in at
+// the identity provider.  It is looked up by the random state passed
+// through the identity provider.
+type ssoState struct {
+	OrganizationUuid string    `json:"organizationUuid"`
+	Nonce            string    `json:"nonce"`
+	UserUuid         string    `json:"userUuid"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// newSsoProvider returns the sso.Provider to use for signing in
+// through provider.
+var newSsoProvider = func(ctxt RequestContext, provider *domain.SsoProvider) (sso.Provider, error) {
+	ssoConfig := ctxt.Config().SsoConfig()
+	callbackUrl := fmt.Sprintf(ssoConfig.CallbackUri, provider.OrganizationUuid)
+	return sso.NewProvider(provider, callbackUrl, ssoConfig.EntityId)
+}
+
+type ssoHandler struct{}
+
+func MountSsoHandler(r *mux.Router, ctxt ServerContext) {
+	h := ssoHandler{}
+
+	root := r.PathPrefix("/sso").Subrouter()
+	root.Methods("GET").Path("/discover").Handler(HandlerFunc(ctxt, h.Discover)).
+		Name("sso-discover")
+
+	root.Methods("POST").Path("/link").Handler(HandlerFunc(ctxt, h.Link)).
+		Name("sso-link")
+
+	related := root.PathPrefix("/{organizationUuid}/").Subrouter()
+	related.Methods("GET").Path("/signin").Handler(HandlerFunc(ctxt, h.Signin)).
+		Name("sso-signin")
+	related.Methods("GET", "POST").Path("/callback").Handler(HandlerFunc(ctxt, h.Callback)).
+		Name("sso-callback")
+}
+
+// Discover tells the frontend whether the email address given in the
+// "email" query parameter needs to sign in through single sign-on.
+func (self ssoHandler) Discover(ctxt RequestContext) error {
+	email := ctxt.R().URL.Query().Get("email")
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ErrNotFound
+	}
+
+	provider, err := stores.NewDbSsoProviderStore(ctxt.Tx()).FindByEmailDomain(email[at+1:])
+	if err != nil {
+		return err
+	}
+
+	response := struct {
+		OrganizationUuid string `json:"organizationUuid"`
+		Protocol         string `json:"protocol"`
+		Enforced         bool   `json:"enforced"`
+		Signin           string `json:"signin"`
+	}{
+		OrganizationUuid: provider.OrganizationUuid,
+		Protocol:         provider.Protocol,
+		Enforced:         provider.Enforced,
+		Signin:           provider.Links(map[string]map[string]string{}, requestScheme(ctxt.R()), requestBaseUri(ctxt.R()))["signin"]["href"],
+	}
+
+	ctxt.W().Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(ctxt.W()).Encode(&response)
+}
+
+// Signin returns the URL of the organization's identity provider in
+// the Location header and binds the sign-in to the requesting browser
+// with a cookie.  If a user is logged in, the identity returned by the
+// identity provider is linked to that user.
+func (self ssoHandler) Signin(ctxt RequestContext) error {
+	provider, err := stores.NewDbSsoProviderStore(ctxt.Tx()).FindByOrganizationUuid(ctxt.PathParameter("organizationUuid"))
+	if err != nil {
+		return err
+	}
+
+	client, err := newSsoProvider(ctxt, provider)
+	if err != nil {
+		return err
+	}
+
+	state := oAuthHandler{}.makeRandomString()
+	authUrl, nonce, err := client.AuthURL(state)
+	if err != nil {
+		return self.translateError(err)
+	}
+
+	data := &ssoState{
+		OrganizationUuid: provider.OrganizationUuid,
+		Nonce:            nonce,
+		CreatedAt:        time.Now(),
+	}
+	if ctxt.User() != nil {
+		data.UserUuid = ctxt.User().Uuid
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if err := ctxt.KeyValueStore().Set(self.stateKey(state), encoded); err != nil {
+		return err
+	}
+
+	secure := requestScheme(ctxt.R()) == "https"
+	sameSite := http.SameSiteLaxMode
+	if secure {
+		// SAML identity providers POST their response to the
+		// callback, which lax cookies are not sent along with.
+		sameSite = http.SameSiteNoneMode
+	}
+	http.SetCookie(ctxt.W(), &http.Cookie{
+		Name:     ssoBindingCookie,
+		Value:    base32.StdEncoding.EncodeToString(self.stateMac(ctxt, state)),
+		Path:     "/sso/",
+		MaxAge:   int(ssoStateLifetime / time.Second),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: sameSite,
+	})
+
+	ctxt.W().Header().Add("Location", authUrl)
+
+	return nil
+}
+
+// Callback verifies the response of the identity provider, signs the
+// user up if necessary and redirects to the frontend with a new
+// session.
+func (self ssoHandler) Callback(ctxt RequestContext) error {
+	if err := ctxt.R().ParseForm(); err != nil {
+		return err
+	}
+	params := ctxt.R().Form
+
+	organizationUuid := ctxt.PathParameter("organizationUuid")
+	stateParam := params.Get("state")
+	if stateParam == "" {
+		stateParam = params.Get("RelayState")
+	}
+
+	if err := self.verifyBinding(ctxt, stateParam); err != nil {
+		return err
+	}
+
+	state, err := self.consumeState(ctxt, stateParam)
+	if err != nil {
+		return err
+	}
+
+	if state.OrganizationUuid != organizationUuid {
+		return NewSsoError("state_mismatch", fmt.Errorf("state belongs to organization %q", state.OrganizationUuid))
+	}
+
+	provider, err := stores.NewDbSsoProviderStore(ctxt.Tx()).FindByOrganizationUuid(organizationUuid)
+	if err != nil {
+		return err
+	}
+
+	client, err := newSsoProvider(ctxt, provider)
+	if err != nil {
+		return err
+	}
+
+	identity, err := client.Complete(params, state.Nonce)
+	if err != nil {
+		return self.translateError(err)
+	}
+
+	if !provider.AcceptsEmail(identity.Email) {
+		return NewSsoError("email_domain_mismatch", fmt.Errorf("%q does not belong to any domain of the organization", identity.Email))
+	}
+
+	membershipType := provider.MembershipTypeFor(identity.Groups)
+	if membershipType == "" {
+		return NewSsoError("no_membership", errors.New("None of the user's groups grant access to the organization"))
+	}
+
+	user, err := self.findOrCreateUser(ctxt, provider, identity, state)
+	if linkRequired, ok := err.(*ssoLinkRequired); ok {
+		return self.requestLink(ctxt, provider, identity, linkRequired.user)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := self.syncMembership(ctxt, provider, user, membershipType); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.UserLoggedInViaSso(user), &user.Uuid)
+
+	session, _, err := startSession(ctxt, user)
+	if err != nil {
+		return err
+	}
+
+	redirectUri := fmt.Sprintf(ctxt.Config().SsoConfig().SessionRedirectUri, session.Uuid)
+	http.Redirect(ctxt.W(), ctxt.R(), redirectUri, http.StatusSeeOther)
+
+	return nil
+}
+
+// ssoLinkRequired is returned by findOrCreateUser if the email
+// address of an identity belongs to an existing user.
+type ssoLinkRequired struct {
+	user *domain.User
+}
+
+func (self *ssoLinkRequired) Error() string {
+	return fmt.Sprintf("sso: identity not linked to user %s", self.user.Uuid)
+}
+
+// findOrCreateUser returns the user linked to identity.  Identities
+// are never linked to existing users by email address alone, because
+// the identity provider might not verify email addresses.  Existing
+// users link their account by signing in while being logged in, or
+// by confirming the link sent to them by requestLink.
+func (self ssoHandler) findOrCreateUser(ctxt RequestContext, provider *domain.SsoProvider, identity *sso.Identity, state *ssoState) (*domain.User, error) {
+	c := ctxt.Config()
+	userStore := stores.NewDbUserStore(ctxt.Tx(), &c)
+	identityStore := stores.NewDbSsoIdentityStore(ctxt.Tx())
+
+	linked, err := identityStore.FindByProviderUuidAndSubject(provider.Uuid, identity.Subject)
+	if err == nil {
+		if err := identityStore.MarkLoggedIn(linked.Uuid); err != nil {
+			return nil, err
+		}
+		return userStore.FindByUuid(linked.UserUuid)
+	}
+	if !domain.IsNotFound(err) {
+		return nil, err
+	}
+
+	var user *domain.User
+	if state.UserUuid != "" {
+		user, err = userStore.FindByUuid(state.UserUuid)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		existing, err := userStore.FindByEmailAddress(identity.Email)
+		if err != nil && !domain.IsNotFound(err) {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, &ssoLinkRequired{user: existing}
+		}
+
+		user, err = self.newUser(ctxt, userStore, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	identityUuid, err := identityStore.Create(&domain.SsoIdentity{
+		ProviderUuid: provider.Uuid,
+		Subject:      identity.Subject,
+		UserUuid:     user.Uuid,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := identityStore.MarkLoggedIn(identityUuid); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// requestLink mails user a link for confirming that identity belongs
+// to them and sends them to a page telling them so.
+func (self ssoHandler) requestLink(ctxt RequestContext, provider *domain.SsoProvider, identity *sso.Identity, user *domain.User) error {
+	link := &domain.SsoLink{
+		UserUuid:     user.Uuid,
+		ProviderUuid: provider.Uuid,
+		Subject:      identity.Subject,
+		ExpiresAt:    time.Now().Add(ssoLinkLifetime).Truncate(time.Second),
+	}
+	ctxt.EnqueueActivity(activities.UserRequestedSsoLink(link), &user.Uuid)
+
+	http.Redirect(ctxt.W(), ctxt.R(), ctxt.Config().SsoConfig().LinkRequestedRedirectUri, http.StatusSeeOther)
+
+	return nil
+}
+
+// Link links the identity sent to a user by requestLink to that user
+// and starts a session for them.  Their membership in the
+// organization is synced the next time they sign in through the
+// identity provider.
+func (self ssoHandler) Link(ctxt RequestContext) error {
+	params := struct {
+		User     string `json:"user"`
+		Provider string `json:"provider"`
+		Subject  string `json:"subject"`
+		Expires  int64  `json:"expires"`
+		Mac      string `json:"mac"`
+	}{}
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&params); err != nil {
+		return err
+	}
+
+	link := &domain.SsoLink{
+		UserUuid:     params.User,
+		ProviderUuid: params.Provider,
+		Subject:      params.Subject,
+		ExpiresAt:    time.Unix(params.Expires, 0),
+	}
+
+	mac, err := base32.StdEncoding.DecodeString(params.Mac)
+	if err != nil {
+		return NewSsoError("link_invalid", err)
+	}
+	c := ctxt.Config()
+	if !hmac.Equal(mac, link.HMAC([]byte(c.HttpConfig().UserHmacSecret))) {
+		return NewSsoError("link_invalid", errors.New("Signature mismatch"))
+	}
+	if link.Expired(time.Now()) {
+		return NewSsoError("link_expired", fmt.Errorf("Link expired at %s", link.ExpiresAt))
+	}
+
+	user, err := stores.NewDbUserStore(ctxt.Tx(), &c).FindByUuid(link.UserUuid)
+	if err != nil {
+		return err
+	}
+
+	identityStore := stores.NewDbSsoIdentityStore(ctxt.Tx())
+	identity, err := identityStore.FindByProviderUuidAndSubject(link.ProviderUuid, link.Subject)
+	if err != nil && !domain.IsNotFound(err) {
+		return err
+	}
+	if identity != nil && identity.UserUuid != user.Uuid {
+		return NewSsoError("link_invalid", errors.New("Identity belongs to another user"))
+	}
+
+	identityUuid := ""
+	if identity != nil {
+		identityUuid = identity.Uuid
+	} else {
+		identityUuid, err = identityStore.Create(&domain.SsoIdentity{
+			ProviderUuid: link.ProviderUuid,
+			Subject:      link.Subject,
+			UserUuid:     user.Uuid,
+		})
+		if err != nil {
+			return err
+		}
+		ctxt.EnqueueActivity(activities.UserLinkedSsoIdentity(link), &user.Uuid)
+	}
+
+	if err := identityStore.MarkLoggedIn(identityUuid); err != nil {
+		return err
+	}
+
+	session, userBlocks, err := startSession(ctxt, user)
+	if err != nil {
+		return err
+	}
+
+	ctxt.W().Header().Add("Location", urlForSubject(ctxt.R(), session))
+	ctxt.W().WriteHeader(http.StatusCreated)
+
+	result := struct {
+		*domain.Session
+		Blocks []*domain.UserBlock `json:"blocks"`
+	}{
+		Session: session,
+		Blocks:  userBlocks,
+	}
+	writeAsJson(ctxt, &result)
+
+	return nil
+}
+
+func (self ssoHandler) newUser(ctxt RequestContext, userStore *stores.DbUserStore, identity *sso.Identity) (*domain.User, error) {
+	name := identity.Name
+	if name == "" {
+		name = identity.Email
+	}
+
+	user := &domain.User{
+		Email:           identity.Email,
+		Name:            name,
+		UrlHost:         ctxt.R().Host,
+		WithoutPassword: true,
+	}
+	uuid, err := userStore.Create(user)
+	if err != nil {
+		return nil, NewSsoError("unable_to_create_user", fmt.Errorf("Unable to create user: %s", err))
+	}
+
+	user, err = userStore.FindByUuid(uuid)
+	if err != nil {
+		return nil, NewSsoError("unable_to_load_user", fmt.Errorf("Unable to load user: %s", err))
+	}
+
+	ctxt.Log().Info().Msgf("new sso signup: email=%q name=%q\n", user.Email, user.Name)
+	ctxt.EnqueueActivity(activities.UserSignedUpViaSso(user), &user.Uuid)
+
+	return user, nil
+}
+
+// syncMembership makes user a member of the provider's organization
+// with the given membership type.  Owners are never demoted, so that
+// a misconfigured identity provider cannot lock them out.
+func (self ssoHandler) syncMembership(ctxt RequestContext, provider *domain.SsoProvider, user *domain.User, membershipType string) error {
+	store := stores.NewDbOrganizationMembershipStore(ctxt.Tx())
+
+	membership, err := store.FindByOrganizationAndUserUuids(provider.OrganizationUuid, user.Uuid)
+	if err != nil && !domain.IsNotFound(err) {
+		return err
+	}
+
+	if membership == nil {
+		return store.Create(&domain.OrganizationMembership{
+			OrganizationUuid: provider.OrganizationUuid,
+			UserUuid:         user.Uuid,
+			Type:             membershipType,
+		})
+	}
+
+	if membership.Type == membershipType || membership.Type == domain.MembershipTypeOwner {
+		return nil
+	}
+
+	membership.Type = membershipType
+	return store.UpdateType(membership)
+}
+
+// consumeState looks up and removes the state stored when starting
+// to sign in, so that each response of the identity provider can only
+// be used once.
+func (self ssoHandler) consumeState(ctxt RequestContext, state string) (*ssoState, error) {
+	if state == "" {
+		return nil, NewSsoError("state_mismatch", errors.New("No state"))
+	}
+
+	key := self.stateKey(state)
+	data, err := ctxt.KeyValueStore().Get(key)
+	if err != nil {
+		return nil, NewSsoError("state_mismatch", fmt.Errorf("Unknown state %q", state))
+	}
+
+	if err := ctxt.KeyValueStore().Del(key); err != nil {
+		return nil, err
+	}
+
+	result := &ssoState{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, NewSsoError("state_mismatch", err)
+	}
+
+	if time.Since(result.CreatedAt) > ssoStateLifetime {
+		return nil, NewSsoError("state_expired", fmt.Errorf("State %q expired", state))
+	}
+
+	return result, nil
+}
+
+// verifyBinding makes sure that the browser completing a sign-in is
+// the one that started it, so that nobody can log a victim into their
+// own account or link their identity to the victim's account by
+// sending them to the callback.  The binding cookie is removed once
+// it has been checked.
+func (self ssoHandler) verifyBinding(ctxt RequestContext, state string) error {
+	cookie, err := ctxt.R().Cookie(ssoBindingCookie)
+	if err != nil {
+		return NewSsoError("state_mismatch", errors.New("Sign-in not started by this browser"))
+	}
+
+	http.SetCookie(ctxt.W(), &http.Cookie{
+		Name:     ssoBindingCookie,
+		Path:     "/sso/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	mac, err := base32.StdEncoding.DecodeString(cookie.Value)
+	if err != nil || !hmac.Equal(mac, self.stateMac(ctxt, state)) {
+		return NewSsoError("state_mismatch", errors.New("Sign-in not started by this browser"))
+	}
+
+	return nil
+}
+
+// stateMac returns the signature of state stored in the binding
+// cookie.
+func (self ssoHandler) stateMac(ctxt RequestContext, state string) []byte {
+	c := ctxt.Config()
+	mac := hmac.New(sha256.New, []byte(c.HttpConfig().UserHmacSecret))
+	mac.Write([]byte(self.stateKey(state)))
+	return mac.Sum(nil)
+}
+
+func (self ssoHandler) stateKey(state string) string {
+	return "sso-state:" + state
+}
+
+func (self ssoHandler) translateError(err error) error {
+	if ssoErr, ok := err.(*sso.Error); ok {
+		return NewSsoError(ssoErr.Reason, ssoErr)
+	}
+
+	return err
+}
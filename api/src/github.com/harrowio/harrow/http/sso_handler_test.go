@@ -0,0 +1,341 @@
+package http
+
+import (
+	"encoding/base32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/services/sso/ssotest"
+	"github.com/harrowio/harrow/stores"
+)
+
+func Test_SsoHandler_Routing(t *testing.T) {
+	r := mux.NewRouter()
+	MountSsoHandler(r, nil)
+
+	spec := routingSpec{
+		{"GET", "/sso/discover", "sso-discover"},
+		{"POST", "/sso/link", "sso-link"},
+		{"GET", "/sso/:uuid/signin", "sso-signin"},
+		{"GET", "/sso/:uuid/callback", "sso-callback"},
+		{"POST", "/sso/:uuid/callback", "sso-callback"},
+	}
+
+	spec.run(r, t)
+}
+
+// ssoTestIdentityProvider starts a stand-in OpenID Connect issuer
+// signing in user and configures it for the default organization.
+func ssoTestIdentityProvider(h *httpHandlerTest, user ssotest.User) (*domain.SsoProvider, func()) {
+	idp := ssotest.NewOIDCProvider("harrow", "secret", user)
+	server := httptest.NewServer(idp)
+	idp.Issuer = server.URL
+
+	provider := &domain.SsoProvider{
+		OrganizationUuid:      h.World().Organization("default").Uuid,
+		Protocol:              domain.SsoProtocolOIDC,
+		EmailDomains:          domain.SsoEmailDomains{"example.com", "localhost"},
+		GroupsAttribute:       "groups",
+		GroupMappings:         domain.SsoGroupMappings{"admins": domain.MembershipTypeManager},
+		DefaultMembershipType: domain.MembershipTypeMember,
+		OidcIssuer:            server.URL,
+		OidcClientId:          "harrow",
+		OidcClientSecret:      "secret",
+	}
+	if _, err := stores.NewDbSsoProviderStore(h.Tx()).Create(provider); err != nil {
+		h.t.Fatal(err)
+	}
+
+	return provider, server.Close
+}
+
+// ssoStartSignin starts signing in at the default organization and
+// returns the callback URL the identity provider redirects to together
+// with the cookie binding the sign-in to the browser.
+func ssoStartSignin(h *httpHandlerTest) (string, *http.Cookie) {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	organizationUuid := h.World().Organization("default").Uuid
+	h.Do("GET", h.Url("/sso/"+organizationUuid+"/signin"), nil)
+	authUrl := h.Response().Header.Get("Location")
+	if authUrl == "" {
+		h.t.Fatalf("No Location header in response:\n%s", h.ResponseBody())
+	}
+
+	var binding *http.Cookie
+	for _, cookie := range h.Response().Cookies() {
+		if cookie.Name == ssoBindingCookie {
+			binding = cookie
+		}
+	}
+	if binding == nil {
+		h.t.Fatalf("No %s cookie in response", ssoBindingCookie)
+	}
+
+	idpResponse, err := client.Get(authUrl)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	idpResponse.Body.Close()
+
+	location, err := idpResponse.Location()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return h.Url("/sso/" + organizationUuid + "/callback?" + location.RawQuery), binding
+}
+
+// ssoCallback sends the browser to callbackUrl, sending along the
+// binding cookie unless it is nil.
+func ssoCallback(h *httpHandlerTest, callbackUrl string, binding *http.Cookie) *http.Response {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequest("GET", callbackUrl, nil)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if binding != nil {
+		req.AddCookie(binding)
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	response.Body.Close()
+
+	return response
+}
+
+// ssoSignin signs in at the default organization and returns the
+// response of the callback.
+func ssoSignin(h *httpHandlerTest) *http.Response {
+	callbackUrl, binding := ssoStartSignin(h)
+	return ssoCallback(h, callbackUrl, binding)
+}
+
+func Test_SsoHandler_Callback_signsUpNewUsers(t *testing.T) {
+	h := NewHandlerTest(MountSsoHandler, t)
+	defer h.Cleanup()
+
+	_, cleanup := ssoTestIdentityProvider(h, ssotest.User{
+		Subject: "jane",
+		Email:   "jane@example.com",
+		Name:    "Jane Doe",
+		Groups:  []string{"admins"},
+	})
+	defer cleanup()
+
+	response := ssoSignin(h)
+	if got, want := response.StatusCode, http.StatusSeeOther; got != want {
+		t.Fatalf("response.StatusCode = %d; want %d", got, want)
+	}
+
+	if location := response.Header.Get("Location"); !strings.Contains(location, "/sso/session/") {
+		t.Errorf(`Location = %q; want session redirect`, location)
+	}
+
+	user, err := stores.NewDbUserStore(h.Tx(), h.Config()).FindByEmailAddress("jane@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	membership, err := stores.NewDbOrganizationMembershipStore(h.Tx()).FindByOrganizationAndUserUuids(h.World().Organization("default").Uuid, user.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := membership.Type, domain.MembershipTypeManager; got != want {
+		t.Errorf(`membership.Type = %q; want %q`, got, want)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "user.signed-up-via-sso" {
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "user.signed-up-via-sso")
+}
+
+func Test_SsoHandler_Callback_requestsLinkForExistingUsers(t *testing.T) {
+	h := NewHandlerTest(MountSsoHandler, t)
+	defer h.Cleanup()
+
+	user := h.World().User("other")
+	provider, cleanup := ssoTestIdentityProvider(h, ssotest.User{
+		Subject: "mallory",
+		Email:   user.Email,
+	})
+	defer cleanup()
+
+	response := ssoSignin(h)
+	if got, want := response.StatusCode, http.StatusSeeOther; got != want {
+		t.Fatalf("response.StatusCode = %d; want %d", got, want)
+	}
+
+	if location := response.Header.Get("Location"); !strings.Contains(location, "/sso/link-requested") {
+		t.Errorf(`Location = %q; want link requested redirect`, location)
+	}
+
+	if _, err := stores.NewDbSsoIdentityStore(h.Tx()).FindByProviderUuidAndSubject(provider.Uuid, "mallory"); !domain.IsNotFound(err) {
+		t.Fatalf("err = %#v; want NotFoundError", err)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name != "user.requested-sso-link" {
+			continue
+		}
+		link := activity.Payload.(*domain.SsoLink)
+		if got, want := link.UserUuid, user.Uuid; got != want {
+			t.Errorf(`link.UserUuid = %q; want %q`, got, want)
+		}
+		return
+	}
+
+	t.Fatalf("Activity %q not found", "user.requested-sso-link")
+}
+
+func Test_SsoHandler_Link_linksIdentityAndStartsSession(t *testing.T) {
+	h := NewHandlerTest(MountSsoHandler, t)
+	defer h.Cleanup()
+
+	user := h.World().User("other")
+	provider, cleanup := ssoTestIdentityProvider(h, ssotest.User{
+		Subject: "other",
+		Email:   user.Email,
+	})
+	defer cleanup()
+
+	link := &domain.SsoLink{
+		UserUuid:     user.Uuid,
+		ProviderUuid: provider.Uuid,
+		Subject:      "other",
+		ExpiresAt:    time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	mac := base32.StdEncoding.EncodeToString(link.HMAC([]byte(h.Config().HttpConfig().UserHmacSecret)))
+	params := map[string]interface{}{
+		"user":     link.UserUuid,
+		"provider": link.ProviderUuid,
+		"subject":  "mallory",
+		"expires":  link.ExpiresAt.Unix(),
+		"mac":      mac,
+	}
+
+	h.Do("POST", h.Url("/sso/link"), params)
+	if got, want := h.Response().StatusCode, http.StatusBadRequest; got != want {
+		t.Fatalf("forged: h.Response().StatusCode = %d; want %d", got, want)
+	}
+
+	params["subject"] = link.Subject
+	h.Do("POST", h.Url("/sso/link"), params)
+	if got, want := h.Response().StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	identity, err := stores.NewDbSsoIdentityStore(h.Tx()).FindByProviderUuidAndSubject(provider.Uuid, link.Subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := identity.UserUuid, user.Uuid; got != want {
+		t.Errorf(`identity.UserUuid = %q; want %q`, got, want)
+	}
+}
+
+func Test_SsoHandler_Callback_linksIdentityToLoggedInUser(t *testing.T) {
+	h := NewHandlerTest(MountSsoHandler, t)
+	defer h.Cleanup()
+
+	user := h.World().User("other")
+	provider, cleanup := ssoTestIdentityProvider(h, ssotest.User{
+		Subject: "other",
+		Email:   user.Email,
+	})
+	defer cleanup()
+
+	h.LoginAs("other")
+	response := ssoSignin(h)
+	if got, want := response.StatusCode, http.StatusSeeOther; got != want {
+		t.Fatalf("response.StatusCode = %d; want %d", got, want)
+	}
+
+	identity, err := stores.NewDbSsoIdentityStore(h.Tx()).FindByProviderUuidAndSubject(provider.Uuid, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := identity.UserUuid, user.Uuid; got != want {
+		t.Errorf(`identity.UserUuid = %q; want %q`, got, want)
+	}
+}
+
+func Test_SsoHandler_Callback_rejectsReusedState(t *testing.T) {
+	h := NewHandlerTest(MountSsoHandler, t)
+	defer h.Cleanup()
+
+	_, cleanup := ssoTestIdentityProvider(h, ssotest.User{
+		Subject: "jane",
+		Email:   "jane@example.com",
+	})
+	defer cleanup()
+
+	response := ssoSignin(h)
+	if got, want := response.StatusCode, http.StatusSeeOther; got != want {
+		t.Fatalf("response.StatusCode = %d; want %d", got, want)
+	}
+
+	binding, err := response.Request.Cookie(ssoBindingCookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := ssoCallback(h, response.Request.URL.String(), binding)
+
+	if got, want := replayed.StatusCode, http.StatusBadRequest; got != want {
+		t.Fatalf("replayed.StatusCode = %d; want %d", got, want)
+	}
+}
+
+func Test_SsoHandler_Callback_rejectsSigninStartedByAnotherBrowser(t *testing.T) {
+	h := NewHandlerTest(MountSsoHandler, t)
+	defer h.Cleanup()
+
+	provider, cleanup := ssoTestIdentityProvider(h, ssotest.User{
+		Subject: "attacker",
+		Email:   "attacker@example.com",
+	})
+	defer cleanup()
+
+	callbackUrl, binding := ssoStartSignin(h)
+	binding.Value = base32.StdEncoding.EncodeToString([]byte("forged"))
+
+	for _, cookie := range []*http.Cookie{nil, binding} {
+		response := ssoCallback(h, callbackUrl, cookie)
+		if got, want := response.StatusCode, http.StatusBadRequest; got != want {
+			t.Errorf("response.StatusCode = %d; want %d", got, want)
+		}
+	}
+
+	if _, err := stores.NewDbUserStore(h.Tx(), h.Config()).FindByEmailAddress("attacker@example.com"); !domain.IsNotFound(err) {
+		t.Errorf("Expected no user to be signed up, got err = %v", err)
+	}
+
+	if _, err := stores.NewDbSsoIdentityStore(h.Tx()).FindByProviderUuidAndSubject(provider.Uuid, "attacker"); !domain.IsNotFound(err) {
+		t.Errorf("Expected no identity to be linked, got err = %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// ssoProviderParams is the request body for configuring single sign-on.
+// Unlike domain.SsoProvider it includes the OIDC client secret, which
+// is never sent back to clients.
+type ssoProviderParams struct {
+	Protocol              string            `json:"protocol"`
+	Enforced              bool              `json:"enforced"`
+	EmailDomains          []string          `json:"emailDomains"`
+	GroupsAttribute       string            `json:"groupsAttribute"`
+	GroupMappings         map[string]string `json:"groupMappings"`
+	DefaultMembershipType string            `json:"defaultMembershipType"`
+	OidcIssuer            string            `json:"oidcIssuer"`
+	OidcClientId          string            `json:"oidcClientId"`
+	OidcClientSecret      string            `json:"oidcClientSecret"`
+	SamlSsoUrl            string            `json:"samlSsoUrl"`
+	SamlIssuer            string            `json:"samlIssuer"`
+	SamlCertificate       string            `json:"samlCertificate"`
+}
+
+// ssoProviderHandler manages the single sign-on configuration of
+// organizations.  Its routes are mounted as relationships of
+// organizations.
+//
+// All routes require the permission to update the organization.
+type ssoProviderHandler struct {
+}
+
+func (self ssoProviderHandler) ShowForOrganization(ctxt RequestContext) error {
+
+	organization, err := self.findOrganization(ctxt)
+	if err != nil {
+		return err
+	}
+
+	provider, err := stores.NewDbSsoProviderStore(ctxt.Tx()).FindByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		return err
+	}
+
+	writeAsJson(ctxt, provider)
+
+	return nil
+}
+
+func (self ssoProviderHandler) UpdateForOrganization(ctxt RequestContext) error {
+
+	organization, err := self.findOrganization(ctxt)
+	if err != nil {
+		return err
+	}
+
+	store := stores.NewDbSsoProviderStore(ctxt.Tx())
+	existing, err := store.FindByOrganizationUuid(organization.Uuid)
+	if err != nil && !domain.IsNotFound(err) {
+		return err
+	}
+
+	params := new(ssoProviderParams)
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: params}); err != nil {
+		return err
+	}
+
+	var previous *domain.SsoProvider
+	provider := existing
+	if provider == nil {
+		provider = &domain.SsoProvider{}
+	} else {
+		copied := *existing
+		previous = &copied
+	}
+	provider.OrganizationUuid = organization.Uuid
+	provider.Protocol = params.Protocol
+	provider.Enforced = params.Enforced
+	provider.EmailDomains = domain.SsoEmailDomains(params.EmailDomains)
+	provider.GroupsAttribute = params.GroupsAttribute
+	if provider.GroupsAttribute == "" {
+		provider.GroupsAttribute = "groups"
+	}
+	provider.GroupMappings = domain.SsoGroupMappings(params.GroupMappings)
+	provider.DefaultMembershipType = params.DefaultMembershipType
+	provider.OidcIssuer = params.OidcIssuer
+	provider.OidcClientId = params.OidcClientId
+	// The secret is never returned, so clients leave it blank to
+	// keep the current one.
+	if params.OidcClientSecret != "" {
+		provider.OidcClientSecret = params.OidcClientSecret
+	}
+	provider.SamlSsoUrl = params.SamlSsoUrl
+	provider.SamlIssuer = params.SamlIssuer
+	provider.SamlCertificate = params.SamlCertificate
+
+	if err := provider.Validate(); err != nil {
+		return err
+	}
+
+	if err := self.checkEmailDomains(store, provider); err != nil {
+		return err
+	}
+
+	if existing == nil {
+		if _, err := store.Create(provider); err != nil {
+			return err
+		}
+	} else {
+		if err := store.Update(provider); err != nil {
+			return err
+		}
+	}
+
+	activity := activities.SsoProviderChanged(provider)
+	if previous != nil {
+		activity.SetPrevious(previous)
+	}
+	ctxt.EnqueueActivity(activity, nil)
+	writeAsJson(ctxt, provider)
+
+	return nil
+}
+
+func (self ssoProviderHandler) ArchiveForOrganization(ctxt RequestContext) error {
+
+	organization, err := self.findOrganization(ctxt)
+	if err != nil {
+		return err
+	}
+
+	store := stores.NewDbSsoProviderStore(ctxt.Tx())
+	provider, err := store.FindByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		return err
+	}
+
+	if err := store.ArchiveByUuid(provider.Uuid); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.SsoProviderRemoved(provider), nil)
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (self ssoProviderHandler) findOrganization(ctxt RequestContext) (*domain.Organization, error) {
+
+	if ctxt.User() == nil {
+		return nil, ErrLoginRequired
+	}
+
+	organization, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(organization); !allowed {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+// checkEmailDomains ensures that no email domain is bound to more than
+// one organization, as otherwise any organization could take over the
+// accounts of another organization's users.
+func (self ssoProviderHandler) checkEmailDomains(store *stores.DbSsoProviderStore, provider *domain.SsoProvider) error {
+	for _, emailDomain := range provider.EmailDomains {
+		other, err := store.FindByEmailDomain(emailDomain)
+		if err != nil && !domain.IsNotFound(err) {
+			return err
+		}
+
+		if other != nil && other.OrganizationUuid != provider.OrganizationUuid {
+			return domain.NewValidationError("emailDomains", "taken")
+		}
+	}
+
+	return nil
+}
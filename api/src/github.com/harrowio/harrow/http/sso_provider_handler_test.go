@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+)
+
+func Test_SsoProviderHandler_UpdateForOrganization_keepsClientSecretPrivate(t *testing.T) {
+	h := NewHandlerTest(MountOrganizationHandler, t)
+	defer h.Cleanup()
+
+	organization := h.World().Organization("default")
+	h.LoginAs("default")
+	h.Do("PUT", h.Url("/organizations/"+organization.Uuid+"/sso"), &halWrapper{
+		Subject: &ssoProviderParams{
+			Protocol:              domain.SsoProtocolOIDC,
+			EmailDomains:          []string{"example.com"},
+			DefaultMembershipType: domain.MembershipTypeMember,
+			OidcIssuer:            "https://idp.example.com",
+			OidcClientId:          "harrow",
+			OidcClientSecret:      "very-secret",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if strings.Contains(string(h.ResponseBody()), "very-secret") {
+		t.Fatalf("Client secret found in response:\n%s", h.ResponseBody())
+	}
+
+	provider, err := stores.NewDbSsoProviderStore(h.Tx()).FindByOrganizationUuid(organization.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := provider.OidcClientSecret, "very-secret"; got != want {
+		t.Errorf(`provider.OidcClientSecret = %q; want %q`, got, want)
+	}
+
+	if got, want := provider.GroupsAttribute, "groups"; got != want {
+		t.Errorf(`provider.GroupsAttribute = %q; want %q`, got, want)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "sso-provider.changed" {
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "sso-provider.changed")
+}
+
+func Test_SsoProviderHandler_UpdateForOrganization_rejectsEmailDomainsOfOtherOrganizations(t *testing.T) {
+	h := NewHandlerTest(MountOrganizationHandler, t)
+	defer h.Cleanup()
+
+	otherOrganization := test_helpers.MustCreateOrganization(t, h.Tx(), &domain.Organization{
+		Name: "Other Inc.",
+	})
+	other := &domain.SsoProvider{
+		OrganizationUuid: otherOrganization.Uuid,
+		Protocol:         domain.SsoProtocolOIDC,
+		EmailDomains:     domain.SsoEmailDomains{"example.com"},
+		OidcIssuer:       "https://idp.example.com",
+		OidcClientId:     "harrow",
+		OidcClientSecret: "secret",
+	}
+	if _, err := stores.NewDbSsoProviderStore(h.Tx()).Create(other); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("default")
+	h.Do("PUT", h.Url("/organizations/"+h.World().Organization("default").Uuid+"/sso"), &halWrapper{
+		Subject: &ssoProviderParams{
+			Protocol:         domain.SsoProtocolOIDC,
+			EmailDomains:     []string{"Example.com"},
+			OidcIssuer:       "https://idp.example.com",
+			OidcClientId:     "harrow",
+			OidcClientSecret: "secret",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, 422; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+}
@@ -2,12 +2,16 @@ package http
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/harrowio/harrow/activities"
 	"github.com/harrowio/harrow/authz"
 	"github.com/harrowio/harrow/config"
 	"github.com/harrowio/harrow/domain"
@@ -22,12 +26,14 @@ type standardContext struct {
 	kv stores.KeyValueStore
 	ss stores.SecretKeyValueStore
 
-	t          *sqlx.Tx
-	u          *domain.User
-	r          *http.Request
-	activities []*domain.Activity
-	w          http.ResponseWriter
-	auth       authz.Service
+	t           *sqlx.Tx
+	u           *domain.User
+	token       *domain.ApiToken
+	r           *http.Request
+	activities  []*domain.Activity
+	afterCommit []func() error
+	w           http.ResponseWriter
+	auth        authz.Service
 
 	log logger.Logger
 }
@@ -56,6 +62,10 @@ func (sc *standardContext) User() *domain.User {
 	return sc.u
 }
 
+func (sc *standardContext) ApiToken() *domain.ApiToken {
+	return sc.token
+}
+
 func (sc *standardContext) Auth() authz.Service {
 	return sc.auth
 }
@@ -102,7 +112,14 @@ func (sc *standardContext) RequestContext(w http.ResponseWriter, req *http.Reque
 	if err := requestContext.loadUser(); err != nil {
 		return nil, err
 	}
-	requestContext.auth = authz.NewService(requestContext.t, requestContext.User(), sc.c)
+	if err := requestContext.enforceIpAllowlists(); err != nil {
+		return nil, err
+	}
+	auth := authz.NewService(requestContext.t, requestContext.User(), sc.c)
+	if requestContext.token != nil {
+		auth.RestrictTo(requestContext.token)
+	}
+	requestContext.auth = auth
 
 	return requestContext, nil
 }
@@ -126,11 +143,29 @@ func (sc *standardContext) newTx() *sqlx.Tx {
 
 var (
 	AllowedWithInvalidatedSession = regexp.MustCompile(`/(sessions/|api-features)`)
+
+	// AllowedWithoutRequiredTotp matches exactly the paths needed
+	// for enrolling in two-factor authentication.
+	AllowedWithoutRequiredTotp = regexp.MustCompile(`^/(sessions/[^/]+|api-features|users/[^/]+(/mfa|/recovery-codes|/organizations)?)$`)
+
+	// AllowedFromAnyAddress matches exactly the paths exempt from
+	// organizations' API allowlists.
+	AllowedFromAnyAddress = regexp.MustCompile(`^/(sessions/[^/]+|api-features)$`)
+
+	// ManagesIpAllowlist matches the paths owners can use to manage an
+	// organization's allowlist from addresses not on it.
+	ManagesIpAllowlist = regexp.MustCompile(`^/(ip-allowlist-entries(/[^/]+)?|organizations/[^/]+/ip-allowlist-entries)$`)
+
+	webhookDeliveryPath = regexp.MustCompile(`^/wh/([^/]+)`)
 )
 
 func (sc *standardContext) loadUser() error {
 
 	if sc.u == nil {
+		if plainToken := sc.apiTokenFromRequest(); plainToken != "" {
+			return sc.loadUserFromApiToken(plainToken)
+		}
+
 		sessionUuid := sc.R().Header.Get(http.CanonicalHeaderKey("x-harrow-session-uuid"))
 		c := sc.Config()
 		user, err := CurrentUser(&c, sc.Tx(), sessionUuid)
@@ -145,12 +180,275 @@ func (sc *standardContext) loadUser() error {
 		}
 		// could be nil on an unauthenticated request
 		sc.u = user
+
+		if user != nil && !AllowedWithoutRequiredTotp.MatchString(sc.R().URL.Path) {
+			if err := sc.requireTotpEnrollment(user); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
+
+// requireTotpEnrollment returns ErrTotpRequired if any organization
+// user is a member of requires two-factor authentication, but user
+// hasn't enabled it yet.
+func (sc *standardContext) requireTotpEnrollment(user *domain.User) error {
+	if user.TotpEnabled() {
+		return nil
+	}
+
+	organizations, err := stores.NewDbOrganizationStore(sc.Tx()).FindAllByUserUuidThroughMemberships(user.Uuid)
+	if err != nil {
+		return err
+	}
+
+	if organization := user.TotpRequiredBy(organizations); organization != nil {
+		sc.Log().Info().Msgf("user %s: two-factor authentication required by organization %s", user.Uuid, organization.Uuid)
+		return ErrTotpRequired
+	}
+
+	return nil
+}
+
+// requireSso returns ErrSsoRequired if any organization user is a
+// member of enforces single sign-on.  Sessions are only started by
+// the identity provider then, so this only concerns API tokens.
+func (sc *standardContext) requireSso(user *domain.User) error {
+	enforcedProviders, err := stores.NewDbSsoProviderStore(sc.Tx()).FindAllEnforcedByUserUuid(user.Uuid)
+	if err != nil {
+		return err
+	}
+
+	if len(enforcedProviders) > 0 {
+		sc.Log().Info().Msgf("user %s: single sign-on required by organization %s", user.Uuid, enforcedProviders[0].OrganizationUuid)
+		return ErrSsoRequired
+	}
+
+	return nil
+}
+
+// apiTokenFromRequest returns the API token sent either in the
+// x-harrow-api-token header or as a bearer token.
+func (sc *standardContext) apiTokenFromRequest() string {
+	if token := sc.R().Header.Get(http.CanonicalHeaderKey("x-harrow-api-token")); token != "" {
+		return token
+	}
+
+	authorization := sc.R().Header.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+		if domain.LooksLikeApiToken(token) {
+			return token
+		}
+	}
+
+	return ""
+}
+
+func (sc *standardContext) loadUserFromApiToken(plainToken string) error {
+	c := sc.Config()
+	user, token, err := CurrentApiTokenUser(&c, sc.Tx(), plainToken)
+	if err != nil {
+		return err
+	}
+
+	// Tokens are subject to the same organization policies as
+	// sessions, without the exemptions for enrolling in two-factor
+	// authentication: that happens in the browser.
+	if err := sc.requireTotpEnrollment(user); err != nil {
+		return err
+	}
+	if err := sc.requireSso(user); err != nil {
+		return err
+	}
+
+	clientAddress := sc.clientAddress()
+
+	// Only reported uses are persisted, so that requests made with
+	// a token don't all have to write to the database.
+	if token.MarkUsed(time.Now(), clientAddress) {
+		if err := stores.NewDbApiTokenStore(sc.Tx()).MarkUsed(token); err != nil {
+			return err
+		}
+		userUuid := user.Uuid
+		sc.EnqueueActivity(activities.ApiTokenUsed(token), &userUuid)
+	}
+
+	sc.u = user
+	sc.token = token
+	return nil
+}
+
+// clientAddress returns the address the request was sent from.
+func (sc *standardContext) clientAddress() string {
+	c := sc.Config()
+	return requestClientAddress(sc.R(), c.HttpConfig().TrustedProxies)
+}
+
+// requestClientAddress returns the address r was sent from.  The
+// X-Forwarded-For header is only considered for connections from one
+// of trustedProxies, since anybody else can send arbitrary headers.
+// The client is then the rightmost address in the header which is not
+// a trusted proxy itself.
+func requestClientAddress(r *http.Request, trustedProxies []string) string {
+	remote := domain.ParseClientAddress(r.RemoteAddr)
+	if remote == nil {
+		return r.RemoteAddr
+	}
+
+	if !isTrustedProxy(remote, trustedProxies) {
+		return remote.String()
+	}
+
+	hops := []string{}
+	for _, header := range r.Header[http.CanonicalHeaderKey("X-Forwarded-For")] {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	clientAddress := remote.String()
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		ip := domain.ParseClientAddress(hop)
+		if ip == nil {
+			return hop
+		}
+
+		clientAddress = ip.String()
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return clientAddress
+}
+
+// isTrustedProxy reports whether ip is one of trustedProxies, given
+// as addresses or networks in CIDR notation.
+func isTrustedProxy(ip net.IP, trustedProxies []string) bool {
+	for _, proxy := range trustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if trusted := net.ParseIP(proxy); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enforceIpAllowlists returns ErrIpNotAllowed if the request comes
+// from an address not on the allowlist of an organization it
+// concerns: for webhook deliveries the organization owning the
+// webhook, for everything else every organization the current user is
+// a member of.
+func (sc *standardContext) enforceIpAllowlists() error {
+	path := sc.R().URL.Path
+	if match := webhookDeliveryPath.FindStringSubmatch(path); match != nil {
+		return sc.enforceWebhookAllowlist(match[1])
+	}
+
+	if sc.u == nil || AllowedFromAnyAddress.MatchString(path) {
+		return nil
+	}
+
+	organizations, err := stores.NewDbOrganizationStore(sc.Tx()).FindAllByUserUuidThroughMemberships(sc.u.Uuid)
+	if err != nil {
+		return err
+	}
+
+	for _, organization := range organizations {
+		if err := sc.enforceAllowlist(organization, domain.IpAllowlistScopeApi); err != nil {
+			if err != ErrIpNotAllowed || !sc.canOverrideAllowlist(organization) {
+				return err
+			}
+			sc.EnqueueActivity(activities.IpAllowlistOverridden(organization, sc.clientAddress(), path), nil)
+		}
+	}
+
+	return nil
+}
+
+func (sc *standardContext) enforceWebhookAllowlist(slug string) error {
+	webhook, err := stores.NewDbWebhookStore(sc.Tx()).FindBySlug(slug)
+	if err != nil {
+		// Unknown webhooks are reported by the handler.
+		return nil
+	}
+
+	project, err := stores.NewDbProjectStore(sc.Tx()).FindByUuid(webhook.ProjectUuid)
+	if err != nil {
+		return err
+	}
+
+	organization, err := stores.NewDbOrganizationStore(sc.Tx()).FindByUuid(project.OrganizationUuid)
+	if err != nil {
+		return err
+	}
+
+	return sc.enforceAllowlist(organization, domain.IpAllowlistScopeWebhook)
+}
+
+// enforceAllowlist checks the request against the allowlist of
+// organization for scope.  Rejected requests are recorded right away,
+// since their transaction is never committed.
+func (sc *standardContext) enforceAllowlist(organization *domain.Organization, scope string) error {
+	allowlist, err := stores.NewDbIpAllowlistStore(sc.Tx()).FindAllowlist(organization.Uuid, scope)
+	if err != nil {
+		return err
+	}
+
+	clientAddress := sc.clientAddress()
+	if allowlist.Allows(clientAddress) {
+		return nil
+	}
+
+	sc.Log().Info().Msgf("organization %s: %s request from %s not allowed", organization.Uuid, scope, clientAddress)
+	activity := activities.IpAllowlistRejectedRequest(organization, scope, clientAddress, sc.R().URL.Path)
+	if sc.u != nil {
+		contextUserUuid := sc.u.Uuid
+		activity.ContextUserUuid = &contextUserUuid
+	}
+	activitySink.EmitActivity(activity)
+
+	return ErrIpNotAllowed
+}
+
+// canOverrideAllowlist reports whether the current user may bypass
+// organization's allowlist for this request.  Owners can always manage
+// the allowlist, so that they cannot lock themselves out.
+func (sc *standardContext) canOverrideAllowlist(organization *domain.Organization) bool {
+	if !ManagesIpAllowlist.MatchString(sc.R().URL.Path) {
+		return false
+	}
+
+	membership, err := stores.NewDbOrganizationMembershipStore(sc.Tx()).FindByOrganizationAndUserUuids(organization.Uuid, sc.u.Uuid)
+	if err != nil {
+		return false
+	}
+
+	return membership.Type == domain.MembershipTypeOwner
+}
+
 func (sc *standardContext) CommitTx() error {
-	return sc.Tx().Commit()
+	if err := sc.Tx().Commit(); err != nil {
+		return err
+	}
+
+	for _, fn := range sc.afterCommit {
+		if err := fn(); err != nil {
+			sc.Log().Error().Msgf("after commit: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (sc *standardContext) AfterCommit(fn func() error) {
+	sc.afterCommit = append(sc.afterCommit, fn)
 }
 
 func (sc *standardContext) RollbackTx() {
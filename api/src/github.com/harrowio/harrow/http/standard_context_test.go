@@ -1,12 +1,15 @@
 package http
 
 import (
+	"net/http"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/harrowio/harrow/config"
 	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
 	"github.com/harrowio/harrow/test_helpers"
 )
 
@@ -178,3 +181,444 @@ func Test_StandardContext_RequestContext_EnqueueActivity_recordsProvidedUserUuid
 		t.Errorf("*activity.ContextUserUuid = %s; want %s", got, want)
 	}
 }
+
+func Test_StandardContext_RequestContext_authenticatesWithBearerApiToken(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	token := domain.NewApiToken("ci", user.Uuid, nil, []string{"read-job"}, nil)
+	if _, err := stores.NewDbApiTokenStore(tx).Create(token); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := newRequest("GET", "/does-not-exist", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+
+	reqCtxt, err := ctxt.RequestContext(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u := reqCtxt.User(); u == nil || u.Uuid != user.Uuid {
+		t.Fatalf("reqCtxt.User() = %#v; want user %q", u, user.Uuid)
+	}
+
+	if allowed, _ := reqCtxt.Auth().CanUpdate(world.Job("default")); allowed {
+		t.Errorf("Expected token to restrict updating jobs")
+	}
+
+	if allowed, err := reqCtxt.Auth().CanRead(world.Job("default")); !allowed {
+		t.Errorf("Expected token to allow reading jobs: %s", err)
+	}
+
+	found := false
+	for _, activity := range reqCtxt.Activities() {
+		if activity.Name == "api-token.used" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Activity %q not found", "api-token.used")
+	}
+}
+
+func Test_StandardContext_RequestContext_returnsErrApiTokenRevoked_ifTokenIsRevoked(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	token := domain.NewApiToken("ci", world.User("default").Uuid, nil, []string{"read-job"}, nil)
+	store := stores.NewDbApiTokenStore(tx)
+	if _, err := store.Create(token); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RevokeByUuid(token.Uuid); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := newRequest("GET", "/does-not-exist", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Harrow-Api-Token", token.Token)
+
+	_, err = ctxt.RequestContext(nil, req)
+	if got, want := err, ErrApiTokenRevoked; !reflect.DeepEqual(got, want) {
+		t.Fatalf("err = %#v; want %#v", got, want)
+	}
+}
+
+func Test_StandardContext_RequestContext_returnsErrTotpRequired_ifRequiredByOrganization(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	session := setupTestLoginSession(t, tx, user)
+
+	tx.MustExec(`UPDATE organizations SET require_totp = true WHERE uuid = $1`, world.Organization("default").Uuid)
+
+	req, err := newAuthenticatedRequest(session.Uuid, "GET", "/does-not-exist", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ctxt.RequestContext(nil, req)
+	if got, want := err, ErrTotpRequired; !reflect.DeepEqual(got, want) {
+		t.Fatalf("err = %#v; want %#v", got, want)
+	}
+
+	req, err = newAuthenticatedRequest(session.Uuid, "PATCH", "/users/"+user.Uuid+"/mfa", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ctxt.RequestContext(nil, req); err != nil {
+		t.Fatalf("err = %#v; want nil", err)
+	}
+
+	for _, path := range []string{
+		"/projects/" + user.Uuid + "/sessions/",
+		"/jobs/api-features",
+		"/organizations/users/" + user.Uuid,
+		"/users/" + user.Uuid + "/mfa/jobs",
+	} {
+		req, err = newAuthenticatedRequest(session.Uuid, "GET", path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ctxt.RequestContext(nil, req)
+		if got, want := err, ErrTotpRequired; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: err = %#v; want %#v", path, got, want)
+		}
+	}
+}
+
+func Test_StandardContext_RequestContext_returnsErrTotpRequired_forApiTokens(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	token := domain.NewApiToken("ci", user.Uuid, nil, []string{"read-job"}, nil)
+	if _, err := stores.NewDbApiTokenStore(tx).Create(token); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.MustExec(`UPDATE organizations SET require_totp = true WHERE uuid = $1`, world.Organization("default").Uuid)
+
+	for _, path := range []string{"/does-not-exist", "/users/" + user.Uuid + "/mfa"} {
+		req, err := newRequest("GET", path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+
+		_, err = ctxt.RequestContext(nil, req)
+		if got, want := err, ErrTotpRequired; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: err = %#v; want %#v", path, got, want)
+		}
+	}
+}
+
+func Test_StandardContext_RequestContext_returnsErrSsoRequired_forApiTokens(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("other")
+	token := domain.NewApiToken("ci", user.Uuid, nil, []string{"read-job"}, nil)
+	if _, err := stores.NewDbApiTokenStore(tx).Create(token); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &domain.SsoProvider{
+		OrganizationUuid: world.Organization("default").Uuid,
+		Protocol:         domain.SsoProtocolOIDC,
+		Enforced:         true,
+		EmailDomains:     domain.SsoEmailDomains{"localhost"},
+		OidcIssuer:       "https://idp.example.com",
+		OidcClientId:     "harrow",
+		OidcClientSecret: "secret",
+	}
+	if _, err := stores.NewDbSsoProviderStore(tx).Create(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := newRequest("GET", "/does-not-exist", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Harrow-Api-Token", token.Token)
+
+	_, err = ctxt.RequestContext(nil, req)
+	if got, want := err, ErrSsoRequired; !reflect.DeepEqual(got, want) {
+		t.Fatalf("err = %#v; want %#v", got, want)
+	}
+}
+
+func Test_StandardContext_RequestContext_returnsErrIpNotAllowed_ifNotOnOrganizationAllowlist(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "127.0.0.1")
+	defer os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "")
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	session := setupTestLoginSession(t, tx, world.User("other"))
+
+	if _, err := stores.NewDbIpAllowlistStore(tx).Create(&domain.IpAllowlistEntry{
+		OrganizationUuid: world.Organization("default").Uuid,
+		Scope:            domain.IpAllowlistScopeApi,
+		Cidr:             "10.0.0.0/8",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := newAuthenticatedRequest(session.Uuid, "GET", "/does-not-exist", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = "127.0.0.1:40112"
+	req.Header.Set("X-Forwarded-For", "192.0.2.1")
+
+	sink := NewArrayActivitySink()
+	withActivitySink(sink, func() {
+		_, err = ctxt.RequestContext(nil, req)
+	})
+
+	if got, want := err, ErrIpNotAllowed; !reflect.DeepEqual(got, want) {
+		t.Fatalf("err = %#v; want %#v", got, want)
+	}
+
+	if got, want := len(sink.Emitted), 1; got != want {
+		t.Fatalf("len(sink.Emitted) = %d; want %d", got, want)
+	}
+
+	if got, want := sink.Emitted[0].Name, "ip-allowlist.rejected-request"; got != want {
+		t.Errorf("sink.Emitted[0].Name = %q; want %q", got, want)
+	}
+
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	if _, err := ctxt.RequestContext(nil, req); err != nil {
+		t.Fatalf("err = %#v; want nil", err)
+	}
+}
+
+func Test_StandardContext_RequestContext_exemptsOnlySessionsAndApiFeaturesFromAllowlist(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "127.0.0.1")
+	defer os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "")
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	session := setupTestLoginSession(t, tx, world.User("other"))
+
+	if _, err := stores.NewDbIpAllowlistStore(tx).Create(&domain.IpAllowlistEntry{
+		OrganizationUuid: world.Organization("default").Uuid,
+		Scope:            domain.IpAllowlistScopeApi,
+		Cidr:             "10.0.0.0/8",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		path string
+		want error
+	}{
+		{"/sessions/" + session.Uuid, nil},
+		{"/api-features", nil},
+		{"/projects/" + world.Project("private").Uuid + "/sessions/" + session.Uuid, ErrIpNotAllowed},
+		{"/jobs/api-features", ErrIpNotAllowed},
+		{"/sessions/" + session.Uuid + "/secrets", ErrIpNotAllowed},
+	}
+
+	for _, testcase := range testcases {
+		req, err := newAuthenticatedRequest(session.Uuid, "GET", testcase.path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "127.0.0.1:40112"
+		req.Header.Set("X-Forwarded-For", "192.0.2.1")
+
+		_, err = ctxt.RequestContext(nil, req)
+		if got, want := err, testcase.want; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: err = %#v; want %#v", testcase.path, got, want)
+		}
+	}
+}
+
+func Test_StandardContext_RequestContext_allowsOwnersToManageAllowlistFromAnyAddress(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "127.0.0.1")
+	defer os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "")
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	organization := world.Organization("default")
+
+	if _, err := stores.NewDbIpAllowlistStore(tx).Create(&domain.IpAllowlistEntry{
+		OrganizationUuid: organization.Uuid,
+		Scope:            domain.IpAllowlistScopeApi,
+		Cidr:             "10.0.0.0/8",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, who := range []string{"default", "other"} {
+		session := setupTestLoginSession(t, tx, world.User(who))
+		req, err := newAuthenticatedRequest(session.Uuid, "GET", "/organizations/"+organization.Uuid+"/ip-allowlist-entries", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "127.0.0.1:40112"
+		req.Header.Set("X-Forwarded-For", "192.0.2.1")
+
+		reqCtxt, err := ctxt.RequestContext(nil, req)
+		if who == "other" {
+			if got, want := err, ErrIpNotAllowed; !reflect.DeepEqual(got, want) {
+				t.Errorf("err = %#v; want %#v", got, want)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("err = %#v; want nil", err)
+		}
+
+		activities := reqCtxt.(*standardContext).Activities()
+		if got, want := len(activities), 1; got != want {
+			t.Fatalf("len(activities) = %d; want %d", got, want)
+		}
+
+		if got, want := activities[0].Name, "ip-allowlist.overridden"; got != want {
+			t.Errorf("activities[0].Name = %q; want %q", got, want)
+		}
+	}
+}
+
+func Test_StandardContext_RequestContext_ignoresForgedXForwardedFor(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	session := setupTestLoginSession(t, tx, world.User("other"))
+
+	if _, err := stores.NewDbIpAllowlistStore(tx).Create(&domain.IpAllowlistEntry{
+		OrganizationUuid: world.Organization("default").Uuid,
+		Scope:            domain.IpAllowlistScopeApi,
+		Cidr:             "10.0.0.0/8",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := newAuthenticatedRequest(session.Uuid, "GET", "/does-not-exist", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = "192.0.2.1:40112"
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+
+	_, err = ctxt.RequestContext(nil, req)
+	if got, want := err, ErrIpNotAllowed; !reflect.DeepEqual(got, want) {
+		t.Fatalf("err = %#v; want %#v", got, want)
+	}
+
+	os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "127.0.0.1")
+	defer os.Setenv("HAR_HTTP_TRUSTED_PROXIES", "")
+	req.RemoteAddr = "127.0.0.1:40112"
+	req.Header.Set("X-Forwarded-For", "10.1.2.3, 192.0.2.1")
+
+	_, err = ctxt.RequestContext(nil, req)
+	if got, want := err, ErrIpNotAllowed; !reflect.DeepEqual(got, want) {
+		t.Fatalf("err = %#v; want %#v", got, want)
+	}
+}
+
+func Test_requestClientAddress(t *testing.T) {
+	trustedProxies := []string{"127.0.0.1", "198.51.100.0/24"}
+
+	testcases := []struct {
+		remoteAddr    string
+		forwardedFor  []string
+		clientAddress string
+	}{
+		{"192.0.2.1:40112", nil, "192.0.2.1"},
+		{"192.0.2.1:40112", []string{"10.1.2.3"}, "192.0.2.1"},
+		{"127.0.0.1:40112", nil, "127.0.0.1"},
+		{"127.0.0.1:40112", []string{"10.1.2.3"}, "10.1.2.3"},
+		{"127.0.0.1:40112", []string{"10.1.2.3, 192.0.2.1"}, "192.0.2.1"},
+		{"127.0.0.1:40112", []string{"10.1.2.3, 192.0.2.1, 198.51.100.7"}, "192.0.2.1"},
+		{"127.0.0.1:40112", []string{"10.1.2.3", "192.0.2.1"}, "192.0.2.1"},
+		{"127.0.0.1:40112", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"127.0.0.1:40112", []string{"10.1.2.3, not-an-address"}, "not-an-address"},
+	}
+
+	for _, testcase := range testcases {
+		req, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = testcase.remoteAddr
+		for _, header := range testcase.forwardedFor {
+			req.Header.Add("X-Forwarded-For", header)
+		}
+
+		if got, want := requestClientAddress(req, trustedProxies), testcase.clientAddress; got != want {
+			t.Errorf("requestClientAddress(%q, %q) = %q; want %q", testcase.remoteAddr, testcase.forwardedFor, got, want)
+		}
+	}
+}
+
+func Test_StandardContext_RequestContext_enforcesWebhookAllowlistForDeliveries(t *testing.T) {
+	db := test_helpers.GetDbConnection(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	ctxt := NewStandardContextTx(db, tx, config.GetConfig(), test_helpers.NewMockKeyValueStore(), test_helpers.NewMockSecretKeyValueStore())
+	world := test_helpers.MustNewWorld(tx, t)
+	project := world.Project("public")
+
+	webhook := domain.NewWebhook(project.Uuid, world.User("default").Uuid, world.Job("default").Uuid, "deploy")
+	if _, err := stores.NewDbWebhookStore(tx).Create(webhook); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stores.NewDbIpAllowlistStore(tx).Create(&domain.IpAllowlistEntry{
+		OrganizationUuid: project.OrganizationUuid,
+		Scope:            domain.IpAllowlistScopeWebhook,
+		Cidr:             "192.30.252.0/22",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", "/wh/"+webhook.Slug, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = "198.51.100.7:40112"
+
+	_, err = ctxt.RequestContext(nil, req)
+	if got, want := err, ErrIpNotAllowed; !reflect.DeepEqual(got, want) {
+		t.Fatalf("err = %#v; want %#v", got, want)
+	}
+
+	req.RemoteAddr = "192.30.252.10:40112"
+	if _, err := ctxt.RequestContext(nil, req); err != nil {
+		t.Fatalf("err = %#v; want nil", err)
+	}
+}
@@ -115,6 +115,7 @@ func (self taskHandler) CreateUpdate(ctxt RequestContext) (err error) {
 	if isNew {
 		model = &domain.Task{}
 	}
+	previous := *model
 	copyTaskParams(params, model)
 
 	var uuid string
@@ -135,7 +136,7 @@ func (self taskHandler) CreateUpdate(ctxt RequestContext) (err error) {
 		}
 		err = store.Update(model)
 		uuid = model.Uuid
-		ctxt.EnqueueActivity(activities.TaskEdited(model), nil)
+		ctxt.EnqueueActivity(activities.TaskEdited(model).SetPrevious(&previous), nil)
 	}
 	if err != nil {
 		return err
@@ -85,6 +85,10 @@ func (self *patchUserParams) IsGenerateTotpSecret() bool {
 	return self.TotpGenerateSecret != nil && *self.TotpGenerateSecret
 }
 
+type recoveryCodesParams struct {
+	TotpToken int32 `json:"totpToken"`
+}
+
 func MountUserHandler(r *mux.Router, ctxt ServerContext) {
 
 	uh := &userHandler{}
@@ -113,6 +117,18 @@ func MountUserHandler(r *mux.Router, ctxt ServerContext) {
 		Name("user-verify-email")
 	related.Methods("PATCH").Path("/mfa").Handler(HandlerFunc(ctxt, uh.ChangeMFA)).
 		Name("user-change-mfa")
+	related.Methods("POST").Path("/recovery-codes").Handler(HandlerFunc(ctxt, uh.GenerateRecoveryCodes)).
+		Name("user-generate-recovery-codes")
+	related.Methods("GET").Path("/notification-preferences").Handler(HandlerFunc(ctxt, uh.NotificationPreferences)).
+		Name("user-notification-preferences")
+	related.Methods("PUT").Path("/notification-preferences").Handler(HandlerFunc(ctxt, uh.UpdateNotificationPreferences)).
+		Name("user-update-notification-preferences")
+
+	at := apiTokenHandler{}
+	related.Methods("GET").Path("/api-tokens").Handler(HandlerFunc(ctxt, at.IndexForUser)).
+		Name("user-api-tokens")
+	related.Methods("POST").Path("/api-tokens").Handler(HandlerFunc(ctxt, at.CreateForUser)).
+		Name("user-api-token-create")
 
 	// Item
 	item := root.Path("/{uuid}").Subrouter()
@@ -285,6 +301,7 @@ func (self userHandler) ChangeMFA(ctxt RequestContext) (err error) {
 		return err
 	}
 
+	recoveryCodes := []string(nil)
 	if params.IsEnableTotp() {
 		totp := *params.TotpToken
 		if len(fmt.Sprintf("%0d", totp)) != 6 {
@@ -300,6 +317,11 @@ func (self userHandler) ChangeMFA(ctxt RequestContext) (err error) {
 			return err
 		}
 
+		recoveryCodes, err = self.replaceRecoveryCodes(ctxt, user)
+		if err != nil {
+			return err
+		}
+
 	} else if params.IsGenerateTotpSecret() {
 		user.GenerateTotpSecret()
 		err = userStore.Update(user)
@@ -312,6 +334,17 @@ func (self userHandler) ChangeMFA(ctxt RequestContext) (err error) {
 			return err
 		}
 	} else if params.IsDisableTotp() {
+		organizations, err := stores.NewDbOrganizationStore(ctxt.Tx()).FindAllByUserUuidThroughMemberships(user.Uuid)
+		if err != nil {
+			return err
+		}
+
+		for _, organization := range organizations {
+			if organization.RequireTotp {
+				return domain.NewValidationError("totp", "required_by_organization")
+			}
+		}
+
 		err = user.DisableTotp(*params.TotpToken)
 		if err != nil {
 			return err
@@ -321,20 +354,167 @@ func (self userHandler) ChangeMFA(ctxt RequestContext) (err error) {
 		if err != nil {
 			return err
 		}
+
+		err = stores.NewDbRecoveryCodeStore(ctxt.Tx()).DeleteAllForUser(user.Uuid)
+		if err != nil {
+			return err
+		}
 	} else {
 		return domain.NewValidationError("totpToken", "required")
 	}
 
 	view := struct {
 		*domain.User
-		TotpSecret string `json:"totpSecret,omitempty"`
-	}{user, user.TotpSecret}
+		TotpSecret    string   `json:"totpSecret,omitempty"`
+		RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	}{user, user.TotpSecret, recoveryCodes}
 
 	writeAsJson(ctxt, view)
 
 	return err
 }
 
+// GenerateRecoveryCodes replaces all recovery codes of a user with a
+// fresh set.  Since the old codes stop working, a valid TOTP token is
+// required.
+func (self userHandler) GenerateRecoveryCodes(ctxt RequestContext) (err error) {
+
+	c := ctxt.Config()
+	userStore := stores.NewDbUserStore(ctxt.Tx(), &c)
+
+	user, err := userStore.FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(user); !allowed {
+		return err
+	}
+
+	params := recoveryCodesParams{}
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&params); err != nil {
+		return err
+	}
+
+	if !user.TotpEnabled() {
+		return domain.NewValidationError("totp", "not_enabled")
+	}
+
+	if !user.IsValidTotpToken(params.TotpToken) {
+		return domain.ErrTotpTokenNotValid
+	}
+
+	recoveryCodes, err := self.replaceRecoveryCodes(ctxt, user)
+	if err != nil {
+		return err
+	}
+
+	view := struct {
+		*domain.User
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}{user, recoveryCodes}
+
+	writeAsJson(ctxt, view)
+
+	return nil
+}
+
+func (self userHandler) replaceRecoveryCodes(ctxt RequestContext, user *domain.User) ([]string, error) {
+	plain, codes := domain.NewRecoveryCodes(user.Uuid)
+	if err := stores.NewDbRecoveryCodeStore(ctxt.Tx()).ReplaceAllForUser(user.Uuid, codes); err != nil {
+		return nil, err
+	}
+
+	ctxt.EnqueueActivity(activities.UserGeneratedRecoveryCodes(user), &user.Uuid)
+
+	return plain, nil
+}
+
+func (self userHandler) NotificationPreferences(ctxt RequestContext) error {
+
+	c := ctxt.Config()
+	user, err := stores.NewDbUserStore(ctxt.Tx(), &c).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(user); !allowed {
+		return err
+	}
+
+	preferences, err := stores.NewDbNotificationPreferencesStore(ctxt.Tx()).FindByUserUuid(user.Uuid)
+	if err != nil {
+		return err
+	}
+
+	writeAsJson(ctxt, preferences)
+
+	return nil
+}
+
+func (self userHandler) UpdateNotificationPreferences(ctxt RequestContext) error {
+
+	c := ctxt.Config()
+	user, err := stores.NewDbUserStore(ctxt.Tx(), &c).FindByUuid(ctxt.PathParameter("uuid"))
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(user); !allowed {
+		return err
+	}
+
+	preferences := domain.NewNotificationPreferences(user.Uuid)
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: preferences}); err != nil {
+		return err
+	}
+	preferences.UserUuid = user.Uuid
+	if preferences.MutedProjectUuids == nil {
+		preferences.MutedProjectUuids = []string{}
+	}
+
+	if err := preferences.Validate(); err != nil {
+		return err
+	}
+
+	projects := stores.NewDbProjectStore(ctxt.Tx())
+	for _, projectUuid := range preferences.MutedProjectUuids {
+		if !uuidhelper.IsValid(projectUuid) {
+			return domain.NewValidationError("mutedProjectUuids", "malformed")
+		}
+
+		// Projects the user cannot read are reported just like
+		// projects that do not exist, so as not to reveal which
+		// projects exist.
+		project, err := projects.FindByUuid(projectUuid)
+		if err != nil && !domain.IsNotFound(err) {
+			return err
+		}
+		if project == nil {
+			return domain.NewValidationError("mutedProjectUuids", "not_found")
+		}
+		if allowed, _ := ctxt.Auth().CanRead(project); !allowed {
+			return domain.NewValidationError("mutedProjectUuids", "not_found")
+		}
+	}
+
+	preferencesStore := stores.NewDbNotificationPreferencesStore(ctxt.Tx())
+	if err := preferencesStore.Save(preferences); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.UserChangedNotificationPreferences(user), &user.Uuid)
+
+	preferences, err = preferencesStore.FindByUserUuid(user.Uuid)
+	if err != nil {
+		return err
+	}
+
+	writeAsJson(ctxt, preferences)
+
+	return nil
+}
+
 func (self userHandler) Organizations(ctxt RequestContext) (err error) {
 
 	userUuid := ctxt.PathParameter("uuid")
@@ -34,6 +34,11 @@ func Test_UserHandler_Routing(t *testing.T) {
 		{"GET", "/users/:uuid", "user-show"},
 		{"POST", "/users/:uuid/verify-email", "user-verify-email"},
 		{"PATCH", "/users/:uuid/mfa", "user-change-mfa"},
+		{"POST", "/users/:uuid/recovery-codes", "user-generate-recovery-codes"},
+		{"GET", "/users/:uuid/notification-preferences", "user-notification-preferences"},
+		{"PUT", "/users/:uuid/notification-preferences", "user-update-notification-preferences"},
+		{"GET", "/users/:uuid/api-tokens", "user-api-tokens"},
+		{"POST", "/users/:uuid/api-tokens", "user-api-token-create"},
 	}
 
 	spec.run(r, t)
@@ -655,6 +660,174 @@ func Test_UserHandler_Patch_DisablesTotp(t *testing.T) {
 
 }
 
+func Test_UserHandler_Patch_returnsRecoveryCodes_IfTotpTokenIsValid(t *testing.T) {
+	test_helpers.Flaky(t)
+	h := NewHandlerTest(MountUserHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+
+	u := h.User()
+	u.GenerateTotpSecret()
+	userStore := stores.NewDbUserStore(h.Tx(), h.Config())
+	if err := userStore.Update(u); err != nil {
+		t.Fatal(err)
+	}
+
+	result := struct {
+		Subject struct {
+			RecoveryCodes []string `json:"recoveryCodes"`
+		} `json:"subject"`
+	}{}
+	h.ResultTo(&result)
+	h.Subject(u)
+	token := u.CurrentTotpToken()
+	h.Do("PATCH", h.UrlFor("mfa"), &patchUserParams{
+		TwoFactorAuthEnabled: true,
+		TotpToken:            &token,
+	})
+
+	if got, want := len(result.Subject.RecoveryCodes), domain.RecoveryCodeCount; got != want {
+		t.Fatalf("len(RecoveryCodes) = %d; want %d", got, want)
+	}
+
+	remaining, err := stores.NewDbRecoveryCodeStore(h.Tx()).CountUnusedByUserUuid(u.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := remaining, domain.RecoveryCodeCount; got != want {
+		t.Errorf("remaining = %d; want %d", got, want)
+	}
+}
+
+func Test_UserHandler_Patch_doesNotDisableTotp_IfRequiredByOrganization(t *testing.T) {
+	test_helpers.Flaky(t)
+	h := NewHandlerTest(MountUserHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+
+	organization := h.World().Organization("default")
+	organization.RequireTotp = true
+	if err := stores.NewDbOrganizationStore(h.Tx()).Update(organization); err != nil {
+		t.Fatal(err)
+	}
+
+	u := h.User()
+	u.GenerateTotpSecret()
+	u.EnableTotp(u.CurrentTotpToken())
+	userStore := stores.NewDbUserStore(h.Tx(), h.Config())
+	if err := userStore.Update(u); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Subject(u)
+	token := u.CurrentTotpToken()
+	h.Do("PATCH", h.UrlFor("mfa"), &patchUserParams{
+		TwoFactorAuthEnabled: false,
+		TotpToken:            &token,
+	})
+
+	if got, want := h.Response().StatusCode, StatusUnprocessableEntity; got != want {
+		t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+	}
+}
+
+func Test_UserHandler_GenerateRecoveryCodes_replacesExistingCodes(t *testing.T) {
+	test_helpers.Flaky(t)
+	h := NewHandlerTest(MountUserHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+
+	u := h.User()
+	u.GenerateTotpSecret()
+	u.EnableTotp(u.CurrentTotpToken())
+	userStore := stores.NewDbUserStore(h.Tx(), h.Config())
+	if err := userStore.Update(u); err != nil {
+		t.Fatal(err)
+	}
+
+	recoveryCodes := stores.NewDbRecoveryCodeStore(h.Tx())
+	oldCodes, codes := domain.NewRecoveryCodes(u.Uuid)
+	if err := recoveryCodes.ReplaceAllForUser(u.Uuid, codes); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Subject(u)
+	h.Do("POST", h.UrlFor("recovery-codes"), &recoveryCodesParams{
+		TotpToken: u.CurrentTotpToken(),
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+	}
+
+	if _, err := recoveryCodes.Use(u.Uuid, oldCodes[0]); err != domain.ErrRecoveryCodeNotValid {
+		t.Errorf("err = %v; want %v", err, domain.ErrRecoveryCodeNotValid)
+	}
+}
+
+func Test_UserHandler_UpdateNotificationPreferences_savesPreferences(t *testing.T) {
+	h := NewHandlerTest(MountUserHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+
+	u := h.User()
+	project := h.World().Project("public")
+	h.Subject(u)
+	h.Do("PUT", h.UrlFor("notification-preferences"), &halWrapper{
+		Subject: &domain.NotificationPreferences{
+			Channel:           domain.NotificationChannelSlack,
+			SlackWebhookURL:   "https://hooks.slack.com/services/T0/B0/x",
+			Timezone:          "Europe/Berlin",
+			QuietHoursStart:   "22:00",
+			QuietHoursEnd:     "07:00",
+			MutedProjectUuids: []string{project.Uuid},
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+	}
+
+	preferences, err := stores.NewDbNotificationPreferencesStore(h.Tx()).FindByUserUuid(u.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := preferences.Channel, domain.NotificationChannelSlack; got != want {
+		t.Errorf("preferences.Channel = %q; want %q", got, want)
+	}
+
+	if !preferences.IsMuted(project.Uuid) {
+		t.Errorf("preferences.IsMuted(%q) = false; want true", project.Uuid)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "user.changed-notification-preferences" {
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "user.changed-notification-preferences")
+}
+
+func Test_UserHandler_UpdateNotificationPreferences_rejectsUnknownChannels(t *testing.T) {
+	h := NewHandlerTest(MountUserHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("default")
+
+	h.Subject(h.User())
+	h.Do("PUT", h.UrlFor("notification-preferences"), &halWrapper{
+		Subject: &domain.NotificationPreferences{
+			Channel:  "carrier-pigeon",
+			Timezone: "UTC",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, StatusUnprocessableEntity; got != want {
+		t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+	}
+}
+
 func Test_UserOrganizationsHandler_Status200OkOnFoundUser_Owner(t *testing.T) {
 
 	ts, ctxt := setupHandlerTestServer(MountUserHandler, t)
@@ -1527,3 +1700,28 @@ func Test_UserHandler_VerifyEmail_doesNotRemoveAnUnverifiedEmailBlockForTheUser_
 		t.Errorf(`len(foundBlocks) = %v; want %v`, got, want)
 	}
 }
+
+func Test_UserHandler_UpdateNotificationPreferences_treatsUnreadableProjectsAsNotFound(t *testing.T) {
+	h := NewHandlerTest(MountUserHandler, t)
+	defer h.Cleanup()
+	h.LoginAs("non-member")
+
+	for _, projectUuid := range []string{h.World().Project("private").Uuid, uuidhelper.MustNewV4()} {
+		h.Subject(h.User())
+		h.Do("PUT", h.UrlFor("notification-preferences"), &halWrapper{
+			Subject: &domain.NotificationPreferences{
+				Channel:           domain.NotificationChannelEmail,
+				Timezone:          "UTC",
+				MutedProjectUuids: []string{projectUuid},
+			},
+		})
+
+		if got, want := h.Response().StatusCode, StatusUnprocessableEntity; got != want {
+			t.Fatalf("Response().StatusCode = %d; want %d", got, want)
+		}
+
+		if got, want := string(h.ResponseBody()), `"not_found"`; !strings.Contains(got, want) {
+			t.Errorf("ResponseBody() = %s; want it to contain %s", got, want)
+		}
+	}
+}
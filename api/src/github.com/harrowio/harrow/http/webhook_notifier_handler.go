@@ -0,0 +1,272 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/harrowio/harrow/activities"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+// webhookNotifierAttemptsLimit is the number of attempts shown for a
+// webhook notifier.
+const webhookNotifierAttemptsLimit = 100
+
+// webhookNotifierParams carries the secret of a webhook notifier,
+// which is not part of its regular JSON representation.  It is
+// accepted when creating and updating a notifier and returned only
+// once, in the response to creating it.
+type webhookNotifierParams struct {
+	*domain.WebhookNotifier
+	Secret string `json:"secret"`
+}
+
+type WebhookNotifierHandler struct {
+	store   *stores.DbWebhookNotifierStore
+	subject *domain.WebhookNotifier
+}
+
+func (h *WebhookNotifierHandler) init(ctxt RequestContext) (*WebhookNotifierHandler, error) {
+	handler := &WebhookNotifierHandler{}
+	handler.store = stores.NewDbWebhookNotifierStore(ctxt.Tx())
+
+	uuid := ctxt.PathParameter("uuid")
+	if uuid != "" {
+		subject, err := handler.store.FindByUuid(uuid)
+		if err != nil {
+			return nil, err
+		}
+		handler.subject = subject
+	}
+
+	return handler, nil
+}
+
+func MountWebhookNotifierHandler(r *mux.Router, ctxt ServerContext) {
+	h := &WebhookNotifierHandler{}
+
+	root := r.PathPrefix("/webhook-notifiers").Subrouter()
+
+	// Collection
+	root.Methods("POST").Handler(HandlerFunc(ctxt, h.Create)).
+		Name("webhook-notifiers-create")
+	root.Methods("PUT").Handler(HandlerFunc(ctxt, h.Update)).
+		Name("webhook-notifiers-update")
+
+	// Relationships
+	related := root.PathPrefix("/{uuid}/").Subrouter()
+	related.Methods("GET").Path("/attempts").Handler(HandlerFunc(ctxt, h.Attempts)).
+		Name("webhook-notifiers-attempts")
+	related.Methods("GET").Path("/attempts/{attemptUuid}").Handler(HandlerFunc(ctxt, h.Attempt)).
+		Name("webhook-notifiers-attempt")
+
+	// Item
+	item := root.PathPrefix("/{uuid}").Subrouter()
+	item.Methods("GET").Handler(HandlerFunc(ctxt, h.Show)).
+		Name("webhook-notifiers-show")
+	item.Methods("DELETE").Handler(HandlerFunc(ctxt, h.Archive)).
+		Name("webhook-notifiers-archive")
+
+}
+
+func (h *WebhookNotifierHandler) Create(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	params := &webhookNotifierParams{WebhookNotifier: new(domain.WebhookNotifier)}
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: params}); err != nil {
+		return err
+	}
+	self := params.WebhookNotifier
+	self.Secret = params.Secret
+
+	if allowed, err := ctxt.Auth().CanCreate(self); !allowed {
+		return err
+	}
+
+	if self.Secret == "" {
+		self.GenerateSecret()
+	}
+
+	if err := self.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := h.store.Create(self); err != nil {
+		return err
+	}
+	ctxt.EnqueueActivity(activities.WebhookNotifierCreated(self), nil)
+	ctxt.W().Header().Set("Location", urlForSubject(ctxt.R(), self))
+	ctxt.W().WriteHeader(http.StatusCreated)
+	writeAsJson(ctxt, &webhookNotifierParams{WebhookNotifier: self, Secret: self.Secret})
+
+	return nil
+}
+
+func (h *WebhookNotifierHandler) Update(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	params := &webhookNotifierParams{WebhookNotifier: new(domain.WebhookNotifier)}
+	if err := json.NewDecoder(ctxt.R().Body).Decode(&halWrapper{Subject: params}); err != nil {
+		return err
+	}
+	newVersion := params.WebhookNotifier
+	newVersion.Secret = params.Secret
+
+	h.subject, err = h.store.FindByUuid(newVersion.Uuid)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanUpdate(h.subject); !allowed {
+		return err
+	}
+
+	previous := h.subject.WithoutSecret()
+
+	h.subject.Name = newVersion.Name
+	h.subject.URL = newVersion.URL
+	h.subject.BodyTemplate = newVersion.BodyTemplate
+	if newVersion.Secret != "" {
+		h.subject.Secret = newVersion.Secret
+	}
+	if err := h.subject.Validate(); err != nil {
+		return err
+	}
+
+	if err := h.store.Update(h.subject); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.WebhookNotifierEdited(h.subject).SetPrevious(previous), nil)
+	writeAsJson(ctxt, h.subject)
+
+	return nil
+}
+
+func (h *WebhookNotifierHandler) Show(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(h.subject); !allowed {
+		return err
+	}
+
+	writeAsJson(ctxt, h.subject)
+
+	return nil
+}
+
+func (h *WebhookNotifierHandler) Archive(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanArchive(h.subject); !allowed {
+		return err
+	}
+
+	if err := h.store.ArchiveByUuid(h.subject.Uuid); err != nil {
+		return err
+	}
+
+	ctxt.EnqueueActivity(activities.WebhookNotifierDeleted(h.subject), nil)
+
+	ctxt.W().WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (h *WebhookNotifierHandler) Attempts(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(h.subject); !allowed {
+		return err
+	}
+
+	attempts, err := h.store.FindAllAttemptsByWebhookNotifierUuid(h.subject.Uuid, webhookNotifierAttemptsLimit)
+	if err != nil {
+		return err
+	}
+
+	result := []interface{}{}
+	for _, attempt := range attempts {
+		result = append(result, attempt)
+	}
+
+	writeCollectionPageAsJson(ctxt, &CollectionPage{
+		Total:      len(result),
+		Count:      len(result),
+		Collection: result,
+	})
+
+	return nil
+}
+
+func (h *WebhookNotifierHandler) Attempt(ctxt RequestContext) error {
+
+	if ctxt.User() == nil {
+		return ErrLoginRequired
+	}
+
+	h, err := h.init(ctxt)
+	if err != nil {
+		return err
+	}
+
+	if allowed, err := ctxt.Auth().CanRead(h.subject); !allowed {
+		return err
+	}
+
+	attempt, err := h.store.FindAttemptByUuid(ctxt.PathParameter("attemptUuid"))
+	if err != nil {
+		return err
+	}
+
+	if attempt.WebhookNotifierUuid != h.subject.Uuid {
+		return new(domain.NotFoundError)
+	}
+
+	writeAsJson(ctxt, attempt)
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+)
+
+func Test_WebhookNotifierHandler_Routing(t *testing.T) {
+	r := mux.NewRouter()
+	MountWebhookNotifierHandler(r, nil)
+
+	spec := routingSpec{
+		{"POST", "/webhook-notifiers", "webhook-notifiers-create"},
+		{"PUT", "/webhook-notifiers", "webhook-notifiers-update"},
+		{"GET", "/webhook-notifiers/:uuid", "webhook-notifiers-show"},
+		{"DELETE", "/webhook-notifiers/:uuid", "webhook-notifiers-archive"},
+		{"GET", "/webhook-notifiers/:uuid/attempts", "webhook-notifiers-attempts"},
+		{"GET", "/webhook-notifiers/:uuid/attempts/:attempt", "webhook-notifiers-attempt"},
+	}
+
+	spec.run(r, t)
+}
+
+func Test_WebhookNotifierHandler_Create_generatesSecret(t *testing.T) {
+	h := NewHandlerTest(MountWebhookNotifierHandler, t)
+	defer h.Cleanup()
+
+	result := struct {
+		Subject struct {
+			Uuid   string
+			Secret string
+		}
+	}{}
+	h.ResultTo(&result)
+	h.LoginAs("default")
+	h.Do("POST", h.Url("/webhook-notifiers"), &halWrapper{
+		Subject: &domain.WebhookNotifier{
+			Name:        "CI dashboard",
+			ProjectUuid: h.World().Project("public").Uuid,
+			URL:         "https://example.com/harrow",
+		},
+	})
+
+	if got, want := h.Response().StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if result.Subject.Secret == "" {
+		t.Errorf("expected a secret to be generated")
+	}
+
+	if _, err := stores.NewDbWebhookNotifierStore(h.Tx()).FindByUuid(result.Subject.Uuid); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, activity := range h.Activities() {
+		if activity.Name == "webhook-notifiers.created" {
+			if got := activity.Payload.(*domain.WebhookNotifier).Secret; got != "" {
+				t.Errorf("activity.Payload.Secret = %q; want it to be empty", got)
+			}
+			return
+		}
+	}
+
+	t.Fatalf("Activity %q not found", "webhook-notifiers.created")
+}
+
+func Test_WebhookNotifierHandler_Show_doesNotReturnSecret(t *testing.T) {
+	h := NewHandlerTest(MountWebhookNotifierHandler, t)
+	defer h.Cleanup()
+
+	notifier := &domain.WebhookNotifier{
+		Name:        "CI dashboard",
+		ProjectUuid: h.World().Project("public").Uuid,
+		URL:         "https://example.com/harrow",
+	}
+	notifier.GenerateSecret()
+	if _, err := stores.NewDbWebhookNotifierStore(h.Tx()).Create(notifier); err != nil {
+		t.Fatal(err)
+	}
+
+	h.LoginAs("default")
+	h.Do("GET", h.Url("/webhook-notifiers/"+notifier.Uuid), nil)
+
+	if got, want := h.Response().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("h.Response().StatusCode = %d; want %d\n%s", got, want, h.ResponseBody())
+	}
+
+	if body := string(h.ResponseBody()); strings.Contains(body, notifier.Secret) {
+		t.Errorf("response contains secret %q:\n%s", notifier.Secret, body)
+	}
+}
@@ -0,0 +1,169 @@
+// Package junit parses test reports in the JUnit XML format, as
+// emitted by most xUnit style test runners.
+//
+// Only the parts of the format that are needed for reporting results
+// per test case are understood: test suites (possibly nested), test
+// cases and their failure, error and skipped markers.
+package junit
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+
+	// maxMessageLength is the maximum number of bytes kept from a
+	// failure message.
+	maxMessageLength = 1024
+)
+
+var (
+	// ErrUnknownFormat is returned if the document is neither a
+	// <testsuites> nor a <testsuite> document.
+	ErrUnknownFormat = errors.New("junit: unknown report format")
+)
+
+// Result is the outcome of a single test case.
+type Result struct {
+	Suite     string  `json:"suite"`
+	ClassName string  `json:"className"`
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Duration  float64 `json:"duration"`
+	Message   string  `json:"message,omitempty"`
+}
+
+type testSuites struct {
+	Suites []*testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name      string       `xml:"name,attr"`
+	Suites    []*testSuite `xml:"testsuite"`
+	TestCases []*testCase  `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *problem `xml:"failure"`
+	Error     *problem `xml:"error"`
+	Skipped   *problem `xml:"skipped"`
+}
+
+type problem struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func (self *problem) String() string {
+	message := strings.TrimSpace(self.Message)
+	if message == "" {
+		message = strings.TrimSpace(self.Body)
+	}
+
+	if len(message) > maxMessageLength {
+		message = message[0:maxMessageLength]
+	}
+
+	return message
+}
+
+// Parse reads a JUnit XML report from src and returns the results of
+// all test cases found in it, in document order.
+func Parse(src io.Reader) ([]*Result, error) {
+	decoder := xml.NewDecoder(src)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, ErrUnknownFormat
+			}
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "testsuites":
+			suites := &testSuites{}
+			if err := decoder.DecodeElement(suites, &start); err != nil {
+				return nil, err
+			}
+			results := []*Result{}
+			for _, suite := range suites.Suites {
+				results = suite.collect(results)
+			}
+			return results, nil
+		case "testsuite":
+			suite := &testSuite{}
+			if err := decoder.DecodeElement(suite, &start); err != nil {
+				return nil, err
+			}
+			return suite.collect([]*Result{}), nil
+		default:
+			return nil, ErrUnknownFormat
+		}
+	}
+}
+
+func (self *testSuite) collect(results []*Result) []*Result {
+	for _, tc := range self.TestCases {
+		results = append(results, tc.result(self.Name))
+	}
+
+	for _, nested := range self.Suites {
+		results = nested.collect(results)
+	}
+
+	return results
+}
+
+func (self *testCase) result(suiteName string) *Result {
+	result := &Result{
+		Suite:     suiteName,
+		ClassName: self.ClassName,
+		Name:      self.Name,
+		Status:    StatusPassed,
+		Duration:  parseSeconds(self.Time),
+	}
+
+	switch {
+	case self.Failure != nil:
+		result.Status = StatusFailed
+		result.Message = self.Failure.String()
+	case self.Error != nil:
+		result.Status = StatusError
+		result.Message = self.Error.String()
+	case self.Skipped != nil:
+		result.Status = StatusSkipped
+		result.Message = self.Skipped.String()
+	}
+
+	return result
+}
+
+// parseSeconds parses the value of a "time" attribute.  Some test
+// runners use a thousands separator, so commas are ignored.  Malformed
+// values are treated as zero.
+func parseSeconds(value string) float64 {
+	value = strings.Replace(strings.TrimSpace(value), ",", "", -1)
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+
+	return seconds
+}
@@ -0,0 +1,88 @@
+package junit
+
+import (
+	"strings"
+	"testing"
+)
+
+const exampleReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="models" tests="3" time="1.5">
+    <testcase classname="models.UserTest" name="test_create" time="0.25"/>
+    <testcase classname="models.UserTest" name="test_login" time="1,000.5">
+      <failure message="expected true, got false">stack trace</failure>
+    </testcase>
+    <testcase classname="models.UserTest" name="test_pending">
+      <skipped/>
+    </testcase>
+    <testsuite name="nested">
+      <testcase classname="models.Nested" name="test_boom" time="0.1">
+        <error>NullPointerException</error>
+      </testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>
+`
+
+func TestParse_returnsOneResultPerTestCase(t *testing.T) {
+	results, err := Parse(strings.NewReader(exampleReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(results), 4; got != want {
+		t.Fatalf(`len(results) = %v; want %v`, got, want)
+	}
+
+	expected := []struct {
+		Suite, Name, Status, Message string
+		Duration                     float64
+	}{
+		{"models", "test_create", StatusPassed, "", 0.25},
+		{"models", "test_login", StatusFailed, "expected true, got false", 1000.5},
+		{"models", "test_pending", StatusSkipped, "", 0},
+		{"nested", "test_boom", StatusError, "NullPointerException", 0.1},
+	}
+
+	for i, want := range expected {
+		got := results[i]
+		if got.Suite != want.Suite {
+			t.Errorf(`results[%d].Suite = %q; want %q`, i, got.Suite, want.Suite)
+		}
+		if got.Name != want.Name {
+			t.Errorf(`results[%d].Name = %q; want %q`, i, got.Name, want.Name)
+		}
+		if got.Status != want.Status {
+			t.Errorf(`results[%d].Status = %q; want %q`, i, got.Status, want.Status)
+		}
+		if got.Message != want.Message {
+			t.Errorf(`results[%d].Message = %q; want %q`, i, got.Message, want.Message)
+		}
+		if got.Duration != want.Duration {
+			t.Errorf(`results[%d].Duration = %v; want %v`, i, got.Duration, want.Duration)
+		}
+	}
+}
+
+func TestParse_acceptsASingleTestSuiteAsRootElement(t *testing.T) {
+	report := `<testsuite name="single"><testcase classname="a" name="b"/></testsuite>`
+	results, err := Parse(strings.NewReader(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(results), 1; got != want {
+		t.Fatalf(`len(results) = %v; want %v`, got, want)
+	}
+
+	if got, want := results[0].Suite, "single"; got != want {
+		t.Errorf(`results[0].Suite = %q; want %q`, got, want)
+	}
+}
+
+func TestParse_returnsErrUnknownFormat_forOtherDocuments(t *testing.T) {
+	_, err := Parse(strings.NewReader(`<html></html>`))
+	if got, want := err, ErrUnknownFormat; got != want {
+		t.Errorf(`err = %v; want %v`, got, want)
+	}
+}
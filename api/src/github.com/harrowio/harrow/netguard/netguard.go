@@ -0,0 +1,105 @@
+// Package netguard keeps requests to URLs supplied by users from
+// reaching hosts on internal networks.
+package netguard
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ForbiddenAddressError is returned for requests to addresses that
+// are not publicly routable.
+type ForbiddenAddressError struct {
+	Host    string
+	Address net.IP
+}
+
+func (self *ForbiddenAddressError) Error() string {
+	if self.Host == "" || self.Host == self.Address.String() {
+		return fmt.Sprintf("netguard: address %s is not public", self.Address)
+	}
+
+	return fmt.Sprintf("netguard: host %s resolves to %s, which is not public", self.Host, self.Address)
+}
+
+// sharedAddressSpace is the range used for carrier-grade NAT, RFC 6598.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublic reports whether ip is publicly routable, i.e. neither a
+// loopback, private, link-local, multicast nor unspecified address.
+func IsPublic(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// CheckHost resolves host and returns a *ForbiddenAddressError if any
+// of its addresses is not public.
+func CheckHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublic(ip) {
+			return &ForbiddenAddressError{Host: host, Address: ip}
+		}
+		return nil
+	}
+
+	addresses, err := net.LookupIP(host)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range addresses {
+		if !IsPublic(ip) {
+			return &ForbiddenAddressError{Host: host, Address: ip}
+		}
+	}
+
+	return nil
+}
+
+// NewClient returns an HTTP client that refuses to connect to
+// addresses which are not public.  The address is checked when
+// connecting, after DNS resolution, so that hosts resolving to
+// different addresses over time cannot get around the check.
+// Proxies from the environment are not used, since the client could
+// not check the final address then.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); !IsPublic(ip) {
+				return &ForbiddenAddressError{Address: ip}
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package netguard
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	testcases := []struct {
+		address string
+		public  bool
+	}{
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"192.0.2.1", true},
+		{"2001:db8::1", true},
+	}
+
+	for _, testcase := range testcases {
+		if got, want := IsPublic(net.ParseIP(testcase.address)), testcase.public; got != want {
+			t.Errorf("IsPublic(%q) = %v; want %v", testcase.address, got, want)
+		}
+	}
+}
+
+func TestCheckHost_rejectsLoopback(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "localhost"} {
+		err := CheckHost(host)
+		if _, ok := err.(*ForbiddenAddressError); !ok {
+			t.Errorf("CheckHost(%q) = %v; want *ForbiddenAddressError", host, err)
+		}
+	}
+}
+
+func TestNewClient_refusesToConnectToLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request reached %s", r.URL)
+	}))
+	defer server.Close()
+
+	response, err := NewClient(time.Second).Get(server.URL)
+	if err == nil {
+		response.Body.Close()
+		t.Fatalf("expected an error")
+	}
+}
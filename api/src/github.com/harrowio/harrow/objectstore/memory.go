@@ -0,0 +1,72 @@
+package objectstore
+
+import "sync"
+
+// Memory is a Store keeping all objects in memory.  It is meant for
+// tests.
+type Memory struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		objects: map[string][]byte{},
+	}
+}
+
+func (self *Memory) Put(key string, data []byte) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	stored := make([]byte, len(data))
+	copy(stored, data)
+	self.objects[key] = stored
+	return nil
+}
+
+func (self *Memory) Get(key string) ([]byte, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	data, found := self.objects[key]
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	result := make([]byte, len(data))
+	copy(result, data)
+	return result, nil
+}
+
+func (self *Memory) Size(key string) (int64, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	data, found := self.objects[key]
+	if !found {
+		return 0, ErrNotFound
+	}
+
+	return int64(len(data)), nil
+}
+
+func (self *Memory) Delete(key string) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	delete(self.objects, key)
+	return nil
+}
+
+// Keys returns the keys of all stored objects in no particular order.
+func (self *Memory) Keys() []string {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	keys := make([]string, 0, len(self.objects))
+	for key := range self.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,22 @@
+// Package objectstore provides a minimal interface for storing whole
+// objects by key, backed by S3 compatible services or memory.
+package objectstore
+
+import "errors"
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("objectstore: object not found")
+
+// Store stores objects by key.  Objects are always read and written
+// as a whole.
+type Store interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Size(key string) (int64, error)
+	Delete(key string) error
+}
+
+// IsNotFound returns true if err signals a missing object.
+func IsNotFound(err error) bool {
+	return err == ErrNotFound
+}
@@ -0,0 +1,107 @@
+package objectstore
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3 is a Store keeping objects in a bucket of an S3 compatible
+// service.  All keys are placed below prefix.
+type S3 struct {
+	client *s3.S3
+	bucket string
+	prefix string
+}
+
+func NewS3(awsConfig *aws.Config, bucket, prefix string) (*S3, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("objectstore: no bucket configured")
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{
+		client: s3.New(sess),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (self *S3) Put(key string, data []byte) error {
+	_, err := self.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(self.bucket),
+		Key:    aws.String(self.key(key)),
+		Body:   bytes.NewReader(data),
+	})
+	return self.translateError(err)
+}
+
+func (self *S3) Get(key string) ([]byte, error) {
+	out, err := self.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(self.bucket),
+		Key:    aws.String(self.key(key)),
+	})
+	if err != nil {
+		return nil, self.translateError(err)
+	}
+	defer out.Body.Close()
+
+	return ioutil.ReadAll(out.Body)
+}
+
+func (self *S3) Size(key string) (int64, error) {
+	out, err := self.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(self.bucket),
+		Key:    aws.String(self.key(key)),
+	})
+	if err != nil {
+		return 0, self.translateError(err)
+	}
+
+	return aws.Int64Value(out.ContentLength), nil
+}
+
+func (self *S3) Delete(key string) error {
+	_, err := self.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(self.bucket),
+		Key:    aws.String(self.key(key)),
+	})
+	return self.translateError(err)
+}
+
+func (self *S3) key(key string) string {
+	return path.Join(self.prefix, key)
+}
+
+// translateError maps the different ways S3 reports missing objects
+// (NoSuchKey for GET, a bare 404 for HEAD) to ErrNotFound.
+func (self *S3) translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchBucket {
+		return err
+	}
+
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,181 @@
+package objectstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// fakeS3Server is a stand-in for MinIO that understands just enough
+// of the S3 API to store objects addressed path-style.  Signatures
+// are not verified.
+type fakeS3Server struct {
+	bucket  string
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func (self *fakeS3Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+	if parts[0] != self.bucket {
+		self.error(w, req, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+
+	switch req.Method {
+	case "PUT":
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			self.error(w, req, http.StatusInternalServerError, "InternalError")
+			return
+		}
+		self.objects[key] = data
+		w.WriteHeader(http.StatusOK)
+	case "GET", "HEAD":
+		data, found := self.objects[key]
+		if !found {
+			self.error(w, req, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+		w.WriteHeader(http.StatusOK)
+		if req.Method == "GET" {
+			w.Write(data)
+		}
+	case "DELETE":
+		delete(self.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		self.error(w, req, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (self *fakeS3Server) error(w http.ResponseWriter, req *http.Request, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if req.Method != "HEAD" {
+		fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+	}
+}
+
+func newTestS3(t *testing.T, bucket string) (*S3, *fakeS3Server, func()) {
+	fake := &fakeS3Server{
+		bucket:  "logs",
+		objects: map[string][]byte{},
+	}
+	server := httptest.NewServer(fake)
+	store, err := NewS3(&aws.Config{
+		Endpoint:         aws.String(server.URL),
+		Region:           aws.String("us-east-1"),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("key", "secret", ""),
+	}, bucket, "op-logs")
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return store, fake, server.Close
+}
+
+func TestS3_Put_storesObjectBelowPrefix(t *testing.T) {
+	store, fake, done := newTestS3(t, "logs")
+	defer done()
+
+	if err := store.Put("a.json.gz", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(fake.objects["op-logs/a.json.gz"]), "content"; got != want {
+		t.Errorf(`fake.objects["op-logs/a.json.gz"] = %q; want %q`, got, want)
+	}
+}
+
+func TestS3_Get_returnsStoredObject(t *testing.T) {
+	store, _, done := newTestS3(t, "logs")
+	defer done()
+
+	if err := store.Put("a.json.gz", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := store.Get("a.json.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(data), "content"; got != want {
+		t.Errorf(`string(data) = %q; want %q`, got, want)
+	}
+}
+
+func TestS3_Size_returnsObjectSize(t *testing.T) {
+	store, _, done := newTestS3(t, "logs")
+	defer done()
+
+	if err := store.Put("a.json.gz", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := store.Size("a.json.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := size, int64(len("content")); got != want {
+		t.Errorf(`size = %d; want %d`, got, want)
+	}
+}
+
+func TestS3_Delete_removesObject(t *testing.T) {
+	store, fake, done := newTestS3(t, "logs")
+	defer done()
+
+	if err := store.Put("a.json.gz", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete("a.json.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := fake.objects["op-logs/a.json.gz"]; found {
+		t.Errorf("Expected object to be deleted")
+	}
+}
+
+func TestS3_reportsMissingObjectsAsNotFound(t *testing.T) {
+	store, _, done := newTestS3(t, "logs")
+	defer done()
+
+	if _, err := store.Get("missing"); !IsNotFound(err) {
+		t.Errorf(`store.Get("missing"): err = %v; want %v`, err, ErrNotFound)
+	}
+
+	if _, err := store.Size("missing"); !IsNotFound(err) {
+		t.Errorf(`store.Size("missing"): err = %v; want %v`, err, ErrNotFound)
+	}
+}
+
+func TestS3_doesNotReportMissingBucketAsNotFound(t *testing.T) {
+	store, _, done := newTestS3(t, "other")
+	defer done()
+
+	if _, err := store.Get("a.json.gz"); err == nil || IsNotFound(err) {
+		t.Errorf(`store.Get("a.json.gz"): err = %v; want a bucket error`, err)
+	}
+}
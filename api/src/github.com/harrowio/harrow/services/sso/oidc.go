@@ -0,0 +1,365 @@
+package sso
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+// clockSkew is the tolerance when checking the expiry of ID tokens.
+const clockSkew = time.Minute
+
+// OIDCProvider signs users in using the OpenID Connect authorization
+// code flow.  ID tokens need to be signed with RS256.
+type OIDCProvider struct {
+	config      *domain.SsoProvider
+	callbackUrl string
+
+	// Now returns the current time, for checking the expiry of
+	// ID tokens.
+	Now func() time.Time
+}
+
+func NewOIDCProvider(config *domain.SsoProvider, callbackUrl string) *OIDCProvider {
+	return &OIDCProvider{
+		config:      config,
+		callbackUrl: callbackUrl,
+		Now:         time.Now,
+	}
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	IdToken string `json:"id_token"`
+}
+
+type oidcKeySet struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+type oidcClaims struct {
+	Issuer   string       `json:"iss"`
+	Subject  string       `json:"sub"`
+	Audience oidcAudience `json:"aud"`
+	Expiry   float64      `json:"exp"`
+	Nonce    string       `json:"nonce"`
+	Email    string       `json:"email"`
+	Name     string       `json:"name"`
+}
+
+// oidcAudience is the "aud" claim, which is either a single string
+// or a list of strings.
+type oidcAudience []string
+
+func (self *oidcAudience) UnmarshalJSON(data []byte) error {
+	single := ""
+	if err := json.Unmarshal(data, &single); err == nil {
+		*self = oidcAudience{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(self))
+}
+
+func (self oidcAudience) contains(audience string) bool {
+	for _, value := range self {
+		if value == audience {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *OIDCProvider) AuthURL(state string) (string, string, error) {
+	discovery, err := self.discover()
+	if err != nil {
+		return "", "", err
+	}
+
+	authUrl, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", newError("discovery", err)
+	}
+
+	nonce := randomString()
+	query := authUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", self.config.OidcClientId)
+	query.Set("redirect_uri", self.callbackUrl)
+	query.Set("scope", "openid email profile")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	authUrl.RawQuery = query.Encode()
+
+	return authUrl.String(), nonce, nil
+}
+
+func (self *OIDCProvider) Complete(params url.Values, nonce string) (*Identity, error) {
+	if reason := params.Get("error"); reason != "" {
+		return nil, newError("denied", fmt.Errorf("%s: %s", reason, params.Get("error_description")))
+	}
+
+	code := params.Get("code")
+	if code == "" {
+		return nil, newError("missing_code", errors.New("no authorization code"))
+	}
+
+	discovery, err := self.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := self.exchangeCode(discovery, code)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := self.verifySignature(discovery, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := oidcClaims{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, newError("invalid_id_token", err)
+	}
+
+	if err := self.verifyClaims(&claims, nonce); err != nil {
+		return nil, err
+	}
+
+	groups, err := self.groupsFrom(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		Name:    claims.Name,
+		Groups:  groups,
+	}, nil
+}
+
+func (self *OIDCProvider) discover() (*oidcDiscovery, error) {
+	issuer := strings.TrimRight(self.config.OidcIssuer, "/")
+	discovery := &oidcDiscovery{}
+	if err := self.getJSON(issuer+"/.well-known/openid-configuration", discovery); err != nil {
+		return nil, newError("discovery", err)
+	}
+
+	if got, want := strings.TrimRight(discovery.Issuer, "/"), issuer; got != want {
+		return nil, newError("discovery", fmt.Errorf("issuer = %q; want %q", got, want))
+	}
+
+	return discovery, nil
+}
+
+func (self *OIDCProvider) exchangeCode(discovery *oidcDiscovery, code string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", self.callbackUrl)
+
+	req, err := http.NewRequest("POST", discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", newError("token", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(self.config.OidcClientId), url.QueryEscape(self.config.OidcClientSecret))
+
+	response := oidcTokenResponse{}
+	if err := self.doJSON(req, &response); err != nil {
+		return "", newError("token", err)
+	}
+
+	if response.IdToken == "" {
+		return "", newError("token", errors.New("no id_token in response"))
+	}
+
+	return response.IdToken, nil
+}
+
+// verifySignature checks the signature of idToken against the keys
+// published by the issuer and returns the decoded payload.
+func (self *OIDCProvider) verifySignature(discovery *oidcDiscovery, idToken string) ([]byte, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, newError("invalid_id_token", errors.New("malformed token"))
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, newError("invalid_id_token", err)
+	}
+
+	if header.Alg != "RS256" {
+		return nil, newError("invalid_id_token", fmt.Errorf("unsupported algorithm %q", header.Alg))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, newError("invalid_id_token", err)
+	}
+
+	keys, err := self.fetchKeys(discovery, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	for _, key := range keys {
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+			payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, newError("invalid_id_token", err)
+			}
+			return payload, nil
+		}
+	}
+
+	return nil, newError("invalid_signature", errors.New("no matching key"))
+}
+
+func (self *OIDCProvider) fetchKeys(discovery *oidcDiscovery, kid string) ([]*rsa.PublicKey, error) {
+	keySet := oidcKeySet{}
+	if err := self.getJSON(discovery.JwksUri, &keySet); err != nil {
+		return nil, newError("jwks", err)
+	}
+
+	result := []*rsa.PublicKey{}
+	for _, key := range keySet.Keys {
+		if key.Kty != "RSA" || (kid != "" && key.Kid != kid) {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, newError("jwks", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, newError("jwks", err)
+		}
+
+		result = append(result, &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		})
+	}
+
+	return result, nil
+}
+
+func (self *OIDCProvider) verifyClaims(claims *oidcClaims, nonce string) error {
+	if got, want := strings.TrimRight(claims.Issuer, "/"), strings.TrimRight(self.config.OidcIssuer, "/"); got != want {
+		return newError("invalid_id_token", fmt.Errorf("iss = %q; want %q", got, want))
+	}
+
+	if !claims.Audience.contains(self.config.OidcClientId) {
+		return newError("invalid_id_token", fmt.Errorf("aud = %v; want %q", claims.Audience, self.config.OidcClientId))
+	}
+
+	expiresAt := time.Unix(int64(claims.Expiry), 0)
+	if !self.Now().Before(expiresAt.Add(clockSkew)) {
+		return newError("expired", fmt.Errorf("token expired at %s", expiresAt))
+	}
+
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return newError("nonce_mismatch", errors.New("nonce does not match"))
+	}
+
+	if claims.Subject == "" {
+		return newError("invalid_id_token", errors.New("no subject"))
+	}
+
+	return nil
+}
+
+// groupsFrom extracts the claim configured as groups attribute, which
+// can be either a single string or a list of strings.
+func (self *OIDCProvider) groupsFrom(payload []byte) ([]string, error) {
+	all := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &all); err != nil {
+		return nil, newError("invalid_id_token", err)
+	}
+
+	raw, found := all[self.config.GroupsAttribute]
+	if !found {
+		return []string{}, nil
+	}
+
+	groups := []string{}
+	if err := json.Unmarshal(raw, &groups); err == nil {
+		return groups, nil
+	}
+
+	group := ""
+	if err := json.Unmarshal(raw, &group); err != nil {
+		return nil, newError("invalid_groups", err)
+	}
+
+	return []string{group}, nil
+}
+
+func (self *OIDCProvider) getJSON(url string, dest interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	return self.doJSON(req, dest)
+}
+
+func (self *OIDCProvider) doJSON(req *http.Request, dest interface{}) error {
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: status %d: %s", req.Method, req.URL, resp.StatusCode, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+func decodeSegment(segment string, dest interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, dest)
+}
@@ -0,0 +1,137 @@
+package sso
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/services/sso/ssotest"
+)
+
+const testCallbackUrl = "https://harrow.example.com/api/sso/org/callback"
+
+func newTestOIDCProvider(t *testing.T) (*ssotest.OIDCProvider, *OIDCProvider, func()) {
+	idp := ssotest.NewOIDCProvider("harrow", "secret", ssotest.User{
+		Subject: "jane",
+		Email:   "jane@example.com",
+		Name:    "Jane Doe",
+		Groups:  []string{"developers"},
+	})
+	server := httptest.NewServer(idp)
+	idp.Issuer = server.URL
+
+	provider := NewOIDCProvider(&domain.SsoProvider{
+		Protocol:         domain.SsoProtocolOIDC,
+		GroupsAttribute:  "groups",
+		OidcIssuer:       server.URL,
+		OidcClientId:     "harrow",
+		OidcClientSecret: "secret",
+	}, testCallbackUrl)
+
+	return idp, provider, server.Close
+}
+
+// signIn follows the redirect of the identity provider and returns
+// the parameters it sends back to the callback URL.
+func signIn(t *testing.T, authUrl string) url.Values {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, err := client.Get(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	location, err := response.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return location.Query()
+}
+
+func TestOIDCProvider_Complete_returnsIdentityFromIdToken(t *testing.T) {
+	_, provider, cleanup := newTestOIDCProvider(t)
+	defer cleanup()
+
+	authUrl, nonce, err := provider.AuthURL("the-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := signIn(t, authUrl)
+	if got, want := params.Get("state"), "the-state"; got != want {
+		t.Errorf(`params.Get("state") = %q; want %q`, got, want)
+	}
+
+	identity, err := provider.Complete(params, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Identity{
+		Subject: "jane",
+		Email:   "jane@example.com",
+		Name:    "Jane Doe",
+		Groups:  []string{"developers"},
+	}
+	if !reflect.DeepEqual(identity, expected) {
+		t.Errorf(`identity = %#v; want %#v`, identity, expected)
+	}
+}
+
+func TestOIDCProvider_Complete_rejectsWrongNonce(t *testing.T) {
+	_, provider, cleanup := newTestOIDCProvider(t)
+	defer cleanup()
+
+	authUrl, _, err := provider.AuthURL("the-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = provider.Complete(signIn(t, authUrl), "another-nonce")
+	if ssoErr, ok := err.(*Error); !ok || ssoErr.Reason != "nonce_mismatch" {
+		t.Fatalf(`err = %v; want nonce_mismatch`, err)
+	}
+}
+
+func TestOIDCProvider_Complete_rejectsExpiredIdToken(t *testing.T) {
+	_, provider, cleanup := newTestOIDCProvider(t)
+	defer cleanup()
+	provider.Now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	authUrl, nonce, err := provider.AuthURL("the-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = provider.Complete(signIn(t, authUrl), nonce)
+	if ssoErr, ok := err.(*Error); !ok || ssoErr.Reason != "expired" {
+		t.Fatalf(`err = %v; want expired`, err)
+	}
+}
+
+func TestOIDCProvider_verifySignature_rejectsTokensSignedByOtherKeys(t *testing.T) {
+	_, provider, cleanup := newTestOIDCProvider(t)
+	defer cleanup()
+
+	impostor := ssotest.NewOIDCProvider("harrow", "secret", ssotest.User{Subject: "mallory"})
+	impostor.Issuer = provider.config.OidcIssuer
+	discovery, err := provider.discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = provider.verifySignature(discovery, impostor.IdToken("nonce"))
+	if ssoErr, ok := err.(*Error); !ok || ssoErr.Reason != "invalid_signature" {
+		t.Fatalf(`err = %v; want invalid_signature`, err)
+	}
+}
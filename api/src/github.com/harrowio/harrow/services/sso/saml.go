@@ -0,0 +1,110 @@
+package sso
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/harrowio/harrow/domain"
+	saml2 "github.com/russellhaering/gosaml2"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// SAMLProvider signs users in using the SAML 2.0 HTTP-Redirect binding
+// for requests and the HTTP-POST binding for responses.  Responses or
+// their assertions need to be signed with one of the certificates
+// configured for the provider.
+type SAMLProvider struct {
+	config *domain.SsoProvider
+	sp     *saml2.SAMLServiceProvider
+}
+
+func NewSAMLProvider(config *domain.SsoProvider, callbackUrl, entityId string) (*SAMLProvider, error) {
+	certificates, err := config.SamlCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SAMLProvider{
+		config: config,
+		sp: &saml2.SAMLServiceProvider{
+			IdentityProviderSSOURL:      config.SamlSsoUrl,
+			IdentityProviderIssuer:      config.SamlIssuer,
+			AssertionConsumerServiceURL: callbackUrl,
+			ServiceProviderIssuer:       entityId,
+			AudienceURI:                 entityId,
+			IDPCertificateStore:         &dsig.MemoryX509CertificateStore{Roots: certificates},
+			NameIdFormat:                saml2.NameIdFormatPersistent,
+			AllowMissingAttributes:      true,
+		},
+	}, nil
+}
+
+// AuthURL returns the ID of the authentication request as nonce.
+func (self *SAMLProvider) AuthURL(state string) (string, string, error) {
+	doc, err := self.sp.BuildAuthRequestDocument()
+	if err != nil {
+		return "", "", newError("authn_request", err)
+	}
+
+	requestId := doc.Root().SelectAttrValue("ID", "")
+	authUrl, err := self.sp.BuildAuthURLRedirect(state, doc)
+	if err != nil {
+		return "", "", newError("authn_request", err)
+	}
+
+	return authUrl, requestId, nil
+}
+
+func (self *SAMLProvider) Complete(params url.Values, nonce string) (*Identity, error) {
+	encodedResponse := params.Get("SAMLResponse")
+	if encodedResponse == "" {
+		return nil, newError("missing_response", errors.New("no SAMLResponse"))
+	}
+
+	info, err := self.sp.RetrieveAssertionInfo(encodedResponse)
+	if err != nil {
+		return nil, newError("invalid_response", err)
+	}
+
+	if info.WarningInfo.InvalidTime {
+		return nil, newError("expired", errors.New("assertion is not valid at this time"))
+	}
+
+	if info.WarningInfo.NotInAudience {
+		return nil, newError("invalid_response", fmt.Errorf("assertion is not meant for %q", self.sp.AudienceURI))
+	}
+
+	if got := self.inResponseTo(info); got == "" || got != nonce {
+		return nil, newError("nonce_mismatch", fmt.Errorf("InResponseTo = %q; want %q", got, nonce))
+	}
+
+	email := info.Values.Get("email")
+	if email == "" && strings.Contains(info.NameID, "@") {
+		email = info.NameID
+	}
+
+	groups := []string{}
+	if attribute, found := info.Values[self.config.GroupsAttribute]; found {
+		for _, value := range attribute.Values {
+			groups = append(groups, value.Value)
+		}
+	}
+
+	return &Identity{
+		Subject: info.NameID,
+		Email:   email,
+		Name:    info.Values.Get("name"),
+		Groups:  groups,
+	}, nil
+}
+
+func (self *SAMLProvider) inResponseTo(info *saml2.AssertionInfo) string {
+	subject := info.Assertions[0].Subject
+	if subject == nil || subject.SubjectConfirmation == nil || subject.SubjectConfirmation.SubjectConfirmationData == nil {
+		return ""
+	}
+
+	return subject.SubjectConfirmation.SubjectConfirmationData.InResponseTo
+}
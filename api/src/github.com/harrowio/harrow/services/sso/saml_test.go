@@ -0,0 +1,109 @@
+package sso
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/services/sso/ssotest"
+)
+
+const testEntityId = "https://harrow.example.com/api/sso"
+
+func newTestSAMLProvider(t *testing.T) (*ssotest.SAMLProvider, *SAMLProvider) {
+	idp := ssotest.NewSAMLProvider("https://idp.example.com", ssotest.User{
+		Subject: "jane",
+		Email:   "jane@example.com",
+		Name:    "Jane Doe",
+		Groups:  []string{"developers", "admins"},
+	})
+
+	provider, err := NewSAMLProvider(&domain.SsoProvider{
+		Protocol:        domain.SsoProtocolSAML,
+		GroupsAttribute: "groups",
+		SamlSsoUrl:      "https://idp.example.com/sso",
+		SamlIssuer:      "https://idp.example.com",
+		SamlCertificate: idp.CertificatePEM(),
+	}, testCallbackUrl, testEntityId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return idp, provider
+}
+
+func TestSAMLProvider_Complete_returnsIdentityFromAssertion(t *testing.T) {
+	idp, provider := newTestSAMLProvider(t)
+
+	authUrl, nonce, err := provider.AuthURL("the-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acsUrl, params, err := idp.Respond(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := acsUrl, testCallbackUrl; got != want {
+		t.Errorf(`acsUrl = %q; want %q`, got, want)
+	}
+
+	if got, want := params.Get("RelayState"), "the-state"; got != want {
+		t.Errorf(`params.Get("RelayState") = %q; want %q`, got, want)
+	}
+
+	identity, err := provider.Complete(params, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Identity{
+		Subject: "jane",
+		Email:   "jane@example.com",
+		Name:    "Jane Doe",
+		Groups:  []string{"developers", "admins"},
+	}
+	if !reflect.DeepEqual(identity, expected) {
+		t.Errorf(`identity = %#v; want %#v`, identity, expected)
+	}
+}
+
+func TestSAMLProvider_Complete_rejectsResponsesToOtherRequests(t *testing.T) {
+	idp, provider := newTestSAMLProvider(t)
+
+	authUrl, _, err := provider.AuthURL("the-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, params, err := idp.Respond(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = provider.Complete(params, "_another-request")
+	if ssoErr, ok := err.(*Error); !ok || ssoErr.Reason != "nonce_mismatch" {
+		t.Fatalf(`err = %v; want nonce_mismatch`, err)
+	}
+}
+
+func TestSAMLProvider_Complete_rejectsAssertionsSignedByOtherCertificates(t *testing.T) {
+	_, provider := newTestSAMLProvider(t)
+	impostor := ssotest.NewSAMLProvider("https://idp.example.com", ssotest.User{Subject: "mallory"})
+
+	authUrl, nonce, err := provider.AuthURL("the-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, params, err := impostor.Respond(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = provider.Complete(params, nonce)
+	if ssoErr, ok := err.(*Error); !ok || ssoErr.Reason != "invalid_response" {
+		t.Fatalf(`err = %v; want invalid_response`, err)
+	}
+}
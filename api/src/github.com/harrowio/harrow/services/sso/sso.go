@@ -0,0 +1,83 @@
+// Package sso implements signing in through the OpenID Connect issuer
+// or SAML identity provider an organization has configured.
+//
+// Signing in is a two step process: AuthURL returns the URL of the
+// identity provider to send the user to, together with a nonce that
+// needs to be remembered until the identity provider sends the user
+// back.  Complete then verifies the parameters the identity provider
+// sent back and returns the identity of the user.
+package sso
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+)
+
+// HTTPClient is used for talking to identity providers.
+var HTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Identity is a user as reported by an identity provider.
+type Identity struct {
+	// Subject uniquely identifies the user at the identity
+	// provider.
+	Subject string
+	Email   string
+	Name    string
+	Groups  []string
+}
+
+type Provider interface {
+	// AuthURL returns the URL to send the user to for signing in
+	// and a nonce to pass to Complete.  The identity provider
+	// returns state unchanged.
+	AuthURL(state string) (authUrl string, nonce string, err error)
+
+	// Complete verifies the parameters the identity provider
+	// sent back to the callback URL.
+	Complete(params url.Values, nonce string) (*Identity, error)
+}
+
+// Error is returned if the identity provider fails to authenticate
+// a user or returns an invalid response.
+type Error struct {
+	Reason string
+	Err    error
+}
+
+func (self *Error) Error() string {
+	return fmt.Sprintf("sso: %s: %s", self.Reason, self.Err)
+}
+
+func newError(reason string, err error) *Error {
+	return &Error{Reason: reason, Err: err}
+}
+
+// NewProvider returns the Provider implementing the protocol
+// configured for provider.  Identity providers send users back to
+// callbackUrl.  For SAML entityId identifies Harrow as the service
+// provider.
+func NewProvider(provider *domain.SsoProvider, callbackUrl, entityId string) (Provider, error) {
+	switch provider.Protocol {
+	case domain.SsoProtocolOIDC:
+		return NewOIDCProvider(provider, callbackUrl), nil
+	case domain.SsoProtocolSAML:
+		return NewSAMLProvider(provider, callbackUrl, entityId)
+	default:
+		return nil, fmt.Errorf("sso: unsupported protocol %q", provider.Protocol)
+	}
+}
+
+func randomString() string {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		panic("sso: " + err.Error())
+	}
+
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,217 @@
+// Package ssotest provides stand-in identity providers for testing
+// single sign-on without a real OpenID Connect issuer or SAML identity
+// provider.  The providers sign in a fixed user without asking for
+// credentials.
+package ssotest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// User is the user a stand-in identity provider signs in.
+type User struct {
+	Subject string
+	Email   string
+	Name    string
+	Groups  []string
+}
+
+// OIDCProvider is an OpenID Connect issuer.  Issuer needs to be set
+// to the URL the provider is served at, e.g.
+//
+//	idp := ssotest.NewOIDCProvider("harrow", "secret", user)
+//	server := httptest.NewServer(idp)
+//	idp.Issuer = server.URL
+type OIDCProvider struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	User         User
+
+	// TokenLifetime is the time until issued ID tokens expire.
+	TokenLifetime time.Duration
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]oidcAuthRequest
+}
+
+type oidcAuthRequest struct {
+	redirectUri string
+	nonce       string
+}
+
+func NewOIDCProvider(clientId, clientSecret string, user User) *OIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	return &OIDCProvider{
+		ClientId:      clientId,
+		ClientSecret:  clientSecret,
+		User:          user,
+		TokenLifetime: 5 * time.Minute,
+		key:           key,
+		codes:         map[string]oidcAuthRequest{},
+	}
+}
+
+func (self *OIDCProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		self.discovery(w, r)
+	case "/authorize":
+		self.authorize(w, r)
+	case "/token":
+		self.token(w, r)
+	case "/jwks":
+		self.jwks(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (self *OIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                self.Issuer,
+		"authorization_endpoint":                self.Issuer + "/authorize",
+		"token_endpoint":                        self.Issuer + "/token",
+		"jwks_uri":                              self.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+// authorize signs in User immediately and sends the browser back to
+// the client.
+func (self *OIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != self.ClientId {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	redirectUri, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectUri.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := randomHex()
+	self.mu.Lock()
+	self.codes[code] = oidcAuthRequest{
+		redirectUri: query.Get("redirect_uri"),
+		nonce:       query.Get("nonce"),
+	}
+	self.mu.Unlock()
+
+	params := redirectUri.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirectUri.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirectUri.String(), http.StatusFound)
+}
+
+func (self *OIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if !ok || clientId != self.ClientId || clientSecret != self.ClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostFormValue("code")
+	self.mu.Lock()
+	request, found := self.codes[code]
+	delete(self.codes, code)
+	self.mu.Unlock()
+
+	if !found || request.redirectUri != r.PostFormValue("redirect_uri") {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": randomHex(),
+		"token_type":   "Bearer",
+		"id_token":     self.IdToken(request.nonce),
+	})
+}
+
+func (self *OIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	publicKey := self.key.PublicKey
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "ssotest",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			},
+		},
+	})
+}
+
+// IdToken returns a signed ID token for User.
+func (self *OIDCProvider) IdToken(nonce string) string {
+	now := time.Now()
+	return self.SignClaims(map[string]interface{}{
+		"iss":    self.Issuer,
+		"sub":    self.User.Subject,
+		"aud":    self.ClientId,
+		"iat":    now.Unix(),
+		"exp":    now.Add(self.TokenLifetime).Unix(),
+		"nonce":  nonce,
+		"email":  self.User.Email,
+		"name":   self.User.Name,
+		"groups": self.User.Groups,
+	})
+}
+
+// SignClaims returns a JWT containing claims, signed with the
+// provider's key.
+func (self *OIDCProvider) SignClaims(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "ssotest", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, self.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		panic(fmt.Sprintf("ssotest: %s", err))
+	}
+}
+
+func randomHex() string {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,244 @@
+package ssotest
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const samlTimeFormat = "2006-01-02T15:04:05Z"
+
+// SAMLProvider is a SAML 2.0 identity provider accepting
+// authentication requests at /sso.  The assertions it issues are
+// signed with a self-signed certificate, available through
+// CertificatePEM.
+type SAMLProvider struct {
+	Issuer string
+	User   User
+
+	key         *rsa.PrivateKey
+	certificate []byte
+}
+
+type samlAuthRequest struct {
+	id                          string
+	issuer                      string
+	assertionConsumerServiceURL string
+}
+
+var samlPostForm = template.Must(template.New("saml-post").Parse(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form method="POST" action="{{.URL}}">
+<input type="hidden" name="SAMLResponse" value="{{.SAMLResponse}}">
+<input type="hidden" name="RelayState" value="{{.RelayState}}">
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>
+`))
+
+func NewSAMLProvider(issuer string, user User) *SAMLProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	certificateTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ssotest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, certificateTemplate, certificateTemplate, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+
+	return &SAMLProvider{
+		Issuer:      issuer,
+		User:        user,
+		key:         key,
+		certificate: certificate,
+	}
+}
+
+// CertificatePEM returns the certificate with which assertions are
+// signed.
+func (self *SAMLProvider) CertificatePEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: self.certificate}))
+}
+
+// GetKeyPair implements dsig.X509KeyStore.
+func (self *SAMLProvider) GetKeyPair() (*rsa.PrivateKey, []byte, error) {
+	return self.key, self.certificate, nil
+}
+
+// ServeHTTP signs in User immediately and posts the response back to
+// the service provider from the browser.
+func (self *SAMLProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/sso" {
+		http.NotFound(w, r)
+		return
+	}
+
+	acsUrl, params, err := self.Respond(r.URL.String())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	samlPostForm.Execute(w, map[string]string{
+		"URL":          acsUrl,
+		"SAMLResponse": params.Get("SAMLResponse"),
+		"RelayState":   params.Get("RelayState"),
+	})
+}
+
+// Respond answers the authentication request encoded in authUrl,
+// using the HTTP-Redirect binding.  It returns the URL of the service
+// provider's assertion consumer service and the parameters to post
+// there.
+func (self *SAMLProvider) Respond(authUrl string) (string, url.Values, error) {
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	request, err := self.parseAuthRequest(parsed.Query().Get("SAMLRequest"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	response, err := self.buildResponse(request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	params := url.Values{}
+	params.Set("SAMLResponse", base64.StdEncoding.EncodeToString(response))
+	params.Set("RelayState", parsed.Query().Get("RelayState"))
+
+	return request.assertionConsumerServiceURL, params, nil
+}
+
+func (self *SAMLProvider) parseAuthRequest(encoded string) (*samlAuthRequest, error) {
+	deflated, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("ssotest: SAMLRequest: %s", err)
+	}
+
+	data, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		return nil, fmt.Errorf("ssotest: SAMLRequest: %s", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, fmt.Errorf("ssotest: SAMLRequest: %s", err)
+	}
+
+	root := doc.Root()
+	if root == nil || root.Tag != "AuthnRequest" {
+		return nil, fmt.Errorf("ssotest: SAMLRequest: not an AuthnRequest")
+	}
+
+	result := &samlAuthRequest{
+		id:                          root.SelectAttrValue("ID", ""),
+		assertionConsumerServiceURL: root.SelectAttrValue("AssertionConsumerServiceURL", ""),
+	}
+	if issuer := root.FindElement("./Issuer"); issuer != nil {
+		result.issuer = issuer.Text()
+	}
+
+	return result, nil
+}
+
+func (self *SAMLProvider) buildResponse(request *samlAuthRequest) ([]byte, error) {
+	now := time.Now().UTC()
+	notOnOrAfter := now.Add(5 * time.Minute).Format(samlTimeFormat)
+
+	assertion := etree.NewElement("saml:Assertion")
+	assertion.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	assertion.CreateAttr("ID", "_"+randomHex())
+	assertion.CreateAttr("Version", "2.0")
+	assertion.CreateAttr("IssueInstant", now.Format(samlTimeFormat))
+	assertion.CreateElement("saml:Issuer").SetText(self.Issuer)
+
+	subject := assertion.CreateElement("saml:Subject")
+	nameId := subject.CreateElement("saml:NameID")
+	nameId.CreateAttr("Format", "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent")
+	nameId.SetText(self.User.Subject)
+	confirmation := subject.CreateElement("saml:SubjectConfirmation")
+	confirmation.CreateAttr("Method", "urn:oasis:names:tc:SAML:2.0:cm:bearer")
+	confirmationData := confirmation.CreateElement("saml:SubjectConfirmationData")
+	confirmationData.CreateAttr("InResponseTo", request.id)
+	confirmationData.CreateAttr("NotOnOrAfter", notOnOrAfter)
+	confirmationData.CreateAttr("Recipient", request.assertionConsumerServiceURL)
+
+	conditions := assertion.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotBefore", now.Add(-time.Minute).Format(samlTimeFormat))
+	conditions.CreateAttr("NotOnOrAfter", notOnOrAfter)
+	conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(request.issuer)
+
+	authnStatement := assertion.CreateElement("saml:AuthnStatement")
+	authnStatement.CreateAttr("AuthnInstant", now.Format(samlTimeFormat))
+	authnStatement.CreateElement("saml:AuthnContext").
+		CreateElement("saml:AuthnContextClassRef").
+		SetText("urn:oasis:names:tc:SAML:2.0:ac:classes:unspecified")
+
+	attributes := assertion.CreateElement("saml:AttributeStatement")
+	addSamlAttribute(attributes, "email", self.User.Email)
+	addSamlAttribute(attributes, "name", self.User.Name)
+	addSamlAttribute(attributes, "groups", self.User.Groups...)
+
+	signingContext := dsig.NewDefaultSigningContext(self)
+	signingContext.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	signedAssertion, err := signingContext.SignEnveloped(assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	response := etree.NewElement("samlp:Response")
+	response.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	response.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	response.CreateAttr("ID", "_"+randomHex())
+	response.CreateAttr("Version", "2.0")
+	response.CreateAttr("IssueInstant", now.Format(samlTimeFormat))
+	response.CreateAttr("Destination", request.assertionConsumerServiceURL)
+	response.CreateAttr("InResponseTo", request.id)
+	response.CreateElement("saml:Issuer").SetText(self.Issuer)
+	response.CreateElement("samlp:Status").
+		CreateElement("samlp:StatusCode").
+		CreateAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Success")
+	response.AddChild(signedAssertion)
+
+	doc := etree.NewDocument()
+	doc.SetRoot(response)
+	return doc.WriteToBytes()
+}
+
+func addSamlAttribute(statement *etree.Element, name string, values ...string) {
+	attribute := statement.CreateElement("saml:Attribute")
+	attribute.CreateAttr("Name", name)
+	for _, value := range values {
+		attribute.CreateElement("saml:AttributeValue").SetText(value)
+	}
+}
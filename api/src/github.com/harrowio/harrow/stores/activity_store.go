@@ -279,3 +279,79 @@ func (self *DbActivityStore) FindActivityById(id int) (*domain.Activity, error)
 
 	return result.toActivity()
 }
+
+// auditLogConditions restricts activities to those matching an
+// AuditLogFilter.  Activities recorded before they were marked with
+// an organization are found through their project.
+const auditLogConditions = `FROM activities a
+	LEFT JOIN projects p ON p.uuid::text = (a.extra->>'projectUuid')
+	WHERE ((a.extra->>'organizationUuid') = $1 OR p.organization_uuid = $1::uuid)
+	  AND a.name LIKE $2
+	  AND ($3 = '' OR a.context_user_uuid::text = $3)
+	  AND ($4 = '' OR (a.extra->>'projectUuid') = $4)
+	  AND ($5::timestamptz IS NULL OR a.occurred_on >= $5)
+	  AND ($6::timestamptz IS NULL OR a.occurred_on < $6)`
+
+// CountForAuditLog returns the number of activities matching filter.
+func (self *DbActivityStore) CountForAuditLog(filter *domain.AuditLogFilter) (int, error) {
+
+	total := 0
+	q := `SELECT COUNT(*) ` + auditLogConditions
+	err := self.tx.Get(&total, q,
+		filter.OrganizationUuid,
+		filter.NameLike(),
+		filter.ActorUuid,
+		filter.ProjectUuid,
+		filter.Since,
+		filter.Until,
+	)
+	if err != nil {
+		return 0, resolveErrType(err)
+	}
+
+	return total, nil
+}
+
+// AllForAuditLog calls handler for up to limit activities matching
+// filter, newest first, skipping the first offset of them.  A limit of
+// 0 calls handler for all remaining activities.
+func (self *DbActivityStore) AllForAuditLog(filter *domain.AuditLogFilter, offset, limit int, handler func(*domain.Activity) error) error {
+
+	q := `SELECT a.* ` + auditLogConditions + `
+	ORDER BY a.occurred_on DESC, a.id DESC
+	LIMIT NULLIF($7, 0)
+	OFFSET $8`
+
+	rows, err := self.tx.Queryx(q,
+		filter.OrganizationUuid,
+		filter.NameLike(),
+		filter.ActorUuid,
+		filter.ProjectUuid,
+		filter.Since,
+		filter.Until,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return resolveErrType(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := activity{}
+
+		if err := rows.StructScan(&row); err != nil {
+			return resolveErrType(err)
+		}
+		activity, err := row.toActivity()
+		if err != nil {
+			return resolveErrType(err)
+		}
+
+		if err := handler(activity); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
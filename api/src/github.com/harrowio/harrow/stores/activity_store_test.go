@@ -2,6 +2,7 @@ package stores_test
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"bytes"
@@ -133,3 +134,56 @@ func TestActivityStore_FindActivityByNameAndPayloadUuid_searches_by_organization
 		}
 	}
 }
+
+func TestActivityStore_AllForAuditLog_findsActivitiesOfOrganizationAndItsProjects(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	organization := world.Organization("default")
+	user := world.User("default")
+	store := stores.NewDbActivityStore(tx)
+
+	jobEdited := activities.JobEdited(world.Job("default")).SetProjectUuid(world.Project("public").Uuid)
+	jobEdited.ContextUserUuid = &user.Uuid
+	roleCreated := activities.CustomRoleCreated(&domain.CustomRole{OrganizationUuid: organization.Uuid}).SetOrganizationUuid(organization.Uuid)
+	elsewhere := activities.JobEdited(world.Job("default")).SetOrganizationUuid("2d37a9f5-4d0b-4ac8-9d3e-8c7a1c2f5a31")
+
+	for _, activity := range []*domain.Activity{jobEdited, roleCreated, elsewhere} {
+		if err := store.Store(activity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testcases := []struct {
+		filter *domain.AuditLogFilter
+		want   []string
+	}{
+		{&domain.AuditLogFilter{OrganizationUuid: organization.Uuid}, []string{"custom-role.created", "job.edited"}},
+		{&domain.AuditLogFilter{OrganizationUuid: organization.Uuid, NamePattern: "custom-role.*"}, []string{"custom-role.created"}},
+		{&domain.AuditLogFilter{OrganizationUuid: organization.Uuid, ActorUuid: user.Uuid}, []string{"job.edited"}},
+	}
+
+	for _, testcase := range testcases {
+		found := []string{}
+		err := store.AllForAuditLog(testcase.filter, 0, 0, func(activity *domain.Activity) error {
+			found = append(found, activity.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := strings.Join(found, ","), strings.Join(testcase.want, ","); got != want {
+			t.Errorf(`AllForAuditLog(%#v) = %q; want %q`, testcase.filter, got, want)
+		}
+
+		total, err := store.CountForAuditLog(testcase.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := total, len(testcase.want); got != want {
+			t.Errorf(`CountForAuditLog(%#v) = %d; want %d`, testcase.filter, got, want)
+		}
+	}
+}
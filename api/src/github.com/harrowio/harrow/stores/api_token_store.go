@@ -0,0 +1,146 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbApiTokenStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbApiTokenStore(tx *sqlx.Tx) *DbApiTokenStore {
+	return &DbApiTokenStore{tx: tx}
+}
+
+func (store *DbApiTokenStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbApiTokenStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbApiTokenStore) Create(subject *domain.ApiToken) (string, error) {
+
+	if subject.Uuid == "" {
+		subject.Uuid = uuidhelper.MustNewV4()
+	}
+
+	q := `INSERT INTO api_tokens (
+	  uuid,
+	  name,
+	  user_uuid,
+	  project_uuid,
+	  token_hash,
+	  token_prefix,
+	  capabilities,
+	  expires_at
+	) VALUES (
+	  :uuid,
+	  :name,
+	  :user_uuid,
+	  :project_uuid,
+	  :token_hash,
+	  :token_prefix,
+	  :capabilities,
+	  :expires_at
+	);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbApiTokenStore) FindByUuid(uuid string) (*domain.ApiToken, error) {
+
+	result := &domain.ApiToken{}
+	q := `SELECT * FROM api_tokens WHERE uuid = $1`
+	err := store.tx.Get(result, q, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindByToken looks up a token by its plain text value.
+func (store *DbApiTokenStore) FindByToken(token string) (*domain.ApiToken, error) {
+
+	result := &domain.ApiToken{}
+	q := `SELECT * FROM api_tokens WHERE token_hash = $1`
+	err := store.tx.Get(result, q, domain.HashApiToken(token))
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindAllByUserUuid returns the personal tokens of a user, including
+// revoked ones.
+func (store *DbApiTokenStore) FindAllByUserUuid(userUuid string) ([]*domain.ApiToken, error) {
+
+	result := []*domain.ApiToken{}
+	q := `SELECT * FROM api_tokens WHERE user_uuid = $1 AND project_uuid IS NULL ORDER BY created_at DESC`
+	if err := store.tx.Select(&result, q, userUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// FindAllByProjectUuid returns the tokens of a project, including
+// revoked ones.
+func (store *DbApiTokenStore) FindAllByProjectUuid(projectUuid string) ([]*domain.ApiToken, error) {
+
+	result := []*domain.ApiToken{}
+	q := `SELECT * FROM api_tokens WHERE project_uuid = $1 ORDER BY created_at DESC`
+	if err := store.tx.Select(&result, q, projectUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// MarkUsed persists when and from where subject has been used last.
+func (store *DbApiTokenStore) MarkUsed(subject *domain.ApiToken) error {
+
+	q := `UPDATE api_tokens SET last_used_at = :last_used_at, last_used_from = :last_used_from WHERE uuid = :uuid`
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbApiTokenStore) RevokeByUuid(uuid string) error {
+
+	q := `UPDATE api_tokens SET revoked_at = NOW() AT TIME ZONE 'UTC' WHERE uuid = $1 AND revoked_at IS NULL`
+	r, err := store.tx.Exec(q, uuid)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package stores_test
+
+import (
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+)
+
+func Test_DbApiTokenStore_FindByToken_findsTokenByPlainTextValue(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	store := stores.NewDbApiTokenStore(tx)
+	token := domain.NewApiToken("ci", world.User("default").Uuid, nil, []string{"read-job"}, nil)
+	if _, err := store.Create(token); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindByToken(token.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := found.Uuid, token.Uuid; got != want {
+		t.Errorf(`found.Uuid = %q; want %q`, got, want)
+	}
+
+	if got, want := []string(found.Capabilities), []string{"read-job"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf(`found.Capabilities = %v; want %v`, got, want)
+	}
+
+	if _, err := store.FindByToken(token.TokenHash); !domain.IsNotFound(err) {
+		t.Errorf(`FindByToken(hash): err = %v; want not found`, err)
+	}
+}
+
+func Test_DbApiTokenStore_FindAllByUserUuid_returnsOnlyPersonalTokens(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	store := stores.NewDbApiTokenStore(tx)
+	user := world.User("default")
+	project := world.Project("public")
+	personal := domain.NewApiToken("personal", user.Uuid, nil, []string{"read-job"}, nil)
+	projectToken := domain.NewApiToken("project", user.Uuid, &project.Uuid, []string{"read-job"}, nil)
+	for _, token := range []*domain.ApiToken{personal, projectToken} {
+		if _, err := store.Create(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := store.FindAllByUserUuid(user.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 1 || found[0].Uuid != personal.Uuid {
+		t.Errorf(`found = %v; want only %q`, found, personal.Uuid)
+	}
+}
+
+func Test_DbApiTokenStore_RevokeByUuid_setsRevokedAt(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	store := stores.NewDbApiTokenStore(tx)
+	token := domain.NewApiToken("ci", world.User("default").Uuid, nil, []string{"read-job"}, nil)
+	if _, err := store.Create(token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.RevokeByUuid(token.Uuid); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindByUuid(token.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !found.IsRevoked() {
+		t.Errorf("Expected token to be revoked")
+	}
+
+	if err := store.RevokeByUuid(token.Uuid); !domain.IsNotFound(err) {
+		t.Errorf(`second RevokeByUuid: err = %v; want not found`, err)
+	}
+}
@@ -0,0 +1,151 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+// chatNotifierColumns lists the columns of each project notifier table
+// that are written when creating or updating a notifier, apart from
+// its uuid.
+var chatNotifierColumns = map[string][]string{
+	domain.ChatNotifierTeams:      {"name", "url_host", "project_uuid", "webhook_url", "subject_template", "body_template"},
+	domain.ChatNotifierMattermost: {"name", "url_host", "project_uuid", "webhook_url", "subject_template", "body_template"},
+	domain.ChatNotifierDiscord:    {"name", "url_host", "project_uuid", "webhook_url", "subject_template", "body_template"},
+	domain.BlameNotifierType:      {"name", "url_host", "project_uuid"},
+}
+
+// DbChatNotifierStore stores the project notifiers of one type in the
+// table named after that type.
+type DbChatNotifierStore struct {
+	tx           *sqlx.Tx
+	log          logger.Logger
+	notifierType string
+	columns      []string
+}
+
+func NewDbChatNotifierStore(tx *sqlx.Tx, notifierType string) *DbChatNotifierStore {
+	columns, found := chatNotifierColumns[notifierType]
+	if !found {
+		panic(fmt.Sprintf("NewDbChatNotifierStore: unknown notifier type %q", notifierType))
+	}
+
+	return &DbChatNotifierStore{
+		tx:           tx,
+		notifierType: notifierType,
+		columns:      columns,
+	}
+}
+
+func (store *DbChatNotifierStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbChatNotifierStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbChatNotifierStore) Create(subject domain.ProjectNotifier) (string, error) {
+	base := subject.NotifierBase()
+	if base.Uuid == "" {
+		base.Uuid = uuidhelper.MustNewV4()
+	}
+
+	columns := append([]string{"uuid"}, store.columns...)
+	q := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (:%s);`,
+		store.notifierType,
+		strings.Join(columns, ", "),
+		strings.Join(columns, ", :"),
+	)
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return base.Uuid, nil
+}
+
+func (store *DbChatNotifierStore) Update(subject domain.ProjectNotifier) error {
+	if !uuidhelper.IsValid(subject.NotifierBase().Uuid) {
+		return &domain.NotFoundError{}
+	}
+
+	assignments := make([]string, len(store.columns))
+	for i, column := range store.columns {
+		assignments[i] = column + " = :" + column
+	}
+
+	q := fmt.Sprintf(`UPDATE %s SET %s WHERE uuid = :uuid AND archived_at IS NULL`,
+		store.notifierType,
+		strings.Join(assignments, ", "),
+	)
+
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbChatNotifierStore) FindByUuid(uuid string) (domain.ProjectNotifier, error) {
+	result := domain.NewProjectNotifier(store.notifierType)
+	q := fmt.Sprintf(`SELECT * FROM %s WHERE uuid = $1 AND archived_at IS NULL`, store.notifierType)
+	err := store.tx.Get(result, q, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (store *DbChatNotifierStore) FindByProjectUuid(uuid string) ([]domain.ProjectNotifier, error) {
+	result := []domain.ProjectNotifier{}
+	q := fmt.Sprintf(`SELECT * FROM %s WHERE project_uuid = $1 AND archived_at IS NULL`, store.notifierType)
+	rows, err := store.tx.Queryx(q, uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		notifier := domain.NewProjectNotifier(store.notifierType)
+		if err := rows.StructScan(notifier); err != nil {
+			return nil, err
+		}
+		result = append(result, notifier)
+	}
+
+	return result, rows.Err()
+}
+
+func (store *DbChatNotifierStore) ArchiveByUuid(uuid string) error {
+	q := fmt.Sprintf(`UPDATE %s SET archived_at = NOW() AT TIME ZONE 'UTC' WHERE uuid = $1`, store.notifierType)
+	r, err := store.tx.Exec(q, uuid)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
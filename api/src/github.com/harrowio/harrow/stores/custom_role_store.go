@@ -0,0 +1,109 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbCustomRoleStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbCustomRoleStore(tx *sqlx.Tx) *DbCustomRoleStore {
+	return &DbCustomRoleStore{tx: tx}
+}
+
+func (store *DbCustomRoleStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbCustomRoleStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbCustomRoleStore) Create(subject *domain.CustomRole) (string, error) {
+
+	if subject.Uuid == "" {
+		subject.Uuid = uuidhelper.MustNewV4()
+	}
+
+	q := `INSERT INTO custom_roles (uuid, organization_uuid, name, description, capabilities)
+	  VALUES (:uuid, :organization_uuid, :name, :description, :capabilities);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbCustomRoleStore) Update(subject *domain.CustomRole) error {
+
+	if !uuidhelper.IsValid(subject.Uuid) {
+		return &domain.NotFoundError{}
+	}
+
+	q := `UPDATE custom_roles SET
+	  name = :name,
+	  description = :description,
+	  capabilities = :capabilities
+	WHERE uuid = :uuid AND archived_at IS NULL`
+
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbCustomRoleStore) FindByUuid(uuid string) (*domain.CustomRole, error) {
+
+	result := &domain.CustomRole{}
+	q := `SELECT * FROM custom_roles WHERE uuid = $1 AND archived_at IS NULL`
+	err := store.tx.Get(result, q, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+func (store *DbCustomRoleStore) FindAllByOrganizationUuid(organizationUuid string) ([]*domain.CustomRole, error) {
+
+	result := []*domain.CustomRole{}
+	q := `SELECT * FROM custom_roles WHERE organization_uuid = $1 AND archived_at IS NULL ORDER BY name`
+	if err := store.tx.Select(&result, q, organizationUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+func (store *DbCustomRoleStore) ArchiveByUuid(uuid string) error {
+
+	q := `UPDATE custom_roles SET archived_at = NOW() AT TIME ZONE 'UTC' WHERE uuid = $1 AND archived_at IS NULL`
+	r, err := store.tx.Exec(q, uuid)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
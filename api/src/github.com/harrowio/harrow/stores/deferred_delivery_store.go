@@ -0,0 +1,51 @@
+package stores
+
+import (
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// DbDeferredDeliveryStore holds notifications back until the quiet
+// hours of their recipients are over.
+type DbDeferredDeliveryStore struct {
+	tx *sqlx.Tx
+}
+
+func NewDbDeferredDeliveryStore(tx *sqlx.Tx) *DbDeferredDeliveryStore {
+	return &DbDeferredDeliveryStore{tx: tx}
+}
+
+func (store *DbDeferredDeliveryStore) Create(delivery *domain.DeferredDelivery) error {
+
+	q := `INSERT INTO deferred_deliveries (uuid, user_uuid, kind, payload, deliver_at, created_at)
+        VALUES (:uuid, :user_uuid, :kind, :payload, :deliver_at, :created_at)`
+	_, err := store.tx.NamedExec(q, delivery)
+	return resolveErrType(err)
+}
+
+// FindAllDue returns the undelivered deliveries of kind that are due
+// at now, oldest first.  The rows are locked until the transaction
+// ends, so that concurrent workers skip them.
+func (store *DbDeferredDeliveryStore) FindAllDue(kind string, now time.Time) ([]*domain.DeferredDelivery, error) {
+
+	result := []*domain.DeferredDelivery{}
+	q := `SELECT * FROM deferred_deliveries
+        WHERE kind = $1
+          AND delivered_at IS NULL
+          AND deliver_at <= $2
+        ORDER BY deliver_at ASC
+        FOR UPDATE SKIP LOCKED`
+	if err := store.tx.Select(&result, q, kind, now); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+func (store *DbDeferredDeliveryStore) MarkDelivered(uuid string, deliveredAt time.Time) error {
+
+	_, err := store.tx.Exec(`UPDATE deferred_deliveries SET delivered_at = $2 WHERE uuid = $1`, uuid, deliveredAt)
+	return resolveErrType(err)
+}
@@ -108,8 +108,15 @@ func (self *DiskLogStore) createAppendLogLine(operationUuid, tepy string, logLin
 }
 
 func (self *DiskLogStore) getLogFileName(operationUuid, tepy string) string {
-	var pathParts = []string{self.logDir, "", "", operationUuid + "-" + tepy + ".txt"}
-	fmt.Sscanf(pathParts[3], "%4s%4s", &pathParts[1], &pathParts[2])
+	return path.Join(self.logDir, logFilePath(operationUuid, tepy))
+}
+
+// logFilePath returns the path of a log relative to the log
+// directory, spreading logs over subdirectories named after the
+// first eight characters of operationUuid.
+func logFilePath(operationUuid, tepy string) string {
+	var pathParts = []string{"", "", operationUuid + "-" + tepy + ".txt"}
+	fmt.Sscanf(pathParts[2], "%4s%4s", &pathParts[0], &pathParts[1])
 
 	return path.Join(pathParts...)
 }
@@ -39,12 +39,16 @@ func (store *DbEmailNotifierStore) Create(subject *domain.EmailNotifier) (string
 	  uuid,
           recipient,
           project_uuid,
-          url_host
+          url_host,
+          subject_template,
+          body_template
 	) VALUES (
 	  :uuid,
           :recipient,
           :project_uuid,
-          :url_host
+          :url_host,
+          :subject_template,
+          :body_template
 	);`
 
 	_, err := store.tx.NamedExec(q, subject)
@@ -65,7 +69,9 @@ func (store *DbEmailNotifierStore) Update(subject *domain.EmailNotifier) error {
 	  uuid = :uuid,
           recipient = :recipient,
           project_uuid = :project_uuid,
-          url_host = :url_host
+          url_host = :url_host,
+          subject_template = :subject_template,
+          body_template = :body_template
 	WHERE uuid = :uuid AND archived_at IS NULL`
 
 	r, err := store.tx.NamedExec(q, subject)
@@ -0,0 +1,117 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// DbFailedDeliveryStore records deliveries the notifier and the
+// mail-dispatcher failed at, including the dead letters.
+type DbFailedDeliveryStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbFailedDeliveryStore(tx *sqlx.Tx) *DbFailedDeliveryStore {
+	return &DbFailedDeliveryStore{tx: tx}
+}
+
+func (store *DbFailedDeliveryStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbFailedDeliveryStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbFailedDeliveryStore) Create(subject *domain.FailedDelivery) error {
+
+	q := `INSERT INTO failed_deliveries (uuid, source, kind, key, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at, dead_at, replayed_at)
+	  VALUES (:uuid, :source, :kind, :key, :payload, :status, :attempts, :last_error, :next_attempt_at, :created_at, :updated_at, :dead_at, :replayed_at)`
+
+	_, err := store.tx.NamedExec(q, subject)
+	return resolveErrType(err)
+}
+
+func (store *DbFailedDeliveryStore) Update(subject *domain.FailedDelivery) error {
+
+	q := `UPDATE failed_deliveries
+	  SET status = :status, attempts = :attempts, last_error = :last_error, next_attempt_at = :next_attempt_at,
+	      updated_at = :updated_at, dead_at = :dead_at, replayed_at = :replayed_at
+	  WHERE uuid = :uuid`
+
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbFailedDeliveryStore) FindByUuid(uuid string) (*domain.FailedDelivery, error) {
+
+	result := &domain.FailedDelivery{}
+	err := store.tx.Get(result, `SELECT * FROM failed_deliveries WHERE uuid = $1`, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindRetryingBySourceAndKey returns the delivery identified by key
+// that source is still retrying.
+func (store *DbFailedDeliveryStore) FindRetryingBySourceAndKey(source, key string) (*domain.FailedDelivery, error) {
+
+	result := &domain.FailedDelivery{}
+	q := `SELECT * FROM failed_deliveries WHERE source = $1 AND key = $2 AND status = $3 ORDER BY created_at DESC LIMIT 1`
+	err := store.tx.Get(result, q, source, key, domain.FailedDeliveryRetrying)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindAllByStatus returns the most recently updated deliveries with
+// status, newest first.  An empty source matches all sources.
+func (store *DbFailedDeliveryStore) FindAllByStatus(source, status string, limit int) ([]*domain.FailedDelivery, error) {
+
+	result := []*domain.FailedDelivery{}
+	q := `SELECT * FROM failed_deliveries
+	  WHERE status = $1 AND ($2 = '' OR source = $2)
+	  ORDER BY updated_at DESC
+	  LIMIT $3`
+	if err := store.tx.Select(&result, q, status, source, limit); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// FindAllReplaying returns the deliveries of source an administrator
+// asked to replay, oldest first.  The rows are locked until the
+// transaction ends, so that concurrent workers skip them.
+func (store *DbFailedDeliveryStore) FindAllReplaying(source string) ([]*domain.FailedDelivery, error) {
+
+	result := []*domain.FailedDelivery{}
+	q := `SELECT * FROM failed_deliveries
+	  WHERE status = $1 AND source = $2
+	  ORDER BY replayed_at ASC
+	  FOR UPDATE SKIP LOCKED`
+	if err := store.tx.Select(&result, q, domain.FailedDeliveryReplaying, source); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package stores_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+)
+
+func Test_FailedDeliveryStore_FindRetryingBySourceAndKey_ignoresDeadDeliveries(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	store := stores.NewDbFailedDeliveryStore(tx)
+	key := domain.MessageKey("activities", "42")
+	failed, err := domain.NewFailedDelivery(
+		domain.FailedDeliverySourceMailDispatcher,
+		domain.FailedDeliveryKindMessage,
+		key,
+		&domain.FailedMessage{Table: "activities", Id: "42"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed.RecordAttempt(errors.New("connection refused"), domain.FailedDeliveryMaxAttempts)
+	if err := store.Create(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindRetryingBySourceAndKey(domain.FailedDeliverySourceMailDispatcher, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := found.Uuid, failed.Uuid; got != want {
+		t.Errorf("found.Uuid = %q; want %q", got, want)
+	}
+
+	failed.GiveUp(failed.Attempts, errors.New("connection refused"))
+	if err := store.Update(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.FindRetryingBySourceAndKey(domain.FailedDeliverySourceMailDispatcher, key); !domain.IsNotFound(err) {
+		t.Fatalf("err = %v; want a not found error", err)
+	}
+
+	dead, err := store.FindAllByStatus("", domain.FailedDeliveryDead, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(dead), 1; got != want {
+		t.Fatalf("len(dead) = %d; want %d", got, want)
+	}
+}
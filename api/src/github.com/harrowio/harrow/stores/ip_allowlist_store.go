@@ -0,0 +1,99 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbIpAllowlistStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbIpAllowlistStore(tx *sqlx.Tx) *DbIpAllowlistStore {
+	return &DbIpAllowlistStore{tx: tx}
+}
+
+func (store *DbIpAllowlistStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbIpAllowlistStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbIpAllowlistStore) Create(subject *domain.IpAllowlistEntry) (string, error) {
+
+	if subject.Uuid == "" {
+		subject.Uuid = uuidhelper.MustNewV4()
+	}
+
+	q := `INSERT INTO ip_allowlist_entries (uuid, organization_uuid, scope, cidr, description)
+	  VALUES (:uuid, :organization_uuid, :scope, CAST(:cidr AS cidr), :description);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbIpAllowlistStore) FindByUuid(uuid string) (*domain.IpAllowlistEntry, error) {
+
+	result := &domain.IpAllowlistEntry{}
+	q := `SELECT uuid, organization_uuid, scope, cidr::text AS cidr, description, created_at
+	  FROM ip_allowlist_entries WHERE uuid = $1`
+	err := store.tx.Get(result, q, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+func (store *DbIpAllowlistStore) FindAllByOrganizationUuid(organizationUuid string) ([]*domain.IpAllowlistEntry, error) {
+
+	result := []*domain.IpAllowlistEntry{}
+	q := `SELECT uuid, organization_uuid, scope, cidr::text AS cidr, description, created_at
+	  FROM ip_allowlist_entries WHERE organization_uuid = $1 ORDER BY scope, cidr`
+	if err := store.tx.Select(&result, q, organizationUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// FindAllowlist returns the entries an organization has for scope.
+func (store *DbIpAllowlistStore) FindAllowlist(organizationUuid, scope string) (domain.IpAllowlist, error) {
+
+	result := domain.IpAllowlist{}
+	q := `SELECT uuid, organization_uuid, scope, cidr::text AS cidr, description, created_at
+	  FROM ip_allowlist_entries WHERE organization_uuid = $1 AND scope = $2 ORDER BY cidr`
+	if err := store.tx.Select(&result, q, organizationUuid, scope); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+func (store *DbIpAllowlistStore) DeleteByUuid(uuid string) error {
+
+	r, err := store.tx.Exec(`DELETE FROM ip_allowlist_entries WHERE uuid = $1`, uuid)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
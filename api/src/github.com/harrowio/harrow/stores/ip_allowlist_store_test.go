@@ -0,0 +1,48 @@
+package stores_test
+
+import (
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+)
+
+func Test_IpAllowlistStore_FindAllowlist_returnsEntriesForScope(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	organization := world.Organization("default")
+	store := stores.NewDbIpAllowlistStore(tx)
+
+	for _, entry := range []*domain.IpAllowlistEntry{
+		{OrganizationUuid: organization.Uuid, Scope: domain.IpAllowlistScopeApi, Cidr: "10.0.0.0/8"},
+		{OrganizationUuid: organization.Uuid, Scope: domain.IpAllowlistScopeWebhook, Cidr: "192.0.2.1/32"},
+	} {
+		if _, err := store.Create(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	allowlist, err := store.FindAllowlist(organization.Uuid, domain.IpAllowlistScopeApi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(allowlist), 1; got != want {
+		t.Fatalf("len(allowlist) = %d; want %d", got, want)
+	}
+
+	if got, want := allowlist[0].Cidr, "10.0.0.0/8"; got != want {
+		t.Errorf("allowlist[0].Cidr = %q; want %q", got, want)
+	}
+
+	if err := store.DeleteByUuid(allowlist[0].Uuid); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.FindByUuid(allowlist[0].Uuid); err == nil {
+		t.Errorf("expected entry to be deleted")
+	}
+}
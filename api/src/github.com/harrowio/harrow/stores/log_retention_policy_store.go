@@ -0,0 +1,150 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbLogRetentionPolicyStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbLogRetentionPolicyStore(tx *sqlx.Tx) *DbLogRetentionPolicyStore {
+	return &DbLogRetentionPolicyStore{tx: tx}
+}
+
+func (store *DbLogRetentionPolicyStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbLogRetentionPolicyStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbLogRetentionPolicyStore) Create(subject *domain.LogRetentionPolicy) (string, error) {
+
+	if subject.Uuid == "" {
+		subject.Uuid = uuidhelper.MustNewV4()
+	}
+
+	q := `INSERT INTO log_retention_policies (
+	  uuid,
+	  organization_uuid,
+	  project_uuid,
+	  keep_full_days,
+	  keep_tail_kb,
+	  delete_after_days
+	) VALUES (
+	  :uuid,
+	  :organization_uuid,
+	  :project_uuid,
+	  :keep_full_days,
+	  :keep_tail_kb,
+	  :delete_after_days
+	);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbLogRetentionPolicyStore) Update(subject *domain.LogRetentionPolicy) error {
+
+	if !uuidhelper.IsValid(subject.Uuid) {
+		return &domain.NotFoundError{}
+	}
+
+	q := `UPDATE log_retention_policies SET
+	  keep_full_days = :keep_full_days,
+	  keep_tail_kb = :keep_tail_kb,
+	  delete_after_days = :delete_after_days
+	WHERE uuid = :uuid AND archived_at IS NULL`
+
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+// FindByOrganizationUuid returns the policy applying to all projects
+// of an organization.
+func (store *DbLogRetentionPolicyStore) FindByOrganizationUuid(organizationUuid string) (*domain.LogRetentionPolicy, error) {
+
+	result := &domain.LogRetentionPolicy{}
+	q := `SELECT * FROM log_retention_policies WHERE organization_uuid = $1 AND project_uuid IS NULL AND archived_at IS NULL`
+	err := store.tx.Get(result, q, organizationUuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, err
+}
+
+// FindByProjectUuid returns the policy defined specifically for a
+// project.
+func (store *DbLogRetentionPolicyStore) FindByProjectUuid(projectUuid string) (*domain.LogRetentionPolicy, error) {
+
+	result := &domain.LogRetentionPolicy{}
+	q := `SELECT * FROM log_retention_policies WHERE project_uuid = $1 AND archived_at IS NULL`
+	err := store.tx.Get(result, q, projectUuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, err
+}
+
+// FindEffectiveByProject returns the policy that applies to project,
+// taking the policy of the project's organization into account.
+func (store *DbLogRetentionPolicyStore) FindEffectiveByProject(project *domain.Project) (*domain.LogRetentionPolicy, error) {
+
+	projectPolicy, err := store.FindByProjectUuid(project.Uuid)
+	if err != nil && !domain.IsNotFound(err) {
+		return nil, err
+	}
+
+	organizationPolicy, err := store.FindByOrganizationUuid(project.OrganizationUuid)
+	if err != nil && !domain.IsNotFound(err) {
+		return nil, err
+	}
+
+	policy := domain.EffectiveLogRetentionPolicy(organizationPolicy, projectPolicy)
+	if policy == nil {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return policy, nil
+}
+
+func (store *DbLogRetentionPolicyStore) ArchiveByUuid(uuid string) error {
+
+	q := `UPDATE log_retention_policies SET archived_at = NOW() AT TIME ZONE 'UTC' WHERE uuid = $1`
+	r, err := store.tx.Exec(q, uuid)
+
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
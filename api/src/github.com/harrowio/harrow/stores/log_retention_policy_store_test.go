@@ -0,0 +1,64 @@
+package stores_test
+
+import (
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+)
+
+func Test_DbLogRetentionPolicyStore_FindEffectiveByProject_prefersProjectPolicy(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	store := stores.NewDbLogRetentionPolicyStore(tx)
+	project := world.Project("public")
+
+	organizationPolicy := &domain.LogRetentionPolicy{
+		OrganizationUuid: project.OrganizationUuid,
+		KeepFullDays:     30,
+	}
+	if _, err := store.Create(organizationPolicy); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindEffectiveByProject(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := found.Uuid, organizationPolicy.Uuid; got != want {
+		t.Errorf(`found.Uuid = %q; want organization policy %q`, got, want)
+	}
+
+	projectPolicy := &domain.LogRetentionPolicy{
+		OrganizationUuid: project.OrganizationUuid,
+		ProjectUuid:      &project.Uuid,
+		KeepFullDays:     7,
+	}
+	if _, err := store.Create(projectPolicy); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err = store.FindEffectiveByProject(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := found.Uuid, projectPolicy.Uuid; got != want {
+		t.Errorf(`found.Uuid = %q; want project policy %q`, got, want)
+	}
+}
+
+func Test_DbLogRetentionPolicyStore_FindEffectiveByProject_returnsNotFound_withoutPolicy(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	store := stores.NewDbLogRetentionPolicyStore(tx)
+
+	_, err := store.FindEffectiveByProject(world.Project("public"))
+	if !domain.IsNotFound(err) {
+		t.Errorf(`err = %v; want NotFoundError`, err)
+	}
+}
@@ -0,0 +1,107 @@
+package stores
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbNotificationDeliveryStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbNotificationDeliveryStore(tx *sqlx.Tx) *DbNotificationDeliveryStore {
+	return &DbNotificationDeliveryStore{tx: tx}
+}
+
+func (store *DbNotificationDeliveryStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbNotificationDeliveryStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbNotificationDeliveryStore) Create(subject *domain.NotificationDelivery) (string, error) {
+
+	q := `INSERT INTO notification_deliveries (uuid, notification_rule_uuid, project_uuid, notifier_uuid, notifier_type, activity_id, status, attempts, last_error, next_attempt_at, created_at, delivered_at)
+	  VALUES (:uuid, :notification_rule_uuid, :project_uuid, :notifier_uuid, :notifier_type, :activity_id, :status, :attempts, :last_error, :next_attempt_at, :created_at, :delivered_at);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbNotificationDeliveryStore) Update(subject *domain.NotificationDelivery) error {
+
+	q := `UPDATE notification_deliveries
+	  SET status = :status, attempts = :attempts, last_error = :last_error, next_attempt_at = :next_attempt_at, delivered_at = :delivered_at
+	  WHERE uuid = :uuid;`
+
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbNotificationDeliveryStore) FindByUuid(uuid string) (*domain.NotificationDelivery, error) {
+
+	result := &domain.NotificationDelivery{}
+	err := store.tx.Get(result, `SELECT * FROM notification_deliveries WHERE uuid = $1`, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindAllByNotificationRuleUuid returns the most recent deliveries
+// for a notification rule, newest first.
+func (store *DbNotificationDeliveryStore) FindAllByNotificationRuleUuid(notificationRuleUuid string, limit int) ([]*domain.NotificationDelivery, error) {
+
+	result := []*domain.NotificationDelivery{}
+	q := `SELECT * FROM notification_deliveries WHERE notification_rule_uuid = $1 ORDER BY created_at DESC LIMIT $2`
+	if err := store.tx.Select(&result, q, notificationRuleUuid, limit); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// ClaimAllDue returns up to limit pending deliveries whose next
+// attempt is due at now and moves their next attempt to claimedUntil.
+// Deliveries locked by a concurrent claim are skipped.
+func (store *DbNotificationDeliveryStore) ClaimAllDue(now, claimedUntil time.Time, limit int) ([]*domain.NotificationDelivery, error) {
+
+	result := []*domain.NotificationDelivery{}
+	q := `UPDATE notification_deliveries SET next_attempt_at = $2
+	  WHERE uuid IN (
+	    SELECT uuid FROM notification_deliveries
+	    WHERE status = 'pending' AND next_attempt_at <= $1
+	    ORDER BY next_attempt_at
+	    LIMIT $3
+	    FOR UPDATE SKIP LOCKED
+	  )
+	  RETURNING *`
+	if err := store.tx.Select(&result, q, now, claimedUntil, limit); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
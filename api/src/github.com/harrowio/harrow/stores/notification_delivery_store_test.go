@@ -0,0 +1,111 @@
+package stores_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+func Test_NotificationDeliveryStore_Update_recordsAttempts(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	rule := &domain.NotificationRule{
+		ProjectUuid:   world.Project("public").Uuid,
+		NotifierType:  "slack_notifiers",
+		NotifierUuid:  uuidhelper.MustNewV4(),
+		MatchActivity: "operation.*",
+		CreatorUuid:   world.User("default").Uuid,
+	}
+	if _, err := stores.NewDbNotificationRuleStore(tx).Create(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	store := stores.NewDbNotificationDeliveryStore(tx)
+	delivery := domain.NewNotificationDelivery(rule, &domain.Activity{Id: 42})
+	if _, err := store.Create(delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery.RecordAttempt(errors.New("timeout"))
+	delivery.RecordAttempt(nil)
+	if err := store.Update(delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := store.FindAllByNotificationRuleUuid(rule.Uuid, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(deliveries), 1; got != want {
+		t.Fatalf("len(deliveries) = %d; want %d", got, want)
+	}
+
+	if got, want := deliveries[0].Status, domain.NotificationDeliveryDelivered; got != want {
+		t.Errorf("deliveries[0].Status = %q; want %q", got, want)
+	}
+
+	if got, want := deliveries[0].Attempts, 2; got != want {
+		t.Errorf("deliveries[0].Attempts = %d; want %d", got, want)
+	}
+}
+
+func Test_NotificationDeliveryStore_ClaimAllDue_claimsPendingDeliveriesOnce(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	rule := &domain.NotificationRule{
+		ProjectUuid:   world.Project("public").Uuid,
+		NotifierType:  "slack_notifiers",
+		NotifierUuid:  uuidhelper.MustNewV4(),
+		MatchActivity: "operation.*",
+		CreatorUuid:   world.User("default").Uuid,
+	}
+	if _, err := stores.NewDbNotificationRuleStore(tx).Create(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	store := stores.NewDbNotificationDeliveryStore(tx)
+	now := time.Now()
+	due := domain.NewNotificationDelivery(rule, &domain.Activity{Id: 42})
+	due.RecordAttempt(errors.New("timeout"))
+	due.RetryIn(-time.Minute)
+	later := domain.NewNotificationDelivery(rule, &domain.Activity{Id: 43})
+	later.RecordAttempt(errors.New("timeout"))
+	later.RetryIn(time.Hour)
+	for _, delivery := range []*domain.NotificationDelivery{due, later} {
+		if _, err := store.Create(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err := store.ClaimAllDue(now, now.Add(5*time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(claimed), 1; got != want {
+		t.Fatalf("len(claimed) = %d; want %d", got, want)
+	}
+
+	if got, want := claimed[0].Uuid, due.Uuid; got != want {
+		t.Errorf("claimed[0].Uuid = %q; want %q", got, want)
+	}
+
+	claimed, err = store.ClaimAllDue(now, now.Add(5*time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(claimed), 0; got != want {
+		t.Errorf("len(claimed) = %d; want %d", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// DbNotificationPreferencesStore stores the notification preferences
+// of users together with the projects they have muted.
+type DbNotificationPreferencesStore struct {
+	tx *sqlx.Tx
+}
+
+func NewDbNotificationPreferencesStore(tx *sqlx.Tx) *DbNotificationPreferencesStore {
+	return &DbNotificationPreferencesStore{tx: tx}
+}
+
+// FindByUserUuid returns the preferences of the user identified by
+// userUuid.  Users who have never changed their preferences get the
+// default ones.
+func (store *DbNotificationPreferencesStore) FindByUserUuid(userUuid string) (*domain.NotificationPreferences, error) {
+
+	result := domain.NewNotificationPreferences(userUuid)
+	q := `SELECT user_uuid, channel, slack_webhook_url, timezone, quiet_hours_start, quiet_hours_end, updated_at
+        FROM notification_preferences
+        WHERE user_uuid = $1`
+	if err := store.tx.Get(result, q, userUuid); err != nil && err != sql.ErrNoRows {
+		return nil, resolveErrType(err)
+	}
+
+	q = `SELECT project_uuid FROM notification_project_mutes WHERE user_uuid = $1 ORDER BY project_uuid`
+	if err := store.tx.Select(&result.MutedProjectUuids, q, userUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// Save stores preferences, replacing the user's previous preferences
+// and muted projects.
+func (store *DbNotificationPreferencesStore) Save(preferences *domain.NotificationPreferences) error {
+
+	q := `INSERT INTO notification_preferences (user_uuid, channel, slack_webhook_url, timezone, quiet_hours_start, quiet_hours_end, updated_at)
+        VALUES (:user_uuid, :channel, :slack_webhook_url, :timezone, :quiet_hours_start, :quiet_hours_end, NOW())
+        ON CONFLICT (user_uuid) DO UPDATE SET
+          channel = EXCLUDED.channel,
+          slack_webhook_url = EXCLUDED.slack_webhook_url,
+          timezone = EXCLUDED.timezone,
+          quiet_hours_start = EXCLUDED.quiet_hours_start,
+          quiet_hours_end = EXCLUDED.quiet_hours_end,
+          updated_at = EXCLUDED.updated_at`
+	if _, err := store.tx.NamedExec(q, preferences); err != nil {
+		return resolveErrType(err)
+	}
+
+	if _, err := store.tx.Exec(`DELETE FROM notification_project_mutes WHERE user_uuid = $1`, preferences.UserUuid); err != nil {
+		return resolveErrType(err)
+	}
+
+	q = `INSERT INTO notification_project_mutes (user_uuid, project_uuid) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	for _, projectUuid := range preferences.MutedProjectUuids {
+		if _, err := store.tx.Exec(q, preferences.UserUuid, projectUuid); err != nil {
+			return resolveErrType(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package stores_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+)
+
+func Test_NotificationPreferencesStore_FindByUserUuid_returnsDefaults_ifNothingHasBeenSaved(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+
+	preferences, err := stores.NewDbNotificationPreferencesStore(tx).FindByUserUuid(user.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := preferences, domain.NewNotificationPreferences(user.Uuid); !reflect.DeepEqual(got, want) {
+		t.Errorf("preferences = %#v; want %#v", got, want)
+	}
+}
+
+func Test_NotificationPreferencesStore_Save_replacesMutedProjects(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	store := stores.NewDbNotificationPreferencesStore(tx)
+
+	preferences := domain.NewNotificationPreferences(user.Uuid)
+	preferences.QuietHoursStart = "22:00"
+	preferences.QuietHoursEnd = "07:00"
+	preferences.MutedProjectUuids = []string{world.Project("public").Uuid}
+	if err := store.Save(preferences); err != nil {
+		t.Fatal(err)
+	}
+
+	preferences.MutedProjectUuids = []string{world.Project("private").Uuid}
+	if err := store.Save(preferences); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindByUserUuid(user.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := found.QuietHoursStart, "22:00"; got != want {
+		t.Errorf("found.QuietHoursStart = %q; want %q", got, want)
+	}
+
+	if got, want := found.MutedProjectUuids, preferences.MutedProjectUuids; !reflect.DeepEqual(got, want) {
+		t.Errorf("found.MutedProjectUuids = %v; want %v", got, want)
+	}
+}
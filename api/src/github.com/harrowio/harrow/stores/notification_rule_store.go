@@ -95,7 +95,9 @@ func (store *DbNotificationRuleStore) Create(subject *domain.NotificationRule) (
           notifier_uuid,
           notifier_type,
           match_activity,
-          creator_uuid
+          creator_uuid,
+          condition,
+          notify_every
 	) VALUES (
 	  :uuid,
           :project_uuid,
@@ -103,7 +105,9 @@ func (store *DbNotificationRuleStore) Create(subject *domain.NotificationRule) (
           :notifier_uuid,
           :notifier_type,
           :match_activity,
-          :creator_uuid
+          :creator_uuid,
+          :condition,
+          :notify_every
 	);`
 
 	t := template.Must(template.New("main").Parse(qTemplate))
@@ -132,7 +136,9 @@ func (store *DbNotificationRuleStore) Update(subject *domain.NotificationRule) e
 	  match_activity = :match_activity,
 	  notifier_uuid = :notifier_uuid,
 	  notifier_type = :notifier_type,
-	  creator_uuid = :creator_uuid
+	  creator_uuid = :creator_uuid,
+	  condition = :condition,
+	  notify_every = :notify_every
 	WHERE uuid = :uuid AND archived_at IS NULL`
 
 	t := template.Must(template.New("main").Parse(qTemplate))
@@ -42,6 +42,10 @@ func (self *DbNotifierStore) FindByUuidAndType(uuid, typename string) (interface
 		dest = new(domain.JobNotifier)
 	case "slack_notifiers":
 		dest = new(domain.SlackNotifier)
+	case "webhook_notifiers":
+		dest = new(domain.WebhookNotifier)
+	case domain.ChatNotifierTeams, domain.ChatNotifierMattermost, domain.ChatNotifierDiscord, domain.BlameNotifierType:
+		dest = domain.NewProjectNotifier(typename)
 	default:
 		return nil, fmt.Errorf("Unsupported notifier type: %q", typename)
 	}
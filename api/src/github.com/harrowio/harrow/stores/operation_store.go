@@ -2,6 +2,7 @@ package stores
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/harrowio/harrow/domain"
 	"github.com/harrowio/harrow/logger"
@@ -363,14 +364,85 @@ func (store *DbOperationStore) MarkStatusLogs(operationUuid string, logs *domain
 	return store.updateColumn(operationUuid, "status_logs", logs)
 }
 
+func (store *DbOperationStore) MarkTestResults(operationUuid string, results *domain.TestResults) error {
+
+	return store.updateColumn(operationUuid, "test_results", results)
+}
+
+func (store *DbOperationStore) MarkLogTruncated(operationUuid string) error {
+
+	return store.updateTimestamp(operationUuid, "log_truncated_at")
+}
+
+func (store *DbOperationStore) MarkLogDeleted(operationUuid string) error {
+
+	return store.updateTimestamp(operationUuid, "log_deleted_at")
+}
+
+// FindAllWithLogsByProjectUuid returns all operations of the project's
+// jobs, including archived ones, that finished before the given time
+// and whose logs have not been deleted yet.
+func (store DbOperationStore) FindAllWithLogsByProjectUuid(projectUuid string, finishedBefore time.Time) ([]*domain.Operation, error) {
+
+	var operations []*domain.Operation = []*domain.Operation{}
+
+	var q string = `
+		SELECT
+			o.*
+		FROM operations AS o
+		JOIN jobs_projects AS jp
+			ON jp.uuid = o.job_uuid
+		WHERE
+			jp.project_uuid = $1
+			AND o.finished_at < $2
+			AND o.log_deleted_at IS NULL
+		ORDER BY o.finished_at ASC
+	`
+
+	err := store.tx.Select(&operations, q, projectUuid, finishedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	return operations, nil
+}
+
 func (store *DbOperationStore) FindPreviousOperation(currentOperationUuid string) (*domain.Operation, error) {
+	return store.findPreviousOperation(currentOperationUuid, false)
+}
+
+// FindPreviousConcludedOperation returns the last operation of the
+// same job that finished, failed or timed out before the given
+// operation, or nil if there is none.  Canceled operations are
+// skipped, since they have no outcome to compare with.
+func (store *DbOperationStore) FindPreviousConcludedOperation(currentOperationUuid string) (*domain.Operation, error) {
+	return store.findPreviousOperation(currentOperationUuid, true)
+}
+
+// findPreviousOperation looks up the last operation of the same job
+// as the given one.  By default only finished operations are
+// considered.  Concluded lookups also consider failed and timed out
+// operations, which have no finished_at, and therefore order by the
+// time operations terminated, up to the given operation.
+func (store *DbOperationStore) findPreviousOperation(currentOperationUuid string, concluded bool) (*domain.Operation, error) {
+
+	terminatedAt := `finished_at`
+	conditions := ``
+	if concluded {
+		terminatedAt = `COALESCE(finished_at, failed_at, timed_out_at)`
+		conditions = `
+        AND   canceled_at IS NULL
+        AND   ` + terminatedAt + ` < COALESCE((
+                select COALESCE(finished_at, failed_at, timed_out_at, canceled_at) from operations where uuid = $1
+              ), 'infinity')`
+	}
 
 	q := `SELECT * FROM operations
-        WHERE finished_at IS NOT NULL
+        WHERE ` + terminatedAt + ` IS NOT NULL
         AND   archived_at IS NULL
         AND   job_uuid = (select job_uuid from operations where uuid = $1)
-        AND   uuid <> $1
-        ORDER BY finished_at DESC
+        AND   uuid <> $1` + conditions + `
+        ORDER BY ` + terminatedAt + ` DESC
         LIMIT 1;`
 
 	result := &domain.Operation{}
@@ -381,3 +453,75 @@ func (store *DbOperationStore) FindPreviousOperation(currentOperationUuid string
 
 	return result, err
 }
+
+// FindAllSinceLastSuccess returns the terminated operations of the
+// same job as the given operation since the job last succeeded, up to
+// and including the given operation, in the order they terminated.
+// Canceled operations are included, because the commits they checked
+// out are not part of any other operation's git logs.  If the job
+// never succeeded, the result is empty.
+func (store *DbOperationStore) FindAllSinceLastSuccess(operationUuid string) ([]*domain.Operation, error) {
+
+	q := `WITH current AS (
+          SELECT job_uuid, COALESCE(finished_at, failed_at, timed_out_at, canceled_at, 'infinity') AS terminated_at
+          FROM operations WHERE uuid = $1
+        ), job_operations AS (
+          SELECT o.* FROM operations o, current
+          WHERE o.job_uuid = current.job_uuid
+          AND   COALESCE(o.finished_at, o.failed_at, o.timed_out_at, o.canceled_at) <= current.terminated_at
+          AND   o.archived_at IS NULL
+        ), last_success AS (
+          SELECT max(finished_at) AS terminated_at FROM job_operations
+          WHERE finished_at IS NOT NULL
+          AND   exit_status = 0
+          AND   canceled_at IS NULL
+          AND   failed_at IS NULL
+          AND   timed_out_at IS NULL
+          AND   fatal_error IS NULL
+        )
+        SELECT job_operations.* FROM job_operations, last_success
+        WHERE COALESCE(job_operations.finished_at, job_operations.failed_at, job_operations.timed_out_at, job_operations.canceled_at) > last_success.terminated_at
+        ORDER BY COALESCE(job_operations.finished_at, job_operations.failed_at, job_operations.timed_out_at, job_operations.canceled_at) ASC;`
+
+	result := []*domain.Operation{}
+	if err := store.tx.Select(&result, q, operationUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// CountFailuresInARow returns the number of terminated operations of
+// the same job that failed since the job last succeeded, up to and
+// including the given operation.  Canceled operations are not
+// counted.
+func (store *DbOperationStore) CountFailuresInARow(operationUuid string) (int, error) {
+
+	q := `WITH current AS (
+          SELECT job_uuid, COALESCE(finished_at, failed_at, timed_out_at, canceled_at, 'infinity') AS terminated_at
+          FROM operations WHERE uuid = $1
+        ), job_operations AS (
+          SELECT o.*, COALESCE(o.finished_at, o.failed_at, o.timed_out_at, o.canceled_at) AS terminated_at
+          FROM operations o, current
+          WHERE o.job_uuid = current.job_uuid
+          AND   COALESCE(o.finished_at, o.failed_at, o.timed_out_at, o.canceled_at) <= current.terminated_at
+          AND   o.archived_at IS NULL
+          AND   o.canceled_at IS NULL
+        )
+        SELECT count(*) FROM job_operations
+        WHERE terminated_at > COALESCE((
+          SELECT max(terminated_at) FROM job_operations
+          WHERE finished_at IS NOT NULL
+          AND   exit_status = 0
+          AND   failed_at IS NULL
+          AND   timed_out_at IS NULL
+          AND   fatal_error IS NULL
+        ), '-infinity');`
+
+	result := 0
+	if err := store.tx.Get(&result, q, operationUuid); err != nil {
+		return 0, resolveErrType(err)
+	}
+
+	return result, nil
+}
@@ -80,6 +80,31 @@ func (self *TestParams) newOperationWithTimestamps(t *testing.T, created, finish
 	return operation
 }
 
+// newOperationTerminatedBy creates an operation which terminated at
+// the given time, setting only the given timestamp column, like the
+// controller does when an operation fails, times out or is canceled.
+// The exit status is left at zero, so that only the timestamp tells
+// failed and successful operations apart.
+func (self *TestParams) newOperationTerminatedBy(t *testing.T, column string, terminated time.Time) *domain.Operation {
+	operationStore := stores.NewDbOperationStore(self.tx)
+	operation := &domain.Operation{
+		WorkspaceBaseImageUuid: "31b0127a-6d63-4d22-b32b-e1cfc04f4007",
+		JobUuid:                &self.job.Uuid,
+		Type:                   domain.OperationTypeJobScheduled,
+		Uuid:                   uuidhelper.MustNewV4(),
+	}
+	if _, err := operationStore.Create(operation); err != nil {
+		t.Fatal(err)
+	}
+
+	q := `UPDATE operations SET ` + column + ` = $2, created_at = $3, exit_status = 0 WHERE uuid = $1`
+	if _, err := self.tx.Exec(q, operation.Uuid, terminated, terminated.Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	return operation
+}
+
 func Test_OperationStoreStore_SucessfullyCreating(t *testing.T) {
 
 	test := setupOperationStoreTest(t)
@@ -333,3 +358,161 @@ func TestDbOperationStore_FindPreviousOperation_ignoresArchivedOperationsInTheSa
 		t.Errorf(`found.Uuid = %v; want %v`, got, want)
 	}
 }
+
+func TestDbOperationStore_MarkTestResults_storesTestResults(t *testing.T) {
+	test := setupOperationStoreTest(t)
+	defer test.tx.Rollback()
+
+	now := time.Now()
+	operation := test.newOperationWithTimestamps(t, now, now)
+	results := domain.NewTestResults()
+	results.Entries = append(results.Entries, &domain.TestResult{
+		Suite:  "models",
+		Name:   "test_login",
+		Status: domain.TestStatusFailed,
+	})
+
+	store := stores.NewDbOperationStore(test.tx)
+	if err := store.MarkTestResults(operation.Uuid, results); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindByUuid(operation.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := found.TestResults; got == nil {
+		t.Fatalf("found.TestResults is nil")
+	}
+
+	if got, want := len(found.TestResults.Failed()), 1; got != want {
+		t.Errorf(`len(found.TestResults.Failed()) = %v; want %v`, got, want)
+	}
+}
+
+func TestDbOperationStore_CountFailuresInARow_countsFailuresSinceLastSuccess(t *testing.T) {
+	test := setupOperationStoreTest(t)
+	defer test.tx.Rollback()
+	operationStore := stores.NewDbOperationStore(test.tx)
+	now := time.Now()
+	exitStatuses := []int{1, 0, 1, 1, 1}
+	operations := []*domain.Operation{}
+	for i, exitStatus := range exitStatuses {
+		startedAt := now.Add(time.Duration(i-len(exitStatuses)) * time.Hour)
+		operation := test.newOperationWithTimestamps(t, startedAt, startedAt.Add(1*time.Minute))
+		if _, err := test.tx.Exec(`UPDATE operations SET exit_status = $2 WHERE uuid = $1`, operation.Uuid, exitStatus); err != nil {
+			t.Fatal(err)
+		}
+		operations = append(operations, operation)
+	}
+
+	for i, want := range []int{1, 0, 1, 2, 3} {
+		got, err := operationStore.CountFailuresInARow(operations[i].Uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf(`CountFailuresInARow(operations[%d]) = %d; want %d`, i, got, want)
+		}
+	}
+}
+
+func TestDbOperationStore_CountFailuresInARow_countsOperationsMarkedAsFailed(t *testing.T) {
+	test := setupOperationStoreTest(t)
+	defer test.tx.Rollback()
+	operationStore := stores.NewDbOperationStore(test.tx)
+	now := time.Now()
+	columns := []string{"failed_at", "finished_at", "failed_at", "canceled_at", "timed_out_at", "failed_at"}
+	operations := []*domain.Operation{}
+	for i, column := range columns {
+		terminatedAt := now.Add(time.Duration(i-len(columns)) * time.Hour)
+		operations = append(operations, test.newOperationTerminatedBy(t, column, terminatedAt))
+	}
+
+	for i, want := range []int{1, 0, 1, 1, 2, 3} {
+		got, err := operationStore.CountFailuresInARow(operations[i].Uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf(`CountFailuresInARow(operations[%d]) = %d; want %d`, i, got, want)
+		}
+	}
+}
+
+func TestDbOperationStore_FindPreviousConcludedOperation_returnsFailedOperations(t *testing.T) {
+	test := setupOperationStoreTest(t)
+	defer test.tx.Rollback()
+	operationStore := stores.NewDbOperationStore(test.tx)
+	now := time.Now()
+	test.newOperationTerminatedBy(t, "finished_at", now.Add(-3*time.Hour))
+	previous := test.newOperationTerminatedBy(t, "failed_at", now.Add(-2*time.Hour))
+	current := test.newOperationTerminatedBy(t, "finished_at", now)
+
+	found, err := operationStore.FindPreviousConcludedOperation(current.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found == nil {
+		t.Fatalf("found is nil")
+	}
+
+	if got, want := found.Uuid, previous.Uuid; got != want {
+		t.Errorf(`found.Uuid = %v; want %v`, got, want)
+	}
+}
+
+func TestDbOperationStore_FindPreviousConcludedOperation_skipsCanceledOperations(t *testing.T) {
+	test := setupOperationStoreTest(t)
+	defer test.tx.Rollback()
+	operationStore := stores.NewDbOperationStore(test.tx)
+	now := time.Now()
+	previous := test.newOperationTerminatedBy(t, "failed_at", now.Add(-3*time.Hour))
+	test.newOperationTerminatedBy(t, "canceled_at", now.Add(-2*time.Hour))
+	current := test.newOperationTerminatedBy(t, "failed_at", now)
+
+	found, err := operationStore.FindPreviousConcludedOperation(current.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found == nil {
+		t.Fatalf("found is nil")
+	}
+
+	if got, want := found.Uuid, previous.Uuid; got != want {
+		t.Errorf(`found.Uuid = %v; want %v`, got, want)
+	}
+}
+
+func TestDbOperationStore_FindAllSinceLastSuccess_returnsOperationsAfterLastSuccess(t *testing.T) {
+	test := setupOperationStoreTest(t)
+	defer test.tx.Rollback()
+	operationStore := stores.NewDbOperationStore(test.tx)
+	now := time.Now()
+	columns := []string{"failed_at", "finished_at", "failed_at", "canceled_at", "timed_out_at", "failed_at"}
+	operations := []*domain.Operation{}
+	for i, column := range columns {
+		terminated := now.Add(time.Duration(i-len(columns)) * time.Hour)
+		operations = append(operations, test.newOperationTerminatedBy(t, column, terminated))
+	}
+
+	for i, want := range []int{0, 0, 1, 2, 3, 4} {
+		found, err := operationStore.FindAllSinceLastSuccess(operations[i].Uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := len(found); got != want {
+			t.Errorf(`len(FindAllSinceLastSuccess(operations[%d])) = %d; want %d`, i, got, want)
+		}
+
+		if want > 0 && found[len(found)-1].Uuid != operations[i].Uuid {
+			t.Errorf(`FindAllSinceLastSuccess(operations[%d]) ends with %s; want %s`, i, found[len(found)-1].Uuid, operations[i].Uuid)
+		}
+	}
+}
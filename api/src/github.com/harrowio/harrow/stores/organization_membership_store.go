@@ -49,6 +49,22 @@ func (store DbOrganizationMembershipStore) Create(om *domain.OrganizationMembers
 
 }
 
+// UpdateType changes the membership type of an existing membership.
+func (store DbOrganizationMembershipStore) UpdateType(om *domain.OrganizationMembership) error {
+
+	var q string = `UPDATE organization_memberships SET type = :type WHERE organization_uuid = :organization_uuid AND user_uuid = :user_uuid`
+	r, err := store.tx.NamedExec(q, om)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return new(domain.NotFoundError)
+	}
+
+	return nil
+}
+
 func (store DbOrganizationMembershipStore) FindAllByOrganizationAndUserUuidGreaterThan(orgUuid string, userUuid string, minMembershipType string) ([]*domain.OrganizationMembership, error) {
 
 	var orgMemberships []*domain.OrganizationMembership = []*domain.OrganizationMembership{}
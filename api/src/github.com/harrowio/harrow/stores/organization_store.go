@@ -185,7 +185,7 @@ func (store DbOrganizationStore) Create(org *domain.Organization) (string, error
 		org.Uuid = uuidhelper.MustNewV4()
 	}
 
-	var q string = `INSERT INTO organizations (uuid, name, public, github_login) VALUES (:uuid, :name, :public, :github_login) RETURNING uuid;`
+	var q string = `INSERT INTO organizations (uuid, name, public, github_login, require_totp) VALUES (:uuid, :name, :public, :github_login, :require_totp) RETURNING uuid;`
 	rows, err := store.tx.NamedQuery(q, org)
 
 	if err != nil {
@@ -263,7 +263,7 @@ func (store DbOrganizationStore) Update(org *domain.Organization) error {
 		return err
 	}
 
-	var q string = `UPDATE organizations SET (name, public, github_login) = (:name, :public, :github_login) WHERE uuid = :uuid AND archived_at IS NULL;`
+	var q string = `UPDATE organizations SET (name, public, github_login, require_totp) = (:name, :public, :github_login, :require_totp) WHERE uuid = :uuid AND archived_at IS NULL;`
 	result, err := store.tx.NamedExec(q, org)
 
 	if err != nil {
@@ -38,24 +38,24 @@ func (store *DbProjectMemberStore) FindAllByProjectUuid(projectUuid string) ([]*
            WHERE uuid = $1::uuid
         ),
         members_of_project AS (
-          SELECT u.*, pm.project_uuid, pm.membership_type, pm.uuid as membership_uuid
+          SELECT u.*, pm.project_uuid, pm.membership_type, pm.uuid as membership_uuid, pm.custom_role_uuid
           FROM users u
           JOIN project_memberships pm ON u.uuid = pm.user_uuid
           WHERE pm.project_uuid = $1::uuid
             AND pm.archived_at IS NULL
         ),
         members_of_org AS (
-          SELECT u.*, $1::uuid as project_uuid, om.type as membership_type, null::uuid as membership_uuid
+          SELECT u.*, $1::uuid as project_uuid, om.type as membership_type, null::uuid as membership_uuid, null::uuid as custom_role_uuid
           FROM users u
           JOIN organization_memberships om ON u.uuid = om.user_uuid
           WHERE om.organization_uuid = (SELECT * FROM project_organization_uuid)
         ),
         members AS (
-          SELECT uuid, project_uuid, membership_type, membership_uuid FROM members_of_project
+          SELECT uuid, project_uuid, membership_type, membership_uuid, custom_role_uuid FROM members_of_project
           UNION
-          SELECT uuid, project_uuid, membership_type, membership_uuid FROM members_of_org
+          SELECT uuid, project_uuid, membership_type, membership_uuid, custom_role_uuid FROM members_of_org
         )
-        SELECT u.*, m.project_uuid, m.membership_type, m.membership_uuid FROM users u, members m WHERE u.uuid = m.uuid
+        SELECT u.*, m.project_uuid, m.membership_type, m.membership_uuid, m.custom_role_uuid FROM users u, members m WHERE u.uuid = m.uuid
         ORDER BY name ASC
         `
 
@@ -89,8 +89,9 @@ func (store *DbProjectMembershipStore) Create(membership *domain.ProjectMembersh
 		(uuid,
 		 project_uuid,
 		 user_uuid,
-		 membership_type)
-		VALUES (:uuid, :project_uuid, :user_uuid, :membership_type);
+		 membership_type,
+		 custom_role_uuid)
+		VALUES (:uuid, :project_uuid, :user_uuid, :membership_type, :custom_role_uuid);
 	`
 
 	rows, err := store.tx.NamedQuery(q, membership)
@@ -108,7 +109,7 @@ func (store *DbProjectMembershipStore) Update(membership *domain.ProjectMembersh
 		return new(domain.NotFoundError)
 	}
 
-	q := `UPDATE project_memberships SET membership_type = :membership_type WHERE uuid = :uuid`
+	q := `UPDATE project_memberships SET membership_type = :membership_type, custom_role_uuid = :custom_role_uuid WHERE uuid = :uuid`
 
 	r, err := store.tx.NamedExec(q, membership)
 	if err != nil {
@@ -0,0 +1,96 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbRecoveryCodeStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbRecoveryCodeStore(tx *sqlx.Tx) *DbRecoveryCodeStore {
+	return &DbRecoveryCodeStore{tx: tx}
+}
+
+func (store *DbRecoveryCodeStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbRecoveryCodeStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+// ReplaceAllForUser deletes all recovery codes of a user, used or
+// not, and stores codes instead.
+func (store *DbRecoveryCodeStore) ReplaceAllForUser(userUuid string, codes []*domain.RecoveryCode) error {
+
+	if err := store.DeleteAllForUser(userUuid); err != nil {
+		return err
+	}
+
+	q := `INSERT INTO recovery_codes (uuid, user_uuid, code_hash) VALUES (:uuid, :user_uuid, :code_hash)`
+	for _, code := range codes {
+		if code.Uuid == "" {
+			code.Uuid = uuidhelper.MustNewV4()
+		}
+		code.UserUuid = userUuid
+
+		if _, err := store.tx.NamedExec(q, code); err != nil {
+			return resolveErrType(err)
+		}
+	}
+
+	return nil
+}
+
+func (store *DbRecoveryCodeStore) DeleteAllForUser(userUuid string) error {
+	if _, err := store.tx.Exec(`DELETE FROM recovery_codes WHERE user_uuid = $1`, userUuid); err != nil {
+		return resolveErrType(err)
+	}
+
+	return nil
+}
+
+// Use marks the unused recovery code matching plainCode as used.  It
+// returns domain.ErrRecoveryCodeNotValid if the user has no such
+// code, or if it has been used already.
+func (store *DbRecoveryCodeStore) Use(userUuid string, plainCode string) (*domain.RecoveryCode, error) {
+
+	result := &domain.RecoveryCode{}
+	q := `UPDATE recovery_codes SET used_at = NOW()
+	  WHERE uuid = (
+	    SELECT uuid FROM recovery_codes
+	    WHERE user_uuid = $1 AND code_hash = $2 AND used_at IS NULL
+	    LIMIT 1
+	  )
+	  RETURNING *`
+	err := store.tx.Get(result, q, userUuid, domain.HashRecoveryCode(plainCode))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrRecoveryCodeNotValid
+	}
+	if err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// CountUnusedByUserUuid returns how many recovery codes a user has left.
+func (store *DbRecoveryCodeStore) CountUnusedByUserUuid(userUuid string) (int, error) {
+	count := 0
+	q := `SELECT COUNT(*) FROM recovery_codes WHERE user_uuid = $1 AND used_at IS NULL`
+	if err := store.tx.Get(&count, q, userUuid); err != nil {
+		return 0, resolveErrType(err)
+	}
+
+	return count, nil
+}
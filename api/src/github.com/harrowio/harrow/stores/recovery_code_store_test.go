@@ -0,0 +1,63 @@
+package stores_test
+
+import (
+	"testing"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/test_helpers"
+)
+
+func Test_RecoveryCodeStore_Use_acceptsEachCodeOnlyOnce(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	store := stores.NewDbRecoveryCodeStore(tx)
+
+	plain, codes := domain.NewRecoveryCodes(user.Uuid)
+	if err := store.ReplaceAllForUser(user.Uuid, codes); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := store.Use(user.Uuid, plain[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !code.IsUsed() {
+		t.Errorf("code.IsUsed() = false; want true")
+	}
+
+	if _, err := store.Use(user.Uuid, plain[0]); err != domain.ErrRecoveryCodeNotValid {
+		t.Errorf("err = %v; want %v", err, domain.ErrRecoveryCodeNotValid)
+	}
+
+	remaining, err := store.CountUnusedByUserUuid(user.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := remaining, domain.RecoveryCodeCount-1; got != want {
+		t.Errorf("remaining = %d; want %d", got, want)
+	}
+}
+
+func Test_RecoveryCodeStore_Use_rejectsCodesOfOtherUsers(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	store := stores.NewDbRecoveryCodeStore(tx)
+
+	plain, codes := domain.NewRecoveryCodes(user.Uuid)
+	if err := store.ReplaceAllForUser(user.Uuid, codes); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Use(world.User("other").Uuid, plain[0]); err != domain.ErrRecoveryCodeNotValid {
+		t.Errorf("err = %v; want %v", err, domain.ErrRecoveryCodeNotValid)
+	}
+}
@@ -205,6 +205,40 @@ func (store *DbRepositoryStore) MarkAsAccessible(uuid string, accessible bool) e
 	return nil
 }
 
+// PinHostKeys records the SSH host keys of a repository, unless host
+// keys have been pinned for it already.  It reports whether the host
+// keys were pinned.
+func (store *DbRepositoryStore) PinHostKeys(uuid string, hostKeys string) (bool, error) {
+
+	var q string = `UPDATE repositories SET host_keys = $2 WHERE uuid = $1 AND host_keys = '';`
+	r, err := store.tx.Exec(q, uuid, hostKeys)
+
+	if err != nil {
+		return false, resolveErrType(err)
+	}
+
+	n, _ := r.RowsAffected()
+	return n > 0, nil
+}
+
+// ResetHostKeys forgets the SSH host keys pinned for a repository, so
+// that the next access check pins the keys presented by the host.
+func (store *DbRepositoryStore) ResetHostKeys(uuid string) error {
+
+	var q string = `UPDATE repositories SET host_keys = '' WHERE uuid = $1;`
+	r, err := store.tx.Exec(q, uuid)
+
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return new(domain.NotFoundError)
+	}
+
+	return nil
+}
+
 func (store DbRepositoryStore) FindAllByProjectUuid(projUuid string) ([]*domain.Repository, error) {
 
 	var repos []*domain.Repository = []*domain.Repository{}
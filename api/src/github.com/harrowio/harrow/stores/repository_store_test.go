@@ -265,3 +265,44 @@ func Test_RepositoryStore_FindByOldestMetadata_returnsRepositoryWithOldestMetada
 		t.Errorf(`repository.MetadataUpdatedAt = %v; want %v`, got, want)
 	}
 }
+
+func Test_RepositoryStore_PinHostKeys_keepsFirstPinnedHostKeys(t *testing.T) {
+	tx := helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := helpers.MustNewWorld(tx, t)
+	repository := world.Repository("default")
+	store := stores.NewDbRepositoryStore(tx)
+
+	first := "github.com ssh-ed25519 AAAAfirst\n"
+	if pinned, err := store.PinHostKeys(repository.Uuid, first); err != nil {
+		t.Fatal(err)
+	} else if !pinned {
+		t.Errorf("pinned = false; want true")
+	}
+
+	if pinned, err := store.PinHostKeys(repository.Uuid, "github.com ssh-ed25519 AAAAsecond\n"); err != nil {
+		t.Fatal(err)
+	} else if pinned {
+		t.Errorf("pinned = true; want false")
+	}
+
+	reloaded, err := store.FindByUuid(repository.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := reloaded.HostKeys, first; got != want {
+		t.Errorf("reloaded.HostKeys = %q; want %q", got, want)
+	}
+
+	if err := store.ResetHostKeys(repository.Uuid); err != nil {
+		t.Fatal(err)
+	}
+
+	if pinned, err := store.PinHostKeys(repository.Uuid, "github.com ssh-ed25519 AAAAsecond\n"); err != nil {
+		t.Fatal(err)
+	} else if !pinned {
+		t.Errorf("pinned after reset = false; want true")
+	}
+}
@@ -0,0 +1,134 @@
+package stores
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/objectstore"
+)
+
+// S3LogStore is the counterpart of DiskLogStore for hosts that don't
+// share OpLogDir with the controller.  Logs are kept under the same
+// relative paths as on disk.  Objects cannot be appended to, so lines
+// are buffered until the operation has finished.
+type S3LogStore struct {
+	store   objectstore.Store
+	log     logger.Logger
+	lock    sync.Mutex
+	pending map[string]*bytes.Buffer
+}
+
+func NewS3LogStore(store objectstore.Store) *S3LogStore {
+	return &S3LogStore{
+		store:   store,
+		pending: map[string]*bytes.Buffer{},
+	}
+}
+
+// NewArchivedLogStore returns the LogStore for finished operations
+// selected by the log storage configuration.
+func NewArchivedLogStore(c *config.Config) (LogStore, error) {
+	storageConfig := c.LogStorageConfig()
+	switch storageConfig.Backend {
+	case config.LogStorageFilesystem:
+		return NewDiskLogStore(c.FilesystemConfig().OpLogDir), nil
+	case config.LogStorageS3:
+		store, err := objectstore.NewS3(storageConfig.AwsConfig(), storageConfig.S3Bucket, storageConfig.S3Prefix)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3LogStore(store), nil
+	default:
+		return nil, errors.New("unknown log storage backend: " + storageConfig.Backend)
+	}
+}
+
+func (self *S3LogStore) Log() logger.Logger {
+	if self.log == nil {
+		self.log = logger.Discard
+	}
+	return self.log
+}
+
+func (self *S3LogStore) SetLogger(l logger.Logger) {
+	self.log = l
+}
+
+func (self *S3LogStore) FindByOperationUuid(uuid string, tepy string) (*domain.Loggable, error) {
+	data, err := self.store.Get(logFilePath(uuid, tepy))
+	if objectstore.IsNotFound(err) {
+		return nil, new(domain.NotFoundError)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = []string{}
+	}
+
+	log := domain.Loggable{
+		Uuid:     uuid,
+		Status:   domain.LoggableOK,
+		LogLines: domain.LogLinesFromSlice(lines, 0),
+	}
+
+	return &log, nil
+}
+
+func (self *S3LogStore) FindByRange(uuid string, tepy string, from, to int) (*domain.Loggable, error) {
+
+	return nil, errors.New("S3LogStore can't get partial logs")
+}
+
+func (self *S3LogStore) PersistLogLine(operationUuid string, logLine *domain.LogLine) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.buffer(operationUuid, domain.LoggableVerbose).WriteString(logLine.Msg + "\n")
+	if !logLine.IsInternal() {
+		self.buffer(operationUuid, domain.LoggableWorkspace).WriteString(logLine.Msg + "\n")
+	}
+	return nil
+}
+
+// OnFinished uploads all lines buffered for operationUuid.
+func (self *S3LogStore) OnFinished(operationUuid string) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, tepy := range []string{domain.LoggableVerbose, domain.LoggableWorkspace} {
+		key := logFilePath(operationUuid, tepy)
+		buf, found := self.pending[key]
+		if !found {
+			continue
+		}
+		if err := self.store.Put(key, buf.Bytes()); err != nil {
+			return err
+		}
+		delete(self.pending, key)
+	}
+
+	return nil
+}
+
+func (self *S3LogStore) Close() error {
+
+	return nil // noop
+}
+
+func (self *S3LogStore) buffer(operationUuid, tepy string) *bytes.Buffer {
+	key := logFilePath(operationUuid, tepy)
+	buf, found := self.pending[key]
+	if !found {
+		buf = new(bytes.Buffer)
+		self.pending[key] = buf
+	}
+	return buf
+}
@@ -0,0 +1,28 @@
+package stores
+
+import (
+	"fmt"
+
+	redis "gopkg.in/redis.v2"
+
+	"github.com/harrowio/harrow/config"
+)
+
+// NewSecretKeyValueStore returns the SecretKeyValueStore selected by
+// the configuration.  redisClient is only used by the Redis backend.
+func NewSecretKeyValueStore(c *config.Config, redisClient *redis.Client) (SecretKeyValueStore, error) {
+	return NewSecretKeyValueStoreFromConfig(c.SecretStorageConfig(), redisClient)
+}
+
+// NewSecretKeyValueStoreFromConfig returns the SecretKeyValueStore for
+// the backend named in storageConfig.
+func NewSecretKeyValueStoreFromConfig(storageConfig config.SecretStorageConfig, redisClient *redis.Client) (SecretKeyValueStore, error) {
+	switch backend := storageConfig.Backend; backend {
+	case config.SecretStorageRedis:
+		return NewRedisSecretKeyValueStore(redisClient), nil
+	case config.SecretStorageVault:
+		return NewVaultSecretKeyValueStore(storageConfig), nil
+	default:
+		return nil, fmt.Errorf("unknown secret storage backend %q", backend)
+	}
+}
@@ -1,6 +1,7 @@
 package stores
 
 import (
+	"bytes"
 	"crypto/rand"
 	"database/sql"
 	"fmt"
@@ -11,6 +12,10 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// secretColumns selects all columns of secrets, mapping the missing
+// environment of shared secrets to an empty uuid.
+const secretColumns = `uuid, name, COALESCE(environment_uuid::text, '') AS environment_uuid, project_uuid, organization_uuid, override_policy, type, status, archived_at, key, version`
+
 type SecretStore struct {
 	ss  SecretKeyValueStore
 	tx  *sqlx.Tx
@@ -40,6 +45,9 @@ func (self *SecretStore) Create(secret *domain.Secret) (string, error) {
 	if len(secret.Uuid) == 0 {
 		secret.Uuid = uuidhelper.MustNewV4()
 	}
+	if len(secret.OverridePolicy) == 0 {
+		secret.OverridePolicy = domain.SecretOverrideAllow
+	}
 	if len(secret.Status) == 0 {
 		// if this is a ssh secret, the status is pending until keymaker makes the keys
 		if secret.IsSsh() {
@@ -61,7 +69,7 @@ func (self *SecretStore) Create(secret *domain.Secret) (string, error) {
 		secret.Key = b
 	}
 
-	var q string = `INSERT INTO secrets (uuid, name, environment_uuid, type, status, key, archived_at) VALUES (:uuid, :name, :environment_uuid, :type, :status, :key, :archived_at) RETURNING uuid;`
+	var q string = `INSERT INTO secrets (uuid, name, environment_uuid, project_uuid, organization_uuid, override_policy, type, status, key, archived_at) VALUES (:uuid, :name, CAST(NULLIF(:environment_uuid, '') AS uuid), :project_uuid, :organization_uuid, :override_policy, :type, :status, :key, :archived_at) RETURNING uuid;`
 	rows, err := self.tx.NamedQuery(q, secret)
 
 	if err != nil {
@@ -83,6 +91,12 @@ func (self *SecretStore) Create(secret *domain.Secret) (string, error) {
 		return "", err
 	}
 
+	if secret.Status == domain.SecretPresent {
+		if err := self.recordVersion(secret, secret.SecretBytes, secret.AuthorUuid, nil); err != nil {
+			return "", err
+		}
+	}
+
 	return uuid, nil
 }
 
@@ -90,7 +104,7 @@ func (self *SecretStore) FindAll() ([]*domain.Secret, error) {
 
 	var secrets []*domain.Secret = []*domain.Secret{}
 
-	var q string = `SELECT * FROM secrets WHERE archived_at IS NULL`
+	var q string = `SELECT ` + secretColumns + ` FROM secrets WHERE archived_at IS NULL`
 
 	err := self.tx.Select(&secrets, q)
 	if err != nil {
@@ -113,7 +127,7 @@ func (self *SecretStore) FindByUuid(uuid string) (*domain.Secret, error) {
 
 	secret := &domain.Secret{}
 
-	var q string = `SELECT * FROM secrets WHERE uuid = $1 AND archived_at IS NULL`
+	var q string = `SELECT ` + secretColumns + ` FROM secrets WHERE uuid = $1 AND archived_at IS NULL`
 	err := self.tx.Get(secret, q, uuid)
 
 	if err == sql.ErrNoRows {
@@ -139,11 +153,40 @@ func (self *SecretStore) FindByUuid(uuid string) (*domain.Secret, error) {
 }
 
 func (self *SecretStore) FindAllByEnvironmentUuid(environmentUuid string) ([]*domain.Secret, error) {
+	var q string = `SELECT ` + secretColumns + ` FROM secrets WHERE environment_uuid = $1 AND archived_at IS NULL`
+	return self.findAll(q, environmentUuid)
+}
+
+// FindAllByProjectUuid returns the secrets shared by a project with
+// all of its environments.
+func (self *SecretStore) FindAllByProjectUuid(projectUuid string) ([]*domain.Secret, error) {
+	var q string = `SELECT ` + secretColumns + ` FROM secrets WHERE project_uuid = $1 AND archived_at IS NULL`
+	return self.findAll(q, projectUuid)
+}
+
+// FindAllByOrganizationUuid returns the secrets shared by an
+// organization with all environments of its projects.
+func (self *SecretStore) FindAllByOrganizationUuid(organizationUuid string) ([]*domain.Secret, error) {
+	var q string = `SELECT ` + secretColumns + ` FROM secrets WHERE organization_uuid = $1 AND archived_at IS NULL`
+	return self.findAll(q, organizationUuid)
+}
+
+// FindAllSharedWithEnvironmentUuid returns the secrets an environment
+// inherits from its project and organization, project secrets first.
+func (self *SecretStore) FindAllSharedWithEnvironmentUuid(environmentUuid string) ([]*domain.Secret, error) {
+	var q string = `SELECT ` + secretColumns + ` FROM secrets
+	  WHERE archived_at IS NULL
+	  AND (project_uuid = (SELECT project_uuid FROM environments WHERE uuid = $1)
+	    OR organization_uuid = (SELECT p.organization_uuid FROM environments e JOIN projects p ON p.uuid = e.project_uuid WHERE e.uuid = $1))
+	  ORDER BY organization_uuid NULLS FIRST, name`
+	return self.findAll(q, environmentUuid)
+}
+
+func (self *SecretStore) findAll(q string, args ...interface{}) ([]*domain.Secret, error) {
 
 	var secrets []*domain.Secret = []*domain.Secret{}
 
-	var q string = `SELECT * FROM secrets WHERE environment_uuid = $1 AND archived_at IS NULL`
-	err := self.tx.Select(&secrets, q, environmentUuid)
+	err := self.tx.Select(&secrets, q, args...)
 
 	if err == sql.ErrNoRows {
 		return []*domain.Secret{}, nil
@@ -174,7 +217,12 @@ func (self *SecretStore) Update(secret *domain.Secret) error {
 		return new(domain.NotFoundError)
 	}
 
-	var q string = `UPDATE secrets SET (name, environment_uuid, type, status, archived_at) = (:name, :environment_uuid, :type, :status, :archived_at) WHERE uuid = :uuid AND archived_at IS NULL;`
+	previous, err := self.ss.Get(secret.Uuid, secret.Key)
+	if err != nil && err != ErrKeyNotFound {
+		self.Log().Warn().Msgf("secret %s: reading previous value: %s", secret.Uuid, err)
+	}
+
+	var q string = `UPDATE secrets SET (name, override_policy, type, status, archived_at) = (:name, COALESCE(NULLIF(:override_policy, ''), override_policy), :type, :status, :archived_at) WHERE uuid = :uuid AND archived_at IS NULL;`
 	result, err := self.tx.NamedExec(q, secret)
 
 	if err != nil {
@@ -192,7 +240,21 @@ func (self *SecretStore) Update(secret *domain.Secret) error {
 	if err != nil {
 		return err
 	}
-	return nil
+
+	if secret.Status != domain.SecretPresent || (len(previous) > 0 && bytes.Equal(previous, secret.SecretBytes)) {
+		return nil
+	}
+
+	// Secrets written before versioning was introduced have no
+	// versions yet, so their previous value becomes the first
+	// version in order to be able to roll back to it.
+	if len(previous) > 0 {
+		if err := self.recordInitialVersion(secret, previous); err != nil {
+			return err
+		}
+	}
+
+	return self.recordVersion(secret, secret.SecretBytes, secret.AuthorUuid, nil)
 }
 
 func (self *SecretStore) ArchiveByUuid(uuid string) error {
@@ -208,8 +270,148 @@ func (self *SecretStore) ArchiveByUuid(uuid string) error {
 		return new(domain.NotFoundError)
 	}
 
-	err = self.ss.Del(uuid)
+	if err := self.ss.Del(uuid); err != nil {
+		return err
+	}
+
+	versions, err := self.FindAllVersionsBySecretUuid(uuid)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if err := self.ss.Del(version.ValueKey()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindAllVersionsBySecretUuid returns the versions of a secret, most
+// recent first.  Only metadata is loaded, not the values.
+func (self *SecretStore) FindAllVersionsBySecretUuid(secretUuid string) ([]*domain.SecretVersion, error) {
+	result := []*domain.SecretVersion{}
+	q := `SELECT * FROM secret_versions WHERE secret_uuid = $1 ORDER BY version DESC`
+	if err := self.tx.Select(&result, q, secretUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
 
-	return err
+func (self *SecretStore) FindVersion(secretUuid string, version int) (*domain.SecretVersion, error) {
+	result := &domain.SecretVersion{}
+	q := `SELECT * FROM secret_versions WHERE secret_uuid = $1 AND version = $2`
+	err := self.tx.Get(result, q, secretUuid, version)
+	if err == sql.ErrNoRows {
+		return nil, new(domain.NotFoundError)
+	}
+	if err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+// Rollback restores the value secret had in version.  This records a
+// new version instead of removing the versions after version, so that
+// the rollback itself shows up in the history of the secret.
+//
+// Only the new version is written.  The restored value becomes the
+// current value of secret once WriteCurrentValue is called, which must
+// happen after the transaction has been committed.
+func (self *SecretStore) Rollback(secret *domain.Secret, version *domain.SecretVersion) error {
+	if err := secret.RollbackTo(version); err != nil {
+		return err
+	}
+
+	data, err := self.ss.Get(version.ValueKey(), secret.Key)
+	if err == ErrKeyNotFound {
+		return new(domain.NotFoundError)
+	}
+	if err != nil {
+		return err
+	}
+
+	secret.SecretBytes = data
+
+	return self.recordVersion(secret, data, secret.AuthorUuid, &version.Version)
+}
+
+// WriteCurrentValue makes the value of secret the one returned when
+// loading secret.
+func (self *SecretStore) WriteCurrentValue(secret *domain.Secret) error {
+	return self.ss.Set(secret.Uuid, secret.Key, secret.SecretBytes)
+}
+
+// RecordUsage remembers which versions of secrets have been handed to
+// an operation.  Secrets without a version, like overrides passed as
+// operation parameters, are ignored.
+func (self *SecretStore) RecordUsage(operationUuid string, secrets []*domain.Secret) error {
+	q := `INSERT INTO operation_secret_versions (operation_uuid, secret_uuid, version)
+	  VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	for _, secret := range secrets {
+		if secret.Uuid == "" || secret.Version == 0 {
+			continue
+		}
+
+		if _, err := self.tx.Exec(q, operationUuid, secret.Uuid, secret.Version); err != nil {
+			return resolveErrType(err)
+		}
+	}
+
+	return nil
+}
+
+// FindOperationUuidsBySecretVersion returns the uuids of all
+// operations that have been given the value of version of a secret.
+func (self *SecretStore) FindOperationUuidsBySecretVersion(secretUuid string, version int) ([]string, error) {
+	result := []string{}
+	q := `SELECT osv.operation_uuid
+	  FROM operation_secret_versions osv
+	  JOIN operations o ON o.uuid = osv.operation_uuid
+	  WHERE osv.secret_uuid = $1 AND osv.version = $2
+	  ORDER BY o.created_at DESC`
+	if err := self.tx.Select(&result, q, secretUuid, version); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+func (self *SecretStore) recordInitialVersion(secret *domain.Secret, data []byte) error {
+	current := 0
+	if err := self.tx.Get(&current, `SELECT version FROM secrets WHERE uuid = $1`, secret.Uuid); err != nil {
+		return resolveErrType(err)
+	}
+
+	if current > 0 {
+		return nil
+	}
+
+	return self.recordVersion(secret, data, nil, nil)
+}
+
+func (self *SecretStore) recordVersion(secret *domain.Secret, data []byte, createdByUuid *string, rolledBackFrom *int) error {
+	q := `UPDATE secrets SET version = version + 1 WHERE uuid = $1 RETURNING version`
+	if err := self.tx.Get(&secret.Version, q, secret.Uuid); err != nil {
+		return resolveErrType(err)
+	}
+
+	version := &domain.SecretVersion{
+		Uuid:           uuidhelper.MustNewV4(),
+		SecretUuid:     secret.Uuid,
+		Version:        secret.Version,
+		CreatedByUuid:  createdByUuid,
+		RolledBackFrom: rolledBackFrom,
+	}
+
+	q = `INSERT INTO secret_versions (uuid, secret_uuid, version, created_by_uuid, rolled_back_from)
+	  VALUES (:uuid, :secret_uuid, :version, :created_by_uuid, :rolled_back_from)`
+	if _, err := self.tx.NamedExec(q, version); err != nil {
+		return resolveErrType(err)
+	}
 
+	return self.ss.Set(version.ValueKey(), secret.Key, data)
 }
@@ -1,7 +1,9 @@
 package stores_test
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/harrowio/harrow/domain"
 	"github.com/harrowio/harrow/stores"
@@ -268,3 +270,181 @@ func Test_SecretStore_Update_returnsDomainNotFoundError_whenUpdatingNonExistingS
 		t.Errorf("err.(type) = %T; want %T", got, want)
 	}
 }
+
+func updateEnvSecretValue(t *testing.T, store *stores.SecretStore, uuid, value string) *domain.Secret {
+	secret, err := store.FindByUuid(uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envSecret, err := domain.AsEnvironmentSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envSecret.Value = value
+	secret, err = envSecret.AsSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Update(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	return secret
+}
+
+func Test_SecretStore_Update_recordsNewVersionWhenValueChanges(t *testing.T) {
+	tx, store, _, uuid, _ := setupEnvSecret(t)
+	defer tx.Rollback()
+
+	updateEnvSecretValue(t, store, uuid, "bar")
+	updateEnvSecretValue(t, store, uuid, "bar")
+
+	versions, err := store.FindAllVersionsBySecretUuid(uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(versions), 2; got != want {
+		t.Fatalf(`len(versions) = %d; want %d`, got, want)
+	}
+
+	if got, want := versions[0].Version, 2; got != want {
+		t.Errorf(`versions[0].Version = %d; want %d`, got, want)
+	}
+}
+
+func Test_SecretStore_Rollback_restoresValueOfVersion(t *testing.T) {
+	tx, store, _, uuid, _ := setupEnvSecret(t)
+	defer tx.Rollback()
+
+	secret := updateEnvSecretValue(t, store, uuid, "bar")
+	version, err := store.FindVersion(uuid, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Rollback(secret, version); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.WriteCurrentValue(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err = store.FindByUuid(uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envSecret, err := domain.AsEnvironmentSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := envSecret.Value, "foo"; got != want {
+		t.Errorf(`envSecret.Value = %q; want %q`, got, want)
+	}
+
+	current, err := store.FindVersion(uuid, secret.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.RolledBackFrom == nil || *current.RolledBackFrom != 1 {
+		t.Errorf(`current.RolledBackFrom = %v; want 1`, current.RolledBackFrom)
+	}
+}
+
+func Test_SecretStore_RecordUsage_remembersVersionsUsedByOperation(t *testing.T) {
+	test := setupOperationStoreTest(t)
+	defer test.tx.Rollback()
+
+	ss := helpers.NewMockSecretKeyValueStore()
+	store := stores.NewSecretStore(ss, test.tx)
+	secret, err := (&domain.EnvironmentSecret{
+		Secret: &domain.Secret{
+			Name:            "DB_PASSWORD",
+			EnvironmentUuid: test.environment.Uuid,
+			Type:            domain.SecretEnv,
+		},
+		Value: "old",
+	}).AsSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	operation := test.newOperationWithTimestamps(t, time.Now(), time.Now())
+	if err := store.RecordUsage(operation.Uuid, []*domain.Secret{secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	updateEnvSecretValue(t, store, secret.Uuid, "new")
+
+	operationUuids, err := store.FindOperationUuidsBySecretVersion(secret.Uuid, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(operationUuids) != 1 || operationUuids[0] != operation.Uuid {
+		t.Errorf(`operationUuids = %v; want [%s]`, operationUuids, operation.Uuid)
+	}
+
+	operationUuids, err = store.FindOperationUuidsBySecretVersion(secret.Uuid, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(operationUuids) != 0 {
+		t.Errorf(`operationUuids = %v; want none`, operationUuids)
+	}
+}
+
+func Test_SecretStore_FindAllSharedWithEnvironmentUuid_returnsProjectAndOrganizationSecrets(t *testing.T) {
+	tx := helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	ss := helpers.NewMockSecretKeyValueStore()
+	world := helpers.MustNewWorldUsingSecretKeyValueStore(tx, ss, t)
+	store := stores.NewSecretStore(ss, tx)
+
+	projectUuid := world.Project("public").Uuid
+	organizationUuid := world.Organization("default").Uuid
+	otherProjectUuid := world.Project("private").Uuid
+	for _, secret := range []*domain.Secret{
+		{Name: "REGISTRY_PASSWORD", ProjectUuid: &projectUuid},
+		{Name: "REGISTRY_USER", OrganizationUuid: &organizationUuid},
+		{Name: "OTHER_PROJECT", ProjectUuid: &otherProjectUuid},
+	} {
+		secret, err := (&domain.EnvironmentSecret{Secret: secret, Value: "value"}).AsSecret()
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret.Type = domain.SecretEnv
+		if _, err := store.Create(secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secrets, err := store.FindAllSharedWithEnvironmentUuid(world.Environment("default").Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+
+	if got, want := names, []string{"REGISTRY_PASSWORD", "REGISTRY_USER"}; !reflect.DeepEqual(got, want) {
+		t.Errorf(`names = %v; want %v`, got, want)
+	}
+
+	if got, want := secrets[0].EnvironmentUuid, ""; got != want {
+		t.Errorf(`secrets[0].EnvironmentUuid = %q; want %q`, got, want)
+	}
+}
@@ -40,13 +40,17 @@ func (store *DbSlackNotifierStore) Create(subject *domain.SlackNotifier) (string
 	  webhook_url,
 	  url_host,
 	  project_uuid,
-          name
+          name,
+	  subject_template,
+	  body_template
 	) VALUES (
 	  :uuid,
 	  :webhook_url,
 	  :url_host,
 	  :project_uuid,
-          :name
+          :name,
+	  :subject_template,
+	  :body_template
 	);`
 
 	_, err := store.tx.NamedExec(q, subject)
@@ -67,7 +71,9 @@ func (store *DbSlackNotifierStore) Update(subject *domain.SlackNotifier) error {
 	  name = :name,
 	  url_host = :url_host,
 	  project_uuid = :project_uuid,
-	  webhook_url = :webhook_url
+	  webhook_url = :webhook_url,
+	  subject_template = :subject_template,
+	  body_template = :body_template
 	WHERE uuid = :uuid AND archived_at IS NULL`
 
 	r, err := store.tx.NamedExec(q, subject)
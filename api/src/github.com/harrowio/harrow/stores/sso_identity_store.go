@@ -0,0 +1,74 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbSsoIdentityStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbSsoIdentityStore(tx *sqlx.Tx) *DbSsoIdentityStore {
+	return &DbSsoIdentityStore{tx: tx}
+}
+
+func (store *DbSsoIdentityStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbSsoIdentityStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbSsoIdentityStore) Create(subject *domain.SsoIdentity) (string, error) {
+
+	if subject.Uuid == "" {
+		subject.Uuid = uuidhelper.MustNewV4()
+	}
+
+	q := `INSERT INTO sso_identities (uuid, provider_uuid, subject, user_uuid)
+	  VALUES (:uuid, :provider_uuid, :subject, :user_uuid);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbSsoIdentityStore) FindByProviderUuidAndSubject(providerUuid, subject string) (*domain.SsoIdentity, error) {
+
+	result := &domain.SsoIdentity{}
+	q := `SELECT * FROM sso_identities WHERE provider_uuid = $1 AND subject = $2`
+	err := store.tx.Get(result, q, providerUuid, subject)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+func (store *DbSsoIdentityStore) MarkLoggedIn(uuid string) error {
+
+	q := `UPDATE sso_identities SET last_login_at = NOW() AT TIME ZONE 'UTC' WHERE uuid = $1`
+	r, err := store.tx.Exec(q, uuid)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbSsoProviderStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbSsoProviderStore(tx *sqlx.Tx) *DbSsoProviderStore {
+	return &DbSsoProviderStore{tx: tx}
+}
+
+func (store *DbSsoProviderStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbSsoProviderStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbSsoProviderStore) Create(subject *domain.SsoProvider) (string, error) {
+
+	if subject.Uuid == "" {
+		subject.Uuid = uuidhelper.MustNewV4()
+	}
+
+	q := `INSERT INTO sso_providers (
+	  uuid,
+	  organization_uuid,
+	  protocol,
+	  enforced,
+	  email_domains,
+	  groups_attribute,
+	  group_mappings,
+	  default_membership_type,
+	  oidc_issuer,
+	  oidc_client_id,
+	  oidc_client_secret,
+	  saml_sso_url,
+	  saml_issuer,
+	  saml_certificate
+	) VALUES (
+	  :uuid,
+	  :organization_uuid,
+	  :protocol,
+	  :enforced,
+	  :email_domains,
+	  :groups_attribute,
+	  :group_mappings,
+	  :default_membership_type,
+	  :oidc_issuer,
+	  :oidc_client_id,
+	  :oidc_client_secret,
+	  :saml_sso_url,
+	  :saml_issuer,
+	  :saml_certificate
+	);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbSsoProviderStore) Update(subject *domain.SsoProvider) error {
+
+	if !uuidhelper.IsValid(subject.Uuid) {
+		return &domain.NotFoundError{}
+	}
+
+	q := `UPDATE sso_providers SET
+	  protocol = :protocol,
+	  enforced = :enforced,
+	  email_domains = :email_domains,
+	  groups_attribute = :groups_attribute,
+	  group_mappings = :group_mappings,
+	  default_membership_type = :default_membership_type,
+	  oidc_issuer = :oidc_issuer,
+	  oidc_client_id = :oidc_client_id,
+	  oidc_client_secret = :oidc_client_secret,
+	  saml_sso_url = :saml_sso_url,
+	  saml_issuer = :saml_issuer,
+	  saml_certificate = :saml_certificate
+	WHERE uuid = :uuid AND archived_at IS NULL`
+
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbSsoProviderStore) FindByUuid(uuid string) (*domain.SsoProvider, error) {
+
+	result := &domain.SsoProvider{}
+	q := `SELECT * FROM sso_providers WHERE uuid = $1 AND archived_at IS NULL`
+	err := store.tx.Get(result, q, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+func (store *DbSsoProviderStore) FindByOrganizationUuid(organizationUuid string) (*domain.SsoProvider, error) {
+
+	result := &domain.SsoProvider{}
+	q := `SELECT * FROM sso_providers WHERE organization_uuid = $1 AND archived_at IS NULL`
+	err := store.tx.Get(result, q, organizationUuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindByEmailDomain returns the provider an email domain is bound to.
+func (store *DbSsoProviderStore) FindByEmailDomain(emailDomain string) (*domain.SsoProvider, error) {
+
+	result := &domain.SsoProvider{}
+	q := `SELECT sso_providers.* FROM sso_providers
+	  INNER JOIN organizations ON organizations.uuid = sso_providers.organization_uuid
+	  WHERE sso_providers.archived_at IS NULL
+	  AND organizations.archived_at IS NULL
+	  AND EXISTS (
+	    SELECT 1 FROM jsonb_array_elements_text(sso_providers.email_domains) AS bound(domain)
+	    WHERE lower(bound.domain) = lower($1)
+	  )
+	  ORDER BY sso_providers.created_at
+	  LIMIT 1`
+	err := store.tx.Get(result, q, emailDomain)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindAllEnforcedByUserUuid returns the providers enforcing single
+// sign-on for any organization userUuid is a member of.  Owners are
+// exempt, so that a misconfigured identity provider cannot lock an
+// organization out.
+func (store *DbSsoProviderStore) FindAllEnforcedByUserUuid(userUuid string) ([]*domain.SsoProvider, error) {
+
+	result := []*domain.SsoProvider{}
+	q := `SELECT sso_providers.* FROM sso_providers
+	  INNER JOIN organization_memberships ON organization_memberships.organization_uuid = sso_providers.organization_uuid
+	  INNER JOIN organizations ON organizations.uuid = sso_providers.organization_uuid
+	  WHERE organization_memberships.user_uuid = $1
+	  AND organization_memberships.type <> 'owner'
+	  AND sso_providers.enforced
+	  AND sso_providers.archived_at IS NULL
+	  AND organizations.archived_at IS NULL`
+	if err := store.tx.Select(&result, q, userUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+func (store *DbSsoProviderStore) ArchiveByUuid(uuid string) error {
+
+	q := `UPDATE sso_providers SET archived_at = NOW() AT TIME ZONE 'UTC' WHERE uuid = $1 AND archived_at IS NULL`
+	r, err := store.tx.Exec(q, uuid)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
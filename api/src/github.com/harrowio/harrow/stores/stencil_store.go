@@ -24,6 +24,7 @@ type DbStencilStore struct {
 	tasks             *DbTaskStore
 	jobs              *DbJobStore
 	emailNotifiers    *DbEmailNotifierStore
+	slackNotifiers    *DbSlackNotifierStore
 	notificationRules *DbNotificationRuleStore
 	schedules         *DbScheduleStore
 	gitTriggers       *DbGitTriggerStore
@@ -43,6 +44,7 @@ func NewDbStencilStore(ss SecretKeyValueStore, tx *sqlx.Tx, bus ActivitySink) *D
 		tasks:             NewDbTaskStore(tx),
 		jobs:              NewDbJobStore(tx),
 		emailNotifiers:    NewDbEmailNotifierStore(tx),
+		slackNotifiers:    NewDbSlackNotifierStore(tx),
 		notificationRules: NewDbNotificationRuleStore(tx),
 		schedules:         NewDbScheduleStore(tx),
 		gitTriggers:       NewDbGitTriggerStore(tx),
@@ -67,6 +69,8 @@ func (self *DbStencilStore) SetLogger(l logger.Logger) {
 func (self *DbStencilStore) Create(subject *domain.Stencil) error {
 	configuration := self.ToConfiguration()
 	configuration.NotifyViaEmail = subject.NotifyViaEmail
+	configuration.NotifyViaChat = subject.NotifyViaChat
+	configuration.ChatWebhookURL = subject.ChatWebhookURL
 	configuration.UrlHost = subject.UrlHost
 	configuration.UserUuid = subject.UserUuid
 	configuration.ProjectUuid = subject.ProjectUuid
@@ -88,6 +92,7 @@ func (self *DbStencilStore) ToConfiguration() *stencil.Configuration {
 		Tasks:             self,
 		Jobs:              self,
 		EmailNotifiers:    self,
+		ChatNotifiers:     self,
 		NotificationRules: self,
 		Schedules:         self,
 		GitTriggers:       self,
@@ -138,6 +143,22 @@ func (self *DbStencilStore) CreateEmailNotifier(notifier *domain.EmailNotifier)
 	return err
 }
 
+func (self *DbStencilStore) CreateChatNotifier(chatNotifier *domain.ChatNotifier) error {
+	var err error
+	switch notifier := chatNotifier.Notifier().(type) {
+	case *domain.SlackNotifier:
+		_, err = self.slackNotifiers.Create(notifier)
+		self.bus.EnqueueActivity(activities.SlackNotifierCreated(notifier), nil)
+	case domain.ProjectNotifier:
+		_, err = NewDbChatNotifierStore(self.tx, notifier.NotifierType()).Create(notifier)
+		self.bus.EnqueueActivity(activities.ChatNotifierCreated(notifier), nil)
+	default:
+		return domain.NewValidationError("notifyViaChat", "unknown")
+	}
+
+	return err
+}
+
 func (self *DbStencilStore) CreateNotificationRule(notificationRule *domain.NotificationRule) error {
 	_, err := self.notificationRules.Create(notificationRule)
 	self.bus.EnqueueActivity(activities.NotificationRuleCreated(notificationRule), nil)
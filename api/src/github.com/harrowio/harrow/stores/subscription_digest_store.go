@@ -0,0 +1,51 @@
+package stores
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// DbSubscriptionDigestStore keeps track of when digests have been sent
+// to users.
+type DbSubscriptionDigestStore struct {
+	tx *sqlx.Tx
+}
+
+func NewDbSubscriptionDigestStore(tx *sqlx.Tx) *DbSubscriptionDigestStore {
+	return &DbSubscriptionDigestStore{tx: tx}
+}
+
+// FindLastSentAt returns the time at which the user was last sent a
+// digest about the project, or a *domain.NotFoundError if no such
+// digest has been sent yet.
+func (store *DbSubscriptionDigestStore) FindLastSentAt(userUuid, projectUuid, delivery string) (*time.Time, error) {
+
+	q := `SELECT sent_at FROM subscription_digests
+        WHERE user_uuid = $1
+          AND project_uuid = $2
+          AND delivery = $3`
+
+	sentAt := time.Time{}
+	err := store.tx.Get(&sentAt, q, userUuid, projectUuid, delivery)
+	if err == sql.ErrNoRows {
+		return nil, new(domain.NotFoundError)
+	}
+	if err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return &sentAt, nil
+}
+
+func (store *DbSubscriptionDigestStore) MarkSent(userUuid, projectUuid, delivery string, sentAt time.Time) error {
+
+	q := `INSERT INTO subscription_digests (user_uuid, project_uuid, delivery, sent_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_uuid, project_uuid, delivery) DO UPDATE SET sent_at = EXCLUDED.sent_at`
+
+	_, err := store.tx.Exec(q, userUuid, projectUuid, delivery, sentAt)
+	return resolveErrType(err)
+}
@@ -36,9 +36,13 @@ func (store *DbSubscriptionStore) Create(subscription *domain.Subscription) (str
 	}
 
 	q := `INSERT INTO subscriptions
-        (uuid, user_uuid, watchable_uuid, watchable_type, event_name)
+        (uuid, user_uuid, watchable_uuid, watchable_type, event_name, delivery)
         VALUES
-        (:uuid, :user_uuid, :watchable_uuid, :watchable_type, :event_name)`
+        (:uuid, :user_uuid, :watchable_uuid, :watchable_type, :event_name, :delivery)`
+
+	if subscription.Delivery == "" {
+		subscription.Delivery = domain.SubscriptionDeliveryImmediate
+	}
 
 	_, err := store.tx.NamedExec(q, subscription)
 	return subscription.Uuid, err
@@ -102,3 +106,57 @@ func (store *DbSubscriptionStore) FindEventsForUser(watchableId, userUuid string
 
 	return events, nil
 }
+
+// FindDeliveryForUser returns how the user's subscriptions on the
+// watchable are delivered.
+func (store *DbSubscriptionStore) FindDeliveryForUser(watchableId, userUuid string) (string, error) {
+
+	q := `SELECT delivery
+        FROM subscriptions
+        WHERE watchable_uuid = $1
+          AND user_uuid = $2
+          AND archived_at IS NULL
+        ORDER BY created_at DESC
+        LIMIT 1
+	`
+
+	delivery := ""
+	err := store.tx.Get(&delivery, q, watchableId, userUuid)
+	if err == sql.ErrNoRows {
+		return "", new(domain.NotFoundError)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return delivery, nil
+}
+
+func (store *DbSubscriptionStore) UpdateDelivery(watchableId, userUuid, delivery string) error {
+
+	q := `UPDATE subscriptions SET delivery = $3
+        WHERE watchable_uuid = $1
+          AND user_uuid = $2
+          AND archived_at IS NULL`
+
+	_, err := store.tx.Exec(q, watchableId, userUuid, delivery)
+	return resolveErrType(err)
+}
+
+// FindAllByDelivery returns all active subscriptions on jobs that are
+// delivered as given.
+func (store *DbSubscriptionStore) FindAllByDelivery(delivery string) ([]*domain.Subscription, error) {
+
+	q := `SELECT s.* FROM subscriptions s
+        WHERE delivery = $1
+          AND watchable_type = 'job'
+          AND archived_at IS NULL
+        ORDER BY user_uuid, watchable_uuid`
+
+	result := []*domain.Subscription{}
+	if err := store.tx.Select(&result, q, delivery); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
@@ -2,6 +2,7 @@ package stores_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/harrowio/harrow/domain"
 	"github.com/harrowio/harrow/stores"
@@ -186,3 +187,69 @@ func Test_SubscriptionStore_Find_ReturnsNotFoundError_IfNoMatchIsFound(t *testin
 		t.Fatalf("Expected *domain.NotFoundError, got %T", err)
 	}
 }
+
+func Test_SubscriptionStore_UpdateDelivery_changesDeliveryOfAllSubscriptionsOnWatchable(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	job := world.Job("default")
+	store := stores.NewDbSubscriptionStore(tx)
+
+	for _, event := range []string{domain.EventOperationFailed, domain.EventOperationSucceeded} {
+		if _, err := store.Create(domain.NewSubscription(job, event, user.Uuid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.UpdateDelivery(job.Uuid, user.Uuid, domain.SubscriptionDeliveryDaily); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery, err := store.FindDeliveryForUser(job.Uuid, user.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := delivery, domain.SubscriptionDeliveryDaily; got != want {
+		t.Errorf("delivery = %q; want %q", got, want)
+	}
+
+	daily, err := store.FindAllByDelivery(domain.SubscriptionDeliveryDaily)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(daily), 2; got != want {
+		t.Errorf("len(daily) = %d; want %d", got, want)
+	}
+}
+
+func Test_SubscriptionDigestStore_MarkSent_updatesTimeOfLastDigest(t *testing.T) {
+	tx := test_helpers.GetDbTx(t)
+	defer tx.Rollback()
+
+	world := test_helpers.MustNewWorld(tx, t)
+	user := world.User("default")
+	project := world.Project("public")
+	store := stores.NewDbSubscriptionDigestStore(tx)
+
+	if _, err := store.FindLastSentAt(user.Uuid, project.Uuid, domain.SubscriptionDeliveryWeekly); !domain.IsNotFound(err) {
+		t.Fatalf("err = %v; want *domain.NotFoundError", err)
+	}
+
+	first := time.Date(2026, 10, 12, 6, 0, 0, 0, time.UTC)
+	second := first.Add(domain.DigestPeriod(domain.SubscriptionDeliveryWeekly))
+	for _, sentAt := range []time.Time{first, second} {
+		if err := store.MarkSent(user.Uuid, project.Uuid, domain.SubscriptionDeliveryWeekly, sentAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lastSentAt, err := store.FindLastSentAt(user.Uuid, project.Uuid, domain.SubscriptionDeliveryWeekly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lastSentAt.Equal(second) {
+		t.Errorf("lastSentAt = %s; want %s", lastSentAt, second)
+	}
+}
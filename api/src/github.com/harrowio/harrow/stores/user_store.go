@@ -139,7 +139,7 @@ func (store DbUserStore) FindByEmailAddress(email string) (*domain.User, error)
 
 	user := new(domain.User)
 
-	var q string = `SELECT * FROM users WHERE email = $1`
+	var q string = `SELECT * FROM users WHERE lower(email) = lower($1)`
 	err := store.tx.Get(user, q, email)
 
 	if err == sql.ErrNoRows {
@@ -195,6 +195,8 @@ INNER JOIN subscriptions as s
 
 WHERE s.watchable_uuid = $1
   AND s.event_name = $2
+  AND s.delivery = 'immediate'
+  AND s.archived_at IS NULL
 `
 	err := store.tx.Select(&result, q, watchableId, event)
 	if err != nil {
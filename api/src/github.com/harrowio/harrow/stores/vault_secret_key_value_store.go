@@ -0,0 +1,172 @@
+package stores
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/logger"
+)
+
+// VaultSecretKeyValueStore keeps secrets in the KV version 2 secrets
+// engine of a HashiCorp Vault server.  Secrets are encrypted with
+// their passphrase before they are sent to Vault, just like
+// RedisSecretKeyValueStore does, so that reading a secret from Vault
+// alone does not reveal it.
+//
+// Every secret is stored at <mount>/data/<prefix>/<key> in a field
+// named "value".
+type VaultSecretKeyValueStore struct {
+	client    *http.Client
+	address   string
+	token     string
+	namespace string
+	mount     string
+	prefix    string
+	log       logger.Logger
+}
+
+func NewVaultSecretKeyValueStore(c config.SecretStorageConfig) SecretKeyValueStore {
+	return &VaultSecretKeyValueStore{
+		client:    &http.Client{Timeout: 10 * time.Second},
+		address:   strings.TrimRight(c.VaultAddress, "/"),
+		token:     c.VaultToken,
+		namespace: c.VaultNamespace,
+		mount:     strings.Trim(c.VaultMount, "/"),
+		prefix:    strings.Trim(c.VaultPrefix, "/"),
+	}
+}
+
+func (self *VaultSecretKeyValueStore) Log() logger.Logger {
+	if self.log == nil {
+		self.log = logger.Discard
+	}
+	return self.log
+}
+
+func (self *VaultSecretKeyValueStore) SetLogger(l logger.Logger) {
+	self.log = l
+}
+
+type vaultSecretData struct {
+	Value string `json:"value"`
+}
+
+func (self *VaultSecretKeyValueStore) Get(key string, passphrase []byte) ([]byte, error) {
+	response := struct {
+		Data struct {
+			Data *vaultSecretData `json:"data"`
+		} `json:"data"`
+	}{}
+
+	status, err := self.do("GET", self.url("data", key), nil, &response)
+	if status == http.StatusNotFound {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Reading a deleted version returns no data.
+	if response.Data.Data == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	enc, err := base64.StdEncoding.DecodeString(response.Data.Data.Value)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %s: %s", key, err)
+	}
+
+	return decrypt(enc, passphrase)
+}
+
+func (self *VaultSecretKeyValueStore) Set(key string, passphrase, data []byte) error {
+	enc, err := encrypt(data, passphrase)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"data": &vaultSecretData{
+			Value: base64.StdEncoding.EncodeToString(enc),
+		},
+	}
+
+	_, err = self.do("POST", self.url("data", key), body, nil)
+	return err
+}
+
+// Del permanently removes all versions of the secret stored under key.
+func (self *VaultSecretKeyValueStore) Del(key string) error {
+	status, err := self.do("DELETE", self.url("metadata", key), nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (self *VaultSecretKeyValueStore) url(kind, key string) string {
+	path := url.PathEscape(key)
+	if self.prefix != "" {
+		path = self.prefix + "/" + path
+	}
+
+	return fmt.Sprintf("%s/v1/%s/%s/%s", self.address, self.mount, kind, path)
+}
+
+// do sends a request to Vault and decodes the response into result,
+// if result is not nil.  It returns the HTTP status code along with
+// an error for every status code other than 200 and 204.
+func (self *VaultSecretKeyValueStore) do(method, target string, body interface{}, result interface{}) (int, error) {
+	var src io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		src = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, target, src)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("X-Vault-Token", self.token)
+	if self.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", self.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	self.Log().Debug().Msgf("vault: %s %s", method, req.URL.Path)
+	res, err := self.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		if result == nil {
+			return res.StatusCode, nil
+		}
+		return res.StatusCode, json.NewDecoder(res.Body).Decode(result)
+	case http.StatusNoContent:
+		return res.StatusCode, nil
+	default:
+		errors := struct {
+			Errors []string `json:"errors"`
+		}{}
+		json.NewDecoder(res.Body).Decode(&errors)
+		return res.StatusCode, fmt.Errorf("vault: %s %s: %s %s", method, req.URL.Path, res.Status, strings.Join(errors.Errors, "; "))
+	}
+}
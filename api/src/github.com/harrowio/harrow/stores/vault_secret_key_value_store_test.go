@@ -0,0 +1,154 @@
+package stores_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/harrowio/harrow/config"
+	"github.com/harrowio/harrow/stores"
+	"github.com/harrowio/harrow/uuidhelper"
+)
+
+// fakeVault implements the parts of the KV version 2 API used by
+// VaultSecretKeyValueStore.
+type fakeVault struct {
+	sync.Mutex
+	token   string
+	secrets map[string]map[string]interface{}
+}
+
+func (self *fakeVault) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	self.Lock()
+	defer self.Unlock()
+
+	if req.Header.Get("X-Vault-Token") != self.token {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	const dataPrefix, metadataPrefix = "/v1/secret/data/", "/v1/secret/metadata/"
+	switch {
+	case req.Method == "GET" && strings.HasPrefix(req.URL.Path, dataPrefix):
+		data, found := self.secrets[strings.TrimPrefix(req.URL.Path, dataPrefix)]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": data},
+		})
+	case req.Method == "POST" && strings.HasPrefix(req.URL.Path, dataPrefix):
+		body := struct {
+			Data map[string]interface{} `json:"data"`
+		}{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		self.secrets[strings.TrimPrefix(req.URL.Path, dataPrefix)] = body.Data
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": 1}})
+	case req.Method == "DELETE" && strings.HasPrefix(req.URL.Path, metadataPrefix):
+		delete(self.secrets, strings.TrimPrefix(req.URL.Path, metadataPrefix))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newVaultSecretKeyValueStoreForTest returns a store talking to the
+// Vault server at HAR_TEST_VAULT_ADDRESS, e.g. the dev-mode server
+// started by docker-compose, or to a fake if that variable is unset.
+func newVaultSecretKeyValueStoreForTest(t *testing.T) (stores.SecretKeyValueStore, func()) {
+	c := config.SecretStorageConfig{
+		Backend:     config.SecretStorageVault,
+		VaultMount:  "secret",
+		VaultPrefix: "harrow-test",
+	}
+
+	if address := os.Getenv("HAR_TEST_VAULT_ADDRESS"); address != "" {
+		c.VaultAddress = address
+		c.VaultToken = os.Getenv("HAR_TEST_VAULT_TOKEN")
+		if c.VaultToken == "" {
+			c.VaultToken = "harrow-dev-test"
+		}
+		return stores.NewVaultSecretKeyValueStore(c), func() {}
+	}
+
+	fake := &fakeVault{token: "test-token", secrets: map[string]map[string]interface{}{}}
+	server := httptest.NewServer(fake)
+	c.VaultAddress = server.URL
+	c.VaultToken = fake.token
+
+	return stores.NewVaultSecretKeyValueStore(c), server.Close
+}
+
+func TestVaultSecretKeyValueStore_Get_returnsDataSetForKey(t *testing.T) {
+	store, done := newVaultSecretKeyValueStoreForTest(t)
+	defer done()
+
+	key := uuidhelper.MustNewV4()
+	passphrase := []byte("passphrase")
+	if err := store.Set(key, passphrase, []byte("secret data")); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Del(key)
+
+	data, err := store.Get(key, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(data), "secret data"; got != want {
+		t.Errorf(`string(data) = %q; want %q`, got, want)
+	}
+}
+
+func TestVaultSecretKeyValueStore_Get_returnsErrKeyNotFound_forUnknownKey(t *testing.T) {
+	store, done := newVaultSecretKeyValueStoreForTest(t)
+	defer done()
+
+	_, err := store.Get(uuidhelper.MustNewV4(), []byte("passphrase"))
+	if got, want := err, stores.ErrKeyNotFound; got != want {
+		t.Errorf(`err = %v; want %v`, got, want)
+	}
+}
+
+func TestVaultSecretKeyValueStore_Get_failsWithWrongPassphrase(t *testing.T) {
+	store, done := newVaultSecretKeyValueStoreForTest(t)
+	defer done()
+
+	key := uuidhelper.MustNewV4()
+	if err := store.Set(key, []byte("passphrase"), []byte("secret data")); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Del(key)
+
+	if _, err := store.Get(key, []byte("wrong")); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func TestVaultSecretKeyValueStore_Del_removesKey(t *testing.T) {
+	store, done := newVaultSecretKeyValueStoreForTest(t)
+	defer done()
+
+	key := uuidhelper.MustNewV4()
+	passphrase := []byte("passphrase")
+	if err := store.Set(key, passphrase, []byte("secret data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Del(key); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := store.Get(key, passphrase)
+	if got, want := err, stores.ErrKeyNotFound; got != want {
+		t.Errorf(`err = %v; want %v`, got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package stores
+
+import (
+	"database/sql"
+
+	"github.com/harrowio/harrow/domain"
+	"github.com/harrowio/harrow/logger"
+	"github.com/harrowio/harrow/uuidhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+type DbWebhookNotifierStore struct {
+	tx  *sqlx.Tx
+	log logger.Logger
+}
+
+func NewDbWebhookNotifierStore(tx *sqlx.Tx) *DbWebhookNotifierStore {
+	return &DbWebhookNotifierStore{tx: tx}
+}
+
+func (store *DbWebhookNotifierStore) Log() logger.Logger {
+	if store.log == nil {
+		store.log = logger.Discard
+	}
+	return store.log
+}
+
+func (store *DbWebhookNotifierStore) SetLogger(l logger.Logger) {
+	store.log = l
+}
+
+func (store *DbWebhookNotifierStore) Create(subject *domain.WebhookNotifier) (string, error) {
+
+	if subject.Uuid == "" {
+		subject.Uuid = uuidhelper.MustNewV4()
+	}
+
+	q := `INSERT INTO webhook_notifiers (uuid, name, project_uuid, url, secret, body_template)
+	  VALUES (:uuid, :name, :project_uuid, :url, :secret, :body_template);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbWebhookNotifierStore) Update(subject *domain.WebhookNotifier) error {
+
+	if !uuidhelper.IsValid(subject.Uuid) {
+		return &domain.NotFoundError{}
+	}
+
+	q := `UPDATE webhook_notifiers SET
+	  name = :name,
+	  url = :url,
+	  secret = :secret,
+	  body_template = :body_template
+	WHERE uuid = :uuid AND archived_at IS NULL`
+
+	r, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbWebhookNotifierStore) FindByUuid(uuid string) (*domain.WebhookNotifier, error) {
+
+	result := &domain.WebhookNotifier{}
+	q := `SELECT * FROM webhook_notifiers WHERE uuid = $1 AND archived_at IS NULL`
+	err := store.tx.Get(result, q, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+func (store *DbWebhookNotifierStore) FindAllByProjectUuid(projectUuid string) ([]*domain.WebhookNotifier, error) {
+
+	result := []*domain.WebhookNotifier{}
+	q := `SELECT * FROM webhook_notifiers WHERE project_uuid = $1 AND archived_at IS NULL ORDER BY name`
+	if err := store.tx.Select(&result, q, projectUuid); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}
+
+func (store *DbWebhookNotifierStore) ArchiveByUuid(uuid string) error {
+
+	q := `UPDATE webhook_notifiers SET archived_at = NOW() AT TIME ZONE 'UTC' WHERE uuid = $1 AND archived_at IS NULL`
+	r, err := store.tx.Exec(q, uuid)
+	if err != nil {
+		return resolveErrType(err)
+	}
+
+	if n, _ := r.RowsAffected(); n == 0 {
+		return &domain.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (store *DbWebhookNotifierStore) CreateAttempt(subject *domain.WebhookNotifierAttempt) (string, error) {
+
+	q := `INSERT INTO webhook_notifier_attempts (uuid, webhook_notifier_uuid, notification_delivery_uuid, activity_id, attempt, response_status, error, created_at)
+	  VALUES (:uuid, :webhook_notifier_uuid, :notification_delivery_uuid, :activity_id, :attempt, :response_status, :error, :created_at);`
+
+	_, err := store.tx.NamedExec(q, subject)
+	if err != nil {
+		return "", resolveErrType(err)
+	}
+
+	return subject.Uuid, nil
+}
+
+func (store *DbWebhookNotifierStore) FindAttemptByUuid(uuid string) (*domain.WebhookNotifierAttempt, error) {
+
+	result := &domain.WebhookNotifierAttempt{}
+	err := store.tx.Get(result, `SELECT * FROM webhook_notifier_attempts WHERE uuid = $1`, uuid)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{}
+	}
+
+	return result, resolveErrType(err)
+}
+
+// FindAllAttemptsByWebhookNotifierUuid returns the most recent
+// attempts of a webhook notifier, newest first.
+func (store *DbWebhookNotifierStore) FindAllAttemptsByWebhookNotifierUuid(webhookNotifierUuid string, limit int) ([]*domain.WebhookNotifierAttempt, error) {
+
+	result := []*domain.WebhookNotifierAttempt{}
+	q := `SELECT * FROM webhook_notifier_attempts WHERE webhook_notifier_uuid = $1 ORDER BY created_at DESC LIMIT $2`
+	if err := store.tx.Select(&result, q, webhookNotifierUuid, limit); err != nil {
+		return nil, resolveErrType(err)
+	}
+
+	return result, nil
+}